@@ -40,10 +40,11 @@ func main() {
 	// 	logger.Fatal("启动服务器失败: %v", err)
 	// }
 
-	// // 6. 等待中断信号
+	// // 6. 等待中断信号，SIGHUP时重新加载可安全重载的配置
 	// signalChan := make(chan os.Signal, 1)
-	// signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
-	// <-signalChan
+	// signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	// sig := metaServer.HandleSignals(signalChan, *configPath)
+	// logger.Info("收到信号: %v", sig)
 
 	// // 7. 优雅关闭服务器
 	// logger.Info("正在关闭服务器...")
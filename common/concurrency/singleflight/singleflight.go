@@ -0,0 +1,126 @@
+// Package singleflight 合并对同一个键的并发调用，同一时刻只执行一次，
+// 所有等待中的调用方共享这次执行的结果
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// ErrPanicked 合并的调用发生panic时等待者收到的错误，DoWithContext返回的PanicError也匹配该错误
+var ErrPanicked = errors.New("singleflight: 合并的调用发生panic")
+
+// PanicError 在后台执行的fn发生panic时，DoWithContext的调用方收到的错误，记录panic的值和堆栈
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%v: %v\n\n%s", ErrPanicked, e.Value, e.Stack)
+}
+
+// Unwrap 使errors.Is(err, ErrPanicked)成立
+func (e *PanicError) Unwrap() error {
+	return ErrPanicked
+}
+
+// call 一次进行中的调用
+type call struct {
+	done   chan struct{}
+	val    interface{}
+	err    error
+	shared int // 除发起者外等待结果的调用方数量
+}
+
+// Group 按键合并调用，零值可直接使用
+type Group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// Do 执行fn并返回其结果。同一个键已有调用在进行时不再执行fn，而是等待并返回那次调用的结果。
+// shared表示结果是否被多个调用方共享。调用结束后再次调用会重新执行fn，结果不会被缓存
+func (g *Group) Do(key string, fn func() (interface{}, error)) (v interface{}, err error, shared bool) {
+	c, leader := g.join(key)
+	if leader {
+		g.run(key, c, fn)
+	} else {
+		<-c.done
+	}
+	return c.val, c.err, g.isShared(c)
+}
+
+// DoWithContext 与Do相同，但调用方可以通过ctx放弃等待，放弃不会取消进行中的fn，
+// 其他调用方仍会得到结果。fn的执行不受任何调用方ctx的影响。
+// fn在后台协程中执行，发生panic时不会使进程崩溃，所有调用方收到*PanicError
+func (g *Group) DoWithContext(ctx context.Context, key string, fn func() (interface{}, error)) (v interface{}, err error, shared bool) {
+	c, leader := g.join(key)
+	if leader {
+		go g.run(key, c, recoverPanic(fn))
+	}
+	select {
+	case <-c.done:
+		return c.val, c.err, g.isShared(c)
+	case <-ctx.Done():
+		return nil, ctx.Err(), false
+	}
+}
+
+// Forget 使之后对该键的调用不再等待进行中的调用，而是重新执行
+func (g *Group) Forget(key string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.calls, key)
+}
+
+// join 加入键上进行中的调用，没有时创建一个，leader表示调用方负责执行
+func (g *Group) join(key string) (c *call, leader bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	if c, ok := g.calls[key]; ok {
+		c.shared++
+		return c, false
+	}
+	c = &call{done: make(chan struct{})}
+	g.calls[key] = c
+	return c, true
+}
+
+// run 执行fn并唤醒所有等待者，fn发生panic时等待者收到错误以免永久阻塞，panic继续向上传播
+func (g *Group) run(key string, c *call, fn func() (interface{}, error)) {
+	defer func() {
+		g.mu.Lock()
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+		g.mu.Unlock()
+		close(c.done)
+	}()
+	c.err = ErrPanicked
+	c.val, c.err = fn()
+}
+
+// recoverPanic 包装fn，把fn的panic转换为*PanicError返回
+func recoverPanic(fn func() (interface{}, error)) func() (interface{}, error) {
+	return func() (v interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				v, err = nil, &PanicError{Value: r, Stack: debug.Stack()}
+			}
+		}()
+		return fn()
+	}
+}
+
+func (g *Group) isShared(c *call) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return c.shared > 0
+}
@@ -33,9 +33,12 @@ type BaseServerConfig struct {
 // ConsensusConfig 共识算法基础配置
 type ConsensusConfig struct {
 	Protocol           string        `json:"protocol" yaml:"protocol" toml:"protocol" default:"raft"`
+	// Raft日志的持久化目录，为空时只保存在内存中，重启后丢失
 	DataDir            string        `json:"data_dir" yaml:"data_dir" toml:"data_dir"`
 	SnapshotThreshold  int           `json:"snapshot_threshold" yaml:"snapshot_threshold" default:"10000"`
 	CompactionInterval time.Duration `json:"compaction_interval" yaml:"compaction_interval" default:"24h"`
+	// 写入提交到Raft后等待多数派确认的最长时间，超时的写入返回504
+	ApplyTimeout time.Duration `json:"apply_timeout" yaml:"apply_timeout" default:"5s"`
 }
 
 // BaseConfig 所有服务基础配置
@@ -4,8 +4,13 @@ import (
     "fmt"
     "reflect"
     "strconv"
+    "strings"
+    "time"
 )
 
+// durationType time.Duration的类型，底层是int64，需要在按Kind处理之前单独识别
+var durationType = reflect.TypeOf(time.Duration(0))
+
 // IsZeroValue 判断字段是否为零值
 func IsZeroValue(v reflect.Value) bool {
     switch v.Kind() {
@@ -30,13 +35,25 @@ func IsZeroValue(v reflect.Value) bool {
     }
 }
 
-// SetFieldFromString 根据字段类型从字符串设置值
+// SetFieldFromString 根据字段类型从字符串设置值。
+// time.Duration使用time.ParseDuration的格式（如"500ms"、"2s"），切片为逗号分隔的元素列表，元素两侧的空白被忽略
 func SetFieldFromString(field reflect.Value, value string) error {
     if !field.CanSet() {
         return nil
     }
     
+    if field.Type() == durationType {
+        d, err := time.ParseDuration(strings.TrimSpace(value))
+        if err != nil {
+            return fmt.Errorf("无法转换为时长(如500ms、2s、1h): %v", err)
+        }
+        field.SetInt(int64(d))
+        return nil
+    }
+    
     switch field.Kind() {
+    case reflect.Slice:
+        return setSliceFromString(field, value)
     case reflect.String:
         field.SetString(value)
     case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
@@ -69,4 +86,23 @@ func SetFieldFromString(field reflect.Value, value string) error {
     return nil
 }
 
+// setSliceFromString 将逗号分隔的列表解析为切片，空元素被跳过，任一元素解析失败时不修改字段
+func setSliceFromString(field reflect.Value, value string) error {
+    var items []string
+    for _, item := range strings.Split(value, ",") {
+        if item = strings.TrimSpace(item); item != "" {
+            items = append(items, item)
+        }
+    }
+    
+    slice := reflect.MakeSlice(field.Type(), len(items), len(items))
+    for i, item := range items {
+        if err := SetFieldFromString(slice.Index(i), item); err != nil {
+            return fmt.Errorf("第%d个元素%q: %w", i+1, item, err)
+        }
+    }
+    field.Set(slice)
+    return nil
+}
+
 // 其他反射工具函数...
\ No newline at end of file
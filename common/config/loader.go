@@ -90,20 +90,40 @@ func ApplyEnvironmentVariables(config interface{}) error {
 		return fmt.Errorf("config必须是结构体指针")
 	}
 
-	// 获取实际的结构体值
-	val = val.Elem()
+	_, err := applyEnvironmentVariables(val.Elem())
+	return err
+}
+
+// applyEnvironmentVariables 递归地用环境变量覆盖结构体字段，返回是否设置了任何字段
+func applyEnvironmentVariables(val reflect.Value) (bool, error) {
 	typ := val.Type()
+	applied := false
 
 	// 遍历所有字段
 	for i := 0; i < val.NumField(); i++ {
 		field := val.Field(i)
 		fieldType := typ.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
 
 		// 递归处理嵌套结构体
 		if field.Kind() == reflect.Struct {
-			if err := ApplyEnvironmentVariables(field.Addr().Interface()); err != nil {
-				return err
+			set, err := applyEnvironmentVariables(field)
+			if err != nil {
+				return false, err
 			}
+			applied = applied || set
+			continue
+		}
+
+		// 结构体指针：已分配的直接递归；为nil时只在有环境变量覆盖其中的字段时才分配
+		if isStructPtr(field) {
+			set, err := applyToStructPtr(field, applyEnvironmentVariables)
+			if err != nil {
+				return false, err
+			}
+			applied = applied || set
 			continue
 		}
 
@@ -121,11 +141,12 @@ func ApplyEnvironmentVariables(config interface{}) error {
 
 		// 设置字段值
 		if err := reflection.SetFieldFromString(field, envValue); err != nil {
-			return fmt.Errorf("设置字段 %s 失败: %w", fieldType.Name, err)
+			return false, fmt.Errorf("环境变量 %s=%q 无法设置字段 %s: %w", envTag, envValue, fieldType.Name, err)
 		}
+		applied = true
 	}
 
-	return nil
+	return applied, nil
 }
 
 // ApplyDefaults 应用默认值到配置项
@@ -136,18 +157,34 @@ func ApplyDefaults(config interface{}) {
 		return
 	}
 
-	// 获取实际的结构体值
-	val = val.Elem()
+	applyDefaults(val.Elem())
+}
+
+// applyDefaults 递归地为零值字段填充default标签的值，返回是否填充了任何字段
+func applyDefaults(val reflect.Value) bool {
 	typ := val.Type()
+	applied := false
 
 	// 遍历所有字段
 	for i := 0; i < val.NumField(); i++ {
 		field := val.Field(i)
 		fieldType := typ.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
 
 		// 递归处理嵌套结构体
 		if field.Kind() == reflect.Struct {
-			ApplyDefaults(field.Addr().Interface())
+			applied = applyDefaults(field) || applied
+			continue
+		}
+
+		// 结构体指针：已分配的直接递归；为nil时只在其中有字段声明了默认值时才分配
+		if isStructPtr(field) {
+			set, _ := applyToStructPtr(field, func(v reflect.Value) (bool, error) {
+				return applyDefaults(v), nil
+			})
+			applied = applied || set
 			continue
 		}
 
@@ -163,8 +200,35 @@ func ApplyDefaults(config interface{}) {
 				// Just log or ignore the error for default values
 				continue
 			}
+			applied = true
 		}
 	}
+
+	return applied
+}
+
+// isStructPtr 判断字段是否为结构体指针
+func isStructPtr(field reflect.Value) bool {
+	return field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Struct
+}
+
+// applyToStructPtr 对结构体指针字段执行apply。指针为nil时在新分配的结构体上执行，
+// 只有apply设置了字段时才把新结构体赋给字段，未配置的可选配置段保持nil
+func applyToStructPtr(field reflect.Value, apply func(reflect.Value) (bool, error)) (bool, error) {
+	if !field.IsNil() {
+		return apply(field.Elem())
+	}
+	if !field.CanSet() {
+		return false, nil
+	}
+
+	allocated := reflect.New(field.Type().Elem())
+	set, err := apply(allocated.Elem())
+	if err != nil || !set {
+		return false, err
+	}
+	field.Set(allocated)
+	return true, nil
 }
 
 // 测试辅助变量和函数 - 从parser.go移植过来
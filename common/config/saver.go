@@ -0,0 +1,159 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Format 配置文件格式
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+	FormatTOML Format = "toml"
+)
+
+// FormatFromPath 根据扩展名判断配置文件格式，与LoadConfig支持的扩展名一致
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	case ".toml":
+		return FormatTOML, nil
+	default:
+		return "", fmt.Errorf("不支持的配置文件格式: %s", filepath.Ext(path))
+	}
+}
+
+// SaveConfig 将配置写入path，格式由扩展名决定，保存后可以用LoadConfig按原格式读回
+func SaveConfig(config interface{}, path string) error {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+	return SaveConfigAs(config, path, format)
+}
+
+// SaveConfigAs 以指定格式将配置写入path。
+// 字段按结构体的声明顺序输出；YAML文件已存在时在原文件上更新取值，保留原有的注释、键的顺序和配置结构体之外的键。
+// 写入临时文件后重命名替换，写入失败时原文件不受影响
+func SaveConfigAs(config interface{}, path string, format Format) error {
+	var data []byte
+	var err error
+	switch format {
+	case FormatJSON:
+		data, err = json.MarshalIndent(config, "", "  ")
+		data = append(data, '\n')
+	case FormatYAML:
+		data, err = marshalYAML(config, path)
+	case FormatTOML:
+		data, err = toml.Marshal(config)
+	default:
+		return fmt.Errorf("不支持的配置文件格式: %s", format)
+	}
+	if err != nil {
+		return fmt.Errorf("序列化%s配置失败: %w", strings.ToUpper(string(format)), err)
+	}
+
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, mode); err != nil {
+		return fmt.Errorf("写入配置文件失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入配置文件失败: %w", err)
+	}
+	return nil
+}
+
+// marshalYAML 序列化配置；path处已有YAML文件时将新的取值合并到原文件的节点树中
+func marshalYAML(config interface{}, path string) ([]byte, error) {
+	var updated yaml.Node
+	if err := updated.Encode(config); err != nil {
+		return nil, err
+	}
+
+	root := &updated
+	if existing, err := os.ReadFile(path); err == nil {
+		var doc yaml.Node
+		if err := yaml.Unmarshal(existing, &doc); err == nil && len(doc.Content) == 1 &&
+			doc.Content[0].Kind == yaml.MappingNode && updated.Kind == yaml.MappingNode {
+			mergeYAMLNode(doc.Content[0], &updated)
+			root = &doc
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// mergeYAMLNode 将updated映射中的取值写入dst映射：已有的键原地替换取值并保留注释，新增的键追加到末尾
+func mergeYAMLNode(dst, updated *yaml.Node) {
+	for i := 0; i+1 < len(updated.Content); i += 2 {
+		key, value := updated.Content[i], updated.Content[i+1]
+
+		found := false
+		for j := 0; j+1 < len(dst.Content); j += 2 {
+			if dst.Content[j].Value != key.Value {
+				continue
+			}
+			found = true
+			old := dst.Content[j+1]
+			if old.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+				mergeYAMLNode(old, value)
+				break
+			}
+			if sameDuration(old, value) {
+				break
+			}
+			value.HeadComment = old.HeadComment
+			value.LineComment = old.LineComment
+			value.FootComment = old.FootComment
+			dst.Content[j+1] = value
+			break
+		}
+		if !found {
+			dst.Content = append(dst.Content, key, value)
+		}
+	}
+}
+
+// sameDuration 原文件中写作"30s"等形式的时长与序列化得到的纳秒数相等时保留原写法
+func sameDuration(old, value *yaml.Node) bool {
+	if old.Kind != yaml.ScalarNode || value.Kind != yaml.ScalarNode {
+		return false
+	}
+	d, err := time.ParseDuration(old.Value)
+	if err != nil {
+		return false
+	}
+	n, err := strconv.ParseInt(value.Value, 10, 64)
+	return err == nil && time.Duration(n) == d
+}
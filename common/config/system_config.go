@@ -23,6 +23,8 @@ type ServerConfig struct {
 	Port         int           `json:"port" yaml:"port" toml:"port" env:"SERVER_PORT" default:"8080"`
 	ReadTimeout  time.Duration `json:"read_timeout" yaml:"read_timeout" toml:"read_timeout" default:"30s"`
 	WriteTimeout time.Duration `json:"write_timeout" yaml:"write_timeout" toml:"write_timeout" default:"30s"`
+	// 集群选出领导者前是否对业务请求返回503
+	RequireLeader bool `json:"require_leader" yaml:"require_leader" toml:"require_leader" default:"true"`
 }
 
 // LoadSystemConfig 加载系统配置
@@ -0,0 +1,80 @@
+package raft
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Command 通过Raft提交的状态机命令，提议方和状态机应使用同一个CommandCodec编解码
+type Command struct {
+	Op    string `json:"op"`              // 操作类型，如put、delete，由状态机解释
+	Key   string `json:"key"`             // 操作的键，如文件路径
+	Value []byte `json:"value,omitempty"` // 操作的数据，编码方式由状态机决定
+}
+
+// CommandCodec 在Command与Propose提交的原始字节之间转换
+type CommandCodec interface {
+	Encode(cmd Command) ([]byte, error)
+	Decode(data []byte) (Command, error)
+}
+
+// ErrEmptyCommand 命令的操作类型为空
+var ErrEmptyCommand = errors.New("命令操作类型为空")
+
+// JSONCodec 以JSON编码命令，便于在日志和诊断信息中查看，Value编码为base64
+type JSONCodec struct{}
+
+// Encode 实现CommandCodec
+func (JSONCodec) Encode(cmd Command) ([]byte, error) {
+	if cmd.Op == "" {
+		return nil, ErrEmptyCommand
+	}
+	return json.Marshal(cmd)
+}
+
+// Decode 实现CommandCodec，拒绝未知字段，避免把其他格式的数据误当作命令
+func (JSONCodec) Decode(data []byte) (Command, error) {
+	var cmd Command
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cmd); err != nil {
+		return Command{}, fmt.Errorf("解码JSON命令失败: %w", err)
+	}
+	if cmd.Op == "" {
+		return Command{}, ErrEmptyCommand
+	}
+	return cmd, nil
+}
+
+// GobCodec 以gob编码命令，Value较大时比JSON更紧凑
+type GobCodec struct{}
+
+// Encode 实现CommandCodec
+func (GobCodec) Encode(cmd Command) ([]byte, error) {
+	if cmd.Op == "" {
+		return nil, ErrEmptyCommand
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(cmd); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode 实现CommandCodec
+func (GobCodec) Decode(data []byte) (Command, error) {
+	var cmd Command
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&cmd); err != nil {
+		return Command{}, fmt.Errorf("解码gob命令失败: %w", err)
+	}
+	if cmd.Op == "" {
+		return Command{}, ErrEmptyCommand
+	}
+	return cmd, nil
+}
+
+// DefaultCommandCodec 未指定编解码器时提议方和状态机共同使用的编解码器
+var DefaultCommandCodec CommandCodec = JSONCodec{}
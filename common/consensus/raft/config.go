@@ -1,6 +1,8 @@
 package raft
 
 import (
+	"time"
+
 	etcdraft "go.etcd.io/etcd/raft/v3"
 )
 
@@ -14,7 +16,7 @@ type Config struct {
 	HeartbeatTick int
 	// 选举超时时间(毫秒)
 	ElectionTick int
-	// 存储目录
+	// 日志持久化目录，为空时日志只保存在内存中，重启后丢失
 	StorageDir string
 	// 单次快照数据大小限制
 	SnapshotChunkSize uint64
@@ -22,19 +24,25 @@ type Config struct {
 	ApplyBufferSize int
 	// 发送通道缓冲大小
 	SendBufferSize int
+	// 快照之后可压缩的日志条目达到该数量时立即压缩，0表示只按间隔压缩
+	SnapshotThreshold uint64
+	// 定期压缩日志的间隔，丢弃最近一次快照已覆盖的条目，0表示不定期压缩
+	CompactionInterval time.Duration
 }
 
 // DefaultConfig 返回默认配置
 func DefaultConfig() *Config {
 	return &Config{
-		NodeID:            1,
-		Peers:             []uint64{1},
-		HeartbeatTick:     1,
-		ElectionTick:      10,
-		StorageDir:        "./raft-data",
-		SnapshotChunkSize: 1024 * 1024, // 1MB
-		ApplyBufferSize:   1024,
-		SendBufferSize:    1024,
+		NodeID:             1,
+		Peers:              []uint64{1},
+		HeartbeatTick:      1,
+		ElectionTick:       10,
+		StorageDir:         "",
+		SnapshotChunkSize:  1024 * 1024, // 1MB
+		ApplyBufferSize:    1024,
+		SendBufferSize:     1024,
+		SnapshotThreshold:  10000,
+		CompactionInterval: 24 * time.Hour,
 	}
 }
 
@@ -47,4 +55,4 @@ func (c *Config) ToEtcdConfig() *etcdraft.Config {
 		MaxSizePerMsg:   1024 * 1024,
 		MaxInflightMsgs: 256,
 	}
-}
\ No newline at end of file
+}
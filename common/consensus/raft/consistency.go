@@ -0,0 +1,295 @@
+package raft
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	etcdraft "go.etcd.io/etcd/raft/v3"
+)
+
+// ConsistencyLevel 写入返回成功前需要确认的副本范围
+type ConsistencyLevel string
+
+const (
+	// ConsistencyOne 条目写入提议节点自己的日志后即返回，其余副本最终复制
+	ConsistencyOne ConsistencyLevel = "one"
+	// ConsistencyQuorum 条目被多数派复制（已提交）且在提议节点上应用后返回
+	ConsistencyQuorum ConsistencyLevel = "quorum"
+	// ConsistencyAll 在ConsistencyQuorum的基础上，等待所有存活节点都复制了该条目，只能在领导者上使用
+	ConsistencyAll ConsistencyLevel = "all"
+)
+
+// DefaultConsistencyLevel 未指定一致性级别时使用的级别
+const DefaultConsistencyLevel = ConsistencyQuorum
+
+var (
+	// ErrNotLeader 需要领导者视角的操作在非领导者节点上调用
+	ErrNotLeader = errors.New("当前节点不是领导者")
+	// ErrStopped 节点已停止
+	ErrStopped = errors.New("raft节点已停止")
+	// ErrApplyTimeout 提议在超时时间内没有被提交和应用，通常是因为集群缺少多数派
+	ErrApplyTimeout = errors.New("提议未在超时时间内提交")
+)
+
+// DefaultApplyTimeout ProposeAndWait未指定超时时间时使用的超时时间
+const DefaultApplyTimeout = 5 * time.Second
+
+// ParseConsistencyLevel 解析一致性级别，不区分大小写，空字符串返回DefaultConsistencyLevel
+func ParseConsistencyLevel(s string) (ConsistencyLevel, error) {
+	switch level := ConsistencyLevel(strings.ToLower(strings.TrimSpace(s))); level {
+	case "":
+		return DefaultConsistencyLevel, nil
+	case ConsistencyOne, ConsistencyQuorum, ConsistencyAll:
+		return level, nil
+	default:
+		return "", fmt.Errorf("未知的一致性级别: %q，可选one、quorum、all", s)
+	}
+}
+
+// replicationPollInterval 等待所有存活节点复制时查询复制进度的间隔
+const replicationPollInterval = 10 * time.Millisecond
+
+// waitMagic 由ProposeWait提交的条目的前缀，其后是8字节的提议ID。
+// 条目应用时去掉前缀和ID，应用通道收到的仍是原始命令
+var waitMagic = []byte{0xff, 'D', 'W', 0x01}
+
+// waitHeaderLen 前缀与提议ID的总长度
+const waitHeaderLen = 4 + 8
+
+// proposalWaiter 等待一个提议被写入本地日志和应用
+type proposalWaiter struct {
+	persistedOnce sync.Once
+	persisted     chan struct{} // 条目写入本节点日志后关闭
+	appliedOnce   sync.Once
+	applied       chan struct{} // 条目应用后关闭
+	index         uint64        // 条目应用时的日志索引，applied关闭后可读
+}
+
+// requestSeqBits 提议ID和ReadIndex请求ID中本节点序号占用的低位数，高位为节点ID
+const requestSeqBits = 40
+
+// MaxNodeID 节点ID的上限。节点ID放在提议ID和ReadIndex请求ID的高24位，
+// 更大的节点ID左移后溢出，与其他节点的请求ID冲突，创建节点时拒绝
+const MaxNodeID = 1<<(64-requestSeqBits) - 1
+
+// waitRegistry 记录本节点发出、仍在等待确认的提议
+type waitRegistry struct {
+	mu      sync.Mutex
+	nextID  uint64
+	prefix  uint64 // 节点ID，放在提议ID的高位，使不同节点的提议ID不会冲突
+	waiters map[uint64]*proposalWaiter
+}
+
+func newWaitRegistry(nodeID uint64) *waitRegistry {
+	return &waitRegistry{prefix: nodeID << requestSeqBits, waiters: make(map[uint64]*proposalWaiter)}
+}
+
+// register 分配提议ID并登记等待者
+func (r *waitRegistry) register() (uint64, *proposalWaiter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	id := r.prefix | r.nextID
+	w := &proposalWaiter{persisted: make(chan struct{}), applied: make(chan struct{})}
+	r.waiters[id] = w
+	return id, w
+}
+
+// unregister 删除等待者
+func (r *waitRegistry) unregister(id uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.waiters, id)
+}
+
+// persisted 通知条目已写入本节点日志，条目不是本节点发出的提议时不做任何事
+func (r *waitRegistry) persisted(data []byte) {
+	id, _, ok := decodeWaitEntry(data)
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	w := r.waiters[id]
+	r.mu.Unlock()
+	if w != nil {
+		w.persistedOnce.Do(func() { close(w.persisted) })
+	}
+}
+
+// applied 通知条目已在index处应用
+func (r *waitRegistry) applied(id, index uint64) {
+	r.mu.Lock()
+	w := r.waiters[id]
+	r.mu.Unlock()
+	if w != nil {
+		w.appliedOnce.Do(func() {
+			w.index = index
+			close(w.applied)
+		})
+	}
+}
+
+// encodeWaitEntry 为命令加上前缀和提议ID
+func encodeWaitEntry(id uint64, command []byte) []byte {
+	data := make([]byte, waitHeaderLen+len(command))
+	copy(data, waitMagic)
+	binary.BigEndian.PutUint64(data[len(waitMagic):], id)
+	copy(data[waitHeaderLen:], command)
+	return data
+}
+
+// decodeWaitEntry 解析ProposeWait提交的条目，返回提议ID和原始命令；其他条目返回false
+func decodeWaitEntry(data []byte) (uint64, []byte, bool) {
+	if len(data) < waitHeaderLen || !bytes.HasPrefix(data, waitMagic) {
+		return 0, nil, false
+	}
+	return binary.BigEndian.Uint64(data[len(waitMagic):]), data[waitHeaderLen:], true
+}
+
+// ProposeWait 提交命令并按一致性级别等待确认，返回条目的日志索引（ConsistencyOne时为0，此时条目尚未提交）。
+// 应用通道收到的命令与直接调用Propose相同。ctx结束时返回ctx的错误，此时条目仍可能在之后被提交；
+// 领导者变更导致条目被覆盖时一直等待到ctx结束
+func (rn *RaftNode) ProposeWait(ctx context.Context, command []byte, level ConsistencyLevel) (uint64, error) {
+	if level == "" {
+		level = DefaultConsistencyLevel
+	}
+	if _, err := ParseConsistencyLevel(string(level)); err != nil {
+		return 0, err
+	}
+	if level == ConsistencyAll && !rn.IsLeader() {
+		return 0, ErrNotLeader
+	}
+
+	id, w := rn.waits.register()
+	defer rn.waits.unregister(id)
+
+	if err := rn.node.Propose(ctx, encodeWaitEntry(id, command)); err != nil {
+		if errors.Is(err, etcdraft.ErrStopped) {
+			return 0, ErrStopped
+		}
+		return 0, err
+	}
+
+	if level == ConsistencyOne {
+		return 0, rn.waitFor(ctx, w.persisted)
+	}
+	if err := rn.waitFor(ctx, w.applied); err != nil {
+		return 0, err
+	}
+	if level == ConsistencyQuorum {
+		return w.index, nil
+	}
+
+	ticker := time.NewTicker(replicationPollInterval)
+	defer ticker.Stop()
+	for {
+		done, err := rn.replicatedToLive(w.index)
+		if err != nil || done {
+			return w.index, err
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return w.index, ctx.Err()
+		case <-rn.done:
+			return w.index, ErrStopped
+		}
+	}
+}
+
+// ProposeAndWait 以DefaultConsistencyLevel提交命令，最多等待timeout（不大于0时为DefaultApplyTimeout）。
+// 没有领导者时提议被丢弃，此时在超时前重新提交；超时返回包装了ErrApplyTimeout的错误，
+// 此时条目仍可能在之后被提交。ctx先于超时结束时返回ctx的错误
+func (rn *RaftNode) ProposeAndWait(ctx context.Context, command []byte, timeout time.Duration) (uint64, error) {
+	return rn.ProposeAndWaitLevel(ctx, command, DefaultConsistencyLevel, timeout)
+}
+
+// ProposeAndWaitLevel 与ProposeAndWait相同，按指定的一致性级别等待确认，返回值含义与ProposeWait相同
+func (rn *RaftNode) ProposeAndWaitLevel(ctx context.Context, command []byte, level ConsistencyLevel, timeout time.Duration) (uint64, error) {
+	if timeout <= 0 {
+		timeout = DefaultApplyTimeout
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		index, err := rn.ProposeWait(waitCtx, command, level)
+		if errors.Is(err, etcdraft.ErrProposalDropped) {
+			select {
+			case <-time.After(replicationPollInterval):
+				continue
+			case <-waitCtx.Done():
+				err = waitCtx.Err()
+			}
+		}
+		if err != nil && ctx.Err() == nil && waitCtx.Err() != nil {
+			return 0, fmt.Errorf("%w: %v", ErrApplyTimeout, timeout)
+		}
+		return index, err
+	}
+}
+
+// waitFor 等待ch关闭、ctx结束或节点停止
+func (rn *RaftNode) waitFor(ctx context.Context, ch <-chan struct{}) error {
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-rn.done:
+		return ErrStopped
+	}
+}
+
+// replicatedToLive 检查所有存活节点是否都已复制到index，本节点不再是领导者时返回ErrNotLeader
+func (rn *RaftNode) replicatedToLive(index uint64) (bool, error) {
+	status := rn.node.Status()
+	if status.RaftState != etcdraft.StateLeader {
+		return false, ErrNotLeader
+	}
+	for id, pr := range status.Progress {
+		if id == rn.config.NodeID || !rn.peerLive(id) {
+			continue
+		}
+		if pr.Match < index {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// liveWindow 节点在该时间内发来过消息即视为存活，与选举超时相同
+func (rn *RaftNode) liveWindow() time.Duration {
+	return time.Duration(rn.config.ElectionTick) * tickInterval
+}
+
+// recordContact 记录收到peer消息的时间
+func (rn *RaftNode) recordContact(peer uint64) {
+	if peer == 0 {
+		return
+	}
+	now := time.Now().UnixNano()
+	if v, ok := rn.lastContact.Load(peer); ok {
+		atomic.StoreInt64(v.(*int64), now)
+		return
+	}
+	v, _ := rn.lastContact.LoadOrStore(peer, new(int64))
+	atomic.StoreInt64(v.(*int64), now)
+}
+
+// peerLive 判断peer最近是否发来过消息
+func (rn *RaftNode) peerLive(peer uint64) bool {
+	v, ok := rn.lastContact.Load(peer)
+	if !ok {
+		return false
+	}
+	last := time.Unix(0, atomic.LoadInt64(v.(*int64)))
+	return time.Since(last) < rn.liveWindow()
+}
@@ -2,7 +2,10 @@ package raft
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/22827099/DFS_v1/common/logging"
@@ -16,20 +19,36 @@ type RaftNode struct {
     isLeader    bool                  // 是否为领导者
     config      *Config               // 配置
     node        etcdraft.Node         // etcd/raft 节点
-    raftStorage *MemoryStorage        // 内存存储
+    raftStorage Storage               // 日志存储，StorageDir为空时为内存存储
     transport   Transport             // 网络传输接口
     readyHandler *readyHandler        // Ready对象处理器
     applyCh     chan ApplyMsg         // 应用通道，用于接收已提交的日志条目
-    leaderCh    chan bool             // 通知领导者变更
+    leaderCh    chan bool             // 通知领导者变更，只保留最新的状态
     proposeC    chan []byte           // 提案通道
-    confChangeC chan raftpb.ConfChange // 配置变更通道
     commitC     chan *commit           // 提交通道
     done        chan struct{}          // 停止信号
+    stopped     chan struct{}          // 处理循环退出、存储关闭后关闭
     stopOnce    sync.Once              // 确保停止操作只执行一次
+    waits       *waitRegistry          // ProposeWait发出、仍在等待确认的提议
+    reads       *readRegistry          // ReadIndex发出、仍在等待结果的请求
+    deliveredIndex uint64              // 最近一次交给应用通道的消息的日志索引，原子访问
+    lastContact sync.Map               // 节点ID -> 最近一次收到其消息的时间(*int64, UnixNano)
+    snapshotFn  SnapshotFunc           // 状态机序列化回调，为nil时不自动创建快照，由mu保护
+    snapshotting int32                 // 自动快照是否正在进行，原子访问
+    confIndex   uint64                 // 最近一次应用的配置变更的日志索引，原子访问
 }
 
+// SnapshotFunc 由状态机注册，序列化其当前状态。返回的index是状态中已包含的最后一个日志索引，
+// 即状态机从应用通道处理过的最后一条消息的索引，data为序列化后的状态
+type SnapshotFunc func() (index uint64, data []byte, err error)
 
-// ApplyMsg 表示需要应用到状态机的消息
+// tickInterval Raft逻辑时钟的间隔，ElectionTick和HeartbeatTick以此为单位
+const tickInterval = 100 * time.Millisecond
+
+
+// ApplyMsg 表示需要应用到状态机的消息。
+// CommandValid、SnapshotValid、ConfChangeValid三者至多一个为true；
+// 配置变更由Raft节点自身应用，只通知上层成员变化，Command中永远不会出现配置变更的原始数据
 type ApplyMsg struct {
 	CommandValid bool
 	Command      []byte
@@ -40,6 +59,12 @@ type ApplyMsg struct {
 	Snapshot      []byte
 	SnapshotTerm  uint64
 	SnapshotIndex uint64
+	// 配置变更相关字段，ConfState为应用变更后的集群成员
+	ConfChangeValid bool
+	ConfChange      raftpb.ConfChangeV2
+	ConfState       raftpb.ConfState
+	ConfChangeIndex uint64
+	ConfChangeTerm  uint64
 }
 
 type commit struct {
@@ -49,23 +74,44 @@ type commit struct {
 
 // Step 处理从网络接收到的 Raft 消息
 func (rn *RaftNode) Step(ctx context.Context, msg raftpb.Message) error {
+	rn.recordContact(msg.From)
 	return rn.node.Step(ctx, msg)
 }
 
-// NewRaftNode 创建一个新的Raft节点
+// NewRaftNode 创建一个新的Raft节点。StorageDir不为空时日志持久化到该目录，
+// 目录中已有日志时从中恢复（忽略Peers），并先把已持久化的快照交给应用通道；
+// Peers为空时不引导集群，节点由已有集群的领导者加入。节点ID超过MaxNodeID时返回错误
 func NewRaftNode(config *Config, transport Transport) (*RaftNode, error) {
-	storage := NewMemoryStorage()
+	if config.NodeID > MaxNodeID {
+		return nil, fmt.Errorf("节点ID%d超过上限%d", config.NodeID, MaxNodeID)
+	}
+
+	storage, err := openStorage(config.StorageDir)
+	if err != nil {
+		return nil, err
+	}
 
 	etcdConfig := config.ToEtcdConfig()
 	etcdConfig.Storage = storage
 
-	// 初始化集群成员
-	peers := make([]etcdraft.Peer, len(config.Peers))
-	for i, id := range config.Peers {
-		peers[i] = etcdraft.Peer{ID: id}
+	restart, err := hasState(storage)
+	if err != nil {
+		storage.Close()
+		return nil, err
 	}
 
-	node := etcdraft.StartNode(etcdConfig, peers)
+	var node etcdraft.Node
+	if restart || len(config.Peers) == 0 {
+		// 没有初始成员的节点等待领导者通过成员变更把它加入已有集群
+		node = etcdraft.RestartNode(etcdConfig)
+	} else {
+		// 初始化集群成员
+		peers := make([]etcdraft.Peer, len(config.Peers))
+		for i, id := range config.Peers {
+			peers[i] = etcdraft.Peer{ID: id}
+		}
+		node = etcdraft.StartNode(etcdConfig, peers)
+	}
 
 	rn := &RaftNode{
 		config:      config,
@@ -73,24 +119,44 @@ func NewRaftNode(config *Config, transport Transport) (*RaftNode, error) {
 		raftStorage: storage,
 		transport:   transport,
 		applyCh:     make(chan ApplyMsg, config.ApplyBufferSize),
+		leaderCh:    make(chan bool, 1),
 		proposeC:    make(chan []byte, config.SendBufferSize),
-		confChangeC: make(chan raftpb.ConfChange),
 		commitC:     make(chan *commit),
 		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+		waits:       newWaitRegistry(config.NodeID),
+		reads:       newReadRegistry(config.NodeID),
 	}
 
 	rn.readyHandler = newReadyHandler(rn)
 
+	// 重启时已持久化的快照不会出现在Ready中，先交给状态机恢复，之后只重放快照之后的条目
+	if snapshot, err := storage.Snapshot(); err == nil && !etcdraft.IsEmptySnap(snapshot) {
+		rn.applyCh <- ApplyMsg{
+			SnapshotValid: true,
+			Snapshot:      snapshot.Data,
+			SnapshotTerm:  snapshot.Metadata.Term,
+			SnapshotIndex: snapshot.Metadata.Index,
+		}
+		rn.deliveredIndex = snapshot.Metadata.Index
+		rn.readyHandler.appliedIndex = snapshot.Metadata.Index
+		rn.readyHandler.appliedTerm = snapshot.Metadata.Term
+		rn.reads.notifyApplied(snapshot.Metadata.Index)
+	}
+
 	// 启动节点处理循环
 	go rn.run()
 	go rn.serveProposals()
+	if config.CompactionInterval > 0 {
+		go rn.compactLoop(config.CompactionInterval)
+	}
 
 	return rn, nil
 }
 
 // 处理Raft节点事件的主循环
 func (rn *RaftNode) run() {
-	ticker := time.NewTicker(100 * time.Millisecond)
+	ticker := time.NewTicker(tickInterval)
 	defer ticker.Stop()
 
 	for {
@@ -103,6 +169,10 @@ func (rn *RaftNode) run() {
 
 		case <-rn.done:
 			rn.node.Stop()
+			if err := rn.raftStorage.Close(); err != nil {
+				logging.Error("关闭Raft存储失败: %v", err)
+			}
+			close(rn.stopped)
 			return
 		}
 	}
@@ -115,9 +185,6 @@ func (rn *RaftNode) serveProposals() {
 		case prop := <-rn.proposeC:
 			rn.node.Propose(context.TODO(), prop)
 
-		case cc := <-rn.confChangeC:
-			rn.node.ProposeConfChange(context.TODO(), cc)
-
 		case <-rn.done:
 			return
 		}
@@ -134,20 +201,251 @@ func (rn *RaftNode) Propose(command []byte) bool {
 	}
 }
 
-// Stop 停止Raft节点
+// ProposeConfChange 提交一个集群成员变更，提交后由Raft节点应用，并通过ApplyCh以ConfChangeValid消息通知。
+// 节点已停止时返回ErrStopped，ctx结束前提议未被Raft接收时返回ctx的错误
+func (rn *RaftNode) ProposeConfChange(ctx context.Context, cc raftpb.ConfChange) error {
+	select {
+	case <-rn.done:
+		return ErrStopped
+	default:
+	}
+	if err := rn.node.ProposeConfChange(ctx, cc); err != nil {
+		if errors.Is(err, etcdraft.ErrStopped) {
+			return ErrStopped
+		}
+		return err
+	}
+	return nil
+}
+
+// Campaign 让本节点立即发起选举，而不必等待选举超时
+func (rn *RaftNode) Campaign(ctx context.Context) error {
+	return rn.node.Campaign(ctx)
+}
+
+// ReportUnreachable 由传输层在消息无法送达id时调用，领导者随后改为探测该节点而不是持续发送日志
+func (rn *RaftNode) ReportUnreachable(id uint64) {
+	rn.node.ReportUnreachable(id)
+}
+
+// TransferLeadership 请求将领导权转移给target，只在领导者上生效。
+// 请求是异步的，目标在一个选举超时内没有当选时Raft放弃转移，是否完成需要通过Status确认
+func (rn *RaftNode) TransferLeadership(ctx context.Context, target uint64) {
+	rn.node.TransferLeadership(ctx, rn.config.NodeID, target)
+}
+
+// ReportSnapshot 由传输层报告发往id的快照是否送达，未报告时领导者不会再向该节点发送快照
+func (rn *RaftNode) ReportSnapshot(id uint64, failed bool) {
+	status := etcdraft.SnapshotFinish
+	if failed {
+		status = etcdraft.SnapshotFailure
+	}
+	rn.node.ReportSnapshot(id, status)
+}
+
+// CreateSnapshot 记录状态机在index处的快照，index必须是已应用的日志索引且不早于当前快照。
+// 快照之前的日志在下一次定期压缩时丢弃；快照之后可压缩的日志数达到SnapshotThreshold时立即压缩
+func (rn *RaftNode) CreateSnapshot(index uint64, data []byte) error {
+	applied := atomic.LoadUint64(&rn.readyHandler.appliedIndex)
+	if index > applied {
+		return fmt.Errorf("快照索引%d超过已应用的日志索引%d", index, applied)
+	}
+	if err := rn.raftStorage.CreateSnapshot(index, data); err != nil {
+		return err
+	}
+
+	threshold := rn.config.SnapshotThreshold
+	if threshold > 0 && index-rn.CompactedIndex() >= threshold {
+		rn.compact()
+	}
+	return nil
+}
+
+// SetSnapshotFunc 注册状态机的序列化回调。已应用的日志超过最近一次快照SnapshotThreshold条时，
+// 节点在后台调用fn并以其结果创建快照，随后压缩快照之前的日志；fn为nil时停止自动快照
+func (rn *RaftNode) SetSnapshotFunc(fn SnapshotFunc) {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	rn.snapshotFn = fn
+}
+
+// SnapshotIndex 返回最近一次快照的日志索引，没有快照时返回0
+func (rn *RaftNode) SnapshotIndex() uint64 {
+	snapshot, err := rn.raftStorage.Snapshot()
+	if err != nil {
+		return 0
+	}
+	return snapshot.Metadata.Index
+}
+
+// maybeSnapshot 已应用的日志超过最近一次快照SnapshotThreshold条时在后台创建快照，
+// 同一时间只有一次自动快照在进行；回调可能依赖状态机处理应用通道，因此不能在处理循环中调用。
+// 已有快照早于最近一次配置变更时也立即创建快照：快照记录的成员不含之后加入的节点，
+// 新节点会拒绝这样的快照，而它需要的日志已被压缩
+func (rn *RaftNode) maybeSnapshot(applied uint64) {
+	threshold := rn.config.SnapshotThreshold
+	if threshold == 0 {
+		return
+	}
+	rn.mu.RLock()
+	fn := rn.snapshotFn
+	rn.mu.RUnlock()
+	if fn == nil {
+		return
+	}
+	snapshotIndex := rn.SnapshotIndex()
+	stale := snapshotIndex > 0 && snapshotIndex < atomic.LoadUint64(&rn.confIndex)
+	if !stale && applied-snapshotIndex <= threshold {
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&rn.snapshotting, 0, 1) {
+		return
+	}
+
+	go func() {
+		defer atomic.StoreInt32(&rn.snapshotting, 0)
+
+		index, data, err := fn()
+		if err != nil {
+			logging.Error("序列化状态机失败: %v", err)
+			return
+		}
+		select {
+		case <-rn.done:
+			return
+		default:
+		}
+		// 状态机尚未处理到新的条目，等下一次触发
+		if index <= rn.SnapshotIndex() {
+			return
+		}
+		if err := rn.CreateSnapshot(index, data); err != nil {
+			logging.Error("创建Raft快照失败，索引: %d，错误: %v", index, err)
+			return
+		}
+		logging.Info("创建Raft快照，索引: %d", index)
+	}()
+}
+
+// CompactedIndex 返回已被压缩丢弃的最大日志索引，尚未压缩时返回0
+func (rn *RaftNode) CompactedIndex() uint64 {
+	first, _ := rn.raftStorage.FirstIndex()
+	return first - 1
+}
+
+// compactLoop 按固定间隔压缩日志，写入量很小、达不到快照阈值时也能限制日志占用的内存
+func (rn *RaftNode) compactLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			rn.compact()
+		case <-rn.done:
+			return
+		}
+	}
+}
+
+// compact 丢弃最近一次快照已覆盖的日志条目，没有快照时不做任何事
+func (rn *RaftNode) compact() {
+	if n := rn.raftStorage.Compact(); n > 0 {
+		logging.Info("压缩Raft日志，丢弃%d条，压缩至索引: %d", n, rn.CompactedIndex())
+	}
+}
+
+// Stop 停止Raft节点，等待处理循环退出并关闭存储后返回
 func (rn *RaftNode) Stop() {
 	rn.stopOnce.Do(func() {
 		close(rn.done)
 	})
+	<-rn.stopped
 }
 
-// IsLeader 返回当前节点是否为领导者
+// IsLeader 返回当前节点是否为领导者。
+// 节点当选后，直到本任期之前的已提交条目全部交给应用通道才报告为领导者
 func (rn *RaftNode) IsLeader() bool {
 	rn.mu.RLock()
 	defer rn.mu.RUnlock()
 	return rn.isLeader
 }
 
+// AppliedIndex 返回已交给应用通道的最大日志索引
+func (rn *RaftNode) AppliedIndex() uint64 {
+	return atomic.LoadUint64(&rn.readyHandler.appliedIndex)
+}
+
+// ConfState 返回存储中记录的成员配置，即最近一次应用的配置变更或快照中的成员
+func (rn *RaftNode) ConfState() raftpb.ConfState {
+	_, confState, _ := rn.raftStorage.InitialState()
+	return confState
+}
+
+// setLeader 更新对外报告的领导者状态，状态变化时通知LeaderCh
+func (rn *RaftNode) setLeader(isLeader bool) {
+	rn.mu.Lock()
+	changed := rn.isLeader != isLeader
+	rn.isLeader = isLeader
+	rn.mu.Unlock()
+	if !changed {
+		return
+	}
+
+	// 接收方来不及处理时丢弃过时的状态，只有处理循环发送，清空后一定能写入
+	select {
+	case rn.leaderCh <- isLeader:
+	default:
+		select {
+		case <-rn.leaderCh:
+		default:
+		}
+		rn.leaderCh <- isLeader
+	}
+}
+
+// Term 返回节点当前所处的Raft任期，节点停止后返回0
+func (rn *RaftNode) Term() uint64 {
+	return rn.node.Status().Term
+}
+
+// Status 节点Raft状态的摘要，用于诊断
+type Status struct {
+	ID             uint64            `json:"id"`
+	RaftState      string            `json:"raft_state"` // StateFollower、StateCandidate、StateLeader等
+	Term           uint64            `json:"term"`
+	Vote           uint64            `json:"vote"`
+	Lead           uint64            `json:"lead"`
+	Commit         uint64            `json:"commit"`
+	Applied        uint64            `json:"applied"`            // 已交给应用通道的最大索引
+	CompactedIndex uint64            `json:"compacted_index"`    // 最近一次日志压缩到的索引
+	Progress       map[uint64]uint64 `json:"progress,omitempty"` // 仅领导者有，节点ID -> 已复制到的索引
+	IsLeader       bool              `json:"is_leader"`          // 对外报告的领导者身份，见IsLeader
+}
+
+// Status 返回节点当前的Raft状态，需要与节点的处理循环交互，循环阻塞时调用也会阻塞
+func (rn *RaftNode) Status() Status {
+	st := rn.node.Status()
+	status := Status{
+		ID:             st.ID,
+		RaftState:      st.RaftState.String(),
+		Term:           st.Term,
+		Vote:           st.Vote,
+		Lead:           st.Lead,
+		Commit:         st.Commit,
+		Applied:        rn.AppliedIndex(),
+		CompactedIndex: rn.CompactedIndex(),
+		IsLeader:       rn.IsLeader(),
+	}
+	if len(st.Progress) > 0 {
+		status.Progress = make(map[uint64]uint64, len(st.Progress))
+		for id, pr := range st.Progress {
+			status.Progress[id] = pr.Match
+		}
+	}
+	return status
+}
+
 // ApplyCh 返回应用通道，用于接收已提交的日志条目
 func (rn *RaftNode) ApplyCh() <-chan ApplyMsg {
 	return rn.applyCh
@@ -160,7 +458,10 @@ func (rn *RaftNode) LeaderCh() <-chan bool {
 
 // readyHandler 处理Ready对象
 type readyHandler struct {
-	rn *RaftNode
+	rn           *RaftNode
+	appliedIndex uint64 // 已应用的最大日志索引，重复提交的条目（如重放）不会再次应用；只在处理循环中写入，其他协程需原子读取
+	appliedTerm  uint64 // 最后应用的条目所属的任期
+	leaderTerm   uint64 // 已当选但尚未对外报告的任期，0表示没有等待报告的领导权
 }
 
 func newReadyHandler(rn *RaftNode) *readyHandler {
@@ -168,63 +469,40 @@ func newReadyHandler(rn *RaftNode) *readyHandler {
 }
 
 func (rh *readyHandler) handleReady(rd etcdraft.Ready) {
-    // 1. 持久化日志条目和 HardState
+    // 1. 先保存快照，再持久化 HardState 和日志条目：同一个Ready中的条目紧接在快照之后，
+    // 先追加条目会与快照之前的旧日志不连续。持久化失败时继续运行可能违反Raft的安全性
+    if !etcdraft.IsEmptySnap(rd.Snapshot) {
+        if err := rh.rn.raftStorage.SaveSnapshot(rd.Snapshot); err != nil {
+            logging.Error("持久化Raft快照失败: %v", err)
+            panic(err)
+        }
+    }
+
     if !etcdraft.IsEmptyHardState(rd.HardState) {
-        rh.rn.raftStorage.mu.Lock()
-        rh.rn.raftStorage.hardState = rd.HardState
-        rh.rn.raftStorage.mu.Unlock()
+        if err := rh.rn.raftStorage.SaveState(rd.HardState); err != nil {
+            logging.Error("持久化Raft状态失败: %v", err)
+            panic(err)
+        }
     }
     
     if len(rd.Entries) > 0 {
-        rh.rn.raftStorage.mu.Lock()
-        if len(rh.rn.raftStorage.entries) == 0 {
-            // 存储为空，直接使用新条目
-            rh.rn.raftStorage.entries = append([]raftpb.Entry{}, rd.Entries...)
-        } else {
-            // 处理已有条目情况
-            firstNewIdx := rd.Entries[0].Index
-            firstStoreIdx := rh.rn.raftStorage.entries[0].Index
-            
-            // 计算在存储中的偏移
-            offset := int(firstNewIdx - firstStoreIdx)
-            
-            if offset < 0 {
-                // 新条目比存储的更早
-                rh.rn.raftStorage.entries = append([]raftpb.Entry{}, rd.Entries...)
-            } else if offset < len(rh.rn.raftStorage.entries) {
-                // 有重叠，保留前面的条目，覆盖重叠部分，添加新条目
-                rh.rn.raftStorage.entries = append(
-                    rh.rn.raftStorage.entries[:offset],
-                    rd.Entries...,
-                )
-            } else if offset == len(rh.rn.raftStorage.entries) {
-                // 直接接续，没有间隙
-                rh.rn.raftStorage.entries = append(rh.rn.raftStorage.entries, rd.Entries...)
-            } else {
-                // 有间隙，不应该发生，日志会丢失
-                panic("raft log has gap")
+        if err := rh.rn.raftStorage.SaveEntries(rd.Entries); err != nil {
+            logging.Error("持久化Raft日志失败: %v", err)
+            panic(err)
+        }
+        
+        // 通知等待写入本地日志的提议
+        for _, entry := range rd.Entries {
+            if entry.Type == raftpb.EntryNormal {
+                rh.rn.waits.persisted(entry.Data)
             }
         }
-        rh.rn.raftStorage.mu.Unlock()
     }
     
-    // 2. 处理快照
+    // 2. 快照交给状态机，之后的已提交条目在快照的基础上应用
     if !etcdraft.IsEmptySnap(rd.Snapshot) {
-        rh.rn.raftStorage.mu.Lock()
-        rh.rn.raftStorage.snapshot = rd.Snapshot
-        // 快照可能会使旧日志条目过时，需要更新 entries 数组
         snapshotIndex := rd.Snapshot.Metadata.Index
         
-        // 保留快照索引之后的条目
-        newEntries := make([]raftpb.Entry, 0)
-        for _, entry := range rh.rn.raftStorage.entries {
-            if entry.Index > snapshotIndex {
-                newEntries = append(newEntries, entry)
-            }
-        }
-        rh.rn.raftStorage.entries = newEntries
-        rh.rn.raftStorage.mu.Unlock()
-        
         // 构造应用消息并发送到 applyCh
         applyMsg := ApplyMsg{
             SnapshotValid: true,
@@ -232,7 +510,11 @@ func (rh *readyHandler) handleReady(rd etcdraft.Ready) {
             SnapshotTerm:  rd.Snapshot.Metadata.Term,
             SnapshotIndex: snapshotIndex,
         }
-        rh.rn.applyCh <- applyMsg
+        rh.deliver(applyMsg)
+        if snapshotIndex > rh.appliedIndex {
+            atomic.StoreUint64(&rh.appliedIndex, snapshotIndex)
+            rh.appliedTerm = rd.Snapshot.Metadata.Term
+        }
     }
     
     // 3. 发送消息到其他节点
@@ -240,68 +522,122 @@ func (rh *readyHandler) handleReady(rd etcdraft.Ready) {
         rh.rn.transport.Send(rd.Messages)
     }
     
-    // 4. 应用已提交的条目到状态机
+    // 4. 应用已提交的条目：普通命令交给状态机，配置变更由Raft节点应用后单独通知
     for _, entry := range rd.CommittedEntries {
-        if entry.Type == raftpb.EntryNormal && len(entry.Data) > 0 {
-            // 打印日志帮助调试
-        	logging.Info("应用命令，索引: %d，长度: %d\n", entry.Index, len(entry.Data))
-
-			// 普通命令，应用到状态机
-            applyMsg := ApplyMsg{
-                CommandValid: true,
-                Command:      append([]byte{}, entry.Data...),
-                CommandIndex: entry.Index,
-                CommandTerm:  entry.Term,
+        if entry.Index <= rh.appliedIndex {
+            continue
+        }
+        atomic.StoreUint64(&rh.appliedIndex, entry.Index)
+        rh.appliedTerm = entry.Term
+        
+        switch entry.Type {
+        case raftpb.EntryNormal:
+            // ProposeWait提交的条目去掉前缀后交给状态机，交出后通知等待者
+            command := entry.Data
+            id, stripped, waited := decodeWaitEntry(entry.Data)
+            if waited {
+                command = stripped
             }
-            rh.rn.applyCh <- applyMsg
-        } else if entry.Type == raftpb.EntryConfChange {
-            // 处理配置变更
-            var cc raftpb.ConfChange
-            if err := cc.Unmarshal(entry.Data); err != nil {
-                // 反序列化失败，记录错误并继续
-                // 实际生产环境应该有日志记录
+            // 领导者当选后追加的空条目不需要应用
+            if len(command) == 0 {
+                if waited {
+                    rh.rn.waits.applied(id, entry.Index)
+                }
                 continue
             }
-            
-            // 应用配置变更
-            confState := rh.rn.node.ApplyConfChange(cc)
-            
-            // 更新存储的配置状态
-            rh.rn.raftStorage.mu.Lock()
-            rh.rn.raftStorage.confState = *confState
-            rh.rn.raftStorage.mu.Unlock()
-            
-            // 通知上层应用配置变更
-            applyMsg := ApplyMsg{
+            // 打印日志帮助调试
+            logging.Info("应用命令，索引: %d，长度: %d\n", entry.Index, len(command))
+
+            rh.deliver(ApplyMsg{
                 CommandValid: true,
-                Command:      entry.Data,
+                Command:      append([]byte{}, command...),
                 CommandIndex: entry.Index,
                 CommandTerm:  entry.Term,
+            })
+            if waited {
+                rh.rn.waits.applied(id, entry.Index)
             }
-            rh.rn.applyCh <- applyMsg
+        case raftpb.EntryConfChange, raftpb.EntryConfChangeV2:
+            rh.applyConfChange(entry)
         }
     }
+    rh.rn.reads.notifyApplied(rh.appliedIndex)
+    // 没有新条目时也检查，状态机处理完配置变更后才能创建包含新成员的快照
+    rh.rn.maybeSnapshot(rh.appliedIndex)
+    
+    // ReadIndex请求的结果，等待者随后等待应用索引追上
+    if len(rd.ReadStates) > 0 {
+        rh.rn.reads.resolve(rd.ReadStates)
+    }
     
-    // 5. 处理领导者变更
+    // 5. 处理领导者变更：当选后先记录任期，等到本任期的第一个条目（当选时追加的空条目）被应用后才对外报告，
+    // 此时之前任期的所有已提交条目都已交给应用通道，新领导者不会基于未应用完的状态提供服务；失去领导权立即报告
     if rd.SoftState != nil {
-        wasLeader := rh.rn.isLeader
-        newIsLeader := rd.SoftState.RaftState == etcdraft.StateLeader
-        
-        // 只有状态变化时才需要更新
-        if wasLeader != newIsLeader {
-            rh.rn.mu.Lock()
-            rh.rn.isLeader = newIsLeader
-            rh.rn.mu.Unlock()
-            
-            // 可以在这里处理领导者变更的其他逻辑
-            // 如：领导者选举后的初始化工作
+        if rd.SoftState.RaftState == etcdraft.StateLeader {
+            if rh.leaderTerm == 0 && !rh.rn.IsLeader() {
+                hardState, _, _ := rh.rn.raftStorage.InitialState()
+                rh.leaderTerm = hardState.Term
+            }
+        } else {
+            rh.leaderTerm = 0
+            rh.rn.setLeader(false)
         }
     }
+    if rh.leaderTerm != 0 && rh.appliedTerm >= rh.leaderTerm {
+        rh.leaderTerm = 0
+        rh.rn.setLeader(true)
+    }
     
     // 6. 通知 raft 库已处理完 Ready
     rh.rn.node.Advance()
 }
 
+// applyConfChange 将配置变更应用到Raft节点并更新存储的成员配置，再通知上层成员变化。
+// 反序列化失败的条目被跳过，不会作为命令交给状态机
+func (rh *readyHandler) applyConfChange(entry raftpb.Entry) {
+    var cc raftpb.ConfChangeI
+    if entry.Type == raftpb.EntryConfChange {
+        var v1 raftpb.ConfChange
+        if err := v1.Unmarshal(entry.Data); err != nil {
+            logging.Error("解析配置变更失败，索引: %d，错误: %v", entry.Index, err)
+            return
+        }
+        cc = v1
+    } else {
+        var v2 raftpb.ConfChangeV2
+        if err := v2.Unmarshal(entry.Data); err != nil {
+            logging.Error("解析配置变更失败，索引: %d，错误: %v", entry.Index, err)
+            return
+        }
+        cc = v2
+    }
+    
+    confState := rh.rn.node.ApplyConfChange(cc)
+    
+    if err := rh.rn.raftStorage.SetConfState(*confState); err != nil {
+        logging.Error("持久化成员配置失败: %v", err)
+        panic(err)
+    }
+    atomic.StoreUint64(&rh.rn.confIndex, entry.Index)
+    
+    rh.deliver(ApplyMsg{
+        ConfChangeValid: true,
+        ConfChange:      cc.AsV2(),
+        ConfState:       *confState,
+        ConfChangeIndex: entry.Index,
+        ConfChangeTerm:  entry.Term,
+    })
+}
+
+// deliver 将消息交给应用通道，节点停止后不再等待，避免没有接收方时处理循环无法退出
+func (rh *readyHandler) deliver(msg ApplyMsg) {
+    select {
+    case rh.rn.applyCh <- msg:
+        atomic.StoreUint64(&rh.rn.deliveredIndex, max(msg.CommandIndex, msg.SnapshotIndex, msg.ConfChangeIndex))
+    case <-rh.rn.done:
+    }
+}
+
 // MemoryStorage 是一个内存存储实现
 type MemoryStorage struct {
     // 添加必要的字段
@@ -318,6 +654,10 @@ func (m *MemoryStorage) Entries(lo uint64, hi uint64, maxSize uint64) ([]raftpb.
     defer m.mu.RUnlock()
     
     if len(m.entries) == 0 {
+        // 全部条目都已被压缩时，快照及之前的索引返回ErrCompacted，Raft据此改为发送快照
+        if lo <= m.snapshot.Metadata.Index {
+            return nil, etcdraft.ErrCompacted
+        }
         return nil, etcdraft.ErrUnavailable
     }
     
@@ -403,6 +743,10 @@ func (m *MemoryStorage) Term(i uint64) (uint64, error) {
 	if i < m.snapshot.Metadata.Index {
 		return 0, etcdraft.ErrCompacted
 	}
+	// 压缩后快照索引处的条目已被丢弃，任期由快照记录
+	if i == m.snapshot.Metadata.Index {
+		return m.snapshot.Metadata.Term, nil
+	}
 	
 	if len(m.entries) == 0 {
 		// If there are no entries but the index matches the snapshot index
@@ -430,6 +774,123 @@ func NewMemoryStorage() *MemoryStorage {
 	return &MemoryStorage{}
 }
 
+// Initialize 内存存储不需要初始化
+func (m *MemoryStorage) Initialize() error {
+	return nil
+}
+
+// SaveEntries 追加日志条目，与已有条目重叠时覆盖重叠部分及其之后的条目
+func (m *MemoryStorage) SaveEntries(entries []raftpb.Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.entries) == 0 {
+		// 存储为空，直接使用新条目
+		m.entries = append([]raftpb.Entry{}, entries...)
+		return nil
+	}
+
+	// 计算在存储中的偏移
+	offset := int(entries[0].Index) - int(m.entries[0].Index)
+	switch {
+	case offset < 0:
+		// 新条目比存储的更早
+		m.entries = append([]raftpb.Entry{}, entries...)
+	case offset < len(m.entries):
+		// 有重叠，保留前面的条目，覆盖重叠部分，添加新条目
+		m.entries = append(m.entries[:offset:offset], entries...)
+	case offset == len(m.entries):
+		// 直接接续，没有间隙
+		m.entries = append(m.entries, entries...)
+	default:
+		// 有间隙，不应该发生，日志会丢失
+		return fmt.Errorf("raft log has gap: 期望索引%d，得到%d", m.entries[0].Index+uint64(len(m.entries)), entries[0].Index)
+	}
+	return nil
+}
+
+// SaveState 保存硬状态
+func (m *MemoryStorage) SaveState(state raftpb.HardState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hardState = state
+	return nil
+}
+
+// SaveSnapshot 应用从领导者收到的快照并丢弃全部日志条目。Raft只在本地日志与快照不一致时发来快照，
+// 快照之后的旧条目可能与领导者冲突，之后的条目由领导者重新发送
+func (m *MemoryStorage) SaveSnapshot(snapshot raftpb.Snapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.snapshot = snapshot
+	m.confState = snapshot.Metadata.ConfState
+	m.entries = nil
+	return nil
+}
+
+// SetConfState 记录应用配置变更后的成员配置
+func (m *MemoryStorage) SetConfState(confState raftpb.ConfState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.confState = confState
+	return nil
+}
+
+// Close 内存存储不需要关闭
+func (m *MemoryStorage) Close() error {
+	return nil
+}
+
+// CreateSnapshot 以index处的日志任期和当前成员配置记录快照，不丢弃日志
+func (m *MemoryStorage) CreateSnapshot(index uint64, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if index == 0 {
+		return fmt.Errorf("快照索引不能为0")
+	}
+	if index < m.snapshot.Metadata.Index {
+		return fmt.Errorf("快照索引%d早于当前快照索引%d", index, m.snapshot.Metadata.Index)
+	}
+	if index == m.snapshot.Metadata.Index {
+		m.snapshot.Data = data
+		return nil
+	}
+	if len(m.entries) == 0 || index < m.entries[0].Index || index > m.entries[len(m.entries)-1].Index {
+		return fmt.Errorf("快照索引%d不在日志范围内", index)
+	}
+
+	m.snapshot = raftpb.Snapshot{
+		Data: data,
+		Metadata: raftpb.SnapshotMetadata{
+			Index:     index,
+			Term:      m.entries[index-m.entries[0].Index].Term,
+			ConfState: m.confState,
+		},
+	}
+	return nil
+}
+
+// Compact 丢弃快照已覆盖的日志条目，返回丢弃的条目数
+func (m *MemoryStorage) Compact() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for n < len(m.entries) && m.entries[n].Index <= m.snapshot.Metadata.Index {
+		n++
+	}
+	if n > 0 {
+		m.entries = append([]raftpb.Entry(nil), m.entries[n:]...)
+	}
+	return n
+}
+
 // Transport 定义网络传输接口
 type Transport interface {
 	// 发送消息到指定节点
@@ -0,0 +1,108 @@
+package raft
+
+import (
+	"sync"
+
+	"go.etcd.io/etcd/raft/v3/raftpb"
+)
+
+// nodePair 一对节点，较小的ID在前，分区对两个方向同时生效
+type nodePair struct {
+	a, b uint64
+}
+
+func newNodePair(a, b uint64) nodePair {
+	if a > b {
+		a, b = b, a
+	}
+	return nodePair{a: a, b: b}
+}
+
+// PartitionFilter 记录节点之间被阻断的连接，用于在进程内模拟网络分区，不需要iptables或root权限。
+// 同一个过滤器可以被多个节点的传输层共享，并发安全
+type PartitionFilter struct {
+	mu      sync.RWMutex
+	blocked map[nodePair]struct{}
+}
+
+// NewPartitionFilter 创建不阻断任何连接的分区过滤器
+func NewPartitionFilter() *PartitionFilter {
+	return &PartitionFilter{
+		blocked: make(map[nodePair]struct{}),
+	}
+}
+
+// Partition 阻断两个节点之间双向的消息
+func (f *PartitionFilter) Partition(a, b uint64) {
+	if a == b {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.blocked[newNodePair(a, b)] = struct{}{}
+}
+
+// PartitionGroups 将节点划分为互相隔离的分组，不同分组的节点之间的连接全部阻断，
+// 已有的分区保持不变
+func (f *PartitionFilter) PartitionGroups(groups ...[]uint64) {
+	for i, group := range groups {
+		for _, other := range groups[i+1:] {
+			for _, a := range group {
+				for _, b := range other {
+					f.Partition(a, b)
+				}
+			}
+		}
+	}
+}
+
+// Heal 恢复两个节点之间的连接
+func (f *PartitionFilter) Heal(a, b uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.blocked, newNodePair(a, b))
+}
+
+// HealAll 恢复所有连接
+func (f *PartitionFilter) HealAll() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.blocked = make(map[nodePair]struct{})
+}
+
+// Allowed 检查from发往to的消息是否可以送达
+func (f *PartitionFilter) Allowed(from, to uint64) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	_, blocked := f.blocked[newNodePair(from, to)]
+	return !blocked
+}
+
+// FilteredTransport 在Transport之上按分区过滤器丢弃消息，被阻断的消息不会到达底层传输层
+type FilteredTransport struct {
+	Transport
+	nodeID uint64
+	filter *PartitionFilter
+}
+
+// NewFilteredTransport 包装transport，nodeID为本节点ID
+func NewFilteredTransport(transport Transport, nodeID uint64, filter *PartitionFilter) *FilteredTransport {
+	return &FilteredTransport{
+		Transport: transport,
+		nodeID:    nodeID,
+		filter:    filter,
+	}
+}
+
+// Send 丢弃发往被阻断节点的消息，其余消息交给底层传输层
+func (t *FilteredTransport) Send(messages []raftpb.Message) {
+	allowed := messages[:0:0]
+	for _, msg := range messages {
+		if t.filter.Allowed(t.nodeID, msg.To) {
+			allowed = append(allowed, msg)
+		}
+	}
+	if len(allowed) > 0 {
+		t.Transport.Send(allowed)
+	}
+}
@@ -0,0 +1,135 @@
+package raft
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	etcdraft "go.etcd.io/etcd/raft/v3"
+)
+
+// readIndexRetryInterval 没有领导者或领导者变更时ReadIndex请求会被丢弃且没有任何通知，
+// 在该间隔内没有收到结果时重新发起
+const readIndexRetryInterval = 500 * time.Millisecond
+
+// readRegistry 记录本节点发出、仍在等待结果的ReadIndex请求，并在应用索引前进时通知等待者
+type readRegistry struct {
+	mu       sync.Mutex
+	nextID   uint64
+	prefix   uint64 // 节点ID，放在请求ID的高位，与提议ID的编码方式相同
+	waiters  map[string]chan uint64
+	applied  uint64        // 已交给应用通道的最大日志索引
+	appliedC chan struct{} // applied前进时关闭并替换
+}
+
+func newReadRegistry(nodeID uint64) *readRegistry {
+	return &readRegistry{
+		prefix:   nodeID << requestSeqBits,
+		waiters:  make(map[string]chan uint64),
+		appliedC: make(chan struct{}),
+	}
+}
+
+// register 分配请求ID并登记等待者，同一请求重新发起时可能收到多次结果，只保留第一个
+func (r *readRegistry) register() ([]byte, chan uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	id := make([]byte, 8)
+	binary.BigEndian.PutUint64(id, r.prefix|r.nextID)
+	ch := make(chan uint64, 1)
+	r.waiters[string(id)] = ch
+	return id, ch
+}
+
+// unregister 删除等待者
+func (r *readRegistry) unregister(id []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.waiters, string(id))
+}
+
+// resolve 将ReadIndex的结果交给等待者，请求不是本节点发出或已经有结果时忽略
+func (r *readRegistry) resolve(states []etcdraft.ReadState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, state := range states {
+		if ch, ok := r.waiters[string(state.RequestCtx)]; ok {
+			select {
+			case ch <- state.Index:
+			default:
+			}
+		}
+	}
+}
+
+// notifyApplied 记录index及之前的条目都已交给应用通道，并唤醒等待的协程
+func (r *readRegistry) notifyApplied(index uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if index <= r.applied {
+		return
+	}
+	r.applied = index
+	close(r.appliedC)
+	r.appliedC = make(chan struct{})
+}
+
+// appliedState 返回已交给应用通道的最大日志索引，以及该索引下一次前进时关闭的通道
+func (r *readRegistry) appliedState() (uint64, <-chan struct{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.applied, r.appliedC
+}
+
+// ReadIndex 通过Raft的ReadIndex机制确认领导者身份，返回此刻领导者的提交索引。
+// 本节点的状态机应用到该索引后读取本地状态即满足线性一致性，跟随者上也不会读到旧值。
+// 没有领导者时请求被丢弃，此时按readIndexRetryInterval重新发起，直到ctx结束
+func (rn *RaftNode) ReadIndex(ctx context.Context) (uint64, error) {
+	id, ch := rn.reads.register()
+	defer rn.reads.unregister(id)
+
+	ticker := time.NewTicker(readIndexRetryInterval)
+	defer ticker.Stop()
+	for {
+		if err := rn.node.ReadIndex(ctx, id); err != nil {
+			if errors.Is(err, etcdraft.ErrStopped) {
+				return 0, ErrStopped
+			}
+			return 0, err
+		}
+		select {
+		case index := <-ch:
+			return index, nil
+		case <-ticker.C:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-rn.done:
+			return 0, ErrStopped
+		}
+	}
+}
+
+// WaitApplied 等待index及之前的条目都交给应用通道，ctx结束时返回ctx的错误。
+// 状态机异步处理应用通道时，返回时状态机可能还没有处理完这些条目，应再等待状态机处理到DeliveredIndex
+func (rn *RaftNode) WaitApplied(ctx context.Context, index uint64) error {
+	for {
+		applied, advanced := rn.reads.appliedState()
+		if applied >= index {
+			return nil
+		}
+		if err := rn.waitFor(ctx, advanced); err != nil {
+			return err
+		}
+	}
+}
+
+// DeliveredIndex 返回最近一次交给应用通道的消息的日志索引。
+// 领导者当选时追加的空条目等不会交给应用通道，应用通道中的索引并不连续，
+// 状态机处理到该索引即处理完了此刻通道中已有的全部消息
+func (rn *RaftNode) DeliveredIndex() uint64 {
+	return atomic.LoadUint64(&rn.deliveredIndex)
+}
@@ -21,6 +21,43 @@ type RaftStorage interface {
 	Close() error
 }
 
+// Storage RaftNode使用的日志存储：etcd/raft通过raft.Storage读取日志，处理循环通过RaftStorage写入。
+// MemoryStorage和WALStorage实现了该接口
+type Storage interface {
+	raft.Storage
+	RaftStorage
+	// 记录应用配置变更后的成员配置
+	SetConfState(confState raftpb.ConfState) error
+	// 以index处的日志任期和当前成员配置记录快照，不丢弃日志
+	CreateSnapshot(index uint64, data []byte) error
+	// 丢弃快照已覆盖的日志条目，返回丢弃的条目数
+	Compact() int
+}
+
+// openStorage dir为空时返回内存存储，否则打开dir中的WAL存储
+func openStorage(dir string) (Storage, error) {
+	if dir == "" {
+		return NewMemoryStorage(), nil
+	}
+	return NewWALStorage(dir)
+}
+
+// hasState 检查存储中是否已有日志或状态，有则节点应从存储中恢复而不是重新引导
+func hasState(storage Storage) (bool, error) {
+	hardState, _, err := storage.InitialState()
+	if err != nil {
+		return false, err
+	}
+	if !raft.IsEmptyHardState(hardState) {
+		return true, nil
+	}
+	last, err := storage.LastIndex()
+	if err != nil {
+		return false, err
+	}
+	return last > 0, nil
+}
+
 // MemoryRaftStorage 实现基于etcd/raft的内存存储
 type MemoryRaftStorage struct {
 	storage *raft.MemoryStorage
@@ -0,0 +1,412 @@
+package raft
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	etcdraft "go.etcd.io/etcd/raft/v3"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+)
+
+const (
+	// walFileName 追加写入日志条目、硬状态和成员配置的文件
+	walFileName = "wal.log"
+	// snapshotFileName 最近一次快照
+	snapshotFileName = "snapshot"
+)
+
+// WAL记录类型
+const (
+	walRecordEntry     byte = 1
+	walRecordHardState byte = 2
+	walRecordConfState byte = 3
+)
+
+// walRecordHeaderLen 记录头长度：1字节类型、4字节数据长度、4字节数据的CRC32
+const walRecordHeaderLen = 1 + 4 + 4
+
+// maxWALRecordSize 单条记录的数据长度上限。WAL只保存日志条目、硬状态和成员配置，快照写入单独的文件；
+// 条目需要能放进一条Raft消息，节点之间传递的消息体不超过256MiB。重放时长度超过上限的记录头视为损坏，
+// 不按其中的长度分配内存
+const maxWALRecordSize = 256 << 20
+
+// WALStorage 持久化到磁盘的日志存储，实现与MemoryStorage相同的接口。
+// 日志条目、硬状态和成员配置以追加方式写入WAL文件，每次写入后同步到磁盘；快照写入单独的文件。
+// 压缩日志时用快照之后的条目重写WAL，因此WAL的大小受快照频率限制。
+// 内存中保留与MemoryStorage相同的索引，打开时先加载快照再重放WAL重建
+type WALStorage struct {
+	*MemoryStorage
+
+	dir  string
+	mu   sync.Mutex // 保护WAL文件，写入磁盘和更新内存索引在同一把锁下进行
+	file *os.File
+}
+
+// NewWALStorage 打开dir中的存储，目录不存在时创建。
+// WAL末尾不完整或校验失败的记录（写入时崩溃）被截断丢弃
+func NewWALStorage(dir string) (*WALStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建Raft存储目录失败: %w", err)
+	}
+
+	w := &WALStorage{MemoryStorage: NewMemoryStorage(), dir: dir}
+	if err := w.loadSnapshot(); err != nil {
+		return nil, err
+	}
+	if err := w.replay(); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(w.path(walFileName), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("打开WAL失败: %w", err)
+	}
+	w.file = file
+	return w, nil
+}
+
+// path 返回存储目录中的文件路径
+func (w *WALStorage) path(name string) string {
+	return filepath.Join(w.dir, name)
+}
+
+// loadSnapshot 加载快照文件，不存在时不做任何事
+func (w *WALStorage) loadSnapshot() error {
+	data, err := os.ReadFile(w.path(snapshotFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取快照失败: %w", err)
+	}
+
+	var snapshot raftpb.Snapshot
+	if err := snapshot.Unmarshal(data); err != nil {
+		return fmt.Errorf("解析快照失败: %w", err)
+	}
+	w.MemoryStorage.snapshot = snapshot
+	w.MemoryStorage.confState = snapshot.Metadata.ConfState
+	return nil
+}
+
+// replay 按顺序重放WAL中的记录，遇到不完整或校验失败的记录时截断文件
+func (w *WALStorage) replay() error {
+	file, err := os.Open(w.path(walFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("打开WAL失败: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var offset int64
+	for {
+		typ, data, err := readRecord(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			logging.Warn("WAL在偏移%d处损坏，截断之后的内容: %v", offset, err)
+			file.Close()
+			return os.Truncate(w.path(walFileName), offset)
+		}
+		if err := w.applyRecord(typ, data); err != nil {
+			return fmt.Errorf("重放WAL失败，偏移%d: %w", offset, err)
+		}
+		offset += int64(walRecordHeaderLen + len(data))
+	}
+}
+
+// applyRecord 将一条WAL记录应用到内存索引，快照已覆盖的条目被跳过
+func (w *WALStorage) applyRecord(typ byte, data []byte) error {
+	switch typ {
+	case walRecordEntry:
+		var entry raftpb.Entry
+		if err := entry.Unmarshal(data); err != nil {
+			return err
+		}
+		if entry.Index <= w.MemoryStorage.snapshot.Metadata.Index {
+			return nil
+		}
+		return w.MemoryStorage.SaveEntries([]raftpb.Entry{entry})
+	case walRecordHardState:
+		var hardState raftpb.HardState
+		if err := hardState.Unmarshal(data); err != nil {
+			return err
+		}
+		return w.MemoryStorage.SaveState(hardState)
+	case walRecordConfState:
+		var confState raftpb.ConfState
+		if err := confState.Unmarshal(data); err != nil {
+			return err
+		}
+		return w.MemoryStorage.SetConfState(confState)
+	default:
+		return fmt.Errorf("未知的WAL记录类型: %d", typ)
+	}
+}
+
+// Initialize 存储在NewWALStorage中已经打开
+func (w *WALStorage) Initialize() error {
+	return nil
+}
+
+// SaveEntries 将日志条目写入WAL并同步到磁盘后更新内存索引
+func (w *WALStorage) SaveEntries(entries []raftpb.Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var buf []byte
+	for i := range entries {
+		data, err := entries[i].Marshal()
+		if err != nil {
+			return err
+		}
+		if len(data) > maxWALRecordSize {
+			return fmt.Errorf("日志条目%d长度%d超过WAL记录上限%d", entries[i].Index, len(data), maxWALRecordSize)
+		}
+		buf = appendRecord(buf, walRecordEntry, data)
+	}
+	if err := w.write(buf); err != nil {
+		return err
+	}
+	return w.MemoryStorage.SaveEntries(entries)
+}
+
+// SaveState 将硬状态写入WAL并同步到磁盘后更新内存索引
+func (w *WALStorage) SaveState(state raftpb.HardState) error {
+	data, err := state.Marshal()
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.write(appendRecord(nil, walRecordHardState, data)); err != nil {
+		return err
+	}
+	return w.MemoryStorage.SaveState(state)
+}
+
+// SetConfState 将成员配置写入WAL并同步到磁盘后更新内存索引
+func (w *WALStorage) SetConfState(confState raftpb.ConfState) error {
+	data, err := confState.Marshal()
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.write(appendRecord(nil, walRecordConfState, data)); err != nil {
+		return err
+	}
+	return w.MemoryStorage.SetConfState(confState)
+}
+
+// SaveSnapshot 保存从领导者收到的快照，快照覆盖的条目从内存和WAL中丢弃
+func (w *WALStorage) SaveSnapshot(snapshot raftpb.Snapshot) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.writeSnapshot(snapshot); err != nil {
+		return err
+	}
+	if err := w.MemoryStorage.SaveSnapshot(snapshot); err != nil {
+		return err
+	}
+	return w.rewrite()
+}
+
+// CreateSnapshot 记录快照并写入快照文件，不丢弃日志
+func (w *WALStorage) CreateSnapshot(index uint64, data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.MemoryStorage.CreateSnapshot(index, data); err != nil {
+		return err
+	}
+	snapshot, err := w.MemoryStorage.Snapshot()
+	if err != nil {
+		return err
+	}
+	return w.writeSnapshot(snapshot)
+}
+
+// Compact 丢弃快照已覆盖的日志条目并重写WAL，返回丢弃的条目数；重写失败时WAL保持原样，下次压缩时重试
+func (w *WALStorage) Compact() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	n := w.MemoryStorage.Compact()
+	if n > 0 {
+		if err := w.rewrite(); err != nil {
+			logging.Error("重写WAL失败: %v", err)
+		}
+	}
+	return n
+}
+
+// Close 关闭WAL文件
+func (w *WALStorage) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// write 追加记录并同步到磁盘，调用方需持有w.mu
+func (w *WALStorage) write(buf []byte) error {
+	if w.file == nil {
+		return errors.New("WAL已关闭")
+	}
+	if _, err := w.file.Write(buf); err != nil {
+		return fmt.Errorf("写入WAL失败: %w", err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("同步WAL失败: %w", err)
+	}
+	return nil
+}
+
+// rewrite 用内存中的硬状态、成员配置和日志条目替换WAL，调用方需持有w.mu
+func (w *WALStorage) rewrite() error {
+	if w.file == nil {
+		return errors.New("WAL已关闭")
+	}
+
+	m := w.MemoryStorage
+	m.mu.RLock()
+	var buf []byte
+	var err error
+	if !etcdraft.IsEmptyHardState(m.hardState) {
+		buf, err = appendMarshaled(buf, walRecordHardState, &m.hardState)
+	}
+	if err == nil {
+		buf, err = appendMarshaled(buf, walRecordConfState, &m.confState)
+	}
+	for i := 0; err == nil && i < len(m.entries); i++ {
+		buf, err = appendMarshaled(buf, walRecordEntry, &m.entries[i])
+	}
+	m.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileSync(w.path(walFileName), buf); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(w.path(walFileName), os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("重新打开WAL失败: %w", err)
+	}
+	w.file.Close()
+	w.file = file
+	return nil
+}
+
+// writeSnapshot 原子地替换快照文件
+func (w *WALStorage) writeSnapshot(snapshot raftpb.Snapshot) error {
+	data, err := snapshot.Marshal()
+	if err != nil {
+		return err
+	}
+	return writeFileSync(w.path(snapshotFileName), data)
+}
+
+// marshaler WAL记录中保存的protobuf消息
+type marshaler interface {
+	Marshal() ([]byte, error)
+}
+
+// appendMarshaled 序列化msg并作为一条记录追加到buf
+func appendMarshaled(buf []byte, typ byte, msg marshaler) ([]byte, error) {
+	data, err := msg.Marshal()
+	if err != nil {
+		return buf, err
+	}
+	return appendRecord(buf, typ, data), nil
+}
+
+// appendRecord 将一条记录追加到buf
+func appendRecord(buf []byte, typ byte, data []byte) []byte {
+	var header [walRecordHeaderLen]byte
+	header[0] = typ
+	binary.BigEndian.PutUint32(header[1:5], uint32(len(data)))
+	binary.BigEndian.PutUint32(header[5:9], crc32.ChecksumIEEE(data))
+	buf = append(buf, header[:]...)
+	return append(buf, data...)
+}
+
+// readRecord 读取一条记录，文件正好结束时返回io.EOF
+func readRecord(r io.Reader) (byte, []byte, error) {
+	var header [walRecordHeaderLen]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return 0, nil, fmt.Errorf("记录头不完整: %w", err)
+		}
+		return 0, nil, err
+	}
+	size := binary.BigEndian.Uint32(header[1:5])
+	if size > maxWALRecordSize {
+		return 0, nil, fmt.Errorf("记录不完整: 长度%d超过上限: %w", size, io.ErrUnexpectedEOF)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, nil, fmt.Errorf("记录不完整: %w", io.ErrUnexpectedEOF)
+	}
+	if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(header[5:9]) {
+		return 0, nil, errors.New("记录校验失败")
+	}
+	return header[0], data, nil
+}
+
+// writeFileSync 先写入临时文件并同步，再重命名替换目标文件，最后同步目录，保证崩溃后文件要么是旧内容要么是新内容
+func writeFileSync(path string, data []byte) error {
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
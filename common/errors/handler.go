@@ -189,6 +189,16 @@ func IsResourceExhausted(err error) bool {
 	return IsErrorCode(err, ResourceExhausted)
 }
 
+// 检查是否为超时错误
+func IsTimeout(err error) bool {
+	return IsErrorCode(err, Timeout)
+}
+
+// 检查是否为服务不可用错误
+func IsUnavailable(err error) bool {
+	return IsErrorCode(err, Unavailable)
+}
+
 // 检查是否为内部错误
 func IsInternal(err error) bool {
 	return IsErrorCode(err, Internal)
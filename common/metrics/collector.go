@@ -7,7 +7,7 @@ import (
 
 // Collector 定义指标收集器接口
 type Collector interface {
-    // RecordHTTPRequest 记录HTTP请求指标
+    // RecordHTTPRequest 记录HTTP请求指标，path应为路由模板而非原始路径，避免标签数量无限增长
     RecordHTTPRequest(method, path string, statusCode int, durationMs int64)
     
     // RecordSystemMetrics 记录系统指标
@@ -19,6 +19,9 @@ type Collector interface {
     // GetSystemMetrics 获取系统指标
     GetSystemMetrics() []SystemMetric
     
+    // GetRouteLatencies 获取每个路由的延迟统计，键为"方法 路由模板"
+    GetRouteLatencies() map[string]LatencyStats
+    
     // Reset 重置所有指标
     Reset()
 }
@@ -29,6 +32,7 @@ type SimpleCollector struct {
     httpMetrics   []HTTPMetric
     systemMetrics []SystemMetric
     maxItems      int
+    latencies     map[string]*latencyHistogram // 按"方法 路由模板"分组的延迟直方图
     mu            sync.RWMutex
 }
 
@@ -39,6 +43,7 @@ func NewCollector(name string) Collector {
         httpMetrics:   make([]HTTPMetric, 0, 1000),
         systemMetrics: make([]SystemMetric, 0, 100),
         maxItems:      1000, // 限制存储项数，避免内存无限增长
+        latencies:     make(map[string]*latencyHistogram),
     }
 }
 
@@ -59,6 +64,15 @@ func (c *SimpleCollector) RecordHTTPRequest(method, path string, statusCode int,
         Duration:   durationMs,
         Timestamp:  time.Now(),
     })
+    
+    // 直方图不受maxItems限制，统计服务启动以来的全部请求
+    key := method + " " + path
+    histogram, ok := c.latencies[key]
+    if !ok {
+        histogram = newLatencyHistogram(DefaultLatencyBucketsMs)
+        c.latencies[key] = histogram
+    }
+    histogram.observe(durationMs)
 }
 
 // RecordSystemMetrics 实现Collector接口
@@ -101,6 +115,18 @@ func (c *SimpleCollector) GetSystemMetrics() []SystemMetric {
     return result
 }
 
+// GetRouteLatencies 实现Collector接口
+func (c *SimpleCollector) GetRouteLatencies() map[string]LatencyStats {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    
+    result := make(map[string]LatencyStats, len(c.latencies))
+    for key, histogram := range c.latencies {
+        result[key] = histogram.stats()
+    }
+    return result
+}
+
 // Reset 实现Collector接口
 func (c *SimpleCollector) Reset() {
     c.mu.Lock()
@@ -108,4 +134,5 @@ func (c *SimpleCollector) Reset() {
     
     c.httpMetrics = make([]HTTPMetric, 0, 1000)
     c.systemMetrics = make([]SystemMetric, 0, 100)
+    c.latencies = make(map[string]*latencyHistogram)
 }
\ No newline at end of file
@@ -0,0 +1,86 @@
+package metrics
+
+import "math"
+
+// DefaultLatencyBucketsMs 延迟直方图的默认桶上界(毫秒)，超过最后一个上界的请求计入溢出桶
+var DefaultLatencyBucketsMs = []int64{1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// LatencyStats 某个路由的请求延迟统计，分位数取所在桶的上界，不超过观测到的最大值
+type LatencyStats struct {
+	Count uint64  `json:"count"`
+	AvgMs float64 `json:"avg_ms"`
+	P50Ms int64   `json:"p50_ms"`
+	P95Ms int64   `json:"p95_ms"`
+	P99Ms int64   `json:"p99_ms"`
+	MaxMs int64   `json:"max_ms"`
+}
+
+// latencyHistogram 固定桶的延迟直方图，内存占用与请求数无关，不是并发安全的
+type latencyHistogram struct {
+	bounds []int64
+	counts []uint64 // 比bounds多一个溢出桶
+	count  uint64
+	sum    int64
+	max    int64
+}
+
+func newLatencyHistogram(bounds []int64) *latencyHistogram {
+	return &latencyHistogram{
+		bounds: bounds,
+		counts: make([]uint64, len(bounds)+1),
+	}
+}
+
+// observe 记录一次请求的耗时
+func (h *latencyHistogram) observe(durationMs int64) {
+	if durationMs < 0 {
+		durationMs = 0
+	}
+	i := 0
+	for i < len(h.bounds) && durationMs > h.bounds[i] {
+		i++
+	}
+	h.counts[i]++
+	h.count++
+	h.sum += durationMs
+	if durationMs > h.max {
+		h.max = durationMs
+	}
+}
+
+// quantile 返回第q分位数所在桶的上界，落在溢出桶时返回最大值
+func (h *latencyHistogram) quantile(q float64) int64 {
+	if h.count == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(h.count)))
+	if rank == 0 {
+		rank = 1
+	}
+	var cumulative uint64
+	for i, c := range h.counts {
+		cumulative += c
+		if cumulative < rank {
+			continue
+		}
+		if i < len(h.bounds) && h.bounds[i] < h.max {
+			return h.bounds[i]
+		}
+		return h.max
+	}
+	return h.max
+}
+
+func (h *latencyHistogram) stats() LatencyStats {
+	stats := LatencyStats{
+		Count: h.count,
+		P50Ms: h.quantile(0.50),
+		P95Ms: h.quantile(0.95),
+		P99Ms: h.quantile(0.99),
+		MaxMs: h.max,
+	}
+	if h.count > 0 {
+		stats.AvgMs = float64(h.sum) / float64(h.count)
+	}
+	return stats
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"os"
+	"runtime"
+	"time"
+)
+
+// RuntimeStats 进程自身的Go运行时与资源使用情况，用于容量规划
+type RuntimeStats struct {
+	Goroutines     int       `json:"goroutines"`        // 当前goroutine数量
+	HeapAllocBytes uint64    `json:"heap_alloc_bytes"`  // 堆上已分配且仍在使用的字节数
+	HeapSysBytes   uint64    `json:"heap_sys_bytes"`    // 从操作系统获取的堆内存字节数
+	HeapObjects    uint64    `json:"heap_objects"`      // 堆上存活的对象数
+	SysBytes       uint64    `json:"sys_bytes"`         // 从操作系统获取的内存总字节数
+	NumGC          uint32    `json:"num_gc"`            // 已完成的GC次数
+	LastGCPauseMs  float64   `json:"last_gc_pause_ms"`  // 最近一次GC的停顿时间
+	TotalGCPauseMs float64   `json:"total_gc_pause_ms"` // 累计GC停顿时间
+	LastGC         time.Time `json:"last_gc"`           // 最近一次GC的完成时间，未发生GC时为零值
+	OpenFDs        int       `json:"open_fds"`          // 打开的文件描述符数量，-1表示当前平台无法统计
+	Timestamp      time.Time `json:"timestamp"`         // 采集时间
+}
+
+// ReadRuntimeStats 采集当前进程的运行时统计
+// 注意runtime.ReadMemStats会短暂暂停所有goroutine，不应在热路径上频繁调用
+func ReadRuntimeStats() RuntimeStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	stats := RuntimeStats{
+		Goroutines:     runtime.NumGoroutine(),
+		HeapAllocBytes: m.HeapAlloc,
+		HeapSysBytes:   m.HeapSys,
+		HeapObjects:    m.HeapObjects,
+		SysBytes:       m.Sys,
+		NumGC:          m.NumGC,
+		TotalGCPauseMs: float64(m.PauseTotalNs) / float64(time.Millisecond),
+		OpenFDs:        countOpenFDs(),
+		Timestamp:      time.Now(),
+	}
+	if m.NumGC > 0 {
+		// PauseNs是环形缓冲区，最近一次GC位于(NumGC+255)%256
+		stats.LastGCPauseMs = float64(m.PauseNs[(m.NumGC+255)%256]) / float64(time.Millisecond)
+		stats.LastGC = time.Unix(0, int64(m.LastGC))
+	}
+	return stats
+}
+
+// countOpenFDs 通过/proc/self/fd统计打开的文件描述符，不扣除统计时打开的目录本身；
+// 没有procfs的平台返回-1
+func countOpenFDs() int {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return -1
+	}
+	return len(entries)
+}
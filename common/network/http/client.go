@@ -15,8 +15,16 @@ type Client struct {
     baseURL    string
     httpClient *http.Client
     retryPolicy *RetryPolicy
+    cache       *responseCache
+    userAgent   string
+    defaultHeaders map[string]string
+    compression bool // 压缩请求体并请求gzip编码的响应
+    compressionThreshold int // 小于该字节数的请求体不压缩
 }
 
+// DefaultUserAgent 客户端默认的User-Agent，便于在日志中识别DFS流量
+const DefaultUserAgent = "DFS-Client/1.0"
+
 // ClientOption 定义客户端选项函数
 type ClientOption func(*Client)
 
@@ -25,7 +33,88 @@ type RetryPolicy struct {
     MaxRetries    int
     RetryInterval time.Duration
     MaxBackoff    time.Duration
-    ShouldRetry   func(*http.Response, error) bool
+    ShouldRetry   func(*http.Response, error) bool // 为nil时使用DefaultShouldRetry
+}
+
+// DefaultShouldRetry 默认重试判断：连接错误或5xx响应时重试
+func DefaultShouldRetry(resp *http.Response, err error) bool {
+    return err != nil || (resp != nil && resp.StatusCode >= 500)
+}
+
+// DefaultRetryPolicy 返回默认重试策略
+func DefaultRetryPolicy() *RetryPolicy {
+    return &RetryPolicy{
+        MaxRetries:    3,
+        RetryInterval: 500 * time.Millisecond,
+        MaxBackoff:    5 * time.Second,
+        ShouldRetry:   DefaultShouldRetry,
+    }
+}
+
+// shouldRetry 判断是否需要重试，未设置ShouldRetry时使用默认判断
+func (p *RetryPolicy) shouldRetry(resp *http.Response, err error) bool {
+    if p.ShouldRetry == nil {
+        return DefaultShouldRetry(resp, err)
+    }
+    return p.ShouldRetry(resp, err)
+}
+
+// maxRetriesBodyLimit MaxRetriesError保留的响应体最大长度
+const maxRetriesBodyLimit = 512
+
+// MaxRetriesError 重试次数用尽时返回的错误，保留最后一次尝试的结果便于排查
+type MaxRetriesError struct {
+    Attempts   int    // 总尝试次数，包括首次请求
+    StatusCode int    // 最后一次响应的状态码，连接错误时为0
+    Body       string // 最后一次响应的响应体，超过maxRetriesBodyLimit时截断
+    Err        error  // 最后一次请求的错误，收到响应时为nil
+}
+
+func (e *MaxRetriesError) Error() string {
+    if e.Err != nil {
+        return fmt.Sprintf("最大重试次数已达到(max retries exceeded), 共尝试%d次: %v", e.Attempts, e.Err)
+    }
+    return fmt.Sprintf("最大重试次数已达到(max retries exceeded), 共尝试%d次: HTTP %d: %s",
+        e.Attempts, e.StatusCode, e.Body)
+}
+
+// Unwrap 返回最后一次请求的错误
+func (e *MaxRetriesError) Unwrap() error {
+    return e.Err
+}
+
+// newMaxRetriesError 根据最后一次尝试的结果构造错误，负责关闭响应体
+func newMaxRetriesError(attempts int, resp *http.Response, err error) *MaxRetriesError {
+    retryErr := &MaxRetriesError{Attempts: attempts, Err: err}
+    if resp != nil {
+        retryErr.StatusCode = resp.StatusCode
+        if resp.Body != nil {
+            bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, maxRetriesBodyLimit+1))
+            resp.Body.Close()
+            if len(bodyBytes) > maxRetriesBodyLimit {
+                bodyBytes = append(bodyBytes[:maxRetriesBodyLimit], "..."...)
+            }
+            retryErr.Body = string(bodyBytes)
+        }
+    }
+    return retryErr
+}
+
+// CallOption 单次请求的选项，覆盖客户端的默认设置
+type CallOption func(*callOptions)
+
+type callOptions struct {
+    retryPolicy *RetryPolicy
+}
+
+// WithRequestRetry 为单次请求使用指定的重试策略，如对非幂等写入设置MaxRetries为0禁用重试
+func WithRequestRetry(policy RetryPolicy) CallOption {
+    if policy.MaxRetries < 0 {
+        policy.MaxRetries = 0
+    }
+    return func(o *callOptions) {
+        o.retryPolicy = &policy
+    }
 }
 
 // NewClient 创建新的HTTP客户端
@@ -35,14 +124,8 @@ func NewClient(baseURL string, options ...ClientOption) *Client {
             Timeout: 30 * time.Second,
         },
         baseURL: baseURL,
-        retryPolicy: &RetryPolicy{
-            MaxRetries:    3,
-            RetryInterval: 500 * time.Millisecond,
-            MaxBackoff:    5 * time.Second,
-            ShouldRetry: func(resp *http.Response, err error) bool {
-                return err != nil || (resp != nil && resp.StatusCode >= 500)
-            },
-        },
+        userAgent: DefaultUserAgent,
+        retryPolicy: DefaultRetryPolicy(),
     }
     
     for _, option := range options {
@@ -53,14 +136,27 @@ func NewClient(baseURL string, options ...ClientOption) *Client {
 }
 
 // 基础请求方法
-func (c *Client) request(ctx context.Context, method, path string, body interface{}, headers map[string]string) (*http.Response, error) {
+func (c *Client) request(ctx context.Context, method, path string, body interface{}, headers map[string]string, opts ...CallOption) (*http.Response, error) {
+    call := callOptions{retryPolicy: c.retryPolicy}
+    for _, opt := range opts {
+        opt(&call)
+    }
+    
     var bodyReader io.Reader
+    compressed := false
     
     if body != nil {
         jsonData, err := json.Marshal(body)
         if err != nil {
             return nil, fmt.Errorf("序列化请求体失败: %w", err)
         }
+        if c.compressRequest(jsonData) {
+            // 压缩结果完整缓冲在内存中，重试时可以重新发送
+            if jsonData, err = gzipBytes(jsonData); err != nil {
+                return nil, fmt.Errorf("压缩请求体失败: %w", err)
+            }
+            compressed = true
+        }
         bodyReader = bytes.NewReader(jsonData)
     }
     
@@ -72,24 +168,59 @@ func (c *Client) request(ctx context.Context, method, path string, body interfac
     if body != nil {
         req.Header.Set("Content-Type", "application/json")
     }
+    if compressed {
+        req.Header.Set("Content-Encoding", "gzip")
+    }
+    if c.compression {
+        req.Header.Set("Accept-Encoding", "gzip")
+    }
+    
+    // 默认头先设置，单次请求的头可以覆盖
+    if c.userAgent != "" {
+        req.Header.Set("User-Agent", c.userAgent)
+    }
+    for k, v := range c.defaultHeaders {
+        req.Header.Set(k, v)
+    }
     
     for k, v := range headers {
         req.Header.Set(k, v)
     }
     
-    return c.doWithRetry(req)
+    if c.cache == nil || method != http.MethodGet {
+        return c.doWithRetry(req, call.retryPolicy)
+    }
+    
+    // 带缓存验证器发起条件请求，304时返回缓存的响应
+    key := req.URL.String()
+    cached := c.cache.get(key)
+    if cached != nil {
+        cached.applyValidators(req)
+    }
+    
+    resp, err := c.doWithRetry(req, call.retryPolicy)
+    if err != nil {
+        return nil, err
+    }
+    
+    if resp.StatusCode == http.StatusNotModified && cached != nil {
+        resp.Body.Close()
+        return cached.response(req), nil
+    }
+    
+    return c.cache.store(key, resp)
 }
 
 // 带重试的请求执行
-func (c *Client) doWithRetry(req *http.Request) (*http.Response, error) {
+func (c *Client) doWithRetry(req *http.Request, policy *RetryPolicy) (*http.Response, error) {
     var resp *http.Response
     var err error
     
-    for retryCount := 0; retryCount <= c.retryPolicy.MaxRetries; retryCount++ {
+    for retryCount := 0; retryCount <= policy.MaxRetries; retryCount++ {
         if retryCount > 0 {
-            backoffTime := c.retryPolicy.RetryInterval * time.Duration(1<<uint(retryCount-1))
-            if backoffTime > c.retryPolicy.MaxBackoff {
-                backoffTime = c.retryPolicy.MaxBackoff
+            backoffTime := policy.RetryInterval * time.Duration(1<<uint(retryCount-1))
+            if policy.MaxBackoff > 0 && backoffTime > policy.MaxBackoff {
+                backoffTime = policy.MaxBackoff
             }
             time.Sleep(backoffTime)
             
@@ -106,20 +237,24 @@ func (c *Client) doWithRetry(req *http.Request) (*http.Response, error) {
             }
         }
         
+        // 每次尝试都根据剩余时间重新计算，让服务端知道客户端还愿意等待多久
+        c.setRequestTimeoutHeader(req)
+
         resp, err = c.httpClient.Do(req)
+        if err == nil && c.compression {
+            // 显式请求了gzip时传输层不会自动解压
+            err = decompressResponse(resp)
+            if err != nil {
+                resp = nil
+            }
+        }
         
-        if !c.retryPolicy.ShouldRetry(resp, err) {
+        if !policy.shouldRetry(resp, err) {
             return resp, err
         }
         
-        if retryCount == c.retryPolicy.MaxRetries {
-            if resp != nil {
-                bodyBytes, _ := io.ReadAll(resp.Body)
-                resp.Body.Close()
-                return nil, fmt.Errorf("最大重试次数已达到: HTTP %d: %s", 
-                    resp.StatusCode, string(bodyBytes))
-            }
-            return nil, fmt.Errorf("最大重试次数已达到: %w", err)
+        if retryCount == policy.MaxRetries {
+            return nil, newMaxRetriesError(retryCount+1, resp, err)
         }
         
         if resp != nil && resp.Body != nil {
@@ -130,12 +265,40 @@ func (c *Client) doWithRetry(req *http.Request) (*http.Response, error) {
     return resp, err
 }
 
+// setRequestTimeoutHeader 根据上下文截止时间和客户端超时设置请求超时头
+// 两者都未设置时不发送该头
+func (c *Client) setRequestTimeoutHeader(req *http.Request) {
+    var budget time.Duration
+    if deadline, ok := req.Context().Deadline(); ok {
+        budget = time.Until(deadline)
+        if budget <= 0 {
+            // 已经超时，交由httpClient.Do返回上下文错误
+            req.Header.Del(RequestTimeoutHeader)
+            return
+        }
+    }
+    if c.httpClient.Timeout > 0 && (budget == 0 || c.httpClient.Timeout < budget) {
+        budget = c.httpClient.Timeout
+    }
+
+    if budget <= 0 {
+        req.Header.Del(RequestTimeoutHeader)
+        return
+    }
+    req.Header.Set(RequestTimeoutHeader, FormatRequestTimeout(budget))
+}
+
 // DoJSON 执行HTTP请求并处理JSON响应
-func (c *Client) DoJSON(ctx context.Context, method, path string, reqBody, respBody interface{}, headers map[string]string) error {
-    resp, err := c.request(ctx, method, path, reqBody, headers)
+func (c *Client) DoJSON(ctx context.Context, method, path string, reqBody, respBody interface{}, headers map[string]string, opts ...CallOption) error {
+    resp, err := c.request(ctx, method, path, reqBody, headers, opts...)
     if err != nil {
         return err
     }
+    return decodeJSONResponse(resp, respBody)
+}
+
+// decodeJSONResponse 检查响应状态并将JSON响应体解析到respBody，负责关闭响应体
+func decodeJSONResponse(resp *http.Response, respBody interface{}) error {
     defer resp.Body.Close()
     
     // 检查响应状态
@@ -160,23 +323,23 @@ func (c *Client) DoJSON(ctx context.Context, method, path string, reqBody, respB
 }
 
 // GetJSON 发送GET请求并解析JSON响应
-func (c *Client) GetJSON(ctx context.Context, path string, result interface{}) error {
-    return c.DoJSON(ctx, http.MethodGet, path, nil, result, nil)
+func (c *Client) GetJSON(ctx context.Context, path string, result interface{}, opts ...CallOption) error {
+    return c.DoJSON(ctx, http.MethodGet, path, nil, result, nil, opts...)
 }
 
 // PostJSON 发送POST请求并解析JSON响应
-func (c *Client) PostJSON(ctx context.Context, path string, body, result interface{}) error {
-    return c.DoJSON(ctx, http.MethodPost, path, body, result, nil)
+func (c *Client) PostJSON(ctx context.Context, path string, body, result interface{}, opts ...CallOption) error {
+    return c.DoJSON(ctx, http.MethodPost, path, body, result, nil, opts...)
 }
 
 // PutJSON 发送PUT请求并解析JSON响应
-func (c *Client) PutJSON(ctx context.Context, path string, body, result interface{}) error {
-    return c.DoJSON(ctx, http.MethodPut, path, body, result, nil)
+func (c *Client) PutJSON(ctx context.Context, path string, body, result interface{}, opts ...CallOption) error {
+    return c.DoJSON(ctx, http.MethodPut, path, body, result, nil, opts...)
 }
 
 // DeleteJSON 发送DELETE请求并解析JSON响应
-func (c *Client) DeleteJSON(ctx context.Context, path string, result interface{}) error {
-    return c.DoJSON(ctx, http.MethodDelete, path, nil, result, nil)
+func (c *Client) DeleteJSON(ctx context.Context, path string, result interface{}, opts ...CallOption) error {
+    return c.DoJSON(ctx, http.MethodDelete, path, nil, result, nil, opts...)
 }
 
 // WithTimeout 设置客户端超时时间
@@ -194,9 +357,79 @@ func WithRetryPolicy(maxRetries int, retryInterval time.Duration) ClientOption {
     }
 }
 
+// WithCustomRetryPolicy 使用完整的重试策略，ShouldRetry为nil时按默认规则判断，MaxBackoff不大于0时不限制退避时间
+func WithCustomRetryPolicy(policy RetryPolicy) ClientOption {
+    if policy.MaxRetries < 0 {
+        policy.MaxRetries = 0
+    }
+    return func(c *Client) {
+        c.retryPolicy = &policy
+    }
+}
+
+// WithResponseCache 启用GET响应缓存
+// 缓存带有ETag或Last-Modified的响应，后续请求携带条件头，服务端返回304时使用缓存的响应体
+// maxEntries限制缓存条目数，ttl为条目的最长保留时间
+func WithResponseCache(maxEntries int, ttl time.Duration) ClientOption {
+    return func(c *Client) {
+        c.cache = newResponseCache(maxEntries, ttl)
+    }
+}
+
+// WithUserAgent 设置所有请求使用的User-Agent
+func WithUserAgent(userAgent string) ClientOption {
+    return func(c *Client) {
+        c.userAgent = userAgent
+    }
+}
+
+// WithDefaultHeaders 设置所有请求默认携带的请求头，例如节点ID
+// 单次请求传入的同名请求头会覆盖默认值
+func WithDefaultHeaders(headers map[string]string) ClientOption {
+    return func(c *Client) {
+        if c.defaultHeaders == nil {
+            c.defaultHeaders = make(map[string]string, len(headers))
+        }
+        for k, v := range headers {
+            c.defaultHeaders[k] = v
+        }
+    }
+}
+
+// WithCompression 启用gzip压缩：不小于阈值（默认DefaultCompressionThreshold）的请求体压缩后发送，
+// 并请求服务端压缩响应，GetJSON、PostJSON等方法读取响应时自动解压
+func WithCompression() ClientOption {
+    return func(c *Client) {
+        c.compression = true
+    }
+}
+
+// WithCompressionThreshold 设置启用压缩时请求体的最小压缩字节数，不大于0时使用默认值
+func WithCompressionThreshold(n int) ClientOption {
+    return func(c *Client) {
+        c.compressionThreshold = n
+    }
+}
+
+// compressRequest 判断请求体是否需要压缩
+func (c *Client) compressRequest(data []byte) bool {
+    if !c.compression {
+        return false
+    }
+    threshold := c.compressionThreshold
+    if threshold <= 0 {
+        threshold = DefaultCompressionThreshold
+    }
+    return len(data) >= threshold
+}
+
 // WithHTTPClient 设置自定义HTTP客户端
 func WithHTTPClient(httpClient *http.Client) ClientOption {
     return func(c *Client) {
         c.httpClient = httpClient
     }
-}
\ No newline at end of file
+}
+// CloseIdleConnections 关闭底层传输中的空闲连接，不再使用客户端时调用，正在进行的请求不受影响
+func (c *Client) CloseIdleConnections() {
+    c.httpClient.CloseIdleConnections()
+}
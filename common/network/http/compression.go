@@ -0,0 +1,240 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultCompressionThreshold 默认的最小压缩字节数，更小的请求体和响应体压缩收益不抵开销
+const DefaultCompressionThreshold = 1024
+
+// MaxDecompressedBodySize gzip请求体解压后的最大字节数。压缩比很高的请求体解压后可能远大于传输的字节数，
+// 超过上限时读取返回*http.MaxBytesError，处理器据此返回413
+const MaxDecompressedBodySize int64 = 32 << 20
+
+// compressedContentTypes 本身已经压缩的内容类型，再次压缩只会浪费CPU
+var compressedContentTypes = []string{
+	"image/",
+	"video/",
+	"audio/",
+	"application/gzip",
+	"application/x-gzip",
+	"application/zip",
+	"application/zstd",
+	"application/x-7z-compressed",
+	"application/x-bzip2",
+	"application/x-xz",
+	"application/octet-stream",
+}
+
+// isCompressedContentType 判断内容类型是否已经压缩
+func isCompressedContentType(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, prefix := range compressedContentTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsGzip 判断请求方是否接受gzip编码的响应
+func acceptsGzip(r *http.Request) bool {
+	for _, value := range r.Header.Values("Accept-Encoding") {
+		for _, coding := range strings.Split(value, ",") {
+			coding = strings.TrimSpace(coding)
+			if name, params, _ := strings.Cut(coding, ";"); strings.EqualFold(strings.TrimSpace(name), "gzip") {
+				return strings.ReplaceAll(params, " ", "") != "q=0"
+			}
+		}
+	}
+	return false
+}
+
+// CompressionMiddleware 解压gzip编码的请求体（解压后最多读取MaxDecompressedBodySize字节），
+// 并在客户端发送Accept-Encoding: gzip时压缩响应。
+// 响应体小于minSize（不大于0时使用DefaultCompressionThreshold）、处理器已设置Content-Encoding
+// 或内容类型本身已经压缩时原样返回
+func CompressionMiddleware(minSize int) Middleware {
+	if minSize <= 0 {
+		minSize = DefaultCompressionThreshold
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") && r.Body != nil && r.Body != http.NoBody {
+				gz, err := gzip.NewReader(r.Body)
+				if err != nil {
+					RespondError(w, http.StatusBadRequest, "无效的gzip请求体")
+					return
+				}
+				defer gz.Close()
+				r.Body = http.MaxBytesReader(w, gz, MaxDecompressedBodySize)
+				r.Header.Del("Content-Encoding")
+				r.Header.Del("Content-Length")
+				r.ContentLength = -1
+			}
+
+			if !acceptsGzip(r) || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Accept-Encoding")
+			cw := &compressWriter{ResponseWriter: w, minSize: minSize, status: http.StatusOK}
+			defer cw.Close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// compressWriter 缓冲响应体直到确定是否压缩：达到阈值后切换为gzip输出，处理结束时仍未达到则原样写出
+type compressWriter struct {
+	http.ResponseWriter
+	minSize     int
+	status      int
+	wroteHeader bool // 处理器是否调用过WriteHeader
+	decided     bool // 是否已经写出响应头并确定了编码
+	buf         bytes.Buffer
+	gz          *gzip.Writer
+}
+
+// WriteHeader 记录状态码，推迟到确定编码后再写出
+func (w *compressWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader || w.decided {
+		return
+	}
+	w.wroteHeader = true
+	w.status = statusCode
+}
+
+// Write 缓冲响应体，累计达到阈值时开始压缩
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.buf.Write(b)
+		if w.buf.Len() < w.minSize {
+			return len(b), nil
+		}
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.gz != nil {
+		return w.gz.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// decide 根据已缓冲的数据确定是否压缩，写出响应头和已缓冲的数据
+func (w *compressWriter) decide() error {
+	w.decided = true
+	header := w.ResponseWriter.Header()
+	if header.Get("Content-Type") == "" && w.buf.Len() > 0 {
+		header.Set("Content-Type", http.DetectContentType(w.buf.Bytes()))
+	}
+
+	compress := w.buf.Len() >= w.minSize &&
+		header.Get("Content-Encoding") == "" &&
+		!isCompressedContentType(header.Get("Content-Type")) &&
+		w.status != http.StatusNoContent && w.status != http.StatusNotModified
+	if compress {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if w.gz != nil {
+		_, err = w.gz.Write(w.buf.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
+
+// Flush 按已缓冲的数据确定编码后刷新，流式响应不必等到达到阈值
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide()
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Close 写出剩余的缓冲数据并结束gzip流
+func (w *compressWriter) Close() error {
+	if !w.decided {
+		if !w.wroteHeader && w.buf.Len() == 0 {
+			// 处理器没有写出任何内容，交由net/http写出默认响应
+			return nil
+		}
+		if err := w.decide(); err != nil {
+			return err
+		}
+	}
+	if w.gz != nil {
+		return w.gz.Close()
+	}
+	return nil
+}
+
+// Unwrap 返回被包装的ResponseWriter，使http.ResponseController能够访问底层连接
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// gzipBytes 压缩数据
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// gzipReadCloser 读取解压后的数据，关闭时同时关闭原始响应体
+type gzipReadCloser struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (r *gzipReadCloser) Close() error {
+	r.Reader.Close()
+	return r.body.Close()
+}
+
+// decompressResponse 解压gzip编码的响应体，之后的读取得到原始内容
+func decompressResponse(resp *http.Response) error {
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") || resp.Body == nil || resp.Body == http.NoBody {
+		return nil
+	}
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		if err == io.EOF {
+			// 空响应体
+			return nil
+		}
+		resp.Body.Close()
+		return err
+	}
+	resp.Body = &gzipReadCloser{Reader: gz, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RequestTimeoutHeader 客户端告知服务端自身愿意等待的时间（毫秒）
+const RequestTimeoutHeader = "X-Request-Timeout-Ms"
+
+// FormatRequestTimeout 将超时时间格式化为请求头的值，不足1毫秒按1毫秒计
+func FormatRequestTimeout(timeout time.Duration) string {
+	ms := timeout.Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	return strconv.FormatInt(ms, 10)
+}
+
+// ParseRequestTimeout 解析请求中的超时头，头不存在或无效时返回false
+func ParseRequestTimeout(r *http.Request) (time.Duration, bool) {
+	value := r.Header.Get(RequestTimeoutHeader)
+	if value == "" {
+		return 0, false
+	}
+
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || ms <= 0 {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+// DeadlineMiddleware 根据客户端提供的超时预算限制处理器上下文
+// maxTimeout大于0时，客户端的预算不能超过该值；客户端放弃后处理器可通过ctx.Done()及时停止
+func DeadlineMiddleware(maxTimeout time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout, ok := ParseRequestTimeout(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if maxTimeout > 0 && timeout > maxTimeout {
+				timeout = maxTimeout
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
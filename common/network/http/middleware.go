@@ -139,3 +139,8 @@ func (r *responseRecorder) WriteHeader(statusCode int) {
 	r.StatusCode = statusCode
 	r.ResponseWriter.WriteHeader(statusCode)
 }
+
+// Unwrap 返回被包装的ResponseWriter，使http.ResponseController能够访问Flush等能力
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,422 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 延迟的指数加权移动平均系数
+const latencyEWMAAlpha = 0.3
+
+// MultiEndpointClient 在多个对等节点之间分发请求的HTTP客户端
+// 根据各端点的健康状态和延迟选择目标，失败时自动切换到其他端点，
+// 连续失败的端点会被降级，冷却期过后重新尝试
+type MultiEndpointClient struct {
+	mu               sync.Mutex
+	endpoints        []*endpoint
+	cooldown         time.Duration
+	failureThreshold int
+	clientOptions    []ClientOption
+	hedgeDelay       time.Duration
+
+	probePath     string
+	probeInterval time.Duration
+	stopCh        chan struct{}
+	stopOnce      sync.Once
+	wg            sync.WaitGroup
+}
+
+// endpoint 单个端点的状态
+type endpoint struct {
+	baseURL  string
+	client   *Client
+	healthy  bool
+	failures int
+	latency  time.Duration
+	retryAt  time.Time
+	probing  bool
+}
+
+// EndpointStatus 端点状态快照
+type EndpointStatus struct {
+	BaseURL  string        `json:"base_url"`
+	Healthy  bool          `json:"healthy"`
+	Failures int           `json:"failures"`
+	Latency  time.Duration `json:"latency"`
+	RetryAt  time.Time     `json:"retry_at,omitempty"`
+}
+
+// MultiClientOption 定义多端点客户端选项函数
+type MultiClientOption func(*MultiEndpointClient)
+
+// NewMultiEndpointClient 创建多端点客户端
+// 每个端点内部使用不重试的Client，重试由端点切换完成
+func NewMultiEndpointClient(baseURLs []string, options ...MultiClientOption) *MultiEndpointClient {
+	m := &MultiEndpointClient{
+		cooldown:         10 * time.Second,
+		failureThreshold: 1,
+		stopCh:           make(chan struct{}),
+	}
+
+	for _, option := range options {
+		option(m)
+	}
+
+	clientOptions := append([]ClientOption{WithRetryPolicy(0, 0)}, m.clientOptions...)
+	for _, baseURL := range baseURLs {
+		m.endpoints = append(m.endpoints, &endpoint{
+			baseURL: baseURL,
+			client:  NewClient(baseURL, clientOptions...),
+			healthy: true,
+		})
+	}
+
+	if m.probePath != "" && m.probeInterval > 0 {
+		m.wg.Add(1)
+		go m.probeLoop()
+	}
+
+	return m
+}
+
+// WithEndpointCooldown 设置端点被降级后到再次尝试之间的冷却时间
+func WithEndpointCooldown(cooldown time.Duration) MultiClientOption {
+	return func(m *MultiEndpointClient) {
+		if cooldown > 0 {
+			m.cooldown = cooldown
+		}
+	}
+}
+
+// WithFailureThreshold 设置端点被降级前允许的连续失败次数
+func WithFailureThreshold(threshold int) MultiClientOption {
+	return func(m *MultiEndpointClient) {
+		if threshold > 0 {
+			m.failureThreshold = threshold
+		}
+	}
+}
+
+// WithEndpointClientOptions 设置每个端点内部Client的选项
+func WithEndpointClientOptions(options ...ClientOption) MultiClientOption {
+	return func(m *MultiEndpointClient) {
+		m.clientOptions = append(m.clientOptions, options...)
+	}
+}
+
+// WithHealthProbe 启用后台健康探测，定期对冷却期已过的降级端点发送GET请求
+func WithHealthProbe(path string, interval time.Duration) MultiClientOption {
+	return func(m *MultiEndpointClient) {
+		m.probePath = path
+		m.probeInterval = interval
+	}
+}
+
+// WithHedging 为幂等请求启用对冲：请求在delay内未返回时，向另一个端点再发一次，
+// 取先返回的结果并取消另一个请求
+func WithHedging(delay time.Duration) MultiClientOption {
+	return func(m *MultiEndpointClient) {
+		m.hedgeDelay = delay
+	}
+}
+
+// DoJSON 执行HTTP请求并处理JSON响应，失败时切换到下一个端点
+// 网络错误和5xx响应视为端点故障，4xx响应直接返回给调用方
+func (m *MultiEndpointClient) DoJSON(ctx context.Context, method, path string, reqBody, respBody interface{}, headers map[string]string) error {
+	if m.hedgeDelay > 0 && len(m.endpoints) > 1 && isIdempotent(method) {
+		return m.doHedged(ctx, method, path, reqBody, respBody, headers)
+	}
+
+	tried := make(map[*endpoint]bool)
+	var lastErr error
+
+	for {
+		ep := m.pick(tried)
+		if ep == nil {
+			break
+		}
+		tried[ep] = true
+
+		start := time.Now()
+		resp, err := ep.client.request(ctx, method, path, reqBody, headers)
+		if err == nil {
+			m.markSuccess(ep, time.Since(start))
+			return decodeJSONResponse(resp, respBody)
+		}
+
+		// 调用方取消不应算作端点故障
+		if ctx.Err() != nil {
+			m.releaseProbe(ep)
+			return ctx.Err()
+		}
+
+		m.markFailure(ep)
+		lastErr = fmt.Errorf("端点%s请求失败: %w", ep.baseURL, err)
+	}
+
+	if lastErr == nil {
+		return fmt.Errorf("没有可用的端点")
+	}
+	return lastErr
+}
+
+// hedgedResult 对冲请求中单个端点的结果
+type hedgedResult struct {
+	ep      *endpoint
+	resp    *http.Response
+	err     error
+	latency time.Duration
+}
+
+// doHedged 执行对冲请求，同一时刻最多有两个端点在处理请求
+func (m *MultiEndpointClient) doHedged(ctx context.Context, method, path string, reqBody, respBody interface{}, headers map[string]string) error {
+	hedgeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan hedgedResult, len(m.endpoints))
+	tried := make(map[*endpoint]bool)
+	inflight := 0
+	launch := func() bool {
+		ep := m.pick(tried)
+		if ep == nil {
+			return false
+		}
+		tried[ep] = true
+		inflight++
+		go func() {
+			start := time.Now()
+			resp, err := ep.client.request(hedgeCtx, method, path, reqBody, headers)
+			results <- hedgedResult{ep: ep, resp: resp, err: err, latency: time.Since(start)}
+		}()
+		return true
+	}
+
+	if !launch() {
+		return fmt.Errorf("没有可用的端点")
+	}
+
+	timer := time.NewTimer(m.hedgeDelay)
+	defer timer.Stop()
+
+	var lastErr error
+	for inflight > 0 {
+		select {
+		case <-timer.C:
+			launch()
+
+		case r := <-results:
+			inflight--
+			if r.err == nil {
+				m.markSuccess(r.ep, r.latency)
+				// 被取消的请求在后台回收，不计入端点故障
+				go m.drainHedged(results, inflight)
+				return decodeJSONResponse(r.resp, respBody)
+			}
+
+			if ctx.Err() != nil {
+				m.releaseProbe(r.ep)
+				go m.drainHedged(results, inflight)
+				return ctx.Err()
+			}
+
+			m.markFailure(r.ep)
+			lastErr = fmt.Errorf("端点%s请求失败: %w", r.ep.baseURL, r.err)
+			// 快速失败时立即切换，不等待对冲延迟
+			launch()
+		}
+	}
+
+	return lastErr
+}
+
+// drainHedged 回收对冲中落败的请求
+func (m *MultiEndpointClient) drainHedged(results <-chan hedgedResult, pending int) {
+	for i := 0; i < pending; i++ {
+		r := <-results
+		if r.resp != nil {
+			r.resp.Body.Close()
+		}
+		m.releaseProbe(r.ep)
+	}
+}
+
+// isIdempotent 判断请求方法是否幂等，只有幂等请求才能安全地对冲
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// GetJSON 发送GET请求并解析JSON响应
+func (m *MultiEndpointClient) GetJSON(ctx context.Context, path string, result interface{}) error {
+	return m.DoJSON(ctx, http.MethodGet, path, nil, result, nil)
+}
+
+// PostJSON 发送POST请求并解析JSON响应
+func (m *MultiEndpointClient) PostJSON(ctx context.Context, path string, body, result interface{}) error {
+	return m.DoJSON(ctx, http.MethodPost, path, body, result, nil)
+}
+
+// PutJSON 发送PUT请求并解析JSON响应
+func (m *MultiEndpointClient) PutJSON(ctx context.Context, path string, body, result interface{}) error {
+	return m.DoJSON(ctx, http.MethodPut, path, body, result, nil)
+}
+
+// DeleteJSON 发送DELETE请求并解析JSON响应
+func (m *MultiEndpointClient) DeleteJSON(ctx context.Context, path string, result interface{}) error {
+	return m.DoJSON(ctx, http.MethodDelete, path, nil, result, nil)
+}
+
+// Endpoints 返回所有端点的状态快照
+func (m *MultiEndpointClient) Endpoints() []EndpointStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	statuses := make([]EndpointStatus, 0, len(m.endpoints))
+	for _, ep := range m.endpoints {
+		statuses = append(statuses, EndpointStatus{
+			BaseURL:  ep.baseURL,
+			Healthy:  ep.healthy,
+			Failures: ep.failures,
+			Latency:  ep.latency,
+			RetryAt:  ep.retryAt,
+		})
+	}
+	return statuses
+}
+
+// Close 停止后台健康探测
+func (m *MultiEndpointClient) Close() {
+	m.stopOnce.Do(func() {
+		close(m.stopCh)
+	})
+	m.wg.Wait()
+}
+
+// pick 选择下一个要尝试的端点
+// 优先级：冷却期已过的降级端点（每次只允许一个请求探测）> 健康端点（按延迟升序）> 仍在冷却期的端点
+func (m *MultiEndpointClient) pick(tried map[*endpoint]bool) *endpoint {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	var healthy, cooling []*endpoint
+	for _, ep := range m.endpoints {
+		if tried[ep] {
+			continue
+		}
+		if ep.healthy {
+			healthy = append(healthy, ep)
+			continue
+		}
+		if !ep.probing && !now.Before(ep.retryAt) {
+			ep.probing = true
+			return ep
+		}
+		cooling = append(cooling, ep)
+	}
+
+	if len(healthy) > 0 {
+		sort.SliceStable(healthy, func(i, j int) bool {
+			return healthy[i].latency < healthy[j].latency
+		})
+		return healthy[0]
+	}
+
+	// 所有端点都不健康时仍然尝试，尽早到期的优先
+	if len(cooling) > 0 {
+		sort.SliceStable(cooling, func(i, j int) bool {
+			return cooling[i].retryAt.Before(cooling[j].retryAt)
+		})
+		return cooling[0]
+	}
+
+	return nil
+}
+
+// markSuccess 记录成功请求，恢复端点健康状态并更新延迟
+func (m *MultiEndpointClient) markSuccess(ep *endpoint, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ep.healthy = true
+	ep.failures = 0
+	ep.probing = false
+	ep.retryAt = time.Time{}
+	if ep.latency == 0 {
+		ep.latency = latency
+	} else {
+		ep.latency = time.Duration(latencyEWMAAlpha*float64(latency) + (1-latencyEWMAAlpha)*float64(ep.latency))
+	}
+}
+
+// markFailure 记录失败请求，连续失败达到阈值后降级端点
+func (m *MultiEndpointClient) markFailure(ep *endpoint) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ep.failures++
+	ep.probing = false
+	if ep.failures >= m.failureThreshold {
+		ep.healthy = false
+		ep.retryAt = time.Now().Add(m.cooldown)
+	}
+}
+
+// releaseProbe 探测请求被调用方取消时释放探测标记
+func (m *MultiEndpointClient) releaseProbe(ep *endpoint) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ep.probing = false
+}
+
+// probeLoop 定期探测降级端点
+func (m *MultiEndpointClient) probeLoop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.probeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-ticker.C:
+			m.probeOnce()
+		}
+	}
+}
+
+// probeOnce 对冷却期已过的降级端点发送一次探测请求
+func (m *MultiEndpointClient) probeOnce() {
+	m.mu.Lock()
+	now := time.Now()
+	var targets []*endpoint
+	for _, ep := range m.endpoints {
+		if !ep.healthy && !ep.probing && !now.Before(ep.retryAt) {
+			ep.probing = true
+			targets = append(targets, ep)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, ep := range targets {
+		ctx, cancel := context.WithTimeout(context.Background(), m.probeInterval)
+		start := time.Now()
+		resp, err := ep.client.request(ctx, http.MethodGet, m.probePath, nil, nil)
+		cancel()
+		if err != nil {
+			m.markFailure(ep)
+			continue
+		}
+		resp.Body.Close()
+		m.markSuccess(ep, time.Since(start))
+	}
+}
@@ -0,0 +1,345 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RouteInfo 路由元数据，用于生成OpenAPI文档
+type RouteInfo struct {
+	Method        string
+	Path          string
+	Summary       string
+	RequestType   reflect.Type
+	ResponseType  reflect.Type
+	QueryParams   []QueryParam
+	HeaderParams  []QueryParam
+	SuccessStatus int         // 返回ResponseType的状态码，0表示200
+	StatusDocs    []StatusDoc // 其他不携带data的成功状态码
+	NoEnvelope    bool        // 处理器直接调用RespondJSON，不使用文档配置的响应信封
+}
+
+// StatusDoc 路由可能返回的其他成功状态码描述
+type StatusDoc struct {
+	Code        int
+	Description string
+}
+
+// QueryParam 查询参数或请求头参数描述
+type QueryParam struct {
+	Name        string
+	Type        string // OpenAPI基本类型: string、integer、boolean、number
+	Description string
+}
+
+// RouteOption 注册路由时附加的元数据选项
+type RouteOption func(*RouteInfo)
+
+// WithSummary 设置路由摘要
+func WithSummary(summary string) RouteOption {
+	return func(info *RouteInfo) {
+		info.Summary = summary
+	}
+}
+
+// WithRequestType 声明请求体类型，传入该类型的零值即可
+func WithRequestType(v interface{}) RouteOption {
+	return func(info *RouteInfo) {
+		info.RequestType = reflect.TypeOf(v)
+	}
+}
+
+// WithResponseType 声明成功响应中data字段的类型，传入该类型的零值即可
+func WithResponseType(v interface{}) RouteOption {
+	return func(info *RouteInfo) {
+		info.ResponseType = reflect.TypeOf(v)
+	}
+}
+
+// WithQueryParamDoc 声明路由支持的查询参数
+func WithQueryParamDoc(name, typ, description string) RouteOption {
+	return func(info *RouteInfo) {
+		info.QueryParams = append(info.QueryParams, QueryParam{
+			Name:        name,
+			Type:        typ,
+			Description: description,
+		})
+	}
+}
+
+// WithSuccessStatus 声明返回ResponseType的成功状态码，如创建资源时的201，未声明时为200
+func WithSuccessStatus(code int) RouteOption {
+	return func(info *RouteInfo) {
+		info.SuccessStatus = code
+	}
+}
+
+// WithStatusDoc 声明路由可能返回的其他成功状态码，如异步确认写入时的202，这些响应不携带data
+func WithStatusDoc(code int, description string) RouteOption {
+	return func(info *RouteInfo) {
+		info.StatusDocs = append(info.StatusDocs, StatusDoc{Code: code, Description: description})
+	}
+}
+
+// WithoutResponseEnvelope 声明处理器直接以RespondJSON返回ResponseType，不使用WithResponseEnvelope配置的信封
+func WithoutResponseEnvelope() RouteOption {
+	return func(info *RouteInfo) {
+		info.NoEnvelope = true
+	}
+}
+
+// WithHeaderParamDoc 声明路由读取的请求头，typ同样使用OpenAPI基本类型
+func WithHeaderParamDoc(name, typ, description string) RouteOption {
+	return func(info *RouteInfo) {
+		info.HeaderParams = append(info.HeaderParams, QueryParam{
+			Name:        name,
+			Type:        typ,
+			Description: description,
+		})
+	}
+}
+
+// openAPIConfig 生成OpenAPI文档的配置
+type openAPIConfig struct {
+	envelope      reflect.Type // 处理器放入StandardResponse的data中的响应信封，nil表示直接放入ResponseType
+	envelopeField string       // 信封中存放ResponseType的JSON字段名
+}
+
+// OpenAPIOption 生成OpenAPI文档的配置选项
+type OpenAPIOption func(*openAPIConfig)
+
+// WithResponseEnvelope 声明处理器把响应数据包装在envelope类型中再交给RespondJSON，
+// ResponseType位于信封的dataField字段（JSON字段名）。传入信封类型的零值即可
+func WithResponseEnvelope(envelope interface{}, dataField string) OpenAPIOption {
+	return func(cfg *openAPIConfig) {
+		cfg.envelope = reflect.TypeOf(envelope)
+		cfg.envelopeField = dataField
+	}
+}
+
+// muxVarPattern 匹配gorilla/mux路径变量，例如{path:.*}
+var muxVarPattern = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// standardResponseType RespondJSON包装所有响应使用的类型
+var standardResponseType = reflect.TypeOf(StandardResponse{})
+
+// GenerateOpenAPI 根据路由元数据生成OpenAPI 3文档
+func GenerateOpenAPI(title, version string, routes []RouteInfo, opts ...OpenAPIOption) map[string]interface{} {
+	var cfg openAPIConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	paths := make(map[string]interface{})
+
+	for _, route := range routes {
+		openAPIPath := muxVarPattern.ReplaceAllString(route.Path, "{$1}")
+
+		item, ok := paths[openAPIPath].(map[string]interface{})
+		if !ok {
+			item = make(map[string]interface{})
+			paths[openAPIPath] = item
+		}
+
+		item[strings.ToLower(route.Method)] = buildOperation(route, cfg)
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   title,
+			"version": version,
+		},
+		"paths": paths,
+	}
+}
+
+// buildOperation 生成单个路由的operation对象
+func buildOperation(route RouteInfo, cfg openAPIConfig) map[string]interface{} {
+	op := map[string]interface{}{}
+	if route.Summary != "" {
+		op["summary"] = route.Summary
+	}
+
+	var params []interface{}
+	for _, match := range muxVarPattern.FindAllStringSubmatch(route.Path, -1) {
+		params = append(params, map[string]interface{}{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		})
+	}
+	params = appendParams(params, "query", route.QueryParams)
+	params = appendParams(params, "header", route.HeaderParams)
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	if route.RequestType != nil {
+		op["requestBody"] = map[string]interface{}{
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": schemaForType(route.RequestType, map[reflect.Type]bool{}),
+				},
+			},
+		}
+	}
+
+	var data map[string]interface{}
+	if route.ResponseType != nil {
+		data = schemaForType(route.ResponseType, map[reflect.Type]bool{})
+	}
+	status := route.SuccessStatus
+	if status == 0 {
+		status = http.StatusOK
+	}
+	responses := map[string]interface{}{
+		strconv.Itoa(status): jsonResponse("成功", responseSchema(route, cfg, data)),
+		"default":            map[string]interface{}{"description": "错误"},
+	}
+	for _, doc := range route.StatusDocs {
+		responses[strconv.Itoa(doc.Code)] = jsonResponse(doc.Description, responseSchema(route, cfg, nil))
+	}
+	op["responses"] = responses
+
+	return op
+}
+
+// jsonResponse 生成内容为JSON的response对象
+func jsonResponse(description string, schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schema},
+		},
+	}
+}
+
+// responseSchema 生成成功响应的完整结构：RespondJSON把响应放入StandardResponse的data字段，
+// 配置了响应信封且路由未排除时data为信封，data为nil表示响应不携带数据
+func responseSchema(route RouteInfo, cfg openAPIConfig, data map[string]interface{}) map[string]interface{} {
+	if cfg.envelope != nil && !route.NoEnvelope {
+		data = envelopeSchema(cfg.envelope, cfg.envelopeField, data)
+	}
+	return envelopeSchema(standardResponseType, "data", data)
+}
+
+// envelopeSchema 生成信封类型的结构，并以data替换其中field字段的结构，data为nil时去掉该字段
+func envelopeSchema(envelope reflect.Type, field string, data map[string]interface{}) map[string]interface{} {
+	schema := schemaForType(envelope, map[reflect.Type]bool{})
+	if properties, ok := schema["properties"].(map[string]interface{}); ok {
+		if data == nil {
+			delete(properties, field)
+		} else {
+			properties[field] = data
+		}
+	}
+	return schema
+}
+
+// appendParams 把查询参数或请求头参数转换为OpenAPI参数对象，in为参数位置
+func appendParams(params []interface{}, in string, docs []QueryParam) []interface{} {
+	for _, q := range docs {
+		param := map[string]interface{}{
+			"name":   q.Name,
+			"in":     in,
+			"schema": map[string]interface{}{"type": q.Type},
+		}
+		if q.Description != "" {
+			param["description"] = q.Description
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaForType 通过反射生成JSON Schema，seen用于避免递归类型无限展开
+func schemaForType(t reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": schemaForType(t.Elem(), seen)}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": schemaForType(t.Elem(), seen),
+		}
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]interface{}{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		properties := make(map[string]interface{})
+		collectStructProperties(t, properties, seen)
+		return map[string]interface{}{"type": "object", "properties": properties}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// collectStructProperties 按encoding/json规则收集结构体字段，匿名嵌入的结构体字段会被展开
+func collectStructProperties(t reflect.Type, properties map[string]interface{}, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				collectStructProperties(ft, properties, seen)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = schemaForType(field.Type, seen)
+	}
+}
+
+// OpenAPIHandler 返回提供OpenAPI文档的处理器，文档在每次请求时根据当前路由生成
+func (s *Server) OpenAPIHandler(title, version string, opts ...OpenAPIOption) ServerHandler {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(GenerateOpenAPI(title, version, s.Routes(), opts...))
+	}
+}
@@ -0,0 +1,182 @@
+package http
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRateLimitIdleTimeout 令牌桶闲置多久后被回收
+const DefaultRateLimitIdleTimeout = time.Minute
+
+// KeyFunc 从请求中提取限流的键，返回空字符串时使用客户端IP
+type KeyFunc func(r *http.Request) string
+
+// ClientIPKey 以连接的对端IP作为限流的键，不信任客户端可以伪造的X-Forwarded-For等头
+func ClientIPKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ForwardedForKey 以X-Forwarded-For中最左侧的地址作为限流的键，只应在可信的反向代理之后使用
+func ForwardedForKey(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		first, _, _ := strings.Cut(forwarded, ",")
+		return strings.TrimSpace(first)
+	}
+	return ClientIPKey(r)
+}
+
+// RequestIDKey 以请求头X-Request-ID作为限流的键
+func RequestIDKey(r *http.Request) string {
+	return r.Header.Get("X-Request-ID")
+}
+
+// RateLimitOption 限流器配置选项
+type RateLimitOption func(*RateLimiter)
+
+// WithRateLimitKey 设置提取限流键的函数，默认为ClientIPKey
+func WithRateLimitKey(fn KeyFunc) RateLimitOption {
+	return func(l *RateLimiter) {
+		l.keyFunc = fn
+	}
+}
+
+// WithRateLimitIdleTimeout 设置令牌桶的回收时间，不会短于令牌桶从空到满所需的时间
+func WithRateLimitIdleTimeout(timeout time.Duration) RateLimitOption {
+	return func(l *RateLimiter) {
+		l.idleTimeout = timeout
+	}
+}
+
+// tokenBucket 单个键的令牌桶，按时间差惰性补充令牌
+type tokenBucket struct {
+	tokens   float64
+	updated  time.Time // 上次补充令牌的时间
+	lastSeen time.Time // 上次访问时间，用于回收
+}
+
+// RateLimiter 按键（默认为客户端IP）限流的令牌桶集合。
+// 每个键的令牌以每秒rps个的速度补充，最多累积burst个；闲置超过回收时间的令牌桶被删除，
+// 此时令牌桶已经补满，删除后重新创建不改变限流结果
+type RateLimiter struct {
+	mu          sync.Mutex
+	rate        float64 // 每秒补充的令牌数
+	burst       float64
+	keyFunc     KeyFunc
+	idleTimeout time.Duration
+	buckets     map[string]*tokenBucket
+	lastSweep   time.Time
+}
+
+// NewRateLimiter 创建令牌桶限流器，rps为每秒补充的令牌数，burst为令牌桶容量；
+// 两者不大于0时分别视为1和rps
+func NewRateLimiter(rps, burst int, opts ...RateLimitOption) *RateLimiter {
+	if rps <= 0 {
+		rps = 1
+	}
+	if burst <= 0 {
+		burst = rps
+	}
+	l := &RateLimiter{
+		rate:        float64(rps),
+		burst:       float64(burst),
+		keyFunc:     ClientIPKey,
+		idleTimeout: DefaultRateLimitIdleTimeout,
+		buckets:     make(map[string]*tokenBucket),
+		lastSweep:   time.Now(),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if fill := time.Duration(l.burst / l.rate * float64(time.Second)); l.idleTimeout < fill {
+		l.idleTimeout = fill
+	}
+	return l
+}
+
+// Allow 从key的令牌桶中取出一个令牌，令牌不足时返回false和下一个令牌补充到位前需要等待的时间
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweepLocked(now)
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, updated: now}
+		l.buckets[key] = bucket
+	}
+	bucket.lastSeen = now
+
+	elapsed := now.Sub(bucket.updated).Seconds()
+	bucket.tokens = math.Min(l.burst, bucket.tokens+elapsed*l.rate)
+	bucket.updated = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// Buckets 返回当前保留的令牌桶数量
+func (l *RateLimiter) Buckets() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweepLocked(time.Now())
+	return len(l.buckets)
+}
+
+// sweepLocked 每隔回收时间删除一次闲置的令牌桶，调用方需持有锁
+func (l *RateLimiter) sweepLocked(now time.Time) {
+	if now.Sub(l.lastSweep) < l.idleTimeout {
+		return
+	}
+	l.lastSweep = now
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.lastSeen) >= l.idleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Middleware 返回限流中间件，令牌耗尽时返回429和Retry-After头（秒，向上取整）
+func (l *RateLimiter) Middleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := l.keyFunc(r)
+			if key == "" {
+				key = ClientIPKey(r)
+			}
+
+			allowed, wait := l.Allow(key)
+			if !allowed {
+				seconds := int(math.Ceil(wait.Seconds()))
+				if seconds < 1 {
+					seconds = 1
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(seconds))
+				RespondError(w, http.StatusTooManyRequests, "请求频率超过限制，请稍后再试")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimitMiddleware 创建按客户端IP限流的令牌桶中间件，每秒补充rps个令牌，最多累积burst个，
+// 可以通过WithRateLimitKey改为按X-Request-ID或认证主体限流
+func RateLimitMiddleware(rps, burst int, opts ...RateLimitOption) Middleware {
+	return NewRateLimiter(rps, burst, opts...).Middleware()
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// ReadinessCheck 就绪条件，返回nil表示满足
+type ReadinessCheck func() error
+
+type namedReadinessCheck struct {
+	name  string
+	check ReadinessCheck
+}
+
+// ReadinessGate 服务就绪门控
+// 服务完成启动并满足所有就绪条件前，除豁免路径外的请求都返回503，
+// 避免请求在依赖组件（如集群领导者）就绪前到达处理器
+type ReadinessGate struct {
+	mu      sync.RWMutex
+	started bool
+	checks  []namedReadinessCheck
+	exempt  map[string]bool
+}
+
+// NewReadinessGate 创建就绪门控，exemptPaths中的路径（如健康检查、心跳）不受门控限制
+func NewReadinessGate(exemptPaths ...string) *ReadinessGate {
+	exempt := make(map[string]bool, len(exemptPaths))
+	for _, path := range exemptPaths {
+		exempt[path] = true
+	}
+	return &ReadinessGate{exempt: exempt}
+}
+
+// AddCheck 添加就绪条件，按添加顺序检查
+func (g *ReadinessGate) AddCheck(name string, check ReadinessCheck) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.checks = append(g.checks, namedReadinessCheck{name: name, check: check})
+}
+
+// SetStarted 设置服务是否已完成启动，停止时应先设置为false再关闭组件
+func (g *ReadinessGate) SetStarted(started bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.started = started
+}
+
+// Ready 返回服务未就绪的原因，nil表示已就绪
+func (g *ReadinessGate) Ready() error {
+	g.mu.RLock()
+	started := g.started
+	checks := g.checks
+	g.mu.RUnlock()
+
+	if !started {
+		return fmt.Errorf("服务尚未完成启动")
+	}
+	for _, c := range checks {
+		if err := c.check(); err != nil {
+			return fmt.Errorf("%s未就绪: %w", c.name, err)
+		}
+	}
+	return nil
+}
+
+// Middleware 返回门控中间件，未就绪时返回503并设置Retry-After
+func (g *ReadinessGate) Middleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if g.exempt[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if err := g.Ready(); err != nil {
+				w.Header().Set("Retry-After", "1")
+				RespondError(w, http.StatusServiceUnavailable, err.Error(), "not_ready")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Handler 返回就绪探针处理器，就绪时返回200，否则返回503及原因
+func (g *ReadinessGate) Handler() ServerHandler {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := g.Ready(); err != nil {
+			RespondError(w, http.StatusServiceUnavailable, err.Error(), "not_ready")
+			return
+		}
+		RespondJSON(w, http.StatusOK, map[string]bool{"ready": true})
+	}
+}
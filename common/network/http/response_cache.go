@@ -0,0 +1,132 @@
+package http
+
+import (
+	"bytes"
+	"container/list"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// responseCache 基于LRU的响应缓存，只保存带有验证器的成功响应
+type responseCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+// cachedResponse 缓存的响应及其验证器
+type cachedResponse struct {
+	key          string
+	status       int
+	header       http.Header
+	body         []byte
+	etag         string
+	lastModified string
+	storedAt     time.Time
+}
+
+func newResponseCache(maxEntries int, ttl time.Duration) *responseCache {
+	if maxEntries <= 0 {
+		maxEntries = 100
+	}
+	return &responseCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// get 返回未过期的缓存条目
+func (c *responseCache) get(key string) *cachedResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+
+	entry := elem.Value.(*cachedResponse)
+	if c.ttl > 0 && time.Since(entry.storedAt) > c.ttl {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil
+	}
+
+	c.order.MoveToFront(elem)
+	return entry
+}
+
+// store 缓存带验证器的200响应，返回可继续读取的响应
+func (c *responseCache) store(key string, resp *http.Response) (*http.Response, error) {
+	etag := resp.Header.Get("ETag")
+	lastModified := resp.Header.Get("Last-Modified")
+	if resp.StatusCode != http.StatusOK || (etag == "" && lastModified == "") {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	entry := &cachedResponse{
+		key:          key,
+		status:       resp.StatusCode,
+		header:       resp.Header.Clone(),
+		body:         body,
+		etag:         etag,
+		lastModified: lastModified,
+		storedAt:     time.Now(),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return resp, nil
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedResponse).key)
+	}
+
+	return resp, nil
+}
+
+// applyValidators 为请求添加条件头，调用方显式设置的条件头优先
+func (e *cachedResponse) applyValidators(req *http.Request) {
+	if e.etag != "" && req.Header.Get("If-None-Match") == "" {
+		req.Header.Set("If-None-Match", e.etag)
+	}
+	if e.lastModified != "" && req.Header.Get("If-Modified-Since") == "" {
+		req.Header.Set("If-Modified-Since", e.lastModified)
+	}
+}
+
+// response 根据缓存条目构造响应
+func (e *cachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        http.StatusText(e.status),
+		StatusCode:    e.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,112 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultHSTSMaxAge Strict-Transport-Security的默认max-age
+const DefaultHSTSMaxAge = 365 * 24 * time.Hour
+
+// SecurityHeadersConfig 安全响应头配置
+type SecurityHeadersConfig struct {
+	// Headers 为所有响应注入的头，值为空的项不注入，可用于关闭某个默认头；
+	// 其中的Strict-Transport-Security替代按HSTSMaxAge生成的值，同样只注入TLS请求
+	Headers map[string]string
+	// HSTSMaxAge 大于0时为TLS请求注入Strict-Transport-Security，明文请求中该头无效，不注入
+	HSTSMaxAge time.Duration
+}
+
+// DefaultSecurityHeaders 返回默认注入的安全响应头
+func DefaultSecurityHeaders() map[string]string {
+	return map[string]string{
+		"X-Content-Type-Options": "nosniff",
+		"X-Frame-Options":        "DENY",
+		"Referrer-Policy":        "no-referrer",
+	}
+}
+
+// DefaultSecurityHeadersConfig 返回默认的安全响应头配置，overrides中的项追加或替换默认头
+func DefaultSecurityHeadersConfig(overrides map[string]string) SecurityHeadersConfig {
+	headers := DefaultSecurityHeaders()
+	for name, value := range overrides {
+		headers[http.CanonicalHeaderKey(name)] = value
+	}
+	return SecurityHeadersConfig{Headers: headers, HSTSMaxAge: DefaultHSTSMaxAge}
+}
+
+// SecurityHeadersMiddleware 在响应头写出前注入安全响应头，处理器显式设置的头不会被覆盖
+func SecurityHeadersMiddleware(cfg SecurityHeadersConfig) Middleware {
+	var hsts string
+	if cfg.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.FormatInt(int64(cfg.HSTSMaxAge/time.Second), 10) + "; includeSubDomains"
+	}
+	headers := make(http.Header, len(cfg.Headers))
+	for name, value := range cfg.Headers {
+		if http.CanonicalHeaderKey(name) == "Strict-Transport-Security" {
+			hsts = value
+			continue
+		}
+		if value != "" {
+			headers.Set(name, value)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			inject := headers
+			if hsts != "" && r.TLS != nil {
+				inject = headers.Clone()
+				inject.Set("Strict-Transport-Security", hsts)
+			}
+			sw := &securityHeadersWriter{ResponseWriter: w, headers: inject}
+			next.ServeHTTP(sw, r)
+			// 处理器没有写出任何内容时，响应头由net/http在返回后写出
+			sw.inject()
+		})
+	}
+}
+
+// securityHeadersWriter 在第一次写出响应头时补充处理器未设置的安全头
+type securityHeadersWriter struct {
+	http.ResponseWriter
+	headers     http.Header
+	wroteHeader bool
+}
+
+func (w *securityHeadersWriter) inject() {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	dst := w.ResponseWriter.Header()
+	for name, values := range w.headers {
+		if _, set := dst[name]; !set {
+			dst[name] = append([]string(nil), values...)
+		}
+	}
+}
+
+// WriteHeader 写出状态码前注入安全头
+func (w *securityHeadersWriter) WriteHeader(statusCode int) {
+	w.inject()
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write 未显式调用WriteHeader时同样注入安全头
+func (w *securityHeadersWriter) Write(b []byte) (int, error) {
+	w.inject()
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush 刷新前注入安全头，流式响应的头在第一次刷新时写出
+func (w *securityHeadersWriter) Flush() {
+	w.inject()
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap 返回被包装的ResponseWriter，使http.ResponseController能够访问Flush等能力
+func (w *securityHeadersWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
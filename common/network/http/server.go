@@ -2,8 +2,14 @@ package http
 
 import (
     "context"
+    "crypto/tls"
+    "fmt"
     "net"
     "net/http"
+    "sort"
+    "strings"
+    "sync"
+    "sync/atomic"
     "time"
 
     "github.com/22827099/DFS_v1/common/logging"
@@ -15,17 +21,40 @@ type ServerHandler func(w http.ResponseWriter, r *http.Request)
 
 // Server 表示HTTP服务器
 type Server struct {
-    addr         string
-    actualAddr   string
-    readTimeout  time.Duration
-    writeTimeout time.Duration
-    idleTimeout  time.Duration
-    router       *mux.Router
-    middlewares  []Middleware
-    server       *http.Server
-    logger       logging.Logger
+    addr          string
+    actualAddr    string
+    serverMu      sync.RWMutex // 保护actualAddr和server，Serve与Stop可能在不同goroutine中调用
+    activeConns   atomic.Int64 // 当前打开的连接数，由http.Server.ConnState维护
+    readTimeout   time.Duration
+    writeTimeout  time.Duration
+    idleTimeout   time.Duration
+    maxHeaderBytes     int  // 请求头的最大字节数，0使用http.DefaultMaxHeaderBytes
+    keepAlivesDisabled bool // 为true时每个连接只处理一个请求
+    router        *mux.Router
+    middlewares   []Middleware
+    server        *http.Server
+    logger        logging.Logger
+    routesMu      sync.RWMutex
+    routes        []RouteInfo
+    trailingSlash TrailingSlashMode
+    tlsConfig     *tls.Config // 不为nil时在监听器上提供HTTPS服务
+    outer         http.Handler // 不为nil时包装整个路由，使404、405和重定向响应也经过处理
+    internal      map[string]http.Handler // HandleInternal注册的路径，不经过路由和中间件
+    uploadIdleTimeout time.Duration // Upload路由每次读取请求体的最长等待时间，0使用DefaultUploadIdleTimeout
 }
 
+// TrailingSlashMode 定义带尾部斜杠的路径如何路由
+type TrailingSlashMode int
+
+const (
+    // TrailingSlashStrict 严格匹配，/a/与/a是不同的路径
+    TrailingSlashStrict TrailingSlashMode = iota
+    // TrailingSlashStrip 路由前去掉尾部斜杠，与存储层path.Clean的规范化保持一致
+    TrailingSlashStrip
+    // TrailingSlashRedirect 使用308重定向到不带尾部斜杠的路径，保留请求方法和请求体
+    TrailingSlashRedirect
+)
+
 // ServerOption 服务器配置选项
 type ServerOption func(*Server)
 
@@ -38,6 +67,8 @@ func NewServer(addr string, options ...ServerOption) *Server {
         writeTimeout: 30 * time.Second,
         idleTimeout:  60 * time.Second,
     }
+    server.router.NotFoundHandler = http.HandlerFunc(DefaultNotFoundHandler)
+    server.router.MethodNotAllowedHandler = http.HandlerFunc(server.defaultMethodNotAllowed)
     
     // 应用所有选项
     for _, option := range options {
@@ -60,20 +91,35 @@ func (s *Server) Start() error {
         return err
     }
     
-    s.actualAddr = listener.Addr().String()
-    
-    s.server = &http.Server{
-        Handler:      s.router,
+    return s.Serve(listener)
+}
+
+// Serve 在指定的监听器上提供服务，阻塞直到服务器关闭
+func (s *Server) Serve(listener net.Listener) error {
+    server := &http.Server{
+        Handler:      s,
         ReadTimeout:  s.readTimeout,
         WriteTimeout: s.writeTimeout,
         IdleTimeout:  s.idleTimeout,
+        ConnState:    s.trackConnState,
+        TLSConfig:    s.tlsConfig,
+        MaxHeaderBytes: s.maxHeaderBytes,
+    }
+    server.SetKeepAlivesEnabled(!s.keepAlivesDisabled)
+    if s.tlsConfig != nil {
+        listener = tls.NewListener(listener, s.tlsConfig)
     }
     
+    s.serverMu.Lock()
+    s.actualAddr = listener.Addr().String()
+    s.server = server
+    s.serverMu.Unlock()
+    
     if s.logger != nil {
-        s.logger.Info("HTTP服务器启动于 %s", s.actualAddr)
+        s.logger.Info("HTTP服务器启动于 %s", listener.Addr().String())
     }
     
-    return s.server.Serve(listener)
+    return server.Serve(listener)
 }
 
 // Stop 停止HTTP服务器
@@ -82,35 +128,173 @@ func (s *Server) Stop(ctx context.Context) error {
         s.logger.Info("正在关闭HTTP服务器")
     }
     
-    if s.server != nil {
-        return s.server.Shutdown(ctx)
+    s.serverMu.RLock()
+    server := s.server
+    s.serverMu.RUnlock()
+    
+    if server != nil {
+        return server.Shutdown(ctx)
     }
     return nil
 }
 
+// ActiveConnections 返回当前打开的客户端连接数，包括空闲的keep-alive连接
+func (s *Server) ActiveConnections() int64 {
+    return s.activeConns.Load()
+}
+
+// trackConnState 根据连接状态变化维护连接数，被劫持（如WebSocket）的连接不再由服务器管理
+func (s *Server) trackConnState(_ net.Conn, state http.ConnState) {
+    switch state {
+    case http.StateNew:
+        s.activeConns.Add(1)
+    case http.StateClosed, http.StateHijacked:
+        s.activeConns.Add(-1)
+    }
+}
+
 // GET 注册GET路由
-func (s *Server) GET(path string, handler ServerHandler) {
-    s.router.HandleFunc(path, handler).Methods(http.MethodGet)
+func (s *Server) GET(path string, handler ServerHandler, opts ...RouteOption) {
+    s.handle(http.MethodGet, path, handler, opts)
 }
 
 // POST 注册POST路由
-func (s *Server) POST(path string, handler ServerHandler) {
-    s.router.HandleFunc(path, handler).Methods(http.MethodPost)
+func (s *Server) POST(path string, handler ServerHandler, opts ...RouteOption) {
+    s.handle(http.MethodPost, path, handler, opts)
 }
 
 // PUT 注册PUT路由
-func (s *Server) PUT(path string, handler ServerHandler) {
-    s.router.HandleFunc(path, handler).Methods(http.MethodPut)
+func (s *Server) PUT(path string, handler ServerHandler, opts ...RouteOption) {
+    s.handle(http.MethodPut, path, handler, opts)
 }
 
 // DELETE 注册DELETE路由
-func (s *Server) DELETE(path string, handler ServerHandler) {
-    s.router.HandleFunc(path, handler).Methods(http.MethodDelete)
+func (s *Server) DELETE(path string, handler ServerHandler, opts ...RouteOption) {
+    s.handle(http.MethodDelete, path, handler, opts)
 }
 
 // OPTIONS 注册OPTIONS路由
-func (s *Server) OPTIONS(path string, handler ServerHandler) {
-    s.router.HandleFunc(path, handler).Methods(http.MethodOptions)
+func (s *Server) OPTIONS(path string, handler ServerHandler, opts ...RouteOption) {
+    s.handle(http.MethodOptions, path, handler, opts)
+}
+
+// handle 注册路由并记录路由元数据
+func (s *Server) handle(method, path string, handler ServerHandler, opts []RouteOption) {
+    s.router.HandleFunc(path, handler).Methods(method)
+
+    info := RouteInfo{Method: method, Path: path}
+    for _, opt := range opts {
+        opt(&info)
+    }
+
+    s.routesMu.Lock()
+    s.routes = append(s.routes, info)
+    s.routesMu.Unlock()
+}
+
+// Routes 返回已注册路由的元数据，按注册顺序排列
+func (s *Server) Routes() []RouteInfo {
+    s.routesMu.RLock()
+    defer s.routesMu.RUnlock()
+
+    routes := make([]RouteInfo, len(s.routes))
+    copy(routes, s.routes)
+    return routes
+}
+
+// HandleInternal 注册节点之间通信使用的内部处理器，只匹配完整路径。
+// 内部路径在路由和全部中间件（包括安全响应头、限流和就绪检查）之前分发，
+// 不出现在Routes和API文档中，处理器需要自行认证请求。应在服务器启动前注册
+func (s *Server) HandleInternal(path string, handler http.Handler) {
+    s.routesMu.Lock()
+    defer s.routesMu.Unlock()
+    if s.internal == nil {
+        s.internal = make(map[string]http.Handler)
+    }
+    s.internal[path] = handler
+}
+
+// ServeHTTP 实现http.Handler，便于在测试或其他服务器中直接使用路由
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    s.routesMu.RLock()
+    internal := s.internal[r.URL.Path]
+    s.routesMu.RUnlock()
+    if internal != nil {
+        internal.ServeHTTP(w, r)
+        return
+    }
+
+    if s.outer != nil {
+        s.outer.ServeHTTP(w, r)
+        return
+    }
+    s.route(w, r)
+}
+
+// route 规范化尾部斜杠后交给路由器处理
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
+    if s.trailingSlash != TrailingSlashStrict && len(r.URL.Path) > 1 && strings.HasSuffix(r.URL.Path, "/") {
+        trimmed := strings.TrimRight(r.URL.Path, "/")
+        if trimmed == "" {
+            trimmed = "/"
+        }
+
+        if s.trailingSlash == TrailingSlashRedirect {
+            target := *r.URL
+            target.Path = trimmed
+            target.RawPath = ""
+            http.Redirect(w, r, target.RequestURI(), http.StatusPermanentRedirect)
+            return
+        }
+
+        r2 := r.Clone(r.Context())
+        r2.URL.Path = trimmed
+        r2.URL.RawPath = ""
+        r = r2
+    }
+
+    s.router.ServeHTTP(w, r)
+}
+
+// AllowedMethods 返回请求路径上已注册的方法
+func (s *Server) AllowedMethods(r *http.Request) []string {
+    s.routesMu.RLock()
+    seen := make(map[string]bool)
+    var candidates []string
+    for _, route := range s.routes {
+        if !seen[route.Method] {
+            seen[route.Method] = true
+            candidates = append(candidates, route.Method)
+        }
+    }
+    s.routesMu.RUnlock()
+
+    var allowed []string
+    for _, method := range candidates {
+        probe := r.Clone(r.Context())
+        probe.Method = method
+        var match mux.RouteMatch
+        if s.router.Match(probe, &match) && match.MatchErr == nil {
+            allowed = append(allowed, method)
+        }
+    }
+    sort.Strings(allowed)
+    return allowed
+}
+
+// defaultMethodNotAllowed 返回405及允许的方法列表
+func (s *Server) defaultMethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+    allowed := s.AllowedMethods(r)
+    w.Header().Set("Allow", strings.Join(allowed, ", "))
+
+    resp := ErrorResponse(fmt.Sprintf("路径%s不支持%s方法", r.URL.Path, r.Method), "method_not_allowed")
+    resp.Data = map[string]interface{}{"allowed_methods": allowed}
+    RespondJSON(w, http.StatusMethodNotAllowed, resp)
+}
+
+// DefaultNotFoundHandler 返回JSON格式的404响应
+func DefaultNotFoundHandler(w http.ResponseWriter, r *http.Request) {
+    RespondError(w, http.StatusNotFound, fmt.Sprintf("路径%s不存在", r.URL.Path), "not_found")
 }
 
 // Group 创建路由组
@@ -123,6 +307,8 @@ func (s *Server) Group(prefix string) RouteGroup {
 
 // GetAddr 返回服务器当前监听地址
 func (s *Server) GetAddr() string {
+    s.serverMu.RLock()
+    defer s.serverMu.RUnlock()
     if s.actualAddr != "" {
         return s.actualAddr
     }
@@ -151,6 +337,73 @@ func WithServerTimeout(read, write, idle time.Duration) ServerOption {
     }
 }
 
+// WithIdleTimeout 设置keep-alive连接在两个请求之间的最长空闲时间，超过后服务器关闭连接
+func WithIdleTimeout(idle time.Duration) ServerOption {
+    return func(s *Server) {
+        if idle > 0 {
+            s.idleTimeout = idle
+        }
+    }
+}
+
+// WithUploadIdleTimeout 设置Upload路由读取请求体时两次收到数据之间的最长等待时间。
+// 上传不受服务器整体的读写超时限制，只要数据持续到达就不会被中断
+func WithUploadIdleTimeout(timeout time.Duration) ServerOption {
+    return func(s *Server) {
+        if timeout > 0 {
+            s.uploadIdleTimeout = timeout
+        }
+    }
+}
+
+// WithMaxHeaderBytes 设置请求头（含请求行）的最大字节数，超过时返回431
+func WithMaxHeaderBytes(n int) ServerOption {
+    return func(s *Server) {
+        if n > 0 {
+            s.maxHeaderBytes = n
+        }
+    }
+}
+
+// WithKeepAlivesDisabled 禁用keep-alive，每个请求处理完后关闭连接
+func WithKeepAlivesDisabled() ServerOption {
+    return func(s *Server) {
+        s.keepAlivesDisabled = true
+    }
+}
+
+// WithNotFoundHandler 设置路径未注册时的处理器
+func WithNotFoundHandler(handler http.Handler) ServerOption {
+    return func(s *Server) {
+        s.router.NotFoundHandler = handler
+    }
+}
+
+// WithMethodNotAllowedHandler 设置路径存在但方法不支持时的处理器
+// 处理器被调用前Allow头已经设置好
+func WithMethodNotAllowedHandler(handler http.Handler) ServerOption {
+    return func(s *Server) {
+        s.router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            w.Header().Set("Allow", strings.Join(s.AllowedMethods(r), ", "))
+            handler.ServeHTTP(w, r)
+        })
+    }
+}
+
+// WithTrailingSlash 设置尾部斜杠的处理方式，默认为TrailingSlashStrict
+func WithTrailingSlash(mode TrailingSlashMode) ServerOption {
+    return func(s *Server) {
+        s.trailingSlash = mode
+    }
+}
+
+// WithSecurityHeaders 为所有响应（包括未匹配路由的404和405）注入安全响应头，处理器显式设置的头优先
+func WithSecurityHeaders(cfg SecurityHeadersConfig) ServerOption {
+    return func(s *Server) {
+        s.outer = SecurityHeadersMiddleware(cfg)(http.HandlerFunc(s.route))
+    }
+}
+
 // WithMiddleware 添加中间件
 func WithMiddleware(middleware ...Middleware) ServerOption {
     return func(s *Server) {
@@ -162,49 +415,70 @@ func WithMiddleware(middleware ...Middleware) ServerOption {
 
 // RouteGroup 表示路由组
 type RouteGroup interface {
-    GET(path string, handler ServerHandler)
-    POST(path string, handler ServerHandler)
-    PUT(path string, handler ServerHandler)
-    DELETE(path string, handler ServerHandler)
-    OPTIONS(path string, handler ServerHandler)
+    GET(path string, handler ServerHandler, opts ...RouteOption)
+    POST(path string, handler ServerHandler, opts ...RouteOption)
+    PUT(path string, handler ServerHandler, opts ...RouteOption)
+    DELETE(path string, handler ServerHandler, opts ...RouteOption)
+    OPTIONS(path string, handler ServerHandler, opts ...RouteOption)
     Group(prefix string) RouteGroup
+    // Use 添加只作用于组内此后注册的路由的中间件
+    Use(middleware Middleware)
 }
 
 // 路由组实现
 type routeGroup struct {
-    prefix string
-    server *Server
+    prefix     string
+    server     *Server
+    middleware []Middleware
+}
+
+// Use 添加组中间件，先添加的中间件在外层
+func (g *routeGroup) Use(middleware Middleware) {
+    g.middleware = append(g.middleware, middleware)
+}
+
+// wrap 用组中间件包装处理器
+func (g *routeGroup) wrap(handler ServerHandler) ServerHandler {
+    if len(g.middleware) == 0 {
+        return handler
+    }
+    var h http.Handler = http.HandlerFunc(handler)
+    for i := len(g.middleware) - 1; i >= 0; i-- {
+        h = g.middleware[i](h)
+    }
+    return h.ServeHTTP
 }
 
 // GET 在组内注册GET路由
-func (g *routeGroup) GET(path string, handler ServerHandler) {
-    g.server.GET(g.prefix+path, handler)
+func (g *routeGroup) GET(path string, handler ServerHandler, opts ...RouteOption) {
+    g.server.GET(g.prefix+path, g.wrap(handler), opts...)
 }
 
 // POST 在组内注册POST路由
-func (g *routeGroup) POST(path string, handler ServerHandler) {
-    g.server.POST(g.prefix+path, handler)
+func (g *routeGroup) POST(path string, handler ServerHandler, opts ...RouteOption) {
+    g.server.POST(g.prefix+path, g.wrap(handler), opts...)
 }
 
 // PUT 在组内注册PUT路由
-func (g *routeGroup) PUT(path string, handler ServerHandler) {
-    g.server.PUT(g.prefix+path, handler)
+func (g *routeGroup) PUT(path string, handler ServerHandler, opts ...RouteOption) {
+    g.server.PUT(g.prefix+path, g.wrap(handler), opts...)
 }
 
 // DELETE 在组内注册DELETE路由
-func (g *routeGroup) DELETE(path string, handler ServerHandler) {
-    g.server.DELETE(g.prefix+path, handler)
+func (g *routeGroup) DELETE(path string, handler ServerHandler, opts ...RouteOption) {
+    g.server.DELETE(g.prefix+path, g.wrap(handler), opts...)
 }
 
 // OPTIONS 在组内注册OPTIONS路由
-func (g *routeGroup) OPTIONS(path string, handler ServerHandler) {
-    g.server.OPTIONS(g.prefix+path, handler)
+func (g *routeGroup) OPTIONS(path string, handler ServerHandler, opts ...RouteOption) {
+    g.server.OPTIONS(g.prefix+path, g.wrap(handler), opts...)
 }
 
 // Group 创建子路由组
 func (g *routeGroup) Group(prefix string) RouteGroup {
     return &routeGroup{
-        prefix: g.prefix + prefix,
-        server: g.server,
+        prefix:     g.prefix + prefix,
+        server:     g.server,
+        middleware: append([]Middleware(nil), g.middleware...),
     }
 }
\ No newline at end of file
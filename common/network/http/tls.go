@@ -0,0 +1,180 @@
+package http
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+)
+
+// DefaultCertReloadInterval 检查证书文件是否变化的默认间隔
+const DefaultCertReloadInterval = 30 * time.Second
+
+// fileStamp 文件的修改时间和大小，任一变化即视为文件已更新
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// CertReloader 从证书和私钥文件加载TLS证书，文件更新后自动重新加载。
+// 通过tls.Config.GetCertificate提供证书，新连接使用最新的证书，已建立的连接不受影响，监听器无需重启
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	logger   logging.Logger
+
+	cert atomic.Pointer[tls.Certificate]
+
+	mu        sync.Mutex // 保护文件状态和watching，避免并发重新加载
+	certStamp fileStamp
+	keyStamp  fileStamp
+
+	watching bool // 已启动后台检查，由mu保护
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewCertReloader 加载证书和私钥，文件不存在或不匹配时返回错误；logger可以为nil
+func NewCertReloader(certFile, keyFile string, logger logging.Logger) (*CertReloader, error) {
+	r := &CertReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		logger:   logger,
+		stopCh:   make(chan struct{}),
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate 返回当前证书，用作tls.Config.GetCertificate
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// TLSConfig 返回使用当前证书的TLS配置
+func (r *CertReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		GetCertificate: r.GetCertificate,
+		MinVersion:     tls.VersionTLS12,
+	}
+}
+
+// Reload 重新读取证书和私钥文件，失败时继续使用原有证书
+func (r *CertReloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	certStamp, keyStamp, err := r.stamps()
+	if err != nil {
+		return err
+	}
+	return r.loadLocked(certStamp, keyStamp)
+}
+
+// ReloadIfChanged 证书或私钥文件的修改时间或大小变化时重新加载，返回是否加载了新证书
+func (r *CertReloader) ReloadIfChanged() (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	certStamp, keyStamp, err := r.stamps()
+	if err != nil {
+		return false, err
+	}
+	if certStamp == r.certStamp && keyStamp == r.keyStamp {
+		return false, nil
+	}
+	if err := r.loadLocked(certStamp, keyStamp); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// loadLocked 加载证书并记录文件状态，调用方需持有锁。
+// 加载失败（如证书已替换而私钥尚未替换）时不记录状态，下次检查时重试
+func (r *CertReloader) loadLocked(certStamp, keyStamp fileStamp) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("加载TLS证书失败: %w", err)
+	}
+	r.cert.Store(&cert)
+	r.certStamp = certStamp
+	r.keyStamp = keyStamp
+	return nil
+}
+
+// stamps 读取证书和私钥文件的当前状态
+func (r *CertReloader) stamps() (fileStamp, fileStamp, error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return fileStamp{}, fileStamp{}, fmt.Errorf("读取证书文件失败: %w", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return fileStamp{}, fileStamp{}, fmt.Errorf("读取私钥文件失败: %w", err)
+	}
+	return fileStamp{certInfo.ModTime(), certInfo.Size()}, fileStamp{keyInfo.ModTime(), keyInfo.Size()}, nil
+}
+
+// Watch 启动后台检查，每隔interval检查一次文件是否变化，interval不大于0时使用DefaultCertReloadInterval。
+// 重复调用无效，调用Stop停止检查
+func (r *CertReloader) Watch(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultCertReloadInterval
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	select {
+	case <-r.stopCh:
+		return
+	default:
+	}
+	if r.watching {
+		return
+	}
+	r.watching = true
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.stopCh:
+				return
+			case <-ticker.C:
+				reloaded, err := r.ReloadIfChanged()
+				if r.logger == nil {
+					continue
+				}
+				if err != nil {
+					r.logger.Warn("TLS证书重新加载失败，继续使用原有证书", "cert_file", r.certFile, "error", err)
+				} else if reloaded {
+					r.logger.Info("TLS证书已重新加载", "cert_file", r.certFile)
+				}
+			}
+		}
+	}()
+}
+
+// Stop 停止后台检查并等待其退出
+func (r *CertReloader) Stop() {
+	r.stopOnce.Do(func() { close(r.stopCh) })
+	r.wg.Wait()
+}
+
+// WithTLS 使用证书加载器提供HTTPS服务，证书更新后新连接立即使用新证书
+func WithTLS(reloader *CertReloader) ServerOption {
+	return func(s *Server) {
+		s.tlsConfig = reloader.TLSConfig()
+	}
+}
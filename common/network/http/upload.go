@@ -0,0 +1,222 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// DefaultUploadMaxMemory 上传请求中普通表单字段可占用内存的默认上限，文件内容不计入
+const DefaultUploadMaxMemory int64 = 1 << 20
+
+// DefaultUploadIdleTimeout Upload路由两次收到请求体数据之间的默认最长等待时间
+const DefaultUploadIdleTimeout = 30 * time.Second
+
+var (
+	// ErrFormTooLarge 普通表单字段累计超过内存上限
+	ErrFormTooLarge = errors.New("表单字段超过内存上限")
+	// ErrInvalidMultipart 请求体不是有效的multipart格式
+	ErrInvalidMultipart = errors.New("无效的multipart请求体")
+)
+
+// UploadPart 上传请求中的一个文件part
+type UploadPart struct {
+	FieldName   string               // 表单字段名
+	FileName    string               // 客户端提供的文件名
+	ContentType string               // part的内容类型
+	Size        int64                // 客户端声明的大小（part的Content-Length头），未声明时为-1
+	Header      textproto.MIMEHeader // part的全部头部
+	Form        url.Values           // 请求中位于此part之前的普通表单字段，客户端应先发送字段再发送文件
+}
+
+// PartSink 接收文件part的内容，r只在调用期间有效，未读完的内容被跳过
+type PartSink func(part *UploadPart, r io.Reader) error
+
+// UploadHandler 逐个处理上传请求中的文件part
+type UploadHandler func(c *Context, part *UploadPart, r io.Reader) error
+
+// UploadedFile 上传完成后返回给客户端的文件信息
+type UploadedFile struct {
+	FieldName string `json:"field"`
+	FileName  string `json:"filename,omitempty"`
+	Size      int64  `json:"size"` // 处理器实际读取的字节数
+}
+
+// ParseMultipart 按顺序读取multipart请求中的文件part并交给sink，文件内容直接从连接流式读取，不在内存中缓冲。
+// 普通表单字段（没有文件名的part）读入内存并作为返回值，累计超过maxMemory（不大于0时使用DefaultUploadMaxMemory）
+// 时返回ErrFormTooLarge；请求不是multipart时，整个请求体作为一个文件part交给sink，声明大小为请求的Content-Length
+func ParseMultipart(c *Context, maxMemory int64, sink PartSink) (url.Values, error) {
+	if maxMemory <= 0 {
+		maxMemory = DefaultUploadMaxMemory
+	}
+	r := c.Request
+	form := make(url.Values)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		part := &UploadPart{
+			ContentType: r.Header.Get("Content-Type"),
+			Size:        r.ContentLength,
+			Header:      textproto.MIMEHeader(r.Header),
+		}
+		return form, sink(part, r.Body)
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMultipart, err)
+	}
+
+	remaining := maxMemory
+	for {
+		p, err := reader.NextPart()
+		if err == io.EOF {
+			return form, nil
+		}
+		if err != nil {
+			return nil, multipartError(err)
+		}
+
+		if p.FileName() == "" {
+			// 多读一个字节以判断是否超过上限
+			value, err := io.ReadAll(io.LimitReader(p, remaining+1))
+			p.Close()
+			if err != nil {
+				return nil, multipartError(err)
+			}
+			remaining -= int64(len(value))
+			if remaining < 0 {
+				return nil, ErrFormTooLarge
+			}
+			form.Add(p.FormName(), string(value))
+			continue
+		}
+
+		part := newUploadPart(p)
+		part.Form = cloneValues(form)
+		err = sink(part, p)
+		p.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// newUploadPart 根据multipart part的头部构造UploadPart
+func newUploadPart(p *multipart.Part) *UploadPart {
+	size := int64(-1)
+	if value := p.Header.Get("Content-Length"); value != "" {
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil && n >= 0 {
+			size = n
+		}
+	}
+	return &UploadPart{
+		FieldName:   p.FormName(),
+		FileName:    p.FileName(),
+		ContentType: p.Header.Get("Content-Type"),
+		Size:        size,
+		Header:      p.Header,
+	}
+}
+
+// cloneValues 复制表单字段，交给sink的字段不受之后读取的字段影响
+func cloneValues(values url.Values) url.Values {
+	clone := make(url.Values, len(values))
+	for key, value := range values {
+		clone[key] = append([]string(nil), value...)
+	}
+	return clone
+}
+
+// multipartError 将读取multipart时的错误归类为请求体无效，请求体大小超限的错误原样返回
+func multipartError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return err
+	}
+	return fmt.Errorf("%w: %v", ErrInvalidMultipart, err)
+}
+
+// countingReader 统计读取的字节数
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// deadlineReader 每次读取前把连接的读取截止时间延后timeout，数据持续到达时读取不会超时
+type deadlineReader struct {
+	io.ReadCloser
+	rc      *http.ResponseController
+	timeout time.Duration
+}
+
+func (d *deadlineReader) Read(p []byte) (int, error) {
+	// 不支持设置截止时间的ResponseWriter（如测试中的记录器）没有连接超时，忽略错误
+	_ = d.rc.SetReadDeadline(time.Now().Add(d.timeout))
+	return d.ReadCloser.Read(p)
+}
+
+// Upload 注册流式上传的POST路由，handler按顺序收到每个文件part的数据流、声明的大小和之前的表单字段，
+// 文件内容不在内存中缓冲。上传不受服务器整体的读写超时限制，两次收到数据的间隔超过
+// WithUploadIdleTimeout（默认DefaultUploadIdleTimeout）时读取失败。全部part处理完成后返回201和各文件实际读取的字节数；
+// 请求体无效或被截断时返回400，表单字段或请求体超过上限时返回413，handler返回错误时返回500，错误详情只写入日志
+func (s *Server) Upload(path string, handler UploadHandler, opts ...RouteOption) {
+	docs := []RouteOption{WithSuccessStatus(http.StatusCreated), WithResponseType([]UploadedFile{}), WithoutResponseEnvelope()}
+	s.POST(path, s.serveUpload(handler), append(docs, opts...)...)
+}
+
+// serveUpload 将UploadHandler转换为ServerHandler
+func (s *Server) serveUpload(handler UploadHandler) ServerHandler {
+	return func(w http.ResponseWriter, r *http.Request) {
+		idle := s.uploadIdleTimeout
+		if idle <= 0 {
+			idle = DefaultUploadIdleTimeout
+		}
+		// 服务器的ReadTimeout和WriteTimeout从读取请求开始计时，大文件上传会被中断；
+		// 改为按读取间隔计时，响应写入不设截止时间
+		rc := http.NewResponseController(w)
+		_ = rc.SetWriteDeadline(time.Time{})
+		r.Body = &deadlineReader{ReadCloser: r.Body, rc: rc, timeout: idle}
+
+		c := &Context{Request: r, Response: w, Params: mux.Vars(r)}
+
+		files := []UploadedFile{}
+		_, err := ParseMultipart(c, DefaultUploadMaxMemory, func(part *UploadPart, body io.Reader) error {
+			counter := &countingReader{r: body}
+			err := handler(c, part, counter)
+			files = append(files, UploadedFile{FieldName: part.FieldName, FileName: part.FileName, Size: counter.n})
+			return err
+		})
+
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case err == nil:
+			RespondJSON(w, http.StatusCreated, files)
+		case errors.Is(err, ErrFormTooLarge), errors.As(err, &maxBytesErr):
+			RespondError(w, http.StatusRequestEntityTooLarge, err.Error())
+		case errors.Is(err, ErrInvalidMultipart), errors.Is(err, io.ErrUnexpectedEOF):
+			RespondError(w, http.StatusBadRequest, err.Error())
+		default:
+			if s.logger != nil {
+				s.logger.Error("处理上传失败 %s: %v", r.URL.Path, err)
+			}
+			RespondError(w, http.StatusInternalServerError, "处理上传失败")
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 常用节点标签键
+const (
+	LabelZone     = "zone"      // 可用区
+	LabelRack     = "rack"      // 机架
+	LabelDiskType = "disk-type" // 磁盘类型，如ssd、hdd
+	LabelTenant   = "tenant"    // 租户
+)
+
+// LabelOperator 标签选择器的匹配操作
+type LabelOperator string
+
+const (
+	LabelOpEquals    LabelOperator = "="  // 标签存在且值相等
+	LabelOpNotEquals LabelOperator = "!=" // 标签不存在或值不相等
+	LabelOpExists    LabelOperator = ""   // 标签存在
+)
+
+// LabelRequirement 单个标签匹配条件
+type LabelRequirement struct {
+	Key      string
+	Operator LabelOperator
+	Value    string
+}
+
+// Matches 判断标签集合是否满足条件
+func (r LabelRequirement) Matches(labels map[string]string) bool {
+	value, exists := labels[r.Key]
+	switch r.Operator {
+	case LabelOpEquals:
+		return exists && value == r.Value
+	case LabelOpNotEquals:
+		return !exists || value != r.Value
+	default:
+		return exists
+	}
+}
+
+// String 返回条件的字符串表示
+func (r LabelRequirement) String() string {
+	if r.Operator == LabelOpExists {
+		return r.Key
+	}
+	return r.Key + string(r.Operator) + r.Value
+}
+
+// LabelSelector 标签选择器，所有条件都满足才算匹配，空选择器匹配任意节点
+type LabelSelector []LabelRequirement
+
+// SelectorFromMap 根据键值对创建等值选择器
+func SelectorFromMap(labels map[string]string) LabelSelector {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	selector := make(LabelSelector, 0, len(keys))
+	for _, key := range keys {
+		selector = append(selector, LabelRequirement{Key: key, Operator: LabelOpEquals, Value: labels[key]})
+	}
+	return selector
+}
+
+// ParseLabelSelector 解析逗号分隔的选择器表达式，如"zone=a,disk-type!=hdd,tenant"
+func ParseLabelSelector(expr string) (LabelSelector, error) {
+	var selector LabelSelector
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		var req LabelRequirement
+		if idx := strings.Index(part, "!="); idx >= 0 {
+			req = LabelRequirement{Key: part[:idx], Operator: LabelOpNotEquals, Value: part[idx+2:]}
+		} else if idx := strings.Index(part, "="); idx >= 0 {
+			req = LabelRequirement{Key: part[:idx], Operator: LabelOpEquals, Value: part[idx+1:]}
+		} else {
+			req = LabelRequirement{Key: part, Operator: LabelOpExists}
+		}
+
+		req.Key = strings.TrimSpace(req.Key)
+		req.Value = strings.TrimSpace(req.Value)
+		if req.Key == "" {
+			return nil, fmt.Errorf("无效的标签选择器: %q", part)
+		}
+		selector = append(selector, req)
+	}
+	return selector, nil
+}
+
+// Matches 判断标签集合是否满足选择器的所有条件
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for _, req := range s {
+		if !req.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// String 返回选择器的字符串表示
+func (s LabelSelector) String() string {
+	parts := make([]string, len(s))
+	for i, req := range s {
+		parts[i] = req.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// FilterNodes 返回标签满足选择器的节点，供放置规划选择候选节点
+func (s LabelSelector) FilterNodes(nodes []NodeInfo) []NodeInfo {
+	result := make([]NodeInfo, 0, len(nodes))
+	for _, node := range nodes {
+		if s.Matches(node.Labels) {
+			result = append(result, node)
+		}
+	}
+	return result
+}
+
+// CloneLabels 复制标签集合
+func CloneLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+	clone := make(map[string]string, len(labels))
+	for k, v := range labels {
+		clone[k] = v
+	}
+	return clone
+}
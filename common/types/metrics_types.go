@@ -1,5 +1,7 @@
 package types
 
+import "fmt"
+
 // NodeMetrics 表示节点的性能和负载指标
 type NodeMetrics struct {
 	NodeID            NodeID  `json:"node_id"`             // 节点ID
@@ -14,6 +16,8 @@ type NodeMetrics struct {
 	LoadScore         float64 `json:"load_score"`          // 综合负载分数
 	IsHealthy         bool    `json:"is_healthy"`          // 节点是否健康
 	LastUpdated       int64   `json:"last_updated"`        // 最后更新时间戳
+
+	Labels map[string]string `json:"labels,omitempty"` // 节点上报的标签
 }
 
 // CalculateUsageRatio 计算并更新磁盘使用率
@@ -36,3 +40,20 @@ func (m *NodeMetrics) CalculateLoadScore() float64 {
 	m.LoadScore = diskScore*diskWeight + m.CPUUsagePercent*cpuWeight
 	return m.LoadScore
 }
+
+// Validate 检查指标取值是否合法
+func (m *NodeMetrics) Validate() error {
+	if m.DiskUsageRatio < 0 || m.DiskUsageRatio > 1 {
+		return fmt.Errorf("磁盘使用率必须在0-1之间: %v", m.DiskUsageRatio)
+	}
+	if m.CPUUsagePercent < 0 || m.CPUUsagePercent > 100 {
+		return fmt.Errorf("CPU使用率必须在0-100之间: %v", m.CPUUsagePercent)
+	}
+	if m.ShardCount < 0 {
+		return fmt.Errorf("分片数量不能为负: %d", m.ShardCount)
+	}
+	if m.DiskCapacityBytes > 0 && m.DiskUsageBytes > m.DiskCapacityBytes {
+		return fmt.Errorf("磁盘使用量%d超过总容量%d", m.DiskUsageBytes, m.DiskCapacityBytes)
+	}
+	return nil
+}
@@ -25,6 +25,8 @@ type NodeInfo struct {
 	JoinTime int64        `json:"join_time"`           // 加入集群的时间戳
 	LastSeen int64        `json:"last_seen,omitempty"` // 最后一次检测到的时间戳
 	Metrics  *NodeMetrics `json:"metrics"`             // 节点度量指标
+
+	Labels map[string]string `json:"labels,omitempty"` // 节点标签，如zone、disk-type、tenant
 }
 
 // String 返回字符串表示
@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// DefaultHashTreeWorkers 是HashTree默认的并发工作者数量
+var DefaultHashTreeWorkers = runtime.NumCPU()
+
+// HashTreeOption 定义HashTree的可选配置
+type HashTreeOption func(*hashTreeOptions)
+
+type hashTreeOptions struct {
+	workers int
+	open    func(path string) (io.ReadCloser, error)
+}
+
+// WithHashWorkers 设置同时计算哈希的最大文件数
+func WithHashWorkers(n int) HashTreeOption {
+	return func(o *hashTreeOptions) {
+		if n > 0 {
+			o.workers = n
+		}
+	}
+}
+
+// WithFileOpener 设置打开文件的函数，默认使用os.Open
+// 主要用于测试或从非本地文件系统读取数据
+func WithFileOpener(open func(path string) (io.ReadCloser, error)) HashTreeOption {
+	return func(o *hashTreeOptions) {
+		if open != nil {
+			o.open = open
+		}
+	}
+}
+
+// HashTree 遍历目录树并计算每个普通文件的哈希值
+// 返回以相对于root的路径（使用"/"分隔）为键的哈希映射
+// 使用固定大小的工作池限制并发，ctx取消时会尽快中止并返回ctx.Err()
+func HashTree(ctx context.Context, root string, hashType HashType, opts ...HashTreeOption) (map[string]string, error) {
+	options := &hashTreeOptions{
+		workers: DefaultHashTreeWorkers,
+		open: func(path string) (io.ReadCloser, error) {
+			return os.Open(path)
+		},
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.workers <= 0 {
+		options.workers = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		results  = make(map[string]string)
+		firstErr error
+	)
+	setErr := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+		cancel()
+	}
+
+	paths := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < options.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				sum, err := hashTreeFile(ctx, path, hashType, options.open)
+				if err != nil {
+					setErr(err)
+					continue
+				}
+
+				rel, err := filepath.Rel(root, path)
+				if err != nil {
+					setErr(err)
+					continue
+				}
+
+				mu.Lock()
+				results[filepath.ToSlash(rel)] = sum
+				mu.Unlock()
+			}
+		}()
+	}
+
+	walkErr := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		select {
+		case paths <- path:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	close(paths)
+	wg.Wait()
+
+	// 工作者的错误优先于遍历因取消而返回的错误
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if walkErr != nil {
+		return nil, walkErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// hashTreeFile 计算单个文件的哈希，读取过程中响应ctx取消
+func hashTreeFile(ctx context.Context, path string, hashType HashType, open func(string) (io.ReadCloser, error)) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	file, err := open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hasher := GetHasher(hashType)
+	if _, err := io.Copy(hasher, &ctxReader{ctx: ctx, r: file}); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// ctxReader 在每次读取前检查ctx，使大文件的哈希计算可以被中途取消
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
@@ -0,0 +1,281 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// 集群配置的默认值，与字段default标签保持一致，供直接构造配置的调用方使用
+const (
+	DefaultElectionTimeout             = 2 * time.Second
+	DefaultHeartbeatTimeout            = 500 * time.Millisecond
+	DefaultHeartbeatInterval           = 1 * time.Second
+	DefaultSuspectTimeout              = 3 * time.Second
+	DefaultDeadTimeout                 = 10 * time.Second
+	DefaultCleanupInterval             = 30 * time.Second
+	DefaultLeaderGracePeriod           = 10 * time.Second
+	DefaultDiscoveryTimeout            = 10 * time.Second
+	DefaultPeerRefreshInterval         = 30 * time.Second
+	DefaultRebalanceEvaluationInterval = 5 * time.Minute
+	DefaultImbalanceThreshold          = 20.0
+	DefaultImbalanceStopRatio          = 0.8
+	DefaultMaxConcurrentMigrations     = 5
+	DefaultMigrationTimeout            = 2 * time.Hour
+	DefaultEventHistorySize            = 64
+	DefaultEventBatchSize              = 100
+	DefaultStatusCacheTTL              = 30 * time.Second
+	DefaultSubsystemStopTimeout        = 5 * time.Second
+	DefaultMetricsHistorySize          = 360
+	DefaultFailureDetector             = FailureDetectorFixed
+	DefaultPhiSuspectThreshold         = 8.0
+	DefaultPhiDeadThreshold            = 16.0
+	DefaultPhiMinStdDeviation          = 500 * time.Millisecond
+	DefaultPhiWindowSize               = 100
+)
+
+// 节点故障检测方式
+const (
+	FailureDetectorPhi   = "phi"   // 按心跳到达间隔的分布计算怀疑度phi，适应网络延迟的波动
+	FailureDetectorFixed = "fixed" // 按SuspectTimeout和DeadTimeout固定超时判定，与旧版本行为一致
+)
+
+// ApplyDefaults 为未设置的集群配置项填充默认值
+// 零值本身有含义的配置项（如MaxClusterSize为0表示不限制、DeadNodeReapDelay为0表示3倍DeadTimeout）保持不变；
+// Peers保持为空，节点必须显式选择引导新集群或通过种子节点加入已有集群，见Validate
+func (c *ClusterConfig) ApplyDefaults() {
+	if c.ElectionTimeout == 0 {
+		c.ElectionTimeout = DefaultElectionTimeout
+	}
+	if c.HeartbeatTimeout == 0 {
+		c.HeartbeatTimeout = DefaultHeartbeatTimeout
+	}
+	if c.DiscoveryTimeout == 0 {
+		c.DiscoveryTimeout = DefaultDiscoveryTimeout
+	}
+	if c.PeerRefreshInterval == 0 {
+		c.PeerRefreshInterval = DefaultPeerRefreshInterval
+	}
+	if c.HeartbeatInterval == 0 {
+		c.HeartbeatInterval = DefaultHeartbeatInterval
+	}
+	if c.SuspectTimeout == 0 {
+		c.SuspectTimeout = DefaultSuspectTimeout
+	}
+	if c.DeadTimeout == 0 {
+		c.DeadTimeout = DefaultDeadTimeout
+	}
+	if c.CleanupInterval == 0 {
+		c.CleanupInterval = DefaultCleanupInterval
+	}
+	if c.FailureDetector == "" {
+		c.FailureDetector = DefaultFailureDetector
+	}
+	if c.PhiSuspectThreshold == 0 {
+		c.PhiSuspectThreshold = DefaultPhiSuspectThreshold
+	}
+	if c.PhiDeadThreshold == 0 {
+		c.PhiDeadThreshold = DefaultPhiDeadThreshold
+	}
+	if c.PhiMinStdDeviation == 0 {
+		c.PhiMinStdDeviation = DefaultPhiMinStdDeviation
+	}
+	if c.PhiWindowSize == 0 {
+		c.PhiWindowSize = DefaultPhiWindowSize
+	}
+	if c.LeaderGracePeriod == 0 {
+		c.LeaderGracePeriod = DefaultLeaderGracePeriod
+	}
+	if c.RebalanceEvaluationInterval == 0 {
+		c.RebalanceEvaluationInterval = DefaultRebalanceEvaluationInterval
+	}
+	if c.ImbalanceThreshold == 0 {
+		c.ImbalanceThreshold = DefaultImbalanceThreshold
+	}
+	if c.ImbalanceStopRatio == 0 {
+		c.ImbalanceStopRatio = DefaultImbalanceStopRatio
+	}
+	if c.MaxConcurrentMigrations == 0 {
+		c.MaxConcurrentMigrations = DefaultMaxConcurrentMigrations
+	}
+	if c.MigrationTimeout == 0 {
+		c.MigrationTimeout = DefaultMigrationTimeout
+	}
+	if c.EventHistorySize == 0 {
+		c.EventHistorySize = DefaultEventHistorySize
+	}
+	if c.EventBatchSize == 0 {
+		c.EventBatchSize = DefaultEventBatchSize
+	}
+	if c.StatusCacheTTL == 0 {
+		c.StatusCacheTTL = DefaultStatusCacheTTL
+	}
+	if c.MetricsHistorySize == 0 {
+		c.MetricsHistorySize = DefaultMetricsHistorySize
+	}
+	if c.SubsystemStopTimeout == 0 {
+		c.SubsystemStopTimeout = DefaultSubsystemStopTimeout
+	}
+}
+
+// RebalanceEnabled 返回是否启动负载均衡管理器，EnableRebalance未设置时启用
+func (c *ClusterConfig) RebalanceEnabled() bool {
+	return c.EnableRebalance == nil || *c.EnableRebalance
+}
+
+// HeartbeatReaperEnabled 返回是否清理长期死亡的节点，EnableHeartbeatReaper未设置时启用
+func (c *ClusterConfig) HeartbeatReaperEnabled() bool {
+	return c.EnableHeartbeatReaper == nil || *c.EnableHeartbeatReaper
+}
+
+// Validate 检查集群配置的取值范围和字段之间的约束，应在ApplyDefaults之后调用
+func (c *ClusterConfig) Validate() error {
+	if c.NodeID == "" {
+		return fmt.Errorf("节点ID不能为空")
+	}
+	// 加入中的节点若默认引导单节点集群，会独自成为领导者而形成另一个集群
+	if len(c.Peers) == 0 && len(c.SeedPeers) == 0 && !c.Bootstrap {
+		return fmt.Errorf("必须配置peers、seed_peers之一，或设置bootstrap以单节点集群引导")
+	}
+	if c.Bootstrap && (len(c.Peers) > 0 || len(c.SeedPeers) > 0) {
+		return fmt.Errorf("bootstrap不能与peers或seed_peers同时配置")
+	}
+	// 加入请求携带本节点地址，领导者据此拒绝重复的节点ID
+	if len(c.SeedPeers) > 0 && c.NodeAddress == "" {
+		return fmt.Errorf("配置seed_peers时必须配置node_address")
+	}
+	if len(c.PeerAddresses) > 0 && len(c.PeerAddresses) != len(c.Peers) {
+		return fmt.Errorf("peer_addresses数量(%d)必须与peers数量(%d)一致", len(c.PeerAddresses), len(c.Peers))
+	}
+	if c.MaxClusterSize < 0 {
+		return fmt.Errorf("max_cluster_size不能为负数: %d", c.MaxClusterSize)
+	}
+	if c.MaxClusterSize > 0 && len(c.Peers) > c.MaxClusterSize {
+		return fmt.Errorf("peers数量(%d)超过max_cluster_size(%d)", len(c.Peers), c.MaxClusterSize)
+	}
+
+	positive := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"election_timeout", c.ElectionTimeout},
+		{"heartbeat_timeout", c.HeartbeatTimeout},
+		{"discovery_timeout", c.DiscoveryTimeout},
+		{"peer_refresh_interval", c.PeerRefreshInterval},
+		{"heartbeat_interval", c.HeartbeatInterval},
+		{"suspect_timeout", c.SuspectTimeout},
+		{"dead_timeout", c.DeadTimeout},
+		{"cleanup_interval", c.CleanupInterval},
+		{"rebalance_eval_interval", c.RebalanceEvaluationInterval},
+		{"migration_timeout", c.MigrationTimeout},
+		{"subsystem_stop_timeout", c.SubsystemStopTimeout},
+	}
+	for _, field := range positive {
+		if field.value <= 0 {
+			return fmt.Errorf("%s必须为正数: %v", field.name, field.value)
+		}
+	}
+
+	// Raft心跳间隔不小于选举超时会导致跟随者在两次心跳之间发起选举
+	if c.HeartbeatTimeout >= c.ElectionTimeout {
+		return fmt.Errorf("heartbeat_timeout(%v)必须小于election_timeout(%v)", c.HeartbeatTimeout, c.ElectionTimeout)
+	}
+	// 节点状态按 健康 -> 可疑 -> 死亡 依次判定，超时必须递增
+	if c.HeartbeatInterval >= c.SuspectTimeout {
+		return fmt.Errorf("heartbeat_interval(%v)必须小于suspect_timeout(%v)", c.HeartbeatInterval, c.SuspectTimeout)
+	}
+	if c.SuspectTimeout >= c.DeadTimeout {
+		return fmt.Errorf("suspect_timeout(%v)必须小于dead_timeout(%v)", c.SuspectTimeout, c.DeadTimeout)
+	}
+
+	if err := validateFailureDetector(c.FailureDetector, c.PhiSuspectThreshold, c.PhiDeadThreshold, c.PhiMinStdDeviation, c.PhiWindowSize); err != nil {
+		return err
+	}
+
+	if c.EventBatchWindow < 0 {
+		return fmt.Errorf("event_batch_window不能为负数: %v", c.EventBatchWindow)
+	}
+	if c.EventBatchSize < 0 {
+		return fmt.Errorf("event_batch_size不能为负数: %d", c.EventBatchSize)
+	}
+
+	if c.ImbalanceThreshold <= 0 {
+		return fmt.Errorf("imbalance_threshold必须为正数: %v", c.ImbalanceThreshold)
+	}
+	// 停止比例为0时停止阈值为0，不平衡度几乎不可能降到0，再平衡一旦启动就不会停止
+	if c.ImbalanceStopRatio <= 0 || c.ImbalanceStopRatio > 1 {
+		return fmt.Errorf("imbalance_stop_ratio必须在(0, 1]范围内: %v", c.ImbalanceStopRatio)
+	}
+	if c.RebalanceMinHealthyRatio < 0 || c.RebalanceMinHealthyRatio > 1 {
+		return fmt.Errorf("rebalance_min_healthy_ratio必须在[0, 1]范围内: %v", c.RebalanceMinHealthyRatio)
+	}
+	if c.RebalanceMaxPlans < 0 {
+		return fmt.Errorf("rebalance_max_plans不能为负数: %d", c.RebalanceMaxPlans)
+	}
+	if c.RebalanceMinNodes < 0 {
+		return fmt.Errorf("rebalance_min_nodes不能为负数: %d", c.RebalanceMinNodes)
+	}
+	if c.MaxConcurrentMigrations < 1 {
+		return fmt.Errorf("max_concurrent_migrations必须大于0: %d", c.MaxConcurrentMigrations)
+	}
+	return nil
+}
+
+// ApplyDefaults 为未设置的心跳配置项填充默认值
+func (c *HeartbeatConfig) ApplyDefaults() {
+	if c.HeartbeatInterval == 0 {
+		c.HeartbeatInterval = DefaultHeartbeatInterval
+	}
+	if c.SuspectTimeout == 0 {
+		c.SuspectTimeout = DefaultSuspectTimeout
+	}
+	if c.DeadTimeout == 0 {
+		c.DeadTimeout = DefaultDeadTimeout
+	}
+	if c.CleanupInterval == 0 {
+		c.CleanupInterval = DefaultCleanupInterval
+	}
+	if c.FailureDetector == "" {
+		c.FailureDetector = DefaultFailureDetector
+	}
+	if c.PhiSuspectThreshold == 0 {
+		c.PhiSuspectThreshold = DefaultPhiSuspectThreshold
+	}
+	if c.PhiDeadThreshold == 0 {
+		c.PhiDeadThreshold = DefaultPhiDeadThreshold
+	}
+	if c.PhiMinStdDeviation == 0 {
+		c.PhiMinStdDeviation = DefaultPhiMinStdDeviation
+	}
+	if c.PhiWindowSize == 0 {
+		c.PhiWindowSize = DefaultPhiWindowSize
+	}
+}
+
+// Validate 检查故障检测方式及phi模式的参数，应在ApplyDefaults之后调用
+func (c *HeartbeatConfig) Validate() error {
+	return validateFailureDetector(c.FailureDetector, c.PhiSuspectThreshold, c.PhiDeadThreshold, c.PhiMinStdDeviation, c.PhiWindowSize)
+}
+
+// validateFailureDetector 检查故障检测方式及phi模式的参数，fixed模式下不检查phi参数
+func validateFailureDetector(detector string, suspectThreshold, deadThreshold float64, minStdDeviation time.Duration, windowSize int) error {
+	switch detector {
+	case FailureDetectorFixed:
+		return nil
+	case FailureDetectorPhi:
+	default:
+		return fmt.Errorf("failure_detector必须为%s或%s: %q", FailureDetectorPhi, FailureDetectorFixed, detector)
+	}
+	if suspectThreshold <= 0 {
+		return fmt.Errorf("phi_suspect_threshold必须为正数: %v", suspectThreshold)
+	}
+	if suspectThreshold >= deadThreshold {
+		return fmt.Errorf("phi_suspect_threshold(%v)必须小于phi_dead_threshold(%v)", suspectThreshold, deadThreshold)
+	}
+	if minStdDeviation <= 0 {
+		return fmt.Errorf("phi_min_std_deviation必须为正数: %v", minStdDeviation)
+	}
+	if windowSize < 2 {
+		return fmt.Errorf("phi_window_size必须不小于2: %d", windowSize)
+	}
+	return nil
+}
@@ -8,11 +8,13 @@ import (
 
 // Config 元数据服务器配置
 type Config struct {
-	commonconfig.BaseConfig                // 嵌入基础配置
-	Database                DatabaseConfig `json:"database" yaml:"database"`
-	Cluster                 ClusterConfig  `json:"cluster" yaml:"cluster"`
-	Security                SecurityConfig `json:"security" yaml:"security"`
-	ShutdownTimeout         time.Duration  `json:"-" yaml:"-"` // 不从配置文件加载
+	commonconfig.BaseConfig                 // 嵌入基础配置
+	Database                DatabaseConfig  `json:"database" yaml:"database"`
+	Cluster                 ClusterConfig   `json:"cluster" yaml:"cluster"`
+	Security                SecurityConfig  `json:"security" yaml:"security"`
+	Placement               PlacementConfig `json:"placement" yaml:"placement"`
+	Files                   FilesConfig     `json:"files" yaml:"files"`
+	ShutdownTimeout         time.Duration   `json:"-" yaml:"-"` // 不从配置文件加载
 }
 
 // DatabaseConfig 数据库配置
@@ -32,24 +34,55 @@ type DatabaseConfig struct {
 // ClusterConfig 集群配置
 type ClusterConfig struct {
 	// 节点配置
-	NodeID string `json:"node_id" yaml:"node_id"`
+	NodeID string `json:"node_id" yaml:"node_id" env:"CLUSTER_NODE_ID"`
 	// 节点地址
-	NodeAddress string `json:"node_address" yaml:"node_address"`
+	NodeAddress string `json:"node_address" yaml:"node_address" env:"CLUSTER_NODE_ADDRESS"`
 
-	// 集群成员配置
-	Peers         []string          `json:"peers" yaml:"peers"`
-	PeerAddresses []string          `json:"peer_addresses" yaml:"peer_addresses"`
+	// 集群成员配置，通过环境变量设置时为逗号分隔的列表
+	Peers         []string          `json:"peers" yaml:"peers" env:"CLUSTER_PEERS"`
+	PeerAddresses []string          `json:"peer_addresses" yaml:"peer_addresses" env:"CLUSTER_PEER_ADDRESSES"`
 	PeerMap       map[string]string `json:"-" yaml:"-"`
+	// 以只包含本节点的单节点集群引导，Peers和SeedPeers都为空时必须显式设置
+	Bootstrap        bool          `json:"bootstrap" yaml:"bootstrap" env:"CLUSTER_BOOTSTRAP"`
+	// 种子节点API地址，配置后启动时通过种子节点发现集群成员并请求加入，无需在所有节点上维护Peers；
+	// 只配置种子节点时本节点以空成员配置启动，等待领导者把它加入集群，不会自行发起选举
+	SeedPeers        []string      `json:"seed_peers" yaml:"seed_peers" env:"CLUSTER_SEED_PEERS"`
+	DiscoveryTimeout time.Duration `json:"discovery_timeout" yaml:"discovery_timeout" default:"10s"`
+	// 通过DNS SRV记录解析节点地址，如"_raft._tcp.metaserver.default.svc.cluster.local"，
+	// 记录目标主机名的第一段为正整数时作为节点ID，为StatefulSet的"名称-序号"时节点ID为序号+1；
+	// 解析不到的节点使用静态配置的地址。为空时只使用静态配置
+	PeerSRVName string `json:"peer_srv_name" yaml:"peer_srv_name"`
+	// 重新解析PeerSRVName的间隔
+	PeerRefreshInterval time.Duration `json:"peer_refresh_interval" yaml:"peer_refresh_interval" default:"30s"`
+	// 节点之间传递Raft消息时携带的共享密钥，各节点必须相同；多节点集群必须配置，否则节点无法创建
+	ClusterSecret string `json:"cluster_secret" yaml:"cluster_secret" env:"CLUSTER_SECRET"`
+	// 集群成员数上限（含本节点），超过后拒绝新节点加入；0表示不限制
+	MaxClusterSize int `json:"max_cluster_size" yaml:"max_cluster_size" default:"9"`
 
 	// 选举配置
-	ElectionTimeout  time.Duration `json:"election_timeout" yaml:"election_timeout" default:"2s"`
-	HeartbeatTimeout time.Duration `json:"heartbeat_timeout" yaml:"heartbeat_timeout" default:"500ms"`
+	ElectionTimeout  time.Duration `json:"election_timeout" yaml:"election_timeout" env:"CLUSTER_ELECTION_TIMEOUT" default:"2s"`
+	HeartbeatTimeout time.Duration `json:"heartbeat_timeout" yaml:"heartbeat_timeout" env:"CLUSTER_HEARTBEAT_TIMEOUT" default:"500ms"`
 
 	// 心跳配置
-	HeartbeatInterval time.Duration `json:"heartbeat_interval" yaml:"heartbeat_interval" default:"1s"`
-	SuspectTimeout    time.Duration `json:"suspect_timeout" yaml:"suspect_timeout" default:"3s"`
-	DeadTimeout       time.Duration `json:"dead_timeout" yaml:"dead_timeout" default:"10s"`
+	HeartbeatInterval time.Duration `json:"heartbeat_interval" yaml:"heartbeat_interval" env:"CLUSTER_HEARTBEAT_INTERVAL" default:"1s"`
+	SuspectTimeout    time.Duration `json:"suspect_timeout" yaml:"suspect_timeout" env:"CLUSTER_SUSPECT_TIMEOUT" default:"3s"`
+	DeadTimeout       time.Duration `json:"dead_timeout" yaml:"dead_timeout" env:"CLUSTER_DEAD_TIMEOUT" default:"10s"`
 	CleanupInterval   time.Duration `json:"cleanup_interval" yaml:"cleanup_interval" default:"30s"`
+	// 节点死亡超过该时长后从心跳监控和Raft成员中永久移除，0表示3倍DeadTimeout，负数表示不移除
+	DeadNodeReapDelay time.Duration `json:"dead_node_reap_delay" yaml:"dead_node_reap_delay" default:"30s"`
+	// 成为领导者后的宽限期，期间不把节点判定为死亡或移除，等待与各节点的心跳建立；0使用默认值，负数表示不设宽限期
+	LeaderGracePeriod time.Duration `json:"leader_grace_period" yaml:"leader_grace_period" default:"10s"`
+	// 是否清理长期死亡的节点，为false时死亡节点一直保留在心跳监控和Raft成员中，需要手动移除；未设置时启用
+	EnableHeartbeatReaper *bool `json:"enable_heartbeat_reaper,omitempty" yaml:"enable_heartbeat_reaper,omitempty"`
+	// 节点故障检测方式：phi按心跳到达间隔的分布自适应判定，fixed按SuspectTimeout和DeadTimeout固定超时判定
+	FailureDetector string `json:"failure_detector" yaml:"failure_detector" default:"fixed"`
+	// phi模式下节点被判定为可疑和死亡的怀疑度阈值，phi每增加1，误判概率降低为原来的1/10
+	PhiSuspectThreshold float64 `json:"phi_suspect_threshold" yaml:"phi_suspect_threshold" default:"8"`
+	PhiDeadThreshold    float64 `json:"phi_dead_threshold" yaml:"phi_dead_threshold" default:"16"`
+	// phi模式下到达间隔标准差的下限，避免心跳非常稳定时轻微延迟就被判定为故障
+	PhiMinStdDeviation time.Duration `json:"phi_min_std_deviation" yaml:"phi_min_std_deviation" default:"500ms"`
+	// phi模式下每个节点保留的最近到达间隔数
+	PhiWindowSize int `json:"phi_window_size" yaml:"phi_window_size" default:"100"`
 
 	// 负载均衡配置
 	RebalanceEvaluationInterval time.Duration `json:"rebalance_eval_interval" yaml:"rebalance_eval_interval" default:"5m"`
@@ -57,6 +90,36 @@ type ClusterConfig struct {
 	MaxConcurrentMigrations     int           `json:"max_concurrent_migrations" yaml:"max_concurrent_migrations" default:"5"`
 	MinMigrationInterval        time.Duration `json:"min_migration_interval" yaml:"min_migration_interval" default:"30m"`
 	MigrationTimeout            time.Duration `json:"migration_timeout" yaml:"migration_timeout" default:"2h"`
+	RebalanceSlowStartWindow    time.Duration `json:"rebalance_slow_start_window" yaml:"rebalance_slow_start_window" default:"2m"`
+	RebalanceSlowStartRamp      time.Duration `json:"rebalance_slow_start_ramp" yaml:"rebalance_slow_start_ramp" default:"10m"`
+	RebalanceMinHealthyRatio    float64       `json:"rebalance_min_healthy_ratio" yaml:"rebalance_min_healthy_ratio" default:"0.8"`
+	RebalanceLeaderStability    time.Duration `json:"rebalance_leader_stability" yaml:"rebalance_leader_stability" default:"1m"`
+	RebalanceMetricsStaleness   time.Duration `json:"rebalance_metrics_staleness" yaml:"rebalance_metrics_staleness" default:"2m"`
+	ImbalanceStopRatio          float64       `json:"imbalance_stop_ratio" yaml:"imbalance_stop_ratio" default:"0.8"`
+	// 每轮评估最多生成的迁移计划数，0表示只受策略自身的节点对数限制
+	RebalanceMaxPlans int `json:"rebalance_max_plans" yaml:"rebalance_max_plans"`
+	// 参与评估的节点数少于该值时视为均衡，不进行任何再平衡；0表示不限制
+	RebalanceMinNodes int `json:"rebalance_min_nodes" yaml:"rebalance_min_nodes"`
+	// 负载均衡管理器启动失败时以降级模式继续运行（禁用再平衡），而不是让集群管理器启动失败
+	AllowDegradedRebalance bool `json:"allow_degraded_rebalance" yaml:"allow_degraded_rebalance" default:"true"`
+	// 是否启动负载均衡管理器，为false时节点只提供元数据服务，不评估也不执行数据迁移；未设置时启用
+	EnableRebalance *bool `json:"enable_rebalance,omitempty" yaml:"enable_rebalance,omitempty"`
+
+	// 保留的最近集群事件数，新订阅者先收到这些历史事件；0使用默认值，负数表示不保留
+	EventHistorySize int `json:"event_history_size" yaml:"event_history_size" default:"64"`
+	// 事件合并窗口，窗口内发生的多个事件合并为一个batch事件分发给订阅者，减少高频状态变化时的逐条开销；0表示不合并
+	EventBatchWindow time.Duration `json:"event_batch_window" yaml:"event_batch_window" default:"0s"`
+	// 单个batch事件包含的最大事件数，达到后立即分发而不等待窗口结束；0使用默认值
+	EventBatchSize int `json:"event_batch_size" yaml:"event_batch_size" default:"100"`
+
+	// 无法获取实时集群状态（领导者未知或获取节点失败）时，最近一次完整快照的最长使用时间；0使用默认值，负数表示不缓存
+	StatusCacheTTL time.Duration `json:"status_cache_ttl" yaml:"status_cache_ttl" default:"30s"`
+
+	// 每个节点保留的最近指标样本数，用于查看容量趋势；0使用默认值，负数表示不保留
+	MetricsHistorySize int `json:"metrics_history_size" yaml:"metrics_history_size" default:"360"`
+
+	// 停止时等待每个子系统（负载均衡、选举、心跳管理器）退出的最长时间，超时后放弃等待并继续停止其余子系统
+	SubsystemStopTimeout time.Duration `json:"subsystem_stop_timeout" yaml:"subsystem_stop_timeout" default:"5s"`
 }
 
 // HeartbeatConfig 心跳管理器配置
@@ -66,6 +129,16 @@ type HeartbeatConfig struct {
 	SuspectTimeout    time.Duration `json:"suspect_timeout" yaml:"suspect_timeout" default:"3s"`
 	DeadTimeout       time.Duration `json:"dead_timeout" yaml:"dead_timeout" default:"10s"`
 	CleanupInterval   time.Duration `json:"cleanup_interval" yaml:"cleanup_interval" default:"30s"`
+	// 节点死亡超过该时长后被永久移除，0表示3倍DeadTimeout，负数表示不移除
+	DeadNodeReapDelay time.Duration `json:"dead_node_reap_delay" yaml:"dead_node_reap_delay" default:"30s"`
+	// 节点ID -> 地址(host:port)，未指定解析器时用于确定心跳目标的地址，与ClusterConfig.PeerMap相同
+	PeerMap map[string]string `json:"-" yaml:"-"`
+	// 节点故障检测方式及phi模式的参数，含义与ClusterConfig中的同名字段相同
+	FailureDetector     string        `json:"failure_detector" yaml:"failure_detector" default:"fixed"`
+	PhiSuspectThreshold float64       `json:"phi_suspect_threshold" yaml:"phi_suspect_threshold" default:"8"`
+	PhiDeadThreshold    float64       `json:"phi_dead_threshold" yaml:"phi_dead_threshold" default:"16"`
+	PhiMinStdDeviation  time.Duration `json:"phi_min_std_deviation" yaml:"phi_min_std_deviation" default:"500ms"`
+	PhiWindowSize       int           `json:"phi_window_size" yaml:"phi_window_size" default:"100"`
 }
 
 // LoadBalancerConfig 负载均衡管理器配置
@@ -75,6 +148,28 @@ type LoadBalancerConfig struct {
 	MaxConcurrentMigrations int           `json:"max_concurrent_migrations" yaml:"max_concurrent_migrations" default:"5"`
 	MinMigrationInterval    time.Duration `json:"min_migration_interval" yaml:"min_migration_interval" default:"30m"`
 	MigrationTimeout        time.Duration `json:"migration_timeout" yaml:"migration_timeout" default:"2h"`
+	// 集群形成后的慢启动窗口，窗口内不生成迁移计划
+	SlowStartWindow time.Duration `json:"slow_start_window" yaml:"slow_start_window" default:"2m"`
+	// 窗口结束后并发迁移数从1逐步提升到MaxConcurrentMigrations所用的时间
+	SlowStartRamp time.Duration `json:"slow_start_ramp" yaml:"slow_start_ramp" default:"10m"`
+	// 允许开始迁移的最低健康节点比例（0-1）
+	MinHealthyRatio float64 `json:"min_healthy_ratio" yaml:"min_healthy_ratio" default:"0.8"`
+	// 领导者变更后禁止迁移的时长
+	LeaderStabilityPeriod time.Duration `json:"leader_stability_period" yaml:"leader_stability_period" default:"1m"`
+	// 按策略名称覆盖不平衡阈值，如weighted_score、capacity、access_frequency
+	StrategyThresholds map[string]float64 `json:"strategy_thresholds" yaml:"strategy_thresholds"`
+	// 迁移只在这些标签取值相同的节点之间进行，如["disk-type"]使SSD分片只迁移到SSD节点
+	LabelGroupKeys []string `json:"label_group_keys" yaml:"label_group_keys"`
+	// 迁移目标节点必须满足的标签选择器，如"tenant=shared,zone!=maintenance"
+	TargetSelector string `json:"target_selector" yaml:"target_selector"`
+	// 节点指标超过该时长未更新即视为过期，不参与评估和迁移规划；0表示不检查
+	MetricsStalenessWindow time.Duration `json:"metrics_staleness_window" yaml:"metrics_staleness_window" default:"2m"`
+	// 停止阈值与启动阈值之比（0-1]，再平衡开始后不平衡度低于"阈值×该比例"才视为恢复均衡；1表示不启用迟滞
+	ImbalanceStopRatio float64 `json:"imbalance_stop_ratio" yaml:"imbalance_stop_ratio" default:"0.8"`
+	// 每轮评估最多生成的迁移计划数，0表示只受策略自身的节点对数限制
+	MaxPlans int `json:"max_plans" yaml:"max_plans"`
+	// 参与评估的节点数少于该值时视为均衡，不进行任何再平衡；0表示不限制
+	MinNodesForRebalance int `json:"min_nodes_for_rebalance" yaml:"min_nodes_for_rebalance"`
 }
 
 // SecurityConfig 安全配置
@@ -82,7 +177,29 @@ type SecurityConfig struct {
 	EnableTLS   bool          `json:"enable_tls" yaml:"enable_tls" default:"false"`
 	CertFile    string        `json:"cert_file" yaml:"cert_file"`
 	KeyFile     string        `json:"key_file" yaml:"key_file"`
+	// 检查证书和私钥文件是否更新的间隔，更新后新连接使用新证书，无需重启；0使用默认值30秒
+	CertReloadInterval time.Duration `json:"cert_reload_interval" yaml:"cert_reload_interval" default:"30s"`
 	EnableAuth  bool          `json:"enable_auth" yaml:"enable_auth" default:"false"`
 	TokenExpiry time.Duration `json:"token_expiry" yaml:"token_expiry" default:"24h"`
 	JWTSecret   string        `json:"jwt_secret" yaml:"jwt_secret"`
+	// 启用基于permissions表的目录访问控制，授予目录的权限对整个子树生效
+	EnableACL bool `json:"enable_acl" yaml:"enable_acl" default:"false"`
+	// 拥有管理员角色的用户名，可以访问/api/v1/admin/下的用户管理接口
+	AdminUsers []string `json:"admin_users" yaml:"admin_users"`
+	// 关闭为响应注入安全响应头（X-Content-Type-Options、X-Frame-Options等）
+	DisableSecurityHeaders bool `json:"disable_security_headers" yaml:"disable_security_headers" default:"false"`
+	// 追加或替换默认的安全响应头，值为空表示不注入该头
+	SecurityHeaders map[string]string `json:"security_headers" yaml:"security_headers"`
+	// 启用TLS时Strict-Transport-Security的max-age
+	HSTSMaxAge time.Duration `json:"hsts_max_age" yaml:"hsts_max_age" default:"8760h"`
+	// 注册/api/v1/admin/debug/dump诊断接口，输出goroutine栈、锁占用和Raft状态，只有管理员可以访问
+	EnableDebugDump bool `json:"enable_debug_dump" yaml:"enable_debug_dump" default:"false"`
+}
+
+// FilesConfig 文件元数据配置
+type FilesConfig struct {
+	// 创建文件时客户端未提供mime_type则根据文件扩展名推断，无法推断时为application/octet-stream
+	InferMimeType bool `json:"infer_mime_type" yaml:"infer_mime_type" default:"false"`
+	// 单次列出目录返回的最大条目数，请求不限制数量时同样生效，超出部分被截断
+	MaxListEntries int `json:"max_list_entries" yaml:"max_list_entries" default:"10000"`
 }
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"time"
 
 	commonconfig "github.com/22827099/DFS_v1/common/config"
@@ -28,6 +29,17 @@ func LoadMetaServerConfig(path string) (*Config, error) {
 
 // 特定的验证函数
 func validateMetaServerConfig(config *Config) error {
-	// 元数据服务器特定的验证...
+	// 集群节点ID未单独配置时使用节点标识
+	if config.Cluster.NodeID == "" {
+		config.Cluster.NodeID = string(config.Node.NodeID)
+	}
+	config.Cluster.ApplyDefaults()
+	if err := config.Cluster.Validate(); err != nil {
+		return fmt.Errorf("集群配置无效: %w", err)
+	}
+	config.Placement.ApplyDefaults()
+	if err := config.Placement.Validate(); err != nil {
+		return fmt.Errorf("放置配置无效: %w", err)
+	}
 	return nil
 }
@@ -0,0 +1,51 @@
+package config
+
+import "fmt"
+
+// 数据块放置策略名称
+const (
+	PlacementRoundRobin = "round_robin" // 按节点ID顺序轮流放置
+	PlacementLeastUsed  = "least_used"  // 优先放在磁盘使用率最低的节点
+	PlacementRackAware  = "rack_aware"  // 按一致性哈希环放置，副本尽量分布在不同机架
+)
+
+// DefaultPlacementVirtualNodes 机架感知策略中每个节点在哈希环上的虚拟节点数
+const DefaultPlacementVirtualNodes = 64
+
+// PlacementConfig 数据块放置配置，决定登记文件的数据块时各副本所在的节点
+type PlacementConfig struct {
+	Strategy string `json:"strategy" yaml:"strategy" default:"round_robin"`
+	// 每个节点在哈希环上的虚拟节点数，仅rack_aware使用；越大分布越均匀
+	VirtualNodes int `json:"virtual_nodes" yaml:"virtual_nodes" default:"64"`
+	// 文件未指定副本数时使用的默认值
+	DefaultReplicas int `json:"default_replicas" yaml:"default_replicas" default:"3"`
+}
+
+// ApplyDefaults 为未设置的放置配置项填充默认值
+func (c *PlacementConfig) ApplyDefaults() {
+	if c.Strategy == "" {
+		c.Strategy = PlacementRoundRobin
+	}
+	if c.VirtualNodes == 0 {
+		c.VirtualNodes = DefaultPlacementVirtualNodes
+	}
+	if c.DefaultReplicas == 0 {
+		c.DefaultReplicas = 3
+	}
+}
+
+// Validate 检查放置配置，应在ApplyDefaults之后调用
+func (c *PlacementConfig) Validate() error {
+	switch c.Strategy {
+	case PlacementRoundRobin, PlacementLeastUsed, PlacementRackAware:
+	default:
+		return fmt.Errorf("未知的放置策略: %q", c.Strategy)
+	}
+	if c.VirtualNodes < 1 {
+		return fmt.Errorf("virtual_nodes必须为正数: %d", c.VirtualNodes)
+	}
+	if c.DefaultReplicas < 1 {
+		return fmt.Errorf("default_replicas必须为正数: %d", c.DefaultReplicas)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/22827099/DFS_v1/common/logging"
+)
+
+// 可在运行时安全重载的配置项，其余配置项变更后需要重启才能生效
+const (
+	reloadLogLevel           = "logging.level"
+	reloadImbalanceThreshold = "cluster.imbalance_threshold"
+	reloadImbalanceStopRatio = "cluster.imbalance_stop_ratio"
+	reloadSuspectTimeout     = "cluster.suspect_timeout"
+	reloadDeadTimeout        = "cluster.dead_timeout"
+)
+
+var reloadableFields = map[string]bool{
+	reloadLogLevel:           true,
+	reloadImbalanceThreshold: true,
+	reloadImbalanceStopRatio: true,
+	reloadSuspectTimeout:     true,
+	reloadDeadTimeout:        true,
+}
+
+// ClusterReloader 支持运行时应用集群配置的组件，由集群管理器实现
+type ClusterReloader interface {
+	ReloadConfig(cfg ClusterConfig)
+}
+
+// ReloadResult 一次配置重载的结果，配置项以JSON路径表示，如cluster.imbalance_threshold
+type ReloadResult struct {
+	Applied         []string // 已在运行时生效的配置项
+	RestartRequired []string // 已变更但需要重启才能生效的配置项
+}
+
+// Changed 返回新配置是否有任何变更
+func (r ReloadResult) Changed() bool {
+	return len(r.Applied) > 0 || len(r.RestartRequired) > 0
+}
+
+// Reload 比较当前配置与新配置，将可安全重载的配置项（日志级别、负载均衡阈值、心跳超时）
+// 应用到运行中的组件并写回current；其余变更只在结果中报告，current保持不变。
+// cluster为nil时只重载日志级别
+func Reload(current, next *Config, cluster ClusterReloader) ReloadResult {
+	var result ReloadResult
+	var changed []string
+	diffFields("", reflect.ValueOf(current).Elem(), reflect.ValueOf(next).Elem(), &changed)
+
+	clusterChanged := false
+	for _, field := range changed {
+		if !reloadableFields[field] || (cluster == nil && strings.HasPrefix(field, "cluster.")) {
+			result.RestartRequired = append(result.RestartRequired, field)
+			continue
+		}
+		result.Applied = append(result.Applied, field)
+
+		switch field {
+		case reloadLogLevel:
+			current.Logging.Level = next.Logging.Level
+			logging.SetGlobalLevel(logging.StringToLevel(next.Logging.Level))
+		case reloadImbalanceThreshold:
+			current.Cluster.ImbalanceThreshold = next.Cluster.ImbalanceThreshold
+			clusterChanged = true
+		case reloadImbalanceStopRatio:
+			current.Cluster.ImbalanceStopRatio = next.Cluster.ImbalanceStopRatio
+			clusterChanged = true
+		case reloadSuspectTimeout:
+			current.Cluster.SuspectTimeout = next.Cluster.SuspectTimeout
+			clusterChanged = true
+		case reloadDeadTimeout:
+			current.Cluster.DeadTimeout = next.Cluster.DeadTimeout
+			clusterChanged = true
+		}
+	}
+
+	if clusterChanged {
+		cluster.ReloadConfig(current.Cluster)
+	}
+	return result
+}
+
+// diffFields 递归比较两个配置结构体，以JSON路径记录取值不同的字段
+// 嵌入的结构体不增加路径前缀，json:"-"的字段不参与比较
+func diffFields(prefix string, a, b reflect.Value, changed *[]string) {
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		av, bv := a.Field(i), b.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			childPrefix := prefix + name + "."
+			if field.Anonymous {
+				childPrefix = prefix
+			}
+			diffFields(childPrefix, av, bv, changed)
+			continue
+		}
+		if !reflect.DeepEqual(av.Interface(), bv.Interface()) {
+			*changed = append(*changed, prefix+name)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package cluster
+
+import (
+	"context"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+)
+
+// defaultDebugTimeout ctx没有截止时间时，诊断信息中每个需要等待其他组件的部分最多等待的时间
+const defaultDebugTimeout = 2 * time.Second
+
+// raftStatusReporter 能报告底层Raft状态的选举管理器，由election.Manager实现
+type raftStatusReporter interface {
+	RaftStatus() raft.Status
+}
+
+// DebugState 返回用于排查卡死问题的内部状态：各把锁当前是否被占用、集群状态、Raft状态和未完成的迁移任务。
+// 只尝试获取锁而不等待，被占用的锁对应的状态标记为不可用；Raft状态和迁移任务在ctx结束
+// （没有截止时间时最多defaultDebugTimeout）前未返回时标记为超时，因此在死锁期间调用也不会阻塞
+func (m *ClusterManager) DebugState(ctx context.Context) map[string]interface{} {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultDebugTimeout)
+		defer cancel()
+	}
+
+	locks := map[string]bool{}
+	result := map[string]interface{}{"locks": locks}
+
+	locks["membership_mu"] = !m.membershipMu.TryLock()
+	if !locks["membership_mu"] {
+		m.membershipMu.Unlock()
+	}
+
+	locks["state_mu"] = !m.state.mu.TryRLock()
+	if locks["state_mu"] {
+		result["state"] = "不可用：state.mu被占用"
+	} else {
+		nodes := make(map[string]string, len(m.state.nodes))
+		for id, status := range m.state.nodes {
+			nodes[id] = string(status)
+		}
+		members := make(map[string]string, len(m.state.members))
+		for id, addr := range m.state.members {
+			members[id] = addr
+		}
+		state := map[string]interface{}{
+			"node_id":       string(m.nodeID),
+			"is_leader":     m.isLeader,
+			"leader":        m.state.leader,
+			"last_election": m.state.lastElection,
+			"nodes":         nodes,
+			"members":       members,
+		}
+		if m.rebalanceErr != nil {
+			state["rebalance_degraded"] = m.rebalanceErr.Error()
+		}
+		m.state.mu.RUnlock()
+		result["state"] = state
+	}
+
+	locks["cache_mu"] = !m.cacheMu.TryRLock()
+	if !locks["cache_mu"] {
+		result["cached_nodes"] = len(m.nodeCache)
+		m.cacheMu.RUnlock()
+	}
+
+	locks["events_mu"] = !m.events.mu.TryLock()
+	if !locks["events_mu"] {
+		result["event_subscribers"] = len(m.events.subscribers)
+		m.events.mu.Unlock()
+	}
+
+	select {
+	case <-m.eventDone:
+		result["event_loop_running"] = false
+	default:
+		result["event_loop_running"] = m.ctx.Err() == nil
+	}
+
+	if reporter, ok := m.electionMgr.(raftStatusReporter); ok {
+		result["raft"] = debugWithTimeout(ctx, func() interface{} { return reporter.RaftStatus() })
+	}
+
+	if m.rebalanceMgr != nil {
+		result["migrations"] = debugWithTimeout(ctx, func() interface{} {
+			return m.rebalanceMgr.GetStatus()["active_tasks"]
+		})
+	}
+	return result
+}
+
+// debugWithTimeout 在ctx结束前返回fn的结果，超时时返回说明文字。fn一直阻塞时其goroutine不会退出，
+// 只在诊断这种已经出现问题的场景下使用
+func debugWithTimeout(ctx context.Context, fn func() interface{}) interface{} {
+	done := make(chan interface{}, 1)
+	go func() { done <- fn() }()
+	select {
+	case value := <-done:
+		return value
+	case <-ctx.Done():
+		return "超时：" + ctx.Err().Error()
+	}
+}
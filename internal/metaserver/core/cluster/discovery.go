@@ -0,0 +1,238 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	httplib "github.com/22827099/DFS_v1/common/network/http"
+	"github.com/22827099/DFS_v1/common/types"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/election"
+)
+
+// 成员发现使用的API路径
+const (
+	MembershipPath = "/api/v1/cluster/members"
+	JoinPath       = "/api/v1/cluster/join"
+	// RaftPath 节点之间传递Raft消息的路径，不经过API认证和公开路由，请求需携带集群密钥
+	RaftPath = election.RaftMessagePath
+)
+
+// ErrNotLeader 只有领导者才能处理成员变更
+var ErrNotLeader = errors.New("当前节点不是领导者")
+
+// ErrClusterFull 集群成员数已达到MaxClusterSize
+var ErrClusterFull = errors.New("集群规模已达上限")
+
+// ErrMissingAddress 加入请求没有提供节点地址
+var ErrMissingAddress = errors.New("节点地址不能为空")
+
+// ErrDuplicateNodeID 节点ID已被另一个地址的活跃成员使用
+var ErrDuplicateNodeID = errors.New("节点ID已被其他活跃节点使用")
+
+// Member 集群成员
+type Member struct {
+	NodeID  string `json:"node_id"`
+	Address string `json:"address,omitempty"` // 节点API地址，如http://10.0.0.1:8080
+}
+
+// Membership 集群成员视图
+type Membership struct {
+	LeaderID string   `json:"leader_id"`
+	Members  []Member `json:"members"`
+}
+
+// Address 返回指定节点的地址，未知时返回空字符串
+func (v Membership) Address(nodeID string) string {
+	for _, member := range v.Members {
+		if member.NodeID == nodeID {
+			return member.Address
+		}
+	}
+	return ""
+}
+
+// JoinRequest 新节点的加入请求
+type JoinRequest struct {
+	NodeID  string `json:"node_id"`
+	Address string `json:"address"`
+}
+
+// membershipEnvelope 元数据服务API的统一响应格式
+type membershipEnvelope struct {
+	Data *Membership `json:"data"`
+}
+
+// DiscoverMembership 通过种子节点发现集群并请求加入
+// 依次尝试各种子节点获取成员视图，向其中的领导者（未知时为该种子节点）发送加入请求，
+// 返回加入后的完整成员视图
+func DiscoverMembership(ctx context.Context, seeds []string, self Member, opts ...httplib.ClientOption) (*Membership, error) {
+	if len(seeds) == 0 {
+		return nil, fmt.Errorf("没有可用的种子节点")
+	}
+
+	var lastErr error
+	for _, seed := range seeds {
+		view, err := fetchMembership(ctx, seed, opts)
+		if err != nil {
+			lastErr = fmt.Errorf("从种子节点%s获取成员失败: %w", seed, err)
+			continue
+		}
+
+		// 成员变更由领导者处理，领导者地址未知或不可达时退回种子节点
+		targets := []string{seed}
+		if leaderAddr := view.Address(view.LeaderID); leaderAddr != "" && leaderAddr != seed {
+			targets = []string{leaderAddr, seed}
+		}
+
+		for _, target := range targets {
+			joined, err := requestJoin(ctx, target, self, opts)
+			if err == nil {
+				return joined, nil
+			}
+			lastErr = fmt.Errorf("向%s请求加入集群失败: %w", target, err)
+		}
+	}
+
+	return nil, lastErr
+}
+
+func fetchMembership(ctx context.Context, baseURL string, opts []httplib.ClientOption) (*Membership, error) {
+	var envelope membershipEnvelope
+	if err := httplib.NewClient(baseURL, opts...).GetJSON(ctx, MembershipPath, &envelope); err != nil {
+		return nil, err
+	}
+	if envelope.Data == nil {
+		return nil, fmt.Errorf("成员视图为空")
+	}
+	return envelope.Data, nil
+}
+
+func requestJoin(ctx context.Context, baseURL string, self Member, opts []httplib.ClientOption) (*Membership, error) {
+	var envelope membershipEnvelope
+	req := JoinRequest{NodeID: self.NodeID, Address: self.Address}
+	// 加入请求会提议成员变更，不自动重试
+	err := httplib.NewClient(baseURL, opts...).PostJSON(ctx, JoinPath, req, &envelope,
+		httplib.WithRequestRetry(httplib.RetryPolicy{MaxRetries: 0}))
+	if err != nil {
+		return nil, err
+	}
+	if envelope.Data == nil {
+		return nil, fmt.Errorf("成员视图为空")
+	}
+	return envelope.Data, nil
+}
+
+// Membership 返回当前节点已知的集群成员视图，成员按节点ID排序
+func (m *ClusterManager) Membership() Membership {
+	leaderID := m.GetCurrentLeader()
+
+	m.state.mu.RLock()
+	defer m.state.mu.RUnlock()
+
+	view := Membership{LeaderID: leaderID, Members: make([]Member, 0, len(m.state.members))}
+	for nodeID, address := range m.state.members {
+		view.Members = append(view.Members, Member{NodeID: nodeID, Address: address})
+	}
+	sort.Slice(view.Members, func(i, j int) bool {
+		return view.Members[i].NodeID < view.Members[j].NodeID
+	})
+	return view
+}
+
+// JoinNode 处理新节点的加入请求，只有领导者可以接受
+// 加入请求必须提供地址，否则返回ErrMissingAddress；
+// 已是成员的节点从同一地址重复加入是幂等的；节点ID已被其他地址的活跃成员使用时返回ErrDuplicateNodeID，成员不变
+func (m *ClusterManager) JoinNode(member Member) (Membership, error) {
+	if member.NodeID == "" {
+		return Membership{}, fmt.Errorf("节点ID不能为空")
+	}
+	// 没有地址就无法与已有成员比较，重复ID检查会被绕过
+	if member.Address == "" {
+		return Membership{}, ErrMissingAddress
+	}
+	if !m.IsLeader() {
+		return m.Membership(), fmt.Errorf("%w, 领导者为%s", ErrNotLeader, m.GetCurrentLeader())
+	}
+
+	// 重复检查与添加在同一临界区内完成，两个节点同时以同一ID加入时只有一个成功
+	m.membershipMu.Lock()
+	err := m.checkDuplicateMember(member)
+	if err == nil {
+		if err = m.addPeerLocked(member.NodeID); err == nil {
+			m.addMember(member)
+		}
+	}
+	m.membershipMu.Unlock()
+
+	if errors.Is(err, ErrDuplicateNodeID) {
+		m.logger.Warn("拒绝重复的节点ID", "node_id", member.NodeID, "address", member.Address, "error", err)
+		return m.Membership(), err
+	}
+	if err != nil {
+		return Membership{}, fmt.Errorf("添加节点%s失败: %w", member.NodeID, err)
+	}
+
+	m.RegisterNode(member.NodeID)
+	return m.Membership(), nil
+}
+
+// checkDuplicateMember 检查加入节点的ID是否已被地址不同的活跃成员使用，调用方需持有membershipMu。
+// 地址相同（节点重启后重新加入）或原成员地址未知（如静态配置的初始成员）时允许加入；原成员已被判定死亡时由新地址接替
+func (m *ClusterManager) checkDuplicateMember(member Member) error {
+	m.state.mu.RLock()
+	existing, exists := m.state.members[member.NodeID]
+	m.state.mu.RUnlock()
+
+	if !exists || existing == "" || strings.TrimSuffix(existing, "/") == strings.TrimSuffix(member.Address, "/") {
+		return nil
+	}
+	if member.NodeID != string(m.nodeID) && m.heartbeatMgr.GetNodeState(member.NodeID) == types.NodeStatusDead {
+		m.logger.Info("节点ID的原成员已死亡，由新地址接替", "node_id", member.NodeID, "old_address", existing, "address", member.Address)
+		return nil
+	}
+	return fmt.Errorf("%w: %s已由%s使用", ErrDuplicateNodeID, member.NodeID, existing)
+}
+
+// addMember 记录集群成员，地址为空时保留已知地址
+func (m *ClusterManager) addMember(member Member) {
+	m.state.mu.Lock()
+	defer m.state.mu.Unlock()
+
+	if member.Address == "" {
+		if _, exists := m.state.members[member.NodeID]; exists {
+			return
+		}
+	}
+	m.state.members[member.NodeID] = member.Address
+}
+
+// discoverPeers 通过配置的种子节点加入集群，并记录发现的成员
+func (m *ClusterManager) discoverPeers() error {
+	timeout := m.cfg.DiscoveryTimeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(m.ctx, timeout)
+	defer cancel()
+
+	self := Member{NodeID: string(m.nodeID), Address: m.cfg.NodeAddress}
+	// 种子节点之间本身可以相互替代，单个种子只做一次短暂重试
+	view, err := DiscoverMembership(ctx, m.cfg.SeedPeers, self,
+		httplib.WithClientTimeout(timeout), httplib.WithRetryPolicy(1, 200*time.Millisecond))
+	if err != nil {
+		return err
+	}
+
+	for _, member := range view.Members {
+		m.addMember(member)
+		if member.NodeID != self.NodeID {
+			m.RegisterNode(member.NodeID)
+		}
+	}
+	m.logger.Info("通过种子节点发现集群成员", "leader_id", view.LeaderID, "member_count", len(view.Members))
+	return nil
+}
@@ -0,0 +1,136 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+)
+
+// CommandApplier 状态机，按日志顺序应用已提交的命令。
+// 集群中每个节点以相同的顺序应用相同的命令，应用失败只作为结果返回给提议方，不影响后续命令
+type CommandApplier interface {
+	ApplyCommand(ctx context.Context, cmd raft.Command) error
+}
+
+// StateSnapshotter 能序列化和恢复全部状态的状态机。注册后节点在已应用的日志超过SnapshotThreshold条时
+// 自动创建快照并压缩之前的日志，收到领导者发来的快照或重启加载快照时用快照恢复状态
+type StateSnapshotter interface {
+	SnapshotState() ([]byte, error)
+	RestoreState(data []byte) error
+}
+
+// errNoSnapshotter 未注册StateSnapshotter时无法序列化状态机
+var errNoSnapshotter = errors.New("未注册状态机快照")
+
+// maxApplyResults 保留的最近命令应用结果数，提议方在命令应用后取走自己的结果
+const maxApplyResults = 1024
+
+// applyTracker 记录状态机处理到的日志索引和最近命令的应用结果
+type applyTracker struct {
+	processing  sync.Mutex // 状态机处理一条消息期间持有，快照在两条消息之间读取状态和索引
+	mu          sync.Mutex
+	applier     CommandApplier
+	snapshotter StateSnapshotter
+	index       uint64           // 状态机处理完的最后一条消息的日志索引
+	advanced    chan struct{}    // index前进时关闭并替换
+	results     map[uint64]error // 最近命令的应用结果，按日志索引
+}
+
+func newApplyTracker() *applyTracker {
+	return &applyTracker{
+		advanced: make(chan struct{}),
+		results:  make(map[uint64]error),
+	}
+}
+
+func (t *applyTracker) setApplier(applier CommandApplier) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.applier = applier
+}
+
+func (t *applyTracker) getApplier() CommandApplier {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.applier
+}
+
+func (t *applyTracker) setSnapshotter(snapshotter StateSnapshotter) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.snapshotter = snapshotter
+}
+
+func (t *applyTracker) getSnapshotter() StateSnapshotter {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.snapshotter
+}
+
+// snapshot 在两条消息之间序列化状态机，返回状态已包含的最后一个日志索引，实现raft.SnapshotFunc
+func (t *applyTracker) snapshot() (uint64, []byte, error) {
+	t.processing.Lock()
+	defer t.processing.Unlock()
+
+	snapshotter := t.getSnapshotter()
+	if snapshotter == nil {
+		return 0, nil, errNoSnapshotter
+	}
+	t.mu.Lock()
+	index := t.index
+	t.mu.Unlock()
+	data, err := snapshotter.SnapshotState()
+	return index, data, err
+}
+
+// advance 记录状态机处理完index处的消息，命令消息同时记录应用结果
+func (t *applyTracker) advance(index uint64, command bool, result error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if command {
+		t.results[index] = result
+		if len(t.results) > maxApplyResults {
+			for i := range t.results {
+				if i+maxApplyResults <= index {
+					delete(t.results, i)
+				}
+			}
+		}
+	}
+	if index > t.index {
+		t.index = index
+		close(t.advanced)
+		t.advanced = make(chan struct{})
+	}
+}
+
+// wait 等待状态机处理到index，ctx结束或done关闭时返回错误
+func (t *applyTracker) wait(ctx context.Context, done <-chan struct{}, index uint64) error {
+	for {
+		t.mu.Lock()
+		applied, advanced := t.index, t.advanced
+		t.mu.Unlock()
+		if applied >= index {
+			return nil
+		}
+		select {
+		case <-advanced:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-done:
+			return raft.ErrStopped
+		}
+	}
+}
+
+// takeResult 取走index处命令的应用结果，结果已被清理时返回nil
+func (t *applyTracker) takeResult(index uint64) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	result := t.results[index]
+	delete(t.results, index)
+	return result
+}
@@ -3,7 +3,10 @@ package election
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/rand"
+	"net/http"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -11,6 +14,8 @@ import (
 	"github.com/22827099/DFS_v1/common/consensus/raft"
 	"github.com/22827099/DFS_v1/common/logging"
 	"github.com/22827099/DFS_v1/common/types"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/resolver"
 	"go.etcd.io/etcd/raft/v3/raftpb"
 )
 
@@ -23,12 +28,46 @@ const (
 	ElectionStateLeader    ElectionState = "leader"
 )
 
+// ErrPeerExists 节点ID已是Raft成员
+var ErrPeerExists = errors.New("节点已是Raft成员")
+
+// ErrNoClusterSecret 多节点集群没有配置节点之间的共享密钥
+var ErrNoClusterSecret = errors.New("多节点集群必须配置集群密钥")
+
+// ErrPeerNotFound 节点ID不是Raft成员
+var ErrPeerNotFound = errors.New("节点不是Raft成员")
+
+// transferPollInterval 等待领导权转移完成时查询当前领导者的间隔
+const transferPollInterval = 10 * time.Millisecond
+
+// singleNodeRaftID 单节点引导模式下节点ID不是数字时使用的Raft节点ID
+const singleNodeRaftID = 1
+
+// singleNodeCampaignInterval 单节点引导模式下成为领导者之前重新发起选举的间隔
+const singleNodeCampaignInterval = 10 * time.Millisecond
+
 // ManagerConfig 选举管理器配置
 type ManagerConfig struct {
 	NodeID           types.NodeID // 修改为统一类型
 	ElectionTimeout  time.Duration
 	HeartbeatTimeout time.Duration
-	PeerList         []string // 添加集群节点列表
+	PeerList         []string // 添加集群节点列表，为空或只包含本节点时以单节点模式引导
+	// 加入已有集群：以空成员配置启动，不引导也不发起选举，等待领导者通过成员变更加入本节点，忽略PeerList
+	Join bool
+	// 快照后可压缩的日志条目达到该数量时立即压缩，0使用Raft默认值
+	SnapshotThreshold int
+	// 定期压缩Raft日志的间隔，0使用Raft默认值
+	CompactionInterval time.Duration
+	// Raft日志的持久化目录，为空时只保存在内存中
+	DataDir string
+	// ProposeAndWait等待写入被多数派确认的最长时间，0使用Raft默认值
+	ApplyTimeout time.Duration
+	// 解析Raft消息目标节点的地址，每条消息发送前重新解析以使用最新地址
+	Resolver resolver.Resolver
+	// 编解码Raft命令，提议方需使用同一个编解码器，nil使用raft.DefaultCommandCodec
+	Codec raft.CommandCodec
+	// 节点之间传递Raft消息时携带的共享密钥，接收方拒绝密钥不符的消息；多节点集群必须配置
+	ClusterSecret string
 }
 
 // Manager 管理领导选举
@@ -45,10 +84,13 @@ type Manager struct {
 	lastElectionTime time.Time
 	electionTimer    *time.Timer
 	leaderChangeCh   chan string
+	voters           map[uint64]bool // 已知的Raft成员，由初始成员和已应用的成员变更维护
 	raftNode         *raft.RaftNode
 	transport        *RaftTransport
 	logger           logging.Logger
 	isLeader         bool
+	singleNode       bool          // 单节点引导模式，启动后立即发起选举
+	applied          *applyTracker // 状态机处理应用通道的进度
 }
 
 // NewManager 创建选举管理器
@@ -59,10 +101,13 @@ func NewManager(cfg *ManagerConfig, logger logging.Logger) (*Manager, error) {
 	}
 
 	if cfg.ElectionTimeout == 0 {
-		cfg.ElectionTimeout = 1000 * time.Millisecond
+		cfg.ElectionTimeout = metaconfig.DefaultElectionTimeout
 	}
 	if cfg.HeartbeatTimeout == 0 {
-		cfg.HeartbeatTimeout = 500 * time.Millisecond
+		cfg.HeartbeatTimeout = metaconfig.DefaultHeartbeatTimeout
+	}
+	if cfg.Codec == nil {
+		cfg.Codec = raft.DefaultCommandCodec
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -77,18 +122,26 @@ func NewManager(cfg *ManagerConfig, logger logging.Logger) (*Manager, error) {
 		cancel:           cancel,
 		leaderChangeCh:   make(chan string, 10),
 		logger:           logger,
+		applied:          newApplyTracker(),
 	}
 
 	// 创建随机选举超时
 	m.resetElectionTimer()
 
-	// 初始化传输层
-	transport := NewRaftTransport(m)
-
-	// 初始化Raft节点
+	// 初始化Raft节点，单节点引导模式下不要求节点ID是数字
+	m.singleNode = isSingleNode(cfg)
 	nodeID, err := strconv.ParseUint(string(cfg.NodeID), 10, 64)
 	if err != nil {
-		return nil, err
+		if !m.singleNode {
+			cancel()
+			return nil, fmt.Errorf("节点ID必须是数字: %w", err)
+		}
+		nodeID = singleNodeRaftID
+	}
+	// 没有密钥时任何能访问端口的客户端都可以注入Raft消息
+	if !m.singleNode && cfg.ClusterSecret == "" {
+		cancel()
+		return nil, ErrNoClusterSecret
 	}
 
 	// 创建Raft配置
@@ -96,22 +149,39 @@ func NewManager(cfg *ManagerConfig, logger logging.Logger) (*Manager, error) {
 	raftConfig.NodeID = nodeID
 	raftConfig.ElectionTick = int(cfg.ElectionTimeout / (100 * time.Millisecond))
 	raftConfig.HeartbeatTick = int(cfg.HeartbeatTimeout / (100 * time.Millisecond))
-
-	// 解析并添加集群成员
-	peers := make([]uint64, 0, len(cfg.PeerList))
-	for _, peerStr := range cfg.PeerList {
-		peerID, err := strconv.ParseUint(peerStr, 10, 64)
-		if err != nil {
-			logger.Error("解析节点ID失败", "peer", peerStr, "error", err)
-			continue
+	if cfg.SnapshotThreshold > 0 {
+		raftConfig.SnapshotThreshold = uint64(cfg.SnapshotThreshold)
+	}
+	if cfg.CompactionInterval > 0 {
+		raftConfig.CompactionInterval = cfg.CompactionInterval
+	}
+	raftConfig.StorageDir = cfg.DataDir
+
+	// 解析并添加集群成员，单节点引导模式下本节点是唯一成员，加入已有集群时成员由领导者的成员变更给出
+	peers := []uint64{nodeID}
+	if cfg.Join {
+		peers = nil
+	} else if !m.singleNode {
+		peers = make([]uint64, 0, len(cfg.PeerList))
+		for _, peerStr := range cfg.PeerList {
+			peerID, err := strconv.ParseUint(peerStr, 10, 64)
+			if err != nil {
+				logger.Error("解析节点ID失败", "peer", peerStr, "error", err)
+				continue
+			}
+			peers = append(peers, peerID)
 		}
-		peers = append(peers, peerID)
 	}
 	raftConfig.Peers = peers
+	m.voters = make(map[uint64]bool, len(peers)+1)
+	for _, id := range peers {
+		m.voters[id] = true
+	}
+	m.voters[nodeID] = true
 
-	// 创建RaftNode
-	m.transport = transport
-	m.raftNode, err = raft.NewRaftNode(raftConfig, transport)
+	// 创建传输层和RaftNode
+	m.transport = NewRaftTransport(m, nodeID)
+	m.raftNode, err = raft.NewRaftNode(raftConfig, m.transport)
 	if err != nil {
 		cancel()
 		return nil, err
@@ -120,6 +190,19 @@ func NewManager(cfg *ManagerConfig, logger logging.Logger) (*Manager, error) {
 	return m, nil
 }
 
+// isSingleNode 不是加入已有集群且节点列表为空或只包含本节点时使用单节点引导模式
+func isSingleNode(cfg *ManagerConfig) bool {
+	if cfg.Join {
+		return false
+	}
+	for _, peer := range cfg.PeerList {
+		if peer != string(cfg.NodeID) {
+			return false
+		}
+	}
+	return true
+}
+
 // Start 启动选举管理
 func (m *Manager) Start() error {
 	m.logger.Info("启动领导选举")
@@ -135,9 +218,36 @@ func (m *Manager) Start() error {
 	// 监听Raft状态变化
 	go m.monitorRaftState()
 
+	// 单节点集群不需要其他节点的投票，立即发起选举而不必等待选举超时
+	if m.singleNode {
+		m.logger.Info("单节点引导模式，立即成为领导者", "node_id", m.cfg.NodeID)
+		go m.bootstrapSingleNode()
+	}
+
 	return nil
 }
 
+// bootstrapSingleNode 反复发起选举直到本节点成为领导者。
+// 引导时的成员变更条目应用之前Raft会拒绝选举，因此不能只发起一次
+func (m *Manager) bootstrapSingleNode() {
+	ticker := time.NewTicker(singleNodeCampaignInterval)
+	defer ticker.Stop()
+
+	for !m.raftNode.IsLeader() {
+		if err := m.raftNode.Campaign(m.ctx); err != nil {
+			if m.ctx.Err() == nil {
+				m.logger.Warn("单节点发起选举失败", "error", err)
+			}
+			return
+		}
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 // Stop 停止选举管理
 func (m *Manager) Stop() error {
 	m.logger.Info("停止领导选举")
@@ -169,6 +279,92 @@ func (m *Manager) IsLeader() bool {
 	return m.raftNode.IsLeader()
 }
 
+// CurrentTerm 返回Raft当前任期，作为领导者签发操作的防护令牌
+func (m *Manager) CurrentTerm() uint64 {
+	return m.raftNode.Term()
+}
+
+// SingleNode 返回是否处于单节点引导模式
+func (m *Manager) SingleNode() bool {
+	return m.singleNode
+}
+
+// ProposeWait 通过Raft提交命令并按一致性级别等待确认，返回条目的日志索引
+func (m *Manager) ProposeWait(ctx context.Context, command []byte, level raft.ConsistencyLevel) (uint64, error) {
+	return m.raftNode.ProposeWait(ctx, command, level)
+}
+
+// ProposeAndWait 通过Raft提交命令并等待多数派确认，最多等待配置的ApplyTimeout，
+// 超时返回包装了raft.ErrApplyTimeout的错误
+func (m *Manager) ProposeAndWait(ctx context.Context, command []byte) (uint64, error) {
+	return m.raftNode.ProposeAndWait(ctx, command, m.cfg.ApplyTimeout)
+}
+
+// SetCommandApplier 注册应用已提交命令的状态机，应在Start之前调用；未注册时命令只被解码和记录
+func (m *Manager) SetCommandApplier(applier CommandApplier) {
+	m.applied.setApplier(applier)
+}
+
+// SetStateSnapshotter 注册能序列化和恢复状态的状态机，应在Start之前调用，通常与SetCommandApplier注册的是同一份状态。
+// 注册后已应用的日志超过SnapshotThreshold条时自动创建快照并压缩之前的日志；传入nil停止自动快照
+func (m *Manager) SetStateSnapshotter(snapshotter StateSnapshotter) {
+	m.applied.setSnapshotter(snapshotter)
+	if snapshotter == nil {
+		m.raftNode.SetSnapshotFunc(nil)
+		return
+	}
+	m.raftNode.SetSnapshotFunc(m.applied.snapshot)
+}
+
+// ProposeCommand 以DefaultConsistencyLevel调用ProposeCommandLevel
+func (m *Manager) ProposeCommand(ctx context.Context, cmd raft.Command) (uint64, error) {
+	return m.ProposeCommandLevel(ctx, cmd, raft.DefaultConsistencyLevel)
+}
+
+// ProposeCommandLevel 以配置的编解码器编码命令并通过Raft提交，按一致性级别等待确认，最多等待配置的ApplyTimeout，
+// 超时返回包装了raft.ErrApplyTimeout的错误：
+//   - one：条目写入本节点日志后返回0，不等待提交和应用
+//   - quorum：多数派确认且本节点的状态机应用后返回，命令已提交但状态机应用失败时返回状态机的错误
+//   - all：在quorum的基础上等待所有存活节点复制该条目，只能在领导者上调用
+func (m *Manager) ProposeCommandLevel(ctx context.Context, cmd raft.Command, level raft.ConsistencyLevel) (uint64, error) {
+	data, err := m.cfg.Codec.Encode(cmd)
+	if err != nil {
+		return 0, fmt.Errorf("编码Raft命令失败: %w", err)
+	}
+	index, err := m.raftNode.ProposeAndWaitLevel(ctx, data, level, m.cfg.ApplyTimeout)
+	if err != nil || level == raft.ConsistencyOne {
+		return index, err
+	}
+	if err := m.applied.wait(ctx, m.ctx.Done(), index); err != nil {
+		return index, err
+	}
+	return index, m.applied.takeResult(index)
+}
+
+// LinearizableRead 通过ReadIndex确认领导者在调用时刻的提交索引，等到该索引之前的条目都被状态机应用后返回，
+// 此后读取本地状态不会读到调用之前已提交写入的旧值，跟随者上也可以调用。
+// 没有领导者时一直重试到ctx结束，调用方应为ctx设置超时
+func (m *Manager) LinearizableRead(ctx context.Context) error {
+	index, err := m.raftNode.ReadIndex(ctx)
+	if err != nil {
+		return err
+	}
+	if err := m.raftNode.WaitApplied(ctx, index); err != nil {
+		return err
+	}
+	return m.applied.wait(ctx, m.ctx.Done(), m.raftNode.DeliveredIndex())
+}
+
+// RaftHandler 返回接收其他节点Raft消息的HTTP处理器，应注册在RaftMessagePath上
+func (m *Manager) RaftHandler() http.Handler {
+	return m.transport
+}
+
+// RaftStatus 返回底层Raft节点的状态，用于诊断
+func (m *Manager) RaftStatus() raft.Status {
+	return m.raftNode.Status()
+}
+
 // TriggerElection 触发新的选举
 func (m *Manager) TriggerElection() {
 	m.mu.Lock()
@@ -200,6 +396,10 @@ func (m *Manager) monitorRaftState() {
 		case msg := <-applyCh:
 			m.handleRaftMsg(msg)
 		case isLeader := <-leaderCh:
+			// Raft节点先把之前任期的条目交给应用通道再报告领导权，这里先处理完它们，
+			// 保证成为领导者时状态机已应用完之前的日志
+			m.drainApplied(applyCh)
+
 			// 领导者状态变更，更新选举时间
 			m.mu.Lock()
 			oldIsLeader := m.isLeader // 假设 Manager 结构体中有 isLeader 字段
@@ -235,19 +435,67 @@ func (m *Manager) monitorRaftState() {
 	}
 }
 
-// 处理Raft消息
+// drainApplied 处理应用通道中已有的消息，不等待新消息
+func (m *Manager) drainApplied(applyCh <-chan raft.ApplyMsg) {
+	for {
+		select {
+		case msg := <-applyCh:
+			m.handleRaftMsg(msg)
+		default:
+			return
+		}
+	}
+}
+
+// 处理Raft消息，命令交给注册的状态机应用，处理完后记录状态机的进度
 func (m *Manager) handleRaftMsg(msg raft.ApplyMsg) {
+	m.applied.processing.Lock()
+	defer m.applied.processing.Unlock()
+
 	if msg.CommandValid {
-		// 处理普通命令
-		m.logger.Info("应用Raft命令", "index", msg.CommandIndex, "term", msg.CommandTerm)
-		// 根据实际需要处理命令
+		// 配置变更不会作为命令出现，命令由提议方以同一个编解码器编码
+		m.applied.advance(msg.CommandIndex, true, m.applyCommand(msg))
 	} else if msg.SnapshotValid {
-		// 处理快照
+		// 快照替换状态机的全部状态，之后的命令在快照的基础上应用
 		m.logger.Info("应用Raft快照", "index", msg.SnapshotIndex, "term", msg.SnapshotTerm)
-		// 处理快照数据
+		if snapshotter := m.applied.getSnapshotter(); snapshotter != nil {
+			if err := snapshotter.RestoreState(msg.Snapshot); err != nil {
+				m.logger.Error("从Raft快照恢复状态机失败", "index", msg.SnapshotIndex, "error", err)
+			}
+		}
+		m.applied.advance(msg.SnapshotIndex, false, nil)
+	} else if msg.ConfChangeValid {
+		// 配置变更已由Raft节点应用，这里只同步成员列表
+		m.logger.Info("Raft成员变更", "index", msg.ConfChangeIndex, "voters", msg.ConfState.Voters)
+		m.mu.Lock()
+		m.voters = make(map[uint64]bool, len(msg.ConfState.Voters))
+		for _, id := range msg.ConfState.Voters {
+			m.voters[id] = true
+		}
+		m.mu.Unlock()
+		m.applied.advance(msg.ConfChangeIndex, false, nil)
 	}
 }
 
+// applyCommand 解码已提交的命令并交给状态机，返回应用结果
+func (m *Manager) applyCommand(msg raft.ApplyMsg) error {
+	cmd, err := m.cfg.Codec.Decode(msg.Command)
+	if err != nil {
+		m.logger.Error("解码Raft命令失败", "index", msg.CommandIndex, "error", err)
+		return err
+	}
+	applier := m.applied.getApplier()
+	if applier == nil {
+		m.logger.Info("应用Raft命令", "index", msg.CommandIndex, "term", msg.CommandTerm, "op", cmd.Op, "key", cmd.Key)
+		return nil
+	}
+	if err := applier.ApplyCommand(m.ctx, cmd); err != nil {
+		m.logger.Warn("状态机应用Raft命令失败", "index", msg.CommandIndex, "op", cmd.Op, "key", cmd.Key, "error", err)
+		return err
+	}
+	return nil
+}
+
 // 运行选举循环
 func (m *Manager) runElection() {
 	// 保留现有代码，但实际选举由Raft库管理
@@ -283,17 +531,18 @@ func (m *Manager) becomeFollower(term uint64, leaderId string) {
 	m.logger.Info("转为跟随者状态", "term", term, "leader", leaderId)
 }
 
-// 重置选举计时器
+// 重置选举计时器。计时器只在NewManager中创建一次，之后原地重置，
+// 避免与读取计时器通道的选举循环和Stop产生数据竞争
 func (m *Manager) resetElectionTimer() {
-	if m.electionTimer != nil {
-		m.electionTimer.Stop()
-	}
-
 	// 随机化超时以防止分裂投票
 	// 使用200ms到400ms的随机值
 	timeout := m.cfg.ElectionTimeout + time.Duration(rand.Int63n(int64(m.cfg.ElectionTimeout)))
 
-	m.electionTimer = time.NewTimer(timeout)
+	if m.electionTimer == nil {
+		m.electionTimer = time.NewTimer(timeout)
+		return
+	}
+	m.electionTimer.Reset(timeout)
 }
 
 // HandleRequestVote 处理投票请求
@@ -354,18 +603,49 @@ func (m *Manager) GetVotedFor() string {
 	return m.votedFor
 }
 
-// TransferLeadership 尝试转移领导权到指定节点
-func (m *Manager) TransferLeadership(targetNodeID string) bool {
+// TransferLeadership 将领导权转移给targetNodeID，等到目标节点成为领导者后返回，用于领导者停止前交出领导权。
+// 本节点不是领导者时返回raft.ErrNotLeader，目标不是Raft成员时返回ErrPeerNotFound；
+// 目标在ctx结束前或两个选举超时内没有当选时返回错误，此时本节点可能仍是领导者
+func (m *Manager) TransferLeadership(ctx context.Context, targetNodeID string) error {
 	if !m.raftNode.IsLeader() {
-		m.logger.Warn("非领导者节点无法转移领导权")
-		return false
+		return raft.ErrNotLeader
+	}
+	target, err := strconv.ParseUint(targetNodeID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: %q不是数字节点ID", ErrPeerNotFound, targetNodeID)
+	}
+	m.mu.RLock()
+	known := m.voters[target]
+	m.mu.RUnlock()
+	if !known {
+		return fmt.Errorf("%w: %s", ErrPeerNotFound, targetNodeID)
 	}
 
-	m.logger.Info("尝试转移领导权", "targetNodeID", targetNodeID)
+	status := m.raftNode.Status()
+	if target == status.ID {
+		return nil
+	}
 
-	// 在实际应用中，可以通过发送特殊配置变更实现
-	// 这里简化处理，只返回成功
-	return true
+	m.logger.Info("转移领导权", "target", targetNodeID, "term", status.Term)
+
+	// Raft在一个选举超时后放弃未完成的转移，多等一个选举超时让目标的当选消息送达
+	ctx, cancel := context.WithTimeout(ctx, 2*m.cfg.ElectionTimeout)
+	defer cancel()
+	m.raftNode.TransferLeadership(ctx, target)
+
+	ticker := time.NewTicker(transferPollInterval)
+	defer ticker.Stop()
+	for {
+		if m.raftNode.Status().Lead == target {
+			m.logger.Info("领导权已转移", "target", targetNodeID)
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("领导权未能转移到节点%s: %w", targetNodeID, ctx.Err())
+		}
+	}
 }
 
 // ResetPeers 重置集群节点列表
@@ -380,11 +660,9 @@ func (m *Manager) ResetPeers(peers []string) error {
 	return nil
 }
 
-// AddPeer 添加新的集群节点
+// AddPeer 添加新的集群节点，等待成员变更被应用后返回。节点已是Raft成员时返回ErrPeerExists；
+// 提议被Raft丢弃（如另一个成员变更尚未应用）或超时未应用时返回错误，调用方可以重试
 func (m *Manager) AddPeer(peerID string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	m.logger.Info("添加集群节点", "peerID", peerID)
 
 	// 解析peerID为uint64
@@ -393,31 +671,36 @@ func (m *Manager) AddPeer(peerID string) error {
 		return err
 	}
 
-	// 通过Raft协议添加节点
+	if id > raft.MaxNodeID {
+		return fmt.Errorf("节点ID%s超过上限%d", peerID, raft.MaxNodeID)
+	}
+
+	// 同一ID重复加入会让两个节点共用一个Raft成员身份，只以已应用的成员配置为准
+	m.mu.RLock()
+	exists := m.voters[id]
+	m.mu.RUnlock()
+	if exists {
+		return fmt.Errorf("%w: %s", ErrPeerExists, peerID)
+	}
+
+	// 通过Raft协议添加节点，变更提交后由Raft节点应用，成员列表在应用通道收到变更时同步
 	cc := raftpb.ConfChange{
 		Type:   raftpb.ConfChangeAddNode,
 		NodeID: id,
 	}
-
-	// 在Raft中提议配置变更
-	data, err := cc.Marshal()
-	if err != nil {
-		return err
+	ctx, cancel := context.WithTimeout(m.ctx, m.cfg.ElectionTimeout)
+	defer cancel()
+	if err := m.raftNode.ProposeConfChange(ctx, cc); err != nil {
+		return fmt.Errorf("提议添加节点%s失败: %w", peerID, err)
 	}
-
-	// 提议配置变更
-	if !m.raftNode.Propose(data) {
-		return err
+	if err := m.waitForVoter(ctx, id, true); err != nil {
+		return fmt.Errorf("添加节点%s的成员变更未被应用: %w", peerID, err)
 	}
-
 	return nil
 }
 
-// RemovePeer 移除集群节点
+// RemovePeer 移除集群节点，等待成员变更被应用后返回
 func (m *Manager) RemovePeer(peerID string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	m.logger.Info("移除集群节点", "peerID", peerID)
 
 	// 解析peerID为uint64
@@ -426,65 +709,60 @@ func (m *Manager) RemovePeer(peerID string) error {
 		return err
 	}
 
-	// 通过Raft协议移除节点
+	// 通过Raft协议移除节点，变更提交后由Raft节点应用，成员列表在应用通道收到变更时同步
 	cc := raftpb.ConfChange{
 		Type:   raftpb.ConfChangeRemoveNode,
 		NodeID: id,
 	}
-
-	// 在Raft中提议配置变更
-	data, err := cc.Marshal()
-	if err != nil {
-		return err
+	ctx, cancel := context.WithTimeout(m.ctx, m.cfg.ElectionTimeout)
+	defer cancel()
+	if err := m.raftNode.ProposeConfChange(ctx, cc); err != nil {
+		return fmt.Errorf("提议移除节点%s失败: %w", peerID, err)
 	}
-
-	// 提议配置变更
-	if !m.raftNode.Propose(data) {
-		return err
+	if err := m.waitForVoter(ctx, id, false); err != nil {
+		return fmt.Errorf("移除节点%s的成员变更未被应用: %w", peerID, err)
 	}
-
 	return nil
 }
 
-// RaftTransport 实现raft.Transport接口
-type RaftTransport struct {
-	nodeID   uint64
-	manager  *Manager
-	receiveC chan raftpb.Message
-}
-
-// NewRaftTransport 创建一个新的传输层
-func NewRaftTransport(manager *Manager) *RaftTransport {
-	nodeID, _ := strconv.ParseUint(string(manager.cfg.NodeID), 10, 64)
-	return &RaftTransport{
-		nodeID:   nodeID,
-		manager:  manager,
-		receiveC: make(chan raftpb.Message, 100),
+// waitForVoter 等待应用通道同步的成员列表中id的成员身份变为member。
+// Raft在上一个成员变更应用前会丢弃新的成员变更提议，提议被接受不代表会被应用
+func (m *Manager) waitForVoter(ctx context.Context, id uint64, member bool) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		m.mu.RLock()
+		applied := m.voters[id] == member
+		m.mu.RUnlock()
+		if applied {
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
 }
 
-// Send 发送Raft消息到其他节点
-func (t *RaftTransport) Send(messages []raftpb.Message) {
-	for _, msg := range messages {
-		// 这里应实现实际的网络传输逻辑
-		// 在实际应用中，应该通过网络发送给目标节点
-		t.manager.logger.Debug("发送消息", "to", msg.To, "type", msg.Type)
+// GetPeers 返回Raft成员配置中的投票成员，按节点ID排序；不包含已提议但尚未应用的成员变更。
+// 单节点引导模式下节点ID不是数字时，本节点以配置中的节点ID返回
+func (m *Manager) GetPeers() []string {
+	voters := m.raftNode.ConfState().Voters
+	_, numericID := strconv.ParseUint(string(m.cfg.NodeID), 10, 64)
+	ids := make([]uint64, len(voters))
+	copy(ids, voters)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	peers := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if numericID != nil && id == singleNodeRaftID {
+			peers = append(peers, string(m.cfg.NodeID))
+			continue
+		}
+		peers = append(peers, strconv.FormatUint(id, 10))
 	}
-}
-
-// Start 启动传输层
-func (t *RaftTransport) Start() error {
-	return nil
-}
-
-// Stop 停止传输层
-func (t *RaftTransport) Stop() {
-	close(t.receiveC)
-}
-
-// 接收消息
-func (t *RaftTransport) receiveMessage(msg raftpb.Message) {
-	t.receiveC <- msg
+	return peers
 }
 
 // GetLastElectionTime 获取最后一次选举时间
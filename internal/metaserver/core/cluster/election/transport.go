@@ -0,0 +1,291 @@
+package election
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/raft/v3/raftpb"
+)
+
+// RaftMessagePath 节点接收Raft消息的HTTP路径
+const RaftMessagePath = "/raft/message"
+
+// ClusterSecretHeader 携带集群共享密钥的请求头，接收方据此拒绝集群外部发来的Raft消息
+const ClusterSecretHeader = "X-Cluster-Secret"
+
+const (
+	// sendQueueSize 每个目标节点的待发送消息数，队列满时丢弃新消息，由Raft重传
+	sendQueueSize = 256
+	// maxSendBatch 一次请求最多携带的消息数，积压的消息合并发送以减少请求数
+	maxSendBatch = 64
+	// sendTimeout 单次发送的超时
+	sendTimeout = 5 * time.Second
+	// maxMessageBodySize 接收的请求体上限，快照消息可能较大
+	maxMessageBodySize = 256 << 20
+)
+
+// RaftTransport 通过HTTP在节点之间传递Raft消息，实现raft.Transport接口。
+// 每个目标节点一个发送队列和发送协程，慢节点或不可达节点只会让自己的队列积压，
+// 不会阻塞Raft的处理循环；发送失败的消息直接丢弃，由Raft重传
+type RaftTransport struct {
+	nodeID  uint64
+	manager *Manager
+	client  *http.Client
+
+	mu     sync.Mutex
+	queues map[uint64]chan raftpb.Message
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewRaftTransport 创建传输层，nodeID为本节点的Raft节点ID，目标节点的地址通过manager的解析器获取
+func NewRaftTransport(manager *Manager, nodeID uint64) *RaftTransport {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &RaftTransport{
+		nodeID:  nodeID,
+		manager: manager,
+		client:  &http.Client{Timeout: sendTimeout},
+		queues:  make(map[uint64]chan raftpb.Message),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Send 将消息放入目标节点的发送队列，队列满或传输层已停止时丢弃
+func (t *RaftTransport) Send(messages []raftpb.Message) {
+	for _, msg := range messages {
+		queue := t.queue(msg.To)
+		if queue == nil {
+			return
+		}
+		select {
+		case queue <- msg:
+		default:
+			t.manager.logger.Debug("发送队列已满，丢弃Raft消息", "to", msg.To, "type", msg.Type)
+			t.reportFailure(msg.To, []raftpb.Message{msg})
+		}
+	}
+}
+
+// queue 返回目标节点的发送队列，首次使用时创建队列并启动发送协程；已停止时返回nil
+func (t *RaftTransport) queue(to uint64) chan raftpb.Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.ctx.Err() != nil {
+		return nil
+	}
+	queue, ok := t.queues[to]
+	if !ok {
+		queue = make(chan raftpb.Message, sendQueueSize)
+		t.queues[to] = queue
+		t.wg.Add(1)
+		go t.sendLoop(to, queue)
+	}
+	return queue
+}
+
+// sendLoop 依次发送队列中的消息，积压的消息合并为一个请求
+func (t *RaftTransport) sendLoop(to uint64, queue chan raftpb.Message) {
+	defer t.wg.Done()
+
+	for {
+		select {
+		case <-t.ctx.Done():
+			return
+		case msg := <-queue:
+			batch := []raftpb.Message{msg}
+		drain:
+			for len(batch) < maxSendBatch {
+				select {
+				case msg := <-queue:
+					batch = append(batch, msg)
+				default:
+					break drain
+				}
+			}
+
+			if err := t.post(to, batch); err != nil {
+				if t.ctx.Err() != nil {
+					return
+				}
+				t.manager.logger.Debug("发送Raft消息失败", "to", to, "count", len(batch), "error", err)
+				t.reportFailure(to, batch)
+				continue
+			}
+			for _, msg := range batch {
+				if msg.Type == raftpb.MsgSnap {
+					t.manager.raftNode.ReportSnapshot(to, false)
+				}
+			}
+		}
+	}
+}
+
+// post 将一批消息发送到目标节点
+func (t *RaftTransport) post(to uint64, batch []raftpb.Message) error {
+	addr := t.peerAddress(to)
+	if addr == "" {
+		return fmt.Errorf("无法解析节点%d的地址", to)
+	}
+	body, err := encodeMessages(batch)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodPost, messageURL(addr), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set(ClusterSecretHeader, t.manager.cfg.ClusterSecret)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("节点%d返回状态码%d", to, resp.StatusCode)
+	}
+	return nil
+}
+
+// reportFailure 通知Raft消息未能送达，发往该节点的快照需要单独报告失败
+func (t *RaftTransport) reportFailure(to uint64, batch []raftpb.Message) {
+	t.manager.raftNode.ReportUnreachable(to)
+	for _, msg := range batch {
+		if msg.Type == raftpb.MsgSnap {
+			t.manager.raftNode.ReportSnapshot(to, true)
+		}
+	}
+}
+
+// peerAddress 解析目标节点的当前地址，未配置解析器或解析失败时返回空字符串
+func (t *RaftTransport) peerAddress(nodeID uint64) string {
+	if t.manager.cfg.Resolver == nil {
+		return ""
+	}
+	addr, err := t.manager.cfg.Resolver.Resolve(strconv.FormatUint(nodeID, 10))
+	if err != nil {
+		return ""
+	}
+	return addr
+}
+
+// messageURL 根据节点地址构造消息接收地址，地址可以是host:port或带协议的URL
+func messageURL(addr string) string {
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	return strings.TrimRight(addr, "/") + RaftMessagePath
+}
+
+// ServeHTTP 接收其他节点发来的Raft消息并交给Raft节点处理。
+// 请求必须携带与本节点相同的集群密钥，本节点未配置密钥时拒绝所有消息
+func (t *RaftTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "只支持POST", http.StatusMethodNotAllowed)
+		return
+	}
+	secret := t.manager.cfg.ClusterSecret
+	if secret == "" {
+		http.Error(w, "未配置集群密钥，不接收Raft消息", http.StatusForbidden)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get(ClusterSecretHeader)), []byte(secret)) != 1 {
+		http.Error(w, "集群密钥无效", http.StatusUnauthorized)
+		return
+	}
+
+	messages, err := decodeMessages(http.MaxBytesReader(w, r.Body, maxMessageBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, msg := range messages {
+		if msg.To != t.nodeID {
+			http.Error(w, fmt.Sprintf("消息目标为节点%d，本节点为%d", msg.To, t.nodeID), http.StatusBadRequest)
+			return
+		}
+		if err := t.manager.raftNode.Step(r.Context(), msg); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Start 启动传输层，发送协程在首次向某个节点发送消息时启动
+func (t *RaftTransport) Start() error {
+	return nil
+}
+
+// Stop 停止所有发送协程并等待其退出，未发送的消息被丢弃，可以重复调用
+func (t *RaftTransport) Stop() {
+	t.stopOnce.Do(func() {
+		t.mu.Lock()
+		t.cancel()
+		t.mu.Unlock()
+	})
+	t.wg.Wait()
+}
+
+// encodeMessages 将消息编码为请求体，每条消息前是4字节大端序的长度
+func encodeMessages(messages []raftpb.Message) ([]byte, error) {
+	var buf bytes.Buffer
+	var size [4]byte
+	for _, msg := range messages {
+		data, err := msg.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		binary.BigEndian.PutUint32(size[:], uint32(len(data)))
+		buf.Write(size[:])
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeMessages 解析encodeMessages编码的请求体
+func decodeMessages(r io.Reader) ([]raftpb.Message, error) {
+	var messages []raftpb.Message
+	var size [4]byte
+	for {
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return messages, nil
+			}
+			return nil, fmt.Errorf("读取消息长度失败: %w", err)
+		}
+		n := binary.BigEndian.Uint32(size[:])
+		if n > maxMessageBodySize {
+			return nil, fmt.Errorf("消息长度%d超过上限", n)
+		}
+		data := make([]byte, n)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, fmt.Errorf("读取消息失败: %w", err)
+		}
+		var msg raftpb.Message
+		if err := msg.Unmarshal(data); err != nil {
+			return nil, fmt.Errorf("解析消息失败: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+}
@@ -0,0 +1,221 @@
+package cluster
+
+import (
+	"sync"
+	"time"
+)
+
+// 集群事件类型
+const (
+	EventLeaderChange = "leader_change" // 领导者变更，NodeID为新领导者，Data为Raft任期
+	EventNodeStatus   = "node_status"   // 节点状态变更，Data为新的types.NodeStatus
+	EventNodeReaped   = "node_reaped"   // 死亡节点被永久移除
+	EventBatch        = "batch"         // 合并窗口内的多个事件，Data为按发生顺序排列的[]ClusterEvent
+)
+
+// defaultEventBufferSize 订阅者通道中为实时事件预留的容量
+const defaultEventBufferSize = 16
+
+// eventBus 集群事件的订阅分发
+// 保留最近的事件供新订阅者回放，订阅时先收到历史事件再收到实时事件；
+// 订阅者处理过慢导致通道已满时丢弃新事件，不阻塞事件循环。
+// 启用合并后，窗口内的事件先暂存，窗口结束或达到batchSize时作为一个batch事件分发
+type eventBus struct {
+	mu          sync.Mutex
+	history     []ClusterEvent // 环形缓冲区，容量为historySize
+	start       int            // 最早事件在history中的位置
+	historySize int
+	subscribers map[uint64]chan ClusterEvent
+	nextID      uint64
+	closed      bool
+
+	batchWindow time.Duration                   // 合并窗口，不大于0时逐条分发
+	batchSize   int                             // 单个batch的最大事件数
+	pending     []ClusterEvent                  // 当前窗口内暂存的事件
+	flushTimer  *time.Timer                     // 窗口结束时分发暂存事件
+	onDrop      func(event ClusterEvent, n int) // 合并分发时有订阅者丢弃事件的回调
+}
+
+// newEventBus 创建事件总线，historySize为回放的最近事件数，不大于0时不回放
+func newEventBus(historySize int) *eventBus {
+	if historySize < 0 {
+		historySize = 0
+	}
+	return &eventBus{
+		history:     make([]ClusterEvent, 0, historySize),
+		historySize: historySize,
+		subscribers: make(map[uint64]chan ClusterEvent),
+	}
+}
+
+// enableBatching 启用事件合并，window不大于0时保持逐条分发；size不大于0时不限制batch大小。
+// 合并分发发生在定时器中，丢弃事件的订阅者数量通过onDrop报告
+func (b *eventBus) enableBatching(window time.Duration, size int, onDrop func(event ClusterEvent, n int)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.batchWindow = window
+	b.batchSize = size
+	b.onDrop = onDrop
+}
+
+// publish 记录事件并分发给所有订阅者，返回因通道已满而丢弃该事件的订阅者数量。
+// 启用合并时事件暂存到窗口结束再分发，此时返回0
+func (b *eventBus) publish(event ClusterEvent) int {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return 0
+	}
+	if b.batchWindow > 0 {
+		b.pending = append(b.pending, event)
+		if b.batchSize > 0 && len(b.pending) >= b.batchSize {
+			b.flushLocked()
+		} else if b.flushTimer == nil {
+			b.flushTimer = time.AfterFunc(b.batchWindow, b.flush)
+		}
+		return 0
+	}
+	b.record(event)
+	return b.deliverLocked(event)
+}
+
+// flush 窗口结束时分发暂存的事件
+func (b *eventBus) flush() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.flushLocked()
+}
+
+// flushLocked 将暂存的事件记入历史并分发，多个事件合并为一个batch事件，调用方需持有锁
+// 在分发时才记入历史，保证窗口内订阅的订阅者不会既从历史又从batch中收到同一事件
+func (b *eventBus) flushLocked() {
+	if b.flushTimer != nil {
+		b.flushTimer.Stop()
+		b.flushTimer = nil
+	}
+	if len(b.pending) == 0 {
+		return
+	}
+	events := b.pending
+	b.pending = nil
+	for _, event := range events {
+		b.record(event)
+	}
+
+	event := events[0]
+	if len(events) > 1 {
+		event = ClusterEvent{Type: EventBatch, Data: events, Timestamp: events[len(events)-1].Timestamp}
+	}
+	if dropped := b.deliverLocked(event); dropped > 0 && b.onDrop != nil {
+		b.onDrop(event, dropped)
+	}
+}
+
+// deliverLocked 将事件分发给所有订阅者，返回因通道已满而丢弃的订阅者数量，调用方需持有锁
+func (b *eventBus) deliverLocked(event ClusterEvent) int {
+	dropped := 0
+	for _, ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// record 将事件写入环形缓冲区，调用方需持有锁
+func (b *eventBus) record(event ClusterEvent) {
+	if b.historySize == 0 {
+		return
+	}
+	if len(b.history) < b.historySize {
+		b.history = append(b.history, event)
+		return
+	}
+	b.history[b.start] = event
+	b.start = (b.start + 1) % b.historySize
+}
+
+// recent 按时间顺序返回缓冲的历史事件，调用方需持有锁
+func (b *eventBus) recent() []ClusterEvent {
+	events := make([]ClusterEvent, 0, len(b.history))
+	events = append(events, b.history[b.start:]...)
+	return append(events, b.history[:b.start]...)
+}
+
+// subscribe 订阅事件，返回的通道先包含历史事件，随后是实时事件
+// buffer为实时事件预留的通道容量；取消订阅或事件总线关闭后通道被关闭
+func (b *eventBus) subscribe(buffer int) (<-chan ClusterEvent, func()) {
+	if buffer <= 0 {
+		buffer = defaultEventBufferSize
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// 在同一临界区内回放并注册，保证历史事件与实时事件之间不重复、不遗漏
+	history := b.recent()
+	ch := make(chan ClusterEvent, len(history)+buffer)
+	for _, event := range history {
+		ch <- event
+	}
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+
+	id := b.nextID
+	b.nextID++
+	b.subscribers[id] = ch
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if sub, ok := b.subscribers[id]; ok {
+				delete(b.subscribers, id)
+				close(sub)
+			}
+		})
+	}
+	return ch, cancel
+}
+
+// close 分发暂存的事件后关闭所有订阅者通道，之后发布的事件被忽略
+func (b *eventBus) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.flushLocked()
+	b.closed = true
+	for id, ch := range b.subscribers {
+		delete(b.subscribers, id)
+		close(ch)
+	}
+}
+
+// SubscribeEvents 订阅集群事件（领导者变更、节点状态变更等）
+// 新订阅者先收到最近EventHistorySize条历史事件，便于重建当前状态，随后收到实时事件；
+// buffer为实时事件的通道容量，订阅者处理过慢时新事件被丢弃。使用完毕后必须调用返回的取消函数。
+// 配置了EventBatchWindow时，窗口内的多个实时事件合并为一个EventBatch事件送达
+func (m *ClusterManager) SubscribeEvents(buffer int) (<-chan ClusterEvent, func()) {
+	return m.events.subscribe(buffer)
+}
+
+// publishEvent 发布集群事件
+func (m *ClusterManager) publishEvent(eventType, nodeID string, data interface{}) {
+	event := ClusterEvent{Type: eventType, NodeID: nodeID, Data: data, Timestamp: time.Now()}
+	if dropped := m.events.publish(event); dropped > 0 {
+		m.logger.Warn("事件订阅者通道已满，事件丢弃", "type", eventType, "node_id", nodeID, "dropped", dropped)
+	}
+}
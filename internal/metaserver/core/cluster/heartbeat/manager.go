@@ -2,6 +2,8 @@ package heartbeat
 
 import (
 	"context"
+	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -9,12 +11,14 @@ import (
 	httplib "github.com/22827099/DFS_v1/common/network/http"
 	"github.com/22827099/DFS_v1/common/types"
 	"github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/resolver"
 )
 
 // StateChange 表示节点状态变化
 type StateChange struct {
 	NodeID string
 	State  types.NodeStatus
+	Reaped bool // 节点死亡超过清理延迟，已从心跳监控中永久移除
 }
 
 // Manager 管理节点心跳检测
@@ -26,6 +30,45 @@ type Manager struct {
 	nodeStates    map[string]*nodeState
 	stateChangeCh chan StateChange
 	logger        logging.Logger
+	resolver      NodeResolver      // 解析心跳目标的地址，解析失败时使用默认地址
+	graceUntil    time.Time         // 此前不把节点判定为死亡，也不清理死亡节点
+	noReaper      bool              // 不启动清理死亡节点的协程，死亡节点一直保留
+
+	clientsMu sync.Mutex
+	clients   map[string]*peerClient // 节点ID -> 发送心跳的客户端，跨周期复用连接
+	stopped   bool                   // Stop后不再创建客户端，由clientsMu保护
+}
+
+// heartbeatTimeout 单次心跳请求的超时时间
+const heartbeatTimeout = 5 * time.Second
+
+// peerClient 向单个节点发送心跳的客户端，独占一个传输以便停止时关闭其连接
+type peerClient struct {
+	url    string
+	client *httplib.Client
+}
+
+// Option 心跳管理器配置选项
+type Option func(*Manager)
+
+// NodeResolver 将节点ID解析为心跳目标的地址（host:port或完整URL），resolver包中的解析器都实现了该接口
+type NodeResolver interface {
+	Resolve(nodeID string) (string, error)
+}
+
+// WithResolver 使用解析器获取心跳目标的地址，每次发送心跳时重新解析，节点地址变化后立即生效。
+// 未指定时使用由配置中PeerMap构建的静态解析器
+func WithResolver(r NodeResolver) Option {
+	return func(m *Manager) {
+		m.resolver = r
+	}
+}
+
+// WithReaperDisabled 不清理死亡节点，节点死亡后一直保留在心跳监控中，恢复心跳后重新成为健康节点
+func WithReaperDisabled() Option {
+	return func(m *Manager) {
+		m.noReaper = true
+	}
 }
 
 // nodeState 内部节点状态记录
@@ -34,33 +77,34 @@ type nodeState struct {
 	State         types.NodeStatus
 	LastHeartbeat time.Time
 	FailCount     int
+	detector      *PhiAccrualDetector // phi模式下记录心跳到达间隔，fixed模式下为nil
 }
 
 // NewManager 创建心跳管理器
-func NewManager(cfg *config.HeartbeatConfig, logger logging.Logger) (*Manager, error) {
-	if cfg.HeartbeatInterval == 0 {
-		cfg.HeartbeatInterval = 1 * time.Second
-	}
-	if cfg.SuspectTimeout == 0 {
-		cfg.SuspectTimeout = 3 * time.Second
-	}
-	if cfg.DeadTimeout == 0 {
-		cfg.DeadTimeout = 10 * time.Second
-	}
-	if cfg.CleanupInterval == 0 {
-		cfg.CleanupInterval = 30 * time.Second
+func NewManager(cfg *config.HeartbeatConfig, logger logging.Logger, opts ...Option) (*Manager, error) {
+	cfg.ApplyDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &Manager{
+	m := &Manager{
 		cfg:           cfg,
 		nodeStates:    make(map[string]*nodeState),
 		stateChangeCh: make(chan StateChange, 100),
+		clients:       make(map[string]*peerClient),
 		ctx:           ctx,
 		cancel:        cancel,
 		logger:        logger,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.resolver == nil {
+		m.resolver = resolver.Static(cfg.PeerMap)
+	}
+	return m, nil
 }
 
 // Start 启动心跳管理
@@ -74,31 +118,77 @@ func (m *Manager) Start() error {
 	go m.checkHeartbeats()
 
 	// 启动过期节点清理协程
-	go m.cleanupDeadNodes()
+	if m.noReaper {
+		m.logger.Info("死亡节点清理已禁用")
+	} else {
+		go m.cleanupDeadNodes()
+	}
 
 	return nil
 }
 
-// Stop 停止心跳管理
+// Stop 停止心跳管理，关闭发送心跳的客户端的连接
 func (m *Manager) Stop() error {
 	m.logger.Info("停止心跳检测")
 	m.cancel()
+
+	m.clientsMu.Lock()
+	defer m.clientsMu.Unlock()
+	m.stopped = true
+	for nodeID, pc := range m.clients {
+		pc.client.CloseIdleConnections()
+		delete(m.clients, nodeID)
+	}
 	return nil
 }
 
-// RegisterNode 注册节点进行心跳监控
-func (m *Manager) RegisterNode(nodeID string) {
+// RegisterNode 注册节点进行心跳监控，返回是否为新注册的节点
+// 重复注册是幂等的：已注册节点保留现有状态和失败计数，只有真实心跳才能将其恢复为健康
+func (m *Manager) RegisterNode(nodeID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if state, exists := m.nodeStates[nodeID]; exists {
+		m.logger.Debug("节点已注册，保留现有状态", "nodeID", nodeID, "state", state.State)
+		return false
+	}
+
+	m.nodeStates[nodeID] = m.newNodeState(nodeID)
+	m.logger.Info("注册节点进行心跳监控", "nodeID", nodeID)
+	return true
+}
+
+// ForceRegisterNode 强制注册节点，已注册节点的状态和失败计数会被重置为健康
+func (m *Manager) ForceRegisterNode(nodeID string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.nodeStates[nodeID] = &nodeState{
+	state, exists := m.nodeStates[nodeID]
+	m.nodeStates[nodeID] = m.newNodeState(nodeID)
+
+	if exists && state.State != types.NodeStatusHealthy {
+		m.stateChangeCh <- StateChange{
+			NodeID: nodeID,
+			State:  types.NodeStatusHealthy,
+		}
+	}
+
+	m.logger.Info("强制注册节点进行心跳监控", "nodeID", nodeID, "existed", exists)
+}
+
+// newNodeState 创建处于健康状态的节点记录，phi模式下以注册时间作为第一次心跳的到达时间
+func (m *Manager) newNodeState(nodeID string) *nodeState {
+	now := time.Now()
+	state := &nodeState{
 		NodeID:        nodeID,
 		State:         types.NodeStatusHealthy,
-		LastHeartbeat: time.Now(),
+		LastHeartbeat: now,
 		FailCount:     0,
 	}
-
-	m.logger.Info("注册节点进行心跳监控", "nodeID", nodeID)
+	if m.cfg.FailureDetector == config.FailureDetectorPhi {
+		state.detector = NewPhiAccrualDetector(m.cfg.PhiWindowSize, m.cfg.PhiMinStdDeviation, m.cfg.HeartbeatInterval, now)
+	}
+	return state
 }
 
 // UnregisterNode 取消节点的心跳监控
@@ -107,17 +197,21 @@ func (m *Manager) UnregisterNode(nodeID string) {
 	defer m.mu.Unlock()
 
 	delete(m.nodeStates, nodeID)
+	m.dropClient(nodeID)
 	m.logger.Info("取消节点的心跳监控", "nodeID", nodeID)
 }
 
 // RecordHeartbeat 记录收到的心跳
-func (m *Manager) RecordHeartbeat(nodeID string) {	
+func (m *Manager) RecordHeartbeat(nodeID string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	if state, exists := m.nodeStates[nodeID]; exists {
 		oldState := state.State
 		state.LastHeartbeat = time.Now()
+		if state.detector != nil {
+			state.detector.Heartbeat(state.LastHeartbeat)
+		}
 		state.FailCount = 0
 		state.State = types.NodeStatusHealthy
 
@@ -129,12 +223,7 @@ func (m *Manager) RecordHeartbeat(nodeID string) {
 		}
 	} else {
 		// 新节点，自动注册
-		m.nodeStates[nodeID] = &nodeState{
-			NodeID:        nodeID,
-			State:         types.NodeStatusHealthy,
-			LastHeartbeat: time.Now(),
-			FailCount:     0,
-		}
+		m.nodeStates[nodeID] = m.newNodeState(nodeID)
 
 		m.stateChangeCh <- StateChange{
 			NodeID: nodeID,
@@ -162,7 +251,7 @@ func (m *Manager) sendHeartbeats() {
 			m.mu.RLock()
 			for nodeID := range m.nodeStates {
 				// 跳过自己
-				if nodeID == m.cfg.NodeID.String() {
+				if nodeID == m.cfg.NodeID {
 					continue
 				}
 				go m.sendHeartbeatToNode(nodeID)
@@ -172,29 +261,66 @@ func (m *Manager) sendHeartbeats() {
 	}
 }
 
+// clientFor 返回向nodeID发送心跳的客户端，节点地址变化时关闭旧客户端的连接并重新创建；Stop后返回nil
+func (m *Manager) clientFor(nodeID, baseURL string) *httplib.Client {
+    m.clientsMu.Lock()
+    defer m.clientsMu.Unlock()
+    
+    if m.stopped {
+        return nil
+    }
+    if pc, ok := m.clients[nodeID]; ok {
+        if pc.url == baseURL {
+            return pc.client
+        }
+        pc.client.CloseIdleConnections()
+    }
+    
+    transport := http.DefaultTransport.(*http.Transport).Clone()
+    client := httplib.NewClient(baseURL, httplib.WithHTTPClient(&http.Client{
+        Timeout:   heartbeatTimeout,
+        Transport: transport,
+    }))
+    m.clients[nodeID] = &peerClient{url: baseURL, client: client}
+    return client
+}
+
+// dropClient 关闭并删除发送心跳的客户端，节点不再接收心跳时调用
+func (m *Manager) dropClient(nodeID string) {
+    m.clientsMu.Lock()
+    defer m.clientsMu.Unlock()
+    if pc, ok := m.clients[nodeID]; ok {
+        pc.client.CloseIdleConnections()
+        delete(m.clients, nodeID)
+    }
+}
+
 // 向单个节点发送心跳
 func (m *Manager) sendHeartbeatToNode(nodeID string) {
     // 获取节点地址
     baseURL := m.getNodeURL(nodeID)
     
-    // 创建自定义HTTP客户端
-    client := httplib.NewClient(baseURL, httplib.WithTimeout(5*time.Second))
+    // 复用该节点的客户端，连接在各周期之间保持
+    client := m.clientFor(nodeID, baseURL)
+    if client == nil {
+        return
+    }
     
     m.logger.Debug("发送心跳", "to", nodeID, "from", m.cfg.NodeID, "url", baseURL)
     
-    // 发送心跳请求
-    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    // 发送心跳请求，停止时取消正在进行的请求
+    ctx, cancel := context.WithTimeout(m.ctx, heartbeatTimeout)
     defer cancel()
     
     // 准备心跳数据
     heartbeatData := map[string]string{
-        "sender_id": m.cfg.NodeID.String(),
+        "sender_id": m.cfg.NodeID,
         "timestamp": time.Now().Format(time.RFC3339),
     }
     
     // 发送POST请求，注意使用client实例调用PostJSON方法
     var response map[string]interface{}
-    err := client.PostJSON(ctx, "/api/v1/heartbeat", heartbeatData, &response, nil)
+    err := client.PostJSON(ctx, "/api/v1/heartbeat", heartbeatData, &response)
     if err != nil {
         m.logger.Error("发送心跳失败", "to", nodeID, "error", err)
         return
@@ -203,11 +329,17 @@ func (m *Manager) sendHeartbeatToNode(nodeID string) {
     m.logger.Debug("心跳响应", "from", nodeID, "response", response)
 }
 
-// 辅助方法：根据节点ID获取节点URL
+// 辅助方法：根据节点ID获取节点URL，使用解析器给出的最新地址，解析失败时假定节点ID是主机名并使用默认端口
 func (m *Manager) getNodeURL(nodeID string) string {
-    // 在实际实现中，应该从配置或服务发现中获取节点地址
-    // 这里简单示例，实际应用需要替换
-    return "http://" + nodeID + ":8080"
+    addr, err := m.resolver.Resolve(nodeID)
+    if err != nil {
+        m.logger.Debug("解析节点地址失败，使用默认地址", "nodeID", nodeID, "error", err)
+        return "http://" + nodeID + ":8080"
+    }
+    if strings.Contains(addr, "://") {
+        return addr
+    }
+    return "http://" + addr
 }
 
 // 检查心跳状态
@@ -225,14 +357,14 @@ func (m *Manager) checkHeartbeats() {
 
 			for nodeID, state := range m.nodeStates {
 				// 跳过自己
-				if nodeID == m.cfg.NodeID.String() {
+				if nodeID == m.cfg.NodeID {
 					continue
 				}
 
-				timeSinceLastHeartbeat := now.Sub(state.LastHeartbeat)
+				suspect, dead := m.evaluateNode(state, now)
 
 				// 处理超时的节点
-				if state.State == types.NodeStatusHealthy && timeSinceLastHeartbeat > m.cfg.SuspectTimeout {
+				if state.State == types.NodeStatusHealthy && suspect {
 					state.State = types.NodeStatusSuspect
 					state.FailCount++
 					m.stateChangeCh <- StateChange{
@@ -240,7 +372,7 @@ func (m *Manager) checkHeartbeats() {
 						State:  types.NodeStatusSuspect,
 					}
 					m.logger.Warn("节点可疑", "nodeID", nodeID, "lastHeartbeat", state.LastHeartbeat)
-				} else if state.State == types.NodeStatusSuspect && timeSinceLastHeartbeat > m.cfg.DeadTimeout {
+				} else if state.State == types.NodeStatusSuspect && dead && !now.Before(m.graceUntil) {
 					state.State = types.NodeStatusDead
 					m.stateChangeCh <- StateChange{
 						NodeID: nodeID,
@@ -255,6 +387,49 @@ func (m *Manager) checkHeartbeats() {
 	}
 }
 
+// evaluateNode 判断节点在now时刻是否达到可疑和死亡的条件，调用方需持有锁。
+// phi模式比较怀疑度与阈值，fixed模式比较距上次心跳的时长与超时
+func (m *Manager) evaluateNode(state *nodeState, now time.Time) (suspect, dead bool) {
+	if state.detector != nil {
+		phi := state.detector.Phi(now)
+		return phi >= m.cfg.PhiSuspectThreshold, phi >= m.cfg.PhiDeadThreshold
+	}
+	timeSinceLastHeartbeat := now.Sub(state.LastHeartbeat)
+	return timeSinceLastHeartbeat > m.cfg.SuspectTimeout, timeSinceLastHeartbeat > m.cfg.DeadTimeout
+}
+
+// SuppressDeathUntil 在until之前不把节点判定为死亡，也不清理已死亡的节点，节点仍可被判定为可疑。
+// 新领导者可能还没有收到各节点的心跳，借此避免过早移除它们；宽限期结束后仍无心跳的节点照常判定和清理
+func (m *Manager) SuppressDeathUntil(until time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if until.After(m.graceUntil) {
+		m.graceUntil = until
+	}
+}
+
+// SetTimeouts 运行时更新可疑和死亡判定超时，非正数时保持不变；只影响fixed模式的判定
+func (m *Manager) SetTimeouts(suspectTimeout, deadTimeout time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if suspectTimeout > 0 {
+		m.cfg.SuspectTimeout = suspectTimeout
+	}
+	if deadTimeout > 0 {
+		m.cfg.DeadTimeout = deadTimeout
+	}
+}
+
+// reapDelay 返回死亡节点被永久移除前的等待时间，未配置时为3倍DeadTimeout，负数表示不清理，调用方需持有锁
+func (m *Manager) reapDelay() time.Duration {
+	if m.cfg.DeadNodeReapDelay == 0 {
+		return 3 * m.cfg.DeadTimeout
+	}
+	return m.cfg.DeadNodeReapDelay
+}
+
 // 清理长期不活跃的节点
 func (m *Manager) cleanupDeadNodes() {
 	ticker := time.NewTicker(m.cfg.CleanupInterval)
@@ -265,20 +440,38 @@ func (m *Manager) cleanupDeadNodes() {
 		case <-m.ctx.Done():
 			return
 		case <-ticker.C:
-			now := time.Now()
-			m.mu.Lock()
-
-			for nodeID, state := range m.nodeStates {
-				// 删除长时间处于死亡状态的节点
-				if state.State == types.NodeStatusDead && now.Sub(state.LastHeartbeat) > 3*m.cfg.DeadTimeout {
-					delete(m.nodeStates, nodeID)
-					m.logger.Info("清理长期不活跃的节点", "nodeID", nodeID)
+			// 在锁外发送通知，避免接收方查询状态时死锁
+			for _, nodeID := range m.reapDeadNodes(time.Now()) {
+				m.dropClient(nodeID)
+				m.stateChangeCh <- StateChange{
+					NodeID: nodeID,
+					State:  types.NodeStatusDead,
+					Reaped: true,
 				}
 			}
+		}
+	}
+}
 
-			m.mu.Unlock()
+// reapDeadNodes 删除死亡时间超过清理延迟的节点，返回被删除的节点ID
+func (m *Manager) reapDeadNodes(now time.Time) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delay := m.reapDelay()
+	if delay < 0 || now.Before(m.graceUntil) {
+		return nil
+	}
+
+	var reaped []string
+	for nodeID, state := range m.nodeStates {
+		if state.State == types.NodeStatusDead && now.Sub(state.LastHeartbeat) > delay {
+			delete(m.nodeStates, nodeID)
+			reaped = append(reaped, nodeID)
+			m.logger.Info("清理长期不活跃的节点", "nodeID", nodeID)
 		}
 	}
+	return reaped
 }
 
 // GetAllNodeStates 返回所有节点的状态信息
@@ -295,6 +488,39 @@ func (m *Manager) GetAllNodeStates() map[string]types.NodeStatus {
     return result
 }
 
+// GetNodeFailCount 返回指定节点连续心跳超时的次数，节点不存在时返回-1
+func (m *Manager) GetNodeFailCount(nodeID string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if state, exists := m.nodeStates[nodeID]; exists {
+		return state.FailCount
+	}
+	return -1
+}
+
+// GetNodeLastHeartbeat 返回最后一次收到指定节点心跳的时间，尚未收到心跳时为注册时间，节点不存在时返回零值
+func (m *Manager) GetNodeLastHeartbeat(nodeID string) time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if state, exists := m.nodeStates[nodeID]; exists {
+		return state.LastHeartbeat
+	}
+	return time.Time{}
+}
+
+// GetNodePhi 返回指定节点当前的怀疑度phi，fixed模式下或节点不存在时返回0
+func (m *Manager) GetNodePhi(nodeID string) float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if state, exists := m.nodeStates[nodeID]; exists && state.detector != nil {
+		return state.detector.Phi(time.Now())
+	}
+	return 0
+}
+
 // GetNodeState 返回指定节点的状态
 func (m *Manager) GetNodeState(nodeID string) types.NodeStatus {
 	m.mu.RLock()
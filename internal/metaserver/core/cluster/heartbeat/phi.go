@@ -0,0 +1,84 @@
+package heartbeat
+
+import (
+	"math"
+	"time"
+)
+
+// PhiAccrualDetector phi-accrual故障检测器，记录节点最近的心跳到达间隔，
+// 按间隔的正态分布估计"距上次心跳已过去这么久而节点仍然存活"的概率，phi = -log10(该概率)。
+// 到达间隔波动大的节点分布更宽，同样的延迟得到更低的phi，不会因网络抖动被误判为故障。
+// 检测器不是并发安全的，由调用方加锁
+type PhiAccrualDetector struct {
+	intervals []float64 // 最近的到达间隔（毫秒），环形缓冲区
+	next      int       // 下一个写入位置
+	count     int       // 缓冲区中的间隔数
+	sum       float64   // 缓冲区中间隔之和
+	sumSq     float64   // 缓冲区中间隔平方之和
+	minStdDev float64   // 标准差下限（毫秒）
+	last      time.Time // 最后一次心跳的到达时间
+}
+
+// NewPhiAccrualDetector 创建phi-accrual故障检测器，start视为第一次心跳的到达时间。
+// 尚未收到心跳时以expectedInterval为均值、其1/4为标准差的两个样本估计分布，随真实间隔的到达逐渐被替换
+func NewPhiAccrualDetector(windowSize int, minStdDeviation, expectedInterval time.Duration, start time.Time) *PhiAccrualDetector {
+	if windowSize < 2 {
+		windowSize = 2
+	}
+	d := &PhiAccrualDetector{
+		intervals: make([]float64, windowSize),
+		minStdDev: durationMillis(minStdDeviation),
+		last:      start,
+	}
+	mean := durationMillis(expectedInterval)
+	d.add(mean - mean/4)
+	d.add(mean + mean/4)
+	return d
+}
+
+// Heartbeat 记录在at时刻到达的心跳，早于上次心跳的到达时间时忽略
+func (d *PhiAccrualDetector) Heartbeat(at time.Time) {
+	if at.Before(d.last) {
+		return
+	}
+	d.add(durationMillis(at.Sub(d.last)))
+	d.last = at
+}
+
+// Phi 返回now时刻对节点故障的怀疑度，距上次心跳越久、历史到达间隔越稳定，phi越高
+func (d *PhiAccrualDetector) Phi(now time.Time) float64 {
+	elapsed := durationMillis(now.Sub(d.last))
+	mean := d.sum / float64(d.count)
+	stdDev := math.Sqrt(math.Max(d.sumSq/float64(d.count)-mean*mean, 0))
+	if stdDev < d.minStdDev {
+		stdDev = d.minStdDev
+	}
+
+	// 用logistic函数近似正态分布的累积分布函数，避免大偏离时直接计算1-CDF的精度损失
+	y := (elapsed - mean) / stdDev
+	e := math.Exp(-y * (1.5976 + 0.070566*y*y))
+	if elapsed > mean {
+		return -math.Log10(e / (1.0 + e))
+	}
+	return -math.Log10(1.0 - 1.0/(1.0+e))
+}
+
+// add 加入一个到达间隔，缓冲区已满时替换最早的间隔
+func (d *PhiAccrualDetector) add(interval float64) {
+	if d.count == len(d.intervals) {
+		old := d.intervals[d.next]
+		d.sum -= old
+		d.sumSq -= old * old
+	} else {
+		d.count++
+	}
+	d.intervals[d.next] = interval
+	d.sum += interval
+	d.sumSq += interval * interval
+	d.next = (d.next + 1) % len(d.intervals)
+}
+
+// durationMillis 将时长转换为毫秒数
+func durationMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -2,29 +2,79 @@ package cluster
 
 import (
 	"context"
+	"net/http"
 	"time"
 
+	"github.com/22827099/DFS_v1/common/consensus/raft"
 	"github.com/22827099/DFS_v1/common/types"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
 )
 
+// RebalanceManager 集群管理器依赖的负载均衡管理器接口，由rebalance.Manager实现
+type RebalanceManager interface {
+	Start() error
+	Stop() error
+	TriggerRebalance()
+	GetStatus() map[string]interface{}
+	GetTaskStatus(taskID string) (*rebalance.MigrationTask, bool)
+	UpdateNodeMetrics(nodeID string, metrics *types.NodeMetrics)
+	GetNodeMetrics(nodeID string) *types.NodeMetrics
+	UpdateClusterHealth(healthyNodes, totalNodes int)
+	RecordLeaderChange(at time.Time)
+	InterlockStatus() rebalance.InterlockStatus
+	UpdateThresholds(imbalanceThreshold, stopRatio float64)
+	Weights() (rebalance.ScoreWeights, error)
+	UpdateWeights(weights rebalance.ScoreWeights) (rebalance.ScoreWeights, error)
+	AdvanceTerm(term uint64)
+}
+
+// ElectionManager 集群管理器依赖的选举管理器接口，由election.Manager实现
+type ElectionManager interface {
+	Start() error
+	Stop() error
+	IsLeader() bool
+	GetCurrentLeader() string
+	CurrentTerm() uint64
+	LeaderChangeChan() <-chan string
+	AddPeer(peerID string) error
+	RemovePeer(peerID string) error
+}
+
 // Manager 定义集群管理的基本接口
 type Manager interface {
 	Start() error                                                // 启动集群管理服务
 	Stop(ctx context.Context) error                              // 停止集群管理服务
 	IsLeader() bool                                              // 检查当前节点是否为leader
 	GetCurrentLeader() string                                    // 获取当前leader的节点ID
-	LeaderChangeChan() <-chan string                             // 返回leader变更通知通道
+	LeaderChangeChan() <-chan string                             // 返回leader变更通知通道，只保留最新的leader
 	GetLeader(ctx context.Context) (*types.NodeInfo, error)      // 获取leader节点信息
 	LastElectionTime() time.Time                                 // 上次选举时间
 	RegisterNode(nodeID string)                                  // 注册新节点到集群
 	UnregisterNode(nodeID string)                                // 从集群中注销节点
 	AddPeer(peerID string) error                                 // 添加一个新的peer节点
 	RemovePeer(peerID string) error                              // 移除一个peer节点
+	IsPeerActive(nodeID string) bool                             // 检查节点是否已在Raft成员配置中
 	ListNodes(ctx context.Context) ([]types.NodeInfo, error)     // 列出所有集群节点
 	GetNodeInfo(ctx context.Context, nodeID string) (*types.NodeInfo, error) // 获取节点信息
 	GetNodeCount() int                                           // 获取节点总数
 	GetHealthyNodeCount() int                                    // 获取健康节点总数
 	UpdateNodeMetrics(nodeID string, metrics *types.NodeMetrics) // 更新节点指标信息
+	UpdateNodeMetricsBatch(batch map[string]*types.NodeMetrics) map[string]error // 批量更新节点指标，返回不合法节点的错误
+	GetNodeMetricsHistory(nodeID string, query MetricsHistoryQuery) ([]MetricsSample, bool) // 获取节点最近的指标样本
+	RebalanceEnabled() bool                                      // 配置是否启用了负载均衡
 	TriggerRebalance()                                           // 触发集群重平衡
 	GetRebalanceStatus() map[string]interface{}                  // 获取重平衡状态信息
+	GetRebalanceTask(taskID string) (*rebalance.MigrationTask, bool) // 获取迁移任务状态及进度
+	GetBalanceWeights() (rebalance.ScoreWeights, error)          // 获取加权得分策略的权重，仅领导者可用
+	UpdateBalanceWeights(weights rebalance.ScoreWeights) (rebalance.ScoreWeights, error) // 运行时更新加权得分策略的权重，仅领导者可用
+	Membership() Membership                                      // 获取已知的集群成员视图
+	JoinNode(member Member) (Membership, error)                  // 处理新节点的加入请求，仅领导者可接受
+	ReloadConfig(cfg metaconfig.ClusterConfig)                   // 运行时应用可安全重载的集群配置
+	GetClusterSnapshot(ctx context.Context) map[string]interface{} // 获取集群状态快照，超时时返回部分数据
+	SubscribeEvents(buffer int) (<-chan ClusterEvent, func())    // 订阅集群事件，先回放最近的历史事件
+	DebugState(ctx context.Context) map[string]interface{}       // 获取锁占用、Raft状态等诊断信息，不会因死锁而阻塞
+	RaftHandler() http.Handler                                   // 接收其他节点Raft消息的HTTP处理器，可能为nil
+	ProposeWrite(ctx context.Context, cmd raft.Command, level raft.ConsistencyLevel) error // 将写操作命令提交到Raft，按一致性级别等待确认
+	LinearizableRead(ctx context.Context) error                  // 等待本节点应用调用之前已提交的所有写入
 }
@@ -2,57 +2,86 @@ package cluster
 
 import (
     "context"
+    "errors"
     "fmt"
+    "net/http"
     "sync"
     "time"
 
+    commonconfig "github.com/22827099/DFS_v1/common/config"
+    "github.com/22827099/DFS_v1/common/consensus/raft"
     "github.com/22827099/DFS_v1/common/types"
     "github.com/22827099/DFS_v1/common/logging"
     metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
     "github.com/22827099/DFS_v1/internal/metaserver/core/cluster/election"
     "github.com/22827099/DFS_v1/internal/metaserver/core/cluster/heartbeat"
     "github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+    "github.com/22827099/DFS_v1/internal/metaserver/core/cluster/resolver"
 )
 
 // ClusterEvent 表示集群中发生的事件
 type ClusterEvent struct {
-    Type      string      // "leader_change", "node_status", "rebalance_status"
-    NodeID    string
-    Data      interface{}
-    Timestamp time.Time
+    Type      string      `json:"type"`               // 事件类型，见EventLeaderChange等常量
+    NodeID    string      `json:"node_id"`
+    Data      interface{} `json:"data,omitempty"`
+    Timestamp time.Time   `json:"timestamp"`
 }
 
 // 集群状态结构体
 type clusterState struct {
     nodes        map[string]types.NodeStatus
+    members      map[string]string // 已知集群成员，节点ID -> 地址
     leader       string
     lastElection time.Time
     mu           sync.RWMutex
 }
 
 // Manager 集群管理器
+//
+// 锁顺序：membershipMu -> state.mu -> cacheMu。需要同时持有多把锁时必须按此顺序获取，
+// 并且持有state.mu或cacheMu时不调用选举、心跳、负载均衡管理器（它们有各自的锁，
+// 且可能阻塞或回调集群管理器），避免与事件循环形成锁环
 type ClusterManager struct {
     cfg           metaconfig.ClusterConfig
     logger        logging.Logger
-    electionMgr   *election.Manager
+    electionMgr   ElectionManager
     heartbeatMgr  *heartbeat.Manager
-    rebalanceMgr  *rebalance.Manager
-    isLeader      bool
+    rebalanceMgr  RebalanceManager
+    rebalanceErr  error // 负载均衡管理器启动失败的原因，非nil表示处于降级模式，由state.mu保护
+    isLeader      bool  // 事件循环观察到的本节点领导者身份，由state.mu保护
     nodeID        types.NodeID
-    leaderChangeCh chan string
+    consensusCfg  commonconfig.ConsensusConfig // 默认选举管理器的日志快照与压缩配置
+    resolver      resolver.Resolver    // 解析节点地址，供心跳和Raft传输使用，见NewManager
+    peerRefresher *resolver.Refreshing // 配置了PeerSRVName时定期刷新DNS SRV记录，否则为nil
+    leaderChangeCh chan string // 容量为1，只保存最新的领导者，见notifyLeaderChange
+    applier       election.CommandApplier // 应用已提交写操作命令的状态机，见WithCommandApplier
+    snapshotter   election.StateSnapshotter // 序列化和恢复状态机，见WithStateSnapshotter
     
     // 新增状态管理
     state        clusterState
     
     // 节点缓存，减少频繁查询
     nodeCache    map[string]nodeInfoCache
+    cacheGen     uint64 // 缓存代数，每次失效时递增，丢弃失效前开始构建的节点信息
     cacheMu      sync.RWMutex
+    
+    // 串行化成员变更，保证集群规模检查与添加的原子性
+    membershipMu sync.Mutex
     cacheTTL     time.Duration
     
     // 事件处理相关
     ctx          context.Context
     cancel       context.CancelFunc
     eventDone    chan struct{}
+    
+    // 集群事件订阅，保留最近事件供新订阅者回放
+    events       *eventBus
+    
+    // 最近一次完整的集群状态快照，无法获取实时状态时返回
+    statusCache  *statusCache
+    
+    // 每个节点最近的指标样本
+    metricsHistory *metricsHistory
 }
 
 // 节点信息缓存
@@ -61,52 +90,61 @@ type nodeInfoCache struct {
     timestamp time.Time
 }
 
-// NewManager 创建集群管理器
-func NewManager(cfg metaconfig.ClusterConfig, logger logging.Logger) (Manager, error) {
-    if cfg.NodeID == "" {
-        return nil, fmt.Errorf("节点ID不能为空")
-    }
-    
-    // 创建选举管理器
-    electionCfg := &election.ManagerConfig{
-        NodeID:           types.NodeID(cfg.NodeID),
-        ElectionTimeout:  cfg.ElectionTimeout,
-        HeartbeatTimeout: cfg.HeartbeatTimeout,
-        PeerList:         cfg.Peers,
+// ManagerOption 集群管理器配置选项
+type ManagerOption func(*ClusterManager)
+
+// WithRebalanceManager 使用指定的负载均衡管理器替代默认实现
+func WithRebalanceManager(rebalanceMgr RebalanceManager) ManagerOption {
+    return func(m *ClusterManager) {
+        m.rebalanceMgr = rebalanceMgr
     }
-    
-    electionMgr, err := election.NewManager(electionCfg, logger)
-    if err != nil {
-        return nil, fmt.Errorf("创建选举管理器失败: %w", err)
+}
+
+// WithStateSnapshotter 设置序列化和恢复状态机的存储，选举管理器支持时注册到选举管理器，
+// 已应用的日志超过共识配置的SnapshotThreshold条时自动创建快照并压缩日志
+func WithStateSnapshotter(snapshotter election.StateSnapshotter) ManagerOption {
+    return func(m *ClusterManager) {
+        m.snapshotter = snapshotter
     }
-    
-    // 创建心跳管理器
-    heartbeatCfg := &metaconfig.HeartbeatConfig{
-        NodeID:            cfg.NodeID,
-        HeartbeatInterval: cfg.HeartbeatInterval,
-        SuspectTimeout:    cfg.SuspectTimeout,
-        DeadTimeout:       cfg.DeadTimeout,
+}
+
+// WithElectionManager 使用指定的选举管理器替代默认实现
+func WithElectionManager(electionMgr ElectionManager) ManagerOption {
+    return func(m *ClusterManager) {
+        m.electionMgr = electionMgr
     }
-    
-    heartbeatMgr, err := heartbeat.NewManager(heartbeatCfg, logger)
-    if err != nil {
-        return nil, fmt.Errorf("创建心跳管理器失败: %w", err)
+}
+
+// WithCommandApplier 设置应用已提交写操作命令的状态机，选举管理器支持时注册到选举管理器，
+// 集群每个节点都按日志顺序应用同样的命令
+func WithCommandApplier(applier election.CommandApplier) ManagerOption {
+    return func(m *ClusterManager) {
+        m.applier = applier
     }
-    
-    // 创建负载均衡管理器
-    rebalanceCfg := &metaconfig.LoadBalancerConfig{
-        EvaluationInterval:      cfg.RebalanceEvaluationInterval,
-        ImbalanceThreshold:      cfg.ImbalanceThreshold,
-        MaxConcurrentMigrations: cfg.MaxConcurrentMigrations,
-        MinMigrationInterval:    cfg.MinMigrationInterval,
-        MigrationTimeout:        cfg.MigrationTimeout,
+}
+
+// WithConsensusConfig 设置默认选举管理器的Raft日志目录、快照阈值和压缩间隔，使用自定义选举管理器时不生效
+func WithConsensusConfig(consensusCfg commonconfig.ConsensusConfig) ManagerOption {
+    return func(m *ClusterManager) {
+        m.consensusCfg = consensusCfg
     }
-    
-    rebalanceMgr, err := rebalance.NewManager(rebalanceCfg, logger)
-    if err != nil {
-        return nil, fmt.Errorf("创建负载均衡管理器失败: %w", err)
+}
+
+// WithPeerResolver 使用自定义解析器获取节点地址，解析不到的节点使用已知成员的地址；
+// 设置后不再使用PeerSRVName
+func WithPeerResolver(r resolver.Resolver) ManagerOption {
+    return func(m *ClusterManager) {
+        m.resolver = r
     }
+}
 
+// NewManager 创建集群管理器
+func NewManager(cfg metaconfig.ClusterConfig, logger logging.Logger, opts ...ManagerOption) (Manager, error) {
+    cfg.ApplyDefaults()
+    if err := cfg.Validate(); err != nil {
+        return nil, fmt.Errorf("集群配置无效: %w", err)
+    }
+    
     // 创建上下文，可用于取消事件循环
     ctx, cancel := context.WithCancel(context.Background())
 
@@ -114,45 +152,196 @@ func NewManager(cfg metaconfig.ClusterConfig, logger logging.Logger) (Manager, e
     manager := &ClusterManager{
         cfg:           cfg,
         logger:        logger.WithContext(map[string]interface{}{"component": "cluster_manager"}),
-        electionMgr:   electionMgr,
-        heartbeatMgr:  heartbeatMgr,
-        rebalanceMgr:  rebalanceMgr,
         nodeID:        types.NodeID(cfg.NodeID),
         isLeader:      false,
-        leaderChangeCh: make(chan string, 10),
+        leaderChangeCh: make(chan string, 1),
         ctx:          ctx,
         cancel:       cancel,
         eventDone:    make(chan struct{}),
+        events:       newEventBus(cfg.EventHistorySize),
+        statusCache:  newStatusCache(cfg.StatusCacheTTL),
+        metricsHistory: newMetricsHistory(cfg.MetricsHistorySize),
         state: clusterState{
-            nodes: make(map[string]types.NodeStatus),
+            nodes:   make(map[string]types.NodeStatus),
+            members: initialMembers(cfg),
         },
         nodeCache:     make(map[string]nodeInfoCache),
         cacheTTL:      10 * time.Second, // 默认缓存10秒
     }
+    manager.events.enableBatching(cfg.EventBatchWindow, cfg.EventBatchSize, func(event ClusterEvent, dropped int) {
+        manager.logger.Warn("事件订阅者通道已满，事件丢弃", "type", event.Type, "node_id", event.NodeID, "dropped", dropped)
+    })
+    
+    for _, opt := range opts {
+        opt(manager)
+    }
+    
+    // 节点地址优先由自定义解析器或DNS SRV记录给出，节点IP变化后无需修改配置
+    primary := manager.resolver
+    if primary == nil && cfg.PeerSRVName != "" {
+        manager.peerRefresher = resolver.NewRefreshing(resolver.SRVLookup(cfg.PeerSRVName), cfg.PeerRefreshInterval,
+            resolver.WithRefreshErrorHandler(func(err error) {
+                logger.Warn("解析节点SRV记录失败，暂时使用已知的地址", "name", cfg.PeerSRVName, "error", err)
+            }))
+        primary = manager.peerRefresher
+    }
+    manager.resolver = resolver.Chain(primary, resolver.Static(cfg.PeerMap), resolver.Func(manager.memberAddress))
+    
+    // 未通过选项指定的组件使用默认实现
+    if manager.electionMgr == nil {
+        // 只配置种子节点时以空成员配置加入已有集群，由领导者的成员变更同步完整成员；
+        // 配置了bootstrap时Peers为空，以单节点集群引导
+        electionCfg := &election.ManagerConfig{
+            NodeID:             types.NodeID(cfg.NodeID),
+            ElectionTimeout:    cfg.ElectionTimeout,
+            HeartbeatTimeout:   cfg.HeartbeatTimeout,
+            PeerList:           cfg.Peers,
+            Join:               len(cfg.Peers) == 0 && len(cfg.SeedPeers) > 0,
+            SnapshotThreshold:  manager.consensusCfg.SnapshotThreshold,
+            CompactionInterval: manager.consensusCfg.CompactionInterval,
+            ApplyTimeout:       manager.consensusCfg.ApplyTimeout,
+            DataDir:            manager.consensusCfg.DataDir,
+            Resolver:           manager.resolver,
+            ClusterSecret:      cfg.ClusterSecret,
+        }
+        
+        electionMgr, err := election.NewManager(electionCfg, logger)
+        if err != nil {
+            cancel()
+            return nil, fmt.Errorf("创建选举管理器失败: %w", err)
+        }
+        manager.electionMgr = electionMgr
+    }
+    if setter, ok := manager.electionMgr.(commandApplierSetter); ok && manager.applier != nil {
+        setter.SetCommandApplier(manager.applier)
+    }
+    if setter, ok := manager.electionMgr.(stateSnapshotterSetter); ok && manager.snapshotter != nil {
+        setter.SetStateSnapshotter(manager.snapshotter)
+    }
+    
+    // 创建心跳管理器
+    heartbeatCfg := &metaconfig.HeartbeatConfig{
+        NodeID:              cfg.NodeID,
+        HeartbeatInterval:   cfg.HeartbeatInterval,
+        SuspectTimeout:      cfg.SuspectTimeout,
+        DeadTimeout:         cfg.DeadTimeout,
+        CleanupInterval:     cfg.CleanupInterval,
+        DeadNodeReapDelay:   cfg.DeadNodeReapDelay,
+        PeerMap:             cfg.PeerMap,
+        FailureDetector:     cfg.FailureDetector,
+        PhiSuspectThreshold: cfg.PhiSuspectThreshold,
+        PhiDeadThreshold:    cfg.PhiDeadThreshold,
+        PhiMinStdDeviation:  cfg.PhiMinStdDeviation,
+        PhiWindowSize:       cfg.PhiWindowSize,
+    }
+    
+    heartbeatOpts := []heartbeat.Option{heartbeat.WithResolver(manager.resolver)}
+    if !cfg.HeartbeatReaperEnabled() {
+        heartbeatOpts = append(heartbeatOpts, heartbeat.WithReaperDisabled())
+    }
+    heartbeatMgr, err := heartbeat.NewManager(heartbeatCfg, logger, heartbeatOpts...)
+    if err != nil {
+        cancel()
+        return nil, fmt.Errorf("创建心跳管理器失败: %w", err)
+    }
+    manager.heartbeatMgr = heartbeatMgr
+    
+    if manager.rebalanceMgr == nil {
+        rebalanceCfg := &metaconfig.LoadBalancerConfig{
+            EvaluationInterval:      cfg.RebalanceEvaluationInterval,
+            ImbalanceThreshold:      cfg.ImbalanceThreshold,
+            MaxConcurrentMigrations: cfg.MaxConcurrentMigrations,
+            MinMigrationInterval:    cfg.MinMigrationInterval,
+            MigrationTimeout:        cfg.MigrationTimeout,
+            SlowStartWindow:         cfg.RebalanceSlowStartWindow,
+            SlowStartRamp:           cfg.RebalanceSlowStartRamp,
+            MinHealthyRatio:         cfg.RebalanceMinHealthyRatio,
+            LeaderStabilityPeriod:   cfg.RebalanceLeaderStability,
+            MetricsStalenessWindow:  cfg.RebalanceMetricsStaleness,
+            ImbalanceStopRatio:      cfg.ImbalanceStopRatio,
+            MaxPlans:                cfg.RebalanceMaxPlans,
+            MinNodesForRebalance:    cfg.RebalanceMinNodes,
+        }
+        
+        rebalanceMgr, err := rebalance.NewManager(rebalanceCfg, logger, rebalance.WithFencingTermSource(manager.electionMgr.CurrentTerm))
+        if err != nil {
+            cancel()
+            return nil, fmt.Errorf("创建负载均衡管理器失败: %w", err)
+        }
+        manager.rebalanceMgr = rebalanceMgr
+    }
     
     return manager, nil
 }
 
+// initialMembers 根据静态配置构建初始成员列表，PeerAddresses与Peers按位置对应
+func initialMembers(cfg metaconfig.ClusterConfig) map[string]string {
+    members := make(map[string]string, len(cfg.Peers)+1)
+    for i, peer := range cfg.Peers {
+        address := ""
+        if i < len(cfg.PeerAddresses) {
+            address = cfg.PeerAddresses[i]
+        }
+        members[peer] = address
+    }
+    members[cfg.NodeID] = cfg.NodeAddress
+    return members
+}
+
+// memberAddress 返回已知成员的地址
+func (m *ClusterManager) memberAddress(nodeID string) (string, error) {
+    m.state.mu.RLock()
+    defer m.state.mu.RUnlock()
+    if addr := m.state.members[nodeID]; addr != "" {
+        return addr, nil
+    }
+    return "", fmt.Errorf("%w: %s", resolver.ErrUnknownNode, nodeID)
+}
+
 // Start 启动集群管理器
 func (m *ClusterManager) Start() error {
     m.logger.Info("启动集群管理器")
     
+
     // 启动心跳管理器
     if err := m.heartbeatMgr.Start(); err != nil {
         return fmt.Errorf("启动心跳管理器失败: %w", err)
     }
     
+    // 配置了种子节点时先加入已有集群，避免新节点独自选举形成另一个集群
+    if len(m.cfg.SeedPeers) > 0 {
+        if err := m.discoverPeers(); err != nil {
+            m.heartbeatMgr.Stop()
+            return fmt.Errorf("通过种子节点加入集群失败: %w", err)
+        }
+    }
+    
     // 启动选举管理器
     if err := m.electionMgr.Start(); err != nil {
         m.heartbeatMgr.Stop()
         return fmt.Errorf("启动选举管理器失败: %w", err)
     }
     
-    // 启动负载均衡管理器
-    if err := m.rebalanceMgr.Start(); err != nil {
-        m.electionMgr.Stop()
-        m.heartbeatMgr.Stop()
-        return fmt.Errorf("启动负载均衡管理器失败: %w", err)
+    // 启动负载均衡管理器，再平衡不影响元数据服务，允许时以降级模式继续运行；配置禁用时不启动
+    if !m.cfg.RebalanceEnabled() {
+        m.logger.Info("负载均衡已通过配置禁用，不启动负载均衡管理器")
+    } else if err := m.rebalanceMgr.Start(); err != nil {
+        if !m.cfg.AllowDegradedRebalance {
+            m.electionMgr.Stop()
+            m.heartbeatMgr.Stop()
+            return fmt.Errorf("启动负载均衡管理器失败: %w", err)
+        }
+        
+        m.state.mu.Lock()
+        m.rebalanceErr = err
+        m.state.mu.Unlock()
+        m.logger.Error("启动负载均衡管理器失败，以降级模式运行，再平衡已禁用", "error", err)
+    }
+    
+    // 子系统全部启动后再在后台解析SRV记录，启动失败时无需停止刷新，DNS缓慢时也不阻塞启动；
+    // 解析成功前以及DNS暂时不可用时使用已知成员的地址，之后定期重试
+    if m.peerRefresher != nil {
+        m.peerRefresher.Start()
     }
     
     // 启动统一的事件处理循环，替代原来的多个监听goroutine
@@ -193,73 +382,72 @@ func (m *ClusterManager) eventLoop() {
 
 // handleLeaderChange 处理领导者变更事件
 func (m *ClusterManager) handleLeaderChange(leaderID string) {
-    oldIsLeader := m.isLeader
-    m.isLeader = leaderID == string(m.nodeID)
+    isLeader := leaderID == string(m.nodeID)
     
-    // 更新集群状态
+    // 更新集群状态，并在同一临界区内使缓存失效，避免读到新领导者与旧节点信息的组合
     m.state.mu.Lock()
+    oldIsLeader := m.isLeader
+    m.isLeader = isLeader
     m.state.leader = leaderID
     m.state.lastElection = time.Now()
+    m.invalidateNodeCache()
     m.state.mu.Unlock()
     
+    // 领导者变更后的一段时间内禁止迁移，并使旧任期签发的迁移任务失效
+    term := m.electionMgr.CurrentTerm()
+    m.rebalanceMgr.RecordLeaderChange(time.Now())
+    m.rebalanceMgr.AdvanceTerm(term)
+    
     // 记录领导者变更事件
     m.logger.Info("集群领导者变更", 
         "leader_id", leaderID, 
-        "is_leader", m.isLeader)
+        "is_leader", isLeader,
+        "term", term)
         
     // 转发领导者变更事件到外部通道
-    select {
-    case m.leaderChangeCh <- leaderID:
-        // 成功发送
-    default:
-        // 通道已满，记录警告
-        m.logger.Warn("领导者变更通道已满，消息丢弃")
-    }
+    m.notifyLeaderChange(leaderID)
+    m.publishEvent(EventLeaderChange, leaderID, term)
     
     // 如果本节点成为新领导者
-    if !oldIsLeader && m.isLeader {
+    if !oldIsLeader && isLeader {
         m.onBecomeLeader()
     }
     // 如果本节点失去领导权
-    if oldIsLeader && !m.isLeader {
+    if oldIsLeader && !isLeader {
         m.onLoseLeadership()
     }
-    
-    // 清除缓存，确保节点信息反映最新的领导者状态
-    m.cacheMu.Lock()
-    m.nodeCache = make(map[string]nodeInfoCache)
-    m.cacheMu.Unlock()
 }
 
 // handleNodeStateChange 处理节点状态变更事件
 func (m *ClusterManager) handleNodeStateChange(change heartbeat.StateChange) {
     m.logger.Info("节点状态变更", 
         "node_id", change.NodeID, 
-        "state", change.State)
+        "state", change.State,
+        "reaped", change.Reaped)
+    
+    if change.Reaped {
+        m.handleNodeReaped(change.NodeID)
+        return
+    }
     
-    // 更新集群状态
+    // 更新集群状态并清除对应节点的缓存
     m.state.mu.Lock()
     m.state.nodes[change.NodeID] = change.State
+    m.invalidateNodeCache(change.NodeID)
     m.state.mu.Unlock()
+    m.publishEvent(EventNodeStatus, change.NodeID, change.State)
     
-    // 清除对应节点的缓存
-    m.cacheMu.Lock()
-    delete(m.nodeCache, change.NodeID)
-    m.cacheMu.Unlock()
+    // 同步健康状况到负载均衡安全联锁
+    m.rebalanceMgr.UpdateClusterHealth(m.GetHealthyNodeCount(), m.GetNodeCount())
     
     // 对节点状态变更做出反应
     switch change.State {
     case types.NodeStatusDead:
-        // 节点死亡，如果是集群成员则移除
-        if m.IsLeader() {
-            m.logger.Info("检测到节点死亡，尝试从集群中移除", "node_id", change.NodeID)
-            if err := m.RemovePeer(change.NodeID); err != nil {
-                m.logger.Error("从集群中移除死亡节点失败", "node_id", change.NodeID, "error", err)
-            }
-        }
+        // 死亡节点仍保留在集群成员中，超过清理延迟后才被永久移除，期间恢复心跳即可重新成为健康节点
+        m.logger.Warn("检测到节点死亡，等待清理延迟后移除", "node_id", change.NodeID)
     case types.NodeStatusHealthy:
         // 节点恢复健康，如果是领导者且节点不在集群中，考虑添加回集群
-        if m.IsLeader() && !m.isPeerActive(change.NodeID) {
+        if m.IsLeader() && !m.IsPeerActive(change.NodeID) {
             m.logger.Info("检测到节点恢复健康，尝试添加回集群", "node_id", change.NodeID)
             if err := m.AddPeer(change.NodeID); err != nil {
                 m.logger.Error("将恢复的节点添加回集群失败", "node_id", change.NodeID, "error", err)
@@ -268,10 +456,46 @@ func (m *ClusterManager) handleNodeStateChange(change heartbeat.StateChange) {
     }
 }
 
-// 检查节点是否已经在活跃的集群成员中
-func (m *ClusterManager) isPeerActive(nodeID string) bool {
-    // TODO: 实现检查节点是否在活跃的集群成员中的逻辑
-    // 这需要依赖于electionMgr提供获取当前成员列表的方法
+// handleNodeReaped 处理被心跳管理器永久移除的死亡节点
+// 领导者同时提议将其从Raft成员中移除，避免残留无法响应的成员
+func (m *ClusterManager) handleNodeReaped(nodeID string) {
+    m.state.mu.Lock()
+    delete(m.state.nodes, nodeID)
+    delete(m.state.members, nodeID)
+    m.invalidateNodeCache(nodeID)
+    m.state.mu.Unlock()
+    m.metricsHistory.remove(nodeID)
+    m.publishEvent(EventNodeReaped, nodeID, nil)
+    
+    m.rebalanceMgr.UpdateClusterHealth(m.GetHealthyNodeCount(), m.GetNodeCount())
+    
+    if !m.IsLeader() {
+        return
+    }
+    
+    m.logger.Info("死亡节点已被清理，从集群成员中移除", "node_id", nodeID)
+    if err := m.electionMgr.RemovePeer(nodeID); err != nil {
+        m.logger.Error("从集群成员中移除已清理节点失败", "node_id", nodeID, "error", err)
+    }
+}
+
+// peerLister 能列出当前Raft成员的选举管理器，由election.Manager实现
+type peerLister interface {
+    GetPeers() []string
+}
+
+// IsPeerActive 检查节点是否已经在Raft成员配置中，已是成员的节点恢复健康时不必重新加入。
+// 选举管理器不能列出成员时返回false
+func (m *ClusterManager) IsPeerActive(nodeID string) bool {
+    lister, ok := m.electionMgr.(peerLister)
+    if !ok {
+        return false
+    }
+    for _, peer := range lister.GetPeers() {
+        if peer == nodeID {
+            return true
+        }
+    }
     return false
 }
 
@@ -292,20 +516,27 @@ func (m *ClusterManager) Stop(ctx context.Context) error {
     
     // 关闭通道，避免goroutine泄漏
     close(m.leaderChangeCh)
+    m.events.close()
     
-    // 按照依赖关系的逆序停止
+    // 按照依赖关系的逆序停止，每个子系统的等待时间单独受限，一个子系统卡住不影响其余子系统停止
     var errs []error
     
-    if err := m.rebalanceMgr.Stop(); err != nil {
-        errs = append(errs, fmt.Errorf("停止负载均衡管理器失败: %w", err))
+    if m.cfg.RebalanceEnabled() {
+        if err := m.stopSubsystem(ctx, "负载均衡管理器", m.rebalanceMgr.Stop); err != nil {
+            errs = append(errs, err)
+        }
     }
     
-    if err := m.electionMgr.Stop(); err != nil {
-        errs = append(errs, fmt.Errorf("停止选举管理器失败: %w", err))
+    if err := m.stopSubsystem(ctx, "选举管理器", m.electionMgr.Stop); err != nil {
+        errs = append(errs, err)
     }
     
-    if err := m.heartbeatMgr.Stop(); err != nil {
-        errs = append(errs, fmt.Errorf("停止心跳管理器失败: %w", err))
+    if err := m.stopSubsystem(ctx, "心跳管理器", m.heartbeatMgr.Stop); err != nil {
+        errs = append(errs, err)
+    }
+    
+    if m.peerRefresher != nil {
+        m.peerRefresher.Stop()
     }
     
     if len(errs) > 0 {
@@ -315,11 +546,109 @@ func (m *ClusterManager) Stop(ctx context.Context) error {
     return nil
 }
 
+// stopSubsystem 调用子系统的Stop，最多等待SubsystemStopTimeout且不超过ctx的截止时间。
+// Stop总会被调用；超时后放弃等待，Stop在后台继续执行，集群管理器不再使用该子系统
+func (m *ClusterManager) stopSubsystem(ctx context.Context, name string, stop func() error) error {
+    timeout := m.cfg.SubsystemStopTimeout
+    if timeout <= 0 {
+        timeout = metaconfig.DefaultSubsystemStopTimeout
+    }
+    stopCtx, cancel := context.WithTimeout(ctx, timeout)
+    defer cancel()
+    
+    done := make(chan error, 1)
+    go func() {
+        if err := stop(); err != nil {
+            done <- fmt.Errorf("停止%s失败: %w", name, err)
+            return
+        }
+        done <- nil
+    }()
+    
+    select {
+    case err := <-done:
+        return err
+    case <-stopCtx.Done():
+        // 截止时间与Stop返回同时到达时以Stop的结果为准
+        select {
+        case err := <-done:
+            return err
+        default:
+        }
+        m.logger.Error("停止子系统超时，放弃等待", "subsystem", name, "timeout", timeout, "error", stopCtx.Err())
+        return fmt.Errorf("停止%s超时: %w", name, stopCtx.Err())
+    }
+}
+
 // IsLeader 检查当前节点是否为领导者
 func (m *ClusterManager) IsLeader() bool {
     return m.electionMgr.IsLeader()
 }
 
+// raftHandlerProvider 能接收其他节点Raft消息的选举管理器，由election.Manager实现
+type raftHandlerProvider interface {
+    RaftHandler() http.Handler
+}
+
+// RaftHandler 返回接收其他节点Raft消息的HTTP处理器，选举管理器不通过HTTP传递消息时返回nil
+func (m *ClusterManager) RaftHandler() http.Handler {
+    if provider, ok := m.electionMgr.(raftHandlerProvider); ok {
+        return provider.RaftHandler()
+    }
+    return nil
+}
+
+// commandProposer 能通过Raft提交命令并等待状态机应用的选举管理器，由election.Manager实现
+type commandProposer interface {
+    ProposeCommandLevel(ctx context.Context, cmd raft.Command, level raft.ConsistencyLevel) (uint64, error)
+}
+
+// commandApplierSetter 能注册状态机的选举管理器，由election.Manager实现
+type commandApplierSetter interface {
+    SetCommandApplier(applier election.CommandApplier)
+}
+
+// stateSnapshotterSetter 能注册状态机快照的选举管理器，由election.Manager实现
+type stateSnapshotterSetter interface {
+    SetStateSnapshotter(snapshotter election.StateSnapshotter)
+}
+
+// ProposeWrite 将写操作命令提交到Raft，按一致性级别等待确认（为空时使用raft.DefaultConsistencyLevel，含义见
+// election.Manager.ProposeCommandLevel），quorum和all级别下命令已提交但状态机应用失败时返回状态机的错误；
+// 超过共识配置的ApplyTimeout仍未确认时返回包装了raft.ErrApplyTimeout的错误。
+// 选举管理器不支持提交命令时直接交给WithCommandApplier设置的状态机应用
+func (m *ClusterManager) ProposeWrite(ctx context.Context, cmd raft.Command, level raft.ConsistencyLevel) error {
+    proposer, ok := m.electionMgr.(commandProposer)
+    if !ok {
+        if m.applier == nil {
+            return nil
+        }
+        return m.applier.ApplyCommand(ctx, cmd)
+    }
+    if _, err := proposer.ProposeCommandLevel(ctx, cmd, level); err != nil {
+        return fmt.Errorf("提交写入失败: %w", err)
+    }
+    return nil
+}
+
+// linearizableReader 能执行线性一致读的选举管理器，由election.Manager实现
+type linearizableReader interface {
+    LinearizableRead(ctx context.Context) error
+}
+
+// LinearizableRead 等到本节点的状态机应用了调用之前已提交的所有写入后返回，之后读取本地元数据即为线性一致读；
+// 选举管理器不支持时写入直接应用到本地存储，立即返回
+func (m *ClusterManager) LinearizableRead(ctx context.Context) error {
+    reader, ok := m.electionMgr.(linearizableReader)
+    if !ok {
+        return nil
+    }
+    if err := reader.LinearizableRead(ctx); err != nil {
+        return fmt.Errorf("线性一致读失败: %w", err)
+    }
+    return nil
+}
+
 // GetCurrentLeader 获取当前领导者节点ID
 func (m *ClusterManager) GetCurrentLeader() string {
     // 优先从缓存的状态获取领导者ID
@@ -335,27 +664,58 @@ func (m *ClusterManager) GetCurrentLeader() string {
     return m.electionMgr.GetCurrentLeader()
 }
 
-// RegisterNode 注册新的集群节点
+// RegisterNode 注册新的集群节点，重复注册不会重置节点已有的心跳状态
 func (m *ClusterManager) RegisterNode(nodeID string) {
-    m.logger.Info("注册新节点", "node_id", nodeID)
-    m.heartbeatMgr.RegisterNode(nodeID)
+    if m.heartbeatMgr.RegisterNode(nodeID) {
+        m.logger.Info("注册新节点", "node_id", nodeID)
+    }
 }
 
 // UnregisterNode 取消注册集群节点
 func (m *ClusterManager) UnregisterNode(nodeID string) {
     m.logger.Info("注销节点", "node_id", nodeID)
     m.heartbeatMgr.UnregisterNode(nodeID)
+    m.metricsHistory.remove(nodeID)
     
     // 清除该节点的缓存
-    m.cacheMu.Lock()
-    delete(m.nodeCache, nodeID)
-    m.cacheMu.Unlock()
+    m.invalidateNodeCache(nodeID)
 }
 
 // AddPeer 添加新的集群节点到选举组
+// 已是成员的节点直接返回；配置了MaxClusterSize时，成员数达到上限后拒绝添加
 func (m *ClusterManager) AddPeer(peerID string) error {
+    m.membershipMu.Lock()
+    defer m.membershipMu.Unlock()
+    
+    return m.addPeerLocked(peerID)
+}
+
+// addPeerLocked 实现AddPeer，调用方需持有membershipMu
+func (m *ClusterManager) addPeerLocked(peerID string) error {
+    m.state.mu.RLock()
+    _, exists := m.state.members[peerID]
+    size := len(m.state.members)
+    m.state.mu.RUnlock()
+    
+    if exists {
+        m.logger.Debug("节点已是集群成员", "peer_id", peerID)
+        return nil
+    }
+    if m.cfg.MaxClusterSize > 0 && size >= m.cfg.MaxClusterSize {
+        m.logger.Warn("集群规模已达上限，拒绝添加节点",
+            "peer_id", peerID,
+            "cluster_size", size,
+            "max_cluster_size", m.cfg.MaxClusterSize)
+        return fmt.Errorf("%w: 当前%d个成员，上限为%d", ErrClusterFull, size, m.cfg.MaxClusterSize)
+    }
+    
     m.logger.Info("添加节点到集群", "peer_id", peerID)
-    return m.electionMgr.AddPeer(peerID)
+    // 选举管理器已有该成员（如初始成员）时只需补充记录
+    if err := m.electionMgr.AddPeer(peerID); err != nil && !errors.Is(err, election.ErrPeerExists) {
+        return err
+    }
+    m.addMember(Member{NodeID: peerID})
+    return nil
 }
 
 // RemovePeer 从选举组中移除集群节点
@@ -368,39 +728,186 @@ func (m *ClusterManager) RemovePeer(peerID string) error {
         return fmt.Errorf("移除节点失败: %w", err)
     }
     
+    m.state.mu.Lock()
+    delete(m.state.members, peerID)
+    m.state.mu.Unlock()
+    
     // 同时从心跳管理中注销节点
     m.UnregisterNode(peerID)
     return nil
 }
 
+// rebalanceDegraded 返回负载均衡管理器启动失败的原因，nil表示正常运行
+func (m *ClusterManager) rebalanceDegraded() error {
+    m.state.mu.RLock()
+    defer m.state.mu.RUnlock()
+    return m.rebalanceErr
+}
+
+// RebalanceEnabled 返回配置是否启用了负载均衡，禁用时负载均衡管理器不会启动
+func (m *ClusterManager) RebalanceEnabled() bool {
+    return m.cfg.RebalanceEnabled()
+}
+
 // TriggerRebalance 手动触发负载均衡
 func (m *ClusterManager) TriggerRebalance() {
+    if !m.cfg.RebalanceEnabled() {
+        m.logger.Warn("负载均衡已通过配置禁用，忽略触发请求")
+        return
+    }
+    
+    if err := m.rebalanceDegraded(); err != nil {
+        m.logger.Warn("负载均衡处于降级模式，忽略触发请求", "error", err)
+        return
+    }
+    
     // 只有领导者节点才能触发负载均衡
     if !m.IsLeader() {
         m.logger.Warn("只有领导者节点才能触发负载均衡")
         return
     }
     
+    // 集群不稳定时由安全联锁阻止迁移，这里仅提前提示
+    if status := m.rebalanceMgr.InterlockStatus(); status.Engaged {
+        m.logger.Warn("负载均衡安全联锁生效，本次触发不会生成迁移计划", "reason", status.Reason)
+    }
+    
     m.logger.Info("手动触发负载均衡")
     m.rebalanceMgr.TriggerRebalance()
 }
 
-// GetRebalanceStatus 获取负载均衡状态
+// GetRebalanceStatus 获取负载均衡状态，配置禁用时只返回disabled，降级模式下只返回降级原因
 func (m *ClusterManager) GetRebalanceStatus() map[string]interface{} {
-    return m.rebalanceMgr.GetStatus()
+    if !m.cfg.RebalanceEnabled() {
+        return map[string]interface{}{
+            "enabled":  false,
+            "degraded": false,
+            "disabled": true,
+        }
+    }
+    
+    if err := m.rebalanceDegraded(); err != nil {
+        return map[string]interface{}{
+            "enabled":  false,
+            "degraded": true,
+            "error":    err.Error(),
+        }
+    }
+    
+    status := m.rebalanceMgr.GetStatus()
+    status["enabled"] = true
+    status["degraded"] = false
+    return status
+}
+
+// GetRebalanceTask 获取指定迁移任务的状态
+func (m *ClusterManager) GetRebalanceTask(taskID string) (*rebalance.MigrationTask, bool) {
+    return m.rebalanceMgr.GetTaskStatus(taskID)
+}
+
+// GetBalanceWeights 获取加权得分策略当前使用的权重
+// 只有领导者执行再平衡，非领导者返回ErrNotLeader，避免展示不生效的本地权重
+func (m *ClusterManager) GetBalanceWeights() (rebalance.ScoreWeights, error) {
+    if !m.IsLeader() {
+        return rebalance.ScoreWeights{}, fmt.Errorf("%w, 领导者为%s", ErrNotLeader, m.GetCurrentLeader())
+    }
+    return m.rebalanceMgr.Weights()
+}
+
+// UpdateBalanceWeights 运行时更新加权得分策略的权重，校验并归一化后从下一次评估开始生效，返回实际生效的权重
+// 权重只保存在领导者内存中，领导者变更或重启后恢复默认值
+func (m *ClusterManager) UpdateBalanceWeights(weights rebalance.ScoreWeights) (rebalance.ScoreWeights, error) {
+    if !m.IsLeader() {
+        return rebalance.ScoreWeights{}, fmt.Errorf("%w, 领导者为%s", ErrNotLeader, m.GetCurrentLeader())
+    }
+    return m.rebalanceMgr.UpdateWeights(weights)
+}
+
+// ValidateNodeMetrics 检查上报的节点指标，单节点和批量上报使用相同的规则
+func ValidateNodeMetrics(nodeID string, metrics *types.NodeMetrics) error {
+    if nodeID == "" {
+        return fmt.Errorf("节点ID不能为空")
+    }
+    if metrics == nil {
+        return fmt.Errorf("节点%s的指标不能为空", nodeID)
+    }
+    if metrics.NodeID != "" && string(metrics.NodeID) != nodeID {
+        return fmt.Errorf("指标中的节点ID%s与%s不一致", metrics.NodeID, nodeID)
+    }
+    return metrics.Validate()
+}
+
+// UpdateNodeMetricsBatch 批量更新节点指标
+// 先校验全部指标，任一节点不合法时整批拒绝并返回各节点的错误，否则全部写入并返回nil
+func (m *ClusterManager) UpdateNodeMetricsBatch(batch map[string]*types.NodeMetrics) map[string]error {
+    var invalid map[string]error
+    for nodeID, metrics := range batch {
+        if err := ValidateNodeMetrics(nodeID, metrics); err != nil {
+            if invalid == nil {
+                invalid = make(map[string]error)
+            }
+            invalid[nodeID] = err
+        }
+    }
+    if invalid != nil {
+        return invalid
+    }
+    
+    for nodeID, metrics := range batch {
+        metrics.NodeID = types.NodeID(nodeID)
+        m.UpdateNodeMetrics(nodeID, metrics)
+    }
+    return nil
 }
 
 // UpdateNodeMetrics 更新节点度量指标
 func (m *ClusterManager) UpdateNodeMetrics(nodeID string, metrics *types.NodeMetrics) {
     m.rebalanceMgr.UpdateNodeMetrics(nodeID, metrics)
+    m.metricsHistory.record(nodeID, metrics, time.Now())
     
     // 更新后清除该节点的缓存，确保下次获取能拿到最新指标
-    m.cacheMu.Lock()
-    delete(m.nodeCache, nodeID)
-    m.cacheMu.Unlock()
+    m.invalidateNodeCache(nodeID)
+}
+
+// GetNodeMetricsHistory 按时间顺序返回节点最近的指标样本，节点没有上报过指标时返回false
+func (m *ClusterManager) GetNodeMetricsHistory(nodeID string, query MetricsHistoryQuery) ([]MetricsSample, bool) {
+    return m.metricsHistory.query(nodeID, query)
+}
+
+// ReloadConfig 运行时应用可安全重载的集群配置：负载均衡阈值和心跳超时
+// 其余配置（成员、选举参数等）只在启动时读取，变更需要重启
+func (m *ClusterManager) ReloadConfig(cfg metaconfig.ClusterConfig) {
+    m.rebalanceMgr.UpdateThresholds(cfg.ImbalanceThreshold, cfg.ImbalanceStopRatio)
+    m.heartbeatMgr.SetTimeouts(cfg.SuspectTimeout, cfg.DeadTimeout)
+
+    m.logger.Info("集群配置已重载",
+        "imbalance_threshold", cfg.ImbalanceThreshold,
+        "imbalance_stop_ratio", cfg.ImbalanceStopRatio,
+        "suspect_timeout", cfg.SuspectTimeout,
+        "dead_timeout", cfg.DeadTimeout)
+}
+
+// notifyLeaderChange 将最新的领导者放入外部通知通道。
+// 通道中只保留一个值：消费者来不及读取时用新领导者替换尚未读取的旧值，
+// 中间的变更可能被跳过，但消费者最终总能看到当前领导者。只在事件循环中调用
+func (m *ClusterManager) notifyLeaderChange(leaderID string) {
+    for {
+        select {
+        case m.leaderChangeCh <- leaderID:
+            return
+        default:
+        }
+        // 取出尚未被读取的旧值；消费者恰好已经读走时什么也不做，下一轮即可发送成功
+        select {
+        case stale := <-m.leaderChangeCh:
+            m.logger.Debug("领导者变更通知被合并", "skipped_leader", stale, "leader_id", leaderID)
+        default:
+        }
+    }
 }
 
-// LeaderChangeChan 返回领导者变更通知通道
+// LeaderChangeChan 返回领导者变更通知通道。
+// 通道只保存最新的领导者，快速连续变更时中间值可能被跳过，读到的值总是不早于上次读取时的领导者
 func (m *ClusterManager) LeaderChangeChan() <-chan string {
     return m.leaderChangeCh
 }
@@ -409,6 +916,12 @@ func (m *ClusterManager) LeaderChangeChan() <-chan string {
 func (m *ClusterManager) onBecomeLeader() {
     m.logger.Info("本节点成为集群领导者")
     
+    // 新领导者可能还没有收到各节点的心跳，宽限期内不判定节点死亡，避免过早移除成员
+    if grace := m.cfg.LeaderGracePeriod; grace > 0 {
+        m.heartbeatMgr.SuppressDeathUntil(time.Now().Add(grace))
+        m.logger.Info("节点死亡判定宽限期", "grace_period", grace)
+    }
+    
     // 领导者节点负责触发负载均衡等操作
     go func() {
         // 等待一段时间再触发负载均衡，给系统一些稳定时间
@@ -431,6 +944,7 @@ func (m *ClusterManager) onLoseLeadership() {
 }
 
 // ListNodes 获取当前集群所有节点信息
+// 上下文在获取过程中取消或超时时，返回已获取的节点信息（剩余节点不含指标）以及上下文错误
 func (m *ClusterManager) ListNodes(ctx context.Context) ([]types.NodeInfo, error) {
     m.logger.Info("获取集群节点列表")
     
@@ -439,6 +953,9 @@ func (m *ClusterManager) ListNodes(ctx context.Context) ([]types.NodeInfo, error
         return nil, fmt.Errorf("获取节点列表中断: %w", err)
     }
     
+    // 先记录缓存代数，构建期间缓存失效时不回写可能过期的节点信息
+    gen := m.cacheGeneration()
+    
     // 获取心跳管理器中的节点状态
     nodeStates := m.heartbeatMgr.GetAllNodeStates()
     
@@ -448,26 +965,35 @@ func (m *ClusterManager) ListNodes(ctx context.Context) ([]types.NodeInfo, error
     
     // 构建返回结果
     nodes := make([]types.NodeInfo, 0, len(nodeStates))
+    var misses []types.NodeInfo
     
     // 遍历所有节点
     for nodeID, state := range nodeStates {
         // 尝试从缓存获取
         cachedInfo := m.getCachedNodeInfo(nodeID)
         if cachedInfo != nil {
+            // 心跳不会使缓存失效，最后心跳时间总是实时读取
+            cachedInfo.LastSeen = m.nodeLastSeen(nodeID)
             nodes = append(nodes, *cachedInfo)
             continue
         }
         
-        // 缓存未命中，构建基本节点信息
-        nodeInfo := m.buildNodeInfo(nodeID, state, leaderID)
-        
-        // 获取并添加节点指标数据
-        m.addMetricsToNodeInfo(&nodeInfo, nodeID)
-        
-        // 更新缓存
-        m.updateNodeInfoCache(nodeID, &nodeInfo)
-        
-        nodes = append(nodes, nodeInfo)
+        // 缓存未命中，构建基本节点信息，指标稍后并发获取
+        misses = append(misses, m.buildNodeInfo(nodeID, state, leaderID))
+    }
+    
+    // 获取并添加节点指标数据，超时后的节点信息不完整，不写入缓存
+    fetched := m.fetchNodeMetrics(ctx, misses)
+    for i := range misses {
+        if fetched[i] {
+            m.updateNodeInfoCache(string(misses[i].NodeID), &misses[i], gen)
+        }
+        nodes = append(nodes, misses[i])
+    }
+    
+    if err := ctx.Err(); err != nil {
+        m.logger.Warn("获取节点指标超时，返回部分节点信息", "count", len(nodes), "error", err)
+        return nodes, fmt.Errorf("获取节点列表未完成: %w", err)
     }
     
     m.logger.Debug("获取到节点列表", "count", len(nodes))
@@ -489,11 +1015,38 @@ func (m *ClusterManager) getCachedNodeInfo(nodeID string) *types.NodeInfo {
     return nil
 }
 
-// updateNodeInfoCache 更新节点信息缓存
-func (m *ClusterManager) updateNodeInfoCache(nodeID string, info *types.NodeInfo) {
+// cacheGeneration 返回当前缓存代数，应在读取构建节点信息所需的状态之前获取
+func (m *ClusterManager) cacheGeneration() uint64 {
+    m.cacheMu.RLock()
+    defer m.cacheMu.RUnlock()
+    return m.cacheGen
+}
+
+// invalidateNodeCache 清除指定节点的缓存，未指定节点时清除全部缓存
+// 调用方可以持有state.mu，但不能持有cacheMu
+func (m *ClusterManager) invalidateNodeCache(nodeIDs ...string) {
+    m.cacheMu.Lock()
+    defer m.cacheMu.Unlock()
+    
+    m.cacheGen++
+    if len(nodeIDs) == 0 {
+        m.nodeCache = make(map[string]nodeInfoCache)
+        return
+    }
+    for _, nodeID := range nodeIDs {
+        delete(m.nodeCache, nodeID)
+    }
+}
+
+// updateNodeInfoCache 更新节点信息缓存，gen之后缓存发生过失效时放弃写入
+func (m *ClusterManager) updateNodeInfoCache(nodeID string, info *types.NodeInfo, gen uint64) {
     m.cacheMu.Lock()
     defer m.cacheMu.Unlock()
     
+    if gen != m.cacheGen {
+        return
+    }
+    
     // 创建一个副本存入缓存
     infoCopy := *info
     m.nodeCache[nodeID] = nodeInfoCache{
@@ -511,24 +1064,113 @@ func (m *ClusterManager) buildNodeInfo(nodeID string, state types.NodeStatus, le
         NodeID:    types.NodeID(nodeID),
         Status:    status,
         IsLeader:  nodeID == leaderID,
-        LastSeen:  time.Now().Unix(),
+        LastSeen:  m.nodeLastSeen(nodeID),
         Address:   nodeID,
     }
 }
 
+// nodeLastSeen 返回节点最后一次心跳的Unix时间戳，节点未被心跳监控时返回0
+func (m *ClusterManager) nodeLastSeen(nodeID string) int64 {
+    lastHeartbeat := m.heartbeatMgr.GetNodeLastHeartbeat(nodeID)
+    if lastHeartbeat.IsZero() {
+        return 0
+    }
+    return lastHeartbeat.Unix()
+}
+
 // convertNodeStatus 将心跳状态转换为通用节点状态
 func (m *ClusterManager) convertNodeStatus(status types.NodeStatus) types.NodeStatus {
     return status
 }
 
-// addMetricsToNodeInfo 向节点信息中添加性能指标数据
-func (m *ClusterManager) addMetricsToNodeInfo(nodeInfo *types.NodeInfo, nodeID string) {
-    metrics := m.rebalanceMgr.GetNodeMetrics(nodeID)
+// listNodesWorkers 列出节点时并发获取指标的最大goroutine数
+const listNodesWorkers = 8
+
+// nodeMetricsResult 工作goroutine获取到的单个节点指标
+type nodeMetricsResult struct {
+    index   int
+    metrics *types.NodeMetrics
+}
+
+// fetchNodeMetrics 由最多listNodesWorkers个goroutine并发获取各节点的指标并写入nodes，
+// 返回每个节点是否已取得指标。上下文结束时不再等待，已开始的获取在后台完成后丢弃，
+// 因此滞留的goroutine数同样不超过listNodesWorkers
+func (m *ClusterManager) fetchNodeMetrics(ctx context.Context, nodes []types.NodeInfo) []bool {
+    fetched := make([]bool, len(nodes))
+    if len(nodes) == 0 || ctx.Err() != nil {
+        return fetched
+    }
+    
+    // 工作goroutine只读取这份节点ID，调用方返回后继续修改nodes也不会产生竞争；
+    // 任务和结果通道都有足够的缓冲，工作goroutine在调用方返回后也不会阻塞
+    ids := make([]string, len(nodes))
+    jobs := make(chan int, len(nodes))
+    for i := range nodes {
+        ids[i] = string(nodes[i].NodeID)
+        jobs <- i
+    }
+    close(jobs)
+    results := make(chan nodeMetricsResult, len(nodes))
+    
+    workers := listNodesWorkers
+    if workers > len(nodes) {
+        workers = len(nodes)
+    }
+    for w := 0; w < workers; w++ {
+        go func() {
+            for i := range jobs {
+                if ctx.Err() != nil {
+                    return
+                }
+                results <- nodeMetricsResult{index: i, metrics: m.rebalanceMgr.GetNodeMetrics(ids[i])}
+            }
+        }()
+    }
+    
+    for received := 0; received < len(nodes); received++ {
+        select {
+        case res := <-results:
+            fetched[res.index] = true
+            setNodeMetrics(&nodes[res.index], res.metrics)
+        case <-ctx.Done():
+            return fetched
+        }
+    }
+    return fetched
+}
+
+// setNodeMetrics 把指标写入节点信息，metrics为nil时不修改
+func setNodeMetrics(nodeInfo *types.NodeInfo, metrics *types.NodeMetrics) {
     if metrics == nil {
         return
-    }   
+    }
     // 直接复用获取到的metrics对象而不是创建新的
     nodeInfo.Metrics = metrics
+    nodeInfo.Labels = types.CloneLabels(metrics.Labels)
+}
+
+// addMetricsToNodeInfo 向节点信息中添加性能指标数据
+// 上下文结束前未能取得指标时返回false，此时节点信息不含指标
+func (m *ClusterManager) addMetricsToNodeInfo(ctx context.Context, nodeInfo *types.NodeInfo, nodeID string) bool {
+    if ctx.Err() != nil {
+        return false
+    }
+    
+    // 指标获取可能阻塞，在独立的goroutine中执行以响应上下文取消
+    result := make(chan *types.NodeMetrics, 1)
+    go func() {
+        result <- m.rebalanceMgr.GetNodeMetrics(nodeID)
+    }()
+    
+    var metrics *types.NodeMetrics
+    select {
+    case metrics = <-result:
+    case <-ctx.Done():
+        return false
+    }
+    
+    setNodeMetrics(nodeInfo, metrics)
+    return true
 }
 
 // GetNodeInfo 获取指定节点的详细信息
@@ -541,10 +1183,12 @@ func (m *ClusterManager) GetNodeInfo(ctx context.Context, nodeID string) (*types
     // 先检查缓存
     cachedInfo := m.getCachedNodeInfo(nodeID)
     if cachedInfo != nil {
+        cachedInfo.LastSeen = m.nodeLastSeen(nodeID)
         return cachedInfo, nil
     }
     
     // 缓存未命中，执行原有逻辑
+    gen := m.cacheGeneration()
     leaderID := m.GetCurrentLeader()
     
     // 从心跳管理器获取节点状态
@@ -557,10 +1201,12 @@ func (m *ClusterManager) GetNodeInfo(ctx context.Context, nodeID string) (*types
     nodeInfo := m.buildNodeInfo(nodeID, nodeStatus, leaderID)
     
     // 获取并添加节点指标数据
-    m.addMetricsToNodeInfo(&nodeInfo, nodeID)
+    if !m.addMetricsToNodeInfo(ctx, &nodeInfo, nodeID) {
+        return nil, fmt.Errorf("获取节点 %s 的指标中断: %w", nodeID, ctx.Err())
+    }
     
     // 更新缓存
-    m.updateNodeInfoCache(nodeID, &nodeInfo)
+    m.updateNodeInfoCache(nodeID, &nodeInfo, gen)
     
     return &nodeInfo, nil
 }
@@ -610,17 +1256,55 @@ func (m *ClusterManager) LastElectionTime() time.Time {
     return time.Now()
 }
 
+// defaultSnapshotTimeout 调用方未设置截止时间时，获取集群快照的最长耗时
+const defaultSnapshotTimeout = 5 * time.Second
+
 // GetClusterSnapshot 获取当前集群状态快照
-func (m *ClusterManager) GetClusterSnapshot() map[string]interface{} {
-    nodes, _ := m.ListNodes(context.Background())
+// ctx没有截止时间时最多等待defaultSnapshotTimeout；超时后返回部分节点信息，并将partial置为true。
+// 领导者未知或获取节点失败时，若有StatusCacheTTL内的完整快照则返回该快照，
+// 并将stale置为true、cached_at置为快照时间、stale_reason说明原因
+func (m *ClusterManager) GetClusterSnapshot(ctx context.Context) map[string]interface{} {
+    if _, ok := ctx.Deadline(); !ok {
+        var cancel context.CancelFunc
+        ctx, cancel = context.WithTimeout(ctx, defaultSnapshotTimeout)
+        defer cancel()
+    }
+    
+    nodes, err := m.ListNodes(ctx)
+    leaderID := m.GetCurrentLeader()
     
     snapshot := map[string]interface{}{
+        "nodes":            nodes,
         "total_nodes":      len(nodes),
         "healthy_nodes":    m.GetHealthyNodeCount(),
-        "leader_id":        m.GetCurrentLeader(),
+        "leader_id":        leaderID,
         "last_election":    m.LastElectionTime(),
         "rebalance_status": m.GetRebalanceStatus(),
+        "partial":          err != nil,
+        "stale":            false,
+    }
+    if err != nil {
+        snapshot["error"] = err.Error()
     }
     
-    return snapshot
+    now := time.Now()
+    if err == nil && leaderID != "" {
+        m.statusCache.store(snapshot, now)
+        return snapshot
+    }
+    
+    // 无法获取完整的实时状态，优先返回最近的完整快照
+    cached, cachedAt, ok := m.statusCache.load(now)
+    if !ok {
+        return snapshot
+    }
+    reason := "集群领导者未知"
+    if err != nil {
+        reason = err.Error()
+    }
+    cached["stale"] = true
+    cached["cached_at"] = cachedAt
+    cached["stale_reason"] = reason
+    m.logger.Warn("无法获取实时集群状态，返回缓存的快照", "reason", reason, "cached_at", cachedAt)
+    return cached
 }
\ No newline at end of file
@@ -0,0 +1,149 @@
+package cluster
+
+import (
+	"sync"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/types"
+)
+
+// MetricsSample 某一时刻的节点指标
+type MetricsSample struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Metrics   types.NodeMetrics `json:"metrics"`
+}
+
+// MetricsHistoryQuery 查询节点指标历史的条件
+type MetricsHistoryQuery struct {
+	Since     time.Time // 只返回该时间之后的样本，零值表示全部
+	MaxPoints int       // 样本数超过该值时降采样，0表示不降采样
+}
+
+// metricsHistory 为每个节点保留最近capacity个指标样本的环形缓冲区，供容量规划查看趋势
+type metricsHistory struct {
+	mu       sync.RWMutex
+	capacity int
+	nodes    map[string]*sampleRing
+}
+
+// sampleRing 单个节点的样本环形缓冲区，按时间先后追加
+type sampleRing struct {
+	samples []MetricsSample
+	next    int // 下一个写入位置
+	full    bool
+}
+
+// newMetricsHistory 创建指标历史，capacity不大于0时不保留历史
+func newMetricsHistory(capacity int) *metricsHistory {
+	return &metricsHistory{
+		capacity: capacity,
+		nodes:    make(map[string]*sampleRing),
+	}
+}
+
+// record 追加一个样本，超过容量时覆盖该节点最早的样本
+func (h *metricsHistory) record(nodeID string, metrics *types.NodeMetrics, at time.Time) {
+	if h.capacity <= 0 || metrics == nil {
+		return
+	}
+	sample := MetricsSample{Timestamp: at, Metrics: *metrics}
+	sample.Metrics.Labels = types.CloneLabels(metrics.Labels)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ring, ok := h.nodes[nodeID]
+	if !ok {
+		ring = &sampleRing{samples: make([]MetricsSample, h.capacity)}
+		h.nodes[nodeID] = ring
+	}
+	ring.samples[ring.next] = sample
+	ring.next = (ring.next + 1) % h.capacity
+	if ring.next == 0 {
+		ring.full = true
+	}
+}
+
+// remove 删除节点的全部历史
+func (h *metricsHistory) remove(nodeID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.nodes, nodeID)
+}
+
+// query 按时间顺序返回节点满足条件的样本，节点没有历史时返回false
+func (h *metricsHistory) query(nodeID string, q MetricsHistoryQuery) ([]MetricsSample, bool) {
+	h.mu.RLock()
+	ring, ok := h.nodes[nodeID]
+	if !ok {
+		h.mu.RUnlock()
+		return nil, false
+	}
+	ordered := ring.ordered()
+	h.mu.RUnlock()
+
+	result := make([]MetricsSample, 0, len(ordered))
+	for _, sample := range ordered {
+		if !q.Since.IsZero() && sample.Timestamp.Before(q.Since) {
+			continue
+		}
+		result = append(result, sample)
+	}
+	return downsample(result, q.MaxPoints), true
+}
+
+// ordered 返回从旧到新排列的样本副本，调用方需持有读锁
+func (r *sampleRing) ordered() []MetricsSample {
+	if !r.full {
+		return append([]MetricsSample(nil), r.samples[:r.next]...)
+	}
+	result := make([]MetricsSample, 0, len(r.samples))
+	result = append(result, r.samples[r.next:]...)
+	return append(result, r.samples[:r.next]...)
+}
+
+// downsample 将样本按顺序均分为maxPoints组，每组合并为一个样本。
+// 使用率、吞吐、负载等瞬时值取组内平均值，容量、分片数、健康状态、标签等取组内最后一个样本的值，
+// 时间戳为组内最后一个样本的时间
+func downsample(samples []MetricsSample, maxPoints int) []MetricsSample {
+	if maxPoints <= 0 || len(samples) <= maxPoints {
+		return samples
+	}
+
+	result := make([]MetricsSample, 0, maxPoints)
+	for i := 0; i < maxPoints; i++ {
+		start := i * len(samples) / maxPoints
+		end := (i + 1) * len(samples) / maxPoints
+		result = append(result, mergeSamples(samples[start:end]))
+	}
+	return result
+}
+
+// mergeSamples 合并一组连续的样本，group不能为空
+func mergeSamples(group []MetricsSample) MetricsSample {
+	merged := group[len(group)-1]
+	if len(group) == 1 {
+		return merged
+	}
+
+	var diskUsage, memory, netIn, netOut uint64
+	var diskRatio, cpu, load float64
+	for _, s := range group {
+		diskUsage += s.Metrics.DiskUsageBytes
+		memory += s.Metrics.MemoryUsageBytes
+		netIn += s.Metrics.NetworkInBps
+		netOut += s.Metrics.NetworkOutBps
+		diskRatio += s.Metrics.DiskUsageRatio
+		cpu += s.Metrics.CPUUsagePercent
+		load += s.Metrics.LoadScore
+	}
+	n := uint64(len(group))
+	merged.Metrics.DiskUsageBytes = diskUsage / n
+	merged.Metrics.MemoryUsageBytes = memory / n
+	merged.Metrics.NetworkInBps = netIn / n
+	merged.Metrics.NetworkOutBps = netOut / n
+	merged.Metrics.DiskUsageRatio = diskRatio / float64(n)
+	merged.Metrics.CPUUsagePercent = cpu / float64(n)
+	merged.Metrics.LoadScore = load / float64(n)
+	return merged
+}
@@ -0,0 +1,127 @@
+package rebalance
+
+import (
+	"github.com/22827099/DFS_v1/common/types"
+)
+
+// 内置策略名称
+const (
+	StrategyNameWeightedScore   = "weighted_score"
+	StrategyNameCapacity        = "capacity"
+	StrategyNameAccessFrequency = "access_frequency"
+	StrategyNameComposite       = "composite"
+)
+
+// StrategyEvaluation 单次策略评估的详细结果
+type StrategyEvaluation struct {
+	Strategy       string               `json:"strategy"`                  // 策略名称
+	NeedRebalance  bool                 `json:"need_rebalance"`            // 是否需要再平衡
+	ImbalanceScore float64              `json:"imbalance_score"`           // 不平衡度
+	Threshold      float64              `json:"threshold"`                 // 实际生效的阈值
+	StopThreshold  float64              `json:"stop_threshold"`            // 再平衡开始后恢复均衡所需低于的阈值
+	NodeCount      int                  `json:"node_count"`                // 参与评估的节点数
+	BelowMinNodes  bool                 `json:"below_min_nodes,omitempty"` // 节点数少于再平衡所需的最少节点数，未运行策略
+	Source         string               `json:"source,omitempty"`          // 复合策略中决定结果的子策略
+	Components     []StrategyEvaluation `json:"components,omitempty"`      // 复合策略各子策略的评估结果
+}
+
+// DetailedStrategy 能够给出评估细节的均衡策略
+type DetailedStrategy interface {
+	BalanceStrategy
+	// EvaluateDetail 评估集群并返回实际生效的阈值等细节
+	EvaluateDetail(nodeMetrics map[string]*types.NodeMetrics) StrategyEvaluation
+}
+
+// EvaluateStrategy 评估策略并返回详细结果，
+// 未实现DetailedStrategy的自定义策略只填充是否需要再平衡和不平衡度
+func EvaluateStrategy(strategy BalanceStrategy, nodeMetrics map[string]*types.NodeMetrics) StrategyEvaluation {
+	if detailed, ok := strategy.(DetailedStrategy); ok {
+		return detailed.EvaluateDetail(nodeMetrics)
+	}
+
+	need, score := strategy.Evaluate(nodeMetrics)
+	return StrategyEvaluation{
+		Strategy:       "custom",
+		NeedRebalance:  need,
+		ImbalanceScore: score,
+		NodeCount:      len(nodeMetrics),
+	}
+}
+
+// BelowMinNodesEvaluation 节点数少于再平衡所需的最少节点数时的评估结果，不运行策略，直接视为均衡
+func BelowMinNodesEvaluation(strategy BalanceStrategy, nodeCount int) StrategyEvaluation {
+	name := "custom"
+	if named, ok := strategy.(interface{ Name() string }); ok {
+		name = named.Name()
+	}
+	return StrategyEvaluation{
+		Strategy:      name,
+		NodeCount:     nodeCount,
+		BelowMinNodes: true,
+	}
+}
+
+// ApplyThresholds 按策略名称设置阈值，复合策略会递归应用到子策略；
+// thresholds中没有对应条目的策略使用defaultThreshold，非正数表示保持原值
+func ApplyThresholds(strategy BalanceStrategy, thresholds map[string]float64, defaultThreshold float64) {
+	if composite, ok := strategy.(*CompositeStrategy); ok {
+		for _, child := range composite.Strategies() {
+			ApplyThresholds(child, thresholds, defaultThreshold)
+		}
+		return
+	}
+	if constrained, ok := strategy.(*LabelConstrainedStrategy); ok {
+		ApplyThresholds(constrained.Inner(), thresholds, defaultThreshold)
+		return
+	}
+
+	setter, ok := strategy.(interface {
+		Name() string
+		SetImbalanceThreshold(float64)
+	})
+	if !ok {
+		return
+	}
+
+	threshold := defaultThreshold
+	if t, exists := thresholds[setter.Name()]; exists {
+		threshold = t
+	}
+	setter.SetImbalanceThreshold(threshold)
+}
+
+// ApplyMaxPlans 为策略设置单次生成的最大计划数，0表示不额外限制。
+// 复合策略同时限制合并后的计划数和每个子策略；标签约束策略应用到内部策略
+func ApplyMaxPlans(strategy BalanceStrategy, maxPlans int) {
+	if composite, ok := strategy.(*CompositeStrategy); ok {
+		composite.SetMaxPlans(maxPlans)
+		for _, child := range composite.Strategies() {
+			ApplyMaxPlans(child, maxPlans)
+		}
+		return
+	}
+	if constrained, ok := strategy.(*LabelConstrainedStrategy); ok {
+		ApplyMaxPlans(constrained.Inner(), maxPlans)
+		return
+	}
+	if setter, ok := strategy.(interface{ SetMaxPlans(int) }); ok {
+		setter.SetMaxPlans(maxPlans)
+	}
+}
+
+// FindWeightedScoreStrategy 查找策略中的加权得分策略，复合策略按顺序查找子策略，标签约束策略查找内部策略
+func FindWeightedScoreStrategy(strategy BalanceStrategy) (*WeightedScoreStrategy, bool) {
+	switch s := strategy.(type) {
+	case *WeightedScoreStrategy:
+		return s, true
+	case *CompositeStrategy:
+		for _, child := range s.Strategies() {
+			if weighted, ok := FindWeightedScoreStrategy(child); ok {
+				return weighted, true
+			}
+		}
+	case *LabelConstrainedStrategy:
+		return FindWeightedScoreStrategy(s.Inner())
+	}
+	return nil, false
+}
@@ -0,0 +1,78 @@
+package rebalance
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrStaleFencingToken 操作携带的防护令牌早于当前任期
+var ErrStaleFencingToken = errors.New("防护令牌已过期")
+
+// FencingToken 领导者签发操作时附带的防护令牌，取值为签发时的Raft任期
+// 任期单调递增，新领导者当选后旧任期签发的操作都应被拒绝
+type FencingToken uint64
+
+// Fence 记录本节点已知的最新任期，拒绝携带旧任期令牌的操作，
+// 使旧领导者在观察到新任期后停止自己签发的操作。任期只保存在内存中，不在节点间同步，重启后从0开始
+type Fence struct {
+	term   atomic.Uint64
+	source func() uint64 // 当前任期的来源，nil时只使用Advance推进的任期
+}
+
+// FenceOption 防护器配置选项
+type FenceOption func(*Fence)
+
+// WithTermSource 设置当前任期的来源，通常为本节点Raft的当前任期。签发和检查令牌时取来源与已推进任期中较大的一个，
+// 领导者退位后即使没有收到领导者变更通知，也会拒绝旧任期签发的操作
+func WithTermSource(source func() uint64) FenceOption {
+	return func(f *Fence) {
+		f.source = source
+	}
+}
+
+// NewFence 创建防护器
+func NewFence(opts ...FenceOption) *Fence {
+	f := &Fence{}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Advance 将已知任期推进到term，任期只增不减，返回任期是否发生变化
+func (f *Fence) Advance(term uint64) bool {
+	for {
+		current := f.term.Load()
+		if term <= current {
+			return false
+		}
+		if f.term.CompareAndSwap(current, term) {
+			return true
+		}
+	}
+}
+
+// Token 返回当前任期对应的令牌，签发操作时使用
+func (f *Fence) Token() FencingToken {
+	return FencingToken(f.current())
+}
+
+// Check 检查令牌是否仍然有效，早于当前任期时返回ErrStaleFencingToken
+func (f *Fence) Check(token FencingToken) error {
+	if current := f.current(); uint64(token) < current {
+		return fmt.Errorf("%w: 令牌任期%d，当前任期%d", ErrStaleFencingToken, token, current)
+	}
+	return nil
+}
+
+// current 返回已推进的任期与任期来源中较大的一个
+func (f *Fence) current() uint64 {
+	term := f.term.Load()
+	if f.source != nil {
+		if live := f.source(); live > term {
+			return live
+		}
+	}
+	return term
+}
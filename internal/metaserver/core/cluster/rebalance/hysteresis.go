@@ -0,0 +1,80 @@
+package rebalance
+
+import (
+	"sync"
+)
+
+// Hysteresis 再平衡迟滞控制
+// 不平衡度超过启动阈值（策略阈值）时进入再平衡状态，之后直到低于停止阈值
+// （启动阈值×stopRatio）才认为恢复均衡，避免不平衡度在阈值附近波动时反复启停
+type Hysteresis struct {
+	mu        sync.RWMutex
+	stopRatio float64
+	active    bool
+}
+
+// NewHysteresis 创建迟滞控制，stopRatio取值范围为(0, 1]，超出范围时为1即不启用迟滞
+func NewHysteresis(stopRatio float64) *Hysteresis {
+	return &Hysteresis{stopRatio: normalizeStopRatio(stopRatio)}
+}
+
+func normalizeStopRatio(stopRatio float64) float64 {
+	if stopRatio <= 0 || stopRatio > 1 {
+		return 1
+	}
+	return stopRatio
+}
+
+// SetStopRatio 运行时调整停止阈值比例，不改变当前再平衡状态
+func (h *Hysteresis) SetStopRatio(stopRatio float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stopRatio = normalizeStopRatio(stopRatio)
+}
+
+// StopThreshold 返回启动阈值对应的停止阈值
+func (h *Hysteresis) StopThreshold(startThreshold float64) float64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return startThreshold * h.stopRatio
+}
+
+// Active 返回是否处于再平衡状态
+func (h *Hysteresis) Active() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.active
+}
+
+// Apply 按当前状态修正评估结果但不改变状态：
+// 已处于再平衡状态时，只要不平衡度高于停止阈值就继续需要再平衡
+func (h *Hysteresis) Apply(evaluation StrategyEvaluation) StrategyEvaluation {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.applyLocked(evaluation)
+}
+
+// Update 修正评估结果并据此更新再平衡状态
+func (h *Hysteresis) Update(evaluation StrategyEvaluation) StrategyEvaluation {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	evaluation = h.applyLocked(evaluation)
+	h.active = evaluation.NeedRebalance
+	return evaluation
+}
+
+// Reset 清除再平衡状态
+func (h *Hysteresis) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.active = false
+}
+
+func (h *Hysteresis) applyLocked(evaluation StrategyEvaluation) StrategyEvaluation {
+	evaluation.StopThreshold = evaluation.Threshold * h.stopRatio
+	if h.active && !evaluation.NeedRebalance && evaluation.NodeCount >= 2 {
+		evaluation.NeedRebalance = evaluation.ImbalanceScore > evaluation.StopThreshold
+	}
+	return evaluation
+}
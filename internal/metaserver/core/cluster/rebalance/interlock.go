@@ -0,0 +1,90 @@
+package rebalance
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// InterlockStatus 再平衡安全联锁状态
+type InterlockStatus struct {
+	Engaged               bool          `json:"engaged"`                 // 是否阻止迁移
+	Reason                string        `json:"reason,omitempty"`        // 阻止原因
+	HealthyNodes          int           `json:"healthy_nodes"`           // 健康节点数
+	TotalNodes            int           `json:"total_nodes"`             // 节点总数
+	HealthyRatio          float64       `json:"healthy_ratio"`           // 健康节点比例
+	MinHealthyRatio       float64       `json:"min_healthy_ratio"`       // 允许迁移的最低健康比例
+	LastLeaderChange      time.Time     `json:"last_leader_change"`      // 最近一次领导者变更时间
+	LeaderStabilityPeriod time.Duration `json:"leader_stability_period"` // 领导者变更后的静默期
+}
+
+// SafetyInterlock 再平衡安全联锁
+// 集群健康节点比例不足或刚发生领导者变更时阻止迁移，避免在不稳定期间加剧负载
+type SafetyInterlock struct {
+	mu                    sync.RWMutex
+	minHealthyRatio       float64
+	leaderStabilityPeriod time.Duration
+	healthyNodes          int
+	totalNodes            int
+	lastLeaderChange      time.Time
+}
+
+// NewSafetyInterlock 创建安全联锁，minHealthyRatio取值范围为[0, 1]
+func NewSafetyInterlock(minHealthyRatio float64, leaderStabilityPeriod time.Duration) *SafetyInterlock {
+	if minHealthyRatio < 0 {
+		minHealthyRatio = 0
+	}
+	if minHealthyRatio > 1 {
+		minHealthyRatio = 1
+	}
+	return &SafetyInterlock{
+		minHealthyRatio:       minHealthyRatio,
+		leaderStabilityPeriod: leaderStabilityPeriod,
+	}
+}
+
+// UpdateClusterHealth 更新集群健康状况
+func (l *SafetyInterlock) UpdateClusterHealth(healthyNodes, totalNodes int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.healthyNodes = healthyNodes
+	l.totalNodes = totalNodes
+}
+
+// RecordLeaderChange 记录领导者变更时间
+func (l *SafetyInterlock) RecordLeaderChange(at time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lastLeaderChange = at
+}
+
+// Status 返回当前联锁状态
+// 尚未收到任何健康信息时不以健康比例阻止迁移
+func (l *SafetyInterlock) Status() InterlockStatus {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	status := InterlockStatus{
+		HealthyNodes:          l.healthyNodes,
+		TotalNodes:            l.totalNodes,
+		MinHealthyRatio:       l.minHealthyRatio,
+		LastLeaderChange:      l.lastLeaderChange,
+		LeaderStabilityPeriod: l.leaderStabilityPeriod,
+	}
+	if l.totalNodes > 0 {
+		status.HealthyRatio = float64(l.healthyNodes) / float64(l.totalNodes)
+	}
+
+	if l.totalNodes > 0 && status.HealthyRatio < l.minHealthyRatio {
+		status.Engaged = true
+		status.Reason = fmt.Sprintf("健康节点比例%.2f低于要求的%.2f", status.HealthyRatio, l.minHealthyRatio)
+		return status
+	}
+
+	if !l.lastLeaderChange.IsZero() && time.Since(l.lastLeaderChange) < l.leaderStabilityPeriod {
+		status.Engaged = true
+		status.Reason = fmt.Sprintf("领导者于%s前变更，尚未稳定", time.Since(l.lastLeaderChange).Round(time.Millisecond))
+	}
+
+	return status
+}
@@ -0,0 +1,146 @@
+package rebalance
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/22827099/DFS_v1/common/types"
+)
+
+// LabelConstrainedStrategy 带标签约束的均衡策略
+// 节点按groupKeys对应的标签值分组，迁移只在同组节点之间进行（例如SSD分片只在SSD节点间迁移）；
+// 设置targetSelector时，迁移目标还必须满足该选择器
+type LabelConstrainedStrategy struct {
+	inner          BalanceStrategy
+	groupKeys      []string
+	targetSelector types.LabelSelector
+}
+
+// NewLabelConstrainedStrategy 创建带标签约束的策略
+func NewLabelConstrainedStrategy(inner BalanceStrategy, groupKeys []string, targetSelector types.LabelSelector) *LabelConstrainedStrategy {
+	return &LabelConstrainedStrategy{
+		inner:          inner,
+		groupKeys:      groupKeys,
+		targetSelector: targetSelector,
+	}
+}
+
+// Name 返回被约束策略的名称
+func (s *LabelConstrainedStrategy) Name() string {
+	if named, ok := s.inner.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	return "custom"
+}
+
+// Inner 返回被约束的策略
+func (s *LabelConstrainedStrategy) Inner() BalanceStrategy {
+	return s.inner
+}
+
+// Evaluate 评估集群是否需要再平衡，任一分组需要即返回true，不平衡度取各分组最大值
+func (s *LabelConstrainedStrategy) Evaluate(nodeMetrics map[string]*types.NodeMetrics) (bool, float64) {
+	result := s.EvaluateDetail(nodeMetrics)
+	return result.NeedRebalance, result.ImbalanceScore
+}
+
+// EvaluateDetail 分组评估，返回不平衡度最高的分组的评估结果，Source为该分组的标签
+func (s *LabelConstrainedStrategy) EvaluateDetail(nodeMetrics map[string]*types.NodeMetrics) StrategyEvaluation {
+	result := StrategyEvaluation{
+		Strategy:  s.Name(),
+		NodeCount: len(nodeMetrics),
+	}
+
+	first := true
+	for _, group := range s.groupNodes(nodeMetrics) {
+		if len(group.nodes) < 2 {
+			continue
+		}
+
+		evaluation := EvaluateStrategy(s.inner, group.nodes)
+		if evaluation.NeedRebalance {
+			result.NeedRebalance = true
+		}
+		if first || evaluation.ImbalanceScore > result.ImbalanceScore {
+			result.ImbalanceScore = evaluation.ImbalanceScore
+			result.Threshold = evaluation.Threshold
+			result.Source = group.key
+			first = false
+		}
+	}
+
+	return result
+}
+
+// GeneratePlan 在每个分组内独立生成迁移计划，选择迁移目标时只考虑满足选择器的节点
+func (s *LabelConstrainedStrategy) GeneratePlan(nodeMetrics map[string]*types.NodeMetrics) ([]*MigrationPlan, error) {
+	var plans []*MigrationPlan
+	var lastErr error
+	generated := false
+
+	for _, group := range s.groupNodes(nodeMetrics) {
+		if len(group.nodes) < 2 {
+			continue
+		}
+
+		groupPlans, err := GeneratePlanForTargets(s.inner, group.nodes, s.targetFilter(group.nodes))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		generated = true
+		plans = append(plans, groupPlans...)
+	}
+
+	if !generated && lastErr != nil {
+		return nil, lastErr
+	}
+	return plans, nil
+}
+
+// targetFilter 返回只接受标签满足选择器的节点的目标过滤器，未设置选择器时返回nil
+func (s *LabelConstrainedStrategy) targetFilter(nodes map[string]*types.NodeMetrics) TargetFilter {
+	if len(s.targetSelector) == 0 {
+		return nil
+	}
+	return func(nodeID string) bool {
+		target := nodes[nodeID]
+		return target != nil && s.targetSelector.Matches(target.Labels)
+	}
+}
+
+// labelGroup 标签值相同的一组节点
+type labelGroup struct {
+	key   string
+	nodes map[string]*types.NodeMetrics
+}
+
+// groupNodes 按分组标签的取值划分节点，结果按分组键排序以保证稳定
+func (s *LabelConstrainedStrategy) groupNodes(nodeMetrics map[string]*types.NodeMetrics) []labelGroup {
+	groups := make(map[string]map[string]*types.NodeMetrics)
+	for nodeID, metrics := range nodeMetrics {
+		key := s.groupKey(metrics.Labels)
+		if groups[key] == nil {
+			groups[key] = make(map[string]*types.NodeMetrics)
+		}
+		groups[key][nodeID] = metrics
+	}
+
+	result := make([]labelGroup, 0, len(groups))
+	for key, nodes := range groups {
+		result = append(result, labelGroup{key: key, nodes: nodes})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].key < result[j].key
+	})
+	return result
+}
+
+// groupKey 生成形如"disk-type=ssd,zone=a"的分组键
+func (s *LabelConstrainedStrategy) groupKey(labels map[string]string) string {
+	parts := make([]string, len(s.groupKeys))
+	for i, key := range s.groupKeys {
+		parts[i] = key + "=" + labels[key]
+	}
+	return strings.Join(parts, ",")
+}
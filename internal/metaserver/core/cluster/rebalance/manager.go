@@ -2,6 +2,8 @@ package rebalance
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -10,6 +12,9 @@ import (
     "github.com/22827099/DFS_v1/common/types"
 )
 
+// ErrNoWeightedStrategy 当前策略中没有加权得分策略，无法读取或调整权重
+var ErrNoWeightedStrategy = errors.New("当前负载均衡策略不包含加权得分策略")
+
 // Manager 负载均衡管理器
 type Manager struct {
     mu              sync.RWMutex
@@ -23,12 +28,45 @@ type Manager struct {
     lastRebalance   time.Time
     isRebalancing   bool
     triggerCh       chan struct{}
-    nodeMetrics     map[string]*types.NodeMetrics     // 所有节点的性能指标
-    metricsLock     sync.RWMutex                // 保护metrics的互斥锁
+    startedAt       time.Time                   // 启动时间，作为慢启动的起点
+    interlock       *SafetyInterlock            // 集群健康安全联锁
+    targetSelector  types.LabelSelector         // 迁移目标节点的标签选择器
+    hysteresis      *Hysteresis                 // 启停阈值迟滞，防止再平衡反复启停
+    fence           *Fence                      // 领导者任期防护，迁移任务携带签发时的任期
+}
+
+// SlowStartConcurrency 计算慢启动阶段允许的并发迁移数
+// elapsed小于window时返回0，表示不允许生成迁移计划；
+// 此后在ramp时间内从1线性提升到maxConcurrent
+func SlowStartConcurrency(elapsed, window, ramp time.Duration, maxConcurrent int) int {
+    if maxConcurrent < 1 {
+        maxConcurrent = 1
+    }
+    if elapsed < window {
+        return 0
+    }
+    
+    rampElapsed := elapsed - window
+    if ramp <= 0 || rampElapsed >= ramp {
+        return maxConcurrent
+    }
+    
+    return 1 + int(float64(maxConcurrent-1)*float64(rampElapsed)/float64(ramp))
+}
+
+// ManagerOption 负载均衡管理器配置选项
+type ManagerOption func(*Manager)
+
+// WithFencingTermSource 设置防护令牌的任期来源，通常为Raft的当前任期，
+// 本节点退位后迁移器按最新任期拒绝旧任期签发的任务
+func WithFencingTermSource(source func() uint64) ManagerOption {
+    return func(m *Manager) {
+        WithTermSource(source)(m.fence)
+    }
 }
 
 // NewManager 创建负载均衡管理器
-func NewManager(cfg *metaconfig.LoadBalancerConfig, logger logging.Logger) (*Manager, error) {
+func NewManager(cfg *metaconfig.LoadBalancerConfig, logger logging.Logger, opts ...ManagerOption) (*Manager, error) {
     // 检查评估间隔
     if cfg.EvaluationInterval <= 0 {
         cfg.EvaluationInterval = 30 * time.Second
@@ -50,13 +88,23 @@ func NewManager(cfg *metaconfig.LoadBalancerConfig, logger logging.Logger) (*Man
     // 创建指标收集器
     metricCollector := NewMetricCollector()
     
+    // 解析标签约束
+    targetSelector, err := types.ParseLabelSelector(cfg.TargetSelector)
+    if err != nil {
+        cancel()
+        return nil, fmt.Errorf("解析迁移目标选择器失败: %w", err)
+    }
+    
     // 创建默认的均衡策略
-    strategy := NewWeightedScoreStrategy(0.4, 0.2, 0.2, 0.2)
+    weights := DefaultScoreWeights()
+    var strategy BalanceStrategy = NewWeightedScoreStrategy(weights.CPU, weights.Memory, weights.Disk, weights.Shard)
+    strategy = applyStrategyConfig(strategy, cfg, targetSelector)
     
-    // 创建迁移器
-    migrator := NewMigrator(ctx, cfg.MaxConcurrentMigrations, logger)
+    // 创建迁移器，与管理器共享防护器
+    fence := NewFence()
+    migrator := NewMigrator(ctx, cfg.MaxConcurrentMigrations, logger, WithFence(fence))
 
-    return &Manager{
+    m := &Manager{
         ctx:             ctx,
         cancel:          cancel,
         cfg:             cfg,
@@ -67,12 +115,43 @@ func NewManager(cfg *metaconfig.LoadBalancerConfig, logger logging.Logger) (*Man
         lastRebalance:   time.Time{},
         isRebalancing:   false,
         triggerCh:       make(chan struct{}, 1),
-    }, nil
+        interlock:       NewSafetyInterlock(cfg.MinHealthyRatio, cfg.LeaderStabilityPeriod),
+        hysteresis:      NewHysteresis(cfg.ImbalanceStopRatio),
+        targetSelector:  targetSelector,
+        fence:           fence,
+    }
+    for _, opt := range opts {
+        opt(m)
+    }
+    return m, nil
+}
+
+// applyStrategyConfig 将配置中的阈值、计划数上限和标签约束应用到策略
+func applyStrategyConfig(strategy BalanceStrategy, cfg *metaconfig.LoadBalancerConfig, targetSelector types.LabelSelector) BalanceStrategy {
+    ApplyThresholds(strategy, cfg.StrategyThresholds, cfg.ImbalanceThreshold)
+    ApplyMaxPlans(strategy, cfg.MaxPlans)
+    
+    if len(cfg.LabelGroupKeys) == 0 && len(targetSelector) == 0 {
+        return strategy
+    }
+    if _, ok := strategy.(*LabelConstrainedStrategy); ok {
+        return strategy
+    }
+    return NewLabelConstrainedStrategy(strategy, cfg.LabelGroupKeys, targetSelector)
 }
 
 // Start 启动负载均衡管理器
 func (m *Manager) Start() error {
-    m.logger.Info("启动负载均衡管理器")
+    m.logger.Info("启动负载均衡管理器",
+        "slow_start_window", m.cfg.SlowStartWindow,
+        "slow_start_ramp", m.cfg.SlowStartRamp)
+    
+    m.mu.Lock()
+    m.startedAt = time.Now()
+    m.mu.Unlock()
+    
+    // 慢启动期间迁移并发数从1开始
+    m.applySlowStart()
     
     // 启动迁移器
     m.migrator.Start()
@@ -80,6 +159,11 @@ func (m *Manager) Start() error {
     // 启动周期性评估与再平衡
     go m.runEvaluationLoop()
     
+    // 启动并发数爬坡
+    if m.cfg.SlowStartWindow > 0 || m.cfg.SlowStartRamp > 0 {
+        go m.runSlowStartRamp()
+    }
+    
     return nil
 }
 
@@ -94,6 +178,95 @@ func (m *Manager) Stop() error {
     return nil
 }
 
+// SetStrategy 替换负载均衡策略，配置中的阈值和标签约束会应用到新策略
+func (m *Manager) SetStrategy(strategy BalanceStrategy) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.strategy = applyStrategyConfig(strategy, m.cfg, m.targetSelector)
+    // 不同策略的不平衡度不可比较，重新开始迟滞判断
+    m.hysteresis.Reset()
+}
+
+// UpdateThresholds 运行时更新不平衡阈值和停止阈值比例，用于配置重载
+// 阈值非正数、停止比例不在(0, 1]范围内时保持不变；按策略单独配置的阈值仍然优先
+func (m *Manager) UpdateThresholds(imbalanceThreshold, stopRatio float64) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    
+    if imbalanceThreshold > 0 {
+        m.cfg.ImbalanceThreshold = imbalanceThreshold
+        ApplyThresholds(m.strategy, m.cfg.StrategyThresholds, imbalanceThreshold)
+    }
+    if stopRatio > 0 && stopRatio <= 1 {
+        m.cfg.ImbalanceStopRatio = stopRatio
+        m.hysteresis.SetStopRatio(stopRatio)
+    }
+    
+    m.logger.Info("负载均衡阈值已更新",
+        "imbalance_threshold", m.cfg.ImbalanceThreshold,
+        "imbalance_stop_ratio", m.cfg.ImbalanceStopRatio)
+}
+
+// Weights 返回当前策略中加权得分策略的权重，策略中没有加权得分策略时返回ErrNoWeightedStrategy
+func (m *Manager) Weights() (ScoreWeights, error) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+    
+    weighted, ok := FindWeightedScoreStrategy(m.strategy)
+    if !ok {
+        return ScoreWeights{}, ErrNoWeightedStrategy
+    }
+    return weighted.Weights(), nil
+}
+
+// UpdateWeights 运行时更新加权得分策略的权重，权重经校验和归一化后在下一次评估时生效，返回实际生效的权重
+// 不平衡度随权重变化，因此与替换策略一样重新开始迟滞判断
+func (m *Manager) UpdateWeights(weights ScoreWeights) (ScoreWeights, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    
+    weighted, ok := FindWeightedScoreStrategy(m.strategy)
+    if !ok {
+        return ScoreWeights{}, ErrNoWeightedStrategy
+    }
+    applied, err := weighted.SetWeights(weights)
+    if err != nil {
+        return ScoreWeights{}, err
+    }
+    m.hysteresis.Reset()
+    
+    m.logger.Info("加权得分策略权重已更新",
+        "cpu", applied.CPU,
+        "memory", applied.Memory,
+        "disk", applied.Disk,
+        "shard", applied.Shard)
+    return applied, nil
+}
+
+// Evaluate 使用当前指标评估集群，返回实际生效的阈值和产生不平衡度的策略
+// 节点数少于MinNodesForRebalance时不运行策略，直接视为均衡
+func (m *Manager) Evaluate() StrategyEvaluation {
+    m.mu.RLock()
+    strategy := m.strategy
+    m.mu.RUnlock()
+    
+    nodeMetrics := m.metricCollector.GetFreshMetrics(m.cfg.MetricsStalenessWindow)
+    if m.belowMinNodes(len(nodeMetrics)) {
+        return BelowMinNodesEvaluation(strategy, len(nodeMetrics))
+    }
+    return m.hysteresis.Apply(EvaluateStrategy(strategy, nodeMetrics))
+}
+
+// belowMinNodes 返回节点数是否少于配置的再平衡最少节点数
+func (m *Manager) belowMinNodes(nodeCount int) bool {
+    return nodeCount < m.cfg.MinNodesForRebalance
+}
+
+// StaleNodes 返回指标已过期、被排除在评估和迁移规划之外的节点
+func (m *Manager) StaleNodes() []string {
+    return m.metricCollector.StaleNodes(m.cfg.MetricsStalenessWindow)
+}
+
 // TriggerRebalance 手动触发负载均衡
 func (m *Manager) TriggerRebalance() {
     m.logger.Info("手动触发负载均衡")
@@ -115,36 +288,132 @@ func (m *Manager) IsRebalancing() bool {
 
 // GetStatus 获取负载均衡状态
 func (m *Manager) GetStatus() map[string]interface{} {
+    evaluation := m.Evaluate()
+    
     m.mu.RLock()
     defer m.mu.RUnlock()
     
     activeTasks := m.migrator.GetAllActiveTasks()
     
     return map[string]interface{}{
+        "is_balanced":        !evaluation.NeedRebalance,
+        "strategy":           evaluation.Strategy,
+        "threshold":          evaluation.Threshold,
+        "stop_threshold":     evaluation.StopThreshold,
+        "rebalance_active":   m.hysteresis.Active(),
+        "imbalance_score":    evaluation.ImbalanceScore,
+        "evaluation":         evaluation,
         "is_rebalancing":     m.isRebalancing,
         "last_rebalance":     m.lastRebalance,
         "active_tasks_count": len(activeTasks),
         "active_tasks":       activeTasks,
+        "slow_start":         m.slowStartConcurrencyLocked() < m.cfg.MaxConcurrentMigrations,
+        "concurrency_limit":  m.migrator.ConcurrencyLimit(),
+        "interlock":          m.interlock.Status(),
+        "stale_nodes":        m.StaleNodes(),
+        "fencing_term":       m.fence.Token(),
+    }
+}
+
+// UpdateClusterHealth 更新集群健康节点数量，供安全联锁判断
+func (m *Manager) UpdateClusterHealth(healthyNodes, totalNodes int) {
+    m.interlock.UpdateClusterHealth(healthyNodes, totalNodes)
+}
+
+// RecordLeaderChange 记录领导者变更，变更后的稳定期内不启动迁移
+func (m *Manager) RecordLeaderChange(at time.Time) {
+    m.interlock.RecordLeaderChange(at)
+}
+
+// AdvanceTerm 推进已知的领导者任期，此前任期签发的迁移任务不再执行
+func (m *Manager) AdvanceTerm(term uint64) {
+    if m.fence.Advance(term) {
+        m.logger.Info("领导者任期推进", "term", term)
+    }
+}
+
+// FencingToken 返回当前任期对应的防护令牌
+func (m *Manager) FencingToken() FencingToken {
+    return m.fence.Token()
+}
+
+// InterlockStatus 返回安全联锁的当前状态
+func (m *Manager) InterlockStatus() InterlockStatus {
+    return m.interlock.Status()
+}
+
+// InSlowStartWindow 返回是否处于慢启动窗口内，窗口内不生成迁移计划
+func (m *Manager) InSlowStartWindow() bool {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+    return m.slowStartConcurrencyLocked() == 0
+}
+
+// slowStartConcurrencyLocked 根据启动时长计算当前允许的并发迁移数，调用方需持有m.mu
+func (m *Manager) slowStartConcurrencyLocked() int {
+    if m.startedAt.IsZero() {
+        return 0
+    }
+    return SlowStartConcurrency(time.Since(m.startedAt), m.cfg.SlowStartWindow,
+        m.cfg.SlowStartRamp, m.cfg.MaxConcurrentMigrations)
+}
+
+// applySlowStart 将慢启动计算出的并发数应用到迁移器，返回计算结果
+func (m *Manager) applySlowStart() int {
+    m.mu.RLock()
+    concurrency := m.slowStartConcurrencyLocked()
+    m.mu.RUnlock()
+    
+    // 窗口内不会提交任务，迁移器保持最低并发
+    m.migrator.SetConcurrencyLimit(concurrency)
+    return concurrency
+}
+
+// runSlowStartRamp 在慢启动期间逐步提升迁移并发数，达到上限后退出
+func (m *Manager) runSlowStartRamp() {
+    maxConcurrent := m.cfg.MaxConcurrentMigrations
+    if maxConcurrent < 1 {
+        maxConcurrent = 1
+    }
+    
+    // 每一级并发至少检查一次，避免跳级
+    step := m.cfg.SlowStartRamp / time.Duration(maxConcurrent)
+    if m.cfg.SlowStartWindow > 0 && (step <= 0 || m.cfg.SlowStartWindow < step) {
+        step = m.cfg.SlowStartWindow
+    }
+    if step < 10*time.Millisecond {
+        step = 10 * time.Millisecond
+    }
+    
+    ticker := time.NewTicker(step)
+    defer ticker.Stop()
+    
+    for {
+        select {
+        case <-m.ctx.Done():
+            return
+        case <-ticker.C:
+            if m.applySlowStart() >= maxConcurrent {
+                m.logger.Info("负载均衡慢启动结束", "max_concurrent", maxConcurrent)
+                return
+            }
+        }
     }
 }
 
+// GetTaskStatus 获取迁移任务状态，包含已传输字节数和进度
+func (m *Manager) GetTaskStatus(taskID string) (*MigrationTask, bool) {
+    return m.migrator.GetTaskStatus(taskID)
+}
+
 // UpdateNodeMetrics 更新节点度量指标
 func (m *Manager) UpdateNodeMetrics(nodeID string, metrics *types.NodeMetrics) {
     m.metricCollector.UpdateNodeMetrics(nodeID, metrics)
 }
 
-// GetNodeMetrics 获取指定节点的性能指标
+// GetNodeMetrics 获取指定节点的性能指标副本
 func (m *Manager) GetNodeMetrics(nodeID string) *types.NodeMetrics {
-    m.metricsLock.RLock()
-    defer m.metricsLock.RUnlock()
-    
-    if metrics, exists := m.nodeMetrics[nodeID]; exists {
-        // 返回指标副本以避免并发修改问题
-        metricsCopy := *metrics
-        return &metricsCopy
-    }
-    
-    return nil
+    return m.metricCollector.GetNodeMetrics(nodeID)
 }
 
 // 运行评估循环
@@ -185,6 +454,22 @@ func (m *Manager) evaluateAndRebalance() {
         return
     }
     
+    // 慢启动窗口内集群可能尚未稳定，不生成迁移计划
+    if m.slowStartConcurrencyLocked() == 0 {
+        m.mu.Unlock()
+        m.logger.Info("处于慢启动窗口，跳过本次评估",
+            "started_at", m.startedAt,
+            "window", m.cfg.SlowStartWindow)
+        return
+    }
+    
+    // 集群不稳定时不启动迁移
+    if status := m.interlock.Status(); status.Engaged {
+        m.mu.Unlock()
+        m.logger.Warn("负载均衡安全联锁生效，跳过本次评估", "reason", status.Reason)
+        return
+    }
+    
     // 检查距离上次再平衡的时间间隔
     if !m.lastRebalance.IsZero() && time.Since(m.lastRebalance) < m.cfg.MinMigrationInterval {
         m.mu.Unlock()
@@ -194,8 +479,10 @@ func (m *Manager) evaluateAndRebalance() {
         return
     }
     
-    // 设置再平衡状态
+    // 设置再平衡状态，迁移任务携带评估开始时的任期
     m.isRebalancing = true
+    strategy := m.strategy
+    token := m.fence.Token()
     m.mu.Unlock()
     
     // 在函数退出时重置状态
@@ -205,26 +492,40 @@ func (m *Manager) evaluateAndRebalance() {
         m.mu.Unlock()
     }()
     
-    // 获取所有节点指标
-    nodeMetrics := m.metricCollector.GetAllMetrics()
+    // 获取指标未过期的节点，过期节点状态未知，不参与评估和规划
+    nodeMetrics := m.metricCollector.GetFreshMetrics(m.cfg.MetricsStalenessWindow)
+    if stale := m.StaleNodes(); len(stale) > 0 {
+        m.logger.Warn("部分节点指标已过期，不参与本次评估", "stale_nodes", stale)
+    }
     if len(nodeMetrics) < 2 {
         m.logger.Info("节点数量不足，无需再平衡", "node_count", len(nodeMetrics))
         return
     }
+    // 节点太少时迁移只会在一两对节点间来回移动分片，视为均衡并结束已开始的再平衡
+    if m.belowMinNodes(len(nodeMetrics)) {
+        m.hysteresis.Reset()
+        m.logger.Info("节点数少于再平衡所需的最少节点数，视为均衡",
+            "node_count", len(nodeMetrics),
+            "min_nodes", m.cfg.MinNodesForRebalance)
+        return
+    }
     
-    // 评估是否需要再平衡
-    needRebalance, imbalanceScore := m.strategy.Evaluate(nodeMetrics)
+    // 评估是否需要再平衡，已开始再平衡时按停止阈值判断
+    evaluation := m.hysteresis.Update(EvaluateStrategy(strategy, nodeMetrics))
     m.logger.Info("负载均衡评估结果",
-        "need_rebalance", needRebalance,
-        "imbalance_score", imbalanceScore,
-        "threshold", m.cfg.ImbalanceThreshold)
+        "strategy", evaluation.Strategy,
+        "source", evaluation.Source,
+        "need_rebalance", evaluation.NeedRebalance,
+        "imbalance_score", evaluation.ImbalanceScore,
+        "threshold", evaluation.Threshold,
+        "stop_threshold", evaluation.StopThreshold)
     
-    if !needRebalance {
+    if !evaluation.NeedRebalance {
         return
     }
     
     // 执行再平衡
-    err := m.performRebalance(nodeMetrics)
+    err := m.performRebalance(strategy, nodeMetrics, token)
     if err != nil {
         m.logger.Error("执行负载均衡失败", "error", err)
         return
@@ -239,9 +540,9 @@ func (m *Manager) evaluateAndRebalance() {
 }
 
 // 执行再平衡
-func (m *Manager) performRebalance(nodeMetrics map[string]*types.NodeMetrics) error {
+func (m *Manager) performRebalance(strategy BalanceStrategy, nodeMetrics map[string]*types.NodeMetrics, token FencingToken) error {
     // 生成迁移计划
-    plans, err := m.strategy.GeneratePlan(nodeMetrics)
+    plans, err := strategy.GeneratePlan(nodeMetrics)
     if err != nil {
         return err
     }
@@ -254,7 +555,7 @@ func (m *Manager) performRebalance(nodeMetrics map[string]*types.NodeMetrics) er
     m.logger.Info("生成迁移计划", "plan_count", len(plans))
     
     // 提交迁移任务
-    taskIDs := m.migrator.SubmitTasks(plans)
+    taskIDs := m.migrator.SubmitTasks(plans, token)
     m.logger.Info("已提交迁移任务", "task_count", len(taskIDs))
     
     return nil
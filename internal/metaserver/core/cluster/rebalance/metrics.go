@@ -1,7 +1,9 @@
 package rebalance
 
 import (
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/22827099/DFS_v1/common/types"
 )
@@ -9,13 +11,15 @@ import (
 // MetricCollector 节点指标收集器
 type MetricCollector struct {
 	metrics     map[string]*types.NodeMetrics // 节点ID -> 指标
+	updatedAt   map[string]time.Time          // 节点ID -> 最近一次收到指标的时间
 	metricsLock sync.RWMutex                  // 保护metrics的互斥锁
 }
 
 // NewMetricCollector 创建新的指标收集器
 func NewMetricCollector() *MetricCollector {
 	return &MetricCollector{
-		metrics: make(map[string]*types.NodeMetrics),
+		metrics:   make(map[string]*types.NodeMetrics),
+		updatedAt: make(map[string]time.Time),
 	}
 }
 
@@ -24,9 +28,9 @@ func (c *MetricCollector) UpdateNodeMetrics(nodeID string, metrics *types.NodeMe
 	c.metricsLock.Lock()
 	defer c.metricsLock.Unlock()
 
-	// 存储指标副本
-	metricsCopy := *metrics
-	c.metrics[nodeID] = &metricsCopy
+	// 存储指标副本，并以收到的时间作为新鲜度依据
+	c.metrics[nodeID] = copyMetrics(metrics)
+	c.updatedAt[nodeID] = time.Now()
 }
 
 // GetNodeMetrics 获取节点指标
@@ -36,8 +40,7 @@ func (c *MetricCollector) GetNodeMetrics(nodeID string) *types.NodeMetrics {
 
 	if metrics, exists := c.metrics[nodeID]; exists {
 		// 返回副本以避免并发修改
-		metricsCopy := *metrics
-		return &metricsCopy
+		return copyMetrics(metrics)
 	}
 
 	return nil
@@ -51,13 +54,60 @@ func (c *MetricCollector) GetAllMetrics() map[string]*types.NodeMetrics {
 	// 创建副本
 	result := make(map[string]*types.NodeMetrics, len(c.metrics))
 	for nodeID, metrics := range c.metrics {
-		metricsCopy := *metrics
-		result[nodeID] = &metricsCopy
+		result[nodeID] = copyMetrics(metrics)
 	}
 
 	return result
 }
 
+// copyMetrics 深拷贝节点指标，包括标签
+func copyMetrics(metrics *types.NodeMetrics) *types.NodeMetrics {
+	metricsCopy := *metrics
+	metricsCopy.Labels = types.CloneLabels(metrics.Labels)
+	return &metricsCopy
+}
+
+// GetFreshMetrics 获取maxAge内有更新的节点指标，maxAge不大于0时返回全部指标
+func (c *MetricCollector) GetFreshMetrics(maxAge time.Duration) map[string]*types.NodeMetrics {
+	if maxAge <= 0 {
+		return c.GetAllMetrics()
+	}
+
+	c.metricsLock.RLock()
+	defer c.metricsLock.RUnlock()
+
+	now := time.Now()
+	result := make(map[string]*types.NodeMetrics, len(c.metrics))
+	for nodeID, metrics := range c.metrics {
+		if now.Sub(c.updatedAt[nodeID]) <= maxAge {
+			result[nodeID] = copyMetrics(metrics)
+		}
+	}
+
+	return result
+}
+
+// StaleNodes 返回超过maxAge未更新指标的节点ID，按ID排序
+func (c *MetricCollector) StaleNodes(maxAge time.Duration) []string {
+	if maxAge <= 0 {
+		return nil
+	}
+
+	c.metricsLock.RLock()
+	defer c.metricsLock.RUnlock()
+
+	now := time.Now()
+	var stale []string
+	for nodeID := range c.metrics {
+		if now.Sub(c.updatedAt[nodeID]) > maxAge {
+			stale = append(stale, nodeID)
+		}
+	}
+	sort.Strings(stale)
+
+	return stale
+}
+
 // CalculateClusterStats 计算集群整体统计信息
 func (mc *MetricCollector) CalculateClusterStats() *ClusterStats {
 	mc.metricsLock.RLock()
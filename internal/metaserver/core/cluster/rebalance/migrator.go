@@ -24,10 +24,45 @@ type MigrationTask struct {
 	TaskID      string         `json:"task_id"`      // 任务ID
 	Plan        *MigrationPlan `json:"plan"`         // 迁移计划
 	State       TaskState      `json:"state"`        // 任务状态
-	Progress    float64        `json:"progress"`     // 进度（0-100）
+	Progress    float64        `json:"progress"`     // 进度（0-100），按已传输字节计算
 	StartTime   time.Time      `json:"start_time"`   // 开始时间
 	EndTime     time.Time      `json:"end_time"`     // 结束时间
 	ErrorDetail string         `json:"error_detail"` // 错误详情
+
+	TotalBytes       uint64 `json:"total_bytes"`       // 需要传输的总字节数
+	BytesTransferred uint64 `json:"bytes_transferred"` // 已传输的字节数
+
+	FencingToken FencingToken `json:"fencing_token"` // 签发任务时的领导者任期
+}
+
+// MigratorOption 迁移器配置选项
+type MigratorOption func(*Migrator)
+
+// WithShardMigrationTime 设置模拟迁移单个分片所需的时间
+func WithShardMigrationTime(d time.Duration) MigratorOption {
+	return func(m *Migrator) {
+		if d > 0 {
+			m.shardDuration = d
+		}
+	}
+}
+
+// WithProgressInterval 设置迁移进度的更新间隔
+func WithProgressInterval(d time.Duration) MigratorOption {
+	return func(m *Migrator) {
+		if d > 0 {
+			m.progressInterval = d
+		}
+	}
+}
+
+// WithFence 设置防护器，执行任务前及每个分片迁移前检查任务的防护令牌
+func WithFence(fence *Fence) MigratorOption {
+	return func(m *Migrator) {
+		if fence != nil {
+			m.fence = fence
+		}
+	}
 }
 
 // Migrator 数据迁移器
@@ -38,20 +73,40 @@ type Migrator struct {
 	tasks         sync.Map            // 所有任务映射，使用sync.Map减少锁竞争
 	pendingTasks  chan *MigrationTask // 等待执行的任务队列
 	wg            sync.WaitGroup      // 等待所有任务完成
+	taskMu        sync.RWMutex        // 保护任务字段的读写
+
+	shardDuration    time.Duration // 迁移单个分片的时间
+	progressInterval time.Duration // 进度更新间隔
+	fence            *Fence        // 拒绝旧任期签发的任务
+
+	limitMu      sync.RWMutex  // 保护并发上限
+	limit        int           // 当前允许的并发迁移数，不超过maxConcurrent
+	limitChanged chan struct{} // 并发上限变化时关闭，用于唤醒等待中的worker
 }
 
 // NewMigrator 创建新的数据迁移器
-func NewMigrator(ctx context.Context, maxConcurrent int, logger logging.Logger) *Migrator {
+func NewMigrator(ctx context.Context, maxConcurrent int, logger logging.Logger, opts ...MigratorOption) *Migrator {
 	if maxConcurrent <= 0 {
 		maxConcurrent = 5 // 默认最大并发数
 	}
 
-	return &Migrator{
-		ctx:           ctx,
-		maxConcurrent: maxConcurrent,
-		logger:        logger.WithContext(map[string]interface{}{"component": "migrator"}),
-		pendingTasks:  make(chan *MigrationTask, 100), // 缓冲区大小可调整
+	m := &Migrator{
+		ctx:              ctx,
+		maxConcurrent:    maxConcurrent,
+		logger:           logger.WithContext(map[string]interface{}{"component": "migrator"}),
+		pendingTasks:     make(chan *MigrationTask, 100), // 缓冲区大小可调整
+		limit:            maxConcurrent,
+		limitChanged:     make(chan struct{}),
+		shardDuration:    2 * time.Second,
+		progressInterval: 500 * time.Millisecond,
+		fence:            NewFence(),
+	}
+
+	for _, opt := range opts {
+		opt(m)
 	}
+
+	return m
 }
 
 // Start 启动迁移器
@@ -85,20 +140,22 @@ func (m *Migrator) Stop() {
 	}
 }
 
-// SubmitTasks 提交迁移任务
-func (m *Migrator) SubmitTasks(plans []*MigrationPlan) []string {
+// SubmitTasks 提交迁移任务，token为签发任务的领导者任期，任期过期的任务不会被执行
+func (m *Migrator) SubmitTasks(plans []*MigrationPlan, token FencingToken) []string {
 	taskIDs := make([]string, 0, len(plans))
 
 	for _, plan := range plans {
 		taskID := uuid.New().String()
 
 		task := &MigrationTask{
-			TaskID:    taskID,
-			Plan:      plan,
-			State:     TaskStatePending,
-			Progress:  0,
-			StartTime: time.Time{},
-			EndTime:   time.Time{},
+			TaskID:       taskID,
+			Plan:         plan,
+			State:        TaskStatePending,
+			Progress:     0,
+			StartTime:    time.Time{},
+			EndTime:      time.Time{},
+			TotalBytes:   plan.EstimatedBytes,
+			FencingToken: token,
 		}
 
 		m.tasks.Store(taskID, task)
@@ -109,8 +166,10 @@ func (m *Migrator) SubmitTasks(plans []*MigrationPlan) []string {
 			// 成功添加到队列
 		default:
 			// 队列已满，改变任务状态为失败
+			m.taskMu.Lock()
 			task.State = TaskStateFailed
 			task.ErrorDetail = "任务队列已满"
+			m.taskMu.Unlock()
 			m.logger.Warn("任务队列已满，无法提交新任务", "task_id", taskID)
 		}
 
@@ -121,18 +180,58 @@ func (m *Migrator) SubmitTasks(plans []*MigrationPlan) []string {
 			"source", plan.SourceNodeID,
 			"target", plan.TargetNodeID,
 			"shards", len(plan.ShardIDs),
-			"bytes", plan.EstimatedBytes)
+			"bytes", plan.EstimatedBytes,
+			"fencing_token", token)
 	}
 
 	return taskIDs
 }
 
+// SetConcurrencyLimit 调整允许同时执行的迁移任务数，取值范围为[1, maxConcurrent]
+// 正在执行的任务不受影响，超出上限的worker在当前任务完成后暂停领取新任务
+func (m *Migrator) SetConcurrencyLimit(limit int) {
+	if limit < 1 {
+		limit = 1
+	}
+	if limit > m.maxConcurrent {
+		limit = m.maxConcurrent
+	}
+
+	m.limitMu.Lock()
+	defer m.limitMu.Unlock()
+
+	if limit == m.limit {
+		return
+	}
+	m.logger.Info("调整迁移并发上限", "from", m.limit, "to", limit)
+	m.limit = limit
+	close(m.limitChanged)
+	m.limitChanged = make(chan struct{})
+}
+
+// ConcurrencyLimit 返回当前允许的并发迁移数
+func (m *Migrator) ConcurrencyLimit() int {
+	m.limitMu.RLock()
+	defer m.limitMu.RUnlock()
+	return m.limit
+}
+
+// workerEnabled 判断指定worker在当前并发上限下是否可以领取任务，
+// 不可领取时同时返回上限变化的通知通道
+func (m *Migrator) workerEnabled(id int) (bool, <-chan struct{}) {
+	m.limitMu.RLock()
+	defer m.limitMu.RUnlock()
+	return id < m.limit, m.limitChanged
+}
+
 // GetTaskStatus 获取任务状态
 func (m *Migrator) GetTaskStatus(taskID string) (*MigrationTask, bool) {
 	if value, exists := m.tasks.Load(taskID); exists {
 		task := value.(*MigrationTask)
 		// 返回副本以避免并发修改
+		m.taskMu.RLock()
 		taskCopy := *task
+		m.taskMu.RUnlock()
 		return &taskCopy, true
 	}
 	return nil, false
@@ -142,6 +241,9 @@ func (m *Migrator) GetTaskStatus(taskID string) (*MigrationTask, bool) {
 func (m *Migrator) GetAllActiveTasks() []*MigrationTask {
 	activeTasks := make([]*MigrationTask, 0)
 
+	m.taskMu.RLock()
+	defer m.taskMu.RUnlock()
+
 	m.tasks.Range(func(key, value interface{}) bool {
 		task := value.(*MigrationTask)
 		if task.State == TaskStatePending || task.State == TaskStateRunning {
@@ -162,6 +264,17 @@ func (m *Migrator) worker(id int) {
 	m.logger.Info("启动迁移工作协程", "worker_id", id)
 
 	for {
+		// 超出当前并发上限的worker等待上限调整
+		if enabled, changed := m.workerEnabled(id); !enabled {
+			select {
+			case <-m.ctx.Done():
+				m.logger.Info("迁移工作协程退出", "worker_id", id)
+				return
+			case <-changed:
+			}
+			continue
+		}
+
 		select {
 		case <-m.ctx.Done():
 			m.logger.Info("迁移工作协程退出", "worker_id", id)
@@ -175,10 +288,22 @@ func (m *Migrator) worker(id int) {
 
 // processTask 处理迁移任务
 func (m *Migrator) processTask(task *MigrationTask) {
+	// 排队期间领导者已变更的任务直接拒绝
+	if err := m.fence.Check(task.FencingToken); err != nil {
+		m.taskMu.Lock()
+		task.State = TaskStateFailed
+		task.ErrorDetail = err.Error()
+		task.EndTime = time.Now()
+		m.taskMu.Unlock()
+		m.logger.Warn("拒绝执行旧任期签发的迁移任务", "task_id", task.TaskID, "error", err)
+		return
+	}
+
 	// 更新任务状态为运行中
+	m.taskMu.Lock()
 	task.State = TaskStateRunning
 	task.StartTime = time.Now()
-	m.tasks.Store(task.TaskID, task)
+	m.taskMu.Unlock()
 
 	m.logger.Info("开始处理迁移任务",
 		"task_id", task.TaskID,
@@ -188,14 +313,19 @@ func (m *Migrator) processTask(task *MigrationTask) {
 	// 模拟迁移过程
 	success := m.executeMigration(task)
 
+	m.taskMu.Lock()
+	defer m.taskMu.Unlock()
+
 	// 完成时间
 	task.EndTime = time.Now()
 
 	if success {
 		task.State = TaskStateCompleted
+		task.BytesTransferred = task.TotalBytes
 		task.Progress = 100
 		m.logger.Info("迁移任务完成",
 			"task_id", task.TaskID,
+			"bytes", task.BytesTransferred,
 			"duration", task.EndTime.Sub(task.StartTime))
 	} else {
 		task.State = TaskStateFailed
@@ -204,11 +334,30 @@ func (m *Migrator) processTask(task *MigrationTask) {
 		}
 		m.logger.Error("迁移任务失败",
 			"task_id", task.TaskID,
+			"bytes_transferred", task.BytesTransferred,
 			"error", task.ErrorDetail)
 	}
+}
+
+// updateProgress 更新任务的已传输字节数和进度百分比
+func (m *Migrator) updateProgress(task *MigrationTask, transferred uint64) {
+	m.taskMu.Lock()
+	defer m.taskMu.Unlock()
+
+	if transferred > task.TotalBytes {
+		transferred = task.TotalBytes
+	}
+	task.BytesTransferred = transferred
+	if task.TotalBytes > 0 {
+		task.Progress = float64(transferred) / float64(task.TotalBytes) * 100
+	}
+}
 
-	// 更新任务状态
-	m.tasks.Store(task.TaskID, task)
+// failTask 在迁移过程中记录失败原因
+func (m *Migrator) failTask(task *MigrationTask, detail string) {
+	m.taskMu.Lock()
+	defer m.taskMu.Unlock()
+	task.ErrorDetail = detail
 }
 
 // executeMigration 执行迁移操作
@@ -219,47 +368,68 @@ func (m *Migrator) executeMigration(task *MigrationTask) bool {
 	// 模拟迁移进度
 	totalShards := len(task.Plan.ShardIDs)
 	if totalShards == 0 {
-		task.ErrorDetail = "没有要迁移的分片"
+		m.failTask(task, "没有要迁移的分片")
 		return false
 	}
 
-	// 为每个分片分配时间
-	timePerShard := 2 * time.Second
+	// 按分片平均分配字节数，余数计入最后一个分片
+	bytesPerShard := task.TotalBytes / uint64(totalShards)
+	var transferred uint64
 
 	for i, shardID := range task.Plan.ShardIDs {
-		// 检查是否被取消
-		select {
-		case <-m.ctx.Done():
-			task.ErrorDetail = "迁移任务被取消"
+		shardBytes := bytesPerShard
+		if i == totalShards-1 {
+			shardBytes = task.TotalBytes - transferred
+		}
+
+		// 迁移过程中领导者变更，停止执行剩余分片
+		if err := m.fence.Check(task.FencingToken); err != nil {
+			m.failTask(task, err.Error())
 			return false
-		default:
-			// 继续执行
 		}
 
-		// 模拟分片迁移
 		m.logger.Debug("迁移分片",
 			"task_id", task.TaskID,
 			"shard_id", shardID,
-			"progress", float64(i)/float64(totalShards)*100)
-
-		// 更新进度
-		task.Progress = float64(i) / float64(totalShards) * 100
-		m.tasks.Store(task.TaskID, task)
+			"bytes_transferred", transferred)
 
-		// 模拟迁移时间
-		select {
-		case <-time.After(timePerShard):
-			// 分片迁移完成
-		case <-m.ctx.Done():
-			task.ErrorDetail = "迁移任务被取消"
+		if !m.transferShard(task, transferred, shardBytes) {
+			m.failTask(task, "迁移任务被取消")
 			return false
 		}
+		transferred += shardBytes
+		m.updateProgress(task, transferred)
 	}
 
 	// 迁移完成
 	return true
 }
 
+// transferShard 模拟单个分片的传输，期间按进度间隔更新已传输字节数
+// base为此前分片已传输的字节数，返回false表示迁移被取消
+func (m *Migrator) transferShard(task *MigrationTask, base, shardBytes uint64) bool {
+	ticker := time.NewTicker(m.progressInterval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	done := time.After(m.shardDuration)
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return false
+		case <-done:
+			return true
+		case <-ticker.C:
+			fraction := float64(time.Since(start)) / float64(m.shardDuration)
+			if fraction > 1 {
+				fraction = 1
+			}
+			m.updateProgress(task, base+uint64(fraction*float64(shardBytes)))
+		}
+	}
+}
+
 // CancelTask 取消任务
 func (m *Migrator) CancelTask(taskID string) bool {
 	value, exists := m.tasks.Load(taskID)
@@ -268,14 +438,17 @@ func (m *Migrator) CancelTask(taskID string) bool {
 	}
 
 	task := value.(*MigrationTask)
+
+	m.taskMu.Lock()
 	if task.State != TaskStatePending && task.State != TaskStateRunning {
+		m.taskMu.Unlock()
 		return false // 只能取消等待或运行中的任务
 	}
 
 	task.State = TaskStateFailed
 	task.ErrorDetail = "任务被手动取消"
 	task.EndTime = time.Now()
-	m.tasks.Store(taskID, task)
+	m.taskMu.Unlock()
 
 	m.logger.Info("取消迁移任务", "task_id", taskID)
 
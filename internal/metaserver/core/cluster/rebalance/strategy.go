@@ -2,8 +2,11 @@ package rebalance
 
 import (
 	"errors"
+	"fmt"
 	"math"
 	"sort"
+	"strconv"
+	"sync/atomic"
 
 	"github.com/22827099/DFS_v1/common/types"
 	"github.com/google/uuid"
@@ -33,10 +36,69 @@ type MigrationPlan struct {
 	Priority int `json:"priority"`
 }
 
+// TargetFilter 判断节点能否作为迁移目标，nil表示所有节点都可以
+type TargetFilter func(nodeID string) bool
+
+// Accepts 返回节点能否作为迁移目标
+func (f TargetFilter) Accepts(nodeID string) bool {
+	return f == nil || f(nodeID)
+}
+
+// TargetConstrainedStrategy 能在选择迁移目标时应用约束的策略，
+// 约束在配对源节点和目标节点时生效，而不是生成计划后再过滤
+type TargetConstrainedStrategy interface {
+	GeneratePlanForTargets(nodeMetrics map[string]*types.NodeMetrics, filter TargetFilter) ([]*MigrationPlan, error)
+}
+
+// GeneratePlanForTargets 用strategy生成只以filter接受的节点为目标的计划；
+// strategy不支持目标约束时生成计划后丢弃目标不被接受的计划
+func GeneratePlanForTargets(strategy BalanceStrategy, nodeMetrics map[string]*types.NodeMetrics, filter TargetFilter) ([]*MigrationPlan, error) {
+	if constrained, ok := strategy.(TargetConstrainedStrategy); ok {
+		return constrained.GeneratePlanForTargets(nodeMetrics, filter)
+	}
+	plans, err := strategy.GeneratePlan(nodeMetrics)
+	if err != nil || filter == nil {
+		return plans, err
+	}
+	accepted := plans[:0]
+	for _, plan := range plans {
+		if filter.Accepts(string(plan.TargetNodeID)) {
+			accepted = append(accepted, plan)
+		}
+	}
+	return accepted, nil
+}
+
+// nodePairing 一对迁移源节点和目标节点在排序结果中的下标
+type nodePairing struct {
+	source, target int
+}
+
+// selectPairs 在按负载降序排列的n个节点中从两端配对：源节点从负载最高的一端依次选取，
+// 目标节点从负载最低的一端跳过eligible不接受的节点后选取，两端相遇或达到maxPairs对时停止
+func selectPairs(n, maxPairs int, eligible func(i int) bool) []nodePairing {
+	var pairs []nodePairing
+	source, target := 0, n-1
+	for len(pairs) < maxPairs {
+		for target > source && !eligible(target) {
+			target--
+		}
+		if source >= target {
+			break
+		}
+		pairs = append(pairs, nodePairing{source: source, target: target})
+		source++
+		target--
+	}
+	return pairs
+}
+
 // BaseStrategy 基础策略，提供通用功能
 type BaseStrategy struct {
-	// 不平衡阈值
-	imbalanceThreshold float64
+	// 不平衡阈值，以math.Float64bits存储，支持运行时重载
+	imbalanceThreshold atomic.Uint64
+	// 单次GeneratePlan最多生成的计划数，0表示只受策略自身的节点对数限制
+	maxPlans atomic.Int64
 }
 
 // NewBaseStrategy 创建基础策略
@@ -44,41 +106,137 @@ func NewBaseStrategy(threshold float64) *BaseStrategy {
 	if threshold <= 0 {
 		threshold = 20.0 // 默认20%
 	}
-	return &BaseStrategy{
-		imbalanceThreshold: threshold,
+	s := &BaseStrategy{}
+	s.imbalanceThreshold.Store(math.Float64bits(threshold))
+	return s
+}
+
+// ImbalanceThreshold 返回配置的不平衡阈值
+func (s *BaseStrategy) ImbalanceThreshold() float64 {
+	return math.Float64frombits(s.imbalanceThreshold.Load())
+}
+
+// SetImbalanceThreshold 设置不平衡阈值，非正数时忽略
+func (s *BaseStrategy) SetImbalanceThreshold(threshold float64) {
+	if threshold > 0 {
+		s.imbalanceThreshold.Store(math.Float64bits(threshold))
+	}
+}
+
+// MaxPlans 返回单次生成的最大计划数，0表示不额外限制
+func (s *BaseStrategy) MaxPlans() int {
+	return int(s.maxPlans.Load())
+}
+
+// SetMaxPlans 设置单次生成的最大计划数，0表示不额外限制，负数时忽略
+func (s *BaseStrategy) SetMaxPlans(n int) {
+	if n >= 0 {
+		s.maxPlans.Store(int64(n))
 	}
 }
 
+// planLimit 返回本次最多生成的计划数：策略自身允许的节点对数pairs与配置上限中的较小值
+func (s *BaseStrategy) planLimit(pairs int) int {
+	if limit := s.MaxPlans(); limit > 0 && limit < pairs {
+		return limit
+	}
+	return pairs
+}
+
+// ErrInvalidWeights 加权得分策略的权重不合法
+var ErrInvalidWeights = errors.New("无效的策略权重")
+
+// ScoreWeights 加权得分策略中各项指标的权重
+type ScoreWeights struct {
+	CPU    float64 `json:"cpu"`    // CPU使用率权重
+	Memory float64 `json:"memory"` // 内存使用率权重
+	Disk   float64 `json:"disk"`   // 磁盘使用率权重
+	Shard  float64 `json:"shard"`  // 分片数量权重
+}
+
+// DefaultScoreWeights 返回负载均衡管理器默认使用的权重
+func DefaultScoreWeights() ScoreWeights {
+	return ScoreWeights{CPU: 0.4, Memory: 0.2, Disk: 0.2, Shard: 0.2}
+}
+
+// Normalize 校验权重并按总和归一化，使各项权重之和为1。
+// 权重为负数、NaN、无穷大或全部为0时返回ErrInvalidWeights
+func (w ScoreWeights) Normalize() (ScoreWeights, error) {
+	names := [...]string{"cpu", "memory", "disk", "shard"}
+	var sum float64
+	for i, v := range [...]float64{w.CPU, w.Memory, w.Disk, w.Shard} {
+		if math.IsNaN(v) || math.IsInf(v, 0) || v < 0 {
+			return ScoreWeights{}, fmt.Errorf("%w: %s权重必须是非负数，当前为%v", ErrInvalidWeights, names[i], v)
+		}
+		sum += v
+	}
+	if sum == 0 {
+		return ScoreWeights{}, fmt.Errorf("%w: 权重不能全部为0", ErrInvalidWeights)
+	}
+	return ScoreWeights{CPU: w.CPU / sum, Memory: w.Memory / sum, Disk: w.Disk / sum, Shard: w.Shard / sum}, nil
+}
+
 // WeightedScoreStrategy 加权得分策略
 type WeightedScoreStrategy struct {
 	*BaseStrategy
-	cpuWeight    float64 // CPU使用率权重
-	memoryWeight float64 // 内存使用率权重
-	diskWeight   float64 // 磁盘使用率权重
-	shardWeight  float64 // 分片数量权重
+	// 各项指标的权重，支持运行时更新，每次评估或生成计划开始时读取一次
+	weights atomic.Pointer[ScoreWeights]
 }
 
 // NewWeightedScoreStrategy 创建新的加权得分策略
 func NewWeightedScoreStrategy(cpuWeight, memoryWeight, diskWeight, shardWeight float64) *WeightedScoreStrategy {
-	return &WeightedScoreStrategy{
-		BaseStrategy: NewBaseStrategy(0),
-		cpuWeight:    cpuWeight,
-		memoryWeight: memoryWeight,
-		diskWeight:   diskWeight,
-		shardWeight:  shardWeight,
+	s := &WeightedScoreStrategy{BaseStrategy: NewBaseStrategy(0)}
+	s.weights.Store(&ScoreWeights{CPU: cpuWeight, Memory: memoryWeight, Disk: diskWeight, Shard: shardWeight})
+	return s
+}
+
+// Weights 返回当前使用的权重
+func (s *WeightedScoreStrategy) Weights() ScoreWeights {
+	return *s.weights.Load()
+}
+
+// SetWeights 校验并归一化权重后替换当前权重，返回实际生效的权重。
+// 正在进行的评估继续使用旧权重，下一次评估开始使用新权重
+func (s *WeightedScoreStrategy) SetWeights(weights ScoreWeights) (ScoreWeights, error) {
+	normalized, err := weights.Normalize()
+	if err != nil {
+		return ScoreWeights{}, err
 	}
+	s.weights.Store(&normalized)
+	return normalized, nil
 }
 
 // Evaluate 评估集群是否需要再平衡
 func (s *WeightedScoreStrategy) Evaluate(nodeMetrics map[string]*types.NodeMetrics) (bool, float64) {
+	result := s.EvaluateDetail(nodeMetrics)
+	return result.NeedRebalance, result.ImbalanceScore
+}
+
+// EffectiveThreshold 返回指定节点数下实际使用的阈值
+// 节点数量少于3时阈值提高到1.5倍，避免小集群频繁迁移
+func (s *WeightedScoreStrategy) EffectiveThreshold(nodeCount int) float64 {
+	if nodeCount < 3 {
+		return s.ImbalanceThreshold() * 1.5
+	}
+	return s.ImbalanceThreshold()
+}
+
+// EvaluateDetail 评估集群是否需要再平衡，并返回实际使用的阈值
+func (s *WeightedScoreStrategy) EvaluateDetail(nodeMetrics map[string]*types.NodeMetrics) StrategyEvaluation {
+	result := StrategyEvaluation{
+		Strategy:  StrategyNameWeightedScore,
+		Threshold: s.EffectiveThreshold(len(nodeMetrics)),
+		NodeCount: len(nodeMetrics),
+	}
 	if len(nodeMetrics) < 2 {
-		return false, 0.0
+		return result
 	}
 
 	// 计算每个节点的加权负载得分
+	agg := newScoreAggregates(nodeMetrics, s.Weights())
 	scores := make([]float64, 0, len(nodeMetrics))
 	for _, metrics := range nodeMetrics {
-		score := s.calculateNodeScore(metrics, nodeMetrics)
+		score := s.calculateNodeScore(metrics, agg)
 		scores = append(scores, score)
 	}
 
@@ -97,26 +255,23 @@ func (s *WeightedScoreStrategy) Evaluate(nodeMetrics map[string]*types.NodeMetri
 
 	// 防止除零
 	if avg == 0 {
-		return false, 0.0
+		return result
 	}
 
 	// 变异系数作为不平衡度指标
-	imbalanceScore := math.Sqrt(squaredDiffSum/float64(len(scores))) / avg * 100.0
-
-	// 如果节点数量少于3，提高阈值避免频繁迁移
-	threshold := s.imbalanceThreshold
-	if threshold == 0 {
-		threshold = 20.0
-		if len(nodeMetrics) < 3 {
-			threshold = 30.0
-		}
-	}
+	result.ImbalanceScore = math.Sqrt(squaredDiffSum/float64(len(scores))) / avg * 100.0
+	result.NeedRebalance = result.ImbalanceScore > result.Threshold
 
-	return imbalanceScore > threshold, imbalanceScore
+	return result
 }
 
 // GeneratePlan 生成迁移计划
 func (s *WeightedScoreStrategy) GeneratePlan(nodeMetrics map[string]*types.NodeMetrics) ([]*MigrationPlan, error) {
+	return s.GeneratePlanForTargets(nodeMetrics, nil)
+}
+
+// GeneratePlanForTargets 生成迁移计划，只选择filter接受的节点作为迁移目标
+func (s *WeightedScoreStrategy) GeneratePlanForTargets(nodeMetrics map[string]*types.NodeMetrics, filter TargetFilter) ([]*MigrationPlan, error) {
 	if len(nodeMetrics) < 2 {
 		return nil, errors.New("至少需要两个节点才能生成迁移计划")
 	}
@@ -128,9 +283,10 @@ func (s *WeightedScoreStrategy) GeneratePlan(nodeMetrics map[string]*types.NodeM
 		Metric *types.NodeMetrics
 	}
 
+	agg := newScoreAggregates(nodeMetrics, s.Weights())
 	scores := make([]nodeScore, 0, len(nodeMetrics))
 	for nodeID, metrics := range nodeMetrics {
-		score := s.calculateNodeScore(metrics, nodeMetrics)
+		score := s.calculateNodeScore(metrics, agg)
 		scores = append(scores, nodeScore{
 			NodeID: nodeID,
 			Score:  score,
@@ -138,9 +294,12 @@ func (s *WeightedScoreStrategy) GeneratePlan(nodeMetrics map[string]*types.NodeM
 		})
 	}
 
-	// 按得分降序排序，分数越高，负载越重
+	// 按得分降序排序，分数越高，负载越重；得分相同时按节点ID排序，使计划可重现
 	sort.Slice(scores, func(i, j int) bool {
-		return scores[i].Score > scores[j].Score
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].NodeID < scores[j].NodeID
 	})
 
 	// 找出负载最重和最轻的节点
@@ -151,11 +310,13 @@ func (s *WeightedScoreStrategy) GeneratePlan(nodeMetrics map[string]*types.NodeM
 	if maxPairs < 1 {
 		maxPairs = 1
 	}
+	maxPairs = s.planLimit(maxPairs)
 
 	// 构建从高负载节点到低负载节点的迁移计划
-	for i := 0; i < maxPairs && i < len(scores)/2; i++ {
-		sourceNode := scores[i]
-		targetNode := scores[len(scores)-i-1]
+	pairs := selectPairs(len(scores), maxPairs, func(j int) bool { return filter.Accepts(scores[j].NodeID) })
+	for i, pair := range pairs {
+		sourceNode := scores[pair.source]
+		targetNode := scores[pair.target]
 
 		// 计算目标迁移量：尽量使两者负载均衡
 		scoreDiff := sourceNode.Score - targetNode.Score
@@ -190,10 +351,7 @@ func (s *WeightedScoreStrategy) GeneratePlan(nodeMetrics map[string]*types.NodeM
 
 		// 这里无法直接获取分片ID，所以使用占位符
 		// 实际系统中需要通过存储服务获取真实的分片ID
-		shardIDs := make([]string, shardsToMigrate)
-		for j := 0; j < shardsToMigrate; j++ {
-			shardIDs[j] = "shard_placeholder_" + sourceNode.NodeID + "_" + string(rune(j))
-		}
+		shardIDs := placeholderShardIDs("shard_placeholder_", sourceNode.NodeID, shardsToMigrate)
 
 		// 估算数据量（假设每个分片1GB大小）
 		estimatedBytes := uint64(shardsToMigrate) * uint64(1024*1024*1024)
@@ -214,25 +372,54 @@ func (s *WeightedScoreStrategy) GeneratePlan(nodeMetrics map[string]*types.NodeM
 	return plans, nil
 }
 
-// calculateNodeScore 计算节点的加权负载得分
-func (s *WeightedScoreStrategy) calculateNodeScore(metrics *types.NodeMetrics, allMetrics map[string]*types.NodeMetrics) float64 {
-	// 标准化分片数量相对于集群平均水平
-	avgShards := 0.0
+// Name 返回策略名称
+func (s *WeightedScoreStrategy) Name() string {
+	return StrategyNameWeightedScore
+}
+
+// NodeScores 返回每个节点的加权负载得分，得分越高负载越重
+func (s *WeightedScoreStrategy) NodeScores(nodeMetrics map[string]*types.NodeMetrics) map[string]float64 {
+	agg := newScoreAggregates(nodeMetrics, s.Weights())
+	scores := make(map[string]float64, len(nodeMetrics))
+	for nodeID, metrics := range nodeMetrics {
+		scores[nodeID] = s.calculateNodeScore(metrics, agg)
+	}
+	return scores
+}
+
+// scoreAggregates 计算节点得分所需的集群汇总值和权重，每次评估或生成计划时只计算一次
+type scoreAggregates struct {
+	avgShards float64      // 集群平均分片数
+	weights   ScoreWeights // 本次使用的权重，评估过程中权重被更新也不影响本次结果
+}
+
+// newScoreAggregates 汇总所有节点的指标。分片数是整数，求和结果与遍历顺序无关
+func newScoreAggregates(allMetrics map[string]*types.NodeMetrics, weights ScoreWeights) scoreAggregates {
+	agg := scoreAggregates{weights: weights}
+	if len(allMetrics) == 0 {
+		return agg
+	}
 	for _, m := range allMetrics {
-		avgShards += float64(m.ShardCount)
+		agg.avgShards += float64(m.ShardCount)
 	}
-	avgShards /= float64(len(allMetrics))
+	agg.avgShards /= float64(len(allMetrics))
+	return agg
+}
 
+// calculateNodeScore 计算节点的加权负载得分
+func (s *WeightedScoreStrategy) calculateNodeScore(metrics *types.NodeMetrics, agg scoreAggregates) float64 {
+	// 标准化分片数量相对于集群平均水平
 	normalizedShards := 0.0
-	if avgShards > 0 {
-		normalizedShards = float64(metrics.ShardCount) / avgShards
+	if agg.avgShards > 0 {
+		normalizedShards = float64(metrics.ShardCount) / agg.avgShards
 	}
 
 	// 计算加权得分
-	score := s.cpuWeight*metrics.CPUUsagePercent +
-		s.memoryWeight*(float64(metrics.MemoryUsageBytes)/float64(1<<30)) + // 转换为GB
-		s.diskWeight*metrics.DiskUsageRatio*100 + // 转换为百分比
-		s.shardWeight*normalizedShards*100.0 // 将分片比例转为0-100范围
+	w := agg.weights
+	score := w.CPU*metrics.CPUUsagePercent +
+		w.Memory*(float64(metrics.MemoryUsageBytes)/float64(1<<30)) + // 转换为GB
+		w.Disk*metrics.DiskUsageRatio*100 + // 转换为百分比
+		w.Shard*normalizedShards*100.0 // 将分片比例转为0-100范围
 
 	return score
 }
@@ -249,10 +436,26 @@ func NewCapacityBalanceStrategy(threshold float64) *CapacityBalanceStrategy {
 	}
 }
 
+// Name 返回策略名称
+func (s *CapacityBalanceStrategy) Name() string {
+	return StrategyNameCapacity
+}
+
 // Evaluate 评估集群是否需要再平衡
 func (s *CapacityBalanceStrategy) Evaluate(nodeMetrics map[string]*types.NodeMetrics) (bool, float64) {
+	result := s.EvaluateDetail(nodeMetrics)
+	return result.NeedRebalance, result.ImbalanceScore
+}
+
+// EvaluateDetail 评估集群是否需要再平衡，并返回实际使用的阈值
+func (s *CapacityBalanceStrategy) EvaluateDetail(nodeMetrics map[string]*types.NodeMetrics) StrategyEvaluation {
+	result := StrategyEvaluation{
+		Strategy:  StrategyNameCapacity,
+		Threshold: s.ImbalanceThreshold(),
+		NodeCount: len(nodeMetrics),
+	}
 	if len(nodeMetrics) < 2 {
-		return false, 0.0
+		return result
 	}
 
 	// 提取所有节点的磁盘使用率
@@ -276,17 +479,23 @@ func (s *CapacityBalanceStrategy) Evaluate(nodeMetrics map[string]*types.NodeMet
 
 	// 防止除零
 	if avg == 0 {
-		return false, 0.0
+		return result
 	}
 
 	// 变异系数作为不平衡度指标
-	imbalanceScore := math.Sqrt(squaredDiffSum/float64(len(diskRatios))) / avg * 100.0
+	result.ImbalanceScore = math.Sqrt(squaredDiffSum/float64(len(diskRatios))) / avg * 100.0
+	result.NeedRebalance = result.ImbalanceScore > result.Threshold
 
-	return imbalanceScore > s.imbalanceThreshold, imbalanceScore
+	return result
 }
 
 // GeneratePlan 生成迁移计划
 func (s *CapacityBalanceStrategy) GeneratePlan(nodeMetrics map[string]*types.NodeMetrics) ([]*MigrationPlan, error) {
+	return s.GeneratePlanForTargets(nodeMetrics, nil)
+}
+
+// GeneratePlanForTargets 生成迁移计划，只选择filter接受的节点作为迁移目标
+func (s *CapacityBalanceStrategy) GeneratePlanForTargets(nodeMetrics map[string]*types.NodeMetrics, filter TargetFilter) ([]*MigrationPlan, error) {
 	if len(nodeMetrics) < 2 {
 		return nil, errors.New("至少需要两个节点才能生成迁移计划")
 	}
@@ -307,21 +516,25 @@ func (s *CapacityBalanceStrategy) GeneratePlan(nodeMetrics map[string]*types.Nod
 		})
 	}
 
-	// 按磁盘使用率降序排序
+	// 按磁盘使用率降序排序，使用率相同时按节点ID排序
 	sort.Slice(diskUsages, func(i, j int) bool {
-		return diskUsages[i].DiskRatio > diskUsages[j].DiskRatio
+		if diskUsages[i].DiskRatio != diskUsages[j].DiskRatio {
+			return diskUsages[i].DiskRatio > diskUsages[j].DiskRatio
+		}
+		return diskUsages[i].NodeID < diskUsages[j].NodeID
 	})
 
 	var plans []*MigrationPlan
-	maxPairs := int(math.Ceil(float64(len(diskUsages)) / 3.0))
+	maxPairs := s.planLimit(int(math.Ceil(float64(len(diskUsages)) / 3.0)))
 	if maxPairs < 1 {
 		maxPairs = 1
 	}
 
 	// 从使用率最高节点迁移到使用率最低节点
-	for i := 0; i < maxPairs && i < len(diskUsages)/2; i++ {
-		sourceNode := diskUsages[i]
-		targetNode := diskUsages[len(diskUsages)-i-1]
+	pairs := selectPairs(len(diskUsages), maxPairs, func(j int) bool { return filter.Accepts(diskUsages[j].NodeID) })
+	for i, pair := range pairs {
+		sourceNode := diskUsages[pair.source]
+		targetNode := diskUsages[pair.target]
 
 		// 计算差异，如果差异小则不迁移
 		diffRatio := sourceNode.DiskRatio - targetNode.DiskRatio
@@ -337,10 +550,7 @@ func (s *CapacityBalanceStrategy) GeneratePlan(nodeMetrics map[string]*types.Nod
 		}
 
 		// 创建分片ID列表（占位符）
-		shardIDs := make([]string, shardsToMigrate)
-		for j := 0; j < shardsToMigrate; j++ {
-			shardIDs[j] = "capacity_shard_" + sourceNode.NodeID + "_" + string(rune(j))
-		}
+		shardIDs := placeholderShardIDs("capacity_shard_", sourceNode.NodeID, shardsToMigrate)
 
 		// 估算数据量
 		estimatedBytes := uint64(shardsToMigrate) * uint64(1024*1024*1024) // 假设每个分片1GB
@@ -373,8 +583,25 @@ func NewAccessFrequencyStrategy(threshold float64) *AccessFrequencyStrategy {
 	}
 }
 
+// Name 返回策略名称
+func (s *AccessFrequencyStrategy) Name() string {
+	return StrategyNameAccessFrequency
+}
+
 // Evaluate 评估集群是否需要再平衡
 func (s *AccessFrequencyStrategy) Evaluate(nodeMetrics map[string]*types.NodeMetrics) (bool, float64) {
+	result := s.EvaluateDetail(nodeMetrics)
+	return result.NeedRebalance, result.ImbalanceScore
+}
+
+// EvaluateDetail 评估集群是否需要再平衡，并返回实际使用的阈值
+func (s *AccessFrequencyStrategy) EvaluateDetail(nodeMetrics map[string]*types.NodeMetrics) StrategyEvaluation {
+	result := StrategyEvaluation{
+		Strategy:  StrategyNameAccessFrequency,
+		Threshold: s.ImbalanceThreshold(),
+		NodeCount: len(nodeMetrics),
+	}
+
 	// 实现类似于其他策略，但基于访问频率指标
 	// 当前NodeMetrics中还没有包含访问频率信息，这里是一个示例实现
 	// 实际项目中需要扩展NodeMetrics或使用其他数据源
@@ -386,7 +613,7 @@ func (s *AccessFrequencyStrategy) Evaluate(nodeMetrics map[string]*types.NodeMet
 	}
 
 	if len(cpuUsages) < 2 {
-		return false, 0.0
+		return result
 	}
 
 	// 计算变异系数
@@ -404,16 +631,22 @@ func (s *AccessFrequencyStrategy) Evaluate(nodeMetrics map[string]*types.NodeMet
 
 	// 防止除零
 	if avg == 0 {
-		return false, 0.0
+		return result
 	}
 
-	imbalanceScore := math.Sqrt(squaredDiffSum/float64(len(cpuUsages))) / avg * 100.0
+	result.ImbalanceScore = math.Sqrt(squaredDiffSum/float64(len(cpuUsages))) / avg * 100.0
+	result.NeedRebalance = result.ImbalanceScore > result.Threshold
 
-	return imbalanceScore > s.imbalanceThreshold, imbalanceScore
+	return result
 }
 
 // GeneratePlan 生成迁移计划
 func (s *AccessFrequencyStrategy) GeneratePlan(nodeMetrics map[string]*types.NodeMetrics) ([]*MigrationPlan, error) {
+	return s.GeneratePlanForTargets(nodeMetrics, nil)
+}
+
+// GeneratePlanForTargets 生成迁移计划，只选择filter接受的节点作为迁移目标
+func (s *AccessFrequencyStrategy) GeneratePlanForTargets(nodeMetrics map[string]*types.NodeMetrics, filter TargetFilter) ([]*MigrationPlan, error) {
 	// 类似于其他策略的实现，但基于访问频率指标
 	// 示例实现，使用CPU使用率作为替代
 
@@ -437,17 +670,22 @@ func (s *AccessFrequencyStrategy) GeneratePlan(nodeMetrics map[string]*types.Nod
 		})
 	}
 
-	// 降序排序
+	// 降序排序，使用率相同时按节点ID排序
 	sort.Slice(cpuUsages, func(i, j int) bool {
-		return cpuUsages[i].CPUUsage > cpuUsages[j].CPUUsage
+		if cpuUsages[i].CPUUsage != cpuUsages[j].CPUUsage {
+			return cpuUsages[i].CPUUsage > cpuUsages[j].CPUUsage
+		}
+		return cpuUsages[i].NodeID < cpuUsages[j].NodeID
 	})
 
 	var plans []*MigrationPlan
 
 	// 生成计划
-	for i := 0; i < 2 && i < len(cpuUsages)/2; i++ {
-		sourceNode := cpuUsages[i]
-		targetNode := cpuUsages[len(cpuUsages)-i-1]
+	maxPairs := s.planLimit(2)
+	pairs := selectPairs(len(cpuUsages), maxPairs, func(j int) bool { return filter.Accepts(cpuUsages[j].NodeID) })
+	for i, pair := range pairs {
+		sourceNode := cpuUsages[pair.source]
+		targetNode := cpuUsages[pair.target]
 
 		// 如果差异小则不迁移
 		if sourceNode.CPUUsage-targetNode.CPUUsage < 20.0 {
@@ -461,10 +699,7 @@ func (s *AccessFrequencyStrategy) GeneratePlan(nodeMetrics map[string]*types.Nod
 		}
 
 		// 创建分片ID列表
-		shardIDs := make([]string, shardsToMigrate)
-		for j := 0; j < shardsToMigrate; j++ {
-			shardIDs[j] = "hotspot_shard_" + sourceNode.NodeID + "_" + string(rune(j))
-		}
+		shardIDs := placeholderShardIDs("hotspot_shard_", sourceNode.NodeID, shardsToMigrate)
 
 		// 创建迁移计划
 		plan := &MigrationPlan{
@@ -486,8 +721,13 @@ func (s *AccessFrequencyStrategy) GeneratePlan(nodeMetrics map[string]*types.Nod
 type CompositeStrategy struct {
 	strategies []BalanceStrategy
 	weights    []float64
+	shardLimit int // 合并后单轮最多迁移的分片数
+	maxPlans   int // 合并后最多保留的计划数，0表示不限制
 }
 
+// DefaultCompositeShardLimit 复合策略单轮迁移分片数的默认上限
+const DefaultCompositeShardLimit = 100
+
 // NewCompositeStrategy 创建新的复合策略
 func NewCompositeStrategy(strategies []BalanceStrategy, weights []float64) *CompositeStrategy {
 	// 如果权重未提供或长度不匹配，使用平均权重
@@ -501,47 +741,194 @@ func NewCompositeStrategy(strategies []BalanceStrategy, weights []float64) *Comp
 	return &CompositeStrategy{
 		strategies: strategies,
 		weights:    weights,
+		shardLimit: DefaultCompositeShardLimit,
+	}
+}
+
+// SetShardLimit 设置合并后单轮最多迁移的分片数，非正数时忽略
+func (s *CompositeStrategy) SetShardLimit(limit int) {
+	if limit > 0 {
+		s.shardLimit = limit
 	}
 }
 
+// SetMaxPlans 设置合并后最多保留的计划数，0表示不限制，负数时忽略
+func (s *CompositeStrategy) SetMaxPlans(n int) {
+	if n >= 0 {
+		s.maxPlans = n
+	}
+}
+
+// Name 返回策略名称
+func (s *CompositeStrategy) Name() string {
+	return StrategyNameComposite
+}
+
+// Strategies 返回组成复合策略的子策略
+func (s *CompositeStrategy) Strategies() []BalanceStrategy {
+	return s.strategies
+}
+
 // Evaluate 评估集群是否需要再平衡
 func (s *CompositeStrategy) Evaluate(nodeMetrics map[string]*types.NodeMetrics) (bool, float64) {
+	result := s.EvaluateDetail(nodeMetrics)
+	return result.NeedRebalance, result.ImbalanceScore
+}
+
+// EvaluateDetail 评估集群是否需要再平衡
+// 任一子策略超过自身阈值即需要再平衡，此时Source和Threshold取自第一个触发的子策略；
+// 否则取加权贡献最大的子策略，便于说明为何未触发
+func (s *CompositeStrategy) EvaluateDetail(nodeMetrics map[string]*types.NodeMetrics) StrategyEvaluation {
+	result := StrategyEvaluation{
+		Strategy:  StrategyNameComposite,
+		NodeCount: len(nodeMetrics),
+	}
 	if len(s.strategies) == 0 {
-		return false, 0.0
+		return result
 	}
 
-	var totalScore float64
-	var needRebalance bool
+	trigger, dominant := -1, 0
+	var dominantContribution float64
 
 	// 加权计算所有策略的评估结果
+	result.Components = make([]StrategyEvaluation, len(s.strategies))
 	for i, strategy := range s.strategies {
-		need, score := strategy.Evaluate(nodeMetrics)
-		if need {
-			needRebalance = true
+		component := EvaluateStrategy(strategy, nodeMetrics)
+		result.Components[i] = component
+
+		contribution := component.ImbalanceScore * s.weights[i]
+		result.ImbalanceScore += contribution
+
+		if component.NeedRebalance && trigger < 0 {
+			trigger = i
+		}
+		if contribution > dominantContribution {
+			dominant = i
+			dominantContribution = contribution
 		}
-		totalScore += score * s.weights[i]
 	}
 
-	return needRebalance, totalScore
+	source := dominant
+	if trigger >= 0 {
+		source = trigger
+		result.NeedRebalance = true
+	}
+	result.Source = result.Components[source].Strategy
+	result.Threshold = result.Components[source].Threshold
+
+	return result
 }
 
 // GeneratePlan 生成迁移计划
 func (s *CompositeStrategy) GeneratePlan(nodeMetrics map[string]*types.NodeMetrics) ([]*MigrationPlan, error) {
+	return s.GeneratePlanForTargets(nodeMetrics, nil)
+}
+
+// GeneratePlanForTargets 生成只以filter接受的节点为目标的迁移计划，约束传给每个子策略。
+// 合并所有子策略的计划：按"优先级×权重"从高到低选取，同一对节点之间只保留一个计划，
+// 并跳过与已选计划方向相反的迁移；总迁移量受shardLimit限制，并按权重分配给各子策略，计划数受maxPlans限制
+func (s *CompositeStrategy) GeneratePlanForTargets(nodeMetrics map[string]*types.NodeMetrics, filter TargetFilter) ([]*MigrationPlan, error) {
 	if len(s.strategies) == 0 {
 		return nil, errors.New("没有可用的策略")
 	}
 
-	// 使用评分最高的策略生成计划
-	var bestStrategy BalanceStrategy
-	var highestScore float64
+	type candidate struct {
+		plan     *MigrationPlan
+		strategy int
+		weighted float64
+	}
+
+	var candidates []candidate
+	var lastErr error
+	var succeeded int
+	var totalWeight float64
+
+	for i, strategy := range s.strategies {
+		plans, err := GeneratePlanForTargets(strategy, nodeMetrics, filter)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		succeeded++
+		totalWeight += s.weights[i]
+
+		for _, plan := range plans {
+			candidates = append(candidates, candidate{
+				plan:     plan,
+				strategy: i,
+				weighted: float64(plan.Priority) * s.weights[i],
+			})
+		}
+	}
+
+	if succeeded == 0 {
+		return nil, lastErr
+	}
 
-	for _, strategy := range s.strategies {
-		_, score := strategy.Evaluate(nodeMetrics)
-		if score > highestScore {
-			highestScore = score
-			bestStrategy = strategy
+	// 按加权优先级降序排序，相同时保持子策略顺序
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].weighted > candidates[j].weighted
+	})
+
+	// 按权重为各子策略分配分片预算
+	budgets := make([]int, len(s.strategies))
+	for i := range s.strategies {
+		if totalWeight > 0 {
+			budgets[i] = int(math.Floor(float64(s.shardLimit) * s.weights[i] / totalWeight))
+		}
+		if budgets[i] < 1 {
+			budgets[i] = 1
 		}
 	}
 
-	return bestStrategy.GeneratePlan(nodeMetrics)
+	type nodePair struct {
+		source, target types.NodeID
+	}
+	selected := make(map[nodePair]bool)
+
+	var merged []*MigrationPlan
+	for _, c := range candidates {
+		if s.maxPlans > 0 && len(merged) >= s.maxPlans {
+			break
+		}
+		plan := c.plan
+
+		// 同一对节点之间只保留一个计划，且不允许反向迁移
+		pair := nodePair{plan.SourceNodeID, plan.TargetNodeID}
+		reverse := nodePair{plan.TargetNodeID, plan.SourceNodeID}
+		if selected[pair] || selected[reverse] {
+			continue
+		}
+
+		remaining := budgets[c.strategy]
+		if remaining <= 0 || len(plan.ShardIDs) == 0 {
+			continue
+		}
+		if len(plan.ShardIDs) > remaining {
+			plan = truncatePlan(plan, remaining)
+		}
+
+		budgets[c.strategy] -= len(plan.ShardIDs)
+		selected[pair] = true
+		merged = append(merged, plan)
+	}
+
+	return merged, nil
+}
+
+// truncatePlan 返回只保留前n个分片的计划副本，预计数据量按比例缩减
+func truncatePlan(plan *MigrationPlan, n int) *MigrationPlan {
+	truncated := *plan
+	truncated.ShardIDs = append([]string(nil), plan.ShardIDs[:n]...)
+	truncated.EstimatedBytes = plan.EstimatedBytes / uint64(len(plan.ShardIDs)) * uint64(n)
+	return &truncated
+}
+
+// placeholderShardIDs 生成count个形如prefix+nodeID+"_"+序号的占位分片ID，在能获取真实分片ID之前用于迁移计划
+func placeholderShardIDs(prefix, nodeID string, count int) []string {
+	shardIDs := make([]string, count)
+	for j := range shardIDs {
+		shardIDs[j] = prefix + nodeID + "_" + strconv.Itoa(j)
+	}
+	return shardIDs
 }
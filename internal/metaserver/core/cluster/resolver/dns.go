@@ -0,0 +1,69 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// SRVLookup 通过DNS SRV记录查找节点地址，name为完整的SRV记录名，
+// 如Kubernetes无头服务的"_raft._tcp.metaserver.default.svc.cluster.local"
+func SRVLookup(name string) LookupFunc {
+	return func(ctx context.Context) (map[string]string, error) {
+		_, records, err := net.DefaultResolver.LookupSRV(ctx, "", "", name)
+		if err != nil {
+			return nil, err
+		}
+		return AddressesFromSRV(records), nil
+	}
+}
+
+// AddressesFromSRV 将SRV记录转换为节点地址，节点ID由目标主机名的第一段按NodeIDFromHost得出，
+// 得不出节点ID的记录被忽略；同一节点有多条记录时取优先级数值最小、权重最大的一条
+func AddressesFromSRV(records []*net.SRV) map[string]string {
+	addrs := make(map[string]string, len(records))
+	chosen := make(map[string]*net.SRV, len(records))
+	for _, rec := range records {
+		host := strings.TrimSuffix(rec.Target, ".")
+		label := host
+		if i := strings.IndexByte(host, '.'); i > 0 {
+			label = host[:i]
+		}
+		nodeID, ok := NodeIDFromHost(label)
+		if !ok {
+			continue
+		}
+		if prev, ok := chosen[nodeID]; ok {
+			if rec.Priority > prev.Priority || (rec.Priority == prev.Priority && rec.Weight <= prev.Weight) {
+				continue
+			}
+		}
+		chosen[nodeID] = rec
+		addrs[nodeID] = net.JoinHostPort(host, strconv.Itoa(int(rec.Port)))
+	}
+	return addrs
+}
+
+// NodeIDFromHost 由主机名的第一段得出Raft节点ID（正整数）：
+//   - 整段为正整数时直接作为节点ID，如"3"对应节点3
+//   - 以"-序号"结尾时为StatefulSet的Pod，序号从0开始，节点ID为序号+1，如"ms-0"对应节点1
+//
+// 其余主机名返回false
+func NodeIDFromHost(label string) (string, bool) {
+	if id, err := strconv.ParseUint(label, 10, 64); err == nil {
+		if id == 0 {
+			return "", false
+		}
+		return strconv.FormatUint(id, 10), true
+	}
+	i := strings.LastIndexByte(label, '-')
+	if i <= 0 {
+		return "", false
+	}
+	ordinal, err := strconv.ParseUint(label[i+1:], 10, 64)
+	if err != nil || ordinal+1 == 0 {
+		return "", false
+	}
+	return strconv.FormatUint(ordinal+1, 10), true
+}
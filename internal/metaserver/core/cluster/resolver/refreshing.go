@@ -0,0 +1,140 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultLookupTimeout 每次查找的默认超时，刷新间隔更短时以刷新间隔为准
+const DefaultLookupTimeout = 5 * time.Second
+
+// LookupFunc 查找全部节点的当前地址，返回节点ID -> 地址
+type LookupFunc func(ctx context.Context) (map[string]string, error)
+
+// RefreshingOption 解析器配置选项
+type RefreshingOption func(*Refreshing)
+
+// WithLookupTimeout 设置每次查找的超时
+func WithLookupTimeout(timeout time.Duration) RefreshingOption {
+	return func(r *Refreshing) {
+		if timeout > 0 {
+			r.timeout = timeout
+		}
+	}
+}
+
+// WithRefreshErrorHandler 设置后台刷新失败时的回调，用于记录日志
+func WithRefreshErrorHandler(handler func(error)) RefreshingOption {
+	return func(r *Refreshing) {
+		r.onError = handler
+	}
+}
+
+// Refreshing 定期调用LookupFunc刷新节点地址的解析器。
+// 查找失败或返回空结果时保留上一次成功的地址，DNS短暂不可用或滚动重启期间不会丢失全部节点
+type Refreshing struct {
+	lookup   LookupFunc
+	interval time.Duration
+	timeout  time.Duration
+	onError  func(error)
+
+	mu          sync.RWMutex
+	addrs       map[string]string
+	lastErr     error
+	lastRefresh time.Time // 最近一次成功刷新的时间
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewRefreshing 创建每隔interval刷新一次的解析器，每次查找最多等待DefaultLookupTimeout和interval中较短的一个
+func NewRefreshing(lookup LookupFunc, interval time.Duration, opts ...RefreshingOption) *Refreshing {
+	r := &Refreshing{
+		lookup:   lookup,
+		interval: interval,
+		timeout:  min(interval, DefaultLookupTimeout),
+		addrs:    make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Start 启动后台刷新并立即返回，第一次查找在后台立即进行，失败后按刷新间隔重试。
+// 第一次查找成功前Resolve返回ErrUnknownNode，调用方应有其他地址来源作为后备
+func (r *Refreshing) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.done = make(chan struct{})
+	go r.loop(ctx)
+}
+
+// Stop 停止后台刷新，已解析的地址仍然可用
+func (r *Refreshing) Stop() {
+	if r.cancel == nil {
+		return
+	}
+	r.cancel()
+	<-r.done
+}
+
+func (r *Refreshing) loop(ctx context.Context) {
+	defer close(r.done)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Refresh(ctx); err != nil && ctx.Err() == nil && r.onError != nil {
+			r.onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh 立即查找一次节点地址，成功时替换全部地址
+func (r *Refreshing) Refresh(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	addrs, err := r.lookup(ctx)
+	if err == nil && len(addrs) == 0 {
+		err = fmt.Errorf("查找结果为空")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastErr = err
+	if err != nil {
+		return err
+	}
+	r.addrs = make(map[string]string, len(addrs))
+	for id, addr := range addrs {
+		r.addrs[id] = addr
+	}
+	r.lastRefresh = time.Now()
+	return nil
+}
+
+// Resolve 返回最近一次成功刷新得到的地址
+func (r *Refreshing) Resolve(nodeID string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if addr := r.addrs[nodeID]; addr != "" {
+		return addr, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownNode, nodeID)
+}
+
+// Status 返回最近一次成功刷新的时间和最近一次刷新的错误，最近一次刷新成功时错误为nil
+func (r *Refreshing) Status() (lastRefresh time.Time, lastErr error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.lastRefresh, r.lastErr
+}
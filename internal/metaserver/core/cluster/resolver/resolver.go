@@ -0,0 +1,52 @@
+// Package resolver 将集群节点ID解析为网络地址，节点IP会变化的环境（如Kubernetes）中
+// 可以通过DNS SRV记录或自定义回调定期刷新地址，而不依赖静态配置
+package resolver
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownNode 解析器不知道该节点的地址
+var ErrUnknownNode = errors.New("未知节点")
+
+// Resolver 将节点ID解析为节点地址（host:port）
+type Resolver interface {
+	Resolve(nodeID string) (string, error)
+}
+
+// Func 将普通函数适配为Resolver
+type Func func(nodeID string) (string, error)
+
+// Resolve 调用f
+func (f Func) Resolve(nodeID string) (string, error) {
+	return f(nodeID)
+}
+
+// Static 基于固定映射的解析器，节点ID -> 地址
+type Static map[string]string
+
+// Resolve 返回映射中的地址，不存在或为空时返回ErrUnknownNode
+func (s Static) Resolve(nodeID string) (string, error) {
+	if addr := s[nodeID]; addr != "" {
+		return addr, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownNode, nodeID)
+}
+
+// Chain 依次尝试多个解析器，返回第一个成功的结果，全部失败时返回最后一个错误
+func Chain(resolvers ...Resolver) Resolver {
+	return Func(func(nodeID string) (string, error) {
+		err := fmt.Errorf("%w: %s", ErrUnknownNode, nodeID)
+		for _, r := range resolvers {
+			if r == nil {
+				continue
+			}
+			var addr string
+			if addr, err = r.Resolve(nodeID); err == nil {
+				return addr, nil
+			}
+		}
+		return "", err
+	})
+}
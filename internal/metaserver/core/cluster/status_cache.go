@@ -0,0 +1,52 @@
+package cluster
+
+import (
+	"sync"
+	"time"
+)
+
+// statusCache 保存最近一次完整的集群状态快照。
+// 领导者不可达或获取节点失败时，在ttl内用它代替实时数据，使监控在短暂故障期间仍然可用
+type statusCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	snapshot map[string]interface{}
+	cachedAt time.Time
+}
+
+// newStatusCache 创建状态缓存，ttl不大于0时不缓存
+func newStatusCache(ttl time.Duration) *statusCache {
+	return &statusCache{ttl: ttl}
+}
+
+// store 记录完整的快照
+func (c *statusCache) store(snapshot map[string]interface{}, now time.Time) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.snapshot = copySnapshot(snapshot)
+	c.cachedAt = now
+}
+
+// load 返回未过期的缓存快照副本及其记录时间
+func (c *statusCache) load(now time.Time) (map[string]interface{}, time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.snapshot == nil || now.Sub(c.cachedAt) > c.ttl {
+		return nil, time.Time{}, false
+	}
+	return copySnapshot(c.snapshot), c.cachedAt, true
+}
+
+// copySnapshot 复制快照的顶层字段，避免调用方添加的标记写回缓存
+func copySnapshot(snapshot map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(snapshot)+3)
+	for k, v := range snapshot {
+		result[k] = v
+	}
+	return result
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster"
 	"github.com/22827099/DFS_v1/internal/metaserver/core/database"
 	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/acl"
 )
 
 // MetaCore 封装元数据服务器的核心功能
@@ -30,8 +31,8 @@ type MetaCore struct {
 
 // NewMetaCore 创建核心组件管理器
 func NewMetaCore(cfg *metaconfig.Config, logger logging.Logger) (*MetaCore, error) {
-    logger.Info("NewMetaCore 被调用", "cfg", cfg, "nodeID", cfg.NodeID)
-    if cfg.NodeID == "" {
+    logger.Info("NewMetaCore 被调用", "cfg", cfg, "nodeID", cfg.Cluster.NodeID)
+    if cfg.Cluster.NodeID == "" {
         logger.Error("NodeID为空")
         return nil, errors.New("节点ID不能为空")
     }
@@ -42,14 +43,14 @@ func NewMetaCore(cfg *metaconfig.Config, logger logging.Logger) (*MetaCore, erro
 		return nil, err
 	}
 
-	// 初始化元数据管理
-	meta, err := metadata.NewManager(db, logger)
+	// 初始化元数据管理，security.enable_acl启用时各命名空间操作按目录权限检查
+	meta, err := metadata.NewManager(db, logger, metadata.WithACL(cfg.Security.EnableACL))
 	if err != nil {
 		return nil, err
 	}
 
 	// 初始化集群管理
-	clusterMgr, err := cluster.NewManager(cfg.Cluster, logger)
+	clusterMgr, err := cluster.NewManager(cfg.Cluster, logger, cluster.WithConsensusConfig(cfg.Consensus))
 	if err != nil {
 		return nil, err
 	}
@@ -94,3 +95,8 @@ func (c *MetaCore) Stop(ctx context.Context) error {
 	// 停止数据库连接
 	return c.db.Stop(ctx)
 }
+
+// CheckAccess 检查上下文中的用户能否对路径执行操作，未启用security.enable_acl时总是允许
+func (c *MetaCore) CheckAccess(ctx context.Context, path string, op acl.Operation) error {
+	return c.meta.CheckAccess(ctx, path, op)
+}
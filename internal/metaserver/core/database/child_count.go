@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// 目录的child_count列记录未删除的直接子目录和文件数，判断目录是否为空时无需扫描子项
+// 创建、删除和移动子项时必须在同一事务内调用下列函数维护计数
+
+// AdjustChildCount 在事务内调整目录的子项计数，计数变为负数时返回错误（说明计数已与实际不符）
+func AdjustChildCount(ctx context.Context, tx *sql.Tx, dirID int64, delta int) error {
+	if delta == 0 {
+		return nil
+	}
+	result, err := tx.ExecContext(ctx,
+		"UPDATE directories SET child_count = child_count + ? WHERE dir_id = ? AND child_count + ? >= 0",
+		delta, dirID, delta)
+	if err != nil {
+		return fmt.Errorf("更新目录%d的子项计数失败: %w", dirID, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("更新目录%d的子项计数失败: %w", dirID, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("目录%d不存在或子项计数将变为负数", dirID)
+	}
+	return nil
+}
+
+// MoveChild 在事务内将一个子项的计数从oldParentID转移到newParentID，父目录不变时不做修改
+func MoveChild(ctx context.Context, tx *sql.Tx, oldParentID, newParentID int64) error {
+	if oldParentID == newParentID {
+		return nil
+	}
+	if err := AdjustChildCount(ctx, tx, oldParentID, -1); err != nil {
+		return err
+	}
+	return AdjustChildCount(ctx, tx, newParentID, 1)
+}
+
+// ChildCount 返回目录的子项计数
+func (m *Manager) ChildCount(ctx context.Context, dirID int64) (int64, error) {
+	var count int64
+	err := m.QueryRowContext(ctx, "SELECT child_count FROM directories WHERE dir_id = ?", dirID).Scan(&count)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("目录不存在: %d", dirID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("查询目录%d的子项计数失败: %w", dirID, err)
+	}
+	return count, nil
+}
+
+// IsDirectoryEmpty 根据子项计数判断目录是否为空，只读取目录本身的一行
+func (m *Manager) IsDirectoryEmpty(ctx context.Context, dirID int64) (bool, error) {
+	count, err := m.ChildCount(ctx, dirID)
+	if err != nil {
+		return false, err
+	}
+	return count == 0, nil
+}
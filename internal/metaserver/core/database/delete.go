@@ -0,0 +1,153 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// 每次删除操作分配一个删除批次号，写入本次删除的所有条目的delete_batch列。
+// 递归恢复目录时只恢复与目录同一批次删除的条目，此前单独删除的条目保持删除；
+// 迁移前删除的条目没有批次号，视为同一批次
+
+// ErrDirectoryNotEmpty 非递归删除的目录不为空
+var ErrDirectoryNotEmpty = errors.New("目录不为空")
+
+// NewDeleteBatch 在事务内分配新的删除批次号
+func NewDeleteBatch(ctx context.Context, tx *sql.Tx) (int64, error) {
+	var batch int64
+	err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(batch), 0) + 1 FROM (
+            SELECT MAX(delete_batch) AS batch FROM directories
+            UNION ALL
+            SELECT MAX(delete_batch) AS batch FROM files
+        ) AS batches`).Scan(&batch)
+	if err != nil {
+		return 0, fmt.Errorf("分配删除批次失败: %w", err)
+	}
+	return batch, nil
+}
+
+// DeleteFile 在事务内删除文件并减少父目录的子项计数
+func DeleteFile(ctx context.Context, tx *sql.Tx, fileID int64) error {
+	var (
+		parentID int64
+		deleted  bool
+	)
+	err := tx.QueryRowContext(ctx,
+		"SELECT parent_dir_id, is_deleted FROM files WHERE file_id = ?", fileID).
+		Scan(&parentID, &deleted)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && deleted) {
+		return fmt.Errorf("文件不存在: %d", fileID)
+	}
+	if err != nil {
+		return fmt.Errorf("查询文件%d失败: %w", fileID, err)
+	}
+
+	batch, err := NewDeleteBatch(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE files SET is_deleted = TRUE, delete_batch = ? WHERE file_id = ?", batch, fileID); err != nil {
+		return fmt.Errorf("删除文件%d失败: %w", fileID, err)
+	}
+	return AdjustChildCount(ctx, tx, parentID, -1)
+}
+
+// DeleteDirectory 在事务内删除目录，返回删除的条目数（含目录本身）。
+// recursive为false时目录必须为空；为true时子树中所有未删除的条目与目录记录为同一批次删除
+func DeleteDirectory(ctx context.Context, tx *sql.Tx, dirID int64, recursive bool) (int, error) {
+	var (
+		parentID   sql.NullInt64
+		deleted    bool
+		childCount int64
+	)
+	err := tx.QueryRowContext(ctx,
+		"SELECT parent_id, is_deleted, child_count FROM directories WHERE dir_id = ?", dirID).
+		Scan(&parentID, &deleted, &childCount)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && deleted) {
+		return 0, fmt.Errorf("目录不存在: %d", dirID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("查询目录%d失败: %w", dirID, err)
+	}
+	if !parentID.Valid {
+		return 0, fmt.Errorf("不能删除根目录")
+	}
+	if !recursive && childCount > 0 {
+		return 0, fmt.Errorf("目录%d: %w", dirID, ErrDirectoryNotEmpty)
+	}
+
+	batch, err := NewDeleteBatch(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+	deletedCount, err := deleteDescendants(ctx, tx, dirID, batch)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE directories SET is_deleted = TRUE, delete_batch = ? WHERE dir_id = ?", batch, dirID); err != nil {
+		return 0, fmt.Errorf("删除目录%d失败: %w", dirID, err)
+	}
+	if err := AdjustChildCount(ctx, tx, parentID.Int64, -1); err != nil {
+		return 0, err
+	}
+	return deletedCount + 1, nil
+}
+
+// deleteDescendants 以batch删除目录下所有未删除的条目，先删除子目录的子树再删除子目录本身
+func deleteDescendants(ctx context.Context, tx *sql.Tx, dirID, batch int64) (int, error) {
+	// 先读完全部子目录再修改，避免在同一连接上边读边写
+	rows, err := tx.QueryContext(ctx, "SELECT dir_id FROM directories WHERE parent_id = ? AND is_deleted = FALSE", dirID)
+	if err != nil {
+		return 0, fmt.Errorf("查询目录%d的子目录失败: %w", dirID, err)
+	}
+	var dirs []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("查询目录%d的子目录失败: %w", dirID, err)
+		}
+		dirs = append(dirs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("查询目录%d的子目录失败: %w", dirID, err)
+	}
+
+	deleted := 0
+	for _, id := range dirs {
+		n, err := deleteDescendants(ctx, tx, id, batch)
+		if err != nil {
+			return 0, err
+		}
+		deleted += n
+	}
+
+	result, err := tx.ExecContext(ctx,
+		"UPDATE directories SET is_deleted = TRUE, delete_batch = ? WHERE parent_id = ? AND is_deleted = FALSE", batch, dirID)
+	if err != nil {
+		return 0, fmt.Errorf("删除目录%d的子目录失败: %w", dirID, err)
+	}
+	dirCount, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("删除目录%d的子目录失败: %w", dirID, err)
+	}
+	result, err = tx.ExecContext(ctx,
+		"UPDATE files SET is_deleted = TRUE, delete_batch = ? WHERE parent_dir_id = ? AND is_deleted = FALSE", batch, dirID)
+	if err != nil {
+		return 0, fmt.Errorf("删除目录%d下的文件失败: %w", dirID, err)
+	}
+	fileCount, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("删除目录%d下的文件失败: %w", dirID, err)
+	}
+
+	if err := AdjustChildCount(ctx, tx, dirID, -int(dirCount+fileCount)); err != nil {
+		return 0, err
+	}
+	return deleted + int(dirCount+fileCount), nil
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"reflect"
+	"strings"
 )
 
 var (
@@ -91,7 +93,10 @@ func (m *Manager) DoInTransaction(ctx context.Context, fn func(*Transaction) err
 	return tx.Commit()
 }
 
-// ScanRows 将行扫描到结构体切片中
+// ScanRows 将行扫描到结构体切片中，dest必须是指向[]T或[]*T的指针，T为结构体
+// 列按db标签匹配字段，没有标签时使用小写的字段名，匿名嵌入结构体的字段同样参与匹配；没有对应字段的列被忽略。
+// 指针字段和实现了sql.Scanner的字段（如sql.NullString）直接扫描，NULL分别得到nil和Valid=false；
+// 其他字段遇到NULL时保持零值
 func ScanRows(rows *sql.Rows, dest interface{}) error {
 	// 检查dest是否是指向切片的指针
 	destValue := reflect.ValueOf(dest)
@@ -101,35 +106,62 @@ func ScanRows(rows *sql.Rows, dest interface{}) error {
 
 	sliceVal := destValue.Elem()
 	elemType := sliceVal.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("切片元素必须是结构体或结构体指针: %s", elemType)
+	}
 
-	// 获取列信息
+	// 获取列信息，并找出每一列对应的字段
 	columns, err := rows.Columns()
 	if err != nil {
 		return err
 	}
+	fields := columnFields(structType)
+	indexes := make([][]int, len(columns))
+	for i, col := range columns {
+		indexes[i] = fields[col]
+	}
 
 	// 处理每一行
 	for rows.Next() {
-		// 创建新的元素
-		newElem := reflect.New(elemType).Elem()
-
-		// 为每个列创建扫描目标
-		scanTargets := make([]interface{}, len(columns))
-		for i, colName := range columns {
-			field := newElem.FieldByName(colName)
-			if !field.IsValid() {
-				continue // 忽略结构体中不存在的字段
+		elem := reflect.New(structType).Elem()
+
+		// 为每个列创建扫描目标，不能直接接收NULL的字段先扫描到**T
+		targets := make([]interface{}, len(columns))
+		var nullable []nullableField
+		for i, index := range indexes {
+			if index == nil {
+				targets[i] = new(interface{})
+				continue
 			}
-			scanTargets[i] = field.Addr().Interface()
+			field := elem.FieldByIndex(index)
+			if acceptsNull(field.Type()) {
+				targets[i] = field.Addr().Interface()
+				continue
+			}
+			holder := reflect.New(reflect.PointerTo(field.Type()))
+			targets[i] = holder.Interface()
+			nullable = append(nullable, nullableField{field: field, holder: holder.Elem()})
 		}
 
-		// 扫描行到目标
-		if err := rows.Scan(scanTargets...); err != nil {
+		if err := rows.Scan(targets...); err != nil {
 			return err
 		}
+		for _, f := range nullable {
+			if !f.holder.IsNil() {
+				f.field.Set(f.holder.Elem())
+			}
+		}
 
 		// 添加到结果切片
-		sliceVal = reflect.Append(sliceVal, newElem)
+		if elemType.Kind() == reflect.Ptr {
+			sliceVal = reflect.Append(sliceVal, elem.Addr())
+		} else {
+			sliceVal = reflect.Append(sliceVal, elem)
+		}
 	}
 
 	// 检查遍历过程中的错误
@@ -141,3 +173,51 @@ func ScanRows(rows *sql.Rows, dest interface{}) error {
 	destValue.Elem().Set(sliceVal)
 	return nil
 }
+
+// nullableField 不能直接接收NULL的字段，holder为扫描用的*T，NULL时为nil
+type nullableField struct {
+	field  reflect.Value
+	holder reflect.Value
+}
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// acceptsNull 返回字段能否直接作为扫描目标接收NULL
+func acceptsNull(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface || reflect.PointerTo(t).Implements(scannerType)
+}
+
+// columnFields 返回列名到结构体字段索引的映射，嵌入结构体中的同名列被外层字段覆盖
+func columnFields(t reflect.Type) map[string][]int {
+	fields := make(map[string][]int)
+	var walk func(t reflect.Type, prefix []int)
+	walk = func(t reflect.Type, prefix []int) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("db")
+			if tag == "-" {
+				continue
+			}
+			index := append(append([]int(nil), prefix...), i)
+
+			// 没有标签的匿名嵌入结构体展开其字段，嵌入的结构体指针可能为nil，不展开
+			if field.Anonymous && tag == "" && field.Type.Kind() == reflect.Struct {
+				walk(field.Type, index)
+				continue
+			}
+			if !field.IsExported() {
+				continue
+			}
+
+			name := tag
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			if existing, ok := fields[name]; !ok || len(index) < len(existing) {
+				fields[name] = index
+			}
+		}
+	}
+	walk(t, nil)
+	return fields
+}
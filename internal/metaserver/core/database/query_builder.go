@@ -1,10 +1,25 @@
 package database
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
 	"strings"
 )
 
+// ErrUnsafeSQL 条件或标识符中包含内联的值或SQL片段
+// 查询的值必须通过?占位符和参数传递，不能拼接进SQL字符串
+var ErrUnsafeSQL = errors.New("不安全的SQL")
+
+var (
+	// identifierPattern 表名、列名，允许table.column形式
+	identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+	// orderTermPattern ORDER BY的单个排序项
+	orderTermPattern = regexp.MustCompile(`^(?i)[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?(\s+(ASC|DESC))?$`)
+	// numericComparePattern 与数字字面量比较，通常是把值格式化进了条件
+	numericComparePattern = regexp.MustCompile(`(=|<>|!=|<|>|<=|>=)\s*-?[0-9]`)
+)
+
 // QueryBuilder 帮助构建SQL查询
 type QueryBuilder struct {
 	table     string
@@ -14,6 +29,7 @@ type QueryBuilder struct {
 	orderBy   string
 	limit     int
 	offset    int
+	err       error // 第一个不安全的标识符或排序项，构建查询前由Err返回
 }
 
 // NewQueryBuilder 创建新的查询构建器
@@ -28,19 +44,29 @@ func NewQueryBuilder(table string) *QueryBuilder {
 
 // Select 设置要查询的列
 func (qb *QueryBuilder) Select(columns ...string) *QueryBuilder {
+	for _, col := range columns {
+		if col != "*" && !identifierPattern.MatchString(col) {
+			qb.fail(fmt.Errorf("%w: 无效的列名 %q", ErrUnsafeSQL, col))
+		}
+	}
 	qb.columns = columns
 	return qb
 }
 
-// Where 添加WHERE条件
+// Where 添加WHERE条件，条件中的值必须使用?占位符并通过args传递
 func (qb *QueryBuilder) Where(condition string, args ...interface{}) *QueryBuilder {
 	qb.where = append(qb.where, condition)
 	qb.whereArgs = append(qb.whereArgs, args...)
 	return qb
 }
 
-// OrderBy 设置排序
+// OrderBy 设置排序，只接受以逗号分隔的"列名 [ASC|DESC]"
 func (qb *QueryBuilder) OrderBy(orderBy string) *QueryBuilder {
+	for _, term := range strings.Split(orderBy, ",") {
+		if !orderTermPattern.MatchString(strings.TrimSpace(term)) {
+			qb.fail(fmt.Errorf("%w: 无效的排序项 %q", ErrUnsafeSQL, term))
+		}
+	}
 	qb.orderBy = orderBy
 	return qb
 }
@@ -57,6 +83,52 @@ func (qb *QueryBuilder) Offset(offset int) *QueryBuilder {
 	return qb
 }
 
+// Err 检查查询是否只使用参数化的值，执行Build*生成的语句前必须调用
+// 表名、列名和排序项必须是合法标识符；每个WHERE条件不能包含字符串字面量、注释或语句分隔符，
+// 不能与数字字面量比较，且所有条件的占位符总数必须与参数个数一致
+func (qb *QueryBuilder) Err() error {
+	if qb.err != nil {
+		return qb.err
+	}
+	if !identifierPattern.MatchString(qb.table) {
+		return fmt.Errorf("%w: 无效的表名 %q", ErrUnsafeSQL, qb.table)
+	}
+
+	placeholders := 0
+	for _, cond := range qb.where {
+		if err := ValidateCondition(cond); err != nil {
+			return err
+		}
+		placeholders += strings.Count(cond, "?")
+	}
+	if placeholders != len(qb.whereArgs) {
+		return fmt.Errorf("%w: 条件中有%d个占位符，但提供了%d个参数", ErrUnsafeSQL, placeholders, len(qb.whereArgs))
+	}
+	return nil
+}
+
+// ValidateCondition 检查WHERE条件是否只通过占位符引用值
+func ValidateCondition(condition string) error {
+	switch {
+	case strings.TrimSpace(condition) == "":
+		return fmt.Errorf("%w: 条件不能为空", ErrUnsafeSQL)
+	case strings.ContainsAny(condition, "'\"`;"):
+		return fmt.Errorf("%w: 条件包含字面量或语句分隔符 %q", ErrUnsafeSQL, condition)
+	case strings.Contains(condition, "--") || strings.Contains(condition, "/*"):
+		return fmt.Errorf("%w: 条件包含注释 %q", ErrUnsafeSQL, condition)
+	case numericComparePattern.MatchString(condition):
+		return fmt.Errorf("%w: 条件与数字字面量比较 %q", ErrUnsafeSQL, condition)
+	}
+	return nil
+}
+
+// fail 记录第一个错误
+func (qb *QueryBuilder) fail(err error) {
+	if qb.err == nil {
+		qb.err = err
+	}
+}
+
 // BuildSelect 构建SELECT查询
 func (qb *QueryBuilder) BuildSelect() (string, []interface{}) {
 	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(qb.columns, ", "), qb.table)
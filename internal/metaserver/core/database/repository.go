@@ -29,33 +29,34 @@ func NewRepository(manager *Manager, table string) *Repository {
 
 // FindByID 按ID查找记录
 func (r *Repository) FindByID(ctx context.Context, id interface{}, dest interface{}) error {
-	qb := NewQueryBuilder(r.table).Where("id = ?", id)
-	query, args := qb.BuildSelect()
-
-	row := r.manager.QueryRowContext(ctx, query, args...)
-	return scanStruct(row, dest)
+	return r.FindOne(ctx, dest, "id = ?", id)
 }
 
-// FindOne 查找单个记录
+// FindOne 查找单个记录，列按db标签扫描到dest指向的结构体，规则与ScanRows相同；没有记录时返回sql.ErrNoRows
 func (r *Repository) FindOne(ctx context.Context, dest interface{}, where string, args ...interface{}) error {
-	qb := NewQueryBuilder(r.table).Where(where)
-	for _, arg := range args {
-		qb.whereArgs = append(qb.whereArgs, arg)
+	qb := NewQueryBuilder(r.table).Where(where, args...).Limit(1)
+	if err := qb.Err(); err != nil {
+		return err
 	}
 	query, queryArgs := qb.BuildSelect()
 
-	row := r.manager.QueryRowContext(ctx, query, queryArgs...)
-	return scanStruct(row, dest)
+	rows, err := r.manager.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	return scanOne(rows, dest)
 }
 
 // FindAll 查找多个记录
 func (r *Repository) FindAll(ctx context.Context, dest interface{}, where string, args ...interface{}) error {
 	qb := NewQueryBuilder(r.table)
 	if where != "" {
-		qb.Where(where)
-		for _, arg := range args {
-			qb.whereArgs = append(qb.whereArgs, arg)
-		}
+		qb.Where(where, args...)
+	}
+	if err := qb.Err(); err != nil {
+		return err
 	}
 	query, queryArgs := qb.BuildSelect()
 
@@ -68,6 +69,77 @@ func (r *Repository) FindAll(ctx context.Context, dest interface{}, where string
 	return ScanRows(rows, dest)
 }
 
+// DefaultStreamPageSize 分页流式查询的默认每页记录数
+const DefaultStreamPageSize = 1000
+
+// StreamOptions 分页流式查询选项
+type StreamOptions struct {
+	Columns  []string      // 查询的列，为空时查询所有列
+	Where    string        // 查询条件，值必须使用?占位符
+	Args     []interface{} // 条件参数
+	OrderBy  string        // 排序，必须是唯一键以保证翻页时结果稳定
+	PageSize int           // 每页记录数，不大于0时使用DefaultStreamPageSize
+}
+
+// Stream 使用LIMIT/OFFSET分页查询记录并逐行调用fn，同一时间只有一页结果在读取中
+// 每页查询前和每行处理前检查ctx，取消后停止翻页并返回ctx.Err()；fn返回错误时停止并返回该错误
+func (r *Repository) Stream(ctx context.Context, opts StreamOptions, fn func(rows *sql.Rows) error) error {
+	if opts.OrderBy == "" {
+		return errors.New("分页查询必须指定排序")
+	}
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultStreamPageSize
+	}
+
+	for offset := 0; ; offset += pageSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		qb := NewQueryBuilder(r.table).OrderBy(opts.OrderBy).Limit(pageSize).Offset(offset)
+		if len(opts.Columns) > 0 {
+			qb.Select(opts.Columns...)
+		}
+		if opts.Where != "" {
+			qb.Where(opts.Where, opts.Args...)
+		}
+		if err := qb.Err(); err != nil {
+			return err
+		}
+		query, args := qb.BuildSelect()
+
+		n, err := r.streamPage(ctx, query, args, fn)
+		if err != nil {
+			return err
+		}
+		if n < pageSize {
+			return nil
+		}
+	}
+}
+
+// streamPage 读取一页结果，返回处理的行数
+func (r *Repository) streamPage(ctx context.Context, query string, args []interface{}, fn func(rows *sql.Rows) error) (int, error) {
+	rows, err := r.manager.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+		if err := fn(rows); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, rows.Err()
+}
+
 // Insert 插入记录
 func (r *Repository) Insert(ctx context.Context, entity interface{}) (sql.Result, error) {
 	columns, values, err := extractInsertValues(entity)
@@ -92,6 +164,14 @@ func (r *Repository) Update(ctx context.Context, entity interface{}, where strin
 		return nil, err
 	}
 
+	// UPDATE不经过查询构建器，单独检查条件和占位符
+	if err := ValidateCondition(where); err != nil {
+		return nil, err
+	}
+	if n := strings.Count(where, "?"); n != len(args) {
+		return nil, fmt.Errorf("%w: 条件中有%d个占位符，但提供了%d个参数", ErrUnsafeSQL, n, len(args))
+	}
+
 	// 构建SET部分
 	setParts := make([]string, len(columns))
 	for i, col := range columns {
@@ -113,15 +193,13 @@ func (r *Repository) Update(ctx context.Context, entity interface{}, where strin
 
 // Delete 删除记录
 func (r *Repository) Delete(ctx context.Context, where string, args ...interface{}) (sql.Result, error) {
-	qb := NewQueryBuilder(r.table)
-	if where != "" {
-		qb.Where(where)
-		for _, arg := range args {
-			qb.whereArgs = append(qb.whereArgs, arg)
-		}
-	} else {
+	if where == "" {
 		return nil, errors.New("删除操作必须指定WHERE条件")
 	}
+	qb := NewQueryBuilder(r.table).Where(where, args...)
+	if err := qb.Err(); err != nil {
+		return nil, err
+	}
 
 	query, queryArgs := qb.BuildDelete()
 	return r.manager.ExecContext(ctx, query, queryArgs...)
@@ -131,10 +209,10 @@ func (r *Repository) Delete(ctx context.Context, where string, args ...interface
 func (r *Repository) Count(ctx context.Context, where string, args ...interface{}) (int, error) {
 	qb := NewQueryBuilder(r.table)
 	if where != "" {
-		qb.Where(where)
-		for _, arg := range args {
-			qb.whereArgs = append(qb.whereArgs, arg)
-		}
+		qb.Where(where, args...)
+	}
+	if err := qb.Err(); err != nil {
+		return 0, err
 	}
 	query, queryArgs := qb.BuildCount()
 
@@ -145,27 +223,22 @@ func (r *Repository) Count(ctx context.Context, where string, args ...interface{
 
 // 辅助函数
 
-// scanStruct 将行扫描到结构体
-func scanStruct(row *sql.Row, dest interface{}) error {
-	// 获取目标类型信息
+// scanOne 将结果的第一行扫描到dest指向的结构体，没有结果时返回sql.ErrNoRows
+func scanOne(rows *sql.Rows, dest interface{}) error {
 	destVal := reflect.ValueOf(dest)
 	if destVal.Kind() != reflect.Ptr || destVal.Elem().Kind() != reflect.Struct {
 		return errors.New("目标必须是指向结构体的指针")
 	}
 
-	// 获取结构体值
-	structVal := destVal.Elem()
-	structType := structVal.Type()
-
-	// 创建字段列表
-	numFields := structType.NumField()
-	fields := make([]interface{}, numFields)
-	for i := 0; i < numFields; i++ {
-		fields[i] = structVal.Field(i).Addr().Interface()
+	results := reflect.New(reflect.SliceOf(destVal.Elem().Type()))
+	if err := ScanRows(rows, results.Interface()); err != nil {
+		return err
 	}
-
-	// 扫描行到字段
-	return row.Scan(fields...)
+	if results.Elem().Len() == 0 {
+		return sql.ErrNoRows
+	}
+	destVal.Elem().Set(results.Elem().Index(0))
+	return nil
 }
 
 // extractInsertValues 从结构体提取列和值
@@ -196,6 +269,9 @@ func extractInsertValues(entity interface{}) ([]string, []interface{}, error) {
 		if colName == "-" || colName == "id" {
 			continue
 		}
+		if !identifierPattern.MatchString(colName) {
+			return nil, nil, fmt.Errorf("%w: 字段%s的列名无效 %q", ErrUnsafeSQL, field.Name, colName)
+		}
 
 		columns = append(columns, colName)
 		values = append(values, val.Field(i).Interface())
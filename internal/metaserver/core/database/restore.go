@@ -0,0 +1,207 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// 删除是逻辑删除（is_deleted），恢复只需清除删除标记并在同一事务内恢复父目录的子项计数。
+// 递归恢复按删除批次（delete_batch）只恢复与目录在同一次操作中删除的条目
+
+var (
+	// ErrNotDeleted 要恢复的条目未被删除
+	ErrNotDeleted = errors.New("条目未被删除")
+	// ErrParentDeleted 父目录已删除，需要先恢复父目录
+	ErrParentDeleted = errors.New("父目录已删除")
+	// ErrRestoreConflict 同名的未删除条目已存在
+	ErrRestoreConflict = errors.New("同名条目已存在")
+)
+
+// 按父目录和名称统计未删除的目录和文件，恢复前检查名称是否已被占用
+const (
+	liveDirByNameQuery  = "SELECT COUNT(*) FROM directories WHERE parent_id = ? AND name = ? AND is_deleted = FALSE"
+	liveFileByNameQuery = "SELECT COUNT(*) FROM files WHERE parent_dir_id = ? AND name = ? AND is_deleted = FALSE"
+)
+
+// RestoreFile 在事务内恢复已删除的文件，父目录必须未删除且同名位置没有未删除的条目
+func RestoreFile(ctx context.Context, tx *sql.Tx, fileID int64) error {
+	var (
+		parentID int64
+		name     string
+		deleted  bool
+	)
+	err := tx.QueryRowContext(ctx,
+		"SELECT parent_dir_id, name, is_deleted FROM files WHERE file_id = ?", fileID).
+		Scan(&parentID, &name, &deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("文件不存在: %d", fileID)
+	}
+	if err != nil {
+		return fmt.Errorf("查询文件%d失败: %w", fileID, err)
+	}
+	if !deleted {
+		return fmt.Errorf("文件%d: %w", fileID, ErrNotDeleted)
+	}
+	if err := checkParentLive(ctx, tx, parentID); err != nil {
+		return err
+	}
+	return restoreFile(ctx, tx, fileID, parentID, name)
+}
+
+// RestoreDirectory 在事务内恢复已删除的目录，返回恢复的条目数（含目录本身）。
+// recursive为true时同时恢复子树中与目录同一批次删除的目录和文件，任一条目的名称已被占用时整体失败；
+// 在目录之前单独删除的条目不会恢复
+func RestoreDirectory(ctx context.Context, tx *sql.Tx, dirID int64, recursive bool) (int, error) {
+	var (
+		parentID sql.NullInt64
+		name     string
+		deleted  bool
+		batch    int64
+	)
+	err := tx.QueryRowContext(ctx,
+		"SELECT parent_id, name, is_deleted, COALESCE(delete_batch, 0) FROM directories WHERE dir_id = ?", dirID).
+		Scan(&parentID, &name, &deleted, &batch)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("目录不存在: %d", dirID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("查询目录%d失败: %w", dirID, err)
+	}
+	// 根目录没有父目录，不会被删除
+	if !deleted || !parentID.Valid {
+		return 0, fmt.Errorf("目录%d: %w", dirID, ErrNotDeleted)
+	}
+	if err := checkParentLive(ctx, tx, parentID.Int64); err != nil {
+		return 0, err
+	}
+	if err := restoreDirectory(ctx, tx, dirID, parentID.Int64, name); err != nil {
+		return 0, err
+	}
+	if !recursive {
+		return 1, nil
+	}
+
+	restored, err := restoreDescendants(ctx, tx, dirID, batch)
+	if err != nil {
+		return 0, err
+	}
+	return restored + 1, nil
+}
+
+// restoreDescendants 恢复目录下以batch删除的条目，并进入未删除或以batch删除的子目录继续恢复；
+// 以其他批次删除的子目录是单独删除的，其子树保持不变
+func restoreDescendants(ctx context.Context, tx *sql.Tx, dirID, batch int64) (int, error) {
+	type child struct {
+		id      int64
+		name    string
+		deleted bool
+		batch   int64
+	}
+	// 先读完全部子项再修改，避免在同一连接上边读边写
+	collect := func(query string) ([]child, error) {
+		rows, err := tx.QueryContext(ctx, query, dirID)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		var children []child
+		for rows.Next() {
+			var c child
+			if err := rows.Scan(&c.id, &c.name, &c.deleted, &c.batch); err != nil {
+				return nil, err
+			}
+			children = append(children, c)
+		}
+		return children, rows.Err()
+	}
+
+	files, err := collect("SELECT file_id, name, is_deleted, COALESCE(delete_batch, 0) FROM files WHERE parent_dir_id = ? AND is_deleted = TRUE")
+	if err != nil {
+		return 0, fmt.Errorf("查询目录%d下已删除的文件失败: %w", dirID, err)
+	}
+	dirs, err := collect("SELECT dir_id, name, is_deleted, COALESCE(delete_batch, 0) FROM directories WHERE parent_id = ?")
+	if err != nil {
+		return 0, fmt.Errorf("查询目录%d的子目录失败: %w", dirID, err)
+	}
+
+	restored := 0
+	for _, f := range files {
+		if f.batch != batch {
+			continue
+		}
+		if err := restoreFile(ctx, tx, f.id, dirID, f.name); err != nil {
+			return 0, err
+		}
+		restored++
+	}
+	for _, d := range dirs {
+		if d.deleted && d.batch != batch {
+			continue
+		}
+		if d.deleted {
+			if err := restoreDirectory(ctx, tx, d.id, dirID, d.name); err != nil {
+				return 0, err
+			}
+			restored++
+		}
+		n, err := restoreDescendants(ctx, tx, d.id, batch)
+		if err != nil {
+			return 0, err
+		}
+		restored += n
+	}
+	return restored, nil
+}
+
+// checkParentLive 检查父目录存在且未删除
+func checkParentLive(ctx context.Context, tx *sql.Tx, parentID int64) error {
+	var deleted bool
+	err := tx.QueryRowContext(ctx, "SELECT is_deleted FROM directories WHERE dir_id = ?", parentID).Scan(&deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("父目录不存在: %d", parentID)
+	}
+	if err != nil {
+		return fmt.Errorf("查询父目录%d失败: %w", parentID, err)
+	}
+	if deleted {
+		return fmt.Errorf("目录%d: %w", parentID, ErrParentDeleted)
+	}
+	return nil
+}
+
+// checkNameFree 检查父目录下没有同名的未删除目录或文件
+func checkNameFree(ctx context.Context, tx *sql.Tx, parentID int64, name string) error {
+	for _, query := range []string{liveDirByNameQuery, liveFileByNameQuery} {
+		var count int
+		if err := tx.QueryRowContext(ctx, query, parentID, name).Scan(&count); err != nil {
+			return fmt.Errorf("检查名称%q失败: %w", name, err)
+		}
+		if count > 0 {
+			return fmt.Errorf("%q: %w", name, ErrRestoreConflict)
+		}
+	}
+	return nil
+}
+
+func restoreFile(ctx context.Context, tx *sql.Tx, fileID, parentID int64, name string) error {
+	if err := checkNameFree(ctx, tx, parentID, name); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE files SET is_deleted = FALSE, delete_batch = NULL WHERE file_id = ?", fileID); err != nil {
+		return fmt.Errorf("恢复文件%d失败: %w", fileID, err)
+	}
+	return AdjustChildCount(ctx, tx, parentID, 1)
+}
+
+func restoreDirectory(ctx context.Context, tx *sql.Tx, dirID, parentID int64, name string) error {
+	if err := checkNameFree(ctx, tx, parentID, name); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE directories SET is_deleted = FALSE, delete_batch = NULL WHERE dir_id = ?", dirID); err != nil {
+		return fmt.Errorf("恢复目录%d失败: %w", dirID, err)
+	}
+	return AdjustChildCount(ctx, tx, parentID, 1)
+}
@@ -47,6 +47,11 @@ func (s *Schema) Initialize(ctx context.Context) error {
 		}
 	}
 
+	// 应用表结构迁移
+	if err := migrationManager.ApplyMigrations(ctx, SchemaMigrations()); err != nil {
+		return fmt.Errorf("应用迁移失败: %w", err)
+	}
+
 	// 初始化根目录
 	if err := s.initRootDirectory(ctx); err != nil {
 		return fmt.Errorf("初始化根目录失败: %w", err)
@@ -115,6 +120,52 @@ func (s *Schema) GetSchemaVersion(ctx context.Context) (int, error) {
     return version, err
 }
 
+// SchemaMigrations 返回建表之后按版本依次应用的迁移
+func SchemaMigrations() []Migration {
+	return []Migration{
+		{
+			Version:     1,
+			Description: "目录增加子项计数列",
+			SQL:         "ALTER TABLE directories ADD COLUMN child_count BIGINT NOT NULL DEFAULT 0",
+		},
+		{
+			Version:     2,
+			Description: "回填目录子项计数",
+			// 子查询使用派生表，避免MySQL禁止在UPDATE的子查询中引用目标表
+			SQL: `UPDATE directories SET child_count =
+                (SELECT COUNT(*) FROM (SELECT parent_id, is_deleted FROM directories) c
+                    WHERE c.parent_id = directories.dir_id AND c.is_deleted = FALSE) +
+                (SELECT COUNT(*) FROM (SELECT parent_dir_id, is_deleted FROM files) f
+                    WHERE f.parent_dir_id = directories.dir_id AND f.is_deleted = FALSE)`,
+		},
+		{
+			Version:     3,
+			Description: "目录增加删除批次列",
+			SQL:         "ALTER TABLE directories ADD COLUMN delete_batch BIGINT NULL",
+		},
+		{
+			Version:     4,
+			Description: "文件增加删除批次列",
+			SQL:         "ALTER TABLE files ADD COLUMN delete_batch BIGINT NULL",
+		},
+		{
+			Version:     5,
+			Description: "增加ID序列表",
+			SQL: `CREATE TABLE IF NOT EXISTS id_sequences (
+                name            VARCHAR(64) PRIMARY KEY,
+                next_id         BIGINT NOT NULL
+            )`,
+		},
+		{
+			Version:     6,
+			Description: "初始化用户ID序列",
+			// 从现有的最大用户ID之后开始，空库时跳过系统用户的ID
+			SQL: `INSERT INTO id_sequences (name, next_id)
+                SELECT 'users', COALESCE(MAX(user_id), 1) + 1 FROM users`,
+		},
+	}
+}
+
 // 创建表的SQL语句
 var createTableStatements = []string{
 	// 目录表
@@ -0,0 +1,104 @@
+package acl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/22827099/DFS_v1/internal/metaserver/core/database"
+)
+
+// DBStore 基于permissions表的权限数据源
+type DBStore struct {
+	db *database.Manager
+}
+
+// NewDBStore 创建基于数据库的权限数据源
+func NewDBStore(db *database.Manager) *DBStore {
+	return &DBStore{db: db}
+}
+
+// Grants 返回用户在对象上直接拥有的权限
+func (s *DBStore) Grants(ctx context.Context, userID int, objectType ObjectType, objectID int64) ([]Permission, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT permission_type FROM permissions WHERE object_id = ? AND object_type = ? AND user_id = ?",
+		objectID, string(objectType), userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询权限失败: %w", err)
+	}
+	defer rows.Close()
+
+	var grants []Permission
+	for rows.Next() {
+		var perm string
+		if err := rows.Scan(&perm); err != nil {
+			return nil, fmt.Errorf("读取权限失败: %w", err)
+		}
+		grants = append(grants, Permission(perm))
+	}
+	return grants, rows.Err()
+}
+
+// ParentDir 返回对象所在的父目录ID，根目录返回false
+func (s *DBStore) ParentDir(ctx context.Context, objectType ObjectType, objectID int64) (int64, bool, error) {
+	var query string
+	switch objectType {
+	case ObjectFile:
+		query = "SELECT parent_dir_id FROM files WHERE file_id = ?"
+	case ObjectDirectory:
+		query = "SELECT parent_id FROM directories WHERE dir_id = ?"
+	default:
+		return 0, false, fmt.Errorf("未知的对象类型: %s", objectType)
+	}
+
+	var parentID sql.NullInt64
+	err := s.db.QueryRowContext(ctx, query, objectID).Scan(&parentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("查询父目录失败: %w", err)
+	}
+	return parentID.Int64, parentID.Valid, nil
+}
+
+// Grant 授予用户对象权限，已存在时不做修改
+func (s *DBStore) Grant(ctx context.Context, userID int, objectType ObjectType, objectID int64, perm Permission) error {
+	return s.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		var exists int
+		err := tx.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM permissions WHERE object_id = ? AND object_type = ? AND user_id = ? AND permission_type = ?",
+			objectID, string(objectType), userID, string(perm)).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("查询权限失败: %w", err)
+		}
+		if exists > 0 {
+			return nil
+		}
+
+		// permission_id不是自增列，在同一事务内分配
+		var permissionID int64
+		if err := tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(permission_id), 0) + 1 FROM permissions").Scan(&permissionID); err != nil {
+			return fmt.Errorf("分配权限ID失败: %w", err)
+		}
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO permissions (permission_id, object_id, object_type, user_id, permission_type) VALUES (?, ?, ?, ?, ?)",
+			permissionID, objectID, string(objectType), userID, string(perm))
+		if err != nil {
+			return fmt.Errorf("授予权限失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// Revoke 撤销用户的对象权限
+func (s *DBStore) Revoke(ctx context.Context, userID int, objectType ObjectType, objectID int64, perm Permission) error {
+	_, err := s.db.ExecContext(ctx,
+		"DELETE FROM permissions WHERE object_id = ? AND object_type = ? AND user_id = ? AND permission_type = ?",
+		objectID, string(objectType), userID, string(perm))
+	if err != nil {
+		return fmt.Errorf("撤销权限失败: %w", err)
+	}
+	return nil
+}
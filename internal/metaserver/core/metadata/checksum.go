@@ -0,0 +1,68 @@
+package metadata
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/22827099/DFS_v1/common/utils"
+)
+
+// ComputeFileChecksum 根据块校验和计算文件校验和
+// 按块索引排序后拼接各块校验和再做SHA256，没有块时返回空字符串
+func ComputeFileChecksum(chunks []ChunkInfo) string {
+	if len(chunks) == 0 {
+		return ""
+	}
+
+	sorted := make([]ChunkInfo, len(chunks))
+	copy(sorted, chunks)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Index < sorted[j].Index
+	})
+
+	sums := make([]string, len(sorted))
+	for i, chunk := range sorted {
+		sums[i] = chunk.Checksum
+	}
+
+	return utils.SHA256Hash([]byte(strings.Join(sums, ":")))
+}
+
+// ChunkReader 读取块的实际数据，由数据节点客户端实现，从块的任一可用副本读取
+type ChunkReader interface {
+	ReadChunk(ctx context.Context, filePath string, chunk ChunkInfo) (io.ReadCloser, error)
+}
+
+// VerifyFileData 读取文件每个块的数据计算SHA256，与块记录的校验和比较，
+// 再用读到的数据的校验和重新计算文件校验和并与存储的值比较。
+// 返回文件是否完整和校验失败的块索引，读取块数据失败时返回错误
+func VerifyFileData(ctx context.Context, reader ChunkReader, file *FileInfo) (bool, []int, error) {
+	actual := make([]ChunkInfo, len(file.Chunks))
+	var invalid []int
+	for i, chunk := range file.Chunks {
+		sum, err := hashChunk(ctx, reader, file.Path, chunk)
+		if err != nil {
+			return false, nil, fmt.Errorf("读取块%d失败: %w", chunk.Index, err)
+		}
+		if sum != chunk.Checksum {
+			invalid = append(invalid, chunk.Index)
+		}
+		actual[i] = chunk
+		actual[i].Checksum = sum
+	}
+	sort.Ints(invalid)
+	return len(invalid) == 0 && ComputeFileChecksum(actual) == file.Checksum, invalid, nil
+}
+
+// hashChunk 计算块数据的SHA256
+func hashChunk(ctx context.Context, reader ChunkReader, filePath string, chunk ChunkInfo) (string, error) {
+	rc, err := reader.ReadChunk(ctx, filePath, chunk)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	return utils.HashReader(rc, utils.SHA256)
+}
@@ -0,0 +1,208 @@
+package events
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Type 元数据变更事件类型
+type Type string
+
+// 元数据变更事件类型
+const (
+	Create Type = "create" // 创建文件或目录
+	Update Type = "update" // 更新文件元数据
+	Delete Type = "delete" // 删除文件或目录，递归删除目录时只产生目录本身的一条事件
+	Move   Type = "move"   // 移动或重命名，OldPath为原路径；移动目录时只产生目录本身的一条事件
+)
+
+// DefaultCapacity 默认保留的最近事件数
+const DefaultCapacity = 10000
+
+// defaultSubscribeBuffer 订阅者通道中为实时事件预留的容量
+const defaultSubscribeBuffer = 256
+
+// ErrCursorExpired 游标之后的事件已超出保留范围被丢弃，或日志已重置，
+// 消费者需要全量重建索引后从LastSeq继续
+var ErrCursorExpired = errors.New("游标已过期，之后的事件已被丢弃")
+
+// Event 元数据变更事件
+type Event struct {
+	Seq       uint64    `json:"seq"` // 单调递增的序号，作为消费游标
+	Type      Type      `json:"type"`
+	Path      string    `json:"path"`
+	OldPath   string    `json:"old_path,omitempty"`
+	IsDir     bool      `json:"is_dir"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Log 只追加的元数据变更事件日志，保留最近capacity条事件。
+// 消费者以最后处理的事件序号作为游标轮询Since，或通过Subscribe持续接收；
+// 游标为0表示从保留的最早事件开始
+type Log struct {
+	mu          sync.Mutex
+	events      []Event // 环形缓冲区，容量为capacity
+	start       int     // 最早事件在events中的位置
+	capacity    int
+	lastSeq     uint64
+	subscribers map[uint64]chan Event
+	nextID      uint64
+	closed      bool
+}
+
+// NewLog 创建事件日志，capacity不大于0时使用DefaultCapacity
+func NewLog(capacity int) *Log {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	return &Log{
+		events:      make([]Event, 0, capacity),
+		capacity:    capacity,
+		subscribers: make(map[uint64]chan Event),
+	}
+}
+
+// Append 为事件分配序号并追加到日志，分发给所有订阅者，返回追加后的事件。
+// 调用方应在修改已生效后调用；日志关闭后事件被忽略，返回的事件序号为0
+func (l *Log) Append(event Event) Event {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return Event{}
+	}
+	l.lastSeq++
+	event.Seq = l.lastSeq
+	l.record(event)
+
+	// 订阅者通道已满时关闭其通道而不是丢弃事件，消费者从最后收到的序号重新订阅即可补齐
+	for id, ch := range l.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(l.subscribers, id)
+			close(ch)
+		}
+	}
+	return event
+}
+
+// record 将事件写入环形缓冲区，调用方需持有锁
+func (l *Log) record(event Event) {
+	if len(l.events) < l.capacity {
+		l.events = append(l.events, event)
+		return
+	}
+	l.events[l.start] = event
+	l.start = (l.start + 1) % l.capacity
+}
+
+// LastSeq 返回最新事件的序号，没有事件时为0
+func (l *Log) LastSeq() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lastSeq
+}
+
+// Since 按序号顺序返回cursor之后的最多limit条事件（limit不大于0时不限制）以及下一次轮询使用的游标。
+// 没有新事件时返回空列表和原游标；cursor之后的事件已被丢弃或cursor超过最新序号时返回ErrCursorExpired
+func (l *Log) Since(cursor uint64, limit int) ([]Event, uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	events, err := l.sinceLocked(cursor, limit)
+	if err != nil {
+		return nil, cursor, err
+	}
+	if len(events) == 0 {
+		return events, cursor, nil
+	}
+	return events, events[len(events)-1].Seq, nil
+}
+
+// sinceLocked 返回cursor之后的事件，调用方需持有锁
+func (l *Log) sinceLocked(cursor uint64, limit int) ([]Event, error) {
+	// 游标超过最新序号说明日志已随进程重启重置
+	if cursor > l.lastSeq {
+		return nil, ErrCursorExpired
+	}
+	// 保留的事件序号连续，最早一条为oldest
+	oldest := l.lastSeq - uint64(len(l.events)) + 1
+	if cursor+1 < oldest {
+		return nil, ErrCursorExpired
+	}
+
+	n := int(l.lastSeq - cursor)
+	if limit > 0 && n > limit {
+		n = limit
+	}
+	events := make([]Event, n)
+	offset := int(cursor + 1 - oldest)
+	for i := range events {
+		events[i] = l.events[(l.start+offset+i)%len(l.events)]
+	}
+	return events, nil
+}
+
+// Subscribe 订阅cursor之后的事件，返回的通道先包含已保留的事件，随后是实时事件。
+// buffer为实时事件预留的通道容量；订阅者处理过慢导致通道已满、取消订阅或日志关闭后通道被关闭，
+// 消费者可从最后收到的序号重新订阅。使用完毕后必须调用返回的取消函数
+func (l *Log) Subscribe(cursor uint64, buffer int) (<-chan Event, func(), error) {
+	if buffer <= 0 {
+		buffer = defaultSubscribeBuffer
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// 在同一临界区内回放并注册，保证回放事件与实时事件之间不重复、不遗漏
+	backlog, err := l.sinceLocked(cursor, 0)
+	if err != nil {
+		return nil, func() {}, err
+	}
+	ch := make(chan Event, len(backlog)+buffer)
+	for _, event := range backlog {
+		ch <- event
+	}
+	if l.closed {
+		close(ch)
+		return ch, func() {}, nil
+	}
+
+	id := l.nextID
+	l.nextID++
+	l.subscribers[id] = ch
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if sub, ok := l.subscribers[id]; ok {
+				delete(l.subscribers, id)
+				close(sub)
+			}
+		})
+	}
+	return ch, cancel, nil
+}
+
+// Close 关闭所有订阅者通道，之后追加的事件被忽略，已保留的事件仍可通过Since读取
+func (l *Log) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return
+	}
+	l.closed = true
+	for id, ch := range l.subscribers {
+		delete(l.subscribers, id)
+		close(ch)
+	}
+}
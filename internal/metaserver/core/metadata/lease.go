@@ -0,0 +1,43 @@
+package metadata
+
+import (
+	"context"
+	"time"
+)
+
+// leaseHolderKey 写入者租约持有者标识在context中的键
+type leaseHolderKey struct{}
+
+// commandTimeKey 写操作命令时间在context中的键
+type commandTimeKey struct{}
+
+// WithLeaseHolder 返回携带写入者租约持有者标识的context，支持租约的存储在移动和删除目录时据此检查租约
+func WithLeaseHolder(ctx context.Context, holder string) context.Context {
+	if holder == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, leaseHolderKey{}, holder)
+}
+
+// LeaseHolder 返回context携带的租约持有者标识，未携带时返回空字符串
+func LeaseHolder(ctx context.Context) string {
+	holder, _ := ctx.Value(leaseHolderKey{}).(string)
+	return holder
+}
+
+// WithCommandTime 返回携带写操作命令时间的context。集群各节点应用同一条命令时使用提交者记录的时间
+// 判断租约是否过期，而不是各自的本地时钟，因此得出同样的结果
+func WithCommandTime(ctx context.Context, t time.Time) context.Context {
+	if t.IsZero() {
+		return ctx
+	}
+	return context.WithValue(ctx, commandTimeKey{}, t)
+}
+
+// CommandTime 返回context携带的命令时间，未携带时返回当前时间
+func CommandTime(ctx context.Context) time.Time {
+	if t, ok := ctx.Value(commandTimeKey{}).(time.Time); ok {
+		return t
+	}
+	return time.Now()
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/22827099/DFS_v1/common/logging"
 	"github.com/22827099/DFS_v1/internal/metaserver/core/database"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/acl"
 	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/lock"
 	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/namespace"
 	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/transaction"
@@ -22,10 +23,28 @@ type Manager struct {
 	dirRepo     *database.Repository
 	chunkRepo   *database.Repository
 	replicaRepo *database.Repository
+	enableACL   bool
+}
+
+// ManagerOption 元数据管理器配置选项
+type ManagerOption func(*Manager)
+
+// WithACL 启用基于permissions表的目录访问控制（对应security.enable_acl）
+func WithACL(enabled bool) ManagerOption {
+	return func(m *Manager) {
+		m.enableACL = enabled
+	}
+}
+
+// WithListLimits 设置目录列表的条目数上限（0表示不限制）和流式列表的每页条目数
+func WithListLimits(maxEntries, pageSize int) ManagerOption {
+	return func(m *Manager) {
+		m.nsMgr.SetListLimits(maxEntries, pageSize)
+	}
 }
 
 // NewManager 创建新的元数据管理器
-func NewManager(db *database.Manager, logger logging.Logger) (*Manager, error) {
+func NewManager(db *database.Manager, logger logging.Logger, opts ...ManagerOption) (*Manager, error) {
 	if db == nil {
 		return nil, fmt.Errorf("数据库管理器不能为空")
 	}
@@ -51,7 +70,7 @@ func NewManager(db *database.Manager, logger logging.Logger) (*Manager, error) {
 	chunkRepo := database.NewRepository(db, "chunks")
 	replicaRepo := database.NewRepository(db, "replicas")
 
-	return &Manager{
+	m := &Manager{
 		db:          db,
 		logger:      logger,
 		lockMgr:     lockMgr,
@@ -61,7 +80,16 @@ func NewManager(db *database.Manager, logger logging.Logger) (*Manager, error) {
 		dirRepo:     dirRepo,
 		chunkRepo:   chunkRepo,
 		replicaRepo: replicaRepo,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	if m.enableACL {
+		nsMgr.SetACL(acl.NewChecker(acl.NewDBStore(db)))
+	}
+
+	return m, nil
 }
 
 // Start 启动元数据管理器
@@ -103,3 +131,13 @@ func (m *Manager) Stop(ctx context.Context) error {
 
 	return nil
 }
+
+// Restore 恢复已软删除的文件或目录，实现Restorer接口
+func (m *Manager) Restore(ctx context.Context, path string, recursive bool) (int, error) {
+	return m.nsMgr.Restore(ctx, path, recursive)
+}
+
+// CheckAccess 检查上下文中的用户能否对路径执行操作，未启用访问控制时总是允许
+func (m *Manager) CheckAccess(ctx context.Context, path string, op acl.Operation) error {
+	return m.nsMgr.CheckAccess(ctx, path, op)
+}
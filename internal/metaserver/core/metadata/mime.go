@@ -0,0 +1,17 @@
+package metadata
+
+import (
+	"mime"
+	"path"
+)
+
+// DefaultMimeType 无法根据扩展名推断类型时使用的MIME类型
+const DefaultMimeType = "application/octet-stream"
+
+// InferMimeType 根据文件名的扩展名推断MIME类型，没有扩展名或扩展名未知时返回DefaultMimeType
+func InferMimeType(name string) string {
+	if mimeType := mime.TypeByExtension(path.Ext(name)); mimeType != "" {
+		return mimeType
+	}
+	return DefaultMimeType
+}
@@ -0,0 +1,289 @@
+package namespace
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/22827099/DFS_v1/internal/metaserver/core/database"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/models"
+)
+
+// ErrInvalidCursor 游标无法解析，或与本次的排序选项不一致
+var ErrInvalidCursor = errors.New("无效的目录列表游标")
+
+// DirectoryPage 分页列出目录时的一页条目
+type DirectoryPage struct {
+	Entries    []models.PathInfo
+	NextCursor string // 下一页的游标，没有更多条目时为空
+}
+
+// ListDirectoryPage 分页列出目录内容，返回一页条目和下一页的游标。
+// 条目顺序与ListDirectory相同；排序键相同的条目按ID排序，因此游标记录的(排序键, ID)在条目增删后仍能定位到同一位置。
+// 子目录和子文件在一条UNION ALL查询中排序，偏移量和条数都下推到数据库；
+// 游标翻页按(排序键, ID)定位，不需要跳过前面的条目，深翻页应使用游标。未指定分页选项时返回全部条目
+func (m *Manager) ListDirectoryPage(ctx context.Context, path string, options ...ListOption) (*DirectoryPage, error) {
+	opts := defaultListOptions()
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if !opts.paged() {
+		entries, err := m.listAll(ctx, path, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &DirectoryPage{Entries: entries}, nil
+	}
+
+	field, desc := opts.normalizedSort()
+	var cursor *listCursor
+	if opts.Cursor != "" {
+		var err error
+		if cursor, err = decodeListCursor(opts.Cursor, field, desc, opts.DirsFirst); err != nil {
+			return nil, err
+		}
+	}
+
+	dirMeta, err := m.listableDir(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = m.listPageSize
+	}
+	offset := opts.Offset
+	if cursor != nil {
+		offset = 0
+	}
+
+	// 多读一条用于判断是否还有下一页
+	query, args := pageQuery(dirMeta.DirID, field, desc, opts.DirsFirst, cursor, limit+1, offset)
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("获取目录条目失败: %w", err)
+	}
+	defer rows.Close()
+
+	var children []listRow
+	if err := database.ScanRows(rows, &children); err != nil {
+		return nil, fmt.Errorf("读取目录条目失败: %w", err)
+	}
+
+	more := len(children) > limit
+	if more {
+		children = children[:limit]
+	}
+
+	page := &DirectoryPage{}
+	for _, child := range children {
+		page.Entries = append(page.Entries, child.entry(path, dirMeta.DirID))
+	}
+	if more {
+		page.NextCursor = encodeListCursor(&page.Entries[limit-1], field, desc, opts.DirsFirst)
+	}
+	return page, nil
+}
+
+// childrenQuery 目录下未删除的子文件和子目录，两张表的列对齐后合并，kind为0表示目录、1表示文件；
+// 目录没有大小列，大小视为0。文件表在前，合并后的列沿用文件表的列类型
+const childrenQuery = `SELECT * FROM (
+		SELECT 1 AS kind, file_id AS id, name, size, owner_id, mode, checksum, version, 0 AS child_count, created_at, modified_at
+			FROM files WHERE parent_dir_id = ? AND is_deleted = false
+		UNION ALL
+		SELECT 0 AS kind, dir_id AS id, name, 0 AS size, owner_id, mode, NULL AS checksum, 0 AS version, child_count, created_at, modified_at
+			FROM directories WHERE parent_id = ? AND is_deleted = false
+	) AS children`
+
+// listRow 子项联合查询的一行
+type listRow struct {
+	Kind       int64     `db:"kind"`
+	ID         int64     `db:"id"`
+	Name       string    `db:"name"`
+	Size       int64     `db:"size"`
+	OwnerID    int64     `db:"owner_id"`
+	Mode       int32     `db:"mode"`
+	Checksum   string    `db:"checksum"`
+	Version    int32     `db:"version"`
+	ChildCount int64     `db:"child_count"`
+	CreateTime time.Time `db:"created_at"`
+	ModifyTime time.Time `db:"modified_at"`
+}
+
+// entry 构建目录列表中的条目
+func (r *listRow) entry(parentPath string, parentID int64) models.PathInfo {
+	if r.Kind == 0 {
+		return dirEntry(parentPath, models.DirectoryMetadata{
+			DirID:      r.ID,
+			ParentID:   parentID,
+			Name:       r.Name,
+			OwnerID:    r.OwnerID,
+			Mode:       r.Mode,
+			ChildCount: r.ChildCount,
+			CreateTime: r.CreateTime,
+			ModifyTime: r.ModifyTime,
+		})
+	}
+	return fileEntry(parentPath, models.FileMetadata{
+		FileID:     r.ID,
+		DirID:      parentID,
+		Name:       r.Name,
+		Size:       r.Size,
+		Checksum:   r.Checksum,
+		OwnerID:    r.OwnerID,
+		Mode:       r.Mode,
+		Version:    r.Version,
+		CreateTime: r.CreateTime,
+		ModifyTime: r.ModifyTime,
+	})
+}
+
+// sortKey 排序中的一列及其方向
+type sortKey struct {
+	column string
+	desc   bool
+	value  interface{} // 游标条目在该列上的值
+}
+
+// pageQuery 构建读取一页子项的查询，排序与compareEntries一致：
+// 目录优先时先按类型排序，再按排序字段排序，排序字段相同时目录在前，最后按ID升序。
+// 指定游标时只选取排在游标条目之后的条目
+func pageQuery(dirID int64, field string, desc, dirsFirst bool, cursor *listCursor, limit, offset int) (string, []interface{}) {
+	var cursorKind, cursorID int64
+	var cursorKey interface{}
+	if cursor != nil {
+		cursorKind, cursorKey, cursorID = kindOf(cursor.IsDir), cursor.key, cursor.ID
+	}
+
+	// 排序字段就是两张表共有的列名
+	keys := []sortKey{{column: field, desc: desc, value: cursorKey}, {column: "kind", value: cursorKind}}
+	if dirsFirst {
+		keys = []sortKey{{column: "kind", value: cursorKind}, {column: field, desc: desc, value: cursorKey}}
+	}
+	keys = append(keys, sortKey{column: "id", value: cursorID})
+
+	query := childrenQuery
+	args := []interface{}{dirID, dirID}
+	if cursor != nil {
+		cond, condArgs := afterKeys(keys)
+		query += " WHERE " + cond
+		args = append(args, condArgs...)
+	}
+
+	order := make([]string, len(keys))
+	for i, key := range keys {
+		order[i] = key.column + " ASC"
+		if key.desc {
+			order[i] = key.column + " DESC"
+		}
+	}
+	query += " ORDER BY " + strings.Join(order, ", ") + " LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+	return query, args
+}
+
+// afterKeys 返回按keys排序时排在游标条目之后的条件，即按顺序比较各列的值
+func afterKeys(keys []sortKey) (string, []interface{}) {
+	key := keys[len(keys)-1]
+	cond, args := key.column+" "+afterOp(key.desc)+" ?", []interface{}{key.value}
+	for i := len(keys) - 2; i >= 0; i-- {
+		key = keys[i]
+		cond = "(" + key.column + " " + afterOp(key.desc) + " ? OR (" + key.column + " = ? AND " + cond + "))"
+		args = append([]interface{}{key.value, key.value}, args...)
+	}
+	return cond, args
+}
+
+// afterOp 返回选取排在游标之后的值的比较运算符
+func afterOp(desc bool) string {
+	if desc {
+		return "<"
+	}
+	return ">"
+}
+
+// kindOf 返回条目类型在联合查询中的值
+func kindOf(isDir bool) int64 {
+	if isDir {
+		return 0
+	}
+	return 1
+}
+
+// listCursor 游标的内容：生成游标时的排序选项和上一页最后一个条目的(排序键, ID)
+type listCursor struct {
+	SortBy    string `json:"s"`
+	Desc      bool   `json:"o"`
+	DirsFirst bool   `json:"f"`
+	IsDir     bool   `json:"d"`
+	Key       string `json:"k"`
+	ID        int64  `json:"i"`
+
+	key interface{} // 按排序字段解析后的Key，作为查询参数
+}
+
+// encodeListCursor 生成指向info之后的游标
+func encodeListCursor(info *models.PathInfo, field string, desc, dirsFirst bool) string {
+	cursor := listCursor{
+		SortBy:    field,
+		Desc:      desc,
+		DirsFirst: dirsFirst,
+		IsDir:     info.IsDir,
+		ID:        entryID(info),
+	}
+	switch field {
+	case SortBySize:
+		cursor.Key = strconv.FormatInt(entrySize(info), 10)
+	case SortByCreatedAt:
+		created, _ := entryTimes(info)
+		cursor.Key = created.UTC().Format(time.RFC3339Nano)
+	case SortByModifiedAt:
+		_, modified := entryTimes(info)
+		cursor.Key = modified.UTC().Format(time.RFC3339Nano)
+	default:
+		cursor.Key = info.Name
+	}
+
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeListCursor 解析游标，并检查其排序选项与本次列出时相同
+func decodeListCursor(token, field string, desc, dirsFirst bool) (*listCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	var cursor listCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if cursor.SortBy != field || cursor.Desc != desc || cursor.DirsFirst != dirsFirst {
+		return nil, fmt.Errorf("%w: 排序选项与生成游标时不同", ErrInvalidCursor)
+	}
+
+	switch field {
+	case SortBySize:
+		size, err := strconv.ParseInt(cursor.Key, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+		}
+		cursor.key = size
+	case SortByCreatedAt, SortByModifiedAt:
+		t, err := time.Parse(time.RFC3339Nano, cursor.Key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+		}
+		cursor.key = t
+	default:
+		cursor.key = cursor.Key
+	}
+	return &cursor, nil
+}
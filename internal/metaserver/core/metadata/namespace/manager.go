@@ -2,6 +2,8 @@ package namespace
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -9,6 +11,7 @@ import (
 
 	"github.com/22827099/DFS_v1/common/logging"
 	"github.com/22827099/DFS_v1/internal/metaserver/core/database"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/acl"
 	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/lock"
 	"github.com/22827099/DFS_v1/internal/metaserver/core/models"
 )
@@ -20,9 +23,22 @@ type Manager struct {
 	logger    logging.Logger
 	dirRepo   DirectoryRepository
 	fileRepo  FileRepository
-	rootCache sync.Map // 缓存根目录ID
+	rootCache sync.Map     // 缓存根目录ID
+	acl       *acl.Checker // 目录访问控制，为nil时不检查
+
+	maxListEntries int // ListDirectory一次返回的最大条目数，0表示不限制
+	listPageSize   int // ListDirectoryStream每页读取的条目数，也是分页列出时未指定条数的每页条目数
 }
 
+// DefaultMaxListEntries ListDirectory默认的条目数上限，更大的目录需要使用ListDirectoryStream
+const DefaultMaxListEntries = 10000
+
+// ErrTooManyEntries 目录条目数超过ListDirectory的上限
+var ErrTooManyEntries = errors.New("目录条目过多")
+
+// ErrNoDeletedEntry 要恢复的路径既不存在也没有删除记录
+var ErrNoDeletedEntry = errors.New("路径不存在且没有删除记录")
+
 // NewManager 创建新的命名空间管理器
 func NewManager(db *database.Manager, lockMgr *lock.Manager, logger logging.Logger) (*Manager, error) {
 	if db == nil {
@@ -39,6 +55,9 @@ func NewManager(db *database.Manager, lockMgr *lock.Manager, logger logging.Logg
 		lockMgr:   lockMgr,
 		logger:    logger,
 		rootCache: sync.Map{},
+
+		maxListEntries: DefaultMaxListEntries,
+		listPageSize:   database.DefaultStreamPageSize,
 	}, nil
 }
 
@@ -48,6 +67,19 @@ func (m *Manager) SetRepositories(dirRepo DirectoryRepository, fileRepo FileRepo
 	m.fileRepo = fileRepo
 }
 
+// SetACL 设置访问控制检查器，为nil时关闭访问控制
+func (m *Manager) SetACL(checker *acl.Checker) {
+	m.acl = checker
+}
+
+// SetListLimits 设置ListDirectory的条目数上限（0表示不限制）和ListDirectoryStream的每页条目数
+func (m *Manager) SetListLimits(maxEntries, pageSize int) {
+	m.maxListEntries = maxEntries
+	if pageSize > 0 {
+		m.listPageSize = pageSize
+	}
+}
+
 // SetRootDirID 设置根目录ID，用于测试
 func (m *Manager) SetRootDirID(rootID int64) {
 	m.rootCache.Store("/", rootID)
@@ -59,10 +91,10 @@ func (m *Manager) Start() error {
 
 	// 如果还没有设置仓库，则使用默认数据库仓库
 	if m.dirRepo == nil {
-		m.dirRepo = database.NewRepository(m.db, "directories")
+		m.dirRepo = NewDirectoryRepository(m.db)
 	}
 	if m.fileRepo == nil {
-		m.fileRepo = database.NewRepository(m.db, "files")
+		m.fileRepo = NewFileRepository(m.db)
 	}
 
 	// 预加载根目录ID
@@ -71,7 +103,8 @@ func (m *Manager) Start() error {
 		DirID int64 `db:"dir_id"`
 	}{}
 
-	err := m.dirRepo.FindOne(ctx, &rootDir, "parent_id IS NULL AND name='/'")
+	// 条件中不能包含字面量，根目录名称作为参数传入
+	err := m.dirRepo.FindOne(ctx, &rootDir, "parent_id IS NULL AND name = ?", "/")
 	if err != nil {
 		return fmt.Errorf("查找根目录失败: %w", err)
 	}
@@ -88,8 +121,28 @@ func (m *Manager) Stop(ctx context.Context) error {
 	return nil
 }
 
+// ResolveOption 路径解析选项
+type ResolveOption func(*resolveOptions)
+
+type resolveOptions struct {
+	includeDeleted bool
+}
+
+// IncludeDeleted 路径不存在时继续查找已软删除的条目，找到时返回Exists=false、Deleted=true及其元数据，
+// 用于区分已删除和从未存在的路径；祖先目录已删除时，其下的条目同样视为已删除
+func IncludeDeleted() ResolveOption {
+	return func(o *resolveOptions) {
+		o.includeDeleted = true
+	}
+}
+
 // ResolvePath 将路径解析为目录或文件ID
-func (m *Manager) ResolvePath(ctx context.Context, path string) (*models.PathInfo, error) {
+func (m *Manager) ResolvePath(ctx context.Context, path string, opts ...ResolveOption) (*models.PathInfo, error) {
+	var options resolveOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	// 标准化路径
 	path = filepath.Clean("/" + strings.TrimPrefix(path, "/"))
 
@@ -111,7 +164,7 @@ func (m *Manager) ResolvePath(ctx context.Context, path string) (*models.PathInf
 			Path:       "/",
 			Exists:     true,
 			IsDir:      true,
-			Metadata:   rootDir,
+			Metadata:   &rootDir,
 			ParentPath: "/",
 			Name:       "/",
 		}, nil
@@ -121,58 +174,71 @@ func (m *Manager) ResolvePath(ctx context.Context, path string) (*models.PathInf
 	parentPath := filepath.Dir(path)
 	name := filepath.Base(path)
 
-	// 首先解析父目录
-	parentInfo, err := m.ResolvePath(ctx, parentPath)
+	// 首先解析父目录，已删除的父目录也需要继续向下解析
+	parentInfo, err := m.ResolvePath(ctx, parentPath, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	if !parentInfo.Exists || !parentInfo.IsDir {
+	// 父目录不是目录时不返回其元数据
+	parentDir, _ := parentInfo.Metadata.(*models.DirectoryMetadata)
+	if !parentInfo.IsDir || parentDir == nil || (!parentInfo.Exists && !parentInfo.Deleted) {
 		return &models.PathInfo{
 			Path:       path,
 			Exists:     false,
 			ParentPath: parentPath,
 			Name:       name,
-			// 如果父目录存在但不是目录，保留其元数据
-			ParentDir: parentInfo.Metadata.(*models.DirectoryMetadata),
+			ParentDir:  parentDir,
 		}, nil
 	}
 
-	// 获取父目录的目录元数据
-	parentDir := parentInfo.Metadata.(*models.DirectoryMetadata)
+	if parentInfo.Exists {
+		// 尝试查找文件
+		var file models.FileMetadata
+		err = m.fileRepo.FindOne(ctx, &file, "parent_dir_id = ? AND name = ? AND is_deleted = false",
+			parentDir.DirID, name)
+		if err == nil {
+			return &models.PathInfo{
+				Path:       path,
+				Exists:     true,
+				IsFile:     true,
+				IsDir:      false,
+				Metadata:   file,
+				ParentPath: parentPath,
+				ParentDir:  parentDir,
+				Name:       name,
+			}, nil
+		}
 
-	// 尝试查找文件
-	var file models.FileMetadata
-	err = m.fileRepo.FindOne(ctx, &file, "parent_dir_id = ? AND name = ? AND is_deleted = false",
-		parentDir.DirID, name)
-	if err == nil {
-		return &models.PathInfo{
-			Path:       path,
-			Exists:     true,
-			IsFile:     true,
-			IsDir:      false,
-			Metadata:   file,
-			ParentPath: parentPath,
-			ParentDir:  parentDir,
-			Name:       name,
-		}, nil
+		// 尝试查找目录
+		var dir models.DirectoryMetadata
+		err = m.dirRepo.FindOne(ctx, &dir, "parent_id = ? AND name = ? AND is_deleted = false",
+			parentDir.DirID, name)
+		if err == nil {
+			return &models.PathInfo{
+				Path:       path,
+				Exists:     true,
+				IsFile:     false,
+				IsDir:      true,
+				Metadata:   &dir,
+				ParentPath: parentPath,
+				ParentDir:  parentDir,
+				Name:       name,
+			}, nil
+		}
 	}
 
-	// 尝试查找目录
-	var dir models.DirectoryMetadata
-	err = m.dirRepo.FindOne(ctx, &dir, "parent_id = ? AND name = ? AND is_deleted = false",
-		parentDir.DirID, name)
-	if err == nil {
-		return &models.PathInfo{
-			Path:       path,
-			Exists:     true,
-			IsFile:     false,
-			IsDir:      true,
-			Metadata:   dir,
-			ParentPath: parentPath,
-			ParentDir:  parentDir,
-			Name:       name,
-		}, nil
+	if options.includeDeleted {
+		info, err := m.resolveDeleted(ctx, parentDir, parentInfo.Deleted, name)
+		if err != nil {
+			return nil, err
+		}
+		if info != nil {
+			info.Path = path
+			info.ParentPath = parentPath
+			info.Name = name
+			return info, nil
+		}
 	}
 
 	// 路径不存在
@@ -185,10 +251,197 @@ func (m *Manager) ResolvePath(ctx context.Context, path string) (*models.PathInf
 	}, nil
 }
 
+// resolveDeleted 在父目录下查找已软删除的同名条目，同名的删除记录有多条时取最近创建的一条。
+// parentDeleted为true时父目录本身已删除，其下的所有条目都视为已删除；未找到时返回nil
+func (m *Manager) resolveDeleted(ctx context.Context, parentDir *models.DirectoryMetadata, parentDeleted bool, name string) (*models.PathInfo, error) {
+	fileQuery := "parent_dir_id = ? AND name = ? AND is_deleted = true"
+	dirQuery := "parent_id = ? AND name = ? AND is_deleted = true"
+	if parentDeleted {
+		fileQuery = "parent_dir_id = ? AND name = ?"
+		dirQuery = "parent_id = ? AND name = ?"
+	}
+
+	var files []models.FileMetadata
+	if err := m.fileRepo.Find(ctx, &files, fileQuery, parentDir.DirID, name); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("查找已删除文件失败: %w", err)
+	}
+	if len(files) > 0 {
+		latest := files[0]
+		for _, file := range files[1:] {
+			if file.FileID > latest.FileID {
+				latest = file
+			}
+		}
+		return &models.PathInfo{
+			Deleted:   true,
+			IsFile:    true,
+			Metadata:  latest,
+			ParentDir: parentDir,
+		}, nil
+	}
+
+	var dirs []models.DirectoryMetadata
+	if err := m.dirRepo.Find(ctx, &dirs, dirQuery, parentDir.DirID, name); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("查找已删除目录失败: %w", err)
+	}
+	if len(dirs) > 0 {
+		latest := dirs[0]
+		for _, dir := range dirs[1:] {
+			if dir.DirID > latest.DirID {
+				latest = dir
+			}
+		}
+		return &models.PathInfo{
+			Deleted:   true,
+			IsDir:     true,
+			Metadata:  &latest,
+			ParentDir: parentDir,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// Restore 恢复已软删除的文件或目录，返回恢复的条目数。
+// recursive为true时同时恢复目录子树中所有已删除的条目；父目录已删除或同名的未删除条目已存在时失败，不做任何修改
+func (m *Manager) Restore(ctx context.Context, path string, recursive bool) (int, error) {
+	pathInfo, err := m.ResolvePath(ctx, path, IncludeDeleted())
+	if err != nil {
+		return 0, err
+	}
+	if pathInfo.Exists {
+		return 0, fmt.Errorf("%s: %w", path, database.ErrNotDeleted)
+	}
+	if !pathInfo.Deleted {
+		return 0, fmt.Errorf("%s: %w", path, ErrNoDeletedEntry)
+	}
+
+	// 恢复相当于在父目录下重新创建，需要父目录的写权限
+	if m.acl != nil {
+		if err := m.acl.Check(ctx, acl.ObjectDirectory, pathInfo.ParentDir.DirID, acl.OpCreate.RequiredPermission()); err != nil {
+			return 0, err
+		}
+	}
+
+	var restored int
+	err = m.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		switch meta := pathInfo.Metadata.(type) {
+		case models.FileMetadata:
+			restored = 1
+			return database.RestoreFile(ctx, tx, meta.FileID)
+		case *models.DirectoryMetadata:
+			var err error
+			restored, err = database.RestoreDirectory(ctx, tx, meta.DirID, recursive)
+			return err
+		default:
+			return fmt.Errorf("无效的元数据: %s", path)
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	m.logger.Info("恢复已删除的路径 %s，共%d个条目", path, restored)
+	return restored, nil
+}
+
+// Delete 软删除文件或目录，返回删除的条目数。
+// 目录不为空时recursive必须为true，子树中未删除的条目与目录在同一批次删除，可以通过Restore一起恢复
+func (m *Manager) Delete(ctx context.Context, path string, recursive bool) (int, error) {
+	pathInfo, err := m.ResolvePath(ctx, path)
+	if err != nil {
+		return 0, err
+	}
+	if !pathInfo.Exists {
+		return 0, fmt.Errorf("路径不存在: %s", path)
+	}
+	if pathInfo.ParentDir == nil {
+		return 0, fmt.Errorf("不能删除根目录")
+	}
+
+	if m.acl != nil {
+		if err := m.acl.Check(ctx, acl.ObjectDirectory, pathInfo.ParentDir.DirID, acl.OpDelete.RequiredPermission()); err != nil {
+			return 0, err
+		}
+	}
+
+	var deleted int
+	err = m.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		switch meta := pathInfo.Metadata.(type) {
+		case models.FileMetadata:
+			deleted = 1
+			return database.DeleteFile(ctx, tx, meta.FileID)
+		case *models.DirectoryMetadata:
+			var err error
+			deleted, err = database.DeleteDirectory(ctx, tx, meta.DirID, recursive)
+			return err
+		default:
+			return fmt.Errorf("无效的元数据: %s", path)
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	m.logger.Info("删除路径 %s，共%d个条目", path, deleted)
+	return deleted, nil
+}
+
+// CheckAccess 检查上下文中的用户能否对路径执行操作
+// 创建和删除检查父目录的写权限，读取和修改检查对象本身的读/写权限；未启用访问控制时总是允许
+func (m *Manager) CheckAccess(ctx context.Context, path string, op acl.Operation) error {
+	if m.acl == nil {
+		return nil
+	}
+
+	pathInfo, err := m.ResolvePath(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	if op.OnParent() || !pathInfo.Exists {
+		if pathInfo.ParentDir == nil {
+			return fmt.Errorf("父目录不存在: %s", pathInfo.ParentPath)
+		}
+		return m.acl.Check(ctx, acl.ObjectDirectory, pathInfo.ParentDir.DirID, op.RequiredPermission())
+	}
+
+	objectType, objectID, ok := aclObject(pathInfo)
+	if !ok {
+		return fmt.Errorf("无效的元数据: %s", path)
+	}
+	return m.acl.Check(ctx, objectType, objectID, op.RequiredPermission())
+}
+
+// aclObject 返回路径对应的授权对象
+func aclObject(pathInfo *models.PathInfo) (acl.ObjectType, int64, bool) {
+	switch meta := pathInfo.Metadata.(type) {
+	case models.FileMetadata:
+		return acl.ObjectFile, meta.FileID, true
+	case *models.FileMetadata:
+		return acl.ObjectFile, meta.FileID, true
+	case models.DirectoryMetadata:
+		return acl.ObjectDirectory, meta.DirID, true
+	case *models.DirectoryMetadata:
+		return acl.ObjectDirectory, meta.DirID, true
+	}
+	return "", 0, false
+}
+
 // listOptions 定义目录列表选项
 type listOptions struct {
 	SortBy    string // 排序字段
 	SortOrder string // 排序顺序 (asc/desc)
+	DirsFirst bool   // 目录排在文件之前，目录和文件各自按排序字段排序
+	Paged     bool   // 是否分页列出，指定分页或游标选项时为true
+	Offset    int    // 跳过的条目数，指定游标时忽略
+	Limit     int    // 每页条目数，不大于0时使用默认每页条目数
+	Cursor    string // 上一页返回的游标，从该游标之后继续列出
+}
+
+// paged 返回是否分页列出
+func (opts *listOptions) paged() bool {
+	return opts.Paged || opts.Cursor != ""
 }
 
 // defaultListOptions 返回默认列表选项
@@ -202,7 +455,7 @@ func defaultListOptions() *listOptions {
 // ListOption 选项函数类型
 type ListOption func(*listOptions)
 
-// WithSort 设置排序选项
+// WithSort 设置排序字段（name、size、created_at、modified_at）和排序顺序（asc、desc）
 func WithSort(field string, order string) ListOption {
 	return func(opts *listOptions) {
 		opts.SortBy = field
@@ -214,15 +467,118 @@ func WithSort(field string, order string) ListOption {
 	}
 }
 
-// ListDirectory 列出目录内容
+// WithDirectoriesFirst 设置是否将目录排在文件之前
+func WithDirectoriesFirst(enabled bool) ListOption {
+	return func(opts *listOptions) {
+		opts.DirsFirst = enabled
+	}
+}
+
+// WithPagination 跳过前offset个条目，最多返回limit个条目；limit不大于0时使用默认每页条目数
+func WithPagination(offset, limit int) ListOption {
+	return func(opts *listOptions) {
+		opts.Paged = true
+		if offset > 0 {
+			opts.Offset = offset
+		}
+		opts.Limit = limit
+	}
+}
+
+// WithCursor 从上一页返回的游标之后继续列出，排序选项必须与生成游标时相同
+func WithCursor(token string) ListOption {
+	return func(opts *listOptions) {
+		opts.Cursor = token
+	}
+}
+
+// ListDirectory 列出目录内容，按排序选项对目录和文件统一排序，排序字段无效时按名称升序。
+// 指定分页选项时只返回一页条目，需要下一页的游标时使用ListDirectoryPage
 func (m *Manager) ListDirectory(ctx context.Context, path string, options ...ListOption) ([]models.PathInfo, error) {
-	// 应用选项
-	opts := defaultListOptions()
-	for _, opt := range options {
-		opt(opts)
+	page, err := m.ListDirectoryPage(ctx, path, options...)
+	if err != nil {
+		return nil, err
+	}
+	return page.Entries, nil
+}
+
+// listAll 一次性列出目录的全部条目，条目数超过上限时返回ErrTooManyEntries
+func (m *Manager) listAll(ctx context.Context, path string, opts *listOptions) ([]models.PathInfo, error) {
+	dirMeta, err := m.listableDir(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	// 一次性加载前先检查条目数，超大目录应使用ListDirectoryStream
+	if m.maxListEntries > 0 {
+		count, err := m.countChildren(ctx, dirMeta.DirID)
+		if err != nil {
+			return nil, err
+		}
+		if count > m.maxListEntries {
+			return nil, fmt.Errorf("%w: %s 包含%d个条目，上限为%d", ErrTooManyEntries, path, count, m.maxListEntries)
+		}
+	}
+
+	// 获取子文件和子目录
+	var result []models.PathInfo
+
+	// 获取子目录
+	var childDirs []models.DirectoryMetadata
+	err = m.dirRepo.FindAll(ctx, &childDirs, "parent_id = ? AND is_deleted = false", dirMeta.DirID)
+	if err != nil {
+		return nil, fmt.Errorf("获取子目录失败: %w", err)
+	}
+
+	for _, dir := range childDirs {
+		result = append(result, dirEntry(path, dir))
+	}
+
+	// 获取子文件
+	var childFiles []models.FileMetadata
+	err = m.fileRepo.FindAll(ctx, &childFiles, "parent_dir_id = ? AND is_deleted = false", dirMeta.DirID)
+	if err != nil {
+		return nil, fmt.Errorf("获取子文件失败: %w", err)
+	}
+
+	for _, file := range childFiles {
+		result = append(result, fileEntry(path, file))
 	}
 
-	// 原有的目录解析逻辑
+	// 排序
+	sortPathInfos(result, opts)
+
+	return result, nil
+}
+
+// dirEntry 构建目录列表中的子目录条目
+func dirEntry(parentPath string, dir models.DirectoryMetadata) models.PathInfo {
+	return models.PathInfo{
+		Path:       filepath.Join(parentPath, dir.Name),
+		Exists:     true,
+		IsDir:      true,
+		IsFile:     false,
+		Metadata:   dir,
+		ParentPath: parentPath,
+		Name:       dir.Name,
+	}
+}
+
+// fileEntry 构建目录列表中的文件条目
+func fileEntry(parentPath string, file models.FileMetadata) models.PathInfo {
+	return models.PathInfo{
+		Path:       filepath.Join(parentPath, file.Name),
+		Exists:     true,
+		IsDir:      false,
+		IsFile:     true,
+		Metadata:   file,
+		ParentPath: parentPath,
+		Name:       file.Name,
+	}
+}
+
+// listableDir 解析要列出的目录并检查读权限
+func (m *Manager) listableDir(ctx context.Context, path string) (*models.DirectoryMetadata, error) {
 	pathInfo, err := m.ResolvePath(ctx, path)
 	if err != nil {
 		return nil, err
@@ -243,65 +599,85 @@ func (m *Manager) ListDirectory(ctx context.Context, path string, options ...Lis
 		return nil, fmt.Errorf("无效的目录元数据")
 	}
 
-	// 构建排序条件
-	orderClause := ""
-	if opts.SortBy != "" {
-		// 合法性检查
-		validSortFields := map[string]bool{"name": true, "size": true, "created_at": true, "modified_at": true}
-		if validSortFields[opts.SortBy] {
-			orderClause = opts.SortBy
-			if opts.SortOrder == "desc" {
-				orderClause += " DESC"
-			} else {
-				orderClause += " ASC"
-			}
+	// 列出目录需要目录的读权限
+	if m.acl != nil {
+		if err := m.acl.Check(ctx, acl.ObjectDirectory, dirMeta.DirID, acl.PermissionRead); err != nil {
+			return nil, err
 		}
 	}
+	return dirMeta, nil
+}
 
-	// 获取子文件和子目录
-	var result []models.PathInfo
+// countChildren 统计目录下未删除的子目录和文件数
+func (m *Manager) countChildren(ctx context.Context, dirID int64) (int, error) {
+	dirs, err := database.NewRepository(m.db, "directories").Count(ctx, "parent_id = ? AND is_deleted = false", dirID)
+	if err != nil {
+		return 0, fmt.Errorf("统计子目录失败: %w", err)
+	}
+	files, err := database.NewRepository(m.db, "files").Count(ctx, "parent_dir_id = ? AND is_deleted = false", dirID)
+	if err != nil {
+		return 0, fmt.Errorf("统计子文件失败: %w", err)
+	}
+	return dirs + files, nil
+}
 
-	// 获取子目录
-	var childDirs []models.DirectoryMetadata
-	err = m.dirRepo.FindAll(ctx, &childDirs, "parent_id = ? AND is_deleted = false", dirMeta.DirID)
+// ListDirectoryStream 分页读取目录内容并逐条调用fn，先返回子目录再返回文件，各自按ID排序
+// 内存占用与目录大小无关；ctx取消或fn返回错误时停止翻页并返回该错误
+// 翻页基于LIMIT/OFFSET，遍历期间目录被并发修改时可能遗漏或重复条目
+func (m *Manager) ListDirectoryStream(ctx context.Context, path string, fn func(models.PathInfo) error) error {
+	dirMeta, err := m.listableDir(ctx, path)
 	if err != nil {
-		return nil, fmt.Errorf("获取子目录失败: %w", err)
+		return err
 	}
 
-	for _, dir := range childDirs {
-		childPath := filepath.Join(path, dir.Name)
-		result = append(result, models.PathInfo{
-			Path:       childPath,
+	err = database.NewRepository(m.db, "directories").Stream(ctx, database.StreamOptions{
+		Columns:  []string{"dir_id", "name"},
+		Where:    "parent_id = ? AND is_deleted = false",
+		Args:     []interface{}{dirMeta.DirID},
+		OrderBy:  "dir_id",
+		PageSize: m.listPageSize,
+	}, func(rows *sql.Rows) error {
+		var dir models.DirectoryMetadata
+		if err := rows.Scan(&dir.DirID, &dir.Name); err != nil {
+			return fmt.Errorf("读取子目录失败: %w", err)
+		}
+		dir.ParentID = dirMeta.DirID
+		return fn(models.PathInfo{
+			Path:       filepath.Join(path, dir.Name),
+			DirID:      dir.DirID,
 			Exists:     true,
 			IsDir:      true,
-			IsFile:     false,
 			Metadata:   dir,
 			ParentPath: path,
 			Name:       dir.Name,
+			ParentDir:  dirMeta,
 		})
-	}
-
-	// 获取子文件
-	var childFiles []models.FileMetadata
-	err = m.fileRepo.FindAll(ctx, &childFiles, "parent_dir_id = ? AND is_deleted = false", dirMeta.DirID)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("获取子文件失败: %w", err)
+		return err
 	}
 
-	for _, file := range childFiles {
-		childPath := filepath.Join(path, file.Name)
-		result = append(result, models.PathInfo{
-			Path:       childPath,
+	return database.NewRepository(m.db, "files").Stream(ctx, database.StreamOptions{
+		Columns:  []string{"file_id", "name", "size"},
+		Where:    "parent_dir_id = ? AND is_deleted = false",
+		Args:     []interface{}{dirMeta.DirID},
+		OrderBy:  "file_id",
+		PageSize: m.listPageSize,
+	}, func(rows *sql.Rows) error {
+		var file models.FileMetadata
+		if err := rows.Scan(&file.FileID, &file.Name, &file.Size); err != nil {
+			return fmt.Errorf("读取子文件失败: %w", err)
+		}
+		file.DirID = dirMeta.DirID
+		return fn(models.PathInfo{
+			Path:       filepath.Join(path, file.Name),
+			FileID:     file.FileID,
 			Exists:     true,
-			IsDir:      false,
 			IsFile:     true,
 			Metadata:   file,
 			ParentPath: path,
 			Name:       file.Name,
+			ParentDir:  dirMeta,
 		})
-	}
-
-	// 排序
-
-	return result, nil
-}
\ No newline at end of file
+	})
+}
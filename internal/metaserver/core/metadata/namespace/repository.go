@@ -17,6 +17,8 @@ type Repository interface {
 
 	// 特定ID查询
 	FindByID(ctx context.Context, id int64, dest interface{}) error
+	// 分页查询，排序和LIMIT在数据库中执行
+	FindPage(ctx context.Context, dest interface{}, page PageQuery) error
 
 	// 创建、更新和删除操作
 	Create(ctx context.Context, tx *sql.Tx, entity interface{}) (sql.Result, error)
@@ -24,6 +26,14 @@ type Repository interface {
 	Delete(ctx context.Context, tx *sql.Tx, id int64) (sql.Result, error)
 }
 
+// PageQuery 分页查询条件
+type PageQuery struct {
+	Where   string        // 查询条件，值必须使用?占位符
+	Args    []interface{} // 条件参数
+	OrderBy string        // 排序，最后一项应为唯一键以保证翻页时结果稳定
+	Limit   int           // 最多返回的记录数，不大于0时不限制
+}
+
 // DirectoryRepository 定义了目录特有的数据访问接口
 type DirectoryRepository interface {
 	Repository
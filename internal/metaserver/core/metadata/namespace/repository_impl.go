@@ -3,8 +3,8 @@ package namespace
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/22827099/DFS_v1/internal/metaserver/core/database"
 	"github.com/22827099/DFS_v1/internal/metaserver/core/models"
@@ -44,6 +44,70 @@ func NewFileRepository(db *database.Manager) FileRepository {
 	}
 }
 
+// ========== 子项计数维护 ==========
+
+// inTx 在tx内执行fn，tx为nil时开启新事务，保证父目录的子项计数与行修改一起提交
+func inTx(ctx context.Context, db *database.Manager, tx *sql.Tx, fn func(tx *sql.Tx) error) error {
+	if tx != nil {
+		return fn(tx)
+	}
+	return db.WithTransaction(ctx, fn)
+}
+
+// 查询子项的父目录和删除标记
+const (
+	dirStateQuery  = "SELECT parent_id, is_deleted FROM directories WHERE dir_id = ?"
+	fileStateQuery = "SELECT parent_dir_id, is_deleted FROM files WHERE file_id = ?"
+)
+
+// childState 子项相对于父目录计数的状态
+type childState struct {
+	parentID int64
+	deleted  bool
+}
+
+// execTrackingParent 执行修改子项的语句，并按修改前后的父目录和删除标记调整子项计数
+func execTrackingParent(ctx context.Context, tx *sql.Tx, stateQuery string, id int64, query string, args ...interface{}) (sql.Result, error) {
+	before, err := loadChildState(ctx, tx, stateQuery, id)
+	if err != nil {
+		return nil, err
+	}
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	after, err := loadChildState(ctx, tx, stateQuery, id)
+	if err != nil {
+		return nil, err
+	}
+	return result, before.transition(ctx, tx, after)
+}
+
+// loadChildState 读取子项的父目录和删除标记，根目录没有父目录时parentID为0
+func loadChildState(ctx context.Context, tx *sql.Tx, stateQuery string, id int64) (childState, error) {
+	var parentID sql.NullInt64
+	var state childState
+	if err := tx.QueryRowContext(ctx, stateQuery, id).Scan(&parentID, &state.deleted); err != nil {
+		return childState{}, err
+	}
+	state.parentID = parentID.Int64
+	return state, nil
+}
+
+// transition 根据子项修改前后的状态调整父目录的子项计数
+func (before childState) transition(ctx context.Context, tx *sql.Tx, after childState) error {
+	switch {
+	case before.deleted && after.deleted:
+		return nil
+	case before.deleted:
+		return database.AdjustChildCount(ctx, tx, after.parentID, 1)
+	case after.deleted:
+		return database.AdjustChildCount(ctx, tx, before.parentID, -1)
+	default:
+		return database.MoveChild(ctx, tx, before.parentID, after.parentID)
+	}
+}
+
 // ========== DirectoryRepositoryImpl 方法实现 ==========
 
 // FindOne 查找单一记录
@@ -54,10 +118,10 @@ func (r *DirectoryRepositoryImpl) FindOne(ctx context.Context, dest interface{},
 // Find 查找多条记录
 // 使用 FindOne 的实现，因为 baseRepo 没有 Find 方法
 func (r *DirectoryRepositoryImpl) Find(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	// 假设我们需要自己实现 Find 逻辑
-	qb := database.NewQueryBuilder(r.table).Where(query)
-	for _, arg := range args {
-		qb.AddArg(arg)
+	// 条件中的值必须通过占位符传递，拒绝拼接了值的条件
+	qb := database.NewQueryBuilder(r.table).Where(query, args...)
+	if err := qb.Err(); err != nil {
+		return err
 	}
 	sql, queryArgs := qb.BuildSelect()
 
@@ -67,29 +131,57 @@ func (r *DirectoryRepositoryImpl) Find(ctx context.Context, dest interface{}, qu
 	}
 	defer rows.Close()
 
-	// 这里需要根据实际情况实现扫描逻辑
-	// 简化示例，实际情况可能需要使用反射处理各种类型
 	return scanRows(rows, dest)
 }
 
-// 辅助函数：将数据库行扫描到结构体切片
+// 辅助函数：按db标签将数据库行扫描到结构体切片，dest为*[]T或*[]*T；
+// 指针字段和sql.NullX字段可以接收NULL，其他字段遇到NULL时保持零值，规则见database.ScanRows
 func scanRows(rows *sql.Rows, dest interface{}) error {
-	// 实际实现中应该使用反射处理不同类型
-	// 此处仅为占位符
+	if err := database.ScanRows(rows, dest); err != nil {
+		return fmt.Errorf("扫描查询结果失败: %w", err)
+	}
 	return nil
 }
 
+// findPage 在数据库中排序并限制条数，只读取一页记录
+func findPage(ctx context.Context, db *database.Manager, table string, dest interface{}, page PageQuery) error {
+	qb := database.NewQueryBuilder(table).Where(page.Where, page.Args...)
+	if page.OrderBy != "" {
+		qb.OrderBy(page.OrderBy)
+	}
+	if page.Limit > 0 {
+		qb.Limit(page.Limit)
+	}
+	if err := qb.Err(); err != nil {
+		return err
+	}
+	query, args := qb.BuildSelect()
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("分页查询失败: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRows(rows, dest)
+}
+
 // FindAll 查找所有记录 (为测试提供)
 func (r *DirectoryRepositoryImpl) FindAll(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
 	return r.Find(ctx, dest, query, args...)
 }
 
+// FindPage 分页查找目录
+func (r *DirectoryRepositoryImpl) FindPage(ctx context.Context, dest interface{}, page PageQuery) error {
+	return findPage(ctx, r.db, r.table, dest, page)
+}
+
 // FindByID 通过ID查找目录
 func (r *DirectoryRepositoryImpl) FindByID(ctx context.Context, id int64, dest interface{}) error {
 	return r.baseRepo.FindOne(ctx, dest, "dir_id = ?", id)
 }
 
-// Create 创建目录
+// Create 创建目录，DirID为0时在事务内分配下一个ID并写回dir
 func (r *DirectoryRepositoryImpl) Create(ctx context.Context, tx *sql.Tx, entity interface{}) (sql.Result, error) {
 	dir, ok := entity.(*models.DirectoryMetadata)
 	if !ok {
@@ -97,31 +189,40 @@ func (r *DirectoryRepositoryImpl) Create(ctx context.Context, tx *sql.Tx, entity
 	}
 
 	query := `INSERT INTO directories 
-              (name, path, parent_id, owner, group_name, 
-               permission_mode, deleted, created_time, modified_time) 
-              VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+              (dir_id, parent_id, name, owner_id, mode, is_deleted, created_at, modified_at) 
+              VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
 
 	var result sql.Result
-	var err error
-
-	args := []interface{}{
-		dir.Name,
-		dir.Path,
-		dir.ParentID,
-		dir.Owner,
-		dir.Group,
-		dir.Mode,         // 修正: 使用正确的权限字段名
-		dir.Deleted,      // 修正: 使用正确的删除标记字段名
-		dir.CreatedTime,  // 修正: 使用正确的创建时间字段名
-		dir.ModifiedTime, // 修正: 使用正确的修改时间字段名
-	}
-
-	if tx != nil {
-		result, err = tx.ExecContext(ctx, query, args...)
-	} else {
-		result, err = r.db.ExecContext(ctx, query, args...)
-	}
 
+	err := inTx(ctx, r.db, tx, func(tx *sql.Tx) error {
+		if dir.DirID == 0 {
+			id, err := nextID(ctx, tx, "SELECT COALESCE(MAX(dir_id), 0) + 1 FROM directories")
+			if err != nil {
+				return err
+			}
+			dir.DirID = id
+		}
+		created, modified := entityTimes(dir.CreateTime, dir.ModifyTime)
+
+		var err error
+		result, err = tx.ExecContext(ctx, query,
+			dir.DirID,
+			nullableID(dir.ParentID),
+			dir.Name,
+			dir.OwnerID,
+			dir.Mode,
+			dir.Deleted,
+			created,
+			modified,
+		)
+		if err != nil {
+			return err
+		}
+		if dir.Deleted || dir.ParentID == 0 {
+			return nil
+		}
+		return database.AdjustChildCount(ctx, tx, dir.ParentID, 1)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("创建目录失败: %w", err)
 	}
@@ -137,31 +238,28 @@ func (r *DirectoryRepositoryImpl) Update(ctx context.Context, tx *sql.Tx, entity
 	}
 
 	query := `UPDATE directories 
-              SET name = ?, path = ?, parent_id = ?, owner = ?, group_name = ?, 
-                  permission_mode = ?, deleted = ?, modified_time = ? 
+              SET name = ?, parent_id = ?, owner_id = ?, mode = ?, is_deleted = ?, modified_at = ? 
               WHERE dir_id = ?`
 
 	var result sql.Result
-	var err error
 
+	_, modified := entityTimes(dir.CreateTime, dir.ModifyTime)
 	args := []interface{}{
 		dir.Name,
-		dir.Path,
-		dir.ParentID,
-		dir.Owner,
-		dir.Group,
-		dir.Mode,         // 修正: 使用正确的权限字段名
-		dir.Deleted,      // 修正: 使用正确的删除标记字段名
-		dir.ModifiedTime, // 修正: 使用正确的修改时间字段名
+		nullableID(dir.ParentID),
+		dir.OwnerID,
+		dir.Mode,
+		dir.Deleted,
+		modified,
 		dir.DirID,
 	}
 
-	if tx != nil {
-		result, err = tx.ExecContext(ctx, query, args...)
-	} else {
-		result, err = r.db.ExecContext(ctx, query, args...)
-	}
-
+	err := inTx(ctx, r.db, tx, func(tx *sql.Tx) error {
+		// 移动或删除标记变化时同步调整新旧父目录的子项计数
+		var err error
+		result, err = execTrackingParent(ctx, tx, dirStateQuery, dir.DirID, query, args...)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("更新目录失败: %w", err)
 	}
@@ -171,17 +269,19 @@ func (r *DirectoryRepositoryImpl) Update(ctx context.Context, tx *sql.Tx, entity
 
 // Delete 删除目录（逻辑删除）
 func (r *DirectoryRepositoryImpl) Delete(ctx context.Context, tx *sql.Tx, id int64) (sql.Result, error) {
-	query := `UPDATE directories SET deleted = true WHERE dir_id = ?`
+	query := `UPDATE directories SET is_deleted = true, delete_batch = ? WHERE dir_id = ?`
 
 	var result sql.Result
-	var err error
-
-	if tx != nil {
-		result, err = tx.ExecContext(ctx, query, id)
-	} else {
-		result, err = r.db.ExecContext(ctx, query, id)
-	}
 
+	err := inTx(ctx, r.db, tx, func(tx *sql.Tx) error {
+		// 单独删除的目录使用新的删除批次，递归恢复其父目录时不会恢复它
+		batch, err := database.NewDeleteBatch(ctx, tx)
+		if err != nil {
+			return err
+		}
+		result, err = execTrackingParent(ctx, tx, dirStateQuery, id, query, batch, id)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("删除目录失败: %w", err)
 	}
@@ -191,46 +291,15 @@ func (r *DirectoryRepositoryImpl) Delete(ctx context.Context, tx *sql.Tx, id int
 
 // FindByParentAndName 通过父ID和名称查找目录
 func (r *DirectoryRepositoryImpl) FindByParentAndName(ctx context.Context, parentID int64, name string, dest *models.DirectoryMetadata) error {
-	return r.baseRepo.FindOne(ctx, dest, "parent_id = ? AND name = ? AND deleted = false", parentID, name)
+	return r.baseRepo.FindOne(ctx, dest, "parent_id = ? AND name = ? AND is_deleted = false", parentID, name)
 }
 
 // FindChildren 查找子目录
 func (r *DirectoryRepositoryImpl) FindChildren(ctx context.Context, dirID int64) ([]models.DirectoryMetadata, error) {
 	var children []models.DirectoryMetadata
-	// 由于 Find 需要自定义实现，我们直接使用 db.QueryContext
-	query := "SELECT * FROM directories WHERE parent_id = ? AND deleted = false"
-	rows, err := r.db.QueryContext(ctx, query, dirID)
-	if err != nil {
+	if err := r.Find(ctx, &children, "parent_id = ? AND is_deleted = false", dirID); err != nil {
 		return nil, fmt.Errorf("查询子目录失败: %w", err)
 	}
-	defer rows.Close()
-
-	// 手动扫描结果到切片
-	for rows.Next() {
-		var dir models.DirectoryMetadata
-		// 这里应该根据实际的列结构来扫描
-		err := rows.Scan(
-			&dir.DirID,
-			&dir.Name,
-			&dir.Path,
-			&dir.ParentID,
-			&dir.Owner,
-			&dir.Group,
-			&dir.Mode,
-			&dir.Deleted,
-			&dir.CreatedTime,
-			&dir.ModifiedTime,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("扫描目录数据失败: %w", err)
-		}
-		children = append(children, dir)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("遍历结果集失败: %w", err)
-	}
-
 	return children, nil
 }
 
@@ -243,10 +312,10 @@ func (r *FileRepositoryImpl) FindOne(ctx context.Context, dest interface{}, quer
 
 // Find 查找多条记录
 func (r *FileRepositoryImpl) Find(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	// 实现类似 DirectoryRepositoryImpl.Find 的逻辑
-	qb := database.NewQueryBuilder(r.table).Where(query)
-	for _, arg := range args {
-		qb.AddArg(arg)
+	// 条件中的值必须通过占位符传递，拒绝拼接了值的条件
+	qb := database.NewQueryBuilder(r.table).Where(query, args...)
+	if err := qb.Err(); err != nil {
+		return err
 	}
 	sql, queryArgs := qb.BuildSelect()
 
@@ -264,61 +333,66 @@ func (r *FileRepositoryImpl) FindAll(ctx context.Context, dest interface{}, quer
 	return r.Find(ctx, dest, query, args...)
 }
 
+// FindPage 分页查找文件
+func (r *FileRepositoryImpl) FindPage(ctx context.Context, dest interface{}, page PageQuery) error {
+	return findPage(ctx, r.db, r.table, dest, page)
+}
+
 // FindByID 通过ID查找文件
 func (r *FileRepositoryImpl) FindByID(ctx context.Context, id int64, dest interface{}) error {
 	return r.baseRepo.FindOne(ctx, dest, "file_id = ?", id)
 }
 
-// 序列化文件分片信息为JSON
-func serializeChunks(file *models.FileMetadata) ([]byte, error) {
-	return json.Marshal(file.Chunks)
-}
-
-// 从JSON反序列化文件分片信息
-func deserializeChunks(file *models.FileMetadata, data []byte) error {
-	return json.Unmarshal(data, &file.Chunks)
-}
-
-// Create 创建文件
+// Create 创建文件，FileID为0时在事务内分配下一个ID并写回file
 func (r *FileRepositoryImpl) Create(ctx context.Context, tx *sql.Tx, entity interface{}) (sql.Result, error) {
 	file, ok := entity.(*models.FileMetadata)
 	if !ok {
 		return nil, fmt.Errorf("实体类型不是 FileMetadata")
 	}
 
-	// 将分片信息序列化为JSON
-	chunksJSON, err := serializeChunks(file)
-	if err != nil {
-		return nil, fmt.Errorf("序列化分片信息失败: %w", err)
-	}
-
 	query := `INSERT INTO files 
-              (name, dir_id, size, chunks_data, mime_type, owner, group_name, 
-               permission_mode, deleted, created_time, modified_time) 
+              (file_id, parent_dir_id, name, size, owner_id, checksum, mode, is_deleted, 
+               created_at, modified_at, accessed_at) 
               VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	var result sql.Result
 
-	args := []interface{}{
-		file.Name,
-		file.DirID, // 修正: 使用正确的目录ID字段名
-		file.Size,
-		chunksJSON,
-		file.MimeType,
-		file.Owner,
-		file.Group,
-		file.Mode,         // 修正: 使用正确的权限字段名
-		file.Deleted,      // 修正: 使用正确的删除标记字段名
-		file.CreatedTime,  // 修正: 使用正确的创建时间字段名
-		file.ModifiedTime, // 修正: 使用正确的修改时间字段名
-	}
-
-	if tx != nil {
-		result, err = tx.ExecContext(ctx, query, args...)
-	} else {
-		result, err = r.db.ExecContext(ctx, query, args...)
-	}
+	err := inTx(ctx, r.db, tx, func(tx *sql.Tx) error {
+		if file.FileID == 0 {
+			id, err := nextID(ctx, tx, "SELECT COALESCE(MAX(file_id), 0) + 1 FROM files")
+			if err != nil {
+				return err
+			}
+			file.FileID = id
+		}
+		created, modified := entityTimes(file.CreateTime, file.ModifyTime)
+		accessed := file.AccessTime.UTC()
+		if accessed.IsZero() {
+			accessed = modified
+		}
 
+		var err error
+		result, err = tx.ExecContext(ctx, query,
+			file.FileID,
+			file.DirID,
+			file.Name,
+			file.Size,
+			file.OwnerID,
+			nullableString(file.Checksum),
+			file.Mode,
+			file.Deleted,
+			created,
+			modified,
+			accessed,
+		)
+		if err != nil {
+			return err
+		}
+		if file.Deleted {
+			return nil
+		}
+		return database.AdjustChildCount(ctx, tx, file.DirID, 1)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("创建文件失败: %w", err)
 	}
@@ -333,39 +407,31 @@ func (r *FileRepositoryImpl) Update(ctx context.Context, tx *sql.Tx, entity inte
 		return nil, fmt.Errorf("实体类型不是 FileMetadata")
 	}
 
-	// 将分片信息序列化为JSON
-	chunksJSON, err := serializeChunks(file)
-	if err != nil {
-		return nil, fmt.Errorf("序列化分片信息失败: %w", err)
-	}
-
 	query := `UPDATE files 
-              SET name = ?, dir_id = ?, size = ?, chunks_data = ?, mime_type = ?, 
-                  owner = ?, group_name = ?, permission_mode = ?, deleted = ?, modified_time = ? 
+              SET name = ?, parent_dir_id = ?, size = ?, owner_id = ?, checksum = ?, 
+                  mode = ?, is_deleted = ?, modified_at = ? 
               WHERE file_id = ?`
 
 	var result sql.Result
 
+	_, modified := entityTimes(file.CreateTime, file.ModifyTime)
 	args := []interface{}{
 		file.Name,
-		file.DirID, // 修正: 使用正确的目录ID字段名
+		file.DirID,
 		file.Size,
-		chunksJSON,
-		file.MimeType,
-		file.Owner,
-		file.Group,
-		file.Mode,         // 修正: 使用正确的权限字段名
-		file.Deleted,      // 修正: 使用正确的删除标记字段名
-		file.ModifiedTime, // 修正: 使用正确的修改时间字段名
+		file.OwnerID,
+		nullableString(file.Checksum),
+		file.Mode,
+		file.Deleted,
+		modified,
 		file.FileID,
 	}
 
-	if tx != nil {
-		result, err = tx.ExecContext(ctx, query, args...)
-	} else {
-		result, err = r.db.ExecContext(ctx, query, args...)
-	}
-
+	err := inTx(ctx, r.db, tx, func(tx *sql.Tx) error {
+		var err error
+		result, err = execTrackingParent(ctx, tx, fileStateQuery, file.FileID, query, args...)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("更新文件失败: %w", err)
 	}
@@ -375,17 +441,18 @@ func (r *FileRepositoryImpl) Update(ctx context.Context, tx *sql.Tx, entity inte
 
 // Delete 删除文件（逻辑删除）
 func (r *FileRepositoryImpl) Delete(ctx context.Context, tx *sql.Tx, id int64) (sql.Result, error) {
-	query := `UPDATE files SET deleted = true WHERE file_id = ?`
+	query := `UPDATE files SET is_deleted = true, delete_batch = ? WHERE file_id = ?`
 
 	var result sql.Result
-	var err error
-
-	if tx != nil {
-		result, err = tx.ExecContext(ctx, query, id)
-	} else {
-		result, err = r.db.ExecContext(ctx, query, id)
-	}
 
+	err := inTx(ctx, r.db, tx, func(tx *sql.Tx) error {
+		batch, err := database.NewDeleteBatch(ctx, tx)
+		if err != nil {
+			return err
+		}
+		result, err = execTrackingParent(ctx, tx, fileStateQuery, id, query, batch, id)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("删除文件失败: %w", err)
 	}
@@ -395,71 +462,53 @@ func (r *FileRepositoryImpl) Delete(ctx context.Context, tx *sql.Tx, id int64) (
 
 // FindByDirAndName 通过目录ID和名称查找文件
 func (r *FileRepositoryImpl) FindByDirAndName(ctx context.Context, dirID int64, name string, dest *models.FileMetadata) error {
-	err := r.baseRepo.FindOne(ctx, dest, "dir_id = ? AND name = ? AND deleted = false", dirID, name)
-
-	// 如果找到了文件，尝试解析分片信息
-	if err == nil && dest != nil {
-		// 假设数据库查询结果中有个 chunks_data 字段，已经被扫描到了一个未导出的 rawChunks 字段
-		var chunksData []byte
-		// 实际中可能需要单独查询，或者确保扫描到了这个字段
-		// 这里简化处理
-		if dest.RawChunksData != nil {
-			chunksData = dest.RawChunksData
-			err = deserializeChunks(dest, chunksData)
-			if err != nil {
-				return fmt.Errorf("解析文件分片信息失败: %w", err)
-			}
-		}
-	}
-
-	return err
+	return r.baseRepo.FindOne(ctx, dest, "parent_dir_id = ? AND name = ? AND is_deleted = false", dirID, name)
 }
 
 // FindByDir 查找目录中的所有文件
 func (r *FileRepositoryImpl) FindByDir(ctx context.Context, dirID int64) ([]models.FileMetadata, error) {
 	var files []models.FileMetadata
-	// 直接使用 QueryContext 而不是依赖 Find 方法
-	query := "SELECT * FROM files WHERE dir_id = ? AND deleted = false"
-	rows, err := r.db.QueryContext(ctx, query, dirID)
-	if err != nil {
+	if err := r.Find(ctx, &files, "parent_dir_id = ? AND is_deleted = false", dirID); err != nil {
 		return nil, fmt.Errorf("查询目录文件失败: %w", err)
 	}
-	defer rows.Close()
+	return files, nil
+}
 
-	// 手动扫描结果到切片
-	for rows.Next() {
-		var file models.FileMetadata
-		var chunksData []byte
-		// 这里应该根据实际的列结构来扫描
-		err := rows.Scan(
-			&file.FileID,
-			&file.Name,
-			&file.DirID,
-			&file.Size,
-			&chunksData, // 分片数据先扫描到变量
-			&file.MimeType,
-			&file.Owner,
-			&file.Group,
-			&file.Mode,
-			&file.Deleted,
-			&file.CreatedTime,
-			&file.ModifiedTime,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("扫描文件数据失败: %w", err)
-		}
+// ========== 辅助函数 ==========
 
-		// 反序列化分片信息
-		if err := deserializeChunks(&file, chunksData); err != nil {
-			return nil, fmt.Errorf("解析文件分片信息失败: %w", err)
-		}
+// nextID 在事务内读取下一个可用的ID，表的主键不是自增列
+func nextID(ctx context.Context, tx *sql.Tx, query string) (int64, error) {
+	var id int64
+	if err := tx.QueryRowContext(ctx, query).Scan(&id); err != nil {
+		return 0, fmt.Errorf("分配ID失败: %w", err)
+	}
+	return id, nil
+}
 
-		files = append(files, file)
+// entityTimes 返回写入的创建和修改时间，未设置的创建时间取当前时间，未设置的修改时间取创建时间。
+// 时间统一以UTC写入，SQLite按文本比较时间列时顺序才与时间先后一致
+func entityTimes(created, modified time.Time) (time.Time, time.Time) {
+	if created.IsZero() {
+		created = time.Now()
 	}
+	if modified.IsZero() {
+		modified = created
+	}
+	return created.UTC(), modified.UTC()
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("遍历结果集失败: %w", err)
+// nullableID 父目录ID为0（根目录）时写入NULL
+func nullableID(id int64) interface{} {
+	if id == 0 {
+		return nil
 	}
+	return id
+}
 
-	return files, nil
+// nullableString 空字符串写入NULL
+func nullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
 }
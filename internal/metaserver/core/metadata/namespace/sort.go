@@ -0,0 +1,124 @@
+package namespace
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/22827099/DFS_v1/internal/metaserver/core/models"
+)
+
+// 目录列表支持的排序字段
+const (
+	SortByName       = "name"
+	SortBySize       = "size"
+	SortByCreatedAt  = "created_at"
+	SortByModifiedAt = "modified_at"
+)
+
+// normalizedSort 返回实际使用的排序字段和是否降序，排序字段无效时按名称升序
+func (opts *listOptions) normalizedSort() (field string, desc bool) {
+	switch opts.SortBy {
+	case SortByName, SortBySize, SortByCreatedAt, SortByModifiedAt:
+		return opts.SortBy, opts.SortOrder == "desc"
+	}
+	return SortByName, false
+}
+
+// sortPathInfos 按排序选项对目录条目稳定排序，排序字段无效时按名称升序
+func sortPathInfos(entries []models.PathInfo, opts *listOptions) {
+	field, desc := opts.normalizedSort()
+	slices.SortStableFunc(entries, func(a, b models.PathInfo) int {
+		return compareEntries(&a, &b, field, desc, opts.DirsFirst)
+	})
+}
+
+// compareEntries 比较两个条目在列表中的先后：目录优先时先比较类型，再按排序字段比较，
+// 排序字段相同时目录在前，最后按ID升序，保证任意两个条目都有确定的先后，分页结果稳定。
+// 目录的大小视为0
+func compareEntries(a, b *models.PathInfo, field string, desc, dirsFirst bool) int {
+	if dirsFirst && a.IsDir != b.IsDir {
+		return compareKinds(a.IsDir, b.IsDir)
+	}
+	c := compareKeys(a, b, field)
+	if desc {
+		c = -c
+	}
+	if c != 0 {
+		return c
+	}
+	if a.IsDir != b.IsDir {
+		return compareKinds(a.IsDir, b.IsDir)
+	}
+	return cmp.Compare(entryID(a), entryID(b))
+}
+
+// compareKinds 目录排在文件之前
+func compareKinds(aIsDir, bIsDir bool) int {
+	switch {
+	case aIsDir == bIsDir:
+		return 0
+	case aIsDir:
+		return -1
+	}
+	return 1
+}
+
+// compareKeys 按排序字段比较两个条目
+func compareKeys(a, b *models.PathInfo, field string) int {
+	switch field {
+	case SortBySize:
+		return cmp.Compare(entrySize(a), entrySize(b))
+	case SortByCreatedAt:
+		createA, _ := entryTimes(a)
+		createB, _ := entryTimes(b)
+		return createA.Compare(createB)
+	case SortByModifiedAt:
+		_, modifyA := entryTimes(a)
+		_, modifyB := entryTimes(b)
+		return modifyA.Compare(modifyB)
+	}
+	return strings.Compare(a.Name, b.Name)
+}
+
+// entryID 返回条目的目录ID或文件ID，未知元数据返回0
+func entryID(info *models.PathInfo) int64 {
+	switch meta := info.Metadata.(type) {
+	case models.FileMetadata:
+		return meta.FileID
+	case *models.FileMetadata:
+		return meta.FileID
+	case models.DirectoryMetadata:
+		return meta.DirID
+	case *models.DirectoryMetadata:
+		return meta.DirID
+	}
+	return 0
+}
+
+// entrySize 返回文件条目的大小，目录或未知元数据返回0
+func entrySize(info *models.PathInfo) int64 {
+	switch meta := info.Metadata.(type) {
+	case models.FileMetadata:
+		return meta.Size
+	case *models.FileMetadata:
+		return meta.Size
+	}
+	return 0
+}
+
+// entryTimes 返回条目的创建时间和修改时间，未知元数据返回零值
+func entryTimes(info *models.PathInfo) (created, modified time.Time) {
+	switch meta := info.Metadata.(type) {
+	case models.FileMetadata:
+		return meta.CreateTime, meta.ModifyTime
+	case *models.FileMetadata:
+		return meta.CreateTime, meta.ModifyTime
+	case models.DirectoryMetadata:
+		return meta.CreateTime, meta.ModifyTime
+	case *models.DirectoryMetadata:
+		return meta.CreateTime, meta.ModifyTime
+	}
+	return time.Time{}, time.Time{}
+}
@@ -0,0 +1,142 @@
+package placement
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/22827099/DFS_v1/common/errors"
+	"github.com/22827099/DFS_v1/common/types"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+)
+
+// Strategy 数据块放置策略，为一个数据块选择存放副本的节点
+type Strategy interface {
+	// Name 返回策略名称，与配置中的strategy取值一致
+	Name() string
+	// Place 从nodes中为key对应的数据块选择replicas个不同节点，第一个为主副本；
+	// 节点少于replicas个时选择全部节点，没有节点时返回Unavailable
+	Place(key string, nodes []types.NodeInfo, replicas int) ([]types.NodeID, error)
+}
+
+// NodeSource 返回当前的集群节点，通常为cluster.Manager.ListNodes
+type NodeSource func(ctx context.Context) ([]types.NodeInfo, error)
+
+// New 根据配置创建放置策略，未设置的配置项使用默认值
+func New(cfg metaconfig.PlacementConfig) (Strategy, error) {
+	cfg.ApplyDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, errors.Wrap(err, errors.InvalidArgument, "无效的放置配置")
+	}
+
+	switch cfg.Strategy {
+	case metaconfig.PlacementLeastUsed:
+		return NewLeastUsed(), nil
+	case metaconfig.PlacementRackAware:
+		return NewRackAware(cfg.VirtualNodes), nil
+	default:
+		return NewRoundRobin(), nil
+	}
+}
+
+// ChunkKey 返回数据块的放置键，同一文件的同一块总是得到相同的键
+func ChunkKey(path string, index int) string {
+	return path + "#" + strconv.Itoa(index)
+}
+
+// Placer 在登记文件的数据块时，从节点来源获取可用节点并按策略为每个块选择副本节点
+type Placer struct {
+	strategy        Strategy
+	nodes           NodeSource
+	defaultReplicas int
+}
+
+// NewPlacer 创建放置器，defaultReplicas为文件未指定副本数时使用的值
+func NewPlacer(strategy Strategy, nodes NodeSource, defaultReplicas int) *Placer {
+	if defaultReplicas < 1 {
+		defaultReplicas = 1
+	}
+	return &Placer{
+		strategy:        strategy,
+		nodes:           nodes,
+		defaultReplicas: defaultReplicas,
+	}
+}
+
+// Strategy 返回使用的放置策略
+func (p *Placer) Strategy() Strategy {
+	return p.strategy
+}
+
+// Placement 一个文件的数据块放置结果
+type Placement struct {
+	Replicas int              // 目标副本数
+	Chunks   [][]types.NodeID // 每个块的副本节点，按块序号排列
+}
+
+// Shortfall 返回每个块比目标副本数少的副本数，可用节点少于目标副本数时为正数
+func (p Placement) Shortfall() int {
+	if len(p.Chunks) == 0 {
+		return 0
+	}
+	return p.Replicas - len(p.Chunks[0])
+}
+
+// PlaceChunks 为文件的chunkCount个数据块选择副本节点；replicas不大于0时使用默认副本数，已死亡的节点不参与放置。
+// 可用节点少于副本数时每个块放在全部可用节点上，缺少的副本数见Placement.Shortfall；没有可用节点时返回Unavailable
+func (p *Placer) PlaceChunks(ctx context.Context, path string, chunkCount, replicas int) (Placement, error) {
+	if replicas < 1 {
+		replicas = p.defaultReplicas
+	}
+	result := Placement{Replicas: replicas}
+	if chunkCount == 0 {
+		return result, nil
+	}
+
+	all, err := p.nodes(ctx)
+	if err != nil {
+		return Placement{}, errors.Wrap(err, errors.Unavailable, "获取集群节点失败")
+	}
+	nodes := AvailableNodes(all)
+
+	result.Chunks = make([][]types.NodeID, chunkCount)
+	for i := range result.Chunks {
+		result.Chunks[i], err = p.strategy.Place(ChunkKey(path, i), nodes, replicas)
+		if err != nil {
+			return Placement{}, err
+		}
+	}
+	return result, nil
+}
+
+// AvailableNodes 过滤掉已死亡的节点
+func AvailableNodes(nodes []types.NodeInfo) []types.NodeInfo {
+	result := make([]types.NodeInfo, 0, len(nodes))
+	for _, node := range nodes {
+		if node.Status != types.NodeStatusDead {
+			result = append(result, node)
+		}
+	}
+	return result
+}
+
+// sortedByID 返回按节点ID排序的副本，使放置结果与节点列表的顺序无关
+func sortedByID(nodes []types.NodeInfo) []types.NodeInfo {
+	sorted := append([]types.NodeInfo(nil), nodes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].NodeID < sorted[j].NodeID
+	})
+	return sorted
+}
+
+// placeCount 检查副本数和可用节点数，返回实际放置的副本数。
+// 副本必须放在不同节点上，节点少于副本数时只放置节点数个副本
+func placeCount(nodes []types.NodeInfo, replicas int) (int, error) {
+	if replicas < 1 {
+		return 0, errors.New(errors.InvalidArgument, "副本数必须为正数: %d", replicas)
+	}
+	if len(nodes) == 0 {
+		return 0, errors.New(errors.Unavailable, "没有可用节点")
+	}
+	return min(replicas, len(nodes)), nil
+}
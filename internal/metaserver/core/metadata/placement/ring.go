@@ -0,0 +1,90 @@
+package placement
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+
+	"github.com/22827099/DFS_v1/common/types"
+)
+
+// HashRing 一致性哈希环，每个节点映射为多个虚拟节点以平衡各节点负责的区间
+type HashRing struct {
+	virtualNodes int
+	hashes       []uint32 // 虚拟节点哈希值，遍历前排序
+	sorted       bool
+	owners       map[uint32]types.NodeID
+}
+
+// NewHashRing 创建哈希环，virtualNodes为每个节点的虚拟节点数
+func NewHashRing(virtualNodes int) *HashRing {
+	if virtualNodes < 1 {
+		virtualNodes = 1
+	}
+	return &HashRing{
+		virtualNodes: virtualNodes,
+		owners:       make(map[uint32]types.NodeID),
+	}
+}
+
+// Add 将节点加入哈希环，虚拟节点哈希冲突时保留先加入的节点
+func (r *HashRing) Add(nodeID types.NodeID) {
+	for i := 0; i < r.virtualNodes; i++ {
+		h := hashKey(string(nodeID) + "#" + strconv.Itoa(i))
+		if _, exists := r.owners[h]; exists {
+			continue
+		}
+		r.owners[h] = nodeID
+		r.hashes = append(r.hashes, h)
+	}
+	r.sorted = false
+}
+
+// Walk 从key的哈希位置开始顺时针遍历环上的不同节点，fn返回false时停止；不能与Add并发调用
+func (r *HashRing) Walk(key string, fn func(nodeID types.NodeID) bool) {
+	if len(r.hashes) == 0 {
+		return
+	}
+	if !r.sorted {
+		sort.Slice(r.hashes, func(i, j int) bool { return r.hashes[i] < r.hashes[j] })
+		r.sorted = true
+	}
+
+	h := hashKey(key)
+	start := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+
+	visited := make(map[types.NodeID]bool)
+	for i := 0; i < len(r.hashes); i++ {
+		nodeID := r.owners[r.hashes[(start+i)%len(r.hashes)]]
+		if visited[nodeID] {
+			continue
+		}
+		visited[nodeID] = true
+		if !fn(nodeID) {
+			return
+		}
+	}
+}
+
+// Get 返回负责key的节点，环为空时返回空ID
+func (r *HashRing) Get(key string) types.NodeID {
+	var owner types.NodeID
+	r.Walk(key, func(nodeID types.NodeID) bool {
+		owner = nodeID
+		return false
+	})
+	return owner
+}
+
+// hashKey 计算FNV-1a哈希后再做一次混合，相似的键（如"n1#0"、"n1#1"）也能均匀分布在环上
+func hashKey(key string) uint32 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return uint32(x)
+}
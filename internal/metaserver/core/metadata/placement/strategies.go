@@ -0,0 +1,186 @@
+package placement
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/22827099/DFS_v1/common/types"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+)
+
+// RoundRobin 轮询策略，节点按ID排序，每个块从上一个块的下一个节点开始连续选择副本节点
+type RoundRobin struct {
+	next atomic.Uint64
+}
+
+// NewRoundRobin 创建轮询策略
+func NewRoundRobin() *RoundRobin {
+	return &RoundRobin{}
+}
+
+// Name 返回策略名称
+func (s *RoundRobin) Name() string {
+	return metaconfig.PlacementRoundRobin
+}
+
+// Place 选择从当前轮询位置开始的replicas个节点，与key无关
+func (s *RoundRobin) Place(key string, nodes []types.NodeInfo, replicas int) ([]types.NodeID, error) {
+	replicas, err := placeCount(nodes, replicas)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := sortedByID(nodes)
+	start := int((s.next.Add(1) - 1) % uint64(len(sorted)))
+
+	result := make([]types.NodeID, replicas)
+	for i := range result {
+		result[i] = sorted[(start+i)%len(sorted)].NodeID
+	}
+	return result, nil
+}
+
+// LeastUsed 最少使用策略，优先选择磁盘使用率最低的节点，使用率相同时选择分片数少的节点；
+// 没有上报指标的节点排在最后
+type LeastUsed struct{}
+
+// NewLeastUsed 创建最少使用策略
+func NewLeastUsed() *LeastUsed {
+	return &LeastUsed{}
+}
+
+// Name 返回策略名称
+func (s *LeastUsed) Name() string {
+	return metaconfig.PlacementLeastUsed
+}
+
+// Place 选择使用率最低的replicas个节点，与key无关
+func (s *LeastUsed) Place(key string, nodes []types.NodeInfo, replicas int) ([]types.NodeID, error) {
+	replicas, err := placeCount(nodes, replicas)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := sortedByID(nodes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].Metrics, sorted[j].Metrics
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		if ua, ub := usageRatio(a), usageRatio(b); ua != ub {
+			return ua < ub
+		}
+		return a.ShardCount < b.ShardCount
+	})
+
+	result := make([]types.NodeID, replicas)
+	for i := range result {
+		result[i] = sorted[i].NodeID
+	}
+	return result, nil
+}
+
+// usageRatio 返回磁盘使用率，未上报使用率时根据字节数计算
+func usageRatio(m *types.NodeMetrics) float64 {
+	if m.DiskUsageRatio > 0 || m.DiskCapacityBytes == 0 {
+		return m.DiskUsageRatio
+	}
+	return float64(m.DiskUsageBytes) / float64(m.DiskCapacityBytes)
+}
+
+// RackAware 机架感知策略，从块的键在一致性哈希环上的位置开始顺时针选择节点，
+// 副本优先放在不同机架上，机架数不足时再放到已使用机架的其他节点。
+// 节点增减时只有哈希环上相邻区间的块会改变放置位置
+type RackAware struct {
+	virtualNodes int
+
+	mu      sync.Mutex
+	ringKey string // 构建ring时的节点和机架，节点集合不变时复用
+	ring    *HashRing
+	racks   map[types.NodeID]string
+}
+
+// NewRackAware 创建机架感知策略，virtualNodes为每个节点在哈希环上的虚拟节点数
+func NewRackAware(virtualNodes int) *RackAware {
+	if virtualNodes < 1 {
+		virtualNodes = metaconfig.DefaultPlacementVirtualNodes
+	}
+	return &RackAware{virtualNodes: virtualNodes}
+}
+
+// Name 返回策略名称
+func (s *RackAware) Name() string {
+	return metaconfig.PlacementRackAware
+}
+
+// Place 按哈希环为key选择replicas个节点，相同的节点集合和key总是得到相同的结果
+func (s *RackAware) Place(key string, nodes []types.NodeInfo, replicas int) ([]types.NodeID, error) {
+	replicas, err := placeCount(nodes, replicas)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ring, racks := s.ringFor(nodes)
+
+	result := make([]types.NodeID, 0, replicas)
+	chosen := make(map[types.NodeID]bool, replicas)
+	usedRacks := make(map[string]bool, replicas)
+
+	// 第一轮每个机架最多选一个节点
+	ring.Walk(key, func(nodeID types.NodeID) bool {
+		if !usedRacks[racks[nodeID]] {
+			usedRacks[racks[nodeID]] = true
+			chosen[nodeID] = true
+			result = append(result, nodeID)
+		}
+		return len(result) < replicas
+	})
+
+	// 机架数少于副本数时，按同样的顺序补足剩余节点
+	if len(result) < replicas {
+		ring.Walk(key, func(nodeID types.NodeID) bool {
+			if !chosen[nodeID] {
+				chosen[nodeID] = true
+				result = append(result, nodeID)
+			}
+			return len(result) < replicas
+		})
+	}
+	return result, nil
+}
+
+// ringFor 返回节点集合对应的哈希环，节点或机架变化时重建，调用方需持有锁
+func (s *RackAware) ringFor(nodes []types.NodeInfo) (*HashRing, map[types.NodeID]string) {
+	sorted := sortedByID(nodes)
+	var key strings.Builder
+	for _, node := range sorted {
+		key.WriteString(string(node.NodeID))
+		key.WriteByte('@')
+		key.WriteString(Rack(node))
+		key.WriteByte(',')
+	}
+	if s.ring != nil && s.ringKey == key.String() {
+		return s.ring, s.racks
+	}
+
+	s.ring = NewHashRing(s.virtualNodes)
+	s.racks = make(map[types.NodeID]string, len(sorted))
+	for _, node := range sorted {
+		s.ring.Add(node.NodeID)
+		s.racks[node.NodeID] = Rack(node)
+	}
+	s.ringKey = key.String()
+	return s.ring, s.racks
+}
+
+// Rack 返回节点所在的机架，未设置机架标签的节点视为独占一个机架
+func Rack(node types.NodeInfo) string {
+	if rack := node.Labels[types.LabelRack]; rack != "" {
+		return rack
+	}
+	return "node:" + string(node.NodeID)
+}
@@ -16,6 +16,8 @@ type FileInfo struct {
 	ChunkSize           int            `json:"chunk_size"`
 	Chunks              []ChunkInfo    `json:"chunks"`
 	Replicas            int            `json:"replicas"`
+	MissingReplicas     int            `json:"missing_replicas,omitempty"` // 放置数据块时因可用节点不足而缺少的副本数（每个块）
+	Checksum            string         `json:"checksum,omitempty"`         // 由各块校验和计算得到的文件校验和
 }
 
 // ChunkInfo 块信息 - 使用通用基本类型
@@ -51,8 +53,8 @@ type DirectoryEntry struct {
 type Store interface {
 	// 初始化存储
 	Initialize() error
-	// 关闭存储
-	Close() error
+	// 关闭存储，先写入未落盘的状态再释放资源；可重复调用，关闭后的操作返回Unavailable错误
+	Close(ctx context.Context) error
 	// 获取文件信息
 	GetFileInfo(ctx context.Context, path string) (*FileInfo, error)
 	// 创建文件
@@ -68,3 +70,54 @@ type Store interface {
 	// 删除目录
 	DeleteDirectory(ctx context.Context, path string, recursive bool) error
 }
+
+// ParentCreator 由支持自动创建祖先目录的存储实现，对应 mkdir -p 语义。
+// 缺失的祖先目录与目标在同一原子操作内创建，祖先路径上存在同名文件时返回AlreadyExists且不做任何修改
+type ParentCreator interface {
+	// CreateFileWithParents 创建文件及其缺失的祖先目录
+	CreateFileWithParents(ctx context.Context, fileInfo FileInfo) (*FileInfo, error)
+	// CreateDirectoryWithParents 创建目录及其缺失的祖先目录，目录已存在时直接返回已有目录
+	CreateDirectoryWithParents(ctx context.Context, dirInfo DirectoryInfo) (*DirectoryInfo, error)
+}
+
+// Restorer 恢复已软删除的文件或目录，返回恢复的条目数。
+// recursive为true时同时恢复目录子树中所有已删除的条目，同名的未删除条目已存在时失败
+type Restorer interface {
+	Restore(ctx context.Context, path string, recursive bool) (int, error)
+}
+
+// MoveOp 批量移动中的一项
+type MoveOp struct {
+	Src string `json:"src"`
+	Dst string `json:"dst"`
+}
+
+// Mover 由支持移动和重命名的存储实现，移动目录时其子树随之移动
+type Mover interface {
+	Move(ctx context.Context, src, dst string) error
+	// MoveBatch 原子地执行一组移动，整批同时生效：源路径按移动前的状态解析，目标路径按移动后的状态解析，
+	// 因此可以交换条目；任一移动失败或移动之间存在冲突时不做任何修改
+	MoveBatch(ctx context.Context, ops []MoveOp) error
+}
+
+// Lease 文件写租约，同一时刻只有一个持有者可以写入文件
+type Lease struct {
+	Path      string    `json:"path"`
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Leaser 由支持文件写租约的存储实现，用于协调同一文件的多个写入者。
+// 持有者在租约到期前未续约（如进程退出）时租约自动失效，其他持有者可以获取
+type Leaser interface {
+	// AcquireWriteLease 获取文件的写租约，租约被其他持有者持有且未过期时返回AlreadyExists；
+	// 当前持有者再次获取等同于续约
+	AcquireWriteLease(ctx context.Context, path, holder string, ttl time.Duration) (*Lease, error)
+	// RenewLease 将持有者的租约延长到ttl之后，租约已过期或不属于holder时失败
+	RenewLease(ctx context.Context, path, holder string, ttl time.Duration) (*Lease, error)
+	// ReleaseLease 释放持有者的租约，租约不存在或已过期时不做任何事
+	ReleaseLease(ctx context.Context, path, holder string) error
+	// CheckWriteLease 检查holder能否写入path，path或其下的文件被其他持有者持有未过期的租约时返回PermissionDenied；
+	// holder为空表示写入者未持有任何租约
+	CheckWriteLease(ctx context.Context, path, holder string) error
+}
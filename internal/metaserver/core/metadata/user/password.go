@@ -0,0 +1,46 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// argon2id参数，参照RFC 9106的推荐配置
+const (
+	saltSize       = 16 // 十六进制编码后为32字符，与users.salt列宽一致
+	hashSize       = 32 // 十六进制编码后为64字符
+	argonTime      = 1
+	argonMemory    = 64 * 1024 // KiB
+	argonThreads   = 4
+	minPasswordLen = 8
+)
+
+// HashPassword 生成随机盐值并计算密码哈希，返回十六进制编码的哈希和盐值
+func HashPassword(password string) (hash, salt string, err error) {
+	saltBytes := make([]byte, saltSize)
+	if _, err := rand.Read(saltBytes); err != nil {
+		return "", "", fmt.Errorf("生成盐值失败: %w", err)
+	}
+	salt = hex.EncodeToString(saltBytes)
+	return derive(password, saltBytes), salt, nil
+}
+
+// VerifyPassword 以常量时间比较密码与已存储的哈希，盐值格式错误时视为不匹配
+func VerifyPassword(password, hash, salt string) bool {
+	saltBytes, err := hex.DecodeString(salt)
+	if err != nil || len(saltBytes) == 0 {
+		return false
+	}
+	computed := derive(password, saltBytes)
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(hash)) == 1
+}
+
+// derive 使用argon2id计算密码哈希
+func derive(password string, salt []byte) string {
+	key := argon2.IDKey([]byte(password), salt, argonTime, argonMemory, argonThreads, hashSize)
+	return hex.EncodeToString(key)
+}
@@ -0,0 +1,265 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/errors"
+	"github.com/22827099/DFS_v1/common/security/auth"
+	"github.com/22827099/DFS_v1/common/security/token"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/database"
+)
+
+const (
+	// SystemUserID 数据库初始化时创建的系统用户，不能删除也不能登录
+	SystemUserID = 1
+
+	// StatusActive 正常状态，对应users表的status列
+	StatusActive = "active"
+
+	// AdminPathPrefix 只有管理员和系统角色可以访问的API前缀
+	AdminPathPrefix = "/api/v1/admin/"
+
+	// DefaultTokenExpiry 默认令牌有效期，与security.token_expiry的默认值一致
+	DefaultTokenExpiry = 24 * time.Hour
+
+	maxUsernameLen = 64
+)
+
+// User 用户信息，不包含密码哈希和盐值
+type User struct {
+	ID        int       `json:"user_id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+	Status    string    `json:"status"`
+}
+
+// Service 基于users表的用户管理和登录服务
+// 同时实现认证中间件需要的令牌验证和权限检查
+type Service struct {
+	db          *database.Manager
+	maker       token.Maker
+	tokenExpiry time.Duration
+	admins      map[string]bool
+}
+
+// ServiceOption 用户服务配置选项
+type ServiceOption func(*Service)
+
+// WithTokenExpiry 设置登录签发令牌的有效期（对应security.token_expiry）
+func WithTokenExpiry(expiry time.Duration) ServiceOption {
+	return func(s *Service) {
+		if expiry > 0 {
+			s.tokenExpiry = expiry
+		}
+	}
+}
+
+// WithAdminUsers 指定拥有管理员角色的用户名
+func WithAdminUsers(usernames ...string) ServiceOption {
+	return func(s *Service) {
+		for _, name := range usernames {
+			s.admins[name] = true
+		}
+	}
+}
+
+// NewService 创建用户服务，maker用于签发和验证登录令牌
+func NewService(db *database.Manager, maker token.Maker, opts ...ServiceOption) (*Service, error) {
+	if db == nil {
+		return nil, errors.New(errors.InvalidArgument, "数据库管理器不能为空")
+	}
+	if maker == nil {
+		return nil, errors.New(errors.InvalidArgument, "令牌生成器不能为空")
+	}
+
+	s := &Service{
+		db:          db,
+		maker:       maker,
+		tokenExpiry: DefaultTokenExpiry,
+		admins:      make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// Create 创建用户，密码加盐哈希后存储，用户名已存在时返回AlreadyExists
+func (s *Service) Create(ctx context.Context, username, password string) (*User, error) {
+	username = strings.TrimSpace(username)
+	if username == "" || len(username) > maxUsernameLen {
+		return nil, errors.New(errors.InvalidArgument, "用户名不能为空且不能超过%d个字符", maxUsernameLen)
+	}
+	if len(password) < minPasswordLen {
+		return nil, errors.New(errors.InvalidArgument, "密码长度不能少于%d个字符", minPasswordLen)
+	}
+
+	hash, salt, err := HashPassword(password)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.Internal, "计算密码哈希失败")
+	}
+
+	user := &User{Username: username, CreatedAt: time.Now(), Status: StatusActive}
+	err = s.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		var exists int
+		if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE username = ?", username).Scan(&exists); err != nil {
+			return errors.Wrap(err, errors.Internal, "查询用户失败")
+		}
+		if exists > 0 {
+			return errors.New(errors.AlreadyExists, "用户名已存在: %s", username)
+		}
+
+		id, err := nextUserID(ctx, tx)
+		if err != nil {
+			return err
+		}
+		user.ID = id
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO users (user_id, username, password_hash, salt, created_at, status) VALUES (?, ?, ?, ?, ?, ?)",
+			user.ID, username, hash, salt, user.CreatedAt, user.Status)
+		if err != nil {
+			return errors.Wrap(err, errors.Internal, "创建用户失败")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Delete 删除用户及其所有授权，已签发给该用户的令牌随之失效
+func (s *Service) Delete(ctx context.Context, userID int) error {
+	if userID == SystemUserID {
+		return errors.New(errors.PermissionDenied, "不能删除系统用户")
+	}
+
+	return s.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		// 先删除授权，permissions表的外键引用users表
+		if _, err := tx.ExecContext(ctx, "DELETE FROM permissions WHERE user_id = ?", userID); err != nil {
+			return errors.Wrap(err, errors.Internal, "删除用户授权失败")
+		}
+		result, err := tx.ExecContext(ctx, "DELETE FROM users WHERE user_id = ?", userID)
+		if err != nil {
+			return errors.Wrap(err, errors.Internal, "删除用户失败")
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return errors.Wrap(err, errors.Internal, "删除用户失败")
+		}
+		if affected == 0 {
+			return errors.New(errors.NotFound, "用户不存在: %d", userID)
+		}
+		return nil
+	})
+}
+
+// nextUserID 从id_sequences表分配用户ID。序列只增不减，已删除用户的ID不会分配给新用户，
+// 新用户因此不会继承残留在其他表中的旧用户数据。先更新再读取，MySQL下更新持有行锁，并发的创建依次分配
+func nextUserID(ctx context.Context, tx *sql.Tx) (int, error) {
+	if _, err := tx.ExecContext(ctx, "UPDATE id_sequences SET next_id = next_id + 1 WHERE name = 'users'"); err != nil {
+		return 0, errors.Wrap(err, errors.Internal, "分配用户ID失败")
+	}
+	var id int
+	if err := tx.QueryRowContext(ctx, "SELECT next_id - 1 FROM id_sequences WHERE name = 'users'").Scan(&id); err != nil {
+		return 0, errors.Wrap(err, errors.Internal, "分配用户ID失败")
+	}
+	return id, nil
+}
+
+// EnsureUser 用户不存在时创建，用于部署时初始化第一个管理员，返回是否新建了用户
+func (s *Service) EnsureUser(ctx context.Context, username, password string) (bool, error) {
+	_, err := s.Create(ctx, username, password)
+	if errors.IsAlreadyExists(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Login 验证用户名和密码，成功后签发令牌
+// 用户不存在和密码错误返回相同的Unauthenticated错误，避免泄露用户名是否存在
+func (s *Service) Login(ctx context.Context, username, password string) (string, *User, error) {
+	var (
+		user       User
+		hash, salt string
+	)
+	err := s.db.QueryRowContext(ctx,
+		"SELECT user_id, username, password_hash, salt, created_at, status FROM users WHERE username = ?",
+		username).Scan(&user.ID, &user.Username, &hash, &salt, &user.CreatedAt, &user.Status)
+	if stderrors.Is(err, sql.ErrNoRows) {
+		// 仍然计算一次哈希，使不存在的用户与密码错误的响应时间一致
+		VerifyPassword(password, "", "00")
+		return "", nil, errors.New(errors.Unauthenticated, "用户名或密码错误")
+	}
+	if err != nil {
+		return "", nil, errors.Wrap(err, errors.Internal, "查询用户失败")
+	}
+
+	if !VerifyPassword(password, hash, salt) || user.ID == SystemUserID || user.Status != StatusActive {
+		return "", nil, errors.New(errors.Unauthenticated, "用户名或密码错误")
+	}
+
+	tokenStr, err := s.maker.CreateToken(user.Username, strconv.Itoa(user.ID), s.tokenExpiry)
+	if err != nil {
+		return "", nil, errors.Wrap(err, errors.Internal, "签发令牌失败")
+	}
+	return tokenStr, &user, nil
+}
+
+// VerifyToken 验证登录令牌，令牌对应的用户已删除或停用时视为无效
+func (s *Service) VerifyToken(tokenStr string) (*auth.UserInfo, error) {
+	payload, err := s.maker.VerifyToken(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := strconv.Atoi(payload.Subject)
+	if err != nil {
+		return nil, token.ErrInvalidToken
+	}
+	var status string
+	err = s.db.QueryRowContext(context.Background(),
+		"SELECT status FROM users WHERE user_id = ? AND username = ?", userID, payload.Username).Scan(&status)
+	if stderrors.Is(err, sql.ErrNoRows) || (err == nil && status != StatusActive) {
+		return nil, token.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errors.Internal, "查询用户失败")
+	}
+
+	return &auth.UserInfo{
+		UserID:   payload.Subject,
+		Username: payload.Username,
+		Roles:    s.roles(userID, payload.Username),
+	}, nil
+}
+
+// HasPermission 管理接口只允许管理员和系统角色访问，其余资源的访问控制由ACL负责
+func (s *Service) HasPermission(user *auth.UserInfo, resource string, action string) bool {
+	if !strings.HasPrefix(resource, AdminPathPrefix) {
+		return true
+	}
+	for _, role := range user.Roles {
+		if role == auth.RoleAdmin || role == auth.RoleSystem {
+			return true
+		}
+	}
+	return false
+}
+
+// roles 返回用户的角色
+func (s *Service) roles(userID int, username string) []auth.Role {
+	switch {
+	case userID == SystemUserID:
+		return []auth.Role{auth.RoleSystem}
+	case s.admins[username]:
+		return []auth.Role{auth.RoleAdmin}
+	default:
+		return []auth.Role{auth.RoleUser}
+	}
+}
@@ -4,38 +4,42 @@ import (
 	"time"
 )
 
-// FileMetadata 表示文件的元数据
+// FileMetadata 表示文件的元数据，db标签对应files表的列，没有对应列的字段不参与扫描
 type FileMetadata struct {
-	FileID     int64     `db:"file_id"`     // 文件ID
-	DirID      int64     `db:"dir_id"`      // 所在目录ID
-	Name       string    `db:"name"`        // 文件名
-	Path       string    `db:"path"`        // 完整路径
-	Size       int64     `db:"size"`        // 文件大小(字节)
-	Checksum   string    `db:"checksum"`    // 校验和
-	Owner      string    `db:"owner"`       // 所有者
-	Group      string    `db:"group"`       // 组
-	Mode       int32     `db:"mode"`        // 权限模式
-	MimeType   string    `db:"mime_type"`   // MIME类型
-	Blocks     int32     `db:"blocks"`      // 块数量
-	CreateTime time.Time `db:"create_time"` // 创建时间
-	ModifyTime time.Time `db:"modify_time"` // 修改时间
-	AccessTime time.Time `db:"access_time"` // 访问时间
-
+	FileID     int64     `db:"file_id"`       // 文件ID
+	DirID      int64     `db:"parent_dir_id"` // 所在目录ID
+	Name       string    `db:"name"`          // 文件名
+	Path       string    `db:"-"`             // 完整路径
+	Size       int64     `db:"size"`          // 文件大小(字节)
+	Checksum   string    `db:"checksum"`      // 校验和
+	OwnerID    int64     `db:"owner_id"`      // 所有者用户ID
+	Owner      string    `db:"-"`             // 所有者
+	Group      string    `db:"-"`             // 组
+	Mode       int32     `db:"mode"`          // 权限模式
+	MimeType   string    `db:"-"`             // MIME类型
+	Blocks     int32     `db:"-"`             // 块数量
+	Version    int32     `db:"version"`       // 版本号
+	Deleted    bool      `db:"is_deleted"`    // 是否已软删除
+	CreateTime time.Time `db:"created_at"`    // 创建时间
+	ModifyTime time.Time `db:"modified_at"`   // 修改时间
+	AccessTime time.Time `db:"accessed_at"`   // 访问时间
 }
 
-// DirectoryMetadata 表示目录的元数据
+// DirectoryMetadata 表示目录的元数据，db标签对应directories表的列，没有对应列的字段不参与扫描
 type DirectoryMetadata struct {
 	DirID      int64     `db:"dir_id"`      // 目录ID
-	ParentID   int64     `db:"parent_id"`   // 父目录ID
+	ParentID   int64     `db:"parent_id"`   // 父目录ID，根目录为0
 	Name       string    `db:"name"`        // 目录名称
-	Path       string    `db:"path"`        // 完整路径
-	Owner      string    `db:"owner"`       // 所有者
-	Group      string    `db:"group"`       // 组
+	Path       string    `db:"-"`           // 完整路径
+	OwnerID    int64     `db:"owner_id"`    // 所有者用户ID
+	Owner      string    `db:"-"`           // 所有者
+	Group      string    `db:"-"`           // 组
 	Mode       int32     `db:"mode"`        // 权限模式
-	CreateTime time.Time `db:"create_time"` // 创建时间
-	ModifyTime time.Time `db:"modify_time"` // 修改时间
-	AccessTime time.Time `db:"access_time"` // 访问时间
-	
+	Deleted    bool      `db:"is_deleted"`  // 是否已软删除
+	ChildCount int64     `db:"child_count"` // 未删除的直接子项数
+	CreateTime time.Time `db:"created_at"`  // 创建时间
+	ModifyTime time.Time `db:"modified_at"` // 修改时间
+	AccessTime time.Time `db:"-"`           // 访问时间，目录表没有该列
 }
 
 // ChunkMetadata 表示数据块元数据
@@ -64,6 +68,7 @@ type PathInfo struct {
 	IsFile     bool               // 是否为文件
 	IsDir      bool               // 是否为目录
 	Exists     bool               // 路径是否存在
+	Deleted    bool               // 路径指向已软删除的条目，仅在解析时包含已删除条目才会设置
 	ParentPath string             // 父目录路径
 	Name       string             // 文件或目录名称
 	Metadata   interface{}        // 元数据，可能是 DirectoryMetadata 或 FileMetadata
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"net/http"
+
+	"github.com/22827099/DFS_v1/common/errors"
+)
+
+// 请求体解析失败的原因，写入错误元数据的reason字段
+const (
+	DecodeReasonEmptyBody    = "empty_body"
+	DecodeReasonSyntax       = "syntax_error"
+	DecodeReasonType         = "type_error"
+	DecodeReasonTrailingData = "trailing_data"
+	DecodeReasonTooLarge     = "body_too_large"
+	DecodeReasonRead         = "read_error"
+)
+
+// DecodeJSONBody 将请求体解析到dst，请求体不能为空
+// 失败时返回带有reason、field、offset等元数据的InvalidArgument错误，
+// 可直接交给HandleAPIError生成400响应
+func DecodeJSONBody(r *http.Request, dst interface{}) error {
+	return decodeJSONBody(r, dst, false)
+}
+
+// DecodeOptionalJSONBody 与DecodeJSONBody相同，但允许请求体为空
+func DecodeOptionalJSONBody(r *http.Request, dst interface{}) error {
+	return decodeJSONBody(r, dst, true)
+}
+
+func decodeJSONBody(r *http.Request, dst interface{}, allowEmpty bool) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		if allowEmpty {
+			return nil
+		}
+		return errors.New(errors.InvalidArgument, "请求体不能为空").
+			WithField("reason", DecodeReasonEmptyBody)
+	}
+	defer r.Body.Close()
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(dst); err != nil {
+		if err == io.EOF {
+			if allowEmpty {
+				return nil
+			}
+			return errors.New(errors.InvalidArgument, "请求体不能为空").
+				WithField("reason", DecodeReasonEmptyBody)
+		}
+		return classifyDecodeError(err)
+	}
+
+	// 一个合法的JSON值之后不允许再有其他内容
+	var extra json.RawMessage
+	if err := decoder.Decode(&extra); err != io.EOF {
+		return errors.New(errors.InvalidArgument, "无效的请求体: JSON之后存在多余内容").
+			WithField("reason", DecodeReasonTrailingData).
+			WithField("offset", decoder.InputOffset())
+	}
+
+	return nil
+}
+
+// classifyDecodeError 将encoding/json的错误转换为带上下文的系统错误
+func classifyDecodeError(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case stderrors.As(err, &syntaxErr):
+		return errors.New(errors.InvalidArgument, "无效的请求体: 第%d字节处JSON语法错误", syntaxErr.Offset).
+			WithField("reason", DecodeReasonSyntax).
+			WithField("offset", syntaxErr.Offset)
+
+	case stderrors.Is(err, io.ErrUnexpectedEOF):
+		return errors.New(errors.InvalidArgument, "无效的请求体: JSON不完整").
+			WithField("reason", DecodeReasonSyntax)
+
+	case stderrors.As(err, &typeErr):
+		e := errors.New(errors.InvalidArgument, "无效的请求体: 字段%q应为%s类型，实际为%s",
+			typeErr.Field, typeErr.Type.String(), typeErr.Value).
+			WithField("reason", DecodeReasonType).
+			WithField("expected", typeErr.Type.String()).
+			WithField("actual", typeErr.Value).
+			WithField("offset", typeErr.Offset)
+		if typeErr.Field != "" {
+			e.WithField("field", typeErr.Field)
+		}
+		return e
+
+	case stderrors.As(err, &maxBytesErr):
+		return errors.New(errors.ResourceExhausted, "请求体过大，上限为%d字节", maxBytesErr.Limit).
+			WithField("reason", DecodeReasonTooLarge)
+
+	default:
+		return errors.Wrap(err, errors.InvalidArgument, "读取请求体失败").
+			WithField("reason", DecodeReasonRead)
+	}
+}
@@ -96,6 +96,10 @@ func mapErrorCode(code errors.ErrorCode) string {
         return "resource_exhausted"
     case errors.Internal:
         return "internal_server_error"
+    case errors.Timeout:
+        return "timeout"
+    case errors.Unavailable:
+        return "unavailable"
     default:
         return "internal_error"
     }
@@ -118,6 +122,10 @@ func HandleAPIError(w http.ResponseWriter, r *http.Request, err error) {
         statusCode = http.StatusUnauthorized
     } else if errors.IsResourceExhausted(err) {
         statusCode = http.StatusRequestEntityTooLarge // 413 Payload Too Large
+    } else if errors.IsTimeout(err) {
+        statusCode = http.StatusGatewayTimeout
+    } else if errors.IsUnavailable(err) {
+        statusCode = http.StatusServiceUnavailable
     } else if errors.IsInternal(err) {
         statusCode = http.StatusInternalServerError
     }
@@ -0,0 +1,21 @@
+package v1
+
+import (
+	"context"
+
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/acl"
+)
+
+// AccessChecker 检查请求上下文中的用户能否对路径执行操作，权限不足时返回PermissionDenied错误。
+// 启用security.enable_acl时由core.MetaCore提供
+type AccessChecker interface {
+	CheckAccess(ctx context.Context, path string, op acl.Operation) error
+}
+
+// checkAccess 未配置访问检查时总是允许
+func checkAccess(ctx context.Context, checker AccessChecker, path string, op acl.Operation) error {
+	if checker == nil {
+		return nil
+	}
+	return checker.CheckAccess(ctx, path, op)
+}
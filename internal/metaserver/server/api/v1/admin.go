@@ -2,45 +2,71 @@ package v1
 
 import (
 	"net/http"
-	"runtime"
 	"time"
 
 	"github.com/22827099/DFS_v1/common/config"
+	"github.com/22827099/DFS_v1/common/metrics"
 	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster"
 	nethttp "github.com/22827099/DFS_v1/common/network/http"
 	"github.com/shirou/gopsutil/cpu"
 	"github.com/22827099/DFS_v1/internal/metaserver/server/api"
 )
 
+// Version 服务版本号，构建时通过 -ldflags "-X" 注入
+var Version = "dev"
+
+// ConnectionCounter 提供当前活跃连接数，由nethttp.Server实现
+type ConnectionCounter interface {
+	ActiveConnections() int64
+}
+
 // AdminAPI 处理管理相关的API请求
 type AdminAPI struct {
 	config  *config.SystemConfig
 	cluster cluster.Manager
 	startTime time.Time      // 服务启动时间
-    // connMgr   *ConnectionManager // TODO: #1 添加连接管理器
+	conns     ConnectionCounter // 监听器上的连接计数
+	metrics   metrics.Collector // 请求指标，提供按路由分组的延迟统计
 }
 
 // 获取活跃连接数
-func (a *AdminAPI) getActiveConnections() int {
-    // if a.connMgr != nil {
-    //     return a.connMgr.GetActiveConnectionCount()
-    // }
+func (a *AdminAPI) getActiveConnections() int64 {
+    if a.conns != nil {
+        return a.conns.ActiveConnections()
+    }
     return 0
 }
 
-// NewAdminAPI 创建管理API处理器
-func NewAdminAPI(config *config.SystemConfig, cluster cluster.Manager) *AdminAPI {
+// 获取按路由分组的请求延迟统计
+func (a *AdminAPI) getRouteLatencies() map[string]metrics.LatencyStats {
+    if a.metrics != nil {
+        return a.metrics.GetRouteLatencies()
+    }
+    return map[string]metrics.LatencyStats{}
+}
+
+// NewAdminAPI 创建管理API处理器，conns为nil时连接数报告为0，collector为nil时不报告请求延迟
+func NewAdminAPI(config *config.SystemConfig, cluster cluster.Manager, conns ConnectionCounter, collector metrics.Collector) *AdminAPI {
     return &AdminAPI{
         config:    config,
         cluster:   cluster,
         startTime: time.Now(),
+        conns:     conns,
+        metrics:   collector,
     }
 }
 
 // RegisterRoutes 注册管理相关路由
 func (a *AdminAPI) RegisterRoutes(router nethttp.RouteGroup) {
-	router.GET("/health", a.HealthCheck)
-	router.GET("/status", a.ServerStatus)
+	router.GET("/health", a.HealthCheck,
+		nethttp.WithSummary("健康检查"),
+		nethttp.WithResponseType(map[string]interface{}{}))
+	router.GET("/status", a.ServerStatus,
+		nethttp.WithSummary("获取服务器状态"),
+		nethttp.WithResponseType(map[string]interface{}{}))
+	router.GET("/metrics/latency", a.RouteLatencies,
+		nethttp.WithSummary("获取按路由模板分组的请求延迟统计"),
+		nethttp.WithResponseType(map[string]metrics.LatencyStats{}))
 }
 
 // HealthCheck 处理健康检查请求
@@ -55,12 +81,6 @@ func (a *AdminAPI) HealthCheck(w http.ResponseWriter, r *http.Request) {
 }
 
 // 以下是辅助函数，用于获取系统资源使用情况
-func getMemoryUsage() float64 {
-    var m runtime.MemStats
-    runtime.ReadMemStats(&m)
-    return float64(m.Alloc) / 1024 / 1024 // 返回MB
-}
-
 func getCPUUsage() float64 {
 	// 获取CPU使用率，采样间隔为100毫秒
 	// 参数false表示获取整体CPU使用率，而不是每个CPU核心的使用率
@@ -91,18 +111,21 @@ func getDiskUsage() map[string]float64 {
 // ServerStatus 获取服务器状态
 func (a *AdminAPI) ServerStatus(w http.ResponseWriter, r *http.Request) {
 	isLeader := a.cluster.IsLeader()
+	runtimeStats := metrics.ReadRuntimeStats()
 	
 	status := map[string]interface{}{
 		"id":          a.config.NodeID,                		// 节点ID
 		"uptime":      time.Since(a.startTime).String(), 	// 服务运行时间
 		"is_leader":   isLeader,                       		// 是否为集群领导节点
-		// "connections": a.getActiveConnections(),       		// 活跃连接数
-		"version":     a.config.Version,               		// 服务版本号
+		"connections": a.getActiveConnections(),       		// 活跃连接数
+		"request_latency": a.getRouteLatencies(),      		// 按"方法 路由模板"分组的请求延迟(毫秒)
+		"version":     Version,                        		// 服务版本号
 		"system_info": map[string]interface{}{
-			"memory_usage": getMemoryUsage(),         		// 内存使用量(MB)
+			"memory_usage": float64(runtimeStats.HeapAllocBytes) / 1024 / 1024, // 内存使用量(MB)
 			"cpu_usage":    getCPUUsage(),            		// CPU使用率(百分比)
 			"disk_usage":   getDiskUsage(),          		// 磁盘使用情况
-			"goroutines":   runtime.NumGoroutine(),  		// 当前goroutine数量
+			"goroutines":   runtimeStats.Goroutines,  		// 当前goroutine数量
+			"runtime":      runtimeStats,             		// Go运行时、GC及文件描述符统计
 		},
 		"cluster_info": map[string]interface{}{
 			"node_count":    a.cluster.GetNodeCount(),       	// 集群节点总数
@@ -114,3 +137,8 @@ func (a *AdminAPI) ServerStatus(w http.ResponseWriter, r *http.Request) {
 
     api.RespondSuccess(w, r, http.StatusOK, status)
 }
+
+// RouteLatencies 返回每个路由模板的请求数和p50/p95/p99延迟，键为"方法 路由模板"
+func (a *AdminAPI) RouteLatencies(w http.ResponseWriter, r *http.Request) {
+	api.RespondSuccess(w, r, http.StatusOK, a.getRouteLatencies())
+}
@@ -0,0 +1,111 @@
+package v1
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+	"github.com/22827099/DFS_v1/common/errors"
+	nethttp "github.com/22827099/DFS_v1/common/network/http"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+	"github.com/22827099/DFS_v1/internal/metaserver/server/api"
+)
+
+// maxBatchMoves 单个批量移动请求允许的最大移动数，整批在同一把锁内执行
+const maxBatchMoves = 1000
+
+// BatchMoveResponse 批量移动结果
+type BatchMoveResponse struct {
+	Moved int `json:"moved"`
+}
+
+// BatchAPI 处理批量操作请求
+type BatchAPI struct {
+	mover  metadata.Mover
+	writes WriteConfirmer // 批量移动的集群提交，nil时直接在本地存储执行
+}
+
+// BatchOption 批量操作API配置选项
+type BatchOption func(*BatchAPI)
+
+// WithBatchWriteConfirmer 设置批量移动的集群提交，整批作为一条命令经多数派确认后由各节点的FileCommandApplier应用
+func WithBatchWriteConfirmer(confirmer WriteConfirmer) BatchOption {
+	return func(a *BatchAPI) {
+		a.writes = confirmer
+	}
+}
+
+// NewBatchAPI 创建批量操作API处理器
+func NewBatchAPI(mover metadata.Mover, opts ...BatchOption) *BatchAPI {
+	a := &BatchAPI{mover: mover}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// RegisterRoutes 注册批量操作路由
+func (a *BatchAPI) RegisterRoutes(router nethttp.RouteGroup) {
+	router.POST("/batch/move", a.MoveBatch,
+		nethttp.WithSummary("批量移动或重命名，整批同时生效，可以交换条目；全部成功或全部不生效"),
+		nethttp.WithQueryParamDoc("consistency", "string", "写入一致性级别：one（写入本节点日志后返回202）、quorum（默认）或all"),
+		nethttp.WithHeaderParamDoc(ConsistencyHeader, "string", "一致性级别，查询参数consistency优先"),
+		nethttp.WithHeaderParamDoc(LeaseHolderHeader, "string", "写入者的租约持有者标识，被移动的文件有其他持有者的租约时返回403"),
+		nethttp.WithRequestType([]metadata.MoveOp{}),
+		nethttp.WithResponseType(BatchMoveResponse{}),
+		acceptedWriteDoc)
+}
+
+// MoveBatch 同时执行请求中的移动，源路径按移动前的状态解析，目标路径按移动后的状态解析；
+// 移动之间存在冲突（目标相同或形成环）时返回400，某个移动在执行时失败则整批撤销并返回该移动的错误
+func (a *BatchAPI) MoveBatch(w http.ResponseWriter, r *http.Request) {
+	level, err := writeConsistency(r)
+	if err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+
+	var ops []metadata.MoveOp
+	if err := api.DecodeJSONBody(r, &ops); err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+	if len(ops) == 0 {
+		api.HandleAPIError(w, r, errors.New(errors.InvalidArgument, "移动列表不能为空"))
+		return
+	}
+	if len(ops) > maxBatchMoves {
+		api.HandleAPIError(w, r, errors.New(errors.InvalidArgument, "单次最多移动%d个条目: %d", maxBatchMoves, len(ops)))
+		return
+	}
+	for i, op := range ops {
+		if op.Src == "" || op.Dst == "" {
+			api.HandleAPIError(w, r, errors.New(errors.InvalidArgument, "第%d个移动的源路径和目标路径不能为空", i+1))
+			return
+		}
+	}
+
+	if err := a.moveBatch(r, level, ops); err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+
+	if level == raft.ConsistencyOne {
+		api.RespondSuccess(w, r, http.StatusAccepted, nil)
+		return
+	}
+
+	api.RespondSuccess(w, r, http.StatusOK, BatchMoveResponse{Moved: len(ops)})
+}
+
+// moveBatch 执行批量移动：配置了集群提交时整批作为一条命令提交，由各节点按请求者的租约持有者标识检查租约后应用
+func (a *BatchAPI) moveBatch(r *http.Request, level raft.ConsistencyLevel, ops []metadata.MoveOp) error {
+	if a.writes == nil {
+		return a.mover.MoveBatch(leaseContext(r), ops)
+	}
+	cmd, err := newCommand(OpMoveBatch, "", moveBatchPayload{Ops: ops, LeaseHolder: r.Header.Get(LeaseHolderHeader), Time: time.Now()})
+	if err != nil {
+		return err
+	}
+	return proposeCommand(r.Context(), a.writes, level, cmd)
+}
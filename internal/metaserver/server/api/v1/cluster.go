@@ -1,10 +1,18 @@
 package v1
 
 import (
+	stderrors "errors"
 	"net/http"
+	"time"
 
+	"github.com/22827099/DFS_v1/common/errors"
+	"github.com/22827099/DFS_v1/common/types"
+	"github.com/22827099/DFS_v1/common/utils"
 	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
 	nethttp "github.com/22827099/DFS_v1/common/network/http"
+	"github.com/22827099/DFS_v1/internal/metaserver/server/api"
+	"github.com/gorilla/mux"
 )
 
 // ClusterAPI 处理集群相关的API请求
@@ -21,11 +29,46 @@ func NewClusterAPI(cluster cluster.Manager) *ClusterAPI {
 
 // RegisterRoutes 注册集群相关路由
 func (c *ClusterAPI) RegisterRoutes(router nethttp.RouteGroup) {
-	router.GET("/nodes", c.ListNodes)
-	router.GET("/nodes/{id}", c.GetNodeInfo)
-	router.GET("/leader", c.GetLeader)
-	router.POST("/rebalance", c.TriggerRebalance)
-	router.GET("/rebalance/status", c.GetRebalanceStatus)
+	router.GET("/nodes", c.ListNodes, nethttp.WithSummary("列出集群节点"))
+	router.GET("/nodes/{id}", c.GetNodeInfo, nethttp.WithSummary("获取节点信息"))
+	router.GET("/leader", c.GetLeader, nethttp.WithSummary("获取当前领导者"))
+	router.POST("/rebalance", c.TriggerRebalance, nethttp.WithSummary("触发数据均衡"))
+	router.GET("/rebalance/status", c.GetRebalanceStatus,
+		nethttp.WithSummary("获取数据均衡状态"),
+		nethttp.WithResponseType(map[string]interface{}{}))
+	router.GET("/cluster/balance/tasks/{id}", c.GetRebalanceTask,
+		nethttp.WithSummary("获取迁移任务进度"),
+		nethttp.WithResponseType(rebalance.MigrationTask{}))
+	router.GET("/cluster/balance/weights", c.GetBalanceWeights,
+		nethttp.WithSummary("获取加权得分策略的权重"),
+		nethttp.WithResponseType(rebalance.ScoreWeights{}))
+	router.PUT("/cluster/balance/weights", c.UpdateBalanceWeights,
+		nethttp.WithSummary("更新加权得分策略的权重，需要管理员角色"),
+		nethttp.WithRequestType(rebalance.ScoreWeights{}),
+		nethttp.WithResponseType(rebalance.ScoreWeights{}))
+	router.POST("/cluster/metrics", c.ReportMetricsBatch,
+		nethttp.WithSummary("批量上报节点指标"),
+		nethttp.WithRequestType(map[string]types.NodeMetrics{}),
+		nethttp.WithResponseType(map[string]int{}))
+	router.POST("/cluster/metrics/{id}", c.ReportNodeMetrics,
+		nethttp.WithSummary("上报单个节点指标"),
+		nethttp.WithRequestType(types.NodeMetrics{}),
+		nethttp.WithResponseType(map[string]int{}))
+	router.GET("/cluster/metrics/{id}/history", c.GetNodeMetricsHistory,
+		nethttp.WithSummary("获取节点指标历史"),
+		nethttp.WithQueryParamDoc("window", "string", "只返回最近这段时间内的样本，如1h、30m，默认返回全部保留的样本"),
+		nethttp.WithQueryParamDoc("points", "integer", "样本数超过该值时降采样，0表示不降采样"),
+		nethttp.WithResponseType([]cluster.MetricsSample{}))
+	router.GET("/cluster/status", c.GetClusterStatus,
+		nethttp.WithSummary("获取集群状态快照"),
+		nethttp.WithResponseType(map[string]interface{}{}))
+	router.GET("/cluster/members", c.GetMembership,
+		nethttp.WithSummary("获取集群成员视图"),
+		nethttp.WithResponseType(cluster.Membership{}))
+	router.POST("/cluster/join", c.JoinCluster,
+		nethttp.WithSummary("请求加入集群"),
+		nethttp.WithRequestType(cluster.JoinRequest{}),
+		nethttp.WithResponseType(cluster.Membership{}))
 }
 
 // ListNodes 列出集群节点
@@ -46,15 +89,222 @@ func (c *ClusterAPI) GetLeader(w http.ResponseWriter, r *http.Request) {
 	// ...
 }
 
+// GetClusterStatus 获取集群状态快照，客户端断开或超时后返回部分数据而不是一直等待；
+// 领导者不可达时返回最近的完整快照并标记stale，监控在短暂故障期间仍然可用
+func (c *ClusterAPI) GetClusterStatus(w http.ResponseWriter, r *http.Request) {
+	api.RespondSuccess(w, r, http.StatusOK, c.cluster.GetClusterSnapshot(r.Context()))
+}
+
+// rebalanceDisabled 负载均衡被配置禁用时返回501并返回true
+func (c *ClusterAPI) rebalanceDisabled(w http.ResponseWriter, r *http.Request) bool {
+	if c.cluster.RebalanceEnabled() {
+		return false
+	}
+	api.RespondError(w, r, http.StatusNotImplemented, errors.New(errors.Unavailable, "负载均衡已在本节点禁用"))
+	return true
+}
+
 // 可以添加其他集群管理功能...
 // TriggerRebalance 触发数据均衡
 func (c *ClusterAPI) TriggerRebalance(w http.ResponseWriter, r *http.Request) {
+	if c.rebalanceDisabled(w, r) {
+		return
+	}
 	// 从原来的 handleTriggerRebalance 转换而来
 	// ...
 }
 
-// GetRebalanceStatus 获取数据均衡状态
+// GetRebalanceStatus 获取数据均衡状态，负载均衡被配置禁用时返回501
+// 响应中包含实际生效的阈值(threshold)和产生不平衡度的策略(strategy/evaluation)
 func (c *ClusterAPI) GetRebalanceStatus(w http.ResponseWriter, r *http.Request) {
-	// 从原来的 handleGetRebalanceStatus 转换而来
-	// ...
+	if c.rebalanceDisabled(w, r) {
+		return
+	}
+	api.RespondSuccess(w, r, http.StatusOK, c.cluster.GetRebalanceStatus())
+}
+
+// GetRebalanceTask 获取单个迁移任务的状态，progress为已传输字节占总字节的百分比
+func (c *ClusterAPI) GetRebalanceTask(w http.ResponseWriter, r *http.Request) {
+	if c.rebalanceDisabled(w, r) {
+		return
+	}
+	taskID := mux.Vars(r)["id"]
+
+	task, ok := c.cluster.GetRebalanceTask(taskID)
+	if !ok {
+		api.HandleAPIError(w, r, errors.New(errors.NotFound, "迁移任务不存在").WithField("task_id", taskID))
+		return
+	}
+
+	api.RespondSuccess(w, r, http.StatusOK, task)
+}
+
+// GetBalanceWeights 获取加权得分策略当前的CPU、内存、磁盘和分片权重，非领导者返回503
+func (c *ClusterAPI) GetBalanceWeights(w http.ResponseWriter, r *http.Request) {
+	if c.rebalanceDisabled(w, r) {
+		return
+	}
+	weights, err := c.cluster.GetBalanceWeights()
+	if err != nil {
+		c.handleWeightsError(w, r, err)
+		return
+	}
+	api.RespondSuccess(w, r, http.StatusOK, weights)
+}
+
+// UpdateBalanceWeights 运行时更新加权得分策略的权重，权重按总和归一化后从下一次评估开始生效，
+// 响应中返回归一化后的权重；只有管理员可以更新，否则返回403；权重为负数或全部为0时返回400，非领导者返回503
+func (c *ClusterAPI) UpdateBalanceWeights(w http.ResponseWriter, r *http.Request) {
+	if c.rebalanceDisabled(w, r) {
+		return
+	}
+	if !isAdmin(r) {
+		api.RespondError(w, r, http.StatusForbidden, errors.New(errors.PermissionDenied, "只有管理员可以更新策略权重"))
+		return
+	}
+	var weights rebalance.ScoreWeights
+	if err := api.DecodeJSONBody(r, &weights); err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+
+	applied, err := c.cluster.UpdateBalanceWeights(weights)
+	if err != nil {
+		c.handleWeightsError(w, r, err)
+		return
+	}
+	api.RespondSuccess(w, r, http.StatusOK, applied)
+}
+
+// handleWeightsError 将读取或更新权重的错误转换为HTTP响应
+func (c *ClusterAPI) handleWeightsError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case stderrors.Is(err, cluster.ErrNotLeader):
+		api.RespondError(w, r, http.StatusServiceUnavailable,
+			errors.New(errors.Unavailable, "当前节点不是领导者").WithField("leader_id", c.cluster.GetCurrentLeader()))
+	case stderrors.Is(err, rebalance.ErrInvalidWeights):
+		api.HandleAPIError(w, r, errors.Wrap(err, errors.InvalidArgument, "无效的策略权重"))
+	case stderrors.Is(err, rebalance.ErrNoWeightedStrategy):
+		api.HandleAPIError(w, r, errors.Wrap(err, errors.NotFound, "当前负载均衡策略没有可调整的权重"))
+	default:
+		api.HandleAPIError(w, r, errors.Wrap(err, errors.Internal, "处理策略权重失败"))
+	}
+}
+
+// ReportNodeMetrics 上报单个节点的指标
+func (c *ClusterAPI) ReportNodeMetrics(w http.ResponseWriter, r *http.Request) {
+	nodeID := mux.Vars(r)["id"]
+
+	var metrics types.NodeMetrics
+	if err := api.DecodeJSONBody(r, &metrics); err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+	if err := cluster.ValidateNodeMetrics(nodeID, &metrics); err != nil {
+		api.HandleAPIError(w, r, errors.Wrap(err, errors.InvalidArgument, "无效的节点指标").WithField("node_id", nodeID))
+		return
+	}
+
+	metrics.NodeID = types.NodeID(nodeID)
+	c.cluster.UpdateNodeMetrics(nodeID, &metrics)
+	api.RespondSuccess(w, r, http.StatusOK, map[string]interface{}{"accepted": 1})
+}
+
+// GetNodeMetricsHistory 按时间顺序返回节点最近上报的指标样本，节点没有上报过指标时返回404
+func (c *ClusterAPI) GetNodeMetricsHistory(w http.ResponseWriter, r *http.Request) {
+	nodeID := mux.Vars(r)["id"]
+
+	var query cluster.MetricsHistoryQuery
+	if window := r.URL.Query().Get("window"); window != "" {
+		d, err := time.ParseDuration(window)
+		if err != nil || d <= 0 {
+			api.HandleAPIError(w, r, errors.New(errors.InvalidArgument, "window参数必须是正的时长，如1h").WithField("window", window))
+			return
+		}
+		query.Since = time.Now().Add(-d)
+	}
+	points, err := utils.ParseIntParam(r, "points", 0, 0, 10000)
+	if err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+	query.MaxPoints = points
+
+	samples, ok := c.cluster.GetNodeMetricsHistory(nodeID, query)
+	if !ok {
+		api.HandleAPIError(w, r, errors.New(errors.NotFound, "节点没有指标历史").WithField("node_id", nodeID))
+		return
+	}
+	api.RespondSuccess(w, r, http.StatusOK, samples)
+}
+
+// ReportMetricsBatch 批量上报节点指标，请求体为节点ID到指标的映射
+// 校验规则与单节点上报相同，任一节点不合法时整批拒绝，错误详情按节点ID列在errors字段中
+func (c *ClusterAPI) ReportMetricsBatch(w http.ResponseWriter, r *http.Request) {
+	var batch map[string]*types.NodeMetrics
+	if err := api.DecodeJSONBody(r, &batch); err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+	if len(batch) == 0 {
+		api.HandleAPIError(w, r, errors.New(errors.InvalidArgument, "批量指标不能为空"))
+		return
+	}
+
+	if invalid := c.cluster.UpdateNodeMetricsBatch(batch); len(invalid) > 0 {
+		details := make(map[string]string, len(invalid))
+		for nodeID, err := range invalid {
+			details[nodeID] = err.Error()
+		}
+		api.HandleAPIError(w, r, errors.New(errors.InvalidArgument, "无效的节点指标").WithField("errors", details))
+		return
+	}
+
+	api.RespondSuccess(w, r, http.StatusOK, map[string]interface{}{"accepted": len(batch)})
+}
+
+// GetMembership 返回当前节点已知的集群成员及领导者，供新节点通过种子节点发现集群
+func (c *ClusterAPI) GetMembership(w http.ResponseWriter, r *http.Request) {
+	api.RespondSuccess(w, r, http.StatusOK, c.cluster.Membership())
+}
+
+// JoinCluster 处理新节点的加入请求，非领导者返回503并在错误详情中给出领导者ID，
+// 集群规模达到MaxClusterSize或节点ID已被其他地址的活跃成员使用时返回409
+func (c *ClusterAPI) JoinCluster(w http.ResponseWriter, r *http.Request) {
+	var req cluster.JoinRequest
+	if err := api.DecodeJSONBody(r, &req); err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+	if req.NodeID == "" {
+		api.HandleAPIError(w, r, errors.New(errors.InvalidArgument, "节点ID不能为空"))
+		return
+	}
+	if req.Address == "" {
+		api.HandleAPIError(w, r, errors.New(errors.InvalidArgument, "节点地址不能为空"))
+		return
+	}
+
+	view, err := c.cluster.JoinNode(cluster.Member{NodeID: req.NodeID, Address: req.Address})
+	if err != nil {
+		if stderrors.Is(err, cluster.ErrNotLeader) {
+			api.RespondError(w, r, http.StatusServiceUnavailable,
+				errors.New(errors.Unavailable, "当前节点不是领导者").WithField("leader_id", view.LeaderID))
+			return
+		}
+		if stderrors.Is(err, cluster.ErrClusterFull) {
+			api.RespondError(w, r, http.StatusConflict,
+				errors.Wrap(err, errors.ResourceExhausted, "集群规模已达上限").WithField("node_id", req.NodeID))
+			return
+		}
+		if stderrors.Is(err, cluster.ErrDuplicateNodeID) {
+			api.RespondError(w, r, http.StatusConflict,
+				errors.Wrap(err, errors.AlreadyExists, "节点ID已被使用").WithField("node_id", req.NodeID))
+			return
+		}
+		api.HandleAPIError(w, r, errors.Wrap(err, errors.Internal, "加入集群失败").WithField("node_id", req.NodeID))
+		return
+	}
+
+	api.RespondSuccess(w, r, http.StatusOK, view)
 }
@@ -0,0 +1,74 @@
+package v1
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"strings"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+	"github.com/22827099/DFS_v1/common/errors"
+	nethttp "github.com/22827099/DFS_v1/common/network/http"
+)
+
+// ConsistencyHeader 指定请求一致性级别的请求头，查询参数consistency优先
+const ConsistencyHeader = "X-Consistency-Level"
+
+// acceptedWriteDoc 在文档中声明写操作以one级别提交时返回的202响应
+var acceptedWriteDoc = nethttp.WithStatusDoc(http.StatusAccepted, "一致性级别为one时写入本节点日志后返回，不等待集群确认，不包含data")
+
+// 读请求的一致性级别
+const (
+	ReadConsistencyLocal        = "local"        // 默认，直接读取本节点的状态，跟随者上可能读到旧值
+	ReadConsistencyLinearizable = "linearizable" // 读取前确认本节点已应用调用之前提交的所有写入
+)
+
+// ReadBarrier 线性一致读的屏障，返回时本节点的状态已包含调用之前提交的所有写入，由cluster.Manager实现
+type ReadBarrier interface {
+	LinearizableRead(ctx context.Context) error
+}
+
+// requestConsistency 返回请求指定的一致性级别，查询参数consistency优先于ConsistencyHeader请求头
+func requestConsistency(r *http.Request) string {
+	if value := r.URL.Query().Get("consistency"); value != "" {
+		return value
+	}
+	return r.Header.Get(ConsistencyHeader)
+}
+
+// writeConsistency 解析写请求的一致性级别，未指定时为raft.DefaultConsistencyLevel，取值无效时返回InvalidArgument错误
+func writeConsistency(r *http.Request) (raft.ConsistencyLevel, error) {
+	level, err := raft.ParseConsistencyLevel(requestConsistency(r))
+	if err != nil {
+		return "", errors.Wrap(err, errors.InvalidArgument, "无效的一致性级别")
+	}
+	return level, nil
+}
+
+// readLinearizable 解析读请求的一致性级别，返回是否需要线性一致读，取值无效时返回InvalidArgument错误
+func readLinearizable(r *http.Request) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(requestConsistency(r))) {
+	case "", ReadConsistencyLocal:
+		return false, nil
+	case ReadConsistencyLinearizable:
+		return true, nil
+	default:
+		return false, errors.New(errors.InvalidArgument, "无效的读一致性级别，可选local或linearizable")
+	}
+}
+
+// waitLinearizable 等待线性一致读的屏障，未配置屏障时立即返回。
+// 超时返回Timeout错误，其他失败（如没有领导者）返回Unavailable错误
+func waitLinearizable(ctx context.Context, barrier ReadBarrier) error {
+	if barrier == nil {
+		return nil
+	}
+	err := barrier.LinearizableRead(ctx)
+	if err == nil || errors.GetCode(err) != errors.Unknown {
+		return err
+	}
+	if stderrors.Is(err, context.DeadlineExceeded) {
+		return errors.Wrap(err, errors.Timeout, "未能在超时时间内确认读取的一致性")
+	}
+	return errors.Wrap(err, errors.Unavailable, "无法确认读取的一致性")
+}
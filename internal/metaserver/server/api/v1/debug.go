@@ -0,0 +1,77 @@
+package v1
+
+import (
+	"bytes"
+	"net/http"
+	"runtime"
+	"runtime/pprof"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/errors"
+	nethttp "github.com/22827099/DFS_v1/common/network/http"
+	"github.com/22827099/DFS_v1/common/security/auth"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster"
+	"github.com/22827099/DFS_v1/internal/metaserver/server/api"
+)
+
+// DebugAPI 提供排查卡死问题的诊断接口，只在配置启用时注册
+type DebugAPI struct {
+	cluster cluster.Manager
+}
+
+// NewDebugAPI 创建诊断API处理器
+func NewDebugAPI(cluster cluster.Manager) *DebugAPI {
+	return &DebugAPI{cluster: cluster}
+}
+
+// RegisterRoutes 注册诊断路由，/admin/前缀的路由由认证中间件限制为管理员访问
+func (d *DebugAPI) RegisterRoutes(router nethttp.RouteGroup) {
+	router.GET("/admin/debug/dump", d.Dump,
+		nethttp.WithSummary("导出goroutine栈、集群管理器锁与状态、Raft状态和未完成的迁移任务"),
+		nethttp.WithResponseType(map[string]interface{}{}))
+}
+
+// Dump 返回诊断信息：goroutines为全部goroutine的栈，locks为集群管理器各把锁是否被占用，
+// cluster_state为集群状态，raft为Raft节点状态，migrations为未完成的迁移任务。
+// 除认证中间件外，处理器自身也要求请求者具有管理员角色
+func (d *DebugAPI) Dump(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		api.RespondError(w, r, http.StatusForbidden, errors.New(errors.PermissionDenied, "只有管理员可以导出诊断信息"))
+		return
+	}
+
+	var stacks bytes.Buffer
+	pprof.Lookup("goroutine").WriteTo(&stacks, 2)
+
+	dump := map[string]interface{}{
+		"timestamp": time.Now().Format(time.RFC3339Nano),
+		"goroutines": map[string]interface{}{
+			"count":  runtime.NumGoroutine(),
+			"stacks": stacks.String(),
+		},
+	}
+	state := d.cluster.DebugState(r.Context())
+	dump["locks"] = state["locks"]
+	dump["raft"] = state["raft"]
+	dump["migrations"] = state["migrations"]
+	delete(state, "locks")
+	delete(state, "raft")
+	delete(state, "migrations")
+	dump["cluster_state"] = state
+
+	api.RespondSuccess(w, r, http.StatusOK, dump)
+}
+
+// isAdmin 判断请求者是否具有管理员或系统角色
+func isAdmin(r *http.Request) bool {
+	user, ok := auth.GetUserFromContext(r.Context())
+	if !ok || user == nil {
+		return false
+	}
+	for _, role := range user.Roles {
+		if role == auth.RoleAdmin || role == auth.RoleSystem {
+			return true
+		}
+	}
+	return false
+}
@@ -1,34 +1,101 @@
 package v1
 
 import (
+    "context"
     "net/http"
-    "encoding/json"
-    "io"
+    "time"
     
+    "github.com/22827099/DFS_v1/common/consensus/raft"
     "github.com/22827099/DFS_v1/common/errors"
     "github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+    "github.com/22827099/DFS_v1/internal/metaserver/core/metadata/acl"
     "github.com/22827099/DFS_v1/internal/metaserver/server/api"
     nethttp "github.com/22827099/DFS_v1/common/network/http"
     "github.com/22827099/DFS_v1/common/utils"
 )
 
+// TruncatedHeader 列出目录的结果因数量上限被截断时设置为true
+const TruncatedHeader = "X-Result-Truncated"
+
+// truncatingLister 由能报告目录列表是否被截断的存储实现
+type truncatingLister interface {
+    ListDirectoryTruncated(ctx context.Context, path string, recursive bool, limit int) ([]metadata.DirectoryEntry, bool, error)
+}
+
+// directoryReader 由能按路径读取目录信息的存储实现
+type directoryReader interface {
+    GetDirectoryInfo(ctx context.Context, path string) (*metadata.DirectoryInfo, error)
+}
+
 // DirectoriesAPI 处理目录相关的API请求
 type DirectoriesAPI struct {
-    store metadata.Store
+    store   metadata.Store
+    writes  WriteConfirmer      // 写操作的集群提交，nil时直接由applier应用到本地存储
+    applier *FileCommandApplier // 未配置集群提交时应用写操作命令
+    access  AccessChecker       // 按目录权限检查请求，nil时不检查
+    barrier ReadBarrier         // 线性一致读的屏障，nil时所有读取都读本地状态
+}
+
+// DirectoriesOption 目录API配置选项
+type DirectoriesOption func(*DirectoriesAPI)
+
+// WithDirectoryAccessChecker 设置目录操作的访问检查，列出、创建、删除前分别检查对应权限，权限不足时返回403
+func WithDirectoryAccessChecker(checker AccessChecker) DirectoriesOption {
+    return func(d *DirectoriesAPI) {
+        d.access = checker
+    }
+}
+
+// WithDirectoryWriteConfirmer 设置创建和删除目录的集群提交，写操作经多数派确认后由各节点的FileCommandApplier应用到存储
+func WithDirectoryWriteConfirmer(confirmer WriteConfirmer) DirectoriesOption {
+    return func(d *DirectoriesAPI) {
+        d.writes = confirmer
+    }
+}
+
+// WithDirectoryReadBarrier 设置线性一致读的屏障，请求指定consistency=linearizable时列出前等待本节点追上已提交的写入
+func WithDirectoryReadBarrier(barrier ReadBarrier) DirectoriesOption {
+    return func(d *DirectoriesAPI) {
+        d.barrier = barrier
+    }
 }
 
 // NewDirectoriesAPI 创建目录API处理器
-func NewDirectoriesAPI(store metadata.Store) *DirectoriesAPI {
-    return &DirectoriesAPI{
-        store: store,
+func NewDirectoriesAPI(store metadata.Store, opts ...DirectoriesOption) *DirectoriesAPI {
+    d := &DirectoriesAPI{
+        store:   store,
+        applier: NewFileCommandApplier(store),
     }
+    for _, opt := range opts {
+        opt(d)
+    }
+    return d
 }
 
 // RegisterRoutes 注册目录相关路由
 func (d *DirectoriesAPI) RegisterRoutes(router nethttp.RouteGroup) {
-    router.GET("/dirs/{path:.*}", d.ListDirectory)
-    router.POST("/dirs/{path:.*}", d.CreateDirectory) 
-    router.DELETE("/dirs/{path:.*}", d.DeleteDirectory)
+    router.GET("/dirs/{path:.*}", d.ListDirectory,
+        nethttp.WithSummary("列出目录内容"),
+        nethttp.WithQueryParamDoc("recursive", "boolean", "是否递归列出子目录"),
+        nethttp.WithQueryParamDoc("limit", "integer", "返回条目数上限，0表示使用服务器的最大条目数；结果被截断时响应头X-Result-Truncated为true"),
+        nethttp.WithQueryParamDoc("consistency", "string", "读取一致性级别：local（默认，读取本节点状态）或linearizable"),
+        nethttp.WithHeaderParamDoc(ConsistencyHeader, "string", "一致性级别，查询参数consistency优先"),
+        nethttp.WithResponseType([]metadata.DirectoryEntry{}))
+    router.POST("/dirs/{path:.*}", d.CreateDirectory,
+        nethttp.WithSummary("创建目录"),
+        nethttp.WithQueryParamDoc("create_parents", "boolean", "自动创建缺失的祖先目录，目录已存在时不报错"),
+        nethttp.WithQueryParamDoc("consistency", "string", "写入一致性级别：one（写入本节点日志后返回202）、quorum（默认）或all"),
+        nethttp.WithHeaderParamDoc(ConsistencyHeader, "string", "一致性级别，查询参数consistency优先"),
+        nethttp.WithRequestType(metadata.DirectoryInfo{}),
+        nethttp.WithResponseType(metadata.DirectoryInfo{}),
+        acceptedWriteDoc)
+    router.DELETE("/dirs/{path:.*}", d.DeleteDirectory,
+        nethttp.WithSummary("删除目录"),
+        nethttp.WithQueryParamDoc("recursive", "boolean", "是否递归删除"),
+        nethttp.WithQueryParamDoc("consistency", "string", "写入一致性级别：one（写入本节点日志后返回202）、quorum（默认）或all"),
+        nethttp.WithHeaderParamDoc(ConsistencyHeader, "string", "一致性级别，查询参数consistency优先"),
+        nethttp.WithHeaderParamDoc(LeaseHolderHeader, "string", "写入者的租约持有者标识，目录下有其他持有者的租约时返回403"),
+        acceptedWriteDoc)
 }
 
 // ListDirectory 列出目录内容
@@ -40,6 +107,11 @@ func (d *DirectoriesAPI) ListDirectory(w http.ResponseWriter, r *http.Request) {
         return
     }
 
+    if err := checkAccess(r.Context(), d.access, dirPath, acl.OpRead); err != nil {
+        api.HandleAPIError(w, r, err)
+        return
+    }
+
     // 使用工具函数处理recursive参数
     recursive, err := utils.ParseBoolParam(r, "recursive", false)
     if err != nil {
@@ -54,15 +126,39 @@ func (d *DirectoriesAPI) ListDirectory(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-    entries, err := d.store.ListDirectory(r.Context(), dirPath, recursive, limit)
+    linearizable, err := readLinearizable(r)
+    if err != nil {
+        api.HandleAPIError(w, r, err)
+        return
+    }
+    if linearizable {
+        if err := waitLinearizable(r.Context(), d.barrier); err != nil {
+            api.HandleAPIError(w, r, err)
+            return
+        }
+    }
+
+    entries, truncated, err := d.listDirectory(r, dirPath, recursive, limit)
     if err != nil {
         api.HandleAPIError(w, r, err)
         return
     }
+    if truncated {
+        w.Header().Set(TruncatedHeader, "true")
+    }
 
     api.RespondSuccess(w, r, http.StatusOK, entries)
 }
 
+// listDirectory 列出目录，存储能报告截断时一并返回是否还有未返回的条目
+func (d *DirectoriesAPI) listDirectory(r *http.Request, dirPath string, recursive bool, limit int) ([]metadata.DirectoryEntry, bool, error) {
+    if lister, ok := d.store.(truncatingLister); ok {
+        return lister.ListDirectoryTruncated(r.Context(), dirPath, recursive, limit)
+    }
+    entries, err := d.store.ListDirectory(r.Context(), dirPath, recursive, limit)
+    return entries, false, err
+}
+
 // CreateDirectory 创建目录
 func (d *DirectoriesAPI) CreateDirectory(w http.ResponseWriter, r *http.Request) {
     dirPath := api.ExtractPath(r)
@@ -71,22 +167,42 @@ func (d *DirectoriesAPI) CreateDirectory(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-    defer r.Body.Close()
+	if err := checkAccess(r.Context(), d.access, dirPath, acl.OpCreate); err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
 
+	// 尝试解析请求体，但允许为空
 	var dirInfo metadata.DirectoryInfo
-	body, err := io.ReadAll(r.Body)
+	if err := api.DecodeOptionalJSONBody(r, &dirInfo); err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+
+	createParents, err := utils.ParseBoolParam(r, "create_parents", false)
 	if err != nil {
-		// 处理错误
-		api.RespondError(w, r, http.StatusBadRequest, 
-            errors.New(errors.Internal, "读取请求体失败"))
+		api.RespondError(w, r, http.StatusBadRequest, err)
 		return
 	}
-	
-	// 尝试解析请求体，但允许为空
-	if len(body) > 0 {
-		if err := json.Unmarshal(body, &dirInfo); err != nil {
-			api.RespondError(w, r, http.StatusBadRequest, 
-                errors.New(errors.InvalidArgument, "无效的请求体"))
+
+	level, err := writeConsistency(r)
+	if err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+
+	// 提交到集群前完成校验，不合法的写操作不会进入日志
+	if createParents {
+		if _, ok := d.store.(metadata.ParentCreator); !ok {
+			api.HandleAPIError(w, r, errors.New(errors.InvalidArgument, "当前存储不支持create_parents"))
+			return
+		}
+	} else if reader, ok := d.store.(directoryReader); ok {
+		if _, err := reader.GetDirectoryInfo(r.Context(), dirPath); err == nil {
+			api.HandleAPIError(w, r, errors.New(errors.AlreadyExists, "目录已存在"))
+			return
+		} else if !errors.IsErrorCode(err, errors.NotFound) {
+			api.HandleAPIError(w, r, err)
 			return
 		}
 	}
@@ -95,13 +211,27 @@ func (d *DirectoriesAPI) CreateDirectory(w http.ResponseWriter, r *http.Request)
 	dirInfo.Path = dirPath
 
 	// 创建目录
-	entries, err := d.store.CreateDirectory(r.Context(), dirInfo)
-	if err != nil {
+	payload := createDirectoryPayload{Directory: dirInfo, CreateParents: createParents}
+	if err := d.commitWrite(r.Context(), level, DirOpCreate, dirPath, payload); err != nil {
 		api.HandleAPIError(w, r, err)
 		return
 	}
 
-	api.RespondSuccess(w, r, http.StatusOK, entries)
+	// one级别下写入尚未提交，不回读结果
+	if level == raft.ConsistencyOne {
+		api.RespondSuccess(w, r, http.StatusAccepted, nil)
+		return
+	}
+
+	result := &dirInfo
+	if reader, ok := d.store.(directoryReader); ok {
+		if result, err = reader.GetDirectoryInfo(r.Context(), dirPath); err != nil {
+			api.HandleAPIError(w, r, err)
+			return
+		}
+	}
+
+	api.RespondSuccess(w, r, http.StatusOK, result)
 }
 
 // DeleteDirectory 删除目录
@@ -113,6 +243,11 @@ func (d *DirectoriesAPI) DeleteDirectory(w http.ResponseWriter, r *http.Request)
         return
     }
 
+    if err := checkAccess(r.Context(), d.access, dirPath, acl.OpDelete); err != nil {
+        api.HandleAPIError(w, r, err)
+        return
+    }
+
     // 使用工具函数处理recursive参数
     recursive, err := utils.ParseBoolParam(r, "recursive", false)
     if err != nil {
@@ -120,11 +255,42 @@ func (d *DirectoriesAPI) DeleteDirectory(w http.ResponseWriter, r *http.Request)
         return
     }
 
-    err = d.store.DeleteDirectory(r.Context(), dirPath, recursive)
+    level, err := writeConsistency(r)
     if err != nil {
         api.HandleAPIError(w, r, err)
         return
     }
 
+    if reader, ok := d.store.(directoryReader); ok {
+        if _, err := reader.GetDirectoryInfo(r.Context(), dirPath); err != nil {
+            api.HandleAPIError(w, r, err)
+            return
+        }
+    }
+
+    // 租约由各节点在应用命令时按请求者的持有者标识检查
+    payload := deleteDirectoryPayload{Recursive: recursive, LeaseHolder: r.Header.Get(LeaseHolderHeader), Time: time.Now()}
+    if err := d.commitWrite(r.Context(), level, DirOpDelete, dirPath, payload); err != nil {
+        api.HandleAPIError(w, r, err)
+        return
+    }
+
+    if level == raft.ConsistencyOne {
+        api.RespondSuccess(w, r, http.StatusAccepted, nil)
+        return
+    }
+
     api.RespondSuccess(w, r, http.StatusOK, nil)
+}
+
+// commitWrite 提交已校验的目录写操作命令并按一致性级别等待确认，错误的含义见proposeCommand
+func (d *DirectoriesAPI) commitWrite(ctx context.Context, level raft.ConsistencyLevel, op, dirPath string, payload interface{}) error {
+    cmd, err := newCommand(op, dirPath, payload)
+    if err != nil {
+        return err
+    }
+    if d.writes == nil {
+        return d.applier.ApplyCommand(ctx, cmd)
+    }
+    return proposeCommand(ctx, d.writes, level, cmd)
 }
\ No newline at end of file
@@ -0,0 +1,154 @@
+package v1
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/errors"
+	nethttp "github.com/22827099/DFS_v1/common/network/http"
+	"github.com/22827099/DFS_v1/common/utils"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/events"
+	"github.com/22827099/DFS_v1/internal/metaserver/server/api"
+)
+
+// eventStreamKeepAlive 事件流空闲时发送注释行的间隔，防止代理因空闲断开连接
+const eventStreamKeepAlive = 15 * time.Second
+
+// EventsResponse 轮询事件的结果，下一次轮询时将cursor设为next_cursor
+type EventsResponse struct {
+	Events     []events.Event `json:"events"`
+	NextCursor uint64         `json:"next_cursor"`
+}
+
+// EventsAPI 向外部索引等系统提供元数据变更事件
+type EventsAPI struct {
+	log *events.Log
+}
+
+// NewEventsAPI 创建事件API处理器
+func NewEventsAPI(log *events.Log) *EventsAPI {
+	return &EventsAPI{log: log}
+}
+
+// RegisterRoutes 注册事件相关路由
+func (a *EventsAPI) RegisterRoutes(router nethttp.RouteGroup) {
+	router.GET("/events", a.ListEvents,
+		nethttp.WithSummary("按游标轮询元数据变更事件"),
+		nethttp.WithQueryParamDoc("cursor", "integer", "上次处理的最后一个事件序号，0表示从保留的最早事件开始"),
+		nethttp.WithQueryParamDoc("limit", "integer", "返回事件数上限"),
+		nethttp.WithResponseType(EventsResponse{}))
+	router.GET("/events/stream", a.StreamEvents,
+		nethttp.WithSummary("以SSE方式持续接收元数据变更事件"),
+		nethttp.WithQueryParamDoc("cursor", "integer", "从该序号之后开始推送，Last-Event-ID请求头优先"),
+		nethttp.WithHeaderParamDoc("Last-Event-ID", "integer", "断线重连时最后收到的事件序号"))
+}
+
+// ListEvents 返回cursor之后的事件；游标之后的事件已被丢弃时返回410，消费者需要重建索引
+func (a *EventsAPI) ListEvents(w http.ResponseWriter, r *http.Request) {
+	cursor, err := parseCursor(r.URL.Query().Get("cursor"))
+	if err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+	limit, err := utils.ParseIntParam(r, "limit", 100, 1, 1000)
+	if err != nil {
+		api.RespondError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	list, next, err := a.log.Since(cursor, limit)
+	if err != nil {
+		respondCursorError(w, r, err, cursor)
+		return
+	}
+
+	api.RespondSuccess(w, r, http.StatusOK, EventsResponse{Events: list, NextCursor: next})
+}
+
+// StreamEvents 以text/event-stream推送cursor之后的事件，每个事件的id为其序号，
+// 断线重连时浏览器或客户端通过Last-Event-ID从断点继续。
+// 消费过慢被日志断开订阅时结束响应，客户端按同样方式重连即可
+func (a *EventsAPI) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("cursor")
+	}
+	cursor, err := parseCursor(value)
+	if err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+
+	stream, cancel, err := a.log.Subscribe(cursor, 0)
+	if err != nil {
+		respondCursorError(w, r, err, cursor)
+		return
+	}
+	defer cancel()
+
+	// 事件流是长连接，取消服务器的写超时
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(eventStreamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-stream:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, data); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// parseCursor 解析事件游标，空值表示0
+func parseCursor(value string) (uint64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	cursor, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, errors.New(errors.InvalidArgument, "无效的事件游标: %s", value)
+	}
+	return cursor, nil
+}
+
+// respondCursorError 游标过期时返回410，其余错误按通用规则处理
+func respondCursorError(w http.ResponseWriter, r *http.Request, err error, cursor uint64) {
+	if stderrors.Is(err, events.ErrCursorExpired) {
+		api.RespondError(w, r, http.StatusGone,
+			errors.Wrap(err, errors.NotFound, "事件游标已过期，需要重建索引").WithField("cursor", cursor))
+		return
+	}
+	api.HandleAPIError(w, r, err)
+}
@@ -0,0 +1,204 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+	"github.com/22827099/DFS_v1/common/errors"
+	"github.com/22827099/DFS_v1/common/types"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+)
+
+// 文件写操作命令的操作类型，命令的Key为文件路径
+const (
+	FileOpCreate = "create" // Value为JSON编码的createFilePayload
+	FileOpUpdate = "update" // Value为JSON编码的更新字段
+	FileOpDelete = "delete" // Value为空
+)
+
+// 目录和批量移动命令的操作类型，目录命令的Key为目录路径，批量移动命令的Key为空
+const (
+	DirOpCreate = "mkdir"      // Value为JSON编码的createDirectoryPayload
+	DirOpDelete = "rmdir"      // Value为JSON编码的deleteDirectoryPayload
+	OpMoveBatch = "move_batch" // Value为JSON编码的moveBatchPayload
+)
+
+// 写租约命令的操作类型，命令的Key为文件路径，Value为JSON编码的leasePayload
+const (
+	LeaseOpAcquire = "lease_acquire"
+	LeaseOpRenew   = "lease_renew"
+	LeaseOpRelease = "lease_release"
+)
+
+// createFilePayload 创建文件命令的内容
+type createFilePayload struct {
+	Size          int64  `json:"size"`
+	MimeType      string `json:"mime_type,omitempty"`
+	CreateParents bool   `json:"create_parents,omitempty"`
+}
+
+// createDirectoryPayload 创建目录命令的内容
+type createDirectoryPayload struct {
+	Directory     metadata.DirectoryInfo `json:"directory"`
+	CreateParents bool                   `json:"create_parents,omitempty"`
+}
+
+// deleteDirectoryPayload 删除目录命令的内容，LeaseHolder为请求者的租约持有者标识，
+// Time为提交者记录的命令时间，各节点据此判断租约是否过期
+type deleteDirectoryPayload struct {
+	Recursive   bool      `json:"recursive,omitempty"`
+	LeaseHolder string    `json:"lease_holder,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+// moveBatchPayload 批量移动命令的内容，字段含义同deleteDirectoryPayload
+type moveBatchPayload struct {
+	Ops         []metadata.MoveOp `json:"ops"`
+	LeaseHolder string            `json:"lease_holder,omitempty"`
+	Time        time.Time         `json:"time"`
+}
+
+// leasePayload 写租约命令的内容，释放租约时TTL为0
+type leasePayload struct {
+	Holder string        `json:"holder"`
+	TTL    time.Duration `json:"ttl,omitempty"`
+	Time   time.Time     `json:"time"`
+}
+
+// FileCommandApplier 将已提交的文件、目录、批量移动和写租约命令应用到元数据存储，实现election.CommandApplier。
+// 集群每个节点注册一个，按日志顺序应用同样的命令，所有节点的元数据保持一致
+type FileCommandApplier struct {
+	store metadata.Store
+}
+
+// NewFileCommandApplier 创建应用文件写操作命令的状态机
+func NewFileCommandApplier(store metadata.Store) *FileCommandApplier {
+	return &FileCommandApplier{store: store}
+}
+
+// ApplyCommand 应用一条写操作命令，返回存储的错误；不认识的操作类型返回InvalidArgument
+func (a *FileCommandApplier) ApplyCommand(ctx context.Context, cmd raft.Command) error {
+	switch cmd.Op {
+	case FileOpCreate:
+		var payload createFilePayload
+		if err := json.Unmarshal(cmd.Value, &payload); err != nil {
+			return errors.Wrap(err, errors.InvalidArgument, "解析创建文件命令失败")
+		}
+		fileInfo := metadata.FileInfo{
+			BasicFileInfo: types.BasicFileInfo{Path: cmd.Key},
+			Size:          payload.Size,
+			MimeType:      payload.MimeType,
+		}
+		if payload.CreateParents {
+			creator, ok := a.store.(metadata.ParentCreator)
+			if !ok {
+				return errors.New(errors.InvalidArgument, "当前存储不支持create_parents")
+			}
+			_, err := creator.CreateFileWithParents(ctx, fileInfo)
+			return err
+		}
+		_, err := a.store.CreateFile(ctx, fileInfo)
+		return err
+	case FileOpUpdate:
+		var updates map[string]interface{}
+		if err := json.Unmarshal(cmd.Value, &updates); err != nil {
+			return errors.Wrap(err, errors.InvalidArgument, "解析更新文件命令失败")
+		}
+		_, err := a.store.UpdateFile(ctx, cmd.Key, updates)
+		return err
+	case FileOpDelete:
+		return a.store.DeleteFile(ctx, cmd.Key)
+	case DirOpCreate:
+		var payload createDirectoryPayload
+		if err := json.Unmarshal(cmd.Value, &payload); err != nil {
+			return errors.Wrap(err, errors.InvalidArgument, "解析创建目录命令失败")
+		}
+		payload.Directory.Path = cmd.Key
+		if payload.CreateParents {
+			creator, ok := a.store.(metadata.ParentCreator)
+			if !ok {
+				return errors.New(errors.InvalidArgument, "当前存储不支持create_parents")
+			}
+			_, err := creator.CreateDirectoryWithParents(ctx, payload.Directory)
+			return err
+		}
+		_, err := a.store.CreateDirectory(ctx, payload.Directory)
+		return err
+	case DirOpDelete:
+		var payload deleteDirectoryPayload
+		if err := json.Unmarshal(cmd.Value, &payload); err != nil {
+			return errors.Wrap(err, errors.InvalidArgument, "解析删除目录命令失败")
+		}
+		ctx = metadata.WithCommandTime(metadata.WithLeaseHolder(ctx, payload.LeaseHolder), payload.Time)
+		return a.store.DeleteDirectory(ctx, cmd.Key, payload.Recursive)
+	case OpMoveBatch:
+		var payload moveBatchPayload
+		if err := json.Unmarshal(cmd.Value, &payload); err != nil {
+			return errors.Wrap(err, errors.InvalidArgument, "解析批量移动命令失败")
+		}
+		mover, ok := a.store.(metadata.Mover)
+		if !ok {
+			return errors.New(errors.InvalidArgument, "当前存储不支持移动")
+		}
+		ctx = metadata.WithCommandTime(metadata.WithLeaseHolder(ctx, payload.LeaseHolder), payload.Time)
+		return mover.MoveBatch(ctx, payload.Ops)
+	case LeaseOpAcquire, LeaseOpRenew, LeaseOpRelease:
+		return a.applyLease(ctx, cmd)
+	default:
+		return errors.New(errors.InvalidArgument, "未知的文件操作: "+cmd.Op)
+	}
+}
+
+// applyLease 应用写租约命令，租约的有效期从命令时间开始计算
+func (a *FileCommandApplier) applyLease(ctx context.Context, cmd raft.Command) error {
+	var payload leasePayload
+	if err := json.Unmarshal(cmd.Value, &payload); err != nil {
+		return errors.Wrap(err, errors.InvalidArgument, "解析写租约命令失败")
+	}
+	leaser, ok := a.store.(metadata.Leaser)
+	if !ok {
+		return errors.New(errors.InvalidArgument, "当前存储不支持写租约")
+	}
+	ctx = metadata.WithCommandTime(ctx, payload.Time)
+	var err error
+	switch cmd.Op {
+	case LeaseOpAcquire:
+		_, err = leaser.AcquireWriteLease(ctx, cmd.Key, payload.Holder, payload.TTL)
+	case LeaseOpRenew:
+		_, err = leaser.RenewLease(ctx, cmd.Key, payload.Holder, payload.TTL)
+	default:
+		err = leaser.ReleaseLease(ctx, cmd.Key, payload.Holder)
+	}
+	return err
+}
+
+// newCommand 构造写操作命令，payload不为nil时JSON编码为命令的Value
+func newCommand(op, key string, payload interface{}) (raft.Command, error) {
+	var value []byte
+	if payload != nil {
+		var err error
+		if value, err = json.Marshal(payload); err != nil {
+			return raft.Command{}, errors.Wrap(err, errors.Internal, "编码写操作失败")
+		}
+	}
+	return raft.Command{Op: op, Key: key, Value: value}, nil
+}
+
+// proposeCommand 将命令提交到集群并按一致性级别等待确认。存储返回的错误原样返回，
+// 集群确认超时返回Timeout错误，其他提交失败（包括在非领导者上使用all级别）返回Unavailable错误
+func proposeCommand(ctx context.Context, writes WriteConfirmer, level raft.ConsistencyLevel, cmd raft.Command) error {
+	err := writes.ProposeWrite(ctx, cmd, level)
+	if err == nil || errors.GetCode(err) != errors.Unknown {
+		return err
+	}
+	if stderrors.Is(err, raft.ErrApplyTimeout) {
+		return errors.Wrap(err, errors.Timeout, "写入未在超时时间内得到集群多数派确认，未应用")
+	}
+	if stderrors.Is(err, raft.ErrNotLeader) {
+		return errors.Wrap(err, errors.Unavailable, "all级别的写入只能由领导者处理")
+	}
+	return errors.Wrap(err, errors.Unavailable, "写入未能提交到集群")
+}
@@ -1,26 +1,102 @@
 package v1
 
 import (
-    "encoding/json"
+    "context"
+    stderrors "errors"
     "net/http"
+    "path"
     
+    "github.com/22827099/DFS_v1/common/concurrency/singleflight"
+    "github.com/22827099/DFS_v1/common/consensus/raft"
     "github.com/22827099/DFS_v1/common/errors"
     "github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+    "github.com/22827099/DFS_v1/internal/metaserver/core/metadata/acl"
     "github.com/22827099/DFS_v1/internal/metaserver/server/api"
     nethttp "github.com/22827099/DFS_v1/common/network/http"
+    "github.com/22827099/DFS_v1/common/utils"
 
 )
 
 // FilesAPI 处理文件相关的API请求
 type FilesAPI struct {
-    store metadata.Store
+    store   metadata.Store
+    reads   singleflight.Group   // 合并对同一文件的并发读取
+    writes  WriteConfirmer       // 写操作的集群提交，nil时直接由applier应用到本地存储
+    applier *FileCommandApplier  // 未配置集群提交时应用写操作命令
+    access  AccessChecker        // 按目录权限检查请求，nil时不检查
+    barrier ReadBarrier          // 线性一致读的屏障，nil时所有读取都读本地状态
+    chunks  metadata.ChunkReader // 校验文件时读取块数据，nil时不支持verify
+    leases  metadata.Leaser      // 写租约，存储不支持租约时为nil
+}
+
+// WriteConfirmer 将写操作命令提交到集群，按一致性级别等待确认，quorum和all级别下返回本节点状态机的结果，
+// 由cluster.Manager实现
+type WriteConfirmer interface {
+    ProposeWrite(ctx context.Context, cmd raft.Command, level raft.ConsistencyLevel) error
+}
+
+// FilesOption 文件API配置选项
+type FilesOption func(*FilesAPI)
+
+// WithWriteConfirmer 设置写操作的集群提交，写操作经多数派确认后由各节点的FileCommandApplier应用到存储；
+// 确认超时时返回504，本地存储不变
+func WithWriteConfirmer(confirmer WriteConfirmer) FilesOption {
+    return func(f *FilesAPI) {
+        f.writes = confirmer
+    }
+}
+
+// WithFileReadBarrier 设置线性一致读的屏障，请求指定consistency=linearizable时读取前等待本节点追上已提交的写入
+func WithFileReadBarrier(barrier ReadBarrier) FilesOption {
+    return func(f *FilesAPI) {
+        f.barrier = barrier
+    }
+}
+
+// WithChunkReader 设置读取块数据的客户端，verify=true时读取文件所有块的数据计算校验和
+func WithChunkReader(reader metadata.ChunkReader) FilesOption {
+    return func(f *FilesAPI) {
+        f.chunks = reader
+    }
+}
+
+// WithFileAccessChecker 设置文件操作的访问检查，读取、创建、修改、删除前分别检查对应权限，权限不足时返回403
+func WithFileAccessChecker(checker AccessChecker) FilesOption {
+    return func(f *FilesAPI) {
+        f.access = checker
+    }
 }
 
 // NewFilesAPI 创建文件API处理器
-func NewFilesAPI(store metadata.Store) *FilesAPI {
-    return &FilesAPI{
-        store: store,
+func NewFilesAPI(store metadata.Store, opts ...FilesOption) *FilesAPI {
+    f := &FilesAPI{
+        store:   store,
+        applier: NewFileCommandApplier(store),
+    }
+    f.leases, _ = store.(metadata.Leaser)
+    for _, opt := range opts {
+        opt(f)
     }
+    return f
+}
+
+// commitWrite 提交已校验的写操作命令并按一致性级别等待确认，错误的含义见proposeCommand
+func (f *FilesAPI) commitWrite(ctx context.Context, level raft.ConsistencyLevel, op, filePath string, payload interface{}) error {
+    // 命令的Op为create、update或delete，Key为文件路径，Value为JSON编码的命令内容
+    cmd, err := newCommand(op, filePath, payload)
+    if err != nil {
+        return err
+    }
+    if f.writes == nil {
+        return f.applier.ApplyCommand(ctx, cmd)
+    }
+    return proposeCommand(ctx, f.writes, level, cmd)
+}
+
+// requireFile 确认文件存在，写操作提交到集群前调用，不存在时返回NotFound错误
+func (f *FilesAPI) requireFile(ctx context.Context, filePath string) error {
+    _, err := f.store.GetFileInfo(ctx, filePath)
+    return err
 }
 
 // FileRequest 文件操作请求
@@ -31,12 +107,45 @@ type FileRequest struct {
     Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// VerifiedFileInfo 带校验结果的文件信息，仅在verify=true时返回
+type VerifiedFileInfo struct {
+    *metadata.FileInfo
+    ChecksumValid bool  `json:"checksum_valid"`
+    InvalidChunks []int `json:"invalid_chunks,omitempty"` // 数据与记录的校验和不一致的块索引
+}
+
 // RegisterRoutes 注册文件相关路由
 func (f *FilesAPI) RegisterRoutes(router nethttp.RouteGroup) {
-    router.GET("/files/{path:.*}", f.GetFileInfo)
-    router.POST("/files/{path:.*}", f.CreateFile)
-    router.PUT("/files/{path:.*}", f.UpdateFile)
-    router.DELETE("/files/{path:.*}", f.DeleteFile)
+    router.GET("/files/{path:.*}", f.GetFileInfo,
+        nethttp.WithSummary("获取文件信息"),
+        nethttp.WithQueryParamDoc("verify", "boolean", "读取所有块的数据重新计算并校验文件校验和"),
+        nethttp.WithQueryParamDoc("consistency", "string", "读取一致性级别：local（默认，读取本节点状态）或linearizable"),
+        nethttp.WithHeaderParamDoc(ConsistencyHeader, "string", "一致性级别，查询参数consistency优先"),
+        nethttp.WithResponseType(VerifiedFileInfo{}))
+    router.POST("/files/{path:.*}", f.CreateFile,
+        nethttp.WithSummary("创建文件"),
+        nethttp.WithQueryParamDoc("create_parents", "boolean", "自动创建缺失的祖先目录"),
+        nethttp.WithQueryParamDoc("consistency", "string", "写入一致性级别：one（写入本节点日志后返回202）、quorum（默认）或all"),
+        nethttp.WithHeaderParamDoc(ConsistencyHeader, "string", "一致性级别，查询参数consistency优先"),
+        nethttp.WithHeaderParamDoc(LeaseHolderHeader, "string", "写入者的租约持有者标识，文件被其他持有者的租约保护时返回403"),
+        nethttp.WithRequestType(FileRequest{}),
+        nethttp.WithResponseType(metadata.FileInfo{}),
+        nethttp.WithSuccessStatus(http.StatusCreated),
+        acceptedWriteDoc)
+    router.PUT("/files/{path:.*}", f.UpdateFile,
+        nethttp.WithSummary("更新文件信息"),
+        nethttp.WithQueryParamDoc("consistency", "string", "写入一致性级别：one（写入本节点日志后返回202）、quorum（默认）或all"),
+        nethttp.WithHeaderParamDoc(ConsistencyHeader, "string", "一致性级别，查询参数consistency优先"),
+        nethttp.WithHeaderParamDoc(LeaseHolderHeader, "string", "写入者的租约持有者标识，文件被其他持有者的租约保护时返回403"),
+        nethttp.WithRequestType(map[string]interface{}{}),
+        nethttp.WithResponseType(metadata.FileInfo{}),
+        acceptedWriteDoc)
+    router.DELETE("/files/{path:.*}", f.DeleteFile,
+        nethttp.WithSummary("删除文件"),
+        nethttp.WithQueryParamDoc("consistency", "string", "写入一致性级别：one（写入本节点日志后返回202）、quorum（默认）或all"),
+        nethttp.WithHeaderParamDoc(ConsistencyHeader, "string", "一致性级别，查询参数consistency优先"),
+        nethttp.WithHeaderParamDoc(LeaseHolderHeader, "string", "写入者的租约持有者标识，文件被其他持有者的租约保护时返回403"),
+        acceptedWriteDoc)
 }
 
 // GetFileInfo 获取文件信息
@@ -48,15 +157,76 @@ func (f *FilesAPI) GetFileInfo(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-    fileInfo, err := f.store.GetFileInfo(r.Context(), filePath)
+    if err := checkAccess(r.Context(), f.access, filePath, acl.OpRead); err != nil {
+        api.HandleAPIError(w, r, err)
+        return
+    }
+
+    // 校验需要读取所有块的数据，开销较大，只在显式请求时执行
+    verify, err := utils.ParseBoolParam(r, "verify", false)
+    if err != nil {
+        api.RespondError(w, r, http.StatusBadRequest, err)
+        return
+    }
+    if verify && f.chunks == nil {
+        api.HandleAPIError(w, r, errors.New(errors.Unavailable, "未配置块数据读取，无法校验文件数据"))
+        return
+    }
+
+    linearizable, err := readLinearizable(r)
+    if err != nil {
+        api.HandleAPIError(w, r, err)
+        return
+    }
+
+    var fileInfo *metadata.FileInfo
+    if linearizable {
+        // 不与其他请求合并，合并到的读取可能早于屏障开始
+        if err = waitLinearizable(r.Context(), f.barrier); err == nil {
+            fileInfo, err = f.store.GetFileInfo(r.Context(), filePath)
+        }
+    } else {
+        fileInfo, err = f.getFileInfo(r.Context(), filePath)
+    }
     if err != nil {
         api.HandleAPIError(w, r, err)
         return
     }
 
+    if verify {
+        valid, invalid, err := metadata.VerifyFileData(r.Context(), f.chunks, fileInfo)
+        if err != nil {
+            api.HandleAPIError(w, r, errors.Wrap(err, errors.Unavailable, "读取块数据失败"))
+            return
+        }
+        api.RespondSuccess(w, r, http.StatusOK, VerifiedFileInfo{
+            FileInfo:      fileInfo,
+            ChecksumValid: valid,
+            InvalidChunks: invalid,
+        })
+        return
+    }
+
     api.RespondSuccess(w, r, http.StatusOK, fileInfo)
 }
 
+// getFileInfo 读取文件信息，同一路径的并发读取合并为一次存储访问，所有请求共享结果。
+// 读取不受发起请求的连接断开影响，其他请求仍能得到结果；每个请求仍可因自身的ctx放弃等待
+func (f *FilesAPI) getFileInfo(ctx context.Context, filePath string) (*metadata.FileInfo, error) {
+    key := path.Clean(filePath)
+    v, err, _ := f.reads.DoWithContext(ctx, key, func() (interface{}, error) {
+        return f.store.GetFileInfo(context.WithoutCancel(ctx), filePath)
+    })
+    if stderrors.Is(err, singleflight.ErrPanicked) {
+        // panic的值和堆栈只保留在错误原因中，不返回给客户端
+        return nil, errors.Wrap(err, errors.Internal, "读取文件信息失败")
+    }
+    if err != nil {
+        return nil, err
+    }
+    return v.(*metadata.FileInfo), nil
+}
+
 // CreateFile 创建文件
 func (f *FilesAPI) CreateFile(w http.ResponseWriter, r *http.Request) {
     filePath := api.ExtractPath(r)
@@ -66,6 +236,23 @@ func (f *FilesAPI) CreateFile(w http.ResponseWriter, r *http.Request) {
         return
     }
 
+    if err := checkAccess(r.Context(), f.access, filePath, acl.OpCreate); err != nil {
+        api.HandleAPIError(w, r, err)
+        return
+    }
+
+    createParents, err := utils.ParseBoolParam(r, "create_parents", false)
+    if err != nil {
+        api.RespondError(w, r, http.StatusBadRequest, err)
+        return
+    }
+
+    level, err := writeConsistency(r)
+    if err != nil {
+        api.HandleAPIError(w, r, err)
+        return
+    }
+
     // 验证请求体大小
     if r.ContentLength > 1024*1024 {
         api.RespondError(w, r, http.StatusRequestEntityTooLarge, 
@@ -74,12 +261,10 @@ func (f *FilesAPI) CreateFile(w http.ResponseWriter, r *http.Request) {
     }
 
     var fileReq FileRequest
-    if err := json.NewDecoder(r.Body).Decode(&fileReq); err != nil {
-        api.RespondError(w, r, http.StatusBadRequest, 
-            errors.New(errors.InvalidArgument, "无效的请求体: %v", err))
+    if err := api.DecodeJSONBody(r, &fileReq); err != nil {
+        api.HandleAPIError(w, r, err)
         return
     }
-    defer r.Body.Close()
 
     // 验证必填字段
     if fileReq.Size < 0 {
@@ -88,16 +273,39 @@ func (f *FilesAPI) CreateFile(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-    // 转换为存储模型
-    fileInfo := metadata.FileInfo{
-        Path:     filePath,
-        Size:     fileReq.Size,
-        MimeType: fileReq.MimeType,
-        // 其他字段设置...
+    // 提交到集群前完成校验，不合法的写操作不会进入日志
+    if createParents {
+        if _, ok := f.store.(metadata.ParentCreator); !ok {
+            api.HandleAPIError(w, r, errors.New(errors.InvalidArgument, "当前存储不支持create_parents"))
+            return
+        }
+    }
+    if _, err := f.store.GetFileInfo(r.Context(), filePath); err == nil {
+        api.HandleAPIError(w, r, errors.New(errors.AlreadyExists, "文件已存在"))
+        return
+    } else if !errors.IsErrorCode(err, errors.NotFound) {
+        api.HandleAPIError(w, r, err)
+        return
+    }
+
+    if err := checkWriteLease(r, f.leases, filePath); err != nil {
+        api.HandleAPIError(w, r, err)
+        return
+    }
+
+    payload := createFilePayload{Size: fileReq.Size, MimeType: fileReq.MimeType, CreateParents: createParents}
+    if err := f.commitWrite(r.Context(), level, FileOpCreate, filePath, payload); err != nil {
+        api.HandleAPIError(w, r, err)
+        return
     }
 
-    // 创建文件元数据
-    result, err := f.store.CreateFile(r.Context(), fileInfo)
+    // one级别下写入尚未提交，不回读结果
+    if level == raft.ConsistencyOne {
+        api.RespondSuccess(w, r, http.StatusAccepted, nil)
+        return
+    }
+
+    result, err := f.store.GetFileInfo(r.Context(), filePath)
     if err != nil {
         api.HandleAPIError(w, r, err)
         return
@@ -115,16 +323,45 @@ func (s *FilesAPI) UpdateFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := checkAccess(r.Context(), s.access, filePath, acl.OpUpdate); err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+
+	level, err := writeConsistency(r)
+	if err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+
 	var updates map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
-		api.RespondError(w, r, http.StatusBadRequest,
-			errors.New(errors.InvalidArgument, "无效的请求体"))
+	if err := api.DecodeJSONBody(r, &updates); err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+
+	if err := s.requireFile(r.Context(), filePath); err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+
+	if err := checkWriteLease(r, s.leases, filePath); err != nil {
+		api.HandleAPIError(w, r, err)
 		return
 	}
-	defer r.Body.Close()
 
 	// 更新文件元数据
-	result, err := s.store.UpdateFile(r.Context(), filePath, updates)
+	if err := s.commitWrite(r.Context(), level, FileOpUpdate, filePath, updates); err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+
+	if level == raft.ConsistencyOne {
+		api.RespondSuccess(w, r, http.StatusAccepted, nil)
+		return
+	}
+
+	result, err := s.store.GetFileInfo(r.Context(), filePath)
 	if err != nil {
 		api.HandleAPIError(w, r, err)
 		return
@@ -142,11 +379,36 @@ func (s *FilesAPI) DeleteFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := s.store.DeleteFile(r.Context(), filePath)
+	if err := checkAccess(r.Context(), s.access, filePath, acl.OpDelete); err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+
+	level, err := writeConsistency(r)
 	if err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+
+	if err := s.requireFile(r.Context(), filePath); err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+
+	if err := checkWriteLease(r, s.leases, filePath); err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+
+	if err := s.commitWrite(r.Context(), level, FileOpDelete, filePath, nil); err != nil {
         api.HandleAPIError(w, r, err)
 		return
 	}
 
+	if level == raft.ConsistencyOne {
+		api.RespondSuccess(w, r, http.StatusAccepted, nil)
+		return
+	}
+
     api.RespondSuccess(w, r, http.StatusOK, nil)
 }
\ No newline at end of file
@@ -0,0 +1,203 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+	"github.com/22827099/DFS_v1/common/errors"
+	nethttp "github.com/22827099/DFS_v1/common/network/http"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/acl"
+	"github.com/22827099/DFS_v1/internal/metaserver/server/api"
+)
+
+// LeaseHolderHeader 写入者的租约持有者标识。获取、续约和释放租约时必须提供；
+// 写入、删除和移动文件时携带该请求头，持有租约的写入者才能修改被租约保护的文件
+const LeaseHolderHeader = "X-Lease-Holder"
+
+// LeaseRequest 获取或续约租约的请求
+type LeaseRequest struct {
+	TTLSeconds int `json:"ttl_seconds"`
+}
+
+// LeasesAPI 处理文件写租约请求。配置了集群提交时获取、续约和释放租约作为命令提交，
+// 经多数派确认后由每个节点应用，无论请求由哪个节点处理，所有节点看到同样的租约
+type LeasesAPI struct {
+	leaser metadata.Leaser
+	access AccessChecker  // 按目录权限检查请求，nil时不检查
+	writes WriteConfirmer // 租约命令的集群提交，nil时直接修改本地存储
+}
+
+// LeasesOption 租约API配置选项
+type LeasesOption func(*LeasesAPI)
+
+// WithLeaseAccessChecker 设置租约操作的访问检查，获取、续约和释放租约需要文件的写权限
+func WithLeaseAccessChecker(checker AccessChecker) LeasesOption {
+	return func(a *LeasesAPI) {
+		a.access = checker
+	}
+}
+
+// WithLeaseWriteConfirmer 设置租约命令的集群提交，租约命令总是等待多数派确认
+func WithLeaseWriteConfirmer(writes WriteConfirmer) LeasesOption {
+	return func(a *LeasesAPI) {
+		a.writes = writes
+	}
+}
+
+// NewLeasesAPI 创建租约API处理器
+func NewLeasesAPI(leaser metadata.Leaser, opts ...LeasesOption) *LeasesAPI {
+	a := &LeasesAPI{leaser: leaser}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// RegisterRoutes 注册租约相关路由
+func (a *LeasesAPI) RegisterRoutes(router nethttp.RouteGroup) {
+	router.POST("/leases/{path:.*}", a.AcquireLease,
+		nethttp.WithSummary("获取文件写租约，租约被其他持有者持有时返回409"),
+		nethttp.WithHeaderParamDoc(LeaseHolderHeader, "string", "租约持有者标识，必须提供"),
+		nethttp.WithRequestType(LeaseRequest{}),
+		nethttp.WithResponseType(metadata.Lease{}))
+	router.PUT("/leases/{path:.*}", a.RenewLease,
+		nethttp.WithSummary("续约文件写租约"),
+		nethttp.WithHeaderParamDoc(LeaseHolderHeader, "string", "租约持有者标识，必须提供"),
+		nethttp.WithRequestType(LeaseRequest{}),
+		nethttp.WithResponseType(metadata.Lease{}))
+	router.DELETE("/leases/{path:.*}", a.ReleaseLease,
+		nethttp.WithSummary("释放文件写租约"),
+		nethttp.WithHeaderParamDoc(LeaseHolderHeader, "string", "租约持有者标识，必须提供"))
+}
+
+// AcquireLease 获取文件写租约，持有者再次获取等同于续约
+func (a *LeasesAPI) AcquireLease(w http.ResponseWriter, r *http.Request) {
+	filePath, holder, ok := a.leaseTarget(w, r)
+	if !ok {
+		return
+	}
+	ttl, ok := leaseTTL(w, r)
+	if !ok {
+		return
+	}
+
+	lease, err := a.commitLease(r, LeaseOpAcquire, filePath, holder, ttl)
+	if err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+	api.RespondSuccess(w, r, http.StatusOK, lease)
+}
+
+// RenewLease 续约持有者的租约，租约已过期时返回404，由其他持有者持有时返回403
+func (a *LeasesAPI) RenewLease(w http.ResponseWriter, r *http.Request) {
+	filePath, holder, ok := a.leaseTarget(w, r)
+	if !ok {
+		return
+	}
+	ttl, ok := leaseTTL(w, r)
+	if !ok {
+		return
+	}
+
+	lease, err := a.commitLease(r, LeaseOpRenew, filePath, holder, ttl)
+	if err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+	api.RespondSuccess(w, r, http.StatusOK, lease)
+}
+
+// ReleaseLease 释放持有者的租约，租约不存在或已过期时同样成功
+func (a *LeasesAPI) ReleaseLease(w http.ResponseWriter, r *http.Request) {
+	filePath, holder, ok := a.leaseTarget(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := a.commitLease(r, LeaseOpRelease, filePath, holder, 0); err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+	api.RespondSuccess(w, r, http.StatusOK, nil)
+}
+
+// commitLease 执行租约操作。配置了集群提交时以当前时间为命令时间提交命令并等待多数派确认，
+// 存储拒绝的操作（租约冲突、已过期等）返回存储的错误；释放租约时返回的租约为nil
+func (a *LeasesAPI) commitLease(r *http.Request, op, filePath, holder string, ttl time.Duration) (*metadata.Lease, error) {
+	now := time.Now()
+	if a.writes == nil {
+		ctx := metadata.WithCommandTime(r.Context(), now)
+		switch op {
+		case LeaseOpAcquire:
+			return a.leaser.AcquireWriteLease(ctx, filePath, holder, ttl)
+		case LeaseOpRenew:
+			return a.leaser.RenewLease(ctx, filePath, holder, ttl)
+		default:
+			return nil, a.leaser.ReleaseLease(ctx, filePath, holder)
+		}
+	}
+
+	filePath = path.Clean(filePath)
+	cmd, err := newCommand(op, filePath, leasePayload{Holder: holder, TTL: ttl, Time: now})
+	if err != nil {
+		return nil, err
+	}
+	if err := proposeCommand(r.Context(), a.writes, raft.ConsistencyQuorum, cmd); err != nil {
+		return nil, err
+	}
+	if op == LeaseOpRelease {
+		return nil, nil
+	}
+	return &metadata.Lease{Path: filePath, Holder: holder, ExpiresAt: now.Add(ttl)}, nil
+}
+
+// leaseTarget 解析请求的文件路径和持有者并检查写权限，失败时写入错误响应
+func (a *LeasesAPI) leaseTarget(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	filePath := api.ExtractPath(r)
+	if filePath == "" {
+		api.HandleAPIError(w, r, errors.New(errors.InvalidArgument, "无效的文件路径"))
+		return "", "", false
+	}
+	holder := r.Header.Get(LeaseHolderHeader)
+	if holder == "" {
+		api.HandleAPIError(w, r, errors.New(errors.InvalidArgument, "缺少租约持有者请求头"+LeaseHolderHeader))
+		return "", "", false
+	}
+	if err := checkAccess(r.Context(), a.access, filePath, acl.OpUpdate); err != nil {
+		api.HandleAPIError(w, r, err)
+		return "", "", false
+	}
+	return filePath, holder, true
+}
+
+// leaseTTL 解析请求体中的租约有效期，失败时写入错误响应
+func leaseTTL(w http.ResponseWriter, r *http.Request) (time.Duration, bool) {
+	var req LeaseRequest
+	if err := api.DecodeJSONBody(r, &req); err != nil {
+		api.HandleAPIError(w, r, err)
+		return 0, false
+	}
+	if req.TTLSeconds <= 0 {
+		api.HandleAPIError(w, r, errors.New(errors.InvalidArgument, "租约有效期必须为正数"))
+		return 0, false
+	}
+	return time.Duration(req.TTLSeconds) * time.Second, true
+}
+
+// leaseContext 返回携带请求租约持有者标识的context，存储据此检查移动和删除目录是否被租约阻止
+func leaseContext(r *http.Request) context.Context {
+	return metadata.WithLeaseHolder(r.Context(), r.Header.Get(LeaseHolderHeader))
+}
+
+// checkWriteLease 检查请求能否写入文件，存储不支持租约时总是允许
+func checkWriteLease(r *http.Request, leaser metadata.Leaser, filePath string) error {
+	if leaser == nil {
+		return nil
+	}
+	return leaser.CheckWriteLease(r.Context(), filePath, r.Header.Get(LeaseHolderHeader))
+}
@@ -0,0 +1,81 @@
+package v1
+
+import (
+	stderrors "errors"
+	"net/http"
+
+	"github.com/22827099/DFS_v1/common/errors"
+	nethttp "github.com/22827099/DFS_v1/common/network/http"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/database"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/namespace"
+	"github.com/22827099/DFS_v1/internal/metaserver/server/api"
+)
+
+// RestoreRequest 恢复请求，recursive仅对目录有效
+type RestoreRequest struct {
+	Path      string `json:"path"`
+	Recursive bool   `json:"recursive"`
+}
+
+// RestoreResponse 恢复结果，restored为恢复的条目数（含目标本身）
+type RestoreResponse struct {
+	Path     string `json:"path"`
+	Restored int    `json:"restored"`
+}
+
+// RestoreAPI 处理已删除条目的恢复请求
+type RestoreAPI struct {
+	restorer metadata.Restorer
+}
+
+// NewRestoreAPI 创建恢复API处理器
+func NewRestoreAPI(restorer metadata.Restorer) *RestoreAPI {
+	return &RestoreAPI{restorer: restorer}
+}
+
+// RegisterRoutes 注册恢复相关路由
+func (a *RestoreAPI) RegisterRoutes(router nethttp.RouteGroup) {
+	router.POST("/restore", a.Restore,
+		nethttp.WithSummary("恢复已删除的文件或目录"),
+		nethttp.WithRequestType(RestoreRequest{}),
+		nethttp.WithResponseType(RestoreResponse{}))
+}
+
+// Restore 清除条目的删除标记；没有删除记录时返回404，
+// 同名的未删除条目已存在或条目未被删除时返回409，父目录已删除时返回400
+func (a *RestoreAPI) Restore(w http.ResponseWriter, r *http.Request) {
+	var req RestoreRequest
+	if err := api.DecodeJSONBody(r, &req); err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+	if req.Path == "" {
+		api.HandleAPIError(w, r, errors.New(errors.InvalidArgument, "路径不能为空"))
+		return
+	}
+
+	restored, err := a.restorer.Restore(r.Context(), req.Path, req.Recursive)
+	if err != nil {
+		api.HandleAPIError(w, r, restoreError(err, req.Path))
+		return
+	}
+
+	api.RespondSuccess(w, r, http.StatusOK, RestoreResponse{Path: req.Path, Restored: restored})
+}
+
+// restoreError 将恢复失败的原因映射为对应的错误码
+func restoreError(err error, path string) error {
+	switch {
+	case stderrors.Is(err, namespace.ErrNoDeletedEntry):
+		return errors.Wrap(err, errors.NotFound, "没有可恢复的条目").WithField("path", path)
+	case stderrors.Is(err, database.ErrRestoreConflict):
+		return errors.Wrap(err, errors.AlreadyExists, "同名条目已存在").WithField("path", path)
+	case stderrors.Is(err, database.ErrNotDeleted):
+		return errors.Wrap(err, errors.AlreadyExists, "条目未被删除").WithField("path", path)
+	case stderrors.Is(err, database.ErrParentDeleted):
+		return errors.Wrap(err, errors.InvalidArgument, "父目录已删除，需要先恢复父目录").WithField("path", path)
+	default:
+		return err
+	}
+}
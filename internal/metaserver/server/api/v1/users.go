@@ -0,0 +1,98 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/22827099/DFS_v1/common/errors"
+	nethttp "github.com/22827099/DFS_v1/common/network/http"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/user"
+	"github.com/22827099/DFS_v1/internal/metaserver/server/api"
+	"github.com/gorilla/mux"
+)
+
+// CredentialsRequest 创建用户和登录的请求体
+type CredentialsRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// LoginResponse 登录成功的响应，token用于后续请求的Authorization: Bearer头
+type LoginResponse struct {
+	Token string     `json:"token"`
+	User  *user.User `json:"user"`
+}
+
+// UsersAPI 处理用户管理和登录请求
+type UsersAPI struct {
+	users *user.Service
+}
+
+// NewUsersAPI 创建用户API处理器
+func NewUsersAPI(users *user.Service) *UsersAPI {
+	return &UsersAPI{users: users}
+}
+
+// RegisterRoutes 注册用户相关路由，/admin/前缀的路由由认证中间件限制为管理员访问
+func (u *UsersAPI) RegisterRoutes(router nethttp.RouteGroup) {
+	router.POST("/auth/login", u.Login,
+		nethttp.WithSummary("登录并获取令牌"),
+		nethttp.WithRequestType(CredentialsRequest{}),
+		nethttp.WithResponseType(LoginResponse{}))
+	router.POST("/admin/users", u.CreateUser,
+		nethttp.WithSummary("创建用户"),
+		nethttp.WithRequestType(CredentialsRequest{}),
+		nethttp.WithResponseType(user.User{}),
+		nethttp.WithSuccessStatus(http.StatusCreated))
+	router.DELETE("/admin/users/{id}", u.DeleteUser,
+		nethttp.WithSummary("删除用户"),
+		nethttp.WithResponseType(map[string]string{}))
+}
+
+// Login 验证用户名和密码，签发令牌
+func (u *UsersAPI) Login(w http.ResponseWriter, r *http.Request) {
+	var req CredentialsRequest
+	if err := api.DecodeJSONBody(r, &req); err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+
+	token, info, err := u.users.Login(r.Context(), req.Username, req.Password)
+	if err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+	api.RespondSuccess(w, r, http.StatusOK, LoginResponse{Token: token, User: info})
+}
+
+// CreateUser 创建用户
+func (u *UsersAPI) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var req CredentialsRequest
+	if err := api.DecodeJSONBody(r, &req); err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+
+	created, err := u.users.Create(r.Context(), req.Username, req.Password)
+	if err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+	api.RespondSuccess(w, r, http.StatusCreated, created)
+}
+
+// DeleteUser 删除用户
+func (u *UsersAPI) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	userID, err := strconv.Atoi(id)
+	if err != nil {
+		api.HandleAPIError(w, r, errors.New(errors.InvalidArgument, "无效的用户ID").WithField("user_id", id))
+		return
+	}
+
+	if err := u.users.Delete(r.Context(), userID); err != nil {
+		api.HandleAPIError(w, r, err)
+		return
+	}
+	api.RespondSuccess(w, r, http.StatusOK, map[string]interface{}{"deleted": userID})
+}
@@ -2,13 +2,18 @@ package middleware
 
 import (
     "net/http"
+    "strings"
     "time"
     
     nethttp "github.com/22827099/DFS_v1/common/network/http"
     "github.com/22827099/DFS_v1/common/metrics"
+    "github.com/gorilla/mux"
 )
 
-// Metrics 创建指标收集中间件
+// UnmatchedRoute 未匹配到路由的请求使用的路由标签
+const UnmatchedRoute = "unmatched"
+
+// Metrics 创建指标收集中间件，按路由模板（如/api/v1/files/{path}）而非原始路径记录请求
 func Metrics(metricsCollector metrics.Collector) nethttp.Middleware {
     return func(next http.Handler) http.Handler {
         return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -27,10 +32,47 @@ func Metrics(metricsCollector metrics.Collector) nethttp.Middleware {
             duration := time.Since(start)
             metricsCollector.RecordHTTPRequest(
                 r.Method,
-                r.URL.Path,
+                routeTemplate(r),
                 recorder.statusCode,
                 duration.Milliseconds(),
             )
         })
     }
-}
\ No newline at end of file
+}
+
+// routeTemplate 返回请求匹配的路由模板，去掉变量中的正则部分：{path:.*}记为{path}
+func routeTemplate(r *http.Request) string {
+    route := mux.CurrentRoute(r)
+    if route == nil {
+        return UnmatchedRoute
+    }
+    tpl, err := route.GetPathTemplate()
+    if err != nil {
+        return UnmatchedRoute
+    }
+    
+    var b strings.Builder
+    depth := 0
+    skipping := false
+    for _, c := range tpl {
+        switch {
+        case c == '{':
+            depth++
+            if depth == 1 {
+                skipping = false
+            }
+        case c == '}':
+            depth--
+            if depth == 0 {
+                skipping = false
+            }
+        case c == ':' && depth == 1:
+            skipping = true
+        }
+        if skipping && depth > 0 {
+            continue
+        }
+        b.WriteRune(c)
+    }
+    return b.String()
+}
@@ -82,4 +82,9 @@ type responseRecorder struct {
 func (r *responseRecorder) WriteHeader(statusCode int) {
     r.statusCode = statusCode
     r.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Unwrap 返回被包装的ResponseWriter，使http.ResponseController能够访问Flush等能力
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+    return r.ResponseWriter
 }
\ No newline at end of file
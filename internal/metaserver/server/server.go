@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"os"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/22827099/DFS_v1/common/config"
@@ -15,8 +17,13 @@ import (
 	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
 	"github.com/22827099/DFS_v1/internal/metaserver/core"
 	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/election"
 	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+	"github.com/22827099/DFS_v1/internal/metaserver/server/api"
 	"github.com/22827099/DFS_v1/internal/metaserver/server/api/v1"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/events"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/placement"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/user"
 	"github.com/22827099/DFS_v1/internal/metaserver/server/middleware"
 )
 
@@ -33,6 +40,12 @@ type MetadataServer struct {
     metaCore         *core.MetaCore       // 添加这个字段
 	authService      middleware.AuthService       // 添加认证服务
     txManager        middleware.TransactionManager // 添加事务管理器
+	readiness        *nethttp.ReadinessGate        // 就绪前对业务请求返回503
+	metaConfig       *metaconfig.Config            // 当前生效的元数据服务器配置，重载时更新
+	users            *user.Service                 // 用户管理和登录，为nil时不注册相关路由
+	restorer         metadata.Restorer             // 恢复已删除条目，为nil时使用实现了该接口的元数据存储
+	events           *events.Log                   // 元数据变更事件日志，供外部索引系统消费
+	certReloader     *nethttp.CertReloader         // 启用TLS时加载证书，证书文件更新后自动重新加载
 }
 
 // ServerOption 允许配置服务器的选项函数
@@ -52,7 +65,9 @@ func NewServer(cfg *config.SystemConfig, options ...ServerOption) (*MetadataServ
     logger := logging.NewLogger()
     
     // 初始化 HTTP 服务器
-	httpServer := nethttp.NewServer(fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port))
+	// 去掉尾部斜杠后再路由，与存储层的path.Clean规范化保持一致
+	httpServer := nethttp.NewServer(fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
+		nethttp.WithTrailingSlash(nethttp.TrailingSlashStrip))
 
 	// 初始化认证服务 TODO: #2 添加认证服务
 	// authService := middleware.Auth(/* 必要参数 */)
@@ -61,20 +76,17 @@ func NewServer(cfg *config.SystemConfig, options ...ServerOption) (*MetadataServ
     
     // 转换为元数据服务器配置
     metaCfg := &metaconfig.Config{
-		//使用metaserver/config/config.go中的NodeID
-		NodeID: cfg.NodeID,
 		Database: metaconfig.DatabaseConfig{},
+		// 未通过WithClusterConfig指定集群配置时以单节点集群运行
 		Cluster:  metaconfig.ClusterConfig{
-			NodeID: cfg.NodeID,
-			Peers: cfg.Cluster.Peers,
-            ElectionTimeout: cfg.Cluster.ElectionTimeout,
-            HeartbeatTimeout: cfg.Cluster.HeartbeatTimeout,
+			NodeID:    string(cfg.NodeID),
+			Bootstrap: true,
 		},
     }
     // 在创建元数据核心前
 	logger.Info("准备创建MetaCore", 
 	"nodeID", cfg.NodeID, 
-	"metaCfg.NodeID", metaCfg.NodeID)
+	"metaCfg.Cluster.NodeID", metaCfg.Cluster.NodeID)
 
 
     // 初始化元数据核心
@@ -92,8 +104,12 @@ func NewServer(cfg *config.SystemConfig, options ...ServerOption) (*MetadataServ
         logger:           logger,
         httpServer:       httpServer,
         metaCore:         metaCore,
+        metaConfig:       metaCfg,
         metricsCollector: metricsCollector,
         running:          false,
+        // 健康检查、就绪探针以及集群内部通信在就绪前也必须可用
+        readiness: nethttp.NewReadinessGate("/health", "/ready", "/openapi.json",
+            "/api/v1/heartbeat", cluster.MembershipPath, cluster.JoinPath),
 		// authService:      authService,  // 注释掉
         // txManager:        txManager,    // 注释掉
     }
@@ -118,13 +134,68 @@ func NewServer(cfg *config.SystemConfig, options ...ServerOption) (*MetadataServ
 
 	// 如果没有提供集群管理器，创建默认的
 	if server.cluster == nil {
-		clusterMgr, err := cluster.NewManager(metaCfg.Cluster, logger)
+		clusterOpts := []cluster.ManagerOption{cluster.WithConsensusConfig(metaCfg.Consensus),
+			cluster.WithCommandApplier(v1.NewFileCommandApplier(server.metaStore))}
+		// 能序列化的存储注册为状态机快照，日志超过consensus.snapshot_threshold条时创建快照并压缩
+		if snapshotter, ok := server.metaStore.(election.StateSnapshotter); ok {
+			clusterOpts = append(clusterOpts, cluster.WithStateSnapshotter(snapshotter))
+		}
+		clusterMgr, err := cluster.NewManager(metaCfg.Cluster, logger, clusterOpts...)
 		if err != nil {
 			return nil, errors.Wrap(err, errors.Internal, "初始化集群管理器失败")
 		}
 		server.cluster = clusterMgr
 	}
 
+	// 内存存储登记文件的数据块时按配置的放置策略选择副本节点
+	if store, ok := server.metaStore.(*MemoryStore); ok {
+		server.metaConfig.Placement.ApplyDefaults()
+		strategy, err := placement.New(server.metaConfig.Placement)
+		if err != nil {
+			return nil, err
+		}
+		store.SetPlacer(placement.NewPlacer(strategy, server.cluster.ListNodes, server.metaConfig.Placement.DefaultReplicas))
+	}
+
+	// 内存存储的每次修改生效后追加变更事件，并按配置推断新文件的MIME类型
+	if server.events == nil {
+		server.events = events.NewLog(events.DefaultCapacity)
+	}
+	if store, ok := server.metaStore.(*MemoryStore); ok {
+		store.SetEventLog(server.events)
+		store.SetMimeInference(server.metaConfig.Files.InferMimeType)
+		store.SetMaxListEntries(server.metaConfig.Files.MaxListEntries)
+	}
+
+	// 启用TLS时由证书加载器提供证书，轮换证书无需重启
+	if security := server.metaConfig.Security; security.EnableTLS {
+		reloader, err := nethttp.NewCertReloader(security.CertFile, security.KeyFile, logger)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.InvalidArgument, "加载TLS证书失败")
+		}
+		nethttp.WithTLS(reloader)(httpServer)
+		server.certReloader = reloader
+	}
+
+	// 为所有响应注入安全响应头，处理器显式设置的头优先
+	if security := server.metaConfig.Security; !security.DisableSecurityHeaders {
+		headers := nethttp.DefaultSecurityHeadersConfig(security.SecurityHeaders)
+		if security.HSTSMaxAge > 0 {
+			headers.HSTSMaxAge = security.HSTSMaxAge
+		}
+		nethttp.WithSecurityHeaders(headers)(httpServer)
+	}
+
+	// 集群选出领导者前业务请求无法正确路由，默认将其作为就绪条件
+	if cfg.Server.RequireLeader {
+		server.readiness.AddCheck("cluster_leader", func() error {
+			if server.cluster.GetCurrentLeader() == "" {
+				return fmt.Errorf("集群尚未选出领导者")
+			}
+			return nil
+		})
+	}
+
 	// 添加中间件
 	httpServer.Use(nethttp.RequestIDMiddleware())
 	httpServer.Use(nethttp.LoggingMiddleware(logger))
@@ -151,7 +222,69 @@ func WithClusterManager(manager cluster.Manager) ServerOption {
 	}
 }
 
+// WithUserService 设置用户服务，同时作为认证中间件的令牌验证服务
+func WithUserService(users *user.Service) ServerOption {
+	return func(s *MetadataServer) {
+		s.users = users
+		s.authService = users
+	}
+}
+
+// WithClusterConfig 设置集群配置，未指定节点ID时沿用系统配置中的节点ID
+func WithClusterConfig(cfg metaconfig.ClusterConfig) ServerOption {
+	return func(s *MetadataServer) {
+		if cfg.NodeID == "" {
+			cfg.NodeID = s.metaConfig.Cluster.NodeID
+		}
+		s.metaConfig.Cluster = cfg
+	}
+}
+
+// WithPlacementConfig 设置数据块放置策略配置，未设置时使用轮询策略
+func WithPlacementConfig(cfg metaconfig.PlacementConfig) ServerOption {
+	return func(s *MetadataServer) {
+		s.metaConfig.Placement = cfg
+	}
+}
+
+// WithFilesConfig 设置文件元数据配置，如创建文件时是否推断MIME类型
+func WithFilesConfig(cfg metaconfig.FilesConfig) ServerOption {
+	return func(s *MetadataServer) {
+		s.metaConfig.Files = cfg
+	}
+}
+
+// WithSecurityConfig 设置安全配置，启用TLS时使用其中的证书和私钥文件
+func WithSecurityConfig(cfg metaconfig.SecurityConfig) ServerOption {
+	return func(s *MetadataServer) {
+		s.metaConfig.Security = cfg
+	}
+}
+
+// WithRestorer 设置恢复已删除条目的实现，如基于数据库的元数据管理器
+func WithRestorer(restorer metadata.Restorer) ServerOption {
+	return func(s *MetadataServer) {
+		s.restorer = restorer
+	}
+}
+
+// WithEventLog 设置元数据变更事件日志，未设置时创建保留最近events.DefaultCapacity条事件的日志
+func WithEventLog(log *events.Log) ServerOption {
+	return func(s *MetadataServer) {
+		s.events = log
+	}
+}
+
+// WithReadinessCheck 添加额外的就绪条件，所有条件满足前业务请求返回503
+func WithReadinessCheck(name string, check nethttp.ReadinessCheck) ServerOption {
+	return func(s *MetadataServer) {
+		s.readiness.AddCheck(name, check)
+	}
+}
+
 // Start 启动服务器
+// 按依赖顺序启动：元数据存储 -> 集群服务 -> HTTP监听；
+// HTTP监听启动后立即可用，但在所有就绪条件满足前业务请求返回503
 func (s *MetadataServer) Start() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -170,6 +303,10 @@ func (s *MetadataServer) Start() error {
 		return errors.Wrap(err, errors.Internal, "启动集群服务失败")
 	}
 
+	if s.certReloader != nil {
+		s.certReloader.Watch(s.metaConfig.Security.CertReloadInterval)
+	}
+
 	// 启动HTTP服务器
 	go func() {
 		if err := s.httpServer.Start(); err != nil && err != http.ErrServerClosed {
@@ -177,6 +314,7 @@ func (s *MetadataServer) Start() error {
 		}
 	}()
 
+	s.readiness.SetStarted(true)
 	s.running = true
 	s.logger.Info("元数据服务器启动成功")
 
@@ -192,22 +330,32 @@ func (s *MetadataServer) Stop() error {
 		return nil
 	}
 
+	// 先退出就绪状态，关闭期间的新请求返回503
+	s.readiness.SetStarted(false)
+
 	// 创建超时上下文
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	// 先结束事件流订阅，否则长连接会使HTTP服务器等到超时才能关闭
+	s.events.Close()
+
 	// 停止HTTP服务器
-	if err := s.httpServer.Shutdown(ctx); err != nil {
+	if err := s.httpServer.Stop(ctx); err != nil {
 		s.logger.Error("HTTP服务器关闭失败: %v", err)
 	}
 
+	if s.certReloader != nil {
+		s.certReloader.Stop()
+	}
+
 	// 停止集群服务
 	if err := s.cluster.Stop(ctx); err != nil {
 		s.logger.Error("集群服务关闭失败: %v", err)
 	}
 
 	// 关闭元数据存储
-	if err := s.metaStore.Close(); err != nil {
+	if err := s.metaStore.Close(ctx); err != nil {
 		s.logger.Error("元数据存储关闭失败: %v", err)
 	}
 
@@ -217,6 +365,46 @@ func (s *MetadataServer) Stop() error {
 	return nil
 }
 
+// ReloadConfig 重新读取配置文件，将可安全重载的配置项应用到运行中的组件
+// 日志级别、负载均衡阈值和心跳超时立即生效，其余变更只记录日志，需要重启才能生效
+func (s *MetadataServer) ReloadConfig(path string) (metaconfig.ReloadResult, error) {
+	next, err := metaconfig.LoadMetaServerConfig(path)
+	if err != nil {
+		return metaconfig.ReloadResult{}, errors.Wrap(err, errors.InvalidArgument, "重新加载配置失败")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := metaconfig.Reload(s.metaConfig, next, s.cluster)
+	if !result.Changed() {
+		s.logger.Info("配置未变更", "path", path)
+		return result, nil
+	}
+	if len(result.Applied) > 0 {
+		s.logger.Info("配置已重载", "applied", result.Applied)
+	}
+	if len(result.RestartRequired) > 0 {
+		s.logger.Warn("部分配置变更需要重启才能生效", "restart_required", result.RestartRequired)
+	}
+	return result, nil
+}
+
+// HandleSignals 处理进程信号直到收到其他信号：SIGHUP时调用ReloadConfig重新读取configPath，
+// 重载失败只记录日志，服务器继续使用当前配置。返回使服务器应当退出的信号，signals关闭时返回nil
+func (s *MetadataServer) HandleSignals(signals <-chan os.Signal, configPath string) os.Signal {
+	for sig := range signals {
+		if sig != syscall.SIGHUP {
+			return sig
+		}
+		s.logger.Info("收到SIGHUP，重新加载配置", "path", configPath)
+		if _, err := s.ReloadConfig(configPath); err != nil {
+			s.logger.Error("重新加载配置失败，继续使用当前配置", "error", err)
+		}
+	}
+	return nil
+}
+
 // IsRunning 检查服务器是否正在运行
 func (s *MetadataServer) IsRunning() bool {
 	s.mu.RLock()
@@ -230,26 +418,88 @@ func (s *MetadataServer) setupRoutes(httpServer *nethttp.Server) {
     httpServer.Use(nethttp.RequestIDMiddleware())
     httpServer.Use(nethttp.LoggingMiddleware(s.logger))
     httpServer.Use(nethttp.RecoveryMiddleware(s.logger))
+    // 大目录列表等响应按客户端的Accept-Encoding压缩
+    httpServer.Use(nethttp.CompressionMiddleware(nethttp.DefaultCompressionThreshold))
+    httpServer.Use(s.readiness.Middleware())
+    // 按客户端声明的等待时间限制处理器上下文，不超过服务器写超时
+    httpServer.Use(nethttp.DeadlineMiddleware(s.config.Server.WriteTimeout))
     httpServer.Use(middleware.Metrics(s.metricsCollector))
-    httpServer.Use(middleware.RateLimit(100, 1*time.Second))
+    // 按客户端IP的令牌桶限流，每秒补充100个令牌，允许100个请求的突发
+    httpServer.Use(nethttp.RateLimitMiddleware(100, 100))
     
     // 为需要认证的路由组添加认证中间件
     apiRouter := httpServer.Group("/api/v1")
-    apiRouter.Use(middleware.Auth(s.authService))
-    apiRouter.Use(middleware.Transaction(s.txManager))
+    // 未配置认证服务或事务管理器时不启用对应中间件
+    if s.authService != nil {
+        apiRouter.Use(middleware.Auth(s.authService))
+    }
+    if s.txManager != nil {
+        apiRouter.Use(middleware.Transaction(s.txManager))
+    }
     
     // 创建并注册API处理器
-    filesAPI := v1.NewFilesAPI(s.metaStore)
-    dirsAPI := v1.NewDirectoriesAPI(s.metaStore)
+    // 文件写操作、目录的创建删除和批量移动经集群多数派确认后由各节点的状态机应用，确认超时返回504；
+    // 指定consistency=linearizable的读取先等待本节点追上已提交的写入
+    filesOpts := []v1.FilesOption{v1.WithWriteConfirmer(s.cluster), v1.WithFileReadBarrier(s.cluster)}
+    dirsOpts := []v1.DirectoriesOption{v1.WithDirectoryWriteConfirmer(s.cluster), v1.WithDirectoryReadBarrier(s.cluster)}
+    // 启用访问控制时文件和目录操作按元数据库中的目录权限检查，权限不足返回403
+    if s.metaConfig.Security.EnableACL && s.metaCore != nil {
+        filesOpts = append(filesOpts, v1.WithFileAccessChecker(s.metaCore))
+        dirsOpts = append(dirsOpts, v1.WithDirectoryAccessChecker(s.metaCore))
+    }
+    filesAPI := v1.NewFilesAPI(s.metaStore, filesOpts...)
+    dirsAPI := v1.NewDirectoriesAPI(s.metaStore, dirsOpts...)
     clusterAPI := v1.NewClusterAPI(s.cluster)
-    adminAPI := v1.NewAdminAPI(s.config, s.cluster)
+    adminAPI := v1.NewAdminAPI(s.config, s.cluster, httpServer, s.metricsCollector)
     
     // 注册路由
 	filesAPI.RegisterRoutes(apiRouter)
 	dirsAPI.RegisterRoutes(apiRouter)
 	clusterAPI.RegisterRoutes(apiRouter)
 	adminAPI.RegisterRoutes(apiRouter)
+	if s.users != nil {
+		v1.NewUsersAPI(s.users).RegisterRoutes(apiRouter)
+	}
+	// 只有支持软删除的存储才能恢复，否则不注册恢复路由
+	restorer := s.restorer
+	if restorer == nil {
+		restorer, _ = s.metaStore.(metadata.Restorer)
+	}
+	if restorer != nil {
+		v1.NewRestoreAPI(restorer).RegisterRoutes(apiRouter)
+	}
+	v1.NewEventsAPI(s.events).RegisterRoutes(apiRouter)
+	if mover, ok := s.metaStore.(metadata.Mover); ok {
+		v1.NewBatchAPI(mover, v1.WithBatchWriteConfirmer(s.cluster)).RegisterRoutes(apiRouter)
+	}
+	// 写租约命令和文件写操作一样经集群多数派确认后由每个节点应用，任一节点都能检查其他节点上获取的租约
+	if leaser, ok := s.metaStore.(metadata.Leaser); ok {
+		leaseOpts := []v1.LeasesOption{v1.WithLeaseWriteConfirmer(s.cluster)}
+		if s.metaConfig.Security.EnableACL && s.metaCore != nil {
+			leaseOpts = append(leaseOpts, v1.WithLeaseAccessChecker(s.metaCore))
+		}
+		v1.NewLeasesAPI(leaser, leaseOpts...).RegisterRoutes(apiRouter)
+	}
+	// 诊断接口会暴露内部状态，默认不注册
+	if s.metaConfig.Security.EnableDebugDump {
+		v1.NewDebugAPI(s.cluster).RegisterRoutes(apiRouter)
+	}
     
+    // 节点之间的Raft消息不属于API，在公开路由和中间件之外分发，由传输层校验集群密钥
+    if handler := s.cluster.RaftHandler(); handler != nil {
+        httpServer.HandleInternal(cluster.RaftPath, handler)
+    }
+
     // 公开的健康检查端点
-    httpServer.GET("/health", adminAPI.HealthCheck)
+    httpServer.GET("/health", adminAPI.HealthCheck,
+        nethttp.WithSummary("健康检查"),
+        nethttp.WithResponseType(map[string]interface{}{}))
+    httpServer.GET("/ready", s.readiness.Handler(),
+        nethttp.WithSummary("就绪探针"),
+        nethttp.WithResponseType(map[string]bool{}),
+        nethttp.WithoutResponseEnvelope())
+
+    // API描述文档，根据上面注册的路由元数据生成；API处理器的响应数据包装在api.Response中
+    httpServer.GET("/openapi.json", httpServer.OpenAPIHandler("DFS MetaServer API", "v1",
+        nethttp.WithResponseEnvelope(api.Response{}, "data")))
 }
@@ -2,21 +2,36 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"path"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/22827099/DFS_v1/common/errors"
+	"github.com/22827099/DFS_v1/common/types"
 	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/events"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/placement"
 )
 
+// DefaultMaxListEntries 单次列出目录返回的最大条目数，请求不限制数量时同样生效
+const DefaultMaxListEntries = 10000
+
 // MemoryStore 是一个基于内存的元数据存储实现
 type MemoryStore struct {
 	mu          sync.RWMutex
 	files       map[string]*metadata.FileInfo
 	directories map[string]*metadata.DirectoryInfo
+	childCounts map[string]int // 目录路径（带尾部斜杠）-> 直接子项数，判断目录是否为空时无需扫描
 	initialized bool
+	closed      bool                       // Close后置位，之后所有操作返回Unavailable，存储不可重新初始化
+	placer      *placement.Placer          // 为新文件的数据块选择副本节点，为nil时保留请求中的放置信息
+	events      *events.Log                // 元数据变更事件日志，为nil时不记录事件
+	inferMime   bool                       // 创建文件时未提供MIME类型则按扩展名推断
+	maxList     int                        // 单次列出目录的条目上限
+	leases      map[string]*metadata.Lease // 文件路径 -> 写租约，过期的租约在下次获取租约时清理
 }
 
 // NewMemoryStore 创建一个新的内存元数据存储
@@ -24,56 +39,122 @@ func NewMemoryStore() (*MemoryStore, error) {
 	return &MemoryStore{
 		files:       make(map[string]*metadata.FileInfo),
 		directories: make(map[string]*metadata.DirectoryInfo),
+		childCounts: make(map[string]int),
+		leases:      make(map[string]*metadata.Lease),
 		initialized: false,
+		maxList:     DefaultMaxListEntries,
 	}, nil
 }
 
+// SetPlacer 设置数据块放置器，创建文件时为未指定副本节点的数据块按策略选择节点
+func (s *MemoryStore) SetPlacer(placer *placement.Placer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.placer = placer
+}
+
+// SetEventLog 设置元数据变更事件日志，之后每次成功的创建、更新、删除和移动都会追加一条事件
+func (s *MemoryStore) SetEventLog(log *events.Log) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = log
+}
+
+// SetMimeInference 设置创建文件时是否根据扩展名推断未提供的MIME类型，请求中显式提供的类型总是优先
+func (s *MemoryStore) SetMimeInference(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inferMime = enabled
+}
+
+// SetMaxListEntries 设置单次列出目录返回的最大条目数，limit不大于0（不限制）或超过该值的请求都被截断到该值；
+// n不大于0时恢复默认值
+func (s *MemoryStore) SetMaxListEntries(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n <= 0 {
+		n = DefaultMaxListEntries
+	}
+	s.maxList = n
+}
+
+// emitLocked 在修改已生效后追加变更事件，调用方需持有写锁。
+// 持锁追加保证事件顺序与修改的生效顺序一致
+func (s *MemoryStore) emitLocked(event events.Event) {
+	if s.events != nil {
+		s.events.Append(event)
+	}
+}
+
 // Initialize 初始化存储
 func (s *MemoryStore) Initialize() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.closed {
+		return errors.New(errors.Unavailable, "存储已关闭")
+	}
 	if s.initialized {
 		return errors.New(errors.AlreadyExists, "存储已经初始化")
 	}
 
 	// 创建根目录
+	now := time.Now()
 	rootDir := &metadata.DirectoryInfo{
-		Path:      "/",
-		Name:      "/",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		BasicFileInfo: types.BasicFileInfo{
+			Path:      "/",
+			Name:      "/",
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
 	}
 	s.directories["/"] = rootDir
+	s.childCounts["/"] = 0
 
 	s.initialized = true
 	return nil
 }
 
-// Close 关闭存储
-func (s *MemoryStore) Close() error {
+// Close 关闭存储，可重复调用，之后的调用直接返回nil。
+// 关闭时先将未落盘的状态写入底层存储，再释放持有的资源；内存存储没有需要落盘的状态，只释放数据。
+// ctx用于限制落盘耗时，关闭后所有操作都返回Unavailable错误，未初始化的存储同样可以关闭
+func (s *MemoryStore) Close(ctx context.Context) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if !s.initialized {
+	if s.closed {
 		return nil
 	}
+	s.closed = true
 
-	// 清空所有数据
-	s.files = make(map[string]*metadata.FileInfo)
-	s.directories = make(map[string]*metadata.DirectoryInfo)
+	// 释放所有数据
+	s.files = nil
+	s.directories = nil
+	s.childCounts = nil
+	s.leases = nil
 	s.initialized = false
 
 	return nil
 }
 
+// checkOpenLocked 检查存储已初始化且未关闭，调用方需持有读锁或写锁
+func (s *MemoryStore) checkOpenLocked() error {
+	if s.closed {
+		return errors.New(errors.Unavailable, "存储已关闭")
+	}
+	if !s.initialized {
+		return errors.New(errors.Internal, "存储未初始化")
+	}
+	return nil
+}
+
 // GetFileInfo 获取文件信息
 func (s *MemoryStore) GetFileInfo(ctx context.Context, filePath string) (*metadata.FileInfo, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	if !s.initialized {
-		return nil, errors.New(errors.Internal, "存储未初始化")
+	if err := s.checkOpenLocked(); err != nil {
+		return nil, err
 	}
 
 	// 规范化路径
@@ -90,11 +171,15 @@ func (s *MemoryStore) GetFileInfo(ctx context.Context, filePath string) (*metada
 
 // CreateFile 创建新文件
 func (s *MemoryStore) CreateFile(ctx context.Context, fileInfo metadata.FileInfo) (*metadata.FileInfo, error) {
+	if err := s.placeChunks(ctx, &fileInfo); err != nil {
+		return nil, err
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if !s.initialized {
-		return nil, errors.New(errors.Internal, "存储未初始化")
+	if err := s.checkOpenLocked(); err != nil {
+		return nil, err
 	}
 
 	// 规范化路径
@@ -107,13 +192,80 @@ func (s *MemoryStore) CreateFile(ctx context.Context, fileInfo metadata.FileInfo
 	}
 
 	// 检查父目录是否存在
-	parentDir := path.Dir(filePath)
-	if parentDir != "/" {
-		if _, exists := s.directories[parentDir]; !exists {
-			return nil, errors.New(errors.NotFound, "父目录不存在")
+	if _, exists := s.directories[dirKey(path.Dir(filePath))]; !exists {
+		return nil, errors.New(errors.NotFound, "父目录不存在")
+	}
+
+	return s.insertFileLocked(fileInfo), nil
+}
+
+// CreateFileWithParents 创建文件，缺失的祖先目录在同一把锁内一并创建
+func (s *MemoryStore) CreateFileWithParents(ctx context.Context, fileInfo metadata.FileInfo) (*metadata.FileInfo, error) {
+	if err := s.placeChunks(ctx, &fileInfo); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkOpenLocked(); err != nil {
+		return nil, err
+	}
+
+	filePath := path.Clean(fileInfo.Path)
+	fileInfo.Path = filePath
+
+	if _, exists := s.files[filePath]; exists {
+		return nil, errors.New(errors.AlreadyExists, "文件已存在")
+	}
+	if _, exists := s.directories[dirKey(filePath)]; exists {
+		return nil, errors.New(errors.AlreadyExists, "同名目录已存在: %s", filePath)
+	}
+
+	if err := s.ensureParentsLocked(path.Dir(filePath)); err != nil {
+		return nil, err
+	}
+
+	return s.insertFileLocked(fileInfo), nil
+}
+
+// placeChunks 为未指定副本节点的数据块选择节点，第一个节点作为主副本；可用节点少于副本数时
+// 放在全部可用节点上，并在MissingReplicas中记录每个块缺少的副本数。
+// 在获取存储锁之前调用，避免持锁查询集群节点
+func (s *MemoryStore) placeChunks(ctx context.Context, fileInfo *metadata.FileInfo) error {
+	s.mu.RLock()
+	placer := s.placer
+	s.mu.RUnlock()
+	if placer == nil || len(fileInfo.Chunks) == 0 {
+		return nil
+	}
+
+	placed, err := placer.PlaceChunks(ctx, path.Clean(fileInfo.Path), len(fileInfo.Chunks), fileInfo.Replicas)
+	if err != nil {
+		return err
+	}
+
+	// 复制块切片，不修改调用方的数据
+	chunks := make([]metadata.ChunkInfo, len(fileInfo.Chunks))
+	copy(chunks, fileInfo.Chunks)
+	for i := range chunks {
+		if len(chunks[i].Replicas) > 0 {
+			continue
 		}
+		chunks[i].Replicas = placed.Chunks[i]
+		chunks[i].NodeID = placed.Chunks[i][0]
+		// 可用节点不足时先放在已有节点上，记录缺少的副本数
+		fileInfo.MissingReplicas = placed.Shortfall()
+	}
+	fileInfo.Chunks = chunks
+	if fileInfo.Replicas == 0 {
+		fileInfo.Replicas = placed.Replicas
 	}
+	return nil
+}
 
+// insertFileLocked 保存已通过检查的文件并更新父目录计数，调用方需持有写锁
+func (s *MemoryStore) insertFileLocked(fileInfo metadata.FileInfo) *metadata.FileInfo {
 	// 设置创建和更新时间
 	now := time.Now()
 	fileInfo.CreatedAt = now
@@ -121,14 +273,26 @@ func (s *MemoryStore) CreateFile(ctx context.Context, fileInfo metadata.FileInfo
 
 	// 如果没有设置名称，使用路径中的名称
 	if fileInfo.Name == "" {
-		fileInfo.Name = path.Base(filePath)
+		fileInfo.Name = path.Base(fileInfo.Path)
+	}
+
+	// 未提供MIME类型时按扩展名推断
+	if fileInfo.MimeType == "" && s.inferMime {
+		fileInfo.MimeType = metadata.InferMimeType(fileInfo.Name)
+	}
+
+	// 未提供校验和时根据块信息计算
+	if fileInfo.Checksum == "" {
+		fileInfo.Checksum = metadata.ComputeFileChecksum(fileInfo.Chunks)
 	}
 
 	// 存储文件信息的副本
-	s.files[filePath] = cloneFileInfo(&fileInfo)
+	s.files[fileInfo.Path] = cloneFileInfo(&fileInfo)
+	s.childCounts[dirKey(path.Dir(fileInfo.Path))]++
+	s.emitLocked(events.Event{Type: events.Create, Path: fileInfo.Path})
 
 	// 返回文件信息的副本
-	return cloneFileInfo(s.files[filePath]), nil
+	return cloneFileInfo(s.files[fileInfo.Path])
 }
 
 // UpdateFile 更新文件信息
@@ -136,8 +300,8 @@ func (s *MemoryStore) UpdateFile(ctx context.Context, filePath string, updates m
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if !s.initialized {
-		return nil, errors.New(errors.Internal, "存储未初始化")
+	if err := s.checkOpenLocked(); err != nil {
+		return nil, err
 	}
 
 	// 规范化路径
@@ -159,6 +323,7 @@ func (s *MemoryStore) UpdateFile(ctx context.Context, filePath string, updates m
 		case "chunks":
 			if chunks, ok := value.([]metadata.ChunkInfo); ok {
 				file.Chunks = chunks
+				file.Checksum = metadata.ComputeFileChecksum(chunks)
 			}
 		case "mime_type":
 			if mimeType, ok := value.(string); ok {
@@ -173,6 +338,7 @@ func (s *MemoryStore) UpdateFile(ctx context.Context, filePath string, updates m
 
 	// 更新修改时间
 	file.UpdatedAt = time.Now()
+	s.emitLocked(events.Event{Type: events.Update, Path: filePath})
 
 	// 返回文件信息的副本
 	return cloneFileInfo(file), nil
@@ -183,8 +349,8 @@ func (s *MemoryStore) DeleteFile(ctx context.Context, filePath string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if !s.initialized {
-		return errors.New(errors.Internal, "存储未初始化")
+	if err := s.checkOpenLocked(); err != nil {
+		return err
 	}
 
 	// 规范化路径
@@ -195,19 +361,33 @@ func (s *MemoryStore) DeleteFile(ctx context.Context, filePath string) error {
 		return errors.New(errors.NotFound, "文件不存在")
 	}
 
-	// 删除文件
+	// 删除文件，文件的写租约随之失效
 	delete(s.files, filePath)
+	delete(s.leases, filePath)
+	s.childCounts[dirKey(path.Dir(filePath))]--
+	s.emitLocked(events.Event{Type: events.Delete, Path: filePath})
 
 	return nil
 }
 
-// ListDirectory 列出目录内容
+// ListDirectory 列出目录内容，limit不大于0时最多返回SetMaxListEntries设置的条目数
 func (s *MemoryStore) ListDirectory(ctx context.Context, dirPath string, recursive bool, limit int) ([]metadata.DirectoryEntry, error) {
+	entries, _, err := s.ListDirectoryTruncated(ctx, dirPath, recursive, limit)
+	return entries, err
+}
+
+// ListDirectoryTruncated 列出目录内容，并报告是否还有条目因limit或条目上限未返回
+func (s *MemoryStore) ListDirectoryTruncated(ctx context.Context, dirPath string, recursive bool, limit int) ([]metadata.DirectoryEntry, bool, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	if !s.initialized {
-		return nil, errors.New(errors.Internal, "存储未初始化")
+	if err := s.checkOpenLocked(); err != nil {
+		return nil, false, err
+	}
+
+	// 不限制数量的请求同样受条目上限约束，防止一次响应返回整个存储
+	if limit <= 0 || limit > s.maxList {
+		limit = s.maxList
 	}
 
 	// 规范化路径
@@ -218,15 +398,16 @@ func (s *MemoryStore) ListDirectory(ctx context.Context, dirPath string, recursi
 
 	// 检查目录是否存在
 	if _, exists := s.directories[dirPath]; !exists && dirPath != "/" {
-		return nil, errors.New(errors.NotFound, "目录不存在")
+		return nil, false, errors.New(errors.NotFound, "目录不存在")
 	}
 
 	var entries []metadata.DirectoryEntry
 	count := 0
+	truncated := false
 
 	// 添加子目录
 	for path, dir := range s.directories {
-		if count >= limit && limit > 0 {
+		if truncated {
 			break
 		}
 
@@ -245,6 +426,11 @@ func (s *MemoryStore) ListDirectory(ctx context.Context, dirPath string, recursi
 			if !recursive && path != dirPath && strings.Count(path[len(dirPath):], "/") > 1 {
 				continue
 			}
+			// 达到上限后又找到一个匹配项，说明结果被截断
+			if count >= limit {
+				truncated = true
+				break
+			}
 
 			entry := metadata.DirectoryEntry{
 				Name:       dir.Name,
@@ -253,7 +439,7 @@ func (s *MemoryStore) ListDirectory(ctx context.Context, dirPath string, recursi
 				Size:       0,
 				CreatedAt:  dir.CreatedAt,
 				UpdatedAt:  dir.UpdatedAt,
-				ChildCount: countChildren(s, dir.Path),
+				ChildCount: s.childCounts[dir.Path],
 			}
 			entries = append(entries, entry)
 			count++
@@ -262,7 +448,7 @@ func (s *MemoryStore) ListDirectory(ctx context.Context, dirPath string, recursi
 
 	// 添加文件
 	for filePath, file := range s.files {
-		if count >= limit && limit > 0 {
+		if truncated {
 			break
 		}
 
@@ -272,6 +458,10 @@ func (s *MemoryStore) ListDirectory(ctx context.Context, dirPath string, recursi
 		}
 
 		if parentDir == dirPath || (recursive && strings.HasPrefix(parentDir, dirPath)) {
+			if count >= limit {
+				truncated = true
+				break
+			}
 			entry := metadata.DirectoryEntry{
 				Name:      file.Name,
 				Path:      file.Path,
@@ -286,7 +476,23 @@ func (s *MemoryStore) ListDirectory(ctx context.Context, dirPath string, recursi
 		}
 	}
 
-	return entries, nil
+	return entries, truncated, nil
+}
+
+// GetDirectoryInfo 获取目录信息，目录不存在时返回NotFound
+func (s *MemoryStore) GetDirectoryInfo(ctx context.Context, dirPath string) (*metadata.DirectoryInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkOpenLocked(); err != nil {
+		return nil, err
+	}
+
+	dir, exists := s.directories[dirKey(path.Clean(dirPath))]
+	if !exists {
+		return nil, errors.New(errors.NotFound, "目录不存在")
+	}
+	return cloneDirectoryInfo(dir), nil
 }
 
 // CreateDirectory 创建目录
@@ -294,8 +500,8 @@ func (s *MemoryStore) CreateDirectory(ctx context.Context, dirInfo metadata.Dire
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if !s.initialized {
-		return nil, errors.New(errors.Internal, "存储未初始化")
+	if err := s.checkOpenLocked(); err != nil {
+		return nil, err
 	}
 
 	// 规范化路径
@@ -310,15 +516,67 @@ func (s *MemoryStore) CreateDirectory(ctx context.Context, dirInfo metadata.Dire
 		return nil, errors.New(errors.AlreadyExists, "目录已存在")
 	}
 
-	// 检查父目录是否存在
-	parentDir := path.Dir(dirPath)
-	if parentDir != "/" {
-		parentDir += "/"
-	}
-	if _, exists := s.directories[parentDir]; !exists && parentDir != "/" {
+	// 检查父目录是否存在，dirPath带尾部斜杠，需先去掉再取父目录
+	if _, exists := s.directories[dirKey(path.Dir(path.Clean(dirPath)))]; !exists {
 		return nil, errors.New(errors.NotFound, "父目录不存在")
 	}
 
+	return s.insertDirectoryLocked(dirInfo), nil
+}
+
+// CreateDirectoryWithParents 创建目录及缺失的祖先目录（mkdir -p），目录已存在时返回已有目录
+func (s *MemoryStore) CreateDirectoryWithParents(ctx context.Context, dirInfo metadata.DirectoryInfo) (*metadata.DirectoryInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkOpenLocked(); err != nil {
+		return nil, err
+	}
+
+	cleanPath := path.Clean(dirInfo.Path)
+	dirInfo.Path = dirKey(cleanPath)
+
+	if existing, exists := s.directories[dirInfo.Path]; exists {
+		return cloneDirectoryInfo(existing), nil
+	}
+	if _, isFile := s.files[cleanPath]; isFile {
+		return nil, errors.New(errors.AlreadyExists, "同名文件已存在: %s", cleanPath)
+	}
+
+	if err := s.ensureParentsLocked(path.Dir(cleanPath)); err != nil {
+		return nil, err
+	}
+
+	return s.insertDirectoryLocked(dirInfo), nil
+}
+
+// ensureParentsLocked 确保dirPath及其所有祖先目录存在，调用方需持有写锁。
+// 先检查整条路径再创建，祖先路径上存在同名文件时直接返回，不会留下创建了一半的目录
+func (s *MemoryStore) ensureParentsLocked(dirPath string) error {
+	var missing []string
+	for p := path.Clean(dirPath); p != "/"; p = path.Dir(p) {
+		if _, exists := s.directories[dirKey(p)]; exists {
+			break
+		}
+		if _, isFile := s.files[p]; isFile {
+			return errors.New(errors.AlreadyExists, "祖先路径 %s 是文件，无法创建目录", p)
+		}
+		missing = append(missing, p)
+	}
+
+	// 从最外层开始创建，保证每个目录创建时父目录已存在
+	for i := len(missing) - 1; i >= 0; i-- {
+		var dirInfo metadata.DirectoryInfo
+		dirInfo.Path = dirKey(missing[i])
+		s.insertDirectoryLocked(dirInfo)
+	}
+	return nil
+}
+
+// insertDirectoryLocked 保存已通过检查的目录并更新父目录计数，dirInfo.Path需已规范化为目录键
+func (s *MemoryStore) insertDirectoryLocked(dirInfo metadata.DirectoryInfo) *metadata.DirectoryInfo {
+	dirPath := dirInfo.Path
+
 	// 设置创建和更新时间
 	now := time.Now()
 	dirInfo.CreatedAt = now
@@ -331,9 +589,12 @@ func (s *MemoryStore) CreateDirectory(ctx context.Context, dirInfo metadata.Dire
 
 	// 存储目录信息的副本
 	s.directories[dirPath] = cloneDirectoryInfo(&dirInfo)
+	s.childCounts[dirPath] = 0
+	s.childCounts[dirKey(path.Dir(path.Clean(dirPath)))]++
+	s.emitLocked(events.Event{Type: events.Create, Path: path.Clean(dirPath), IsDir: true})
 
 	// 返回目录信息的副本
-	return cloneDirectoryInfo(s.directories[dirPath]), nil
+	return cloneDirectoryInfo(s.directories[dirPath])
 }
 
 // DeleteDirectory 删除目录
@@ -341,8 +602,8 @@ func (s *MemoryStore) DeleteDirectory(ctx context.Context, dirPath string, recur
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if !s.initialized {
-		return errors.New(errors.Internal, "存储未初始化")
+	if err := s.checkOpenLocked(); err != nil {
+		return err
 	}
 
 	// 规范化路径
@@ -361,47 +622,24 @@ func (s *MemoryStore) DeleteDirectory(ctx context.Context, dirPath string, recur
 		return errors.New(errors.NotFound, "目录不存在")
 	}
 
-	// 检查目录是否为空或者是否允许递归删除
-	hasChildren := false
-
-	// 检查是否有子目录
-	for path := range s.directories {
-		if path != dirPath && strings.HasPrefix(path, dirPath) {
-			hasChildren = true
-			if !recursive {
-				return errors.New(errors.PermissionDenied, "目录不为空，需要递归删除")
-			}
-			break
-		}
+	// 目录下的文件被其他写入者持有租约时不能删除
+	if err := s.checkLeaseLocked(path.Clean(dirPath), metadata.LeaseHolder(ctx), metadata.CommandTime(ctx)); err != nil {
+		return err
 	}
 
-	// 检查是否有文件
-	if !hasChildren {
-		for filePath := range s.files {
-			parentDir := path.Dir(filePath)
-			if parentDir != "/" {
-				parentDir += "/"
-			}
-			if strings.HasPrefix(parentDir, dirPath) {
-				hasChildren = true
-				if !recursive {
-					return errors.New(errors.PermissionDenied, "目录不为空，需要递归删除")
-				}
-				break
-			}
+	// 根据子项计数判断目录是否为空，无需扫描
+	if s.childCounts[dirPath] > 0 {
+		if !recursive {
+			return errors.New(errors.PermissionDenied, "目录不为空，需要递归删除")
 		}
-	}
 
-	// 递归删除所有子目录和文件
-	if recursive {
-		// 删除子目录
+		// 递归删除所有子目录和文件
 		for path := range s.directories {
 			if path != dirPath && strings.HasPrefix(path, dirPath) {
 				delete(s.directories, path)
+				delete(s.childCounts, path)
 			}
 		}
-
-		// 删除文件
 		for filePath := range s.files {
 			parentDir := path.Dir(filePath)
 			if parentDir != "/" {
@@ -411,51 +649,305 @@ func (s *MemoryStore) DeleteDirectory(ctx context.Context, dirPath string, recur
 				delete(s.files, filePath)
 			}
 		}
+		for leasePath := range s.leases {
+			if strings.HasPrefix(leasePath, dirPath) {
+				delete(s.leases, leasePath)
+			}
+		}
 	}
 
 	// 删除目录本身
 	delete(s.directories, dirPath)
+	delete(s.childCounts, dirPath)
+	s.childCounts[dirKey(path.Dir(path.Clean(dirPath)))]--
+	s.emitLocked(events.Event{Type: events.Delete, Path: path.Clean(dirPath), IsDir: true})
 
 	return nil
 }
 
-// 辅助函数
+// Move 将文件或目录移动到dst，目录连同其子树一起移动。
+// dst的父目录必须存在且dst未被占用，目录不能移动到自身或其子目录下
+func (s *MemoryStore) Move(ctx context.Context, src, dst string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-// countChildren 计算目录中的子项数量
-func countChildren(s *MemoryStore, dirPath string) int {
-	count := 0
+	if err := s.checkOpenLocked(); err != nil {
+		return err
+	}
 
-	// 规范化路径
-	if dirPath != "/" && dirPath[len(dirPath)-1] != '/' {
-		dirPath += "/"
+	src = path.Clean(src)
+	dst = path.Clean(dst)
+	if src == dst {
+		return nil
 	}
 
-	// 计算直接子目录
-	for path := range s.directories {
-		if path == dirPath {
+	isDir, err := s.checkMoveLocked(src, dst)
+	if err != nil {
+		return err
+	}
+	if err := s.checkMoveLeasesLocked(ctx, src, dst); err != nil {
+		return err
+	}
+
+	s.moveLocked(src, dst, isDir)
+	s.emitLocked(events.Event{Type: events.Move, Path: dst, OldPath: src, IsDir: isDir})
+	return nil
+}
+
+// MoveBatch 在同一把锁内原子地执行一组移动，整批同时生效：源路径按批量移动前的状态解析，
+// 目标路径按批量移动后的状态解析，因此可以交换或轮换条目（/a移到/b同时/b移到/a）。
+// 执行前拒绝目标路径重复、源路径重复以及目标位于被移走的目录之下（包括相互移到对方之下形成环）的移动；
+// 任一移动失败时撤销已执行的步骤，存储保持不变且不产生事件
+func (s *MemoryStore) MoveBatch(ctx context.Context, ops []metadata.MoveOp) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkOpenLocked(); err != nil {
+		return err
+	}
+
+	cleaned := make([]metadata.MoveOp, len(ops))
+	for i, op := range ops {
+		cleaned[i] = metadata.MoveOp{Src: path.Clean(op.Src), Dst: path.Clean(op.Dst)}
+	}
+	if err := checkMoveBatch(cleaned); err != nil {
+		return err
+	}
+	for i, op := range cleaned {
+		if err := s.checkMoveLeasesLocked(ctx, op.Src, op.Dst); err != nil {
+			return errors.Wrapf(err, errors.GetCode(err), "第%d个移动的路径被其他写入者持有租约", i+1).
+				WithField("src", op.Src).WithField("dst", op.Dst)
+		}
+	}
+
+	var pending []int
+	for i, op := range cleaned {
+		if op.Src != op.Dst {
+			pending = append(pending, i)
+		}
+	}
+
+	// 先把所有源移到根目录下的临时名称，较深的源先移，嵌套在其他源中的条目先被取出；
+	// 再按目标由浅到深移到目标路径，目标的父目录可以是本批次中先放置的条目
+	var done []movedEntry
+	rollback := func(i int, err error) error {
+		for k := len(done) - 1; k >= 0; k-- {
+			s.undoMoveLocked(done[k])
+		}
+		return errors.Wrapf(err, errors.GetCode(err), "第%d个移动失败，批量移动已撤销", i+1).
+			WithField("src", cleaned[i].Src).WithField("dst", cleaned[i].Dst)
+	}
+
+	sort.SliceStable(pending, func(a, b int) bool {
+		return strings.Count(cleaned[pending[a]].Src, "/") > strings.Count(cleaned[pending[b]].Src, "/")
+	})
+	staged := make(map[int]string, len(pending))
+	isDirs := make(map[int]bool, len(pending))
+	for _, i := range pending {
+		tmp := s.stagingPathLocked(i)
+		isDir, err := s.checkMoveLocked(cleaned[i].Src, tmp)
+		if err != nil {
+			return rollback(i, err)
+		}
+		done = append(done, s.moveLocked(cleaned[i].Src, tmp, isDir))
+		staged[i], isDirs[i] = tmp, isDir
+	}
+
+	sort.SliceStable(pending, func(a, b int) bool {
+		return strings.Count(cleaned[pending[a]].Dst, "/") < strings.Count(cleaned[pending[b]].Dst, "/")
+	})
+	for _, i := range pending {
+		if _, err := s.checkMoveLocked(staged[i], cleaned[i].Dst); err != nil {
+			return rollback(i, err)
+		}
+		done = append(done, s.moveLocked(staged[i], cleaned[i].Dst, isDirs[i]))
+	}
+
+	// 全部成功后按请求中的顺序产生事件，事件中的路径不含临时名称
+	for i, op := range cleaned {
+		if op.Src != op.Dst {
+			s.emitLocked(events.Event{Type: events.Move, Path: op.Dst, OldPath: op.Src, IsDir: isDirs[i]})
+		}
+	}
+	return nil
+}
+
+// stagingPathLocked 返回批量移动第i项使用的临时路径，位于根目录下且未被占用，调用方需持有锁
+func (s *MemoryStore) stagingPathLocked(i int) string {
+	for n := 0; ; n++ {
+		tmp := fmt.Sprintf("/.move-batch-%d", i)
+		if n > 0 {
+			tmp = fmt.Sprintf("%s-%d", tmp, n)
+		}
+		_, isFile := s.files[tmp]
+		_, isDir := s.directories[dirKey(tmp)]
+		if !isFile && !isDir {
+			return tmp
+		}
+	}
+}
+
+// checkMoveBatch 检查一组已规范化的移动之间的冲突：两个移动的目标或源相同，
+// 或者某个移动的目标位于另一个移动的源目录之下、而该位置在批量移动后没有被其他条目填补。
+// 后者包括目录移到自身子树下，以及/a移到/b下同时/b移到/a下这样的环
+func checkMoveBatch(ops []metadata.MoveOp) error {
+	srcs := make(map[string]int, len(ops))
+	dsts := make(map[string]int, len(ops))
+	for i, op := range ops {
+		if j, dup := dsts[op.Dst]; dup {
+			return errors.New(errors.InvalidArgument, "第%d个和第%d个移动的目标路径相同: %s", j+1, i+1, op.Dst)
+		}
+		if j, dup := srcs[op.Src]; dup {
+			return errors.New(errors.InvalidArgument, "第%d个和第%d个移动的源路径相同: %s", j+1, i+1, op.Src)
+		}
+		dsts[op.Dst] = i
+		srcs[op.Src] = i
+	}
+
+	// 从目标的父目录向上查找：先遇到本批次的某个目标时，父目录由该移动放置；
+	// 先遇到某个移动的源时，该目录已被移走，目标无处安放
+	for i, op := range ops {
+		if op.Src == op.Dst {
 			continue
 		}
-		if strings.HasPrefix(path, dirPath) {
-			// 只计算直接子目录
-			remaining := path[len(dirPath):]
-			if !strings.Contains(remaining, "/") || remaining == "/" {
-				count++
+		for dir := path.Dir(op.Dst); dir != "/"; dir = path.Dir(dir) {
+			if _, ok := dsts[dir]; ok {
+				break
+			}
+			if j, ok := srcs[dir]; ok && ops[j].Src != ops[j].Dst {
+				return errors.New(errors.InvalidArgument, "第%d个移动的目标位于第%d个移动移走的目录之下: %s -> %s",
+					i+1, j+1, op.Src, op.Dst)
 			}
 		}
 	}
+	return nil
+}
 
-	// 计算直接子文件
-	for filePath := range s.files {
-		parentDir := path.Dir(filePath)
-		if parentDir != "/" {
-			parentDir += "/"
+// checkMoveLocked 检查单个移动能否在当前状态下执行，返回源是否为目录，调用方需持有锁
+func (s *MemoryStore) checkMoveLocked(src, dst string) (bool, error) {
+	if src == "/" {
+		return false, errors.New(errors.PermissionDenied, "不允许移动根目录")
+	}
+
+	_, isFile := s.files[src]
+	_, isDir := s.directories[dirKey(src)]
+	if !isFile && !isDir {
+		return false, errors.New(errors.NotFound, "源路径不存在: %s", src)
+	}
+	if isDir && strings.HasPrefix(dst, dirKey(src)) {
+		return false, errors.New(errors.InvalidArgument, "不能将目录移动到其自身或子目录下: %s", dst)
+	}
+	if _, exists := s.files[dst]; exists {
+		return false, errors.New(errors.AlreadyExists, "目标路径已存在: %s", dst)
+	}
+	if _, exists := s.directories[dirKey(dst)]; exists {
+		return false, errors.New(errors.AlreadyExists, "目标路径已存在: %s", dst)
+	}
+	if _, exists := s.directories[dirKey(path.Dir(dst))]; !exists {
+		return false, errors.New(errors.NotFound, "目标父目录不存在: %s", path.Dir(dst))
+	}
+	return isDir, nil
+}
+
+// checkMoveLeasesLocked 检查ctx携带的租约持有者在命令时间能否移走src（目录包括其下所有文件）并写入dst，调用方需持有写锁
+func (s *MemoryStore) checkMoveLeasesLocked(ctx context.Context, src, dst string) error {
+	holder, now := metadata.LeaseHolder(ctx), metadata.CommandTime(ctx)
+	if err := s.checkLeaseLocked(src, holder, now); err != nil {
+		return err
+	}
+	return s.checkLeaseLocked(dst, holder, now)
+}
+
+// movedEntry 记录一次已执行的移动及被移动条目原来的名称和更新时间，用于撤销
+type movedEntry struct {
+	src, dst  string
+	isDir     bool
+	name      string
+	updatedAt time.Time
+}
+
+// moveLocked 移动已通过检查的条目并更新父目录计数，调用方需持有写锁
+func (s *MemoryStore) moveLocked(src, dst string, isDir bool) movedEntry {
+	moved := movedEntry{src: src, dst: dst, isDir: isDir}
+	if isDir {
+		dir := s.directories[dirKey(src)]
+		moved.name, moved.updatedAt = dir.Name, dir.UpdatedAt
+	} else {
+		file := s.files[src]
+		moved.name, moved.updatedAt = file.Name, file.UpdatedAt
+	}
+
+	s.relocateLocked(src, dst, isDir, path.Base(dst), time.Now())
+	return moved
+}
+
+// undoMoveLocked 撤销moveLocked，恢复条目原来的路径、名称和更新时间
+func (s *MemoryStore) undoMoveLocked(moved movedEntry) {
+	s.relocateLocked(moved.dst, moved.src, moved.isDir, moved.name, moved.updatedAt)
+}
+
+// relocateLocked 将条目从src移到dst并设置名称和更新时间，目录的子树和条目上的写租约随之移动，调用方需持有写锁
+func (s *MemoryStore) relocateLocked(src, dst string, isDir bool, name string, updatedAt time.Time) {
+	if isDir {
+		oldPrefix, newPrefix := dirKey(src), dirKey(dst)
+		for dirPath, dir := range s.directories {
+			if !strings.HasPrefix(dirPath, oldPrefix) {
+				continue
+			}
+			newPath := newPrefix + dirPath[len(oldPrefix):]
+			delete(s.directories, dirPath)
+			dir.Path = newPath
+			s.directories[newPath] = dir
+			s.childCounts[newPath] = s.childCounts[dirPath]
+			delete(s.childCounts, dirPath)
 		}
-		if parentDir == dirPath {
-			count++
+		for filePath, file := range s.files {
+			if !strings.HasPrefix(filePath, oldPrefix) {
+				continue
+			}
+			newPath := newPrefix + filePath[len(oldPrefix):]
+			delete(s.files, filePath)
+			file.Path = newPath
+			s.files[newPath] = file
+		}
+		for leasePath, lease := range s.leases {
+			if !strings.HasPrefix(leasePath, oldPrefix) {
+				continue
+			}
+			newPath := newPrefix + leasePath[len(oldPrefix):]
+			delete(s.leases, leasePath)
+			lease.Path = newPath
+			s.leases[newPath] = lease
+		}
+		dir := s.directories[newPrefix]
+		dir.Name = name
+		dir.UpdatedAt = updatedAt
+	} else {
+		file := s.files[src]
+		delete(s.files, src)
+		file.Path = dst
+		file.Name = name
+		file.UpdatedAt = updatedAt
+		s.files[dst] = file
+		if lease, exists := s.leases[src]; exists {
+			delete(s.leases, src)
+			lease.Path = dst
+			s.leases[dst] = lease
 		}
 	}
 
-	return count
+	s.childCounts[dirKey(path.Dir(src))]--
+	s.childCounts[dirKey(path.Dir(dst))]++
+}
+
+// 辅助函数
+
+// dirKey 返回目录在directories和childCounts中的键，除根目录外带尾部斜杠
+func dirKey(dirPath string) string {
+	if dirPath == "/" {
+		return dirPath
+	}
+	return dirPath + "/"
 }
 
 // cloneFileInfo 创建FileInfo的深拷贝
@@ -465,12 +957,14 @@ func cloneFileInfo(info *metadata.FileInfo) *metadata.FileInfo {
 	}
 
 	clone := &metadata.FileInfo{
-		Path:      info.Path,
-		Name:      info.Name,
-		Size:      info.Size,
-		MimeType:  info.MimeType,
-		CreatedAt: info.CreatedAt,
-		UpdatedAt: info.UpdatedAt,
+		BasicFileInfo:   info.BasicFileInfo,
+		Type:            info.Type,
+		Size:            info.Size,
+		MimeType:        info.MimeType,
+		ChunkSize:       info.ChunkSize,
+		Replicas:        info.Replicas,
+		MissingReplicas: info.MissingReplicas,
+		Checksum:        info.Checksum,
 	}
 
 	if info.Metadata != nil {
@@ -483,6 +977,10 @@ func cloneFileInfo(info *metadata.FileInfo) *metadata.FileInfo {
 	if len(info.Chunks) > 0 {
 		clone.Chunks = make([]metadata.ChunkInfo, len(info.Chunks))
 		copy(clone.Chunks, info.Chunks)
+		for i := range clone.Chunks {
+			clone.Chunks[i].Replicas = append([]types.NodeID(nil), info.Chunks[i].Replicas...)
+			clone.Chunks[i].Locations = append([]string(nil), info.Chunks[i].Locations...)
+		}
 	}
 
 	return clone
@@ -495,10 +993,7 @@ func cloneDirectoryInfo(info *metadata.DirectoryInfo) *metadata.DirectoryInfo {
 	}
 
 	clone := &metadata.DirectoryInfo{
-		Path:      info.Path,
-		Name:      info.Name,
-		CreatedAt: info.CreatedAt,
-		UpdatedAt: info.UpdatedAt,
+		BasicFileInfo: info.BasicFileInfo,
 	}
 
 	if info.Metadata != nil {
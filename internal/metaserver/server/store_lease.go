@@ -0,0 +1,157 @@
+package server
+
+import (
+	"context"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/errors"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+)
+
+// AcquireWriteLease 获取文件的写租约，租约被其他持有者持有且未过期时返回AlreadyExists。
+// 路径不要求已存在，写入者可以在创建文件前先获取租约。租约的有效期从ctx携带的命令时间开始计算，
+// 同时清理在该时间已过期的租约
+func (s *MemoryStore) AcquireWriteLease(ctx context.Context, filePath, holder string, ttl time.Duration) (*metadata.Lease, error) {
+	if err := validateLease(holder, ttl); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkOpenLocked(); err != nil {
+		return nil, err
+	}
+
+	filePath = path.Clean(filePath)
+	now := metadata.CommandTime(ctx)
+	s.pruneLeasesLocked(now)
+	if lease := s.activeLeaseLocked(filePath, now); lease != nil && lease.Holder != holder {
+		return nil, errors.New(errors.AlreadyExists, "文件写租约已被其他持有者持有").
+			WithField("path", filePath).
+			WithField("holder", lease.Holder).
+			WithField("expires_at", lease.ExpiresAt)
+	}
+
+	lease := &metadata.Lease{Path: filePath, Holder: holder, ExpiresAt: now.Add(ttl)}
+	s.leases[filePath] = lease
+	result := *lease
+	return &result, nil
+}
+
+// RenewLease 将持有者的租约延长到ttl之后，租约已过期或被其他持有者获取时返回NotFound或PermissionDenied
+func (s *MemoryStore) RenewLease(ctx context.Context, filePath, holder string, ttl time.Duration) (*metadata.Lease, error) {
+	if err := validateLease(holder, ttl); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkOpenLocked(); err != nil {
+		return nil, err
+	}
+
+	filePath = path.Clean(filePath)
+	now := metadata.CommandTime(ctx)
+	lease := s.activeLeaseLocked(filePath, now)
+	if lease == nil {
+		return nil, errors.New(errors.NotFound, "写租约不存在或已过期").WithField("path", filePath)
+	}
+	if lease.Holder != holder {
+		return nil, errors.New(errors.PermissionDenied, "写租约由其他持有者持有").
+			WithField("path", filePath).
+			WithField("holder", lease.Holder)
+	}
+
+	lease.ExpiresAt = now.Add(ttl)
+	result := *lease
+	return &result, nil
+}
+
+// ReleaseLease 释放持有者的租约，租约不存在或已过期时不做任何事，被其他持有者持有时返回PermissionDenied
+func (s *MemoryStore) ReleaseLease(ctx context.Context, filePath, holder string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkOpenLocked(); err != nil {
+		return err
+	}
+
+	filePath = path.Clean(filePath)
+	lease, exists := s.leases[filePath]
+	if !exists {
+		return nil
+	}
+	if !metadata.CommandTime(ctx).Before(lease.ExpiresAt) {
+		delete(s.leases, filePath)
+		return nil
+	}
+	if lease.Holder != holder {
+		return errors.New(errors.PermissionDenied, "写租约由其他持有者持有").
+			WithField("path", filePath).
+			WithField("holder", lease.Holder)
+	}
+
+	delete(s.leases, filePath)
+	return nil
+}
+
+// CheckWriteLease 检查holder能否写入filePath，filePath或其下的文件被其他持有者持有未过期的租约时返回PermissionDenied
+func (s *MemoryStore) CheckWriteLease(ctx context.Context, filePath, holder string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkOpenLocked(); err != nil {
+		return err
+	}
+	return s.checkLeaseLocked(path.Clean(filePath), holder, metadata.CommandTime(ctx))
+}
+
+// checkLeaseLocked 检查holder在now时能否写入已规范化的路径p，p为目录时同时检查其下所有文件的租约，调用方需持有锁
+func (s *MemoryStore) checkLeaseLocked(p, holder string, now time.Time) error {
+	prefix := dirKey(p)
+	for leasePath := range s.leases {
+		if leasePath != p && !strings.HasPrefix(leasePath, prefix) {
+			continue
+		}
+		if lease := s.activeLeaseLocked(leasePath, now); lease != nil && lease.Holder != holder {
+			return errors.New(errors.PermissionDenied, "文件写租约由其他持有者持有").
+				WithField("path", leasePath).
+				WithField("holder", lease.Holder).
+				WithField("expires_at", lease.ExpiresAt)
+		}
+	}
+	return nil
+}
+
+// activeLeaseLocked 返回路径上在now时未过期的租约，不修改租约表，调用方需持有锁
+func (s *MemoryStore) activeLeaseLocked(filePath string, now time.Time) *metadata.Lease {
+	lease, exists := s.leases[filePath]
+	if !exists || !now.Before(lease.ExpiresAt) {
+		return nil
+	}
+	return lease
+}
+
+// pruneLeasesLocked 删除在now时已过期的租约。now来自命令时间，各节点清理的结果相同，调用方需持有写锁
+func (s *MemoryStore) pruneLeasesLocked(now time.Time) {
+	for leasePath, lease := range s.leases {
+		if !now.Before(lease.ExpiresAt) {
+			delete(s.leases, leasePath)
+		}
+	}
+}
+
+// validateLease 检查租约请求的持有者和有效期
+func validateLease(holder string, ttl time.Duration) error {
+	if holder == "" {
+		return errors.New(errors.InvalidArgument, "租约持有者不能为空")
+	}
+	if ttl <= 0 {
+		return errors.New(errors.InvalidArgument, "租约有效期必须为正数")
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"encoding/json"
+	"path"
+
+	"github.com/22827099/DFS_v1/common/errors"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+)
+
+// storeSnapshot MemoryStore序列化后的状态。写租约和文件一样经集群复制，包含在快照中
+type storeSnapshot struct {
+	Files       map[string]*metadata.FileInfo      `json:"files"`
+	Directories map[string]*metadata.DirectoryInfo `json:"directories"`
+	Leases      map[string]*metadata.Lease         `json:"leases,omitempty"`
+}
+
+// SnapshotState 序列化全部文件、目录和写租约，实现election.StateSnapshotter
+func (s *MemoryStore) SnapshotState() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := s.checkOpenLocked(); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(storeSnapshot{Files: s.files, Directories: s.directories, Leases: s.leases})
+	if err != nil {
+		return nil, errors.Wrap(err, errors.Internal, "序列化元数据失败")
+	}
+	return data, nil
+}
+
+// RestoreState 以快照替换全部文件、目录和写租约并重新计算目录的子项数，实现election.StateSnapshotter。
+// 快照无效时返回DataCorruption错误，存储保持不变；恢复不产生变更事件
+func (s *MemoryStore) RestoreState(data []byte) error {
+	var snapshot storeSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return errors.Wrap(err, errors.DataCorruption, "解析元数据快照失败")
+	}
+	if snapshot.Directories["/"] == nil {
+		return errors.New(errors.DataCorruption, "元数据快照缺少根目录")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkOpenLocked(); err != nil {
+		return err
+	}
+
+	files := make(map[string]*metadata.FileInfo, len(snapshot.Files))
+	directories := make(map[string]*metadata.DirectoryInfo, len(snapshot.Directories))
+	childCounts := make(map[string]int, len(snapshot.Directories))
+	for dirPath, info := range snapshot.Directories {
+		directories[dirPath] = info
+		childCounts[dirPath] = 0
+	}
+	for dirPath := range snapshot.Directories {
+		if dirPath != "/" {
+			childCounts[dirKey(path.Dir(path.Clean(dirPath)))]++
+		}
+	}
+	for filePath, info := range snapshot.Files {
+		files[filePath] = info
+		childCounts[dirKey(path.Dir(filePath))]++
+	}
+
+	leases := make(map[string]*metadata.Lease, len(snapshot.Leases))
+	for leasePath, lease := range snapshot.Leases {
+		leases[leasePath] = lease
+	}
+
+	s.files, s.directories, s.childCounts, s.leases = files, directories, childCounts, leases
+	return nil
+}
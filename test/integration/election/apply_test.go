@@ -0,0 +1,139 @@
+package election_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// errRejected kvApplier拒绝op为reject的命令
+var errRejected = errors.New("命令被状态机拒绝")
+
+// kvApplier 把put命令写入内存中的键值表
+type kvApplier struct {
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func newKVApplier() *kvApplier {
+	return &kvApplier{data: make(map[string]string)}
+}
+
+func (a *kvApplier) ApplyCommand(ctx context.Context, cmd raft.Command) error {
+	if cmd.Op == "reject" {
+		return errRejected
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.data[cmd.Key] = string(cmd.Value)
+	return nil
+}
+
+func (a *kvApplier) get(key string) (string, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	value, ok := a.data[key]
+	return value, ok
+}
+
+func TestProposeCommand_AppliedOnEveryNode(t *testing.T) {
+	managers := startNodes(t, 3)
+	appliers := make([]*kvApplier, len(managers))
+	for i, mgr := range managers {
+		// 提交命令前注册，之后提交的命令都会交给状态机
+		appliers[i] = newKVApplier()
+		mgr.SetCommandApplier(appliers[i])
+	}
+	leader := waitForLeader(t, managers)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := leader.ProposeCommand(ctx, raft.Command{Op: "put", Key: "/a.txt", Value: []byte("v1")})
+	require.NoError(t, err)
+
+	// 跟随者的状态机也应用已提交的命令，线性一致读返回时本地状态已包含该写入
+	for i, mgr := range managers {
+		require.NoError(t, mgr.LinearizableRead(ctx))
+		value, ok := appliers[i].get("/a.txt")
+		assert.True(t, ok, "节点%d未应用命令", i+1)
+		assert.Equal(t, "v1", value)
+	}
+}
+
+func TestProposeCommand_ReturnsApplyError(t *testing.T) {
+	managers := startNodes(t, 3)
+	for _, mgr := range managers {
+		mgr.SetCommandApplier(newKVApplier())
+	}
+	leader := waitForLeader(t, managers)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := leader.ProposeCommand(ctx, raft.Command{Op: "reject", Key: "/a.txt"})
+	assert.ErrorIs(t, err, errRejected)
+
+	// 状态机拒绝的命令不影响之后的命令
+	_, err = leader.ProposeCommand(ctx, raft.Command{Op: "put", Key: "/b.txt", Value: []byte("v")})
+	assert.NoError(t, err)
+}
+
+func TestProposeCommandLevel(t *testing.T) {
+	managers := startNodes(t, 3)
+	appliers := make([]*kvApplier, len(managers))
+	for i, mgr := range managers {
+		appliers[i] = newKVApplier()
+		mgr.SetCommandApplier(appliers[i])
+	}
+	leader := waitForLeader(t, managers)
+	leaderIdx := 0
+	for i, mgr := range managers {
+		if mgr == leader {
+			leaderIdx = i
+		}
+	}
+	follower := managers[(leaderIdx+1)%len(managers)]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("quorum", func(t *testing.T) {
+		index, err := leader.ProposeCommandLevel(ctx, raft.Command{Op: "put", Key: "/q.txt", Value: []byte("v")}, raft.ConsistencyQuorum)
+		require.NoError(t, err)
+		assert.NotZero(t, index)
+		// 返回时本节点的状态机已应用该命令
+		_, ok := appliers[leaderIdx].get("/q.txt")
+		assert.True(t, ok)
+	})
+
+	t.Run("all", func(t *testing.T) {
+		index, err := leader.ProposeCommandLevel(ctx, raft.Command{Op: "put", Key: "/all.txt", Value: []byte("v")}, raft.ConsistencyAll)
+		require.NoError(t, err)
+		_, ok := appliers[leaderIdx].get("/all.txt")
+		assert.True(t, ok)
+		// 返回时所有存活节点都已复制该条目
+		for id, match := range leader.RaftStatus().Progress {
+			assert.GreaterOrEqual(t, match, index, "节点%d未复制条目", id)
+		}
+	})
+
+	t.Run("all在跟随者上", func(t *testing.T) {
+		_, err := follower.ProposeCommandLevel(ctx, raft.Command{Op: "put", Key: "/f.txt", Value: []byte("v")}, raft.ConsistencyAll)
+		assert.ErrorIs(t, err, raft.ErrNotLeader)
+	})
+
+	t.Run("one", func(t *testing.T) {
+		_, err := follower.ProposeCommandLevel(ctx, raft.Command{Op: "put", Key: "/one.txt", Value: []byte("v")}, raft.ConsistencyOne)
+		require.NoError(t, err)
+		// one级别不等待提交，条目之后仍会被提交并应用
+		assert.Eventually(t, func() bool {
+			_, ok := appliers[leaderIdx].get("/one.txt")
+			return ok
+		}, 5*time.Second, 20*time.Millisecond)
+	})
+}
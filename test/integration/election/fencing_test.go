@@ -0,0 +1,65 @@
+package election_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/election"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFence_DeposedLeaderStopsMigrations(t *testing.T) {
+	managers := startNodes(t, 3)
+	leader := waitForLeader(t, managers)
+
+	// 防护器只读取Raft任期，从不调用Advance，旧领导者收不到领导者变更通知
+	fence := rebalance.NewFence(rebalance.WithTermSource(leader.CurrentTerm))
+	ctx, cancel := context.WithCancel(context.Background())
+	migrator := rebalance.NewMigrator(ctx, 1, logging.NewLogger(),
+		rebalance.WithShardMigrationTime(100*time.Millisecond),
+		rebalance.WithProgressInterval(5*time.Millisecond),
+		rebalance.WithFence(fence),
+	)
+	t.Cleanup(func() {
+		cancel()
+		migrator.Stop()
+	})
+	migrator.Start()
+
+	shards := make([]string, 50)
+	for i := range shards {
+		shards[i] = "s" + strconv.Itoa(i)
+	}
+	taskIDs := migrator.SubmitTasks([]*rebalance.MigrationPlan{{ShardIDs: shards, EstimatedBytes: 5000}}, fence.Token())
+	require.Eventually(t, func() bool {
+		task, _ := migrator.GetTaskStatus(taskIDs[0])
+		return task.State == rebalance.TaskStateRunning
+	}, time.Second, time.Millisecond)
+
+	var target *election.Manager
+	for _, mgr := range managers {
+		if mgr != leader {
+			target = mgr
+			break
+		}
+	}
+	transferCtx, transferCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer transferCancel()
+	require.NoError(t, leader.TransferLeadership(transferCtx, strconv.FormatUint(target.RaftStatus().ID, 10)))
+	require.Eventually(t, func() bool { return !leader.IsLeader() }, 5*time.Second, 10*time.Millisecond)
+
+	// 退位的领导者观察到新任期后，自己签发的任务在迁移下一个分片前失败
+	var task *rebalance.MigrationTask
+	require.Eventually(t, func() bool {
+		task, _ = migrator.GetTaskStatus(taskIDs[0])
+		return task.State == rebalance.TaskStateFailed || task.State == rebalance.TaskStateCompleted
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, rebalance.TaskStateFailed, task.State)
+	assert.Contains(t, task.ErrorDetail, rebalance.ErrStaleFencingToken.Error())
+	assert.Less(t, task.BytesTransferred, task.TotalBytes)
+}
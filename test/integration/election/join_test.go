@@ -0,0 +1,74 @@
+package election_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/common/types"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/election"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/resolver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJoin_WaitsForLeaderToAddNode(t *testing.T) {
+	addrs := resolver.Static{}
+	managers := map[string]*election.Manager{}
+	for _, id := range []string{"1", "2"} {
+		id := id
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			managers[id].RaftHandler().ServeHTTP(w, r)
+		}))
+		t.Cleanup(srv.Close)
+		addrs[id] = srv.URL
+	}
+
+	newManager := func(id string, join bool) *election.Manager {
+		mgr, err := election.NewManager(&election.ManagerConfig{
+			NodeID:           types.NodeID(id),
+			PeerList:         []string{id},
+			Join:             join,
+			ElectionTimeout:  time.Second,
+			HeartbeatTimeout: 100 * time.Millisecond,
+			ApplyTimeout:     testApplyTimeout,
+			Resolver:         addrs,
+			ClusterSecret:    testClusterSecret,
+		}, logging.NewLogger())
+		require.NoError(t, err)
+		return mgr
+	}
+	seed := newManager("1", false)
+	joiner := newManager("2", true)
+	managers["1"], managers["2"] = seed, joiner
+	applier := newKVApplier()
+	joiner.SetCommandApplier(applier)
+	for _, mgr := range []*election.Manager{seed, joiner} {
+		require.NoError(t, mgr.Start())
+		mgr := mgr
+		t.Cleanup(func() { mgr.Stop() })
+	}
+	assert.False(t, joiner.SingleNode(), "加入中的节点不引导单节点集群")
+	require.Eventually(t, seed.IsLeader, 5*time.Second, 10*time.Millisecond)
+
+	// 领导者加入之前，新节点不会自行选举成为另一个集群的领导者
+	time.Sleep(3 * time.Second)
+	assert.False(t, joiner.IsLeader())
+	assert.Zero(t, joiner.RaftStatus().Lead)
+
+	require.NoError(t, seed.AddPeer("2"))
+	require.Eventually(t, func() bool { return joiner.RaftStatus().Lead == 1 }, 5*time.Second, 20*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := seed.ProposeCommand(ctx, raft.Command{Op: "put", Key: "/a.txt", Value: []byte("v1")})
+	require.NoError(t, err)
+	require.NoError(t, joiner.LinearizableRead(ctx))
+	value, ok := applier.get("/a.txt")
+	assert.True(t, ok)
+	assert.Equal(t, "v1", value)
+}
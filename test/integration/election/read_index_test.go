@@ -0,0 +1,26 @@
+package election_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinearizableRead_FollowerCatchesUpWithWrite(t *testing.T) {
+	managers := startNodes(t, 3)
+	leader := waitForLeader(t, managers)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	index, err := leader.ProposeAndWait(ctx, []byte("write"))
+	require.NoError(t, err)
+
+	// 写入返回后立即在跟随者上读取，返回时跟随者已应用该写入
+	for _, mgr := range managers {
+		require.NoError(t, mgr.LinearizableRead(ctx))
+		assert.GreaterOrEqual(t, mgr.RaftStatus().Applied, index, "节点%d读取前未追上写入", mgr.RaftStatus().ID)
+	}
+}
@@ -0,0 +1,98 @@
+package election_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/common/types"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/election"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/resolver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (a *kvApplier) SnapshotState() ([]byte, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return json.Marshal(a.data)
+}
+
+func (a *kvApplier) RestoreState(data []byte) error {
+	restored := make(map[string]string)
+	if err := json.Unmarshal(data, &restored); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.data = restored
+	return nil
+}
+
+func TestStateSnapshotter_CompactsAndRestoresJoiningNode(t *testing.T) {
+	const threshold = 5
+	addrs := resolver.Static{}
+	managers := map[string]*election.Manager{}
+	for _, id := range []string{"1", "2"} {
+		id := id
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			managers[id].RaftHandler().ServeHTTP(w, r)
+		}))
+		t.Cleanup(srv.Close)
+		addrs[id] = srv.URL
+	}
+
+	appliers := map[string]*kvApplier{}
+	for _, tc := range []struct {
+		id   string
+		join bool
+	}{{"1", false}, {"2", true}} {
+		mgr, err := election.NewManager(&election.ManagerConfig{
+			NodeID:            types.NodeID(tc.id),
+			PeerList:          []string{tc.id},
+			Join:              tc.join,
+			ElectionTimeout:   time.Second,
+			HeartbeatTimeout:  100 * time.Millisecond,
+			ApplyTimeout:      testApplyTimeout,
+			SnapshotThreshold: threshold,
+			Resolver:          addrs,
+			ClusterSecret:     testClusterSecret,
+		}, logging.NewLogger())
+		require.NoError(t, err)
+		appliers[tc.id] = newKVApplier()
+		mgr.SetCommandApplier(appliers[tc.id])
+		mgr.SetStateSnapshotter(appliers[tc.id])
+		managers[tc.id] = mgr
+	}
+	seed, joiner := managers["1"], managers["2"]
+	require.NoError(t, seed.Start())
+	t.Cleanup(func() { seed.Stop() })
+	require.Eventually(t, seed.IsLeader, 5*time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for i := 0; i < 4*threshold; i++ {
+		_, err := seed.ProposeCommand(ctx, raft.Command{Op: "put", Key: fmt.Sprintf("/%d.txt", i), Value: []byte("v")})
+		require.NoError(t, err)
+	}
+
+	// 已应用的日志超过阈值后自动创建快照，快照之前的日志被压缩
+	require.Eventually(t, func() bool { return seed.RaftStatus().CompactedIndex > 0 }, 5*time.Second, 20*time.Millisecond)
+
+	// 新节点需要的日志已被压缩，只能通过快照恢复状态
+	require.NoError(t, joiner.Start())
+	t.Cleanup(func() { joiner.Stop() })
+	require.NoError(t, seed.AddPeer("2"))
+	require.Eventually(t, func() bool { return joiner.RaftStatus().Lead == 1 }, 5*time.Second, 20*time.Millisecond)
+	require.NoError(t, joiner.LinearizableRead(ctx))
+	for i := 0; i < 4*threshold; i++ {
+		_, ok := appliers["2"].get(fmt.Sprintf("/%d.txt", i))
+		assert.True(t, ok, "新节点缺少/%d.txt", i)
+	}
+}
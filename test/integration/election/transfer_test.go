@@ -0,0 +1,59 @@
+package election_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/election"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferLeadership_TargetBecomesLeader(t *testing.T) {
+	managers := startNodes(t, 3)
+	leader := waitForLeader(t, managers)
+
+	var target *election.Manager
+	for _, mgr := range managers {
+		if mgr != leader {
+			target = mgr
+			break
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	targetID := target.RaftStatus().ID
+	require.NoError(t, leader.TransferLeadership(ctx, strconv.FormatUint(targetID, 10)))
+
+	// 目标报告为领导者，原领导者退位，所有节点认同新的领导者
+	assert.Eventually(t, target.IsLeader, 5*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool { return !leader.IsLeader() }, 5*time.Second, 10*time.Millisecond)
+	for _, mgr := range managers {
+		mgr := mgr
+		assert.Eventually(t, func() bool { return mgr.RaftStatus().Lead == targetID }, 5*time.Second, 10*time.Millisecond)
+	}
+
+	// 新领导者可以继续提交写入
+	_, err := target.ProposeAndWait(ctx, []byte("after-transfer"))
+	assert.NoError(t, err)
+}
+
+func TestTransferLeadership_RejectsInvalidRequests(t *testing.T) {
+	managers := startNodes(t, 3)
+	leader := waitForLeader(t, managers)
+	ctx := context.Background()
+
+	assert.ErrorIs(t, leader.TransferLeadership(ctx, "9"), election.ErrPeerNotFound)
+	assert.ErrorIs(t, leader.TransferLeadership(ctx, "node-x"), election.ErrPeerNotFound)
+
+	for _, mgr := range managers {
+		if mgr != leader {
+			assert.ErrorIs(t, mgr.TransferLeadership(ctx, strconv.FormatUint(leader.RaftStatus().ID, 10)), raft.ErrNotLeader)
+		}
+	}
+	assert.True(t, leader.IsLeader(), "失败的转移请求不应影响领导者")
+}
@@ -0,0 +1,172 @@
+package election_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/common/types"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/election"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/resolver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testApplyTimeout 测试节点等待写入被多数派确认的时间
+const testApplyTimeout = 500 * time.Millisecond
+
+// testClusterSecret 测试节点之间传递Raft消息使用的集群密钥
+const testClusterSecret = "test-cluster-secret"
+
+// startNodes 启动n个通过HTTP传输层互联的选举管理器，stalled中的节点接收Raft消息时一直阻塞到测试结束
+func startNodes(t *testing.T, n int, stalled ...int) []*election.Manager {
+	t.Helper()
+
+	release := make(chan struct{})
+	managers := make([]*election.Manager, n)
+	addrs := resolver.Static{}
+	peers := make([]string, n)
+	for i := 0; i < n; i++ {
+		i := i
+		stall := false
+		for _, s := range stalled {
+			stall = stall || s == i+1
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc(election.RaftMessagePath, func(w http.ResponseWriter, r *http.Request) {
+			if stall {
+				<-release
+				return
+			}
+			managers[i].RaftHandler().ServeHTTP(w, r)
+		})
+		srv := httptest.NewServer(mux)
+		t.Cleanup(srv.Close)
+
+		peers[i] = strconv.Itoa(i + 1)
+		addrs[peers[i]] = srv.URL
+	}
+	// 后注册的清理先执行，先放行阻塞的请求再关闭服务器
+	t.Cleanup(func() { close(release) })
+
+	for i := range managers {
+		mgr, err := election.NewManager(&election.ManagerConfig{
+			NodeID:           types.NodeID(peers[i]),
+			PeerList:         peers,
+			ElectionTimeout:  time.Second,
+			HeartbeatTimeout: 100 * time.Millisecond,
+			ApplyTimeout:     testApplyTimeout,
+			Resolver:         addrs,
+			ClusterSecret:    testClusterSecret,
+		}, logging.NewLogger())
+		require.NoError(t, err)
+		managers[i] = mgr
+	}
+	for _, mgr := range managers {
+		require.NoError(t, mgr.Start())
+		mgr := mgr
+		t.Cleanup(func() { mgr.Stop() })
+	}
+	return managers
+}
+
+// waitForLeader 等待选出领导者
+func waitForLeader(t *testing.T, managers []*election.Manager) *election.Manager {
+	t.Helper()
+	var leader *election.Manager
+	require.Eventually(t, func() bool {
+		for _, mgr := range managers {
+			if mgr.IsLeader() {
+				leader = mgr
+				return true
+			}
+		}
+		return false
+	}, 10*time.Second, 20*time.Millisecond, "未选出领导者")
+	return leader
+}
+
+func TestHTTPTransport_ElectsAndReplicates(t *testing.T) {
+	managers := startNodes(t, 2)
+	leader := waitForLeader(t, managers)
+
+	// 两节点集群只有双方都复制后才能提交，all进一步确认跟随者已复制
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	index, err := leader.ProposeWait(ctx, []byte("replicated"), raft.ConsistencyAll)
+	require.NoError(t, err)
+
+	for _, mgr := range managers {
+		mgr := mgr
+		assert.Eventually(t, func() bool { return mgr.RaftStatus().Applied >= index }, 5*time.Second, 20*time.Millisecond,
+			"节点%d未应用提议", mgr.RaftStatus().ID)
+	}
+
+	// 所有节点认同同一个领导者
+	for _, mgr := range managers {
+		if mgr != leader {
+			assert.Equal(t, leader.RaftStatus().ID, mgr.RaftStatus().Lead)
+		}
+	}
+}
+
+func TestHTTPTransport_StalledPeerDoesNotBlock(t *testing.T) {
+	// 节点3接收消息时一直阻塞，发往它的消息在各自的队列中积压，其余两个节点仍能选举和提交
+	managers := startNodes(t, 3, 3)
+	leader := waitForLeader(t, managers[:2])
+
+	for i := 0; i < 20; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		_, err := leader.ProposeWait(ctx, []byte("write-"+strconv.Itoa(i)), raft.ConsistencyQuorum)
+		cancel()
+		require.NoError(t, err, i)
+	}
+}
+
+func TestHTTPTransport_RejectsWrongClusterSecret(t *testing.T) {
+	managers := startNodes(t, 2)
+	handler := managers[0].RaftHandler()
+
+	for name, secret := range map[string]string{"未携带密钥": "", "密钥错误": "wrong-secret"} {
+		req := httptest.NewRequest(http.MethodPost, election.RaftMessagePath, nil)
+		if secret != "" {
+			req.Header.Set(election.ClusterSecretHeader, secret)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, name)
+	}
+}
+
+func TestNewManager_MultiNodeRequiresClusterSecret(t *testing.T) {
+	_, err := election.NewManager(&election.ManagerConfig{
+		NodeID:   "1",
+		PeerList: []string{"1", "2", "3"},
+	}, logging.NewLogger())
+	assert.ErrorIs(t, err, election.ErrNoClusterSecret)
+}
+
+func TestProposeAndWait_TimesOutWithoutQuorum(t *testing.T) {
+	managers := startNodes(t, 3)
+	leader := waitForLeader(t, managers)
+
+	_, err := leader.ProposeAndWait(context.Background(), []byte("before"))
+	require.NoError(t, err)
+
+	// 停止两个跟随者后领导者失去多数派，写入在配置的超时时间内失败而不是一直等待
+	for _, mgr := range managers {
+		if mgr != leader {
+			mgr.Stop()
+		}
+	}
+	start := time.Now()
+	_, err = leader.ProposeAndWait(context.Background(), []byte("no-quorum"))
+	assert.ErrorIs(t, err, raft.ErrApplyTimeout)
+	assert.GreaterOrEqual(t, time.Since(start), testApplyTimeout)
+	assert.Less(t, time.Since(start), testApplyTimeout+time.Second)
+}
@@ -0,0 +1,80 @@
+package raft_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+	"github.com/22827099/DFS_v1/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// proposeWithin 以level提交命令，最多等待timeout
+func proposeWithin(node *testutil.Node, command string, level raft.ConsistencyLevel, timeout time.Duration) (uint64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return node.Raft.ProposeWait(ctx, []byte(command), level)
+}
+
+func TestProposeWait_QuorumAndAll(t *testing.T) {
+	cluster := testutil.NewCluster(t, 3)
+	leader, err := cluster.WaitForLeader(5 * time.Second)
+	require.NoError(t, err)
+
+	// 所有节点都与领导者通信后，all等待每个节点都复制
+	_, err = proposeWithin(leader, "warmup", raft.ConsistencyAll, 5*time.Second)
+	require.NoError(t, err)
+
+	// 隔离一个跟随者，时间短于存活判定窗口，它仍被视为存活节点
+	lagging := others(cluster, leader.ID)[0]
+	cluster.Partition([]uint64{lagging}, others(cluster, lagging))
+
+	// 多数派应用后quorum即返回
+	start := time.Now()
+	index, err := proposeWithin(leader, "quorum-write", raft.ConsistencyQuorum, 2*time.Second)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.GreaterOrEqual(t, leader.Raft.AppliedIndex(), index)
+
+	// all需要等待被隔离但仍存活的节点
+	_, err = proposeWithin(leader, "all-write", raft.ConsistencyAll, 300*time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "存活节点未复制前all不应返回")
+
+	// 恢复连接后该节点追上日志，all返回
+	cluster.Heal()
+	_, err = proposeWithin(leader, "all-after-heal", raft.ConsistencyAll, 5*time.Second)
+	require.NoError(t, err)
+	assert.NoError(t, cluster.Node(lagging).WaitForCommitted([]byte("all-write"), 5*time.Second))
+}
+
+func TestProposeWait_AllSkipsDeadNodes(t *testing.T) {
+	cluster := testutil.NewCluster(t, 3)
+	leader, err := cluster.WaitForLeader(5 * time.Second)
+	require.NoError(t, err)
+	_, err = proposeWithin(leader, "warmup", raft.ConsistencyAll, 5*time.Second)
+	require.NoError(t, err)
+
+	// 隔离超过存活判定窗口（选举超时，默认1秒）后，该节点不再被all等待；
+	// 完全隔离该节点，避免它发起的选举经由其他节点干扰领导者
+	dead := others(cluster, leader.ID)[0]
+	cluster.Partition([]uint64{dead}, others(cluster, dead))
+	time.Sleep(1200 * time.Millisecond)
+
+	_, err = proposeWithin(leader, "all-write", raft.ConsistencyAll, time.Second)
+	assert.NoError(t, err)
+}
+
+func TestProposeWait_One(t *testing.T) {
+	cluster := testutil.NewCluster(t, 3)
+	leader, err := cluster.WaitForLeader(5 * time.Second)
+	require.NoError(t, err)
+
+	_, err = proposeWithin(leader, "one-write", raft.ConsistencyOne, 2*time.Second)
+	require.NoError(t, err)
+	// 其余副本最终复制
+	for _, node := range cluster.Nodes() {
+		assert.NoError(t, node.WaitForCommitted([]byte("one-write"), 5*time.Second))
+	}
+}
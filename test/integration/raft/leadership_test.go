@@ -0,0 +1,54 @@
+package raft_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailover_NewLeaderAppliesLogBeforeReporting(t *testing.T) {
+	cluster := testutil.NewCluster(t, 3, testutil.WithElectionTick(5))
+	leader, err := cluster.WaitForLeader(5 * time.Second)
+	require.NoError(t, err)
+
+	var last []byte
+	for i := 0; i < 20; i++ {
+		last = []byte(fmt.Sprintf("cmd-%d", i))
+		require.True(t, leader.Propose(last))
+	}
+	require.NoError(t, leader.WaitForCommitted(last, 5*time.Second))
+	committed := leader.Raft.AppliedIndex()
+
+	// 领导者提交后立即被隔离，跟随者可能还不知道最新的提交索引
+	cluster.Partition([]uint64{leader.ID}, others(cluster, leader.ID))
+
+	// 新领导者报告领导权时，旧领导者已提交的条目都已应用
+	var newLeader *testutil.Node
+	var applied uint64
+	require.Eventually(t, func() bool {
+		for _, node := range cluster.Nodes() {
+			if node.ID != leader.ID && node.Raft.IsLeader() {
+				newLeader, applied = node, node.Raft.AppliedIndex()
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, time.Millisecond, "多数派应选出新的领导者")
+	assert.Greater(t, applied, committed, "新领导者应已应用之前的日志和本任期的空条目")
+	assert.NoError(t, newLeader.WaitForCommitted(last, time.Second))
+}
+
+// others 返回除id外的所有节点ID
+func others(cluster *testutil.Cluster, id uint64) []uint64 {
+	var ids []uint64
+	for _, node := range cluster.Nodes() {
+		if node.ID != id {
+			ids = append(ids, node.ID)
+		}
+	}
+	return ids
+}
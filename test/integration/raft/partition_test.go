@@ -0,0 +1,72 @@
+package raft_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartition_MinorityCannotCommit(t *testing.T) {
+	cluster := testutil.NewCluster(t, 5, testutil.WithElectionTick(5))
+	leader, err := cluster.WaitForLeader(5 * time.Second)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), leader.ID)
+
+	before := []byte("before partition")
+	require.True(t, leader.Propose(before))
+	for _, node := range cluster.Nodes() {
+		require.NoError(t, node.WaitForCommitted(before, 5*time.Second))
+	}
+
+	// 旧领导者处于少数派
+	cluster.Partition([]uint64{1, 2}, []uint64{3, 4, 5})
+
+	minorityWrite := []byte("minority write")
+	require.True(t, leader.Propose(minorityWrite), "旧领导者仍会接受提议")
+
+	var newLeader *testutil.Node
+	require.Eventually(t, func() bool {
+		newLeader = cluster.Leader()
+		return newLeader != nil && newLeader.ID >= 3
+	}, 5*time.Second, 10*time.Millisecond, "多数派应选出新的领导者")
+
+	majorityWrite := []byte("majority write")
+	require.True(t, newLeader.Propose(majorityWrite))
+	for _, id := range []uint64{3, 4, 5} {
+		require.NoError(t, cluster.Node(id).WaitForCommitted(majorityWrite, 5*time.Second))
+	}
+
+	// 少数派无法与多数派交换消息，既提交不了自己的写入，也看不到多数派的写入
+	for _, id := range []uint64{1, 2} {
+		node := cluster.Node(id)
+		assert.Error(t, node.WaitForCommitted(minorityWrite, 500*time.Millisecond), "少数派节点%d不应提交写入", id)
+		assert.NotContains(t, node.Committed(), majorityWrite, "少数派节点%d不应收到多数派的日志", id)
+	}
+
+	cluster.Heal()
+	for _, node := range cluster.Nodes() {
+		require.NoError(t, node.WaitForCommitted(majorityWrite, 5*time.Second), "恢复后节点%d应追上日志", node.ID)
+		assert.NotContains(t, node.Committed(), minorityWrite, "少数派未提交的写入应被覆盖")
+	}
+}
+
+func TestPartition_IsolatedPairStopsExchangingMessages(t *testing.T) {
+	cluster := testutil.NewCluster(t, 3, testutil.WithElectionTick(5))
+	leader, err := cluster.WaitForLeader(5 * time.Second)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), leader.ID)
+
+	// 只阻断领导者与节点3之间的连接，节点2仍与双方相连
+	cluster.Filter().Partition(1, 3)
+
+	command := []byte("while 1-3 blocked")
+	require.True(t, leader.Propose(command))
+	require.NoError(t, cluster.Node(2).WaitForCommitted(command, 5*time.Second))
+	assert.Error(t, cluster.Node(3).WaitForCommitted(command, 300*time.Millisecond), "节点3收不到领导者的日志")
+
+	cluster.Filter().Heal(1, 3)
+	assert.NoError(t, cluster.Node(3).WaitForCommitted(command, 5*time.Second))
+}
@@ -0,0 +1,58 @@
+package raft_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+	"github.com/22827099/DFS_v1/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadIndex_FollowerSeesPrecedingWrite(t *testing.T) {
+	cluster := testutil.NewCluster(t, 3)
+	leader, err := cluster.WaitForLeader(5 * time.Second)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		command := []byte(fmt.Sprintf("write-%d", i))
+		// 多数派应用后写入即返回，跟随者可能还没有应用
+		_, err := proposeWithin(leader, string(command), raft.ConsistencyQuorum, 5*time.Second)
+		require.NoError(t, err)
+
+		// 写入返回后立即在每个跟随者上读取，都应读到该写入
+		for _, id := range others(cluster, leader.ID) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			committed, err := cluster.Node(id).ReadCommitted(ctx)
+			cancel()
+			require.NoError(t, err)
+			require.NotEmpty(t, committed, "节点%d读到旧状态", id)
+			assert.Equal(t, command, committed[len(committed)-1], "节点%d读到旧状态", id)
+		}
+	}
+}
+
+func TestReadIndex_FailsWithoutLeader(t *testing.T) {
+	cluster := testutil.NewCluster(t, 3)
+	leader, err := cluster.WaitForLeader(5 * time.Second)
+	require.NoError(t, err)
+
+	// 被隔离的跟随者无法确认领导者的提交索引，读取在ctx结束时失败而不是返回本地的旧状态
+	isolated := others(cluster, leader.ID)[0]
+	cluster.Partition([]uint64{isolated}, others(cluster, isolated))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	_, err = cluster.Node(isolated).ReadCommitted(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// 恢复连接后可以读取
+	cluster.Heal()
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = cluster.Node(isolated).ReadCommitted(ctx)
+	assert.NoError(t, err)
+}
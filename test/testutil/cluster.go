@@ -0,0 +1,474 @@
+// Package testutil 提供集成测试使用的辅助工具
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+)
+
+const (
+	// raftMessagePath 节点接收Raft消息的HTTP路径
+	raftMessagePath = "/raft/message"
+	// healthPath 就绪检查路径
+	healthPath = "/health"
+	// sendQueueSize 每个目标节点的待发送消息数，队列满时丢弃消息，由Raft重传
+	sendQueueSize = 256
+)
+
+// ClusterOption 集群配置选项
+type ClusterOption func(*Cluster)
+
+// WithInitialLeader 指定启动后立即发起选举的节点，使首个领导者确定；id为0时等待选举超时自然选出
+func WithInitialLeader(id uint64) ClusterOption {
+	return func(c *Cluster) {
+		c.initialLeader = id
+	}
+}
+
+// WithElectionTick 设置选举超时的tick数（每tick为100毫秒）
+func WithElectionTick(ticks int) ClusterOption {
+	return func(c *Cluster) {
+		if ticks > 0 {
+			c.electionTick = ticks
+		}
+	}
+}
+
+// Cluster 在当前进程中运行的N节点Raft集群。
+// 每个节点在127.0.0.1的临时端口上监听，节点之间通过HTTP传递Raft消息，
+// 可以通过Partition在传输层阻断节点之间的通信
+type Cluster struct {
+	t             testing.TB
+	nodes         []*Node
+	initialLeader uint64
+	electionTick  int
+	filter        *raft.PartitionFilter
+}
+
+// Node 集群中的一个节点
+type Node struct {
+	ID   uint64
+	Addr string // 监听地址，host:port
+	Raft *raft.RaftNode
+
+	cluster   *Cluster
+	listener  net.Listener
+	server    *http.Server
+	transport *httpTransport
+	done      chan struct{}
+	stopOnce  sync.Once
+
+	mu        sync.Mutex
+	committed [][]byte      // 已应用的普通命令，按提交顺序
+	applied   uint64        // 已处理的最大日志索引
+	appliedC  chan struct{} // applied前进时关闭并替换
+}
+
+// NewCluster 启动n个节点的集群，等待所有节点的HTTP服务就绪后返回，测试结束时自动停止。
+// 默认由节点1立即发起选举，集群的首个领导者是确定的
+func NewCluster(t testing.TB, n int, opts ...ClusterOption) *Cluster {
+	t.Helper()
+	if n < 1 {
+		t.Fatalf("集群节点数必须大于0: %d", n)
+	}
+
+	c := &Cluster{
+		t:             t,
+		initialLeader: 1,
+		electionTick:  10,
+		filter:        raft.NewPartitionFilter(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	t.Cleanup(c.Stop)
+
+	// 先为所有节点分配端口，创建Raft节点时需要知道全部成员的地址
+	peers := make([]uint64, n)
+	addrs := make(map[uint64]string, n)
+	for i := 0; i < n; i++ {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("分配节点端口失败: %v", err)
+		}
+		node := &Node{
+			ID:       uint64(i + 1),
+			Addr:     listener.Addr().String(),
+			cluster:  c,
+			listener: listener,
+			done:     make(chan struct{}),
+			appliedC: make(chan struct{}),
+		}
+		c.nodes = append(c.nodes, node)
+		peers[i] = node.ID
+		addrs[node.ID] = node.Addr
+	}
+
+	for _, node := range c.nodes {
+		if err := node.start(peers, addrs); err != nil {
+			t.Fatalf("启动节点%d失败: %v", node.ID, err)
+		}
+	}
+	if err := c.waitReady(5 * time.Second); err != nil {
+		t.Fatalf("集群未就绪: %v", err)
+	}
+
+	if c.initialLeader != 0 {
+		node := c.Node(c.initialLeader)
+		if node == nil {
+			t.Fatalf("指定的初始领导者%d不存在", c.initialLeader)
+		}
+		if err := node.Raft.Campaign(context.Background()); err != nil {
+			t.Fatalf("节点%d发起选举失败: %v", node.ID, err)
+		}
+	}
+	return c
+}
+
+// Nodes 返回所有节点
+func (c *Cluster) Nodes() []*Node {
+	return c.nodes
+}
+
+// Node 返回指定ID的节点，不存在时返回nil
+func (c *Cluster) Node(id uint64) *Node {
+	if id == 0 || id > uint64(len(c.nodes)) {
+		return nil
+	}
+	return c.nodes[id-1]
+}
+
+// Leader 返回当前的领导者，没有领导者时返回nil。
+// 分区期间旧领导者可能仍认为自己是领导者，此时返回任期最高的那个
+func (c *Cluster) Leader() *Node {
+	var leader *Node
+	var leaderTerm uint64
+	for _, node := range c.nodes {
+		if !node.Raft.IsLeader() {
+			continue
+		}
+		if term := node.Raft.Term(); leader == nil || term > leaderTerm {
+			leader, leaderTerm = node, term
+		}
+	}
+	return leader
+}
+
+// WaitForLeader 等待集群选出领导者，超时返回错误
+func (c *Cluster) WaitForLeader(timeout time.Duration) (*Node, error) {
+	var leader *Node
+	err := poll(timeout, func() bool {
+		leader = c.Leader()
+		return leader != nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%v内未选出领导者", timeout)
+	}
+	return leader, nil
+}
+
+// Partition 将节点划分为互相隔离的分区，不同分区的节点之间的Raft消息被丢弃，替换之前的分区；
+// 未出现在任何分组中的节点自成一个分区
+func (c *Cluster) Partition(groups ...[]uint64) {
+	grouped := make(map[uint64]bool)
+	for _, group := range groups {
+		for _, id := range group {
+			grouped[id] = true
+		}
+	}
+	for _, node := range c.nodes {
+		if !grouped[node.ID] {
+			groups = append(groups, []uint64{node.ID})
+		}
+	}
+
+	c.filter.HealAll()
+	c.filter.PartitionGroups(groups...)
+}
+
+// Heal 解除所有分区
+func (c *Cluster) Heal() {
+	c.filter.HealAll()
+}
+
+// Filter 返回集群共享的分区过滤器，可以用来阻断或恢复单对节点之间的连接
+func (c *Cluster) Filter() *raft.PartitionFilter {
+	return c.filter
+}
+
+// Stop 停止所有节点，可以重复调用
+func (c *Cluster) Stop() {
+	for _, node := range c.nodes {
+		node.stop()
+	}
+}
+
+// waitReady 轮询每个节点的就绪检查，直到全部可以访问
+func (c *Cluster) waitReady(timeout time.Duration) error {
+	client := &http.Client{Timeout: time.Second}
+	for _, node := range c.nodes {
+		url := "http://" + node.Addr + healthPath
+		err := poll(timeout, func() bool {
+			resp, err := client.Get(url)
+			if err != nil {
+				return false
+			}
+			resp.Body.Close()
+			return resp.StatusCode == http.StatusOK
+		})
+		if err != nil {
+			return fmt.Errorf("节点%d(%s)未就绪", node.ID, node.Addr)
+		}
+	}
+	return nil
+}
+
+// Propose 提交一个命令，节点已停止时返回false
+func (n *Node) Propose(command []byte) bool {
+	return n.Raft.Propose(command)
+}
+
+// Committed 返回本节点已应用的普通命令
+func (n *Node) Committed() [][]byte {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([][]byte(nil), n.committed...)
+}
+
+// WaitForCommitted 等待本节点应用command，超时返回错误
+func (n *Node) WaitForCommitted(command []byte, timeout time.Duration) error {
+	err := poll(timeout, func() bool {
+		for _, c := range n.Committed() {
+			if bytes.Equal(c, command) {
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return fmt.Errorf("节点%d在%v内未应用命令%q", n.ID, timeout, command)
+	}
+	return nil
+}
+
+// ReadCommitted 线性一致地读取本节点已应用的命令：先通过ReadIndex获得领导者的提交索引，
+// 等该索引之前的条目都交给应用通道、并且本节点处理完通道中的消息后再读取，
+// 跟随者上也能读到调用之前已提交的写入
+func (n *Node) ReadCommitted(ctx context.Context) ([][]byte, error) {
+	index, err := n.Raft.ReadIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := n.Raft.WaitApplied(ctx, index); err != nil {
+		return nil, err
+	}
+	index = n.Raft.DeliveredIndex()
+	for {
+		n.mu.Lock()
+		applied, advanced := n.applied, n.appliedC
+		n.mu.Unlock()
+		if applied >= index {
+			return n.Committed(), nil
+		}
+		select {
+		case <-advanced:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// start 启动HTTP服务和Raft节点
+func (n *Node) start(peers []uint64, addrs map[uint64]string) error {
+	n.transport = newHTTPTransport(n.ID, addrs)
+
+	cfg := raft.DefaultConfig()
+	cfg.NodeID = n.ID
+	cfg.Peers = peers
+	cfg.ElectionTick = n.cluster.electionTick
+	rn, err := raft.NewRaftNode(cfg, raft.NewFilteredTransport(n.transport, n.ID, n.cluster.filter))
+	if err != nil {
+		return err
+	}
+	n.Raft = rn
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(healthPath, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc(raftMessagePath, n.handleMessage)
+	n.server = &http.Server{Handler: mux}
+	go n.server.Serve(n.listener)
+
+	if err := n.transport.Start(); err != nil {
+		return err
+	}
+	go n.applyLoop()
+	return nil
+}
+
+// stop 停止Raft节点、传输层和HTTP服务
+func (n *Node) stop() {
+	n.stopOnce.Do(func() { close(n.done) })
+	if n.Raft != nil {
+		n.Raft.Stop()
+	}
+	if n.transport != nil {
+		n.transport.Stop()
+	}
+	if n.server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		n.server.Shutdown(ctx)
+	} else {
+		n.listener.Close()
+	}
+}
+
+// applyLoop 持续读取应用通道，记录已提交的命令，避免通道写满阻塞Raft
+func (n *Node) applyLoop() {
+	for {
+		select {
+		case <-n.done:
+			return
+		case msg := <-n.Raft.ApplyCh():
+			n.mu.Lock()
+			if msg.CommandValid {
+				n.committed = append(n.committed, msg.Command)
+			}
+			if index := max(msg.CommandIndex, msg.SnapshotIndex, msg.ConfChangeIndex); index > n.applied {
+				n.applied = index
+				close(n.appliedC)
+				n.appliedC = make(chan struct{})
+			}
+			n.mu.Unlock()
+		}
+	}
+}
+
+// handleMessage 接收其他节点发来的Raft消息，来自不同分区的消息被丢弃
+func (n *Node) handleMessage(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var msg raftpb.Message
+	if err := msg.Unmarshal(data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// 分区建立前已发出的消息在接收端丢弃
+	if !n.cluster.filter.Allowed(msg.From, n.ID) {
+		http.Error(w, "网络分区", http.StatusServiceUnavailable)
+		return
+	}
+	if err := n.Raft.Step(r.Context(), msg); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// httpTransport 通过HTTP发送Raft消息，每个目标节点一个发送队列，慢节点不会阻塞Raft的处理循环
+type httpTransport struct {
+	addrs  map[uint64]string
+	client *http.Client
+	queues map[uint64]chan raftpb.Message
+	done   chan struct{}
+	once   sync.Once
+	wg     sync.WaitGroup
+}
+
+func newHTTPTransport(selfID uint64, addrs map[uint64]string) *httpTransport {
+	t := &httpTransport{
+		addrs:  addrs,
+		client: &http.Client{Timeout: time.Second},
+		queues: make(map[uint64]chan raftpb.Message, len(addrs)),
+		done:   make(chan struct{}),
+	}
+	for id := range addrs {
+		if id != selfID {
+			t.queues[id] = make(chan raftpb.Message, sendQueueSize)
+		}
+	}
+	return t
+}
+
+// Send 将消息放入目标节点的发送队列，队列满或目标未知时丢弃
+func (t *httpTransport) Send(messages []raftpb.Message) {
+	for _, msg := range messages {
+		queue, ok := t.queues[msg.To]
+		if !ok {
+			continue
+		}
+		select {
+		case queue <- msg:
+		default:
+		}
+	}
+}
+
+// Start 为每个目标节点启动发送协程
+func (t *httpTransport) Start() error {
+	for id, queue := range t.queues {
+		t.wg.Add(1)
+		go t.sendLoop(t.addrs[id], queue)
+	}
+	return nil
+}
+
+// Stop 停止发送协程并等待其退出
+func (t *httpTransport) Stop() {
+	t.once.Do(func() { close(t.done) })
+	t.wg.Wait()
+}
+
+func (t *httpTransport) sendLoop(addr string, queue <-chan raftpb.Message) {
+	defer t.wg.Done()
+	url := "http://" + addr + raftMessagePath
+	for {
+		select {
+		case <-t.done:
+			return
+		case msg := <-queue:
+			data, err := msg.Marshal()
+			if err != nil {
+				continue
+			}
+			// 发送失败的消息直接丢弃，Raft会重传
+			resp, err := t.client.Post(url, "application/octet-stream", bytes.NewReader(data))
+			if err == nil {
+				resp.Body.Close()
+			}
+		}
+	}
+}
+
+// errTimeout poll超时
+var errTimeout = errors.New("等待超时")
+
+// poll 每10毫秒检查一次cond，直到其返回true或超时
+func poll(timeout time.Duration, cond func() bool) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		if cond() {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errTimeout
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,84 @@
+package testutil
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/database"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/namespace"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/models"
+	"github.com/stretchr/testify/require"
+)
+
+// RootDirID 元数据库初始化时创建的根目录ID
+const RootDirID int64 = 1
+
+// NewMetaDB 在测试的临时目录中创建SQLite元数据库，表结构、根目录和系统用户已初始化，测试结束时关闭
+func NewMetaDB(t testing.TB) *database.Manager {
+	t.Helper()
+	db, err := database.NewManager(metaconfig.DatabaseConfig{
+		Type:         "sqlite3",
+		Database:     filepath.Join(t.TempDir(), "meta.db"),
+		MaxOpenConns: 1,
+	}, logging.NewLogger())
+	require.NoError(t, err)
+	require.NoError(t, db.Start())
+	t.Cleanup(func() { db.Stop(context.Background()) })
+	return db
+}
+
+// SeedDirectory 通过目录仓库创建目录并维护父目录的子项计数，DirID为0时自动分配，返回目录ID
+func SeedDirectory(t testing.TB, db *database.Manager, dir models.DirectoryMetadata) int64 {
+	t.Helper()
+	_, err := namespace.NewDirectoryRepository(db).Create(context.Background(), nil, &dir)
+	require.NoError(t, err)
+	return dir.DirID
+}
+
+// SeedFile 通过文件仓库创建文件并维护所在目录的子项计数，FileID为0时自动分配，返回文件ID
+func SeedFile(t testing.TB, db *database.Manager, file models.FileMetadata) int64 {
+	t.Helper()
+	_, err := namespace.NewFileRepository(db).Create(context.Background(), nil, &file)
+	require.NoError(t, err)
+	return file.FileID
+}
+
+// Mkdir 在parent下创建名为name的目录，id为0时自动分配，返回目录ID
+func Mkdir(t testing.TB, db *database.Manager, id, parent int64, name string) int64 {
+	t.Helper()
+	return SeedDirectory(t, db, models.DirectoryMetadata{DirID: id, ParentID: parent, Name: name, OwnerID: 1, Mode: 755})
+}
+
+// CreateFile 在dir下创建名为name的文件，id为0时自动分配，返回文件ID
+func CreateFile(t testing.TB, db *database.Manager, id, dir int64, name string) int64 {
+	t.Helper()
+	return SeedFile(t, db, models.FileMetadata{FileID: id, DirID: dir, Name: name, OwnerID: 1, Mode: 644})
+}
+
+// DeleteDirectory 通过目录仓库软删除目录
+func DeleteDirectory(t testing.TB, db *database.Manager, id int64) {
+	t.Helper()
+	_, err := namespace.NewDirectoryRepository(db).Delete(context.Background(), nil, id)
+	require.NoError(t, err)
+}
+
+// DeleteFile 通过文件仓库软删除文件
+func DeleteFile(t testing.TB, db *database.Manager, id int64) {
+	t.Helper()
+	_, err := namespace.NewFileRepository(db).Delete(context.Background(), nil, id)
+	require.NoError(t, err)
+}
+
+// MoveDirectory 通过目录仓库把目录移动到newParent下
+func MoveDirectory(t testing.TB, db *database.Manager, id, newParent int64) {
+	t.Helper()
+	repo := namespace.NewDirectoryRepository(db)
+	var dir models.DirectoryMetadata
+	require.NoError(t, repo.FindByID(context.Background(), id, &dir))
+	dir.ParentID = newParent
+	_, err := repo.Update(context.Background(), nil, &dir)
+	require.NoError(t, err)
+}
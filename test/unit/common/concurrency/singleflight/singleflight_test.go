@@ -0,0 +1,194 @@
+package singleflight_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/concurrency/singleflight"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fireConcurrent 启动n个并发调用，返回等待它们全部结束的WaitGroup
+func fireConcurrent(n int, call func(i int)) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			call(i)
+		}(i)
+	}
+	return &wg
+}
+
+func TestGroup_CoalescesConcurrentCalls(t *testing.T) {
+	var g singleflight.Group
+	var calls int32
+	gate := make(chan struct{})
+
+	const n = 50
+	results := make([]interface{}, n)
+	shared := make([]bool, n)
+	wg := fireConcurrent(n, func(i int) {
+		v, err, s := g.Do("key", func() (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			<-gate
+			return "value", nil
+		})
+		assert.NoError(t, err)
+		results[i], shared[i] = v, s
+	})
+
+	// 给所有调用方加入进行中的调用留出时间
+	time.Sleep(100 * time.Millisecond)
+	close(gate)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for i := 0; i < n; i++ {
+		assert.Equal(t, "value", results[i])
+		assert.True(t, shared[i])
+	}
+}
+
+func TestGroup_DoesNotCacheResults(t *testing.T) {
+	var g singleflight.Group
+	calls := 0
+	fn := func() (interface{}, error) {
+		calls++
+		return calls, nil
+	}
+
+	v, err, shared := g.Do("key", fn)
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+	assert.False(t, shared)
+
+	v, _, _ = g.Do("key", fn)
+	assert.Equal(t, 2, v, "调用结束后应重新执行")
+}
+
+func TestGroup_DifferentKeysRunIndependently(t *testing.T) {
+	var g singleflight.Group
+	var calls int32
+	gate := make(chan struct{})
+
+	wg := fireConcurrent(2, func(i int) {
+		g.Do([]string{"a", "b"}[i], func() (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			<-gate
+			return nil, nil
+		})
+	})
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 2 }, time.Second, time.Millisecond)
+	close(gate)
+	wg.Wait()
+}
+
+func TestGroup_SharesErrors(t *testing.T) {
+	var g singleflight.Group
+	errBoom := errors.New("boom")
+	gate := make(chan struct{})
+
+	const n = 10
+	errs := make([]error, n)
+	wg := fireConcurrent(n, func(i int) {
+		_, errs[i], _ = g.Do("key", func() (interface{}, error) {
+			<-gate
+			return nil, errBoom
+		})
+	})
+	time.Sleep(50 * time.Millisecond)
+	close(gate)
+	wg.Wait()
+
+	for _, err := range errs {
+		assert.Equal(t, errBoom, err)
+	}
+}
+
+func TestGroup_DoWithContextCallerCanGiveUp(t *testing.T) {
+	var g singleflight.Group
+	gate := make(chan struct{})
+	started := make(chan struct{})
+
+	resultCh := make(chan interface{}, 1)
+	go func() {
+		v, _, _ := g.DoWithContext(context.Background(), "key", func() (interface{}, error) {
+			close(started)
+			<-gate
+			return "value", nil
+		})
+		resultCh <- v
+	}()
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err, _ := g.DoWithContext(ctx, "key", func() (interface{}, error) {
+		t.Error("已有进行中的调用时不应再次执行")
+		return nil, nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	close(gate)
+	assert.Equal(t, "value", <-resultCh, "一个调用方放弃等待不应影响其他调用方")
+}
+
+func TestGroup_ForgetStartsNewCall(t *testing.T) {
+	var g singleflight.Group
+	gate := make(chan struct{})
+	started := make(chan struct{})
+
+	go g.Do("key", func() (interface{}, error) {
+		close(started)
+		<-gate
+		return nil, nil
+	})
+	<-started
+
+	g.Forget("key")
+	v, _, shared := g.Do("key", func() (interface{}, error) { return "fresh", nil })
+	assert.Equal(t, "fresh", v)
+	assert.False(t, shared)
+	close(gate)
+}
+
+func TestGroup_DoWithContextRecoversPanic(t *testing.T) {
+	var g singleflight.Group
+	gate := make(chan struct{})
+
+	const n = 5
+	errs := make([]error, n)
+	wg := fireConcurrent(n, func(i int) {
+		_, errs[i], _ = g.DoWithContext(context.Background(), "key", func() (interface{}, error) {
+			<-gate
+			panic("boom")
+		})
+	})
+
+	time.Sleep(50 * time.Millisecond)
+	close(gate)
+	wg.Wait()
+
+	// panic发生在后台协程中，不会使进程崩溃，所有等待者都收到PanicError
+	for i := 0; i < n; i++ {
+		require.ErrorIs(t, errs[i], singleflight.ErrPanicked)
+		var panicErr *singleflight.PanicError
+		require.True(t, errors.As(errs[i], &panicErr))
+		assert.Equal(t, "boom", panicErr.Value)
+		assert.NotEmpty(t, panicErr.Stack)
+	}
+
+	// 之后的调用重新执行
+	v, err, _ := g.DoWithContext(context.Background(), "key", func() (interface{}, error) {
+		return "value", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "value", v)
+}
@@ -0,0 +1,107 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// envTestConfig 覆盖时长、切片和嵌套结构体的配置
+type envTestConfig struct {
+	Timeout time.Duration `env:"TEST_TIMEOUT" default:"5s"`
+	Peers   []string      `env:"TEST_PEERS"`
+	Ports   []int         `env:"TEST_PORTS"`
+	Nested  struct {
+		Interval time.Duration `env:"TEST_INTERVAL"`
+	}
+}
+
+func TestApplyEnvironmentVariables_DurationsAndSlices(t *testing.T) {
+	t.Setenv("TEST_TIMEOUT", "1m30s")
+	t.Setenv("TEST_PEERS", " a, b ,,c ")
+	t.Setenv("TEST_PORTS", "8080,9090")
+	t.Setenv("TEST_INTERVAL", "250ms")
+
+	var cfg envTestConfig
+	require.NoError(t, config.ApplyEnvironmentVariables(&cfg))
+	assert.Equal(t, 90*time.Second, cfg.Timeout)
+	assert.Equal(t, []string{"a", "b", "c"}, cfg.Peers)
+	assert.Equal(t, []int{8080, 9090}, cfg.Ports)
+	assert.Equal(t, 250*time.Millisecond, cfg.Nested.Interval)
+}
+
+func TestApplyEnvironmentVariables_MalformedValues(t *testing.T) {
+	for env, value := range map[string]string{
+		"TEST_TIMEOUT": "soon",
+		"TEST_PORTS":   "8080,http",
+	} {
+		t.Run(env, func(t *testing.T) {
+			t.Setenv(env, value)
+			var cfg envTestConfig
+			err := config.ApplyEnvironmentVariables(&cfg)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), env, "错误信息应指出环境变量名")
+			assert.Nil(t, cfg.Ports, "解析失败时不应修改字段")
+		})
+	}
+}
+
+func TestApplyDefaults_ParsesDurationTags(t *testing.T) {
+	var cfg envTestConfig
+	config.ApplyDefaults(&cfg)
+	assert.Equal(t, 5*time.Second, cfg.Timeout)
+}
+
+// pointerSection 带默认值的可选配置段
+type pointerSection struct {
+	Timeout time.Duration `env:"TEST_SECTION_TIMEOUT" default:"2s"`
+	Level   string        `env:"TEST_SECTION_LEVEL" default:"info"`
+}
+
+// bareSection 没有默认值的可选配置段
+type bareSection struct {
+	Name string `env:"TEST_BARE_NAME"`
+}
+
+// pointerTestConfig 包含结构体指针字段的配置
+type pointerTestConfig struct {
+	Section *pointerSection
+	Bare    *bareSection
+}
+
+func TestApplyDefaults_PointerSections(t *testing.T) {
+	var cfg pointerTestConfig
+	config.ApplyDefaults(&cfg)
+	require.NotNil(t, cfg.Section, "声明了默认值的配置段应被分配")
+	assert.Equal(t, 2*time.Second, cfg.Section.Timeout)
+	assert.Equal(t, "info", cfg.Section.Level)
+	assert.Nil(t, cfg.Bare, "没有默认值的配置段保持nil")
+
+	// 已分配的配置段只填充零值字段
+	cfg = pointerTestConfig{Section: &pointerSection{Level: "debug"}}
+	config.ApplyDefaults(&cfg)
+	assert.Equal(t, "debug", cfg.Section.Level)
+	assert.Equal(t, 2*time.Second, cfg.Section.Timeout)
+}
+
+func TestApplyEnvironmentVariables_PointerSections(t *testing.T) {
+	var cfg pointerTestConfig
+	require.NoError(t, config.ApplyEnvironmentVariables(&cfg))
+	assert.Nil(t, cfg.Section, "没有环境变量时不分配配置段")
+	assert.Nil(t, cfg.Bare)
+
+	t.Setenv("TEST_SECTION_TIMEOUT", "750ms")
+	t.Setenv("TEST_BARE_NAME", "from-env")
+	cfg = pointerTestConfig{Section: &pointerSection{Level: "debug"}}
+	require.NoError(t, config.ApplyEnvironmentVariables(&cfg))
+	assert.Equal(t, 750*time.Millisecond, cfg.Section.Timeout)
+	assert.Equal(t, "debug", cfg.Section.Level, "未设置环境变量的字段保持原值")
+	require.NotNil(t, cfg.Bare, "环境变量设置了其中的字段时应分配配置段")
+	assert.Equal(t, "from-env", cfg.Bare.Name)
+
+	t.Setenv("TEST_SECTION_TIMEOUT", "later")
+	assert.ErrorContains(t, config.ApplyEnvironmentVariables(&cfg), "TEST_SECTION_TIMEOUT")
+}
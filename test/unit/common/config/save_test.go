@@ -0,0 +1,146 @@
+package config_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/config"
+	"github.com/22827099/DFS_v1/common/types"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// sampleSystemConfig 返回各字段都不是默认值的配置
+func sampleSystemConfig() *config.SystemConfig {
+	return &config.SystemConfig{
+		NodeID:     types.NodeID("node-save"),
+		MetaServer: "meta:9000",
+		DataDir:    "/var/data/save",
+		ChunkSize:  4096,
+		Replicas:   3,
+		Logging:    config.LoggingConfig{Level: "warn", Console: false, File: "logs/save.log"},
+		Server: config.ServerConfig{
+			Host:          "127.0.0.1",
+			Port:          9090,
+			ReadTimeout:   15 * time.Second,
+			WriteTimeout:  time.Minute,
+			RequireLeader: true,
+		},
+	}
+}
+
+func TestSaveConfig_RoundTripsEachFormat(t *testing.T) {
+	config.DisableEnvOverrideForTests()
+	t.Cleanup(config.EnableEnvOverrideForTests)
+
+	unmarshal := map[string]func([]byte, interface{}) error{
+		"config.json": json.Unmarshal,
+		"config.yaml": yaml.Unmarshal,
+		"config.yml":  yaml.Unmarshal,
+		"config.toml": toml.Unmarshal,
+	}
+	for name, parse := range unmarshal {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			want := sampleSystemConfig()
+			require.NoError(t, config.SaveConfig(want, path))
+
+			// 保存的文件是原格式
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			var parsed config.SystemConfig
+			require.NoError(t, parse(data, &parsed))
+			assert.Equal(t, *want, parsed)
+
+			got := &config.SystemConfig{}
+			require.NoError(t, config.LoadConfig(path, got))
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestSaveConfig_LoadedTOMLStaysTOML(t *testing.T) {
+	config.DisableEnvOverrideForTests()
+	t.Cleanup(config.EnableEnvOverrideForTests)
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	createConfigFile(t, path, []byte(`
+node_id = "toml-node"
+meta_server = "toml-server:7070"
+replicas = 5
+
+[logging]
+level = "warn"
+`))
+	cfg, err := config.LoadConfigTOML(path)
+	require.NoError(t, err)
+	cfg.Replicas = 2
+	require.NoError(t, config.SaveConfig(cfg, path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var parsed config.SystemConfig
+	require.NoError(t, toml.Unmarshal(data, &parsed), "保存后仍应是TOML")
+	assert.Equal(t, 2, parsed.Replicas)
+
+	reloaded, err := config.LoadConfigTOML(path)
+	require.NoError(t, err)
+	assert.Equal(t, cfg, reloaded)
+}
+
+func TestSaveConfig_PreservesYAMLComments(t *testing.T) {
+	config.DisableEnvOverrideForTests()
+	t.Cleanup(config.EnableEnvOverrideForTests)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	createConfigFile(t, path, []byte(`# 节点配置
+replicas: 5 # 副本数
+node_id: "yaml-node"
+custom_key: keep-me
+server:
+  # 读超时
+  read_timeout: 30s
+  port: 8080
+`))
+	cfg, err := config.LoadSystemConfig(path)
+	require.NoError(t, err)
+	cfg.Replicas = 2
+	cfg.Server.Port = 9090
+	require.NoError(t, config.SaveConfig(cfg, path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	content := string(data)
+	assert.Contains(t, content, "# 节点配置")
+	assert.Contains(t, content, "replicas: 2 # 副本数")
+	assert.Contains(t, content, "# 读超时")
+	assert.Contains(t, content, "read_timeout: 30s", "未修改的时长保留原写法")
+	assert.Contains(t, content, "custom_key: keep-me", "配置结构体之外的键应保留")
+	// 原有的键保持原顺序
+	assert.Less(t, strings.Index(content, "replicas:"), strings.Index(content, "node_id:"))
+
+	reloaded, err := config.LoadSystemConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, cfg, reloaded)
+}
+
+func TestSaveConfigAs_ExplicitFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.conf")
+	cfg := sampleSystemConfig()
+
+	assert.Error(t, config.SaveConfig(cfg, path), "无法从扩展名判断格式")
+	assert.Error(t, config.SaveConfigAs(cfg, path, config.Format("ini")))
+
+	require.NoError(t, config.SaveConfigAs(cfg, path, config.FormatTOML))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var parsed config.SystemConfig
+	require.NoError(t, toml.Unmarshal(data, &parsed))
+	assert.Equal(t, *cfg, parsed)
+}
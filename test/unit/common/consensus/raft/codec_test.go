@@ -0,0 +1,80 @@
+package raft_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// codecs 待测试的命令编解码器
+var codecs = map[string]raft.CommandCodec{
+	"json": raft.JSONCodec{},
+	"gob":  raft.GobCodec{},
+}
+
+// sampleCommands 覆盖空值、二进制数据和非ASCII键的命令
+var sampleCommands = []raft.Command{
+	{Op: "put", Key: "/a/b.txt", Value: []byte(`{"size":1024}`)},
+	{Op: "delete", Key: "/a/b.txt"},
+	{Op: "put", Key: "/目录/文件", Value: []byte{0x00, 0xff, 0x10}},
+}
+
+func TestCommandCodec_RoundTrip(t *testing.T) {
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			for _, cmd := range sampleCommands {
+				data, err := codec.Encode(cmd)
+				require.NoError(t, err)
+				decoded, err := codec.Decode(data)
+				require.NoError(t, err)
+				assert.Equal(t, cmd, decoded)
+			}
+		})
+	}
+}
+
+func TestCommandCodec_RejectsInvalidData(t *testing.T) {
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			_, err := codec.Encode(raft.Command{Key: "k"})
+			assert.ErrorIs(t, err, raft.ErrEmptyCommand)
+
+			_, err = codec.Decode([]byte("cmd-0"))
+			assert.Error(t, err, "未经编码的原始数据不是命令")
+		})
+	}
+
+	// 编解码器不一致时不能把其他格式的数据误当作命令
+	data, err := raft.GobCodec{}.Encode(sampleCommands[0])
+	require.NoError(t, err)
+	_, err = raft.JSONCodec{}.Decode(data)
+	assert.Error(t, err)
+}
+
+func TestCommandCodec_StateMachineDecodesProposedCommands(t *testing.T) {
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			node := newLeaderNode(t)
+
+			for _, cmd := range sampleCommands {
+				data, err := codec.Encode(cmd)
+				require.NoError(t, err)
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				_, err = node.ProposeWait(ctx, data, raft.ConsistencyQuorum)
+				cancel()
+				require.NoError(t, err)
+			}
+
+			// 状态机从应用通道解码出的命令与提议的命令一致且顺序相同
+			for _, want := range sampleCommands {
+				got, err := codec.Decode(nextCommand(t, node).Command)
+				require.NoError(t, err)
+				assert.Equal(t, want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package raft_test
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	etcdraft "go.etcd.io/etcd/raft/v3"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+)
+
+// newCompactingNode 启动单节点集群并等待其成为领导者
+func newCompactingNode(t *testing.T, threshold uint64, interval time.Duration) *raft.RaftNode {
+	t.Helper()
+	cfg := raft.DefaultConfig()
+	cfg.ElectionTick = 2
+	cfg.SnapshotThreshold = threshold
+	cfg.CompactionInterval = interval
+	node, err := raft.NewRaftNode(cfg, nopTransport{})
+	require.NoError(t, err)
+	t.Cleanup(node.Stop)
+
+	require.Eventually(t, node.IsLeader, 5*time.Second, 10*time.Millisecond, "单节点集群应当选领导者")
+	return node
+}
+
+// applyCommands 提交n个命令并等待全部应用，返回最后一个命令的索引
+func applyCommands(t *testing.T, node *raft.RaftNode, n int) uint64 {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		require.True(t, node.Propose([]byte(fmt.Sprintf("cmd-%d", i))))
+	}
+	var last uint64
+	for applied := 0; applied < n; {
+		msg := nextMsg(t, node)
+		if msg.CommandValid {
+			applied++
+			last = msg.CommandIndex
+		}
+	}
+	return last
+}
+
+func TestRaftNode_CompactsOnIntervalAfterSnapshot(t *testing.T) {
+	interval := 50 * time.Millisecond
+	node := newCompactingNode(t, 1000, interval)
+
+	index := applyCommands(t, node, 10)
+
+	// 没有快照时不压缩
+	time.Sleep(3 * interval)
+	assert.Zero(t, node.CompactedIndex())
+
+	// 可压缩的条目远少于阈值，只能由定期压缩丢弃
+	require.NoError(t, node.CreateSnapshot(index, []byte("state")))
+	assert.Zero(t, node.CompactedIndex(), "未达到阈值时不应立即压缩")
+	assert.Eventually(t, func() bool { return node.CompactedIndex() == index },
+		10*interval, 5*time.Millisecond, "应在下一次定期压缩时丢弃快照覆盖的日志")
+
+	// 压缩后仍可以继续提交
+	next := applyCommands(t, node, 1)
+	assert.Greater(t, next, index)
+}
+
+func TestRaftNode_CompactsImmediatelyAtThreshold(t *testing.T) {
+	node := newCompactingNode(t, 5, 0)
+
+	index := applyCommands(t, node, 10)
+	require.NoError(t, node.CreateSnapshot(index, []byte("state")))
+	assert.Equal(t, index, node.CompactedIndex())
+}
+
+func TestRaftNode_NoCompactionWithoutIntervalBelowThreshold(t *testing.T) {
+	node := newCompactingNode(t, 1000, 0)
+
+	index := applyCommands(t, node, 3)
+	require.NoError(t, node.CreateSnapshot(index, []byte("state")))
+	time.Sleep(100 * time.Millisecond)
+	assert.Zero(t, node.CompactedIndex())
+}
+
+func TestRaftNode_CreateSnapshotRejectsUnappliedIndex(t *testing.T) {
+	node := newCompactingNode(t, 0, 0)
+
+	index := applyCommands(t, node, 3)
+	assert.Error(t, node.CreateSnapshot(index+100, nil))
+	assert.Error(t, node.CreateSnapshot(0, nil))
+
+	require.NoError(t, node.CreateSnapshot(index, nil))
+	assert.Error(t, node.CreateSnapshot(index-1, nil), "快照不能回退")
+}
+
+func TestRaftNode_SnapshotsAutomaticallyPastThreshold(t *testing.T) {
+	threshold := uint64(5)
+	node := newCompactingNode(t, threshold, 0)
+
+	// 状态机在后台处理应用通道，回调返回已处理到的索引
+	var lastApplied uint64
+	var calls int32
+	go func() {
+		for msg := range node.ApplyCh() {
+			if msg.CommandValid {
+				atomic.StoreUint64(&lastApplied, msg.CommandIndex)
+			}
+		}
+	}()
+	node.SetSnapshotFunc(func() (uint64, []byte, error) {
+		atomic.AddInt32(&calls, 1)
+		index := atomic.LoadUint64(&lastApplied)
+		return index, []byte(fmt.Sprintf("state-%d", index)), nil
+	})
+
+	// 未超过阈值时不创建快照
+	for i := 0; i < int(threshold)-3; i++ {
+		require.True(t, node.Propose([]byte(fmt.Sprintf("cmd-%d", i))))
+	}
+	time.Sleep(100 * time.Millisecond)
+	assert.Zero(t, atomic.LoadInt32(&calls))
+	assert.Zero(t, node.SnapshotIndex())
+
+	for i := 0; i < 20; i++ {
+		require.True(t, node.Propose([]byte(fmt.Sprintf("more-%d", i))))
+	}
+	require.Eventually(t, func() bool { return node.SnapshotIndex() > 0 }, 5*time.Second, 10*time.Millisecond,
+		"超过阈值后应创建快照")
+	assert.Eventually(t, func() bool { return node.CompactedIndex() > 0 && node.CompactedIndex() <= node.SnapshotIndex() },
+		5*time.Second, 10*time.Millisecond, "创建快照后应压缩旧日志")
+	assert.LessOrEqual(t, node.SnapshotIndex(), node.AppliedIndex())
+}
+
+func TestMemoryStorage_EntriesReturnsErrCompacted(t *testing.T) {
+	storage := raft.NewMemoryStorage()
+	var entries []raftpb.Entry
+	for i := uint64(1); i <= 10; i++ {
+		entries = append(entries, raftpb.Entry{Index: i, Term: 1})
+	}
+	require.NoError(t, storage.SaveEntries(entries))
+
+	require.NoError(t, storage.CreateSnapshot(5, nil))
+	// 创建快照后压缩前条目仍可读取
+	_, err := storage.Entries(3, 6, math.MaxUint64)
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, storage.Compact())
+	_, err = storage.Entries(3, 6, math.MaxUint64)
+	assert.ErrorIs(t, err, etcdraft.ErrCompacted)
+	got, err := storage.Entries(6, 11, math.MaxUint64)
+	require.NoError(t, err)
+	assert.Len(t, got, 5)
+
+	// 全部条目被压缩后仍返回ErrCompacted
+	require.NoError(t, storage.CreateSnapshot(10, nil))
+	assert.Equal(t, 5, storage.Compact())
+	_, err = storage.Entries(10, 11, math.MaxUint64)
+	assert.ErrorIs(t, err, etcdraft.ErrCompacted)
+	_, err = storage.Entries(11, 12, math.MaxUint64)
+	assert.ErrorIs(t, err, etcdraft.ErrUnavailable)
+}
@@ -0,0 +1,101 @@
+package raft_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nextCommand 跳过配置变更等消息，返回下一个普通命令
+func nextCommand(t *testing.T, node *raft.RaftNode) raft.ApplyMsg {
+	t.Helper()
+	for {
+		if msg := nextMsg(t, node); msg.CommandValid {
+			return msg
+		}
+	}
+}
+
+func TestParseConsistencyLevel(t *testing.T) {
+	for input, want := range map[string]raft.ConsistencyLevel{
+		"":       raft.DefaultConsistencyLevel,
+		"one":    raft.ConsistencyOne,
+		"QUORUM": raft.ConsistencyQuorum,
+		" all ":  raft.ConsistencyAll,
+	} {
+		level, err := raft.ParseConsistencyLevel(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, level)
+	}
+
+	_, err := raft.ParseConsistencyLevel("two")
+	assert.Error(t, err)
+}
+
+func TestProposeWait_SingleNode(t *testing.T) {
+	node := newLeaderNode(t)
+
+	for _, level := range []raft.ConsistencyLevel{raft.ConsistencyOne, raft.ConsistencyQuorum, raft.ConsistencyAll} {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		index, err := node.ProposeWait(ctx, []byte("cmd-"+string(level)), level)
+		cancel()
+		require.NoError(t, err, level)
+
+		// 应用通道收到的是原始命令
+		msg := nextCommand(t, node)
+		assert.Equal(t, "cmd-"+string(level), string(msg.Command))
+		if level != raft.ConsistencyOne {
+			assert.Equal(t, msg.CommandIndex, index)
+			assert.GreaterOrEqual(t, node.AppliedIndex(), index, "quorum和all返回时条目已应用")
+		}
+	}
+}
+
+func TestProposeWait_AllRequiresLeader(t *testing.T) {
+	cfg := raft.DefaultConfig()
+	cfg.Peers = []uint64{1, 2, 3}
+	node, err := raft.NewRaftNode(cfg, nopTransport{})
+	require.NoError(t, err)
+	t.Cleanup(node.Stop)
+
+	_, err = node.ProposeWait(context.Background(), []byte("x"), raft.ConsistencyAll)
+	assert.ErrorIs(t, err, raft.ErrNotLeader)
+
+	_, err = node.ProposeWait(context.Background(), []byte("x"), raft.ConsistencyLevel("two"))
+	assert.Error(t, err)
+}
+
+func TestProposeAndWait_TimesOutWithoutQuorum(t *testing.T) {
+	// 其他成员不可达，节点选不出领导者，提议一直被丢弃
+	cfg := raft.DefaultConfig()
+	cfg.Peers = []uint64{1, 2, 3}
+	node, err := raft.NewRaftNode(cfg, nopTransport{})
+	require.NoError(t, err)
+	t.Cleanup(node.Stop)
+
+	start := time.Now()
+	_, err = node.ProposeAndWait(context.Background(), []byte("x"), 200*time.Millisecond)
+	assert.ErrorIs(t, err, raft.ErrApplyTimeout)
+	assert.Less(t, time.Since(start), time.Second)
+
+	// 调用方的ctx先结束时返回ctx的错误
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = node.ProposeAndWait(ctx, []byte("x"), time.Second)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotErrorIs(t, err, raft.ErrApplyTimeout)
+}
+
+func TestProposeAndWait_SingleNode(t *testing.T) {
+	node := newLeaderNode(t)
+
+	index, err := node.ProposeAndWait(context.Background(), []byte("cmd"), 0)
+	require.NoError(t, err)
+	msg := nextCommand(t, node)
+	assert.Equal(t, "cmd", string(msg.Command))
+	assert.Equal(t, msg.CommandIndex, index)
+}
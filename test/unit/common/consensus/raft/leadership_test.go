@@ -0,0 +1,46 @@
+package raft_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRaftNode_ReportsLeadershipAfterApplying(t *testing.T) {
+	cfg := raft.DefaultConfig()
+	cfg.ElectionTick = 2
+	cfg.ApplyBufferSize = 0 // 没有接收方时已提交的条目无法交给应用通道
+	node, err := raft.NewRaftNode(cfg, nopTransport{})
+	require.NoError(t, err)
+	t.Cleanup(node.Stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, node.Campaign(ctx))
+
+	// 启动时的成员配置条目尚未应用，当选也不能对外报告
+	time.Sleep(300 * time.Millisecond)
+	assert.False(t, node.IsLeader(), "已提交条目应用前不应报告领导权")
+	select {
+	case <-node.LeaderCh():
+		t.Fatal("已提交条目应用前不应通知领导权")
+	default:
+	}
+
+	msg := nextMsg(t, node)
+	require.True(t, msg.ConfChangeValid)
+
+	require.Eventually(t, node.IsLeader, 5*time.Second, 10*time.Millisecond)
+	// 当选时追加的空条目也已应用，之前任期的条目全部交给了应用通道
+	assert.GreaterOrEqual(t, node.AppliedIndex(), msg.ConfChangeIndex+1)
+	select {
+	case isLeader := <-node.LeaderCh():
+		assert.True(t, isLeader)
+	case <-time.After(time.Second):
+		t.Fatal("应通知领导权变更")
+	}
+}
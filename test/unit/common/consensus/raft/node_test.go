@@ -0,0 +1,91 @@
+package raft_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+)
+
+// nopTransport 丢弃所有消息的传输层，单节点集群不需要发送消息
+type nopTransport struct{}
+
+func (nopTransport) Send([]raftpb.Message) {}
+func (nopTransport) Start() error          { return nil }
+func (nopTransport) Stop()                 {}
+
+// newLeaderNode 启动单节点集群并等待其成为领导者
+func newLeaderNode(t *testing.T) *raft.RaftNode {
+	t.Helper()
+	cfg := raft.DefaultConfig()
+	cfg.ElectionTick = 2
+	node, err := raft.NewRaftNode(cfg, nopTransport{})
+	require.NoError(t, err)
+	t.Cleanup(node.Stop)
+
+	require.Eventually(t, node.IsLeader, 5*time.Second, 10*time.Millisecond, "单节点集群应当选领导者")
+	return node
+}
+
+// nextMsg 从应用通道读取下一条消息
+func nextMsg(t *testing.T, node *raft.RaftNode) raft.ApplyMsg {
+	t.Helper()
+	select {
+	case msg := <-node.ApplyCh():
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("等待应用消息超时")
+		return raft.ApplyMsg{}
+	}
+}
+
+func TestRaftNode_ConfChangeNotAppliedAsCommand(t *testing.T) {
+	node := newLeaderNode(t)
+
+	cc := raftpb.ConfChange{Type: raftpb.ConfChangeAddNode, NodeID: 2, Context: []byte("node-2")}
+	ccData, err := cc.Marshal()
+	require.NoError(t, err)
+
+	require.True(t, node.Propose([]byte("cmd-1")))
+	// 等命令提交后再提交配置变更：新增节点后单节点无法再形成多数派
+	var commands [][]byte
+	for len(commands) == 0 {
+		msg := nextMsg(t, node)
+		require.False(t, msg.CommandValid && msg.ConfChangeValid)
+		if msg.CommandValid {
+			commands = append(commands, msg.Command)
+		}
+	}
+	require.NoError(t, node.ProposeConfChange(context.Background(), cc))
+
+	var applied *raft.ApplyMsg
+	for applied == nil {
+		msg := nextMsg(t, node)
+		if msg.CommandValid {
+			assert.NotEqual(t, ccData, msg.Command, "配置变更数据不应作为命令交给状态机")
+			commands = append(commands, msg.Command)
+			continue
+		}
+		if msg.ConfChangeValid && len(msg.ConfChange.Changes) == 1 && msg.ConfChange.Changes[0].NodeID == 2 {
+			applied = &msg
+		}
+	}
+
+	assert.Equal(t, [][]byte{[]byte("cmd-1")}, commands)
+	assert.Equal(t, raftpb.ConfChangeAddNode, applied.ConfChange.Changes[0].Type)
+	assert.Equal(t, []byte("node-2"), applied.ConfChange.Context)
+	assert.ElementsMatch(t, []uint64{1, 2}, applied.ConfState.Voters)
+	assert.Greater(t, applied.ConfChangeIndex, uint64(0))
+}
+
+func TestNewRaftNode_RejectsNodeIDAboveMax(t *testing.T) {
+	cfg := raft.DefaultConfig()
+	cfg.NodeID = raft.MaxNodeID + 1
+
+	_, err := raft.NewRaftNode(cfg, nopTransport{})
+	assert.Error(t, err, "超过上限的节点ID会与其他节点的请求ID冲突")
+}
@@ -0,0 +1,80 @@
+package raft_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+)
+
+// recordingTransport 记录收到的消息
+type recordingTransport struct {
+	nopTransport
+	mu   sync.Mutex
+	sent []uint64
+}
+
+func (t *recordingTransport) Send(messages []raftpb.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, msg := range messages {
+		t.sent = append(t.sent, msg.To)
+	}
+}
+
+func (t *recordingTransport) Sent() []uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]uint64(nil), t.sent...)
+}
+
+func TestPartitionFilter_Symmetric(t *testing.T) {
+	filter := raft.NewPartitionFilter()
+	assert.True(t, filter.Allowed(1, 2))
+
+	filter.Partition(1, 2)
+	assert.False(t, filter.Allowed(1, 2))
+	assert.False(t, filter.Allowed(2, 1), "分区应双向生效")
+	assert.True(t, filter.Allowed(1, 3))
+
+	filter.Heal(2, 1)
+	assert.True(t, filter.Allowed(1, 2))
+}
+
+func TestPartitionFilter_Groups(t *testing.T) {
+	filter := raft.NewPartitionFilter()
+	filter.PartitionGroups([]uint64{1, 2}, []uint64{3, 4, 5})
+
+	assert.True(t, filter.Allowed(1, 2))
+	assert.True(t, filter.Allowed(3, 5))
+	for _, a := range []uint64{1, 2} {
+		for _, b := range []uint64{3, 4, 5} {
+			assert.False(t, filter.Allowed(a, b), "%d->%d", a, b)
+			assert.False(t, filter.Allowed(b, a), "%d->%d", b, a)
+		}
+	}
+
+	filter.HealAll()
+	assert.True(t, filter.Allowed(1, 5))
+}
+
+func TestFilteredTransport_DropsPartitionedMessages(t *testing.T) {
+	filter := raft.NewPartitionFilter()
+	inner := &recordingTransport{}
+	transport := raft.NewFilteredTransport(inner, 1, filter)
+
+	messages := []raftpb.Message{{From: 1, To: 2}, {From: 1, To: 3}}
+	transport.Send(messages)
+	assert.Equal(t, []uint64{2, 3}, inner.Sent())
+
+	filter.Partition(1, 3)
+	transport.Send(messages)
+	assert.Equal(t, []uint64{2, 3, 2}, inner.Sent())
+
+	filter.Partition(1, 2)
+	transport.Send(messages)
+	assert.Equal(t, []uint64{2, 3, 2}, inner.Sent(), "全部被阻断时不应调用底层传输层")
+	assert.Len(t, messages, 2, "不应修改调用方的消息切片")
+}
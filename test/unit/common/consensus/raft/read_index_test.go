@@ -0,0 +1,48 @@
+package raft_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadIndex_SingleNode(t *testing.T) {
+	node := newLeaderNode(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	index, err := node.ProposeAndWait(ctx, []byte("cmd"), 0)
+	require.NoError(t, err)
+
+	readIndex, err := node.ReadIndex(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, readIndex, index, "读取索引应包含之前已提交的写入")
+	require.NoError(t, node.WaitApplied(ctx, readIndex))
+	assert.GreaterOrEqual(t, node.AppliedIndex(), readIndex)
+}
+
+func TestReadIndex_WaitsForLeader(t *testing.T) {
+	// 其他成员不可达，没有领导者，请求一直被丢弃
+	cfg := raft.DefaultConfig()
+	cfg.Peers = []uint64{1, 2, 3}
+	node, err := raft.NewRaftNode(cfg, nopTransport{})
+	require.NoError(t, err)
+	t.Cleanup(node.Stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err = node.ReadIndex(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// 等待尚未提交的索引同样在ctx结束时返回
+	err = node.WaitApplied(ctx, 100)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	node.Stop()
+	_, err = node.ReadIndex(context.Background())
+	assert.ErrorIs(t, err, raft.ErrStopped)
+}
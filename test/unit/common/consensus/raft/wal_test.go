@@ -0,0 +1,228 @@
+package raft_test
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+)
+
+// makeEntries 生成索引从first到last、任期为term的日志条目
+func makeEntries(first, last, term uint64) []raftpb.Entry {
+	var entries []raftpb.Entry
+	for i := first; i <= last; i++ {
+		entries = append(entries, raftpb.Entry{Index: i, Term: term, Type: raftpb.EntryNormal, Data: []byte{byte(i)}})
+	}
+	return entries
+}
+
+// openWAL 打开dir中的WAL存储，测试结束时关闭
+func openWAL(t *testing.T, dir string) *raft.WALStorage {
+	t.Helper()
+	storage, err := raft.NewWALStorage(dir)
+	require.NoError(t, err)
+	t.Cleanup(func() { storage.Close() })
+	return storage
+}
+
+// assertSameLog 比较两个存储中的日志范围、条目和任期
+func assertSameLog(t *testing.T, want, got *raft.WALStorage) {
+	t.Helper()
+	wantFirst, err := want.FirstIndex()
+	require.NoError(t, err)
+	wantLast, err := want.LastIndex()
+	require.NoError(t, err)
+
+	first, err := got.FirstIndex()
+	require.NoError(t, err)
+	last, err := got.LastIndex()
+	require.NoError(t, err)
+	assert.Equal(t, wantFirst, first)
+	assert.Equal(t, wantLast, last)
+
+	wantEntries, err := want.Entries(wantFirst, wantLast+1, math.MaxUint64)
+	require.NoError(t, err)
+	entries, err := got.Entries(first, last+1, math.MaxUint64)
+	require.NoError(t, err)
+	assert.Equal(t, wantEntries, entries)
+
+	for i := wantFirst - 1; i <= wantLast; i++ {
+		wantTerm, err := want.Term(i)
+		require.NoError(t, err)
+		term, err := got.Term(i)
+		require.NoError(t, err)
+		assert.Equal(t, wantTerm, term, "索引%d的任期", i)
+	}
+}
+
+func TestWALStorage_ReopenRestoresLog(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := raft.NewWALStorage(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, storage.SaveEntries(makeEntries(1, 10, 1)))
+	// 新任期的条目覆盖冲突的尾部
+	require.NoError(t, storage.SaveEntries(makeEntries(8, 12, 2)))
+	hardState := raftpb.HardState{Term: 2, Vote: 1, Commit: 11}
+	require.NoError(t, storage.SaveState(hardState))
+	confState := raftpb.ConfState{Voters: []uint64{1, 2, 3}}
+	require.NoError(t, storage.SetConfState(confState))
+	require.NoError(t, storage.Close())
+
+	reopened := openWAL(t, dir)
+	assertSameLog(t, storage, reopened)
+
+	last, err := reopened.LastIndex()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(12), last)
+	term, err := reopened.Term(9)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), term, "被覆盖的条目不应在重放后恢复")
+
+	gotHardState, gotConfState, err := reopened.InitialState()
+	require.NoError(t, err)
+	assert.Equal(t, hardState, gotHardState)
+	assert.Equal(t, confState.Voters, gotConfState.Voters)
+}
+
+func TestWALStorage_ReopenAfterSnapshotAndCompaction(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := raft.NewWALStorage(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, storage.SaveEntries(makeEntries(1, 20, 1)))
+	require.NoError(t, storage.SaveState(raftpb.HardState{Term: 1, Commit: 20}))
+	require.NoError(t, storage.SetConfState(raftpb.ConfState{Voters: []uint64{1}}))
+	require.NoError(t, storage.CreateSnapshot(15, []byte("state")))
+	assert.Equal(t, 15, storage.Compact())
+	require.NoError(t, storage.SaveEntries(makeEntries(21, 25, 1)))
+	require.NoError(t, storage.Close())
+
+	reopened := openWAL(t, dir)
+	assertSameLog(t, storage, reopened)
+
+	first, err := reopened.FirstIndex()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(16), first, "快照覆盖的条目不应在重放后恢复")
+
+	snapshot, err := reopened.Snapshot()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(15), snapshot.Metadata.Index)
+	assert.Equal(t, []byte("state"), snapshot.Data)
+	assert.Equal(t, []uint64{1}, snapshot.Metadata.ConfState.Voters)
+}
+
+func TestWALStorage_SnapshotFromLeaderReplacesLog(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := raft.NewWALStorage(dir)
+	require.NoError(t, err)
+
+	// 落后的跟随者：旧任期的日志中有未提交的尾部，快照之后的部分与领导者冲突
+	require.NoError(t, storage.SaveEntries(makeEntries(1, 30, 1)))
+	snapshot := raftpb.Snapshot{
+		Data:     []byte("state"),
+		Metadata: raftpb.SnapshotMetadata{Index: 20, Term: 2, ConfState: raftpb.ConfState{Voters: []uint64{1, 2, 3}}},
+	}
+
+	// 与处理Ready的顺序相同：先保存快照，再追加紧接在快照之后的条目
+	require.NoError(t, storage.SaveSnapshot(snapshot))
+	last, err := storage.LastIndex()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(20), last, "快照之后的旧条目不应保留")
+	require.NoError(t, storage.SaveEntries(makeEntries(21, 22, 2)))
+
+	first, err := storage.FirstIndex()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(21), first)
+	last, err = storage.LastIndex()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(22), last)
+	term, err := storage.Term(20)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), term)
+	require.NoError(t, storage.Close())
+
+	reopened := openWAL(t, dir)
+	assertSameLog(t, storage, reopened)
+}
+
+func TestWALStorage_TruncatesTornTail(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := raft.NewWALStorage(dir)
+	require.NoError(t, err)
+	require.NoError(t, storage.SaveEntries(makeEntries(1, 5, 1)))
+	require.NoError(t, storage.Close())
+
+	// 模拟写入最后一条记录时崩溃
+	path := filepath.Join(dir, "wal.log")
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, info.Size()-3))
+
+	reopened := openWAL(t, dir)
+	last, err := reopened.LastIndex()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), last)
+
+	// 截断后可以继续追加
+	require.NoError(t, reopened.SaveEntries(makeEntries(5, 6, 2)))
+	require.NoError(t, reopened.Close())
+	again := openWAL(t, dir)
+	assertSameLog(t, reopened, again)
+}
+
+func TestWALStorage_TruncatesRecordWithOversizedLength(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := raft.NewWALStorage(dir)
+	require.NoError(t, err)
+	require.NoError(t, storage.SaveEntries(makeEntries(1, 3, 1)))
+	require.NoError(t, storage.Close())
+
+	// 追加一个声明长度约4GiB的记录头，重放时不应按该长度分配内存
+	path := filepath.Join(dir, "wal.log")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	_, err = file.Write([]byte{1, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0})
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	reopened := openWAL(t, dir)
+	last, err := reopened.LastIndex()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), last)
+	require.NoError(t, reopened.SaveEntries(makeEntries(4, 4, 1)))
+}
+
+func TestRaftNode_RestartsFromStorageDir(t *testing.T) {
+	cfg := raft.DefaultConfig()
+	cfg.ElectionTick = 2
+	cfg.StorageDir = t.TempDir()
+
+	node, err := raft.NewRaftNode(cfg, nopTransport{})
+	require.NoError(t, err)
+	require.Eventually(t, node.IsLeader, 5*time.Second, 10*time.Millisecond)
+	index := applyCommands(t, node, 3)
+	node.Stop()
+
+	// 重启后从WAL恢复，重新应用已提交的命令并能继续当选和提交
+	restarted, err := raft.NewRaftNode(cfg, nopTransport{})
+	require.NoError(t, err)
+	t.Cleanup(restarted.Stop)
+
+	var commands []string
+	for len(commands) < 3 {
+		if msg := nextMsg(t, restarted); msg.CommandValid {
+			commands = append(commands, string(msg.Command))
+		}
+	}
+	assert.Equal(t, []string{"cmd-0", "cmd-1", "cmd-2"}, commands)
+
+	require.Eventually(t, restarted.IsLeader, 5*time.Second, 10*time.Millisecond)
+	assert.Greater(t, applyCommands(t, restarted, 1), index)
+}
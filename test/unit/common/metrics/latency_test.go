@@ -0,0 +1,42 @@
+package metrics_test
+
+import (
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector_RouteLatencyPercentiles(t *testing.T) {
+	collector := metrics.NewCollector("test")
+
+	// 100个请求：90个3ms，9个40ms，1个20000ms
+	for i := 0; i < 90; i++ {
+		collector.RecordHTTPRequest("GET", "/files/{path}", 200, 3)
+	}
+	for i := 0; i < 9; i++ {
+		collector.RecordHTTPRequest("GET", "/files/{path}", 200, 40)
+	}
+	collector.RecordHTTPRequest("GET", "/files/{path}", 200, 20000)
+	collector.RecordHTTPRequest("POST", "/files/{path}", 201, 7)
+
+	latencies := collector.GetRouteLatencies()
+	require.Len(t, latencies, 2)
+
+	get := latencies["GET /files/{path}"]
+	assert.Equal(t, uint64(100), get.Count)
+	assert.Equal(t, int64(5), get.P50Ms)
+	assert.Equal(t, int64(50), get.P95Ms)
+	assert.Equal(t, int64(50), get.P99Ms)
+	assert.Equal(t, int64(20000), get.MaxMs)
+	assert.InDelta(t, 206.3, get.AvgMs, 0.01)
+
+	// 分位数不超过观测到的最大值
+	post := latencies["POST /files/{path}"]
+	assert.Equal(t, uint64(1), post.Count)
+	assert.Equal(t, int64(7), post.P99Ms)
+
+	collector.Reset()
+	assert.Empty(t, collector.GetRouteLatencies())
+}
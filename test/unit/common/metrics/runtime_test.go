@@ -0,0 +1,88 @@
+package metrics_test
+
+import (
+	"encoding/json"
+	"os"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadRuntimeStats_ReflectsProcessState(t *testing.T) {
+	before := metrics.ReadRuntimeStats()
+	assert.GreaterOrEqual(t, before.Goroutines, 1)
+	assert.Greater(t, before.HeapAllocBytes, uint64(0))
+	assert.GreaterOrEqual(t, before.HeapSysBytes, before.HeapAllocBytes)
+	assert.GreaterOrEqual(t, before.SysBytes, before.HeapSysBytes)
+	assert.WithinDuration(t, time.Now(), before.Timestamp, time.Second)
+
+	// 新增的goroutine应反映在统计中
+	const extra = 20
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < extra; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-release
+		}()
+	}
+	during := metrics.ReadRuntimeStats()
+	close(release)
+	wg.Wait()
+	assert.GreaterOrEqual(t, during.Goroutines, before.Goroutines+extra)
+}
+
+func TestReadRuntimeStats_GCStats(t *testing.T) {
+	runtime.GC()
+	first := metrics.ReadRuntimeStats()
+	require.GreaterOrEqual(t, first.NumGC, uint32(1))
+	assert.False(t, first.LastGC.IsZero())
+	assert.GreaterOrEqual(t, first.LastGCPauseMs, 0.0)
+	assert.GreaterOrEqual(t, first.TotalGCPauseMs, first.LastGCPauseMs)
+
+	runtime.GC()
+	second := metrics.ReadRuntimeStats()
+	assert.Greater(t, second.NumGC, first.NumGC)
+	assert.GreaterOrEqual(t, second.TotalGCPauseMs, first.TotalGCPauseMs)
+}
+
+func TestReadRuntimeStats_OpenFDs(t *testing.T) {
+	before := metrics.ReadRuntimeStats()
+	if before.OpenFDs < 0 {
+		t.Skip("当前平台无法统计文件描述符")
+	}
+	assert.Greater(t, before.OpenFDs, 0)
+
+	// 打开的文件应计入统计
+	files := make([]*os.File, 0, 5)
+	for i := 0; i < cap(files); i++ {
+		f, err := os.CreateTemp(t.TempDir(), "fd")
+		require.NoError(t, err)
+		files = append(files, f)
+	}
+	during := metrics.ReadRuntimeStats()
+	for _, f := range files {
+		f.Close()
+	}
+	assert.GreaterOrEqual(t, during.OpenFDs, before.OpenFDs+len(files))
+}
+
+func TestRuntimeStats_JSONFields(t *testing.T) {
+	data, err := json.Marshal(metrics.ReadRuntimeStats())
+	require.NoError(t, err)
+
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &fields))
+	for _, key := range []string{"goroutines", "heap_alloc_bytes", "num_gc", "last_gc_pause_ms", "open_fds"} {
+		value, ok := fields[key]
+		require.True(t, ok, "缺少字段%s", key)
+		_, isNumber := value.(float64)
+		assert.True(t, isNumber, "字段%s应为数值", key)
+	}
+}
@@ -0,0 +1,252 @@
+package http_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	networkHttp "github.com/22827099/DFS_v1/common/network/http"
+)
+
+// wireCounter 记录中间件之外（即网络上）的请求体和响应体字节数
+type wireCounter struct {
+	mu              sync.Mutex
+	requestBytes    int
+	responseBytes   int
+	requestEncoding string
+	received        []byte
+}
+
+type countingWriter struct {
+	http.ResponseWriter
+	n *int
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	*w.n += len(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// newCompressionServer 启动使用压缩中间件的服务器，/echo原样返回收到的JSON
+func newCompressionServer(t *testing.T, counter *wireCounter, failures int32) *httptest.Server {
+	t.Helper()
+	var attempts atomic.Int32
+	echo := networkHttp.CompressionMiddleware(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("读取请求体失败: %v", err)
+		}
+		counter.mu.Lock()
+		counter.received = body
+		counter.mu.Unlock()
+
+		if attempts.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(strings.NewReader(string(raw)))
+		encoding := r.Header.Get("Content-Encoding")
+
+		var written int
+		echo.ServeHTTP(&countingWriter{ResponseWriter: w, n: &written}, r)
+
+		counter.mu.Lock()
+		counter.requestBytes = len(raw)
+		counter.requestEncoding = encoding
+		counter.responseBytes = written
+		counter.mu.Unlock()
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// largePayload 生成约数十KB、压缩率很高的目录列表
+func largePayload() []map[string]interface{} {
+	entries := make([]map[string]interface{}, 500)
+	for i := range entries {
+		entries[i] = map[string]interface{}{
+			"name":   fmt.Sprintf("file-%04d.txt", i),
+			"path":   fmt.Sprintf("/data/dir/file-%04d.txt", i),
+			"is_dir": false,
+			"size":   1024,
+		}
+	}
+	return entries
+}
+
+func TestCompression_LargeBodyRoundTrip(t *testing.T) {
+	counter := &wireCounter{}
+	server := newCompressionServer(t, counter, 0)
+	client := networkHttp.NewClient(server.URL, networkHttp.WithCompression())
+
+	payload := largePayload()
+	raw, _ := json.Marshal(payload)
+
+	var result []map[string]interface{}
+	if err := client.PostJSON(context.Background(), "/echo", payload, &result); err != nil {
+		t.Fatalf("PostJSON: 返回错误: %v", err)
+	}
+
+	counter.mu.Lock()
+	defer counter.mu.Unlock()
+	if counter.requestEncoding != "gzip" {
+		t.Errorf("期望请求体使用gzip编码，得到%q", counter.requestEncoding)
+	}
+	if string(counter.received) != string(raw) {
+		t.Error("服务端应看到解压后的原始请求体")
+	}
+	if counter.requestBytes >= len(raw) || counter.responseBytes >= len(raw) {
+		t.Errorf("压缩后的传输字节应更少: 原始%d，请求%d，响应%d", len(raw), counter.requestBytes, counter.responseBytes)
+	}
+	if len(result) != len(payload) || result[42]["name"] != "file-0042.txt" {
+		t.Errorf("客户端应透明解压响应，得到%d项", len(result))
+	}
+}
+
+func TestCompression_SmallBodyNotCompressed(t *testing.T) {
+	counter := &wireCounter{}
+	server := newCompressionServer(t, counter, 0)
+	client := networkHttp.NewClient(server.URL, networkHttp.WithCompression())
+
+	var result map[string]string
+	if err := client.PostJSON(context.Background(), "/echo", map[string]string{"k": "v"}, &result); err != nil {
+		t.Fatalf("PostJSON: 返回错误: %v", err)
+	}
+
+	counter.mu.Lock()
+	defer counter.mu.Unlock()
+	if counter.requestEncoding != "" {
+		t.Errorf("小于阈值的请求体不应压缩，得到编码%q", counter.requestEncoding)
+	}
+	if result["k"] != "v" {
+		t.Errorf("期望响应原样返回，得到%v", result)
+	}
+}
+
+func TestCompression_ThresholdConfigurable(t *testing.T) {
+	counter := &wireCounter{}
+	server := newCompressionServer(t, counter, 0)
+	client := networkHttp.NewClient(server.URL, networkHttp.WithCompression(), networkHttp.WithCompressionThreshold(8))
+
+	if err := client.PostJSON(context.Background(), "/echo", map[string]string{"key": "value"}, nil); err != nil {
+		t.Fatalf("PostJSON: 返回错误: %v", err)
+	}
+
+	counter.mu.Lock()
+	defer counter.mu.Unlock()
+	if counter.requestEncoding != "gzip" {
+		t.Errorf("超过自定义阈值的请求体应压缩，得到编码%q", counter.requestEncoding)
+	}
+}
+
+func TestCompression_RetryResendsCompressedBody(t *testing.T) {
+	counter := &wireCounter{}
+	server := newCompressionServer(t, counter, 2)
+	client := networkHttp.NewClient(server.URL,
+		networkHttp.WithCompression(),
+		networkHttp.WithRetryPolicy(3, 10*time.Millisecond))
+
+	payload := largePayload()
+	raw, _ := json.Marshal(payload)
+
+	var result []map[string]interface{}
+	if err := client.PostJSON(context.Background(), "/echo", payload, &result); err != nil {
+		t.Fatalf("PostJSON: 重试后应成功，返回错误: %v", err)
+	}
+
+	counter.mu.Lock()
+	defer counter.mu.Unlock()
+	if string(counter.received) != string(raw) {
+		t.Error("重试时应重新发送完整的压缩请求体")
+	}
+	if len(result) != len(payload) {
+		t.Errorf("期望%d项，得到%d项", len(payload), len(result))
+	}
+}
+
+func TestCompressionMiddleware_SkipsCases(t *testing.T) {
+	large := strings.Repeat("a", 4096)
+	handler := networkHttp.CompressionMiddleware(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/image" {
+			w.Header().Set("Content-Type", "image/png")
+		} else {
+			w.Header().Set("Content-Type", "text/plain")
+		}
+		w.Write([]byte(large))
+	}))
+
+	cases := []struct {
+		name     string
+		path     string
+		encoding string
+		want     string
+	}{
+		{"CompressesText", "/text", "gzip, deflate", "gzip"},
+		{"NoAcceptEncoding", "/text", "", ""},
+		{"GzipRefused", "/text", "gzip;q=0", ""},
+		{"AlreadyCompressedType", "/image", "gzip", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.encoding != "" {
+				req.Header.Set("Accept-Encoding", tc.encoding)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Encoding"); got != tc.want {
+				t.Errorf("期望Content-Encoding为%q，得到%q", tc.want, got)
+			}
+			if tc.want == "" && w.Body.String() != large {
+				t.Error("未压缩时响应体应原样返回")
+			}
+		})
+	}
+}
+
+func TestCompressionMiddleware_LimitsDecompressedBody(t *testing.T) {
+	var readErr error
+	var read int64
+	handler := networkHttp.CompressionMiddleware(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		read, readErr = io.Copy(io.Discard, r.Body)
+	}))
+
+	// 全零数据的压缩比很高，几十KB的请求体解压后超过上限
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	zeros := make([]byte, 1<<20)
+	for written := int64(0); written <= networkHttp.MaxDecompressedBodySize; written += int64(len(zeros)) {
+		gz.Write(zeros)
+	}
+	gz.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(compressed.Bytes()))
+	req.Header.Set("Content-Encoding", "gzip")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var maxBytesErr *http.MaxBytesError
+	if !errors.As(readErr, &maxBytesErr) {
+		t.Fatalf("期望解压超过上限时返回MaxBytesError，得到%v", readErr)
+	}
+	if read != networkHttp.MaxDecompressedBodySize {
+		t.Errorf("期望最多读取%d字节，实际读取%d字节", networkHttp.MaxDecompressedBodySize, read)
+	}
+}
@@ -0,0 +1,76 @@
+package http_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	networkHttp "github.com/22827099/DFS_v1/common/network/http"
+)
+
+// waitForConnections 等待连接数达到期望值，连接状态回调是异步的
+func waitForConnections(t *testing.T, server *networkHttp.Server, want int64) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if server.ActiveConnections() == want {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("活跃连接数期望%d，实际%d", want, server.ActiveConnections())
+}
+
+func TestServer_ActiveConnectionsTracksKeepAliveConnections(t *testing.T) {
+	server := networkHttp.NewServer("127.0.0.1:0")
+	server.GET("/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		server.Serve(listener)
+	}()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		server.Stop(ctx)
+		<-done
+	}()
+
+	if got := server.ActiveConnections(); got != 0 {
+		t.Fatalf("启动后活跃连接数应为0，实际%d", got)
+	}
+
+	// 每个客户端使用独立的Transport，各自保持一个keep-alive连接
+	const clientCount = 3
+	transports := make([]*http.Transport, clientCount)
+	for i := range transports {
+		transports[i] = &http.Transport{}
+		client := &http.Client{Transport: transports[i]}
+		resp, err := client.Get("http://" + listener.Addr().String() + "/ping")
+		if err != nil {
+			t.Fatalf("请求失败: %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	waitForConnections(t, server, clientCount)
+
+	// 关闭空闲连接后连接数回落
+	transports[0].CloseIdleConnections()
+	waitForConnections(t, server, clientCount-1)
+
+	for _, transport := range transports[1:] {
+		transport.CloseIdleConnections()
+	}
+	waitForConnections(t, server, 0)
+}
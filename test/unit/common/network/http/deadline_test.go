@@ -0,0 +1,102 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	networkHttp "github.com/22827099/DFS_v1/common/network/http"
+)
+
+// newDeadlineServer 启动带超时中间件的测试服务器，记录处理器上下文的剩余时间
+func newDeadlineServer(maxTimeout time.Duration, remaining chan<- time.Duration) *httptest.Server {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, ok := r.Context().Deadline()
+		if !ok {
+			remaining <- 0
+		} else {
+			remaining <- time.Until(deadline)
+		}
+		networkHttp.RespondJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	return httptest.NewServer(networkHttp.DeadlineMiddleware(maxTimeout)(handler))
+}
+
+func TestDeadlinePropagation(t *testing.T) {
+	remaining := make(chan time.Duration, 1)
+	server := newDeadlineServer(0, remaining)
+	defer server.Close()
+
+	client := networkHttp.NewClient(server.URL, networkHttp.WithClientTimeout(time.Minute))
+
+	budget := 2 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), budget)
+	defer cancel()
+
+	if err := client.GetJSON(ctx, "/", nil); err != nil {
+		t.Fatalf("GetJSON: 返回错误: %v", err)
+	}
+
+	got := <-remaining
+	if got <= 0 {
+		t.Fatalf("处理器上下文应当带有截止时间")
+	}
+	if got > budget || got < budget-500*time.Millisecond {
+		t.Errorf("处理器剩余时间应接近客户端预算%v，得到%v", budget, got)
+	}
+}
+
+func TestDeadlineFromClientTimeout(t *testing.T) {
+	remaining := make(chan time.Duration, 1)
+	server := newDeadlineServer(0, remaining)
+	defer server.Close()
+
+	// 上下文没有截止时间时，使用客户端自身的超时
+	client := networkHttp.NewClient(server.URL, networkHttp.WithClientTimeout(3*time.Second))
+	if err := client.GetJSON(context.Background(), "/", nil); err != nil {
+		t.Fatalf("GetJSON: 返回错误: %v", err)
+	}
+
+	got := <-remaining
+	if got > 3*time.Second || got < 2*time.Second {
+		t.Errorf("处理器剩余时间应接近客户端超时3s，得到%v", got)
+	}
+}
+
+func TestDeadlineCappedByServer(t *testing.T) {
+	remaining := make(chan time.Duration, 1)
+	server := newDeadlineServer(500*time.Millisecond, remaining)
+	defer server.Close()
+
+	client := networkHttp.NewClient(server.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := client.GetJSON(ctx, "/", nil); err != nil {
+		t.Fatalf("GetJSON: 返回错误: %v", err)
+	}
+
+	if got := <-remaining; got > 500*time.Millisecond {
+		t.Errorf("服务端应将预算限制在500ms以内，得到%v", got)
+	}
+}
+
+func TestDeadlineMiddlewareWithoutHeader(t *testing.T) {
+	var hasDeadline bool
+	handler := networkHttp.DeadlineMiddleware(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hasDeadline = r.Context().Deadline()
+	}))
+
+	for _, value := range []string{"", "abc", "-5", "0"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if value != "" {
+			req.Header.Set(networkHttp.RequestTimeoutHeader, value)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if hasDeadline {
+			t.Errorf("超时头为%q时不应设置截止时间", value)
+		}
+	}
+}
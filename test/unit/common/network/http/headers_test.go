@@ -0,0 +1,90 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	networkHttp "github.com/22827099/DFS_v1/common/network/http"
+)
+
+// newHeaderEchoServer 记录最后一次请求的请求头
+func newHeaderEchoServer(received *http.Header) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*received = r.Header.Clone()
+		networkHttp.RespondJSON(w, http.StatusOK, nil)
+	}))
+}
+
+func TestClient_DefaultUserAgent(t *testing.T) {
+	var received http.Header
+	server := newHeaderEchoServer(&received)
+	defer server.Close()
+
+	client := networkHttp.NewClient(server.URL)
+	if err := client.GetJSON(context.Background(), "/", nil); err != nil {
+		t.Fatalf("GetJSON: 返回错误: %v", err)
+	}
+
+	if got := received.Get("User-Agent"); got != networkHttp.DefaultUserAgent {
+		t.Errorf("期望默认User-Agent为%q，得到%q", networkHttp.DefaultUserAgent, got)
+	}
+}
+
+func TestClient_DefaultHeaders(t *testing.T) {
+	var received http.Header
+	server := newHeaderEchoServer(&received)
+	defer server.Close()
+
+	client := networkHttp.NewClient(server.URL,
+		networkHttp.WithUserAgent("metaserver/node-1"),
+		networkHttp.WithDefaultHeaders(map[string]string{
+			"X-Node-ID": "node-1",
+			"X-Cluster": "dfs-test",
+		}),
+	)
+
+	if err := client.GetJSON(context.Background(), "/", nil); err != nil {
+		t.Fatalf("GetJSON: 返回错误: %v", err)
+	}
+
+	if got := received.Get("User-Agent"); got != "metaserver/node-1" {
+		t.Errorf("期望User-Agent为'metaserver/node-1'，得到%q", got)
+	}
+	if got := received.Get("X-Node-ID"); got != "node-1" {
+		t.Errorf("期望X-Node-ID为'node-1'，得到%q", got)
+	}
+	if got := received.Get("X-Cluster"); got != "dfs-test" {
+		t.Errorf("期望X-Cluster为'dfs-test'，得到%q", got)
+	}
+}
+
+func TestClient_PerRequestHeadersOverrideDefaults(t *testing.T) {
+	var received http.Header
+	server := newHeaderEchoServer(&received)
+	defer server.Close()
+
+	client := networkHttp.NewClient(server.URL,
+		networkHttp.WithUserAgent("default-agent"),
+		networkHttp.WithDefaultHeaders(map[string]string{"X-Node-ID": "node-1", "X-Trace": "default"}),
+	)
+
+	err := client.DoJSON(context.Background(), http.MethodGet, "/", nil, nil, map[string]string{
+		"X-Node-ID":  "node-2",
+		"User-Agent": "custom-agent",
+	})
+	if err != nil {
+		t.Fatalf("DoJSON: 返回错误: %v", err)
+	}
+
+	if got := received.Get("X-Node-ID"); got != "node-2" {
+		t.Errorf("单次请求头应覆盖默认值，期望'node-2'，得到%q", got)
+	}
+	if got := received.Get("User-Agent"); got != "custom-agent" {
+		t.Errorf("单次请求头应覆盖User-Agent，期望'custom-agent'，得到%q", got)
+	}
+	if got := received.Get("X-Trace"); got != "default" {
+		t.Errorf("未覆盖的默认头应保留，期望'default'，得到%q", got)
+	}
+}
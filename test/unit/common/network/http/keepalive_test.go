@@ -0,0 +1,112 @@
+package http_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	networkHttp "github.com/22827099/DFS_v1/common/network/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startPingServer 启动提供/ping的服务器，返回监听地址，测试结束时停止服务器
+func startPingServer(t *testing.T, opts ...networkHttp.ServerOption) (*networkHttp.Server, string) {
+	t.Helper()
+	server := networkHttp.NewServer("127.0.0.1:0", opts...)
+	server.GET("/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		server.Serve(listener)
+	}()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		server.Stop(ctx)
+		<-done
+	})
+	return server, listener.Addr().String()
+}
+
+// pingOverConn 在原始连接上发送请求并读取响应
+func pingOverConn(t *testing.T, conn net.Conn, reader *bufio.Reader, header http.Header) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, "http://"+conn.RemoteAddr().String()+"/ping", nil)
+	require.NoError(t, err)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	require.NoError(t, req.Write(conn))
+	resp, err := http.ReadResponse(reader, req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp
+}
+
+// assertClosedByServer 检查服务器在timeout内关闭了连接
+func assertClosedByServer(t *testing.T, conn net.Conn, reader *bufio.Reader, timeout time.Duration) {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(timeout)))
+	_, err := reader.ReadByte()
+	require.Error(t, err)
+	netErr, ok := err.(net.Error)
+	assert.False(t, ok && netErr.Timeout(), "连接应被服务器关闭而不是读取超时")
+}
+
+func TestServer_IdleTimeoutClosesIdleConnection(t *testing.T) {
+	server, addr := startPingServer(t, networkHttp.WithIdleTimeout(100*time.Millisecond))
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	resp := pingOverConn(t, conn, reader, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.False(t, resp.Close, "默认应保持连接")
+
+	assertClosedByServer(t, conn, reader, 2*time.Second)
+	waitForConnections(t, server, 0)
+}
+
+func TestServer_MaxHeaderBytesRejectsOversizedHeaders(t *testing.T) {
+	_, addr := startPingServer(t, networkHttp.WithMaxHeaderBytes(1024))
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	// 标准库在限制之上额外保留4096字节的余量，请求头需明显超出
+	resp := pingOverConn(t, conn, reader, http.Header{"X-Large": {strings.Repeat("a", 16<<10)}})
+	assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, resp.StatusCode)
+
+	conn2, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn2.Close()
+	resp = pingOverConn(t, conn2, bufio.NewReader(conn2), http.Header{"X-Small": {"a"}})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestServer_KeepAlivesDisabledClosesAfterResponse(t *testing.T) {
+	_, addr := startPingServer(t, networkHttp.WithKeepAlivesDisabled())
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	resp := pingOverConn(t, conn, reader, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, resp.Close, "禁用keep-alive时响应应携带Connection: close")
+	assertClosedByServer(t, conn, reader, 2*time.Second)
+}
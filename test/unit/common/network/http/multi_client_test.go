@@ -0,0 +1,245 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	networkHttp "github.com/22827099/DFS_v1/common/network/http"
+)
+
+// newCountingServer 创建记录请求次数的测试服务器，status决定返回的状态码
+func newCountingServer(hits *int32, status *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		code := int(atomic.LoadInt32(status))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(map[string]int{"code": code})
+	}))
+}
+
+func TestMultiEndpointClient_RoutesAroundDeadEndpoint(t *testing.T) {
+	// 已关闭的服务器模拟不可达的端点
+	dead := httptest.NewServer(http.NotFoundHandler())
+	deadURL := dead.URL
+	dead.Close()
+
+	var hits int32
+	status := int32(http.StatusOK)
+	healthy := newCountingServer(&hits, &status)
+	defer healthy.Close()
+
+	client := networkHttp.NewMultiEndpointClient(
+		[]string{deadURL, healthy.URL},
+		networkHttp.WithEndpointCooldown(time.Minute),
+	)
+	defer client.Close()
+
+	for i := 0; i < 5; i++ {
+		var result map[string]int
+		if err := client.GetJSON(context.Background(), "/", &result); err != nil {
+			t.Fatalf("第%d次请求失败: %v", i, err)
+		}
+		if result["code"] != http.StatusOK {
+			t.Errorf("期望code为200，得到%d", result["code"])
+		}
+	}
+
+	if got := atomic.LoadInt32(&hits); got != 5 {
+		t.Errorf("所有请求都应到达健康端点，期望5次，得到%d次", got)
+	}
+
+	for _, status := range client.Endpoints() {
+		if status.BaseURL == deadURL && status.Healthy {
+			t.Errorf("不可达端点应被降级")
+		}
+		if status.BaseURL == healthy.URL && !status.Healthy {
+			t.Errorf("健康端点不应被降级")
+		}
+	}
+}
+
+func TestMultiEndpointClient_RetriesAfterCooldown(t *testing.T) {
+	var flakyHits, stableHits int32
+	flakyStatus := int32(http.StatusServiceUnavailable)
+	stableStatus := int32(http.StatusOK)
+	flaky := newCountingServer(&flakyHits, &flakyStatus)
+	defer flaky.Close()
+	stable := newCountingServer(&stableHits, &stableStatus)
+	defer stable.Close()
+
+	cooldown := 100 * time.Millisecond
+	client := networkHttp.NewMultiEndpointClient(
+		[]string{flaky.URL, stable.URL},
+		networkHttp.WithEndpointCooldown(cooldown),
+	)
+	defer client.Close()
+
+	// 第一次请求先失败在flaky上，随后切换到stable
+	for i := 0; i < 3; i++ {
+		if err := client.GetJSON(context.Background(), "/", nil); err != nil {
+			t.Fatalf("请求失败: %v", err)
+		}
+	}
+	if got := atomic.LoadInt32(&flakyHits); got != 1 {
+		t.Fatalf("冷却期内不应再访问故障端点，期望1次，得到%d次", got)
+	}
+
+	// 冷却期过后故障端点恢复，应重新被尝试并恢复健康
+	atomic.StoreInt32(&flakyStatus, http.StatusOK)
+	time.Sleep(cooldown + 20*time.Millisecond)
+
+	if err := client.GetJSON(context.Background(), "/", nil); err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	if got := atomic.LoadInt32(&flakyHits); got != 2 {
+		t.Errorf("冷却期过后应重新尝试故障端点，期望2次，得到%d次", got)
+	}
+	for _, status := range client.Endpoints() {
+		if !status.Healthy {
+			t.Errorf("端点%s恢复后应标记为健康", status.BaseURL)
+		}
+	}
+}
+
+func TestMultiEndpointClient_ClientErrorsDoNotFailover(t *testing.T) {
+	var firstHits, secondHits int32
+	notFound := int32(http.StatusNotFound)
+	ok := int32(http.StatusOK)
+	first := newCountingServer(&firstHits, &notFound)
+	defer first.Close()
+	second := newCountingServer(&secondHits, &ok)
+	defer second.Close()
+
+	client := networkHttp.NewMultiEndpointClient([]string{first.URL, second.URL})
+	defer client.Close()
+
+	if err := client.GetJSON(context.Background(), "/", nil); err == nil {
+		t.Fatalf("期望返回404错误")
+	}
+	if atomic.LoadInt32(&secondHits) != 0 {
+		t.Errorf("4xx响应不应切换端点")
+	}
+}
+
+func TestMultiEndpointClient_AllEndpointsDown(t *testing.T) {
+	dead := httptest.NewServer(http.NotFoundHandler())
+	deadURL := dead.URL
+	dead.Close()
+
+	client := networkHttp.NewMultiEndpointClient([]string{deadURL})
+	defer client.Close()
+
+	if err := client.GetJSON(context.Background(), "/", nil); err == nil {
+		t.Fatalf("所有端点不可用时应返回错误")
+	}
+}
+
+func TestMultiEndpointClient_HealthProbe(t *testing.T) {
+	var hits int32
+	status := int32(http.StatusServiceUnavailable)
+	server := newCountingServer(&hits, &status)
+	defer server.Close()
+
+	client := networkHttp.NewMultiEndpointClient(
+		[]string{server.URL},
+		networkHttp.WithEndpointCooldown(20*time.Millisecond),
+		networkHttp.WithHealthProbe("/health", 10*time.Millisecond),
+	)
+	defer client.Close()
+
+	_ = client.GetJSON(context.Background(), "/", nil)
+	atomic.StoreInt32(&status, http.StatusOK)
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if client.Endpoints()[0].Healthy {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Errorf("后台探测应在端点恢复后将其标记为健康")
+}
+
+// newDelayedServer 创建延迟响应的测试服务器，请求被取消时提前返回
+func newDelayedServer(delay time.Duration, hits *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"server": r.Host})
+	}))
+}
+
+func TestMultiEndpointClient_HedgedRead(t *testing.T) {
+	var slowHits, fastHits int32
+	slow := newDelayedServer(2*time.Second, &slowHits)
+	defer slow.Close()
+	fast := newDelayedServer(0, &fastHits)
+	defer fast.Close()
+
+	client := networkHttp.NewMultiEndpointClient(
+		[]string{slow.URL, fast.URL},
+		networkHttp.WithHedging(50*time.Millisecond),
+	)
+	defer client.Close()
+
+	start := time.Now()
+	var result map[string]string
+	if err := client.GetJSON(context.Background(), "/", &result); err != nil {
+		t.Fatalf("对冲请求失败: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	if elapsed > 500*time.Millisecond {
+		t.Errorf("对冲请求应以快速端点的延迟返回，耗时%v", elapsed)
+	}
+	if atomic.LoadInt32(&slowHits) != 1 || atomic.LoadInt32(&fastHits) != 1 {
+		t.Errorf("期望两个端点各收到1次请求，慢端点%d次，快端点%d次", slowHits, fastHits)
+	}
+	if result["server"] != fast.Listener.Addr().String() {
+		t.Errorf("应返回快速端点的响应，得到%v", result)
+	}
+
+	// 落败的慢请求被取消，不应降级慢端点
+	for _, status := range client.Endpoints() {
+		if !status.Healthy {
+			t.Errorf("被取消的对冲请求不应降级端点%s", status.BaseURL)
+		}
+	}
+}
+
+func TestMultiEndpointClient_NoHedgingForWrites(t *testing.T) {
+	var slowHits, fastHits int32
+	slow := newDelayedServer(200*time.Millisecond, &slowHits)
+	defer slow.Close()
+	fast := newDelayedServer(0, &fastHits)
+	defer fast.Close()
+
+	client := networkHttp.NewMultiEndpointClient(
+		[]string{slow.URL, fast.URL},
+		networkHttp.WithHedging(20*time.Millisecond),
+	)
+	defer client.Close()
+
+	start := time.Now()
+	if err := client.PostJSON(context.Background(), "/", map[string]string{"k": "v"}, nil); err != nil {
+		t.Fatalf("POST请求失败: %v", err)
+	}
+
+	if time.Since(start) < 200*time.Millisecond {
+		t.Errorf("非幂等请求不应被对冲")
+	}
+	if atomic.LoadInt32(&fastHits) != 0 {
+		t.Errorf("非幂等请求不应发往第二个端点，得到%d次", fastHits)
+	}
+}
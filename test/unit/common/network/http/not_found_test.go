@@ -0,0 +1,96 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	networkHttp "github.com/22827099/DFS_v1/common/network/http"
+)
+
+func newRoutedServer(options ...networkHttp.ServerOption) *networkHttp.Server {
+	server := networkHttp.NewServer("127.0.0.1:0", options...)
+	api := server.Group("/api/v1")
+	api.GET("/files/{path:.*}", noopHandler)
+	api.DELETE("/files/{path:.*}", noopHandler)
+	api.POST("/dirs/{path:.*}", noopHandler)
+	return server
+}
+
+func TestServer_MethodNotAllowed(t *testing.T) {
+	server := newRoutedServer()
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/api/v1/files/a.txt", nil))
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("期望状态码405，得到%d", w.Code)
+	}
+	if allow := w.Header().Get("Allow"); allow != "DELETE, GET" {
+		t.Errorf("期望Allow头为'DELETE, GET'，得到%q", allow)
+	}
+
+	var resp struct {
+		Success bool                  `json:"success"`
+		Data    map[string][]string   `json:"data"`
+		Error   networkHttp.ErrorInfo `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("无法解析响应: %v", err)
+	}
+	if resp.Success || resp.Error.Code != "method_not_allowed" {
+		t.Errorf("期望method_not_allowed错误，得到%+v", resp)
+	}
+	if len(resp.Data["allowed_methods"]) != 2 {
+		t.Errorf("响应体应列出允许的方法，得到%v", resp.Data)
+	}
+}
+
+func TestServer_NotFound(t *testing.T) {
+	server := newRoutedServer()
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/unknown", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("期望状态码404，得到%d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("期望JSON响应，得到Content-Type %q", ct)
+	}
+
+	var resp networkHttp.StandardResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("无法解析响应: %v", err)
+	}
+	if resp.Success || resp.Error == nil || resp.Error.Code != "not_found" {
+		t.Errorf("期望not_found错误，得到%+v", resp)
+	}
+}
+
+func TestServer_CustomNotFoundHandlers(t *testing.T) {
+	server := newRoutedServer(
+		networkHttp.WithNotFoundHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			networkHttp.RespondText(w, http.StatusNotFound, "custom 404")
+		})),
+		networkHttp.WithMethodNotAllowedHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			networkHttp.RespondText(w, http.StatusMethodNotAllowed, "custom 405")
+		})),
+	)
+	// 选项在路由注册前应用，自定义405处理器仍需看到之后注册的路由
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/nope", nil))
+	if w.Body.String() != "custom 404" {
+		t.Errorf("期望自定义404响应，得到%q", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/v1/dirs/a", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Body.String() != "custom 405" {
+		t.Errorf("期望自定义405响应，得到%d %q", w.Code, w.Body.String())
+	}
+	if allow := w.Header().Get("Allow"); allow != "POST" {
+		t.Errorf("自定义405处理器也应设置Allow头，得到%q", allow)
+	}
+}
@@ -0,0 +1,196 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	networkHttp "github.com/22827099/DFS_v1/common/network/http"
+)
+
+type specBase struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type specFile struct {
+	specBase
+	Size   int64             `json:"size"`
+	Tags   []string          `json:"tags,omitempty"`
+	Meta   map[string]string `json:"meta"`
+	Hidden string            `json:"-"`
+}
+
+func noopHandler(w http.ResponseWriter, r *http.Request) {}
+
+// fetchSpec 注册路由后通过/openapi.json获取文档
+func fetchSpec(t *testing.T, server *networkHttp.Server) map[string]interface{} {
+	server.GET("/openapi.json", server.OpenAPIHandler("test", "v1"))
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("获取OpenAPI文档失败: %d", w.Code)
+	}
+
+	var spec map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("无法解析OpenAPI文档: %v", err)
+	}
+	return spec
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	server := networkHttp.NewServer("127.0.0.1:0")
+	api := server.Group("/api/v1")
+	api.GET("/files/{path:.*}", noopHandler,
+		networkHttp.WithSummary("获取文件信息"),
+		networkHttp.WithQueryParamDoc("verify", "boolean", "校验"),
+		networkHttp.WithResponseType(specFile{}))
+	api.POST("/files/{path:.*}", noopHandler, networkHttp.WithRequestType(specFile{}))
+	api.PUT("/files/{path:.*}", noopHandler)
+	api.DELETE("/files/{path:.*}", noopHandler)
+
+	spec := fetchSpec(t, server)
+
+	if spec["openapi"] != "3.0.3" {
+		t.Errorf("期望openapi版本3.0.3，得到%v", spec["openapi"])
+	}
+
+	paths := spec["paths"].(map[string]interface{})
+	item, ok := paths["/api/v1/files/{path}"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("文档中缺少/api/v1/files/{path}，实际路径: %v", paths)
+	}
+	for _, method := range []string{"get", "post", "put", "delete"} {
+		if _, ok := item[method]; !ok {
+			t.Errorf("/api/v1/files/{path}缺少%s方法", method)
+		}
+	}
+
+	get := item["get"].(map[string]interface{})
+	if get["summary"] != "获取文件信息" {
+		t.Errorf("期望摘要为'获取文件信息'，得到%v", get["summary"])
+	}
+	if params := get["parameters"].([]interface{}); len(params) != 2 {
+		t.Errorf("期望2个参数(path和verify)，得到%d个", len(params))
+	}
+
+	// RespondJSON把响应包装为StandardResponse，声明的类型位于data字段
+	schema := responseSchema(t, get, "200")
+	if _, ok := schema["properties"].(map[string]interface{})["success"]; !ok {
+		t.Errorf("响应结构缺少success字段: %v", schema)
+	}
+	props := schema["properties"].(map[string]interface{})["data"].(map[string]interface{})["properties"].(map[string]interface{})
+	for _, name := range []string{"name", "created_at", "size", "tags", "meta"} {
+		if _, ok := props[name]; !ok {
+			t.Errorf("响应结构缺少字段%s", name)
+		}
+	}
+	if _, ok := props["Hidden"]; ok {
+		t.Errorf("json:\"-\"字段不应出现在文档中")
+	}
+	if created := props["created_at"].(map[string]interface{}); created["format"] != "date-time" {
+		t.Errorf("time.Time应映射为date-time，得到%v", created)
+	}
+
+	if _, ok := item["post"].(map[string]interface{})["requestBody"]; !ok {
+		t.Errorf("POST应包含requestBody")
+	}
+}
+
+func TestOpenAPIHeaderParams(t *testing.T) {
+	server := networkHttp.NewServer("127.0.0.1:0")
+	server.DELETE("/files/{path:.*}", noopHandler,
+		networkHttp.WithQueryParamDoc("recursive", "boolean", "递归"),
+		networkHttp.WithHeaderParamDoc("X-Lease-Holder", "string", "租约持有者"))
+
+	spec := fetchSpec(t, server)
+	op := spec["paths"].(map[string]interface{})["/files/{path}"].(map[string]interface{})["delete"].(map[string]interface{})
+	params := op["parameters"].([]interface{})
+	if len(params) != 3 {
+		t.Fatalf("期望3个参数(path、recursive和X-Lease-Holder)，得到%d个", len(params))
+	}
+	header := params[2].(map[string]interface{})
+	if header["name"] != "X-Lease-Holder" || header["in"] != "header" {
+		t.Errorf("请求头参数不正确: %v", header)
+	}
+}
+
+// responseSchema 返回operation中状态码code的响应结构
+func responseSchema(t *testing.T, op map[string]interface{}, code string) map[string]interface{} {
+	t.Helper()
+	response, ok := op["responses"].(map[string]interface{})[code].(map[string]interface{})
+	if !ok {
+		t.Fatalf("文档中缺少%s响应: %v", code, op["responses"])
+	}
+	return response["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"].(map[string]interface{})
+}
+
+type specEnvelope struct {
+	Status  string      `json:"status"`
+	Payload interface{} `json:"payload,omitempty"`
+}
+
+func TestOpenAPIResponseEnvelopeAndStatus(t *testing.T) {
+	server := networkHttp.NewServer("127.0.0.1:0")
+	server.POST("/files/{path:.*}", noopHandler,
+		networkHttp.WithResponseType(specFile{}),
+		networkHttp.WithSuccessStatus(http.StatusCreated),
+		networkHttp.WithStatusDoc(http.StatusAccepted, "已接受"))
+	server.GET("/ready", noopHandler,
+		networkHttp.WithResponseType(map[string]bool{}),
+		networkHttp.WithoutResponseEnvelope())
+	server.GET("/openapi.json", server.OpenAPIHandler("test", "v1", networkHttp.WithResponseEnvelope(specEnvelope{}, "payload")))
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var spec map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("无法解析OpenAPI文档: %v", err)
+	}
+	paths := spec["paths"].(map[string]interface{})
+	post := paths["/files/{path}"].(map[string]interface{})["post"].(map[string]interface{})
+
+	if _, ok := post["responses"].(map[string]interface{})["200"]; ok {
+		t.Errorf("声明了201的路由不应再列出200")
+	}
+	created := responseSchema(t, post, "201")["properties"].(map[string]interface{})["data"].(map[string]interface{})
+	envelopeProps := created["properties"].(map[string]interface{})
+	if _, ok := envelopeProps["status"]; !ok {
+		t.Errorf("data应为响应信封: %v", created)
+	}
+	payload := envelopeProps["payload"].(map[string]interface{})["properties"].(map[string]interface{})
+	if _, ok := payload["size"]; !ok {
+		t.Errorf("信封的payload应为声明的响应类型: %v", payload)
+	}
+
+	// 202响应只有信封，不包含数据
+	accepted := responseSchema(t, post, "202")["properties"].(map[string]interface{})["data"].(map[string]interface{})
+	if _, ok := accepted["properties"].(map[string]interface{})["payload"]; ok {
+		t.Errorf("202响应不应包含payload: %v", accepted)
+	}
+
+	// 排除信封的路由data直接为声明的类型
+	ready := paths["/ready"].(map[string]interface{})["get"].(map[string]interface{})
+	data := responseSchema(t, ready, "200")["properties"].(map[string]interface{})["data"].(map[string]interface{})
+	if data["type"] != "object" || data["additionalProperties"] == nil {
+		t.Errorf("排除信封的路由data应为map结构: %v", data)
+	}
+}
+
+func TestServerRoutes(t *testing.T) {
+	server := networkHttp.NewServer("127.0.0.1:0")
+	server.GET("/a", noopHandler)
+	server.Group("/g").POST("/b", noopHandler, networkHttp.WithSummary("b"))
+
+	routes := server.Routes()
+	if len(routes) != 2 {
+		t.Fatalf("期望2条路由，得到%d条", len(routes))
+	}
+	if routes[1].Method != http.MethodPost || routes[1].Path != "/g/b" || routes[1].Summary != "b" {
+		t.Errorf("路由元数据不正确: %+v", routes[1])
+	}
+}
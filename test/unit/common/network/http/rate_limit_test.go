@@ -0,0 +1,122 @@
+package http_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	networkHttp "github.com/22827099/DFS_v1/common/network/http"
+)
+
+// newLimitedServer 创建使用限流中间件的服务器
+func newLimitedServer(middleware networkHttp.Middleware) *networkHttp.Server {
+	server := networkHttp.NewServer("127.0.0.1:0")
+	server.Use(middleware)
+	server.GET("/metrics", noopHandler)
+	return server
+}
+
+// limitedRequest 以remoteAddr的身份发送请求，返回响应
+func limitedRequest(server *networkHttp.Server, remoteAddr string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.RemoteAddr = remoteAddr
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimit_BurstBoundary(t *testing.T) {
+	server := newLimitedServer(networkHttp.RateLimitMiddleware(10, 5))
+
+	for i := 0; i < 5; i++ {
+		if w := limitedRequest(server, "10.0.0.1:1234", nil); w.Code == http.StatusTooManyRequests {
+			t.Fatalf("第%d个请求在突发容量内，不应被限流", i+1)
+		}
+	}
+
+	w := limitedRequest(server, "10.0.0.1:5678", nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("超过突发容量期望状态码%d，得到%d", http.StatusTooManyRequests, w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("期望Retry-After为1，得到%q", got)
+	}
+
+	// 其他客户端有各自的令牌桶
+	if w := limitedRequest(server, "10.0.0.2:1234", nil); w.Code == http.StatusTooManyRequests {
+		t.Error("不同IP的客户端不应互相影响")
+	}
+}
+
+func TestRateLimit_Refill(t *testing.T) {
+	server := newLimitedServer(networkHttp.RateLimitMiddleware(10, 2))
+
+	for i := 0; i < 2; i++ {
+		limitedRequest(server, "10.0.0.1:1", nil)
+	}
+	if w := limitedRequest(server, "10.0.0.1:1", nil); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("令牌耗尽后期望状态码%d，得到%d", http.StatusTooManyRequests, w.Code)
+	}
+
+	// 每秒补充10个令牌，150毫秒后补充了一个
+	time.Sleep(150 * time.Millisecond)
+	if w := limitedRequest(server, "10.0.0.1:1", nil); w.Code == http.StatusTooManyRequests {
+		t.Fatal("补充令牌后请求应被放行")
+	}
+	if w := limitedRequest(server, "10.0.0.1:1", nil); w.Code != http.StatusTooManyRequests {
+		t.Errorf("补充的令牌用完后期望状态码%d，得到%d", http.StatusTooManyRequests, w.Code)
+	}
+
+	// 闲置足够久后令牌桶补满，但不超过突发容量
+	time.Sleep(500 * time.Millisecond)
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		if w := limitedRequest(server, "10.0.0.1:1", nil); w.Code != http.StatusTooManyRequests {
+			allowed++
+		}
+	}
+	if allowed != 2 {
+		t.Errorf("令牌桶补满后期望放行2个请求，放行了%d个", allowed)
+	}
+}
+
+func TestRateLimit_CustomKey(t *testing.T) {
+	server := newLimitedServer(networkHttp.RateLimitMiddleware(1, 1,
+		networkHttp.WithRateLimitKey(networkHttp.RequestIDKey)))
+
+	// 同一IP的不同请求ID分别限流
+	for _, id := range []string{"a", "b"} {
+		if w := limitedRequest(server, "10.0.0.1:1", map[string]string{"X-Request-ID": id}); w.Code == http.StatusTooManyRequests {
+			t.Errorf("请求ID %s 的第一个请求不应被限流", id)
+		}
+	}
+	if w := limitedRequest(server, "10.0.0.2:1", map[string]string{"X-Request-ID": "a"}); w.Code != http.StatusTooManyRequests {
+		t.Errorf("相同请求ID从不同IP发出时期望共享令牌桶，得到状态码%d", w.Code)
+	}
+}
+
+func TestRateLimit_IdleBucketsCollected(t *testing.T) {
+	limiter := networkHttp.NewRateLimiter(100, 1, networkHttp.WithRateLimitIdleTimeout(50*time.Millisecond))
+
+	for i := 0; i < 100; i++ {
+		limiter.Allow(fmt.Sprintf("10.0.%d.%d", i/256, i%256))
+	}
+	if got := limiter.Buckets(); got != 100 {
+		t.Fatalf("期望100个令牌桶，得到%d", got)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if got := limiter.Buckets(); got != 0 {
+		t.Errorf("闲置的令牌桶应被回收，仍有%d个", got)
+	}
+
+	// 回收后重新创建的令牌桶是满的
+	if allowed, _ := limiter.Allow("10.0.0.0"); !allowed {
+		t.Error("回收后的客户端应重新获得完整的突发容量")
+	}
+}
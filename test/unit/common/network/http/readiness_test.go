@@ -0,0 +1,82 @@
+package http_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	networkHttp "github.com/22827099/DFS_v1/common/network/http"
+)
+
+func TestReadinessGate_BlocksUntilReady(t *testing.T) {
+	var hasLeader atomic.Bool
+	gate := networkHttp.NewReadinessGate("/health")
+	gate.AddCheck("cluster_leader", func() error {
+		if !hasLeader.Load() {
+			return errors.New("集群尚未选出领导者")
+		}
+		return nil
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/files", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/ready", gate.Handler())
+	server := httptest.NewServer(gate.Middleware()(mux))
+	defer server.Close()
+
+	get := func(path string) *http.Response {
+		t.Helper()
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("请求%s失败: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	// 启动完成前
+	resp := get("/api/v1/files")
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("启动完成前期望503，得到%d", resp.StatusCode)
+	}
+	if resp.Header.Get("Retry-After") == "" {
+		t.Errorf("503响应应设置Retry-After")
+	}
+	if resp := get("/health"); resp.StatusCode != http.StatusOK {
+		t.Errorf("豁免路径不应受门控限制，得到%d", resp.StatusCode)
+	}
+
+	// 已启动但尚无领导者
+	gate.SetStarted(true)
+	if resp := get("/api/v1/files"); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("就绪条件不满足时期望503，得到%d", resp.StatusCode)
+	}
+	if resp := get("/ready"); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("就绪探针期望503，得到%d", resp.StatusCode)
+	}
+	if err := gate.Ready(); err == nil {
+		t.Errorf("未就绪时Ready应返回原因")
+	}
+
+	// 选出领导者后
+	hasLeader.Store(true)
+	if resp := get("/api/v1/files"); resp.StatusCode != http.StatusOK {
+		t.Errorf("就绪后期望200，得到%d", resp.StatusCode)
+	}
+	if resp := get("/ready"); resp.StatusCode != http.StatusOK {
+		t.Errorf("就绪探针期望200，得到%d", resp.StatusCode)
+	}
+
+	// 停止时退出就绪状态
+	gate.SetStarted(false)
+	if resp := get("/api/v1/files"); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("停止后期望503，得到%d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,122 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	networkHttp "github.com/22827099/DFS_v1/common/network/http"
+)
+
+// newETagServer 创建支持ETag条件请求的测试服务器
+func newETagServer(etag string, fullResponses, notModified *int32, lastIfNoneMatch *atomic.Value) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastIfNoneMatch.Store(r.Header.Get("If-None-Match"))
+		if r.Header.Get("If-None-Match") == etag {
+			atomic.AddInt32(notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		atomic.AddInt32(fullResponses, 1)
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"leader": "node-1"})
+	}))
+}
+
+func TestClient_ResponseCacheConditionalRequest(t *testing.T) {
+	var full, notModified int32
+	var lastIfNoneMatch atomic.Value
+	server := newETagServer(`"v1"`, &full, &notModified, &lastIfNoneMatch)
+	defer server.Close()
+
+	client := networkHttp.NewClient(server.URL, networkHttp.WithResponseCache(10, time.Minute))
+
+	var first map[string]string
+	if err := client.GetJSON(context.Background(), "/cluster/status", &first); err != nil {
+		t.Fatalf("第一次请求失败: %v", err)
+	}
+	if got := lastIfNoneMatch.Load().(string); got != "" {
+		t.Errorf("第一次请求不应携带If-None-Match，得到%q", got)
+	}
+
+	var second map[string]string
+	if err := client.GetJSON(context.Background(), "/cluster/status", &second); err != nil {
+		t.Fatalf("第二次请求失败: %v", err)
+	}
+	if got := lastIfNoneMatch.Load().(string); got != `"v1"` {
+		t.Errorf("第二次请求应携带If-None-Match: \"v1\"，得到%q", got)
+	}
+
+	if full != 1 || notModified != 1 {
+		t.Errorf("期望1次完整响应和1次304，得到%d次和%d次", full, notModified)
+	}
+	if second["leader"] != "node-1" {
+		t.Errorf("304时应返回缓存的响应体，得到%v", second)
+	}
+}
+
+func TestClient_ResponseCacheTTL(t *testing.T) {
+	var full, notModified int32
+	var lastIfNoneMatch atomic.Value
+	server := newETagServer(`"v1"`, &full, &notModified, &lastIfNoneMatch)
+	defer server.Close()
+
+	client := networkHttp.NewClient(server.URL, networkHttp.WithResponseCache(10, 20*time.Millisecond))
+
+	if err := client.GetJSON(context.Background(), "/status", nil); err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	// 条目过期后应发起无条件请求
+	if err := client.GetJSON(context.Background(), "/status", nil); err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	if full != 2 || notModified != 0 {
+		t.Errorf("缓存过期后应重新下载，得到%d次完整响应、%d次304", full, notModified)
+	}
+}
+
+func TestClient_ResponseCacheEviction(t *testing.T) {
+	var full, notModified int32
+	var lastIfNoneMatch atomic.Value
+	server := newETagServer(`"v1"`, &full, &notModified, &lastIfNoneMatch)
+	defer server.Close()
+
+	client := networkHttp.NewClient(server.URL, networkHttp.WithResponseCache(1, time.Minute))
+
+	for _, path := range []string{"/a", "/b", "/a"} {
+		if err := client.GetJSON(context.Background(), path, nil); err != nil {
+			t.Fatalf("请求%s失败: %v", path, err)
+		}
+	}
+
+	// 容量为1，/a在请求/b时被淘汰，第三次请求不能使用缓存
+	if full != 3 || notModified != 0 {
+		t.Errorf("超出容量的条目应被淘汰，得到%d次完整响应、%d次304", full, notModified)
+	}
+}
+
+func TestClient_NoCacheByDefault(t *testing.T) {
+	var full, notModified int32
+	var lastIfNoneMatch atomic.Value
+	server := newETagServer(`"v1"`, &full, &notModified, &lastIfNoneMatch)
+	defer server.Close()
+
+	client := networkHttp.NewClient(server.URL)
+	for i := 0; i < 2; i++ {
+		if err := client.GetJSON(context.Background(), "/status", nil); err != nil {
+			t.Fatalf("请求失败: %v", err)
+		}
+	}
+	if full != 2 {
+		t.Errorf("未启用缓存时不应发起条件请求，得到%d次完整响应", full)
+	}
+}
@@ -0,0 +1,172 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	networkHttp "github.com/22827099/DFS_v1/common/network/http"
+)
+
+func TestDefaultRetryPolicy(t *testing.T) {
+	policy := networkHttp.DefaultRetryPolicy()
+	if policy.ShouldRetry == nil {
+		t.Fatalf("DefaultRetryPolicy: ShouldRetry不应为nil")
+	}
+	if !policy.ShouldRetry(&http.Response{StatusCode: http.StatusBadGateway}, nil) {
+		t.Errorf("DefaultRetryPolicy: 5xx响应应重试")
+	}
+	if policy.ShouldRetry(&http.Response{StatusCode: http.StatusNotFound}, nil) {
+		t.Errorf("DefaultRetryPolicy: 4xx响应不应重试")
+	}
+}
+
+func TestClient_NilShouldRetry(t *testing.T) {
+	var requestCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/flaky":
+			if atomic.AddInt32(&requestCount, 1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"status":"ok"}`))
+		default:
+			atomic.AddInt32(&requestCount, 1)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	client := networkHttp.NewClient(server.URL, networkHttp.WithCustomRetryPolicy(networkHttp.RetryPolicy{
+		MaxRetries:    3,
+		RetryInterval: time.Millisecond,
+	}))
+
+	var result map[string]string
+	if err := client.GetJSON(context.Background(), "/flaky", &result); err != nil {
+		t.Fatalf("Client.NilShouldRetry: 5xx后重试应成功，得到错误: %v", err)
+	}
+	if got := atomic.LoadInt32(&requestCount); got != 3 {
+		t.Errorf("Client.NilShouldRetry: 期望3次请求，得到%d次", got)
+	}
+
+	// 4xx不重试
+	atomic.StoreInt32(&requestCount, 0)
+	if err := client.GetJSON(context.Background(), "/bad", nil); err == nil {
+		t.Errorf("Client.NilShouldRetry: 400响应应返回错误")
+	}
+	if got := atomic.LoadInt32(&requestCount); got != 1 {
+		t.Errorf("Client.NilShouldRetry: 4xx不应重试，期望1次请求，得到%d次", got)
+	}
+
+	// 连接错误重试后返回错误
+	server.Close()
+	if err := client.GetJSON(context.Background(), "/flaky", nil); err == nil {
+		t.Errorf("Client.NilShouldRetry: 服务端关闭时应返回错误")
+	}
+}
+
+func TestClient_MaxRetriesError(t *testing.T) {
+	var requestCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requestCount, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(strings.Repeat("x", 2048)))
+	}))
+	defer server.Close()
+
+	client := networkHttp.NewClient(server.URL, networkHttp.WithRetryPolicy(2, time.Millisecond))
+
+	err := client.GetJSON(context.Background(), "/", nil)
+	if err == nil {
+		t.Fatalf("Client.MaxRetriesError: 期望返回错误")
+	}
+	if !strings.Contains(err.Error(), "max retries exceeded") {
+		t.Errorf("Client.MaxRetriesError: 错误信息应包含'max retries exceeded'，得到'%v'", err)
+	}
+
+	var retryErr *networkHttp.MaxRetriesError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("Client.MaxRetriesError: 期望*MaxRetriesError，得到%T", err)
+	}
+	if retryErr.Attempts != 3 || atomic.LoadInt32(&requestCount) != 3 {
+		t.Errorf("Client.MaxRetriesError: 期望尝试3次，错误记录%d次，服务端收到%d次",
+			retryErr.Attempts, atomic.LoadInt32(&requestCount))
+	}
+	if retryErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Client.MaxRetriesError: 期望状态码503，得到%d", retryErr.StatusCode)
+	}
+	if len(retryErr.Body) >= 2048 || !strings.HasPrefix(retryErr.Body, "xxx") {
+		t.Errorf("Client.MaxRetriesError: 响应体应被截断，长度%d", len(retryErr.Body))
+	}
+}
+
+func TestClient_MaxRetriesErrorOnConnectionFailure(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	client := networkHttp.NewClient(server.URL, networkHttp.WithRetryPolicy(1, time.Millisecond))
+
+	var retryErr *networkHttp.MaxRetriesError
+	err := client.GetJSON(context.Background(), "/", nil)
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("Client.MaxRetriesError: 期望*MaxRetriesError，得到%v", err)
+	}
+	if retryErr.Attempts != 2 || retryErr.StatusCode != 0 || retryErr.Err == nil {
+		t.Errorf("Client.MaxRetriesError: 连接错误时期望尝试2次、状态码0并保留原始错误，得到%+v", retryErr)
+	}
+}
+
+func TestClient_PerRequestRetryOverride(t *testing.T) {
+	var requestCount int32
+	// 前4次请求失败，之后成功
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requestCount, 1) <= 4 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	client := networkHttp.NewClient(server.URL, networkHttp.WithRetryPolicy(2, time.Millisecond))
+
+	// 单次请求禁用重试
+	err := client.PostJSON(context.Background(), "/", map[string]string{"op": "write"}, nil,
+		networkHttp.WithRequestRetry(networkHttp.RetryPolicy{MaxRetries: 0}))
+	if err == nil {
+		t.Fatalf("Client.PerRequestRetry: 禁用重试时期望返回错误")
+	}
+	if got := atomic.LoadInt32(&requestCount); got != 1 {
+		t.Errorf("Client.PerRequestRetry: 禁用重试时期望1次请求，得到%d次", got)
+	}
+
+	// 客户端默认重试2次，仍然失败
+	atomic.StoreInt32(&requestCount, 0)
+	if err := client.GetJSON(context.Background(), "/", nil); err == nil {
+		t.Errorf("Client.PerRequestRetry: 默认策略下期望返回错误")
+	}
+	if got := atomic.LoadInt32(&requestCount); got != 3 {
+		t.Errorf("Client.PerRequestRetry: 默认策略期望3次请求，得到%d次", got)
+	}
+
+	// 单次请求放宽到5次重试后成功
+	atomic.StoreInt32(&requestCount, 0)
+	var result map[string]string
+	err = client.GetJSON(context.Background(), "/", &result,
+		networkHttp.WithRequestRetry(networkHttp.RetryPolicy{MaxRetries: 5, RetryInterval: time.Millisecond}))
+	if err != nil {
+		t.Fatalf("Client.PerRequestRetry: 增加重试次数后期望成功，得到错误: %v", err)
+	}
+	if got := atomic.LoadInt32(&requestCount); got != 5 || result["status"] != "ok" {
+		t.Errorf("Client.PerRequestRetry: 期望第5次请求成功，实际%d次请求，结果%v", got, result)
+	}
+}
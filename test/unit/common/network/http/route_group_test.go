@@ -0,0 +1,87 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	networkHttp "github.com/22827099/DFS_v1/common/network/http"
+)
+
+// tagMiddleware 在响应头X-Tags中追加tag，用于观察中间件的执行顺序
+func tagMiddleware(tag string) networkHttp.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Tags", tag)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestRouteGroup_Use(t *testing.T) {
+	server := networkHttp.NewServer("127.0.0.1:0")
+	server.GET("/health", noopHandler)
+
+	api := server.Group("/api/v1")
+	api.Use(tagMiddleware("api"))
+	api.GET("/files", noopHandler)
+
+	admin := api.Group("/admin")
+	admin.Use(tagMiddleware("admin"))
+	admin.GET("/status", noopHandler)
+
+	tests := []struct {
+		path string
+		tags []string
+	}{
+		{"/health", nil},
+		{"/api/v1/files", []string{"api"}},
+		{"/api/v1/admin/status", []string{"api", "admin"}},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		got := w.Header().Values("X-Tags")
+		if len(got) != len(tt.tags) {
+			t.Errorf("%s: 期望中间件%v，得到%v", tt.path, tt.tags, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.tags[i] {
+				t.Errorf("%s: 期望中间件%v，得到%v", tt.path, tt.tags, got)
+				break
+			}
+		}
+	}
+}
+
+func TestServer_HandleInternal(t *testing.T) {
+	server := networkHttp.NewServer("127.0.0.1:0")
+	server.Use(tagMiddleware("public"))
+	server.GET("/health", noopHandler)
+	server.HandleInternal("/internal", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	// 内部路径不经过公开中间件，也不出现在路由列表中
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/internal", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("期望状态码204，得到%d", w.Code)
+	}
+	if tags := w.Header().Values("X-Tags"); len(tags) != 0 {
+		t.Errorf("内部路径不应经过公开中间件，得到%v", tags)
+	}
+	for _, route := range server.Routes() {
+		if route.Path == "/internal" {
+			t.Errorf("内部路径不应出现在路由列表中")
+		}
+	}
+
+	// 只匹配完整路径
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/internal/x", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("期望状态码404，得到%d", w.Code)
+	}
+}
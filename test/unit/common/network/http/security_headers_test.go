@@ -0,0 +1,107 @@
+package http_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	networkHttp "github.com/22827099/DFS_v1/common/network/http"
+)
+
+func newSecureServer(cfg networkHttp.SecurityHeadersConfig) *networkHttp.Server {
+	server := networkHttp.NewServer("127.0.0.1:0", networkHttp.WithSecurityHeaders(cfg))
+	server.GET("/data", func(w http.ResponseWriter, r *http.Request) {
+		networkHttp.RespondJSON(w, http.StatusOK, map[string]string{"ok": "true"})
+	})
+	server.GET("/embed", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Frame-Options", "SAMEORIGIN")
+		networkHttp.RespondJSON(w, http.StatusOK, nil)
+	})
+	server.GET("/empty", noopHandler)
+	return server
+}
+
+func TestSecurityHeaders_InjectedOnAllResponses(t *testing.T) {
+	server := newSecureServer(networkHttp.DefaultSecurityHeadersConfig(nil))
+
+	for _, path := range []string{"/data", "/empty", "/missing"} {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if got := w.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+			t.Errorf("%s: 期望X-Content-Type-Options为nosniff，得到%q", path, got)
+		}
+		if got := w.Header().Get("X-Frame-Options"); got != "DENY" {
+			t.Errorf("%s: 期望X-Frame-Options为DENY，得到%q", path, got)
+		}
+		if got := w.Header().Get("Strict-Transport-Security"); got != "" {
+			t.Errorf("%s: 明文请求不应注入HSTS，得到%q", path, got)
+		}
+	}
+}
+
+func TestSecurityHeaders_HandlerValueWins(t *testing.T) {
+	server := newSecureServer(networkHttp.DefaultSecurityHeadersConfig(nil))
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/embed", nil))
+
+	if got := w.Header().Values("X-Frame-Options"); len(got) != 1 || got[0] != "SAMEORIGIN" {
+		t.Errorf("期望处理器设置的X-Frame-Options生效，得到%v", got)
+	}
+	if got := w.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("其他安全头仍应注入，得到%q", got)
+	}
+}
+
+func TestSecurityHeaders_HSTSOnlyOverTLS(t *testing.T) {
+	cfg := networkHttp.DefaultSecurityHeadersConfig(nil)
+	cfg.HSTSMaxAge = time.Hour
+	server := newSecureServer(cfg)
+
+	req := httptest.NewRequest(http.MethodGet, "/data", nil)
+	req.TLS = &tls.ConnectionState{}
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Strict-Transport-Security"); got != "max-age=3600; includeSubDomains" {
+		t.Errorf("期望TLS请求注入HSTS，得到%q", got)
+	}
+}
+
+func TestSecurityHeaders_Overrides(t *testing.T) {
+	server := newSecureServer(networkHttp.DefaultSecurityHeadersConfig(map[string]string{
+		"x-frame-options":           "",
+		"Content-Security-Policy":   "default-src 'none'",
+		"Strict-Transport-Security": "",
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/data", nil)
+	req.TLS = &tls.ConnectionState{}
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+
+	if _, ok := w.Header()["X-Frame-Options"]; ok {
+		t.Error("值为空的头不应注入")
+	}
+	if got := w.Header().Get("Strict-Transport-Security"); got != "" {
+		t.Errorf("HSTS被关闭后不应注入，得到%q", got)
+	}
+	if got := w.Header().Get("Content-Security-Policy"); got != "default-src 'none'" {
+		t.Errorf("期望追加的头被注入，得到%q", got)
+	}
+}
+
+func TestSecurityHeaders_DisabledByDefault(t *testing.T) {
+	server := networkHttp.NewServer("127.0.0.1:0")
+	server.GET("/data", noopHandler)
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/data", nil))
+
+	if got := w.Header().Get("X-Content-Type-Options"); got != "" {
+		t.Errorf("未启用时不应注入安全头，得到%q", got)
+	}
+}
@@ -0,0 +1,153 @@
+package http_test
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	networkHttp "github.com/22827099/DFS_v1/common/network/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSelfSignedCert 生成通用名为commonName的自签名证书并写入certFile和keyFile
+func writeSelfSignedCert(t *testing.T, certFile, keyFile, commonName string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+}
+
+// dialCommonName 建立TLS连接并返回服务器证书的通用名，调用方负责关闭连接
+func dialCommonName(t *testing.T, addr string) (*tls.Conn, string) {
+	t.Helper()
+	conn, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true})
+	require.NoError(t, err)
+	return conn, conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+}
+
+// getOverConn 在已建立的连接上发送请求，验证连接仍然可用
+func getOverConn(t *testing.T, conn *tls.Conn, path string) int {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, "https://127.0.0.1"+path, nil)
+	require.NoError(t, err)
+	require.NoError(t, req.Write(conn))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestCertReloader_NewConnectionsUseRotatedCert(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	writeSelfSignedCert(t, certFile, keyFile, "old")
+
+	reloader, err := networkHttp.NewCertReloader(certFile, keyFile, nil)
+	require.NoError(t, err)
+	reloader.Watch(20 * time.Millisecond)
+	defer reloader.Stop()
+
+	server := networkHttp.NewServer("127.0.0.1:0", networkHttp.WithTLS(reloader))
+	server.GET("/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		server.Serve(listener)
+	}()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		server.Stop(ctx)
+		<-done
+	}()
+	addr := listener.Addr().String()
+
+	existing, name := dialCommonName(t, addr)
+	defer existing.Close()
+	assert.Equal(t, "old", name)
+	assert.Equal(t, http.StatusOK, getOverConn(t, existing, "/ping"))
+
+	writeSelfSignedCert(t, certFile, keyFile, "new")
+	assert.Eventually(t, func() bool {
+		conn, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true})
+		if err != nil {
+			return false
+		}
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].Subject.CommonName == "new"
+	}, 2*time.Second, 20*time.Millisecond, "新连接应使用更新后的证书")
+
+	// 已建立的连接不受影响，仍然使用原证书并可以继续发送请求
+	assert.Equal(t, "old", existing.ConnectionState().PeerCertificates[0].Subject.CommonName)
+	assert.Equal(t, http.StatusOK, getOverConn(t, existing, "/ping"))
+}
+
+func TestCertReloader_KeepsCertWhenFilesInvalid(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	writeSelfSignedCert(t, certFile, keyFile, "old")
+
+	reloader, err := networkHttp.NewCertReloader(certFile, keyFile, nil)
+	require.NoError(t, err)
+
+	// 证书已替换而私钥尚未替换时加载失败，继续使用原证书
+	otherDir := t.TempDir()
+	writeSelfSignedCert(t, filepath.Join(otherDir, "c"), filepath.Join(otherDir, "k"), "new")
+	data, err := os.ReadFile(filepath.Join(otherDir, "c"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(certFile, data, 0o600))
+
+	reloaded, err := reloader.ReloadIfChanged()
+	assert.Error(t, err)
+	assert.False(t, reloaded)
+	cert, err := reloader.GetCertificate(nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	assert.Equal(t, "old", leaf.Subject.CommonName)
+
+	// 私钥随后替换，下一次检查成功加载
+	data, err = os.ReadFile(filepath.Join(otherDir, "k"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(keyFile, data, 0o600))
+	reloaded, err = reloader.ReloadIfChanged()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+
+	_, err = networkHttp.NewCertReloader(filepath.Join(dir, "missing.crt"), keyFile, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,251 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	networkHttp "github.com/22827099/DFS_v1/common/network/http"
+)
+
+// uploadResponse 上传路由的响应
+type uploadResponse struct {
+	Success bool                       `json:"success"`
+	Data    []networkHttp.UploadedFile `json:"data"`
+}
+
+// streamMultipart 在后台生成包含一个size字节文件part的multipart请求体，返回读取端和Content-Type
+func streamMultipart(size int64) (io.Reader, string) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", `form-data; name="chunk"; filename="chunk.bin"`)
+		header.Set("Content-Type", "application/octet-stream")
+		header.Set("Content-Length", fmt.Sprint(size))
+		part, err := mw.CreatePart(header)
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		block := bytes.Repeat([]byte("0123456789abcdef"), 2048)
+		for written := int64(0); written < size; {
+			n := int64(len(block))
+			if size-written < n {
+				n = size - written
+			}
+			if _, err := part.Write(block[:n]); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			written += n
+		}
+		pw.CloseWithError(mw.Close())
+	}()
+	return pr, mw.FormDataContentType()
+}
+
+func TestUpload_StreamsLargeBodyWithBoundedMemory(t *testing.T) {
+	const size = 50 << 20
+
+	server := networkHttp.NewServer("127.0.0.1:0")
+	var declared, received int64
+	server.Upload("/upload", func(c *networkHttp.Context, part *networkHttp.UploadPart, r io.Reader) error {
+		declared = part.Size
+		n, err := io.Copy(io.Discard, r)
+		received = n
+		return err
+	})
+
+	body, contentType := streamMultipart(size)
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	server.ServeHTTP(w, req)
+	runtime.ReadMemStats(&after)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("期望状态码%d，得到%d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if declared != size || received != size {
+		t.Errorf("期望声明和接收的大小都为%d，得到%d和%d", size, declared, received)
+	}
+
+	// 缓冲整个请求体至少需要50MB，流式读取只需要固定大小的缓冲区
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 8<<20 {
+		t.Errorf("上传50MB分配了%d字节，请求体未被流式处理", allocated)
+	}
+
+	var resp uploadResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("无法解析响应: %v", err)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].FileName != "chunk.bin" || resp.Data[0].Size != size {
+		t.Errorf("响应中的文件信息不正确: %+v", resp.Data)
+	}
+}
+
+func TestParseMultipart_FieldsAndFiles(t *testing.T) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	mw.WriteField("path", "/data/a.bin")
+	first, _ := mw.CreateFormFile("file", "a.bin")
+	first.Write([]byte("first"))
+	second, _ := mw.CreateFormFile("file", "b.bin")
+	second.Write([]byte("second"))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	c := &networkHttp.Context{Request: req, Response: httptest.NewRecorder()}
+
+	var contents []string
+	form, err := networkHttp.ParseMultipart(c, 0, func(part *networkHttp.UploadPart, r io.Reader) error {
+		data, err := io.ReadAll(r)
+		contents = append(contents, part.FileName+":"+string(data))
+		// 文件part之前的普通字段随part交给sink
+		if got := part.Form.Get("path"); got != "/data/a.bin" {
+			t.Errorf("期望part.Form中的path为/data/a.bin，得到%q", got)
+		}
+		if part.Size != -1 {
+			t.Errorf("未声明大小的part期望Size为-1，得到%d", part.Size)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("ParseMultipart返回错误: %v", err)
+	}
+	if got := form.Get("path"); got != "/data/a.bin" {
+		t.Errorf("期望表单字段path为/data/a.bin，得到%q", got)
+	}
+	if strings.Join(contents, ",") != "a.bin:first,b.bin:second" {
+		t.Errorf("文件part内容不正确: %v", contents)
+	}
+}
+
+func TestParseMultipart_RawBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("raw data"))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	c := &networkHttp.Context{Request: req, Response: httptest.NewRecorder()}
+
+	var got string
+	_, err := networkHttp.ParseMultipart(c, 0, func(part *networkHttp.UploadPart, r io.Reader) error {
+		if part.Size != int64(len("raw data")) {
+			t.Errorf("期望声明大小为请求的Content-Length，得到%d", part.Size)
+		}
+		data, err := io.ReadAll(r)
+		got = string(data)
+		return err
+	})
+	if err != nil || got != "raw data" {
+		t.Errorf("非multipart请求体应作为一个part处理，得到%q, %v", got, err)
+	}
+}
+
+func TestUpload_Errors(t *testing.T) {
+	server := networkHttp.NewServer("127.0.0.1:0")
+	server.Upload("/upload", func(c *networkHttp.Context, part *networkHttp.UploadPart, r io.Reader) error {
+		_, err := io.Copy(io.Discard, r)
+		return err
+	})
+
+	// 表单字段超过内存上限
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	mw.WriteField("big", strings.Repeat("x", int(networkHttp.DefaultUploadMaxMemory)+1))
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/upload", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("表单字段超限期望状态码%d，得到%d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+
+	// 被截断的multipart请求体
+	req = httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("--b\r\nContent-Disposition: form-data; name=\"f\"; filename=\"f\"\r\n\r\npartial"))
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=b")
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("截断的请求体期望状态码%d，得到%d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestUpload_HandlerErrorNotExposed(t *testing.T) {
+	server := networkHttp.NewServer("127.0.0.1:0")
+	server.Upload("/upload", func(c *networkHttp.Context, part *networkHttp.UploadPart, r io.Reader) error {
+		return fmt.Errorf("写入/var/lib/dfs/chunks/0001失败: 磁盘已满")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("data"))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("期望状态码%d，得到%d", http.StatusInternalServerError, w.Code)
+	}
+	if strings.Contains(w.Body.String(), "/var/lib/dfs") {
+		t.Errorf("响应不应包含内部错误详情: %s", w.Body.String())
+	}
+}
+
+func TestUpload_NotLimitedByServerReadTimeout(t *testing.T) {
+	// 服务器读写超时200ms，上传持续约1s，数据持续到达时不应被中断
+	server := networkHttp.NewServer("127.0.0.1:0",
+		networkHttp.WithServerTimeout(200*time.Millisecond, 200*time.Millisecond, 0),
+		networkHttp.WithUploadIdleTimeout(time.Second))
+	var received int64
+	server.Upload("/upload", func(c *networkHttp.Context, part *networkHttp.UploadPart, r io.Reader) error {
+		n, err := io.Copy(io.Discard, r)
+		received = n
+		return err
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Stop(context.Background()) })
+
+	pr, pw := io.Pipe()
+	go func() {
+		for i := 0; i < 10; i++ {
+			time.Sleep(100 * time.Millisecond)
+			if _, err := pw.Write(bytes.Repeat([]byte("x"), 1024)); err != nil {
+				return
+			}
+		}
+		pw.Close()
+	}()
+
+	resp, err := http.Post("http://"+listener.Addr().String()+"/upload", "application/octet-stream", pr)
+	if err != nil {
+		t.Fatalf("上传失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("期望状态码%d，得到%d: %s", http.StatusCreated, resp.StatusCode, body)
+	}
+	if received != 10*1024 {
+		t.Errorf("期望收到%d字节，得到%d", 10*1024, received)
+	}
+}
@@ -0,0 +1,61 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/types"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	selector, err := types.ParseLabelSelector("zone=a, disk-type!=hdd,tenant")
+	if err != nil {
+		t.Fatalf("解析选择器失败: %v", err)
+	}
+	if len(selector) != 3 {
+		t.Fatalf("期望3个条件，得到%d个", len(selector))
+	}
+	if got := selector.String(); got != "zone=a,disk-type!=hdd,tenant" {
+		t.Errorf("选择器字符串表示不正确: %q", got)
+	}
+
+	tests := []struct {
+		labels map[string]string
+		match  bool
+	}{
+		{map[string]string{"zone": "a", "disk-type": "ssd", "tenant": "t1"}, true},
+		{map[string]string{"zone": "a", "tenant": "t1"}, true},
+		{map[string]string{"zone": "b", "disk-type": "ssd", "tenant": "t1"}, false},
+		{map[string]string{"zone": "a", "disk-type": "hdd", "tenant": "t1"}, false},
+		{map[string]string{"zone": "a", "disk-type": "ssd"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := selector.Matches(tt.labels); got != tt.match {
+			t.Errorf("Matches(%v) = %v，期望%v", tt.labels, got, tt.match)
+		}
+	}
+
+	if _, err := types.ParseLabelSelector("=ssd"); err == nil {
+		t.Errorf("缺少键的条件应返回错误")
+	}
+}
+
+func TestLabelSelector_FilterNodes(t *testing.T) {
+	nodes := []types.NodeInfo{
+		{NodeID: "n1", Labels: map[string]string{types.LabelDiskType: "ssd"}},
+		{NodeID: "n2", Labels: map[string]string{types.LabelDiskType: "hdd"}},
+		{NodeID: "n3"},
+		{NodeID: "n4", Labels: map[string]string{types.LabelDiskType: "ssd", types.LabelZone: "b"}},
+	}
+
+	ssd := types.SelectorFromMap(map[string]string{types.LabelDiskType: "ssd"})
+	filtered := ssd.FilterNodes(nodes)
+	if len(filtered) != 2 || filtered[0].NodeID != "n1" || filtered[1].NodeID != "n4" {
+		t.Errorf("期望筛选出n1和n4，得到%v", filtered)
+	}
+
+	// 空选择器匹配所有节点
+	if got := len(types.LabelSelector(nil).FilterNodes(nodes)); got != len(nodes) {
+		t.Errorf("空选择器应匹配全部节点，得到%d个", got)
+	}
+}
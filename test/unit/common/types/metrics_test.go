@@ -0,0 +1,27 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/types"
+)
+
+func TestNodeMetrics_Validate(t *testing.T) {
+	valid := types.NodeMetrics{CPUUsagePercent: 50, DiskUsageRatio: 0.5, DiskUsageBytes: 10, DiskCapacityBytes: 100}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("合法指标校验失败: %v", err)
+	}
+
+	invalid := []types.NodeMetrics{
+		{DiskUsageRatio: 1.5},
+		{DiskUsageRatio: -0.1},
+		{CPUUsagePercent: 101},
+		{ShardCount: -1},
+		{DiskUsageBytes: 200, DiskCapacityBytes: 100},
+	}
+	for i, m := range invalid {
+		if err := m.Validate(); err == nil {
+			t.Errorf("第%d组非法指标未被拒绝: %+v", i, m)
+		}
+	}
+}
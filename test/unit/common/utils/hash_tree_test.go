@@ -0,0 +1,116 @@
+package utils_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createHashTree 创建一个包含多级子目录的测试目录树
+func createHashTree(t *testing.T, count int) (string, map[string][]byte) {
+	root := t.TempDir()
+	files := make(map[string][]byte)
+	for i := 0; i < count; i++ {
+		rel := fmt.Sprintf("dir%d/sub/file%d.txt", i%3, i)
+		content := []byte(fmt.Sprintf("content of file %d", i))
+		full := filepath.Join(root, filepath.FromSlash(rel))
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, os.WriteFile(full, content, 0644))
+		files[rel] = content
+	}
+	return root, files
+}
+
+// trackingReadCloser 在关闭时减少活跃计数
+type trackingReadCloser struct {
+	io.ReadCloser
+	active *int32
+}
+
+func (t *trackingReadCloser) Close() error {
+	atomic.AddInt32(t.active, -1)
+	return t.ReadCloser.Close()
+}
+
+func TestHashTreeHashesAllFiles(t *testing.T) {
+	root, files := createHashTree(t, 20)
+
+	result, err := utils.HashTree(context.Background(), root, utils.SHA256)
+	require.NoError(t, err)
+	require.Len(t, result, len(files))
+
+	for rel, content := range files {
+		assert.Equal(t, utils.SHA256Hash(content), result[rel], rel)
+	}
+}
+
+func TestHashTreeBoundsConcurrency(t *testing.T) {
+	root, files := createHashTree(t, 30)
+
+	const workers = 3
+	var active, maxActive int32
+	opener := func(path string) (io.ReadCloser, error) {
+		n := atomic.AddInt32(&active, 1)
+		for {
+			old := atomic.LoadInt32(&maxActive)
+			if n <= old || atomic.CompareAndSwapInt32(&maxActive, old, n) {
+				break
+			}
+		}
+		// 延长打开时间，让并发工作者有机会重叠
+		time.Sleep(5 * time.Millisecond)
+		f, err := os.Open(path)
+		if err != nil {
+			atomic.AddInt32(&active, -1)
+			return nil, err
+		}
+		return &trackingReadCloser{ReadCloser: f, active: &active}, nil
+	}
+
+	result, err := utils.HashTree(context.Background(), root, utils.MD5,
+		utils.WithHashWorkers(workers), utils.WithFileOpener(opener))
+	require.NoError(t, err)
+	assert.Len(t, result, len(files))
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxActive), int32(workers))
+	assert.Greater(t, atomic.LoadInt32(&maxActive), int32(1), "工作者应当并发执行")
+}
+
+func TestHashTreeCancellation(t *testing.T) {
+	root, _ := createHashTree(t, 50)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var opened int32
+	opener := func(path string) (io.ReadCloser, error) {
+		if atomic.AddInt32(&opened, 1) == 1 {
+			cancel()
+		}
+		// 模拟较慢的存储，取消后不应继续处理剩余文件
+		time.Sleep(10 * time.Millisecond)
+		return os.Open(path)
+	}
+
+	start := time.Now()
+	result, err := utils.HashTree(ctx, root, utils.SHA256,
+		utils.WithHashWorkers(2), utils.WithFileOpener(opener))
+	elapsed := time.Since(start)
+
+	assert.True(t, errors.Is(err, context.Canceled), "应返回context.Canceled, 实际: %v", err)
+	assert.Nil(t, result)
+	assert.Less(t, atomic.LoadInt32(&opened), int32(50))
+	assert.Less(t, elapsed, time.Second)
+}
+
+func TestHashTreeMissingRoot(t *testing.T) {
+	_, err := utils.HashTree(context.Background(), filepath.Join(t.TempDir(), "missing"), utils.SHA256)
+	assert.Error(t, err)
+}
@@ -0,0 +1,107 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/errors"
+	"github.com/22827099/DFS_v1/internal/metaserver/server/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type decodeTarget struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// respondDecode 模拟处理器：解析请求体并在失败时返回错误响应
+func respondDecode(body string, optional bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/files/a.txt", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	var target decodeTarget
+	decode := api.DecodeJSONBody
+	if optional {
+		decode = api.DecodeOptionalJSONBody
+	}
+	if err := decode(req, &target); err != nil {
+		api.HandleAPIError(w, req, err)
+		return w
+	}
+	api.RespondSuccess(w, req, http.StatusOK, target)
+	return w
+}
+
+// decodeErrorDetails 从响应中取出错误码和元数据
+func decodeErrorDetails(t *testing.T, w *httptest.ResponseRecorder) (string, map[string]interface{}) {
+	var env struct {
+		Data api.Response `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &env))
+	require.NotNil(t, env.Data.Error)
+
+	details := map[string]interface{}{}
+	if env.Data.Error.Details != "" {
+		require.NoError(t, json.Unmarshal([]byte(env.Data.Error.Details), &details))
+	}
+	return env.Data.Error.Code, details
+}
+
+func TestDecodeJSONBody(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantReason string
+		wantField  string
+	}{
+		{"MalformedJSON", "这不是有效的JSON", api.DecodeReasonSyntax, ""},
+		{"TruncatedJSON", `{"name": "a"`, api.DecodeReasonSyntax, ""},
+		{"WrongFieldType", `{"name": "a", "size": "big"}`, api.DecodeReasonType, "size"},
+		{"TrailingGarbage", `{"name": "a"} garbage`, api.DecodeReasonTrailingData, ""},
+		{"TrailingObject", `{"name": "a"}{"name": "b"}`, api.DecodeReasonTrailingData, ""},
+		{"EmptyBody", "", api.DecodeReasonEmptyBody, ""},
+		{"WhitespaceBody", "  \n ", api.DecodeReasonEmptyBody, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := respondDecode(tt.body, false)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			code, details := decodeErrorDetails(t, w)
+			assert.Equal(t, "invalid_argument", code)
+			assert.Equal(t, tt.wantReason, details["reason"])
+			if tt.wantField != "" {
+				assert.Equal(t, tt.wantField, details["field"])
+			}
+		})
+	}
+}
+
+func TestDecodeJSONBodySuccess(t *testing.T) {
+	w := respondDecode(`{"name": "a.txt", "size": 10}`+"\n", false)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestDecodeOptionalJSONBody(t *testing.T) {
+	w := respondDecode("", true)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 可选请求体仍然需要是合法的JSON
+	w = respondDecode("{bad", true)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestDecodeJSONBodyTooLarge(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name": "`+strings.Repeat("x", 100)+`"}`))
+	req.Body = http.MaxBytesReader(httptest.NewRecorder(), req.Body, 16)
+
+	var target decodeTarget
+	err := api.DecodeJSONBody(req, &target)
+	require.Error(t, err)
+	assert.True(t, errors.IsResourceExhausted(err))
+}
@@ -0,0 +1,55 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/metrics"
+	nethttp "github.com/22827099/DFS_v1/common/network/http"
+	"github.com/22827099/DFS_v1/internal/metaserver/server/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsMiddleware_GroupsByRouteTemplate(t *testing.T) {
+	collector := metrics.NewCollector("test")
+	server := nethttp.NewServer("127.0.0.1:0")
+	server.Use(middleware.Metrics(collector))
+
+	router := server.Group("/api/v1")
+	router.GET("/files/{path:.*}", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	})
+	router.GET("/nodes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	for _, path := range []string{"/api/v1/files/a.txt", "/api/v1/files/dir/b.txt", "/api/v1/nodes/n1", "/api/v1/nodes/n2", "/api/v1/nodes/n3"} {
+		resp, err := http.Get(ts.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	// 不同的原始路径归入同一个路由模板
+	latencies := collector.GetRouteLatencies()
+	require.Len(t, latencies, 2)
+	files, ok := latencies["GET /api/v1/files/{path}"]
+	require.True(t, ok, "%v", latencies)
+	nodes, ok := latencies["GET /api/v1/nodes/{id}"]
+	require.True(t, ok, "%v", latencies)
+
+	assert.Equal(t, uint64(2), files.Count)
+	assert.Equal(t, uint64(3), nodes.Count)
+	assert.GreaterOrEqual(t, files.P50Ms, int64(20))
+	assert.GreaterOrEqual(t, files.MaxMs, int64(20))
+	assert.Less(t, nodes.MaxMs, files.MaxMs)
+
+	for _, m := range collector.GetHTTPMetrics() {
+		assert.Contains(t, []string{"/api/v1/files/{path}", "/api/v1/nodes/{id}"}, m.Path)
+	}
+}
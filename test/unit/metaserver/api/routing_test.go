@@ -0,0 +1,87 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	nethttp "github.com/22827099/DFS_v1/common/network/http"
+	"github.com/22827099/DFS_v1/internal/metaserver/server/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPathEchoServer 创建一个返回规范化资源路径的服务器
+func newPathEchoServer(mode nethttp.TrailingSlashMode) *httptest.Server {
+	server := nethttp.NewServer("127.0.0.1:0", nethttp.WithTrailingSlash(mode))
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		nethttp.RespondText(w, http.StatusOK, api.ExtractPath(r))
+	}
+	router := server.Group("/api/v1")
+	router.GET("/directories/{path:.*}", echo)
+	router.POST("/directories/{path:.*}", echo)
+	router.GET("/nodes/{path}", echo)
+	return httptest.NewServer(server)
+}
+
+// resolve 请求URL并返回最终的状态码和响应体，重定向会被跟随
+func resolve(t *testing.T, method, url string) (int, string) {
+	req, err := http.NewRequest(method, url, nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	buf := make([]byte, 256)
+	n, _ := resp.Body.Read(buf)
+	return resp.StatusCode, string(buf[:n])
+}
+
+func TestTrailingSlashModes(t *testing.T) {
+	modes := map[string]nethttp.TrailingSlashMode{
+		"Strict":   nethttp.TrailingSlashStrict,
+		"Strip":    nethttp.TrailingSlashStrip,
+		"Redirect": nethttp.TrailingSlashRedirect,
+	}
+
+	for name, mode := range modes {
+		t.Run(name, func(t *testing.T) {
+			server := newPathEchoServer(mode)
+			defer server.Close()
+
+			// 通配路由在所有模式下都应解析到同一资源
+			for _, method := range []string{http.MethodGet, http.MethodPost} {
+				code, plain := resolve(t, method, server.URL+"/api/v1/directories/foo")
+				require.Equal(t, http.StatusOK, code)
+				code, slashed := resolve(t, method, server.URL+"/api/v1/directories/foo/")
+				require.Equal(t, http.StatusOK, code)
+				assert.Equal(t, "/foo", plain)
+				assert.Equal(t, plain, slashed, "%s %s模式下两种写法应指向同一资源", method, name)
+			}
+
+			// 非通配路由只有在strip和redirect模式下才接受尾部斜杠
+			code, body := resolve(t, http.MethodGet, server.URL+"/api/v1/nodes/node-1/")
+			if mode == nethttp.TrailingSlashStrict {
+				assert.Equal(t, http.StatusNotFound, code)
+			} else {
+				assert.Equal(t, http.StatusOK, code)
+				assert.Equal(t, "/node-1", body)
+			}
+		})
+	}
+}
+
+func TestTrailingSlashRedirectPreservesQuery(t *testing.T) {
+	server := newPathEchoServer(nethttp.TrailingSlashRedirect)
+	defer server.Close()
+
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(server.URL + "/api/v1/directories/foo/?recursive=true")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusPermanentRedirect, resp.StatusCode)
+	assert.Equal(t, "/api/v1/directories/foo?recursive=true", resp.Header.Get("Location"))
+}
@@ -0,0 +1,73 @@
+package v1_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/common/security/auth"
+	"github.com/22827099/DFS_v1/common/security/token"
+	"github.com/22827099/DFS_v1/common/types"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/acl"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/user"
+	v1 "github.com/22827099/DFS_v1/internal/metaserver/server/api/v1"
+	"github.com/22827099/DFS_v1/test/testutil"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newACLManager 创建启用访问控制的元数据管理器，元数据库中有目录/projects，alice拥有它的读权限
+func newACLManager(t *testing.T) *metadata.Manager {
+	t.Helper()
+	db := testutil.NewMetaDB(t)
+
+	maker, err := token.NewJWTMaker("0123456789abcdef0123456789abcdef")
+	require.NoError(t, err)
+	users, err := user.NewService(db, maker)
+	require.NoError(t, err)
+	alice, err := users.Create(context.Background(), "alice", "password123")
+	require.NoError(t, err)
+	_, err = users.Create(context.Background(), "bob", "password123")
+	require.NoError(t, err)
+
+	projects := testutil.Mkdir(t, db, 0, testutil.RootDirID, "projects")
+	require.NoError(t, acl.NewDBStore(db).Grant(context.Background(), alice.ID, acl.ObjectDirectory, projects, acl.PermissionRead))
+
+	mgr, err := metadata.NewManager(db, logging.NewLogger(), metadata.WithACL(true))
+	require.NoError(t, err)
+	require.NoError(t, mgr.Start())
+	t.Cleanup(func() { mgr.Stop(context.Background()) })
+	return mgr
+}
+
+func TestFilesAPI_ACLDeniesWithoutPermission(t *testing.T) {
+	checker := newACLManager(t)
+	store := newFilesTestStore(t)
+	_, err := store.CreateDirectory(context.Background(), metadata.DirectoryInfo{BasicFileInfo: types.BasicFileInfo{Path: "/projects"}})
+	require.NoError(t, err)
+	_, err = store.CreateFile(context.Background(), metadata.FileInfo{BasicFileInfo: types.BasicFileInfo{Path: "/projects/a.txt"}})
+	require.NoError(t, err)
+	api := v1.NewFilesAPI(store, v1.WithFileAccessChecker(checker))
+
+	serve := func(handler http.HandlerFunc, method, username, userID string) int {
+		req := httptest.NewRequest(method, "/api/v1/files/projects/a.txt", nil)
+		req = req.WithContext(auth.WithUserContext(req.Context(), &auth.UserInfo{UserID: userID, Username: username}))
+		req = mux.SetURLVars(req, map[string]string{"path": "/projects/a.txt"})
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w.Code
+	}
+
+	// alice只有projects的读权限，bob没有任何权限
+	assert.Equal(t, http.StatusOK, serve(api.GetFileInfo, http.MethodGet, "alice", "2"))
+	assert.Equal(t, http.StatusForbidden, serve(api.DeleteFile, http.MethodDelete, "alice", "2"))
+	assert.Equal(t, http.StatusForbidden, serve(api.GetFileInfo, http.MethodGet, "bob", "3"))
+
+	// 被拒绝的删除没有修改存储
+	_, err = store.GetFileInfo(context.Background(), "/projects/a.txt")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,128 @@
+package v1_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/security/auth"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	v1 "github.com/22827099/DFS_v1/internal/metaserver/server/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// weightsCluster 用一个加权得分策略保存权重的集群管理器
+type weightsCluster struct {
+	cluster.Manager
+	leader   bool
+	strategy *rebalance.WeightedScoreStrategy
+}
+
+func newWeightsCluster(leader bool) *weightsCluster {
+	defaults := rebalance.DefaultScoreWeights()
+	return &weightsCluster{
+		leader:   leader,
+		strategy: rebalance.NewWeightedScoreStrategy(defaults.CPU, defaults.Memory, defaults.Disk, defaults.Shard),
+	}
+}
+
+func (c *weightsCluster) RebalanceEnabled() bool   { return true }
+func (c *weightsCluster) GetCurrentLeader() string { return "2" }
+
+func (c *weightsCluster) GetBalanceWeights() (rebalance.ScoreWeights, error) {
+	if !c.leader {
+		return rebalance.ScoreWeights{}, fmt.Errorf("%w, 领导者为2", cluster.ErrNotLeader)
+	}
+	return c.strategy.Weights(), nil
+}
+
+func (c *weightsCluster) UpdateBalanceWeights(weights rebalance.ScoreWeights) (rebalance.ScoreWeights, error) {
+	if !c.leader {
+		return rebalance.ScoreWeights{}, fmt.Errorf("%w, 领导者为2", cluster.ErrNotLeader)
+	}
+	return c.strategy.SetWeights(weights)
+}
+
+// callWeights 以管理员身份调用权重接口，body为空时发送GET请求，否则发送PUT请求
+func callWeights(t *testing.T, c *weightsCluster, body string) (int, rebalance.ScoreWeights) {
+	t.Helper()
+	return callWeightsAs(t, c, body, auth.RoleAdmin)
+}
+
+// callWeightsAs 以具有roles角色的用户调用权重接口
+func callWeightsAs(t *testing.T, c *weightsCluster, body string, roles ...auth.Role) (int, rebalance.ScoreWeights) {
+	t.Helper()
+	clusterAPI := v1.NewClusterAPI(c)
+	w := httptest.NewRecorder()
+	if body == "" {
+		clusterAPI.GetBalanceWeights(w, httptest.NewRequest(http.MethodGet, "/api/v1/cluster/balance/weights", nil))
+	} else {
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/cluster/balance/weights", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(auth.WithUserContext(req.Context(), &auth.UserInfo{Username: "u", Roles: roles}))
+		clusterAPI.UpdateBalanceWeights(w, req)
+	}
+
+	var weights rebalance.ScoreWeights
+	if w.Code == http.StatusOK {
+		var env apiEnvelope
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &env))
+		require.NoError(t, json.Unmarshal(env.Data.Data, &weights))
+	}
+	return w.Code, weights
+}
+
+func TestBalanceWeights_ReadAndUpdate(t *testing.T) {
+	c := newWeightsCluster(true)
+
+	code, weights := callWeights(t, c, "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, rebalance.DefaultScoreWeights(), weights)
+
+	// 更新后返回归一化的权重，之后读取到相同的权重
+	code, weights = callWeights(t, c, `{"cpu": 1, "memory": 1, "disk": 2, "shard": 0}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, rebalance.ScoreWeights{CPU: 0.25, Memory: 0.25, Disk: 0.5}, weights)
+
+	code, weights = callWeights(t, c, "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, rebalance.ScoreWeights{CPU: 0.25, Memory: 0.25, Disk: 0.5}, weights)
+}
+
+func TestBalanceWeights_RejectsInvalidWeights(t *testing.T) {
+	c := newWeightsCluster(true)
+
+	for _, body := range []string{`{"cpu": -1, "shard": 2}`, `{}`, `not json`} {
+		code, _ := callWeights(t, c, body)
+		assert.Equal(t, http.StatusBadRequest, code, body)
+	}
+	assert.Equal(t, rebalance.DefaultScoreWeights(), c.strategy.Weights())
+}
+
+func TestBalanceWeights_FollowerReturnsUnavailable(t *testing.T) {
+	c := newWeightsCluster(false)
+
+	code, _ := callWeights(t, c, "")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	code, _ = callWeights(t, c, `{"cpu": 1}`)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+}
+
+func TestBalanceWeights_UpdateRequiresAdmin(t *testing.T) {
+	c := newWeightsCluster(true)
+
+	code, _ := callWeightsAs(t, c, `{"cpu": 1}`)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = callWeightsAs(t, c, `{"cpu": 1}`, auth.RoleUser)
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Equal(t, rebalance.DefaultScoreWeights(), c.strategy.Weights())
+
+	// 读取权重不需要管理员角色
+	code, _ = callWeightsAs(t, c, "")
+	assert.Equal(t, http.StatusOK, code)
+}
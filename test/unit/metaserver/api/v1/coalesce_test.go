@@ -0,0 +1,95 @@
+package v1_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+	v1 "github.com/22827099/DFS_v1/internal/metaserver/server/api/v1"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingStore 统计GetFileInfo的调用次数，gate关闭前读取一直阻塞
+type countingStore struct {
+	metadata.Store
+	calls int32
+	gate  chan struct{}
+}
+
+func (s *countingStore) GetFileInfo(ctx context.Context, filePath string) (*metadata.FileInfo, error) {
+	atomic.AddInt32(&s.calls, 1)
+	<-s.gate
+	info := &metadata.FileInfo{}
+	info.Path = filePath
+	info.Size = 42
+	return info, nil
+}
+
+func TestGetFileInfo_CoalescesConcurrentReads(t *testing.T) {
+	store := &countingStore{gate: make(chan struct{})}
+	api := v1.NewFilesAPI(store)
+
+	const n = 100
+	codes := make([]int, n)
+	sizes := make([]interface{}, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			code, data := getFileInfo(t, api, "/hot.bin", "")
+			codes[i], sizes[i] = code, data["size"]
+		}(i)
+	}
+
+	// 给所有请求加入进行中的读取留出时间
+	time.Sleep(100 * time.Millisecond)
+	close(store.gate)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&store.calls), "并发读取同一文件应只访问一次存储")
+	for i := 0; i < n; i++ {
+		assert.Equal(t, http.StatusOK, codes[i])
+		assert.Equal(t, float64(42), sizes[i])
+	}
+}
+
+func TestGetFileInfo_SequentialReadsNotCached(t *testing.T) {
+	store := &countingStore{gate: make(chan struct{})}
+	close(store.gate)
+	api := v1.NewFilesAPI(store)
+
+	for i := 0; i < 3; i++ {
+		code, _ := getFileInfo(t, api, "/hot.bin", "")
+		assert.Equal(t, http.StatusOK, code)
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&store.calls), "合并只针对并发读取，不缓存结果")
+}
+
+// panickingStore 读取文件信息时panic
+type panickingStore struct {
+	metadata.Store
+}
+
+func (s *panickingStore) GetFileInfo(ctx context.Context, filePath string) (*metadata.FileInfo, error) {
+	panic("存储内部状态损坏")
+}
+
+func TestGetFileInfo_StorePanicReturnsInternalError(t *testing.T) {
+	api := v1.NewFilesAPI(&panickingStore{})
+
+	// 合并读取在后台协程中执行，panic不会到达RecoveryMiddleware，也不能使进程崩溃
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/files/a.txt", nil)
+	req = mux.SetURLVars(req, map[string]string{"path": "/a.txt"})
+	w := httptest.NewRecorder()
+	api.GetFileInfo(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "存储内部状态损坏", "panic的内容不返回给客户端")
+}
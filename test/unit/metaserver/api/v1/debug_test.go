@@ -0,0 +1,74 @@
+package v1_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/security/auth"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster"
+	v1 "github.com/22827099/DFS_v1/internal/metaserver/server/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// debugCluster 只实现DebugState的集群管理器
+type debugCluster struct {
+	cluster.Manager
+}
+
+func (c *debugCluster) DebugState(ctx context.Context) map[string]interface{} {
+	return map[string]interface{}{
+		"locks":      map[string]bool{"state_mu": false},
+		"state":      map[string]interface{}{"leader": "1"},
+		"raft":       map[string]interface{}{"term": 3},
+		"migrations": []interface{}{},
+	}
+}
+
+// dump 以指定角色的用户请求诊断接口
+func dump(t *testing.T, roles ...auth.Role) (int, map[string]json.RawMessage) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/debug/dump", nil)
+	if roles != nil {
+		req = req.WithContext(auth.WithUserContext(req.Context(), &auth.UserInfo{Username: "u", Roles: roles}))
+	}
+	w := httptest.NewRecorder()
+
+	v1.NewDebugAPI(&debugCluster{}).Dump(w, req)
+
+	var env apiEnvelope
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &env))
+	var data map[string]json.RawMessage
+	if len(env.Data.Data) > 0 {
+		require.NoError(t, json.Unmarshal(env.Data.Data, &data))
+	}
+	return w.Code, data
+}
+
+func TestDebugDump_Sections(t *testing.T) {
+	code, data := dump(t, auth.RoleAdmin)
+	require.Equal(t, http.StatusOK, code)
+
+	for _, section := range []string{"goroutines", "locks", "cluster_state", "raft", "migrations"} {
+		assert.Contains(t, data, section)
+	}
+
+	var goroutines struct {
+		Count  int    `json:"count"`
+		Stacks string `json:"stacks"`
+	}
+	require.NoError(t, json.Unmarshal(data["goroutines"], &goroutines))
+	assert.Positive(t, goroutines.Count)
+	assert.Contains(t, goroutines.Stacks, "goroutine ")
+}
+
+func TestDebugDump_RequiresAdmin(t *testing.T) {
+	code, _ := dump(t, auth.RoleUser)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, _ = dump(t)
+	assert.Equal(t, http.StatusForbidden, code, "未认证的请求不能导出诊断信息")
+}
@@ -0,0 +1,140 @@
+package v1_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/types"
+	"github.com/22827099/DFS_v1/common/utils"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+	"github.com/22827099/DFS_v1/internal/metaserver/server"
+	v1 "github.com/22827099/DFS_v1/internal/metaserver/server/api/v1"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// apiEnvelope 对应nethttp.RespondJSON包装后的api.Response
+type apiEnvelope struct {
+	Success bool `json:"success"`
+	Data    struct {
+		Status string          `json:"status"`
+		Data   json.RawMessage `json:"data"`
+	} `json:"data"`
+}
+
+func newFilesTestStore(t *testing.T) *server.MemoryStore {
+	store, err := server.NewMemoryStore()
+	require.NoError(t, err)
+	require.NoError(t, store.Initialize())
+	return store
+}
+
+// chunkData 测试文件各块的实际数据，按块索引
+var chunkData = [][]byte{[]byte("aaaa"), []byte("bbbb")}
+
+// testChunks 返回记录了chunkData校验和的块信息
+func testChunks() []metadata.ChunkInfo {
+	return []metadata.ChunkInfo{
+		{BasicChunkInfo: types.BasicChunkInfo{Index: 0, Size: 4, Checksum: utils.SHA256Hash(chunkData[0])}},
+		{BasicChunkInfo: types.BasicChunkInfo{Index: 1, Size: 4, Offset: 4, Checksum: utils.SHA256Hash(chunkData[1])}},
+	}
+}
+
+// fakeChunkReader 按文件路径和块索引返回内存中的块数据
+type fakeChunkReader map[string][][]byte
+
+func (r fakeChunkReader) ReadChunk(ctx context.Context, filePath string, chunk metadata.ChunkInfo) (io.ReadCloser, error) {
+	chunks, ok := r[filePath]
+	if !ok || chunk.Index >= len(chunks) {
+		return nil, errors.New("块不可用")
+	}
+	return io.NopCloser(bytes.NewReader(chunks[chunk.Index])), nil
+}
+
+func getFileInfo(t *testing.T, api *v1.FilesAPI, filePath, query string) (int, map[string]interface{}) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/files"+filePath+query, nil)
+	req = mux.SetURLVars(req, map[string]string{"path": filePath})
+	w := httptest.NewRecorder()
+
+	api.GetFileInfo(w, req)
+
+	var env apiEnvelope
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &env))
+	var data map[string]interface{}
+	if len(env.Data.Data) > 0 {
+		require.NoError(t, json.Unmarshal(env.Data.Data, &data))
+	}
+	return w.Code, data
+}
+
+func TestGetFileInfoVerify(t *testing.T) {
+	store := newFilesTestStore(t)
+	reader := fakeChunkReader{
+		"/good.bin":      chunkData,
+		"/corrupted.bin": {chunkData[0], []byte("bbbX")},
+		"/tampered.bin":  chunkData,
+	}
+	api := v1.NewFilesAPI(store, v1.WithChunkReader(reader))
+
+	for _, file := range []metadata.FileInfo{
+		{BasicFileInfo: types.BasicFileInfo{Path: "/good.bin"}, Chunks: testChunks()},
+		{BasicFileInfo: types.BasicFileInfo{Path: "/corrupted.bin"}, Chunks: testChunks()},
+		{BasicFileInfo: types.BasicFileInfo{Path: "/tampered.bin"}, Chunks: testChunks(), Checksum: "deadbeef"},
+		{BasicFileInfo: types.BasicFileInfo{Path: "/lost.bin"}, Chunks: testChunks()},
+	} {
+		_, err := store.CreateFile(context.Background(), file)
+		require.NoError(t, err)
+	}
+
+	t.Run("ValidChecksum", func(t *testing.T) {
+		code, data := getFileInfo(t, api, "/good.bin", "?verify=true")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, true, data["checksum_valid"])
+		assert.Equal(t, metadata.ComputeFileChecksum(testChunks()), data["checksum"])
+		assert.NotContains(t, data, "invalid_chunks")
+	})
+
+	t.Run("CorruptedChunkData", func(t *testing.T) {
+		// 块记录的校验和与文件校验和都未改变，只有读到的数据不一致
+		code, data := getFileInfo(t, api, "/corrupted.bin", "?verify=true")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, false, data["checksum_valid"])
+		assert.Equal(t, []interface{}{float64(1)}, data["invalid_chunks"])
+	})
+
+	t.Run("TamperedChecksum", func(t *testing.T) {
+		code, data := getFileInfo(t, api, "/tampered.bin", "?verify=true")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, false, data["checksum_valid"])
+		assert.NotContains(t, data, "invalid_chunks")
+	})
+
+	t.Run("ChunkUnreadable", func(t *testing.T) {
+		code, _ := getFileInfo(t, api, "/lost.bin", "?verify=true")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+	})
+
+	t.Run("VerifyIsOptIn", func(t *testing.T) {
+		code, data := getFileInfo(t, api, "/tampered.bin", "")
+		assert.Equal(t, http.StatusOK, code)
+		_, present := data["checksum_valid"]
+		assert.False(t, present, "未请求校验时不应返回checksum_valid")
+	})
+
+	t.Run("InvalidVerifyParam", func(t *testing.T) {
+		code, _ := getFileInfo(t, api, "/good.bin", "?verify=maybe")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("NoChunkReader", func(t *testing.T) {
+		code, _ := getFileInfo(t, v1.NewFilesAPI(store), "/good.bin", "?verify=true")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+	})
+}
@@ -0,0 +1,61 @@
+package v1_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/types"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+	v1 "github.com/22827099/DFS_v1/internal/metaserver/server/api/v1"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveLease 以holder的身份调用处理器，path为路由中的路径变量
+func serveLease(handler http.HandlerFunc, method, filePath, holder, body string) int {
+	req := httptest.NewRequest(method, "/api/v1/leases"+filePath, strings.NewReader(body))
+	if holder != "" {
+		req.Header.Set(v1.LeaseHolderHeader, holder)
+	}
+	req = mux.SetURLVars(req, map[string]string{"path": filePath})
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w.Code
+}
+
+func TestLeasesAPI_WritesRequireLeaseHolder(t *testing.T) {
+	store := newFilesTestStore(t)
+	_, err := store.CreateFile(context.Background(), metadata.FileInfo{BasicFileInfo: types.BasicFileInfo{Path: "/a.txt"}})
+	require.NoError(t, err)
+	leases := v1.NewLeasesAPI(store)
+	files := v1.NewFilesAPI(store)
+	batch := v1.NewBatchAPI(store)
+
+	require.Equal(t, http.StatusOK, serveLease(leases.AcquireLease, http.MethodPost, "/a.txt", "writer-1", `{"ttl_seconds":60}`))
+	assert.Equal(t, http.StatusConflict, serveLease(leases.AcquireLease, http.MethodPost, "/a.txt", "writer-2", `{"ttl_seconds":60}`))
+	assert.Equal(t, http.StatusOK, serveLease(leases.RenewLease, http.MethodPut, "/a.txt", "writer-1", `{"ttl_seconds":60}`))
+
+	// 未持有租约的写入者不能修改、删除或移动文件
+	assert.Equal(t, http.StatusForbidden, serveLease(files.UpdateFile, http.MethodPut, "/a.txt", "writer-2", `{"size":1}`))
+	assert.Equal(t, http.StatusForbidden, serveLease(files.DeleteFile, http.MethodDelete, "/a.txt", "", ""))
+	assert.Equal(t, http.StatusForbidden, serveLease(batch.MoveBatch, http.MethodPost, "", "writer-2", `[{"src":"/a.txt","dst":"/b.txt"}]`))
+	_, err = store.GetFileInfo(context.Background(), "/a.txt")
+	require.NoError(t, err)
+
+	// 持有者可以写入，释放后其他写入者可以删除
+	assert.Equal(t, http.StatusOK, serveLease(files.UpdateFile, http.MethodPut, "/a.txt", "writer-1", `{"size":1}`))
+	require.Equal(t, http.StatusOK, serveLease(leases.ReleaseLease, http.MethodDelete, "/a.txt", "writer-1", ""))
+	assert.Equal(t, http.StatusOK, serveLease(files.DeleteFile, http.MethodDelete, "/a.txt", "writer-2", ""))
+}
+
+func TestLeasesAPI_InvalidRequests(t *testing.T) {
+	leases := v1.NewLeasesAPI(newFilesTestStore(t))
+
+	assert.Equal(t, http.StatusBadRequest, serveLease(leases.AcquireLease, http.MethodPost, "/a.txt", "", `{"ttl_seconds":60}`), "缺少持有者")
+	assert.Equal(t, http.StatusBadRequest, serveLease(leases.AcquireLease, http.MethodPost, "/a.txt", "writer-1", `{"ttl_seconds":0}`), "有效期不是正数")
+	assert.Equal(t, http.StatusNotFound, serveLease(leases.RenewLease, http.MethodPut, "/a.txt", "writer-1", `{"ttl_seconds":60}`), "续约不存在的租约")
+}
@@ -0,0 +1,98 @@
+package v1_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/types"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+	v1 "github.com/22827099/DFS_v1/internal/metaserver/server/api/v1"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// catchUpBarrier 模拟落后的跟随者：屏障返回前应用尚未应用的已提交写入
+type catchUpBarrier struct {
+	calls   int
+	err     error
+	pending func()
+}
+
+func (b *catchUpBarrier) LinearizableRead(ctx context.Context) error {
+	b.calls++
+	if b.err != nil {
+		return b.err
+	}
+	if b.pending != nil {
+		b.pending()
+		b.pending = nil
+	}
+	return nil
+}
+
+func serveRead(handler http.HandlerFunc, target, pathVar, header string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if header != "" {
+		req.Header.Set(v1.ConsistencyHeader, header)
+	}
+	req = mux.SetURLVars(req, map[string]string{"path": pathVar})
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w
+}
+
+func TestFilesAPI_LinearizableRead(t *testing.T) {
+	store := newFilesTestStore(t)
+	barrier := &catchUpBarrier{pending: func() {
+		_, err := store.CreateFile(context.Background(), metadata.FileInfo{BasicFileInfo: types.BasicFileInfo{Path: "/a.txt"}, Size: 1})
+		require.NoError(t, err)
+	}}
+	api := v1.NewFilesAPI(store, v1.WithFileReadBarrier(barrier))
+
+	// 默认读取本地状态，不经过屏障，读不到尚未应用的写入
+	w := serveRead(api.GetFileInfo, "/api/v1/files/a.txt", "/a.txt", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Zero(t, barrier.calls)
+
+	w = serveRead(api.GetFileInfo, "/api/v1/files/a.txt?consistency=linearizable", "/a.txt", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, barrier.calls)
+
+	// 请求头同样可以指定
+	w = serveRead(api.GetFileInfo, "/api/v1/files/a.txt", "/a.txt", "Linearizable")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, barrier.calls)
+
+	w = serveRead(api.GetFileInfo, "/api/v1/files/a.txt?consistency=strong", "/a.txt", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 无法确认一致性时不返回可能过期的结果
+	barrier.err = errors.New("没有领导者")
+	w = serveRead(api.GetFileInfo, "/api/v1/files/a.txt?consistency=linearizable", "/a.txt", "")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestDirectoriesAPI_LinearizableRead(t *testing.T) {
+	store := newFilesTestStore(t)
+	_, err := store.CreateDirectory(context.Background(), metadata.DirectoryInfo{BasicFileInfo: types.BasicFileInfo{Path: "/docs"}})
+	require.NoError(t, err)
+	barrier := &catchUpBarrier{pending: func() {
+		_, err := store.CreateFile(context.Background(), metadata.FileInfo{BasicFileInfo: types.BasicFileInfo{Path: "/docs/a.txt"}})
+		require.NoError(t, err)
+	}}
+	api := v1.NewDirectoriesAPI(store, v1.WithDirectoryReadBarrier(barrier))
+
+	w := serveRead(api.ListDirectory, "/api/v1/dirs/docs", "/docs", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "a.txt")
+	assert.Zero(t, barrier.calls)
+
+	w = serveRead(api.ListDirectory, "/api/v1/dirs/docs?consistency=linearizable", "/docs", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "a.txt")
+	assert.Equal(t, 1, barrier.calls)
+}
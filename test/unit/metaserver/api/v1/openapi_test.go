@@ -0,0 +1,103 @@
+package v1_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	nethttp "github.com/22827099/DFS_v1/common/network/http"
+	"github.com/22827099/DFS_v1/internal/metaserver/server/api"
+	v1 "github.com/22827099/DFS_v1/internal/metaserver/server/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetaServerOpenAPISpec(t *testing.T) {
+	store := newFilesTestStore(t)
+	httpServer := nethttp.NewServer("127.0.0.1:0")
+	apiRouter := httpServer.Group("/api/v1")
+	v1.NewFilesAPI(store).RegisterRoutes(apiRouter)
+	v1.NewDirectoriesAPI(store).RegisterRoutes(apiRouter)
+	v1.NewBatchAPI(store).RegisterRoutes(apiRouter)
+	v1.NewLeasesAPI(store).RegisterRoutes(apiRouter)
+	httpServer.GET("/openapi.json", httpServer.OpenAPIHandler("DFS MetaServer API", "v1",
+		nethttp.WithResponseEnvelope(api.Response{}, "data")))
+
+	w := httptest.NewRecorder()
+	httpServer.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var spec struct {
+		Paths map[string]map[string]operation `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &spec))
+
+	files := spec.Paths["/api/v1/files/{path}"]
+	require.NotNil(t, files)
+	for _, method := range []string{"get", "post", "put", "delete"} {
+		assert.Contains(t, files, method)
+	}
+
+	dirs := spec.Paths["/api/v1/dirs/{path}"]
+	require.NotNil(t, dirs)
+	for _, method := range []string{"get", "post", "delete"} {
+		assert.Contains(t, dirs, method)
+	}
+
+	// 写操作声明租约持有者请求头，并带有响应结构
+	assert.Contains(t, files["put"].headerNames(), v1.LeaseHolderHeader)
+	assert.Contains(t, files["get"].headerNames(), v1.ConsistencyHeader)
+	assert.Contains(t, dirs["delete"].headerNames(), v1.LeaseHolderHeader)
+	assert.Contains(t, spec.Paths["/api/v1/batch/move"]["post"].headerNames(), v1.LeaseHolderHeader)
+
+	leases := spec.Paths["/api/v1/leases/{path}"]
+	require.NotNil(t, leases)
+	for _, method := range []string{"post", "put", "delete"} {
+		assert.Contains(t, leases[method].headerNames(), v1.LeaseHolderHeader, method)
+	}
+	assert.NotNil(t, leases["post"].Responses["200"].Content.JSON, "获取租约应声明响应结构")
+
+	// 响应数据位于RespondJSON的data中的api.Response的data字段
+	lease := leases["post"].Responses["200"].Content.JSON.Schema.Properties["data"].Properties
+	assert.Contains(t, lease, "status")
+	assert.Contains(t, lease["data"].Properties, "holder")
+
+	// 创建文件成功返回201，one级别的写入返回202
+	assert.Contains(t, files["post"].Responses, "201")
+	assert.NotContains(t, files["post"].Responses, "200")
+	for _, op := range []operation{files["post"], files["put"], files["delete"], dirs["post"], dirs["delete"], spec.Paths["/api/v1/batch/move"]["post"]} {
+		assert.Contains(t, op.Responses, "202")
+	}
+}
+
+// operation OpenAPI文档中测试关心的operation字段
+type operation struct {
+	Parameters []struct {
+		Name string `json:"name"`
+		In   string `json:"in"`
+	} `json:"parameters"`
+	Responses map[string]struct {
+		Content struct {
+			JSON *struct {
+				Schema schema `json:"schema"`
+			} `json:"application/json"`
+		} `json:"content"`
+	} `json:"responses"`
+}
+
+// schema OpenAPI文档中测试关心的结构字段
+type schema struct {
+	Properties map[string]schema `json:"properties"`
+}
+
+// headerNames 返回operation声明的请求头参数名
+func (op operation) headerNames() []string {
+	var names []string
+	for _, p := range op.Parameters {
+		if p.In == "header" {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,35 @@
+package v1_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster"
+	v1 "github.com/22827099/DFS_v1/internal/metaserver/server/api/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+// rebalanceDisabledCluster 负载均衡被配置禁用的集群管理器，调用其他负载均衡方法时panic
+type rebalanceDisabledCluster struct {
+	cluster.Manager
+}
+
+func (c *rebalanceDisabledCluster) RebalanceEnabled() bool { return false }
+
+func TestRebalanceEndpoints_DisabledReturnsNotImplemented(t *testing.T) {
+	clusterAPI := v1.NewClusterAPI(&rebalanceDisabledCluster{})
+
+	for name, handler := range map[string]http.HandlerFunc{
+		"触发":   clusterAPI.TriggerRebalance,
+		"状态":   clusterAPI.GetRebalanceStatus,
+		"迁移任务": clusterAPI.GetRebalanceTask,
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest(http.MethodGet, "/api/v1/rebalance/status", nil))
+			assert.Equal(t, http.StatusNotImplemented, w.Code)
+			assert.Contains(t, w.Body.String(), "禁用")
+		})
+	}
+}
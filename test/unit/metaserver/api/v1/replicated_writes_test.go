@@ -0,0 +1,139 @@
+package v1_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+	"github.com/22827099/DFS_v1/common/types"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+	"github.com/22827099/DFS_v1/internal/metaserver/server"
+	v1 "github.com/22827099/DFS_v1/internal/metaserver/server/api/v1"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// replicatingConfirmer 把提交的命令按顺序应用到每个节点的状态机，模拟集群中所有节点应用同一条日志，
+// 返回本节点（第一个状态机）的应用结果
+type replicatingConfirmer struct {
+	appliers []*v1.FileCommandApplier
+	cmds     []raft.Command
+}
+
+func (c *replicatingConfirmer) ProposeWrite(ctx context.Context, cmd raft.Command, level raft.ConsistencyLevel) error {
+	c.cmds = append(c.cmds, cmd)
+	var result error
+	for i, applier := range c.appliers {
+		if err := applier.ApplyCommand(ctx, cmd); i == 0 {
+			result = err
+		}
+	}
+	return result
+}
+
+// newReplicatedStores 创建两个节点的存储，两者都包含/a.txt和/dir/b.txt
+func newReplicatedStores(t *testing.T) (*server.MemoryStore, *server.MemoryStore, *replicatingConfirmer) {
+	local, remote := newFilesTestStore(t), newFilesTestStore(t)
+	confirmer := &replicatingConfirmer{appliers: []*v1.FileCommandApplier{
+		v1.NewFileCommandApplier(local), v1.NewFileCommandApplier(remote),
+	}}
+	for _, store := range []*server.MemoryStore{local, remote} {
+		_, err := store.CreateDirectory(context.Background(), metadata.DirectoryInfo{BasicFileInfo: types.BasicFileInfo{Path: "/dir"}})
+		require.NoError(t, err)
+		for _, p := range []string{"/a.txt", "/dir/b.txt"} {
+			_, err := store.CreateFile(context.Background(), metadata.FileInfo{BasicFileInfo: types.BasicFileInfo{Path: p}})
+			require.NoError(t, err)
+		}
+	}
+	return local, remote, confirmer
+}
+
+func serveWrite(handler http.HandlerFunc, method, target, pathVar, body string) int {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if pathVar != "" {
+		req = mux.SetURLVars(req, map[string]string{"path": pathVar})
+	}
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w.Code
+}
+
+func TestReplicatedWrites_BatchMoveReachesEveryNode(t *testing.T) {
+	local, remote, confirmer := newReplicatedStores(t)
+	batch := v1.NewBatchAPI(local, v1.WithBatchWriteConfirmer(confirmer))
+
+	code := serveWrite(batch.MoveBatch, http.MethodPost, "/api/v1/batch/move", "",
+		`[{"src":"/a.txt","dst":"/c.txt"},{"src":"/dir","dst":"/moved"}]`)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, confirmer.cmds, 1, "整批移动应作为一条命令提交")
+	assert.Equal(t, v1.OpMoveBatch, confirmer.cmds[0].Op)
+
+	for name, store := range map[string]*server.MemoryStore{"local": local, "remote": remote} {
+		_, err := store.GetFileInfo(context.Background(), "/c.txt")
+		assert.NoError(t, err, name)
+		_, err = store.GetFileInfo(context.Background(), "/moved/b.txt")
+		assert.NoError(t, err, name)
+		_, err = store.GetFileInfo(context.Background(), "/a.txt")
+		assert.Error(t, err, name)
+	}
+}
+
+func TestReplicatedWrites_DirectoriesReachEveryNode(t *testing.T) {
+	local, remote, confirmer := newReplicatedStores(t)
+	dirs := v1.NewDirectoriesAPI(local, v1.WithDirectoryWriteConfirmer(confirmer))
+
+	require.Equal(t, http.StatusOK, serveWrite(dirs.CreateDirectory, http.MethodPost, "/api/v1/dirs/x/y?create_parents=true", "/x/y", ""))
+	require.Equal(t, http.StatusOK, serveWrite(dirs.DeleteDirectory, http.MethodDelete, "/api/v1/dirs/dir?recursive=true", "/dir", ""))
+
+	for name, store := range map[string]*server.MemoryStore{"local": local, "remote": remote} {
+		_, err := store.GetDirectoryInfo(context.Background(), "/x/y")
+		assert.NoError(t, err, name)
+		_, err = store.GetFileInfo(context.Background(), "/dir/b.txt")
+		assert.Error(t, err, name)
+	}
+
+	// 校验失败的写操作不会提交到集群
+	proposed := len(confirmer.cmds)
+	assert.Equal(t, http.StatusConflict, serveWrite(dirs.CreateDirectory, http.MethodPost, "/api/v1/dirs/x", "/x", ""))
+	assert.Equal(t, http.StatusNotFound, serveWrite(dirs.DeleteDirectory, http.MethodDelete, "/api/v1/dirs/missing", "/missing", ""))
+	assert.Len(t, confirmer.cmds, proposed)
+}
+
+func TestReplicatedWrites_LeaseAcquiredOnOneNodeBlocksOthers(t *testing.T) {
+	local, remote, confirmer := newReplicatedStores(t)
+	// 远端节点提交的命令同样应用到两个节点，返回远端状态机的结果
+	remoteConfirmer := &replicatingConfirmer{appliers: []*v1.FileCommandApplier{confirmer.appliers[1], confirmer.appliers[0]}}
+	localLeases := v1.NewLeasesAPI(local, v1.WithLeaseWriteConfirmer(confirmer))
+	remoteLeases := v1.NewLeasesAPI(remote, v1.WithLeaseWriteConfirmer(remoteConfirmer))
+
+	acquire := func(handler http.HandlerFunc, holder string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/leases/a.txt", strings.NewReader(`{"ttl_seconds":60}`))
+		req = mux.SetURLVars(req, map[string]string{"path": "/a.txt"})
+		req.Header.Set(v1.LeaseHolderHeader, holder)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusOK, acquire(localLeases.AcquireLease, "writer1"))
+	require.Len(t, confirmer.cmds, 1)
+	assert.Equal(t, v1.LeaseOpAcquire, confirmer.cmds[0].Op)
+
+	// 经另一个节点获取同一文件的租约或写入文件都被拒绝
+	assert.Equal(t, http.StatusConflict, acquire(remoteLeases.AcquireLease, "writer2"))
+	assert.Error(t, remote.CheckWriteLease(context.Background(), "/a.txt", "writer2"))
+	assert.NoError(t, remote.CheckWriteLease(context.Background(), "/a.txt", "writer1"))
+
+	// 经远端节点释放后两个节点上的租约都被删除
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/leases/a.txt", nil)
+	req = mux.SetURLVars(req, map[string]string{"path": "/a.txt"})
+	req.Header.Set(v1.LeaseHolderHeader, "writer1")
+	w := httptest.NewRecorder()
+	remoteLeases.ReleaseLease(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, local.CheckWriteLease(context.Background(), "/a.txt", "writer2"))
+}
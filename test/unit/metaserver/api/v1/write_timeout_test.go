@@ -0,0 +1,212 @@
+package v1_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/common/types"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/election"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+	v1 "github.com/22827099/DFS_v1/internal/metaserver/server/api/v1"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+)
+
+// droppingTransport 丢弃所有消息，节点无法与其他成员通信
+type droppingTransport struct{}
+
+func (droppingTransport) Send([]raftpb.Message) {}
+func (droppingTransport) Start() error          { return nil }
+func (droppingTransport) Stop()                 {}
+
+// raftConfirmer 通过Raft节点确认写入，节点无法获得多数派，命令永远不会被应用
+type raftConfirmer struct {
+	node    *raft.RaftNode
+	timeout time.Duration
+}
+
+func (c raftConfirmer) ProposeWrite(ctx context.Context, cmd raft.Command, level raft.ConsistencyLevel) error {
+	data, err := raft.DefaultCommandCodec.Encode(cmd)
+	if err != nil {
+		return err
+	}
+	_, err = c.node.ProposeAndWaitLevel(ctx, data, level, c.timeout)
+	return err
+}
+
+// countingConfirmer 记录提交的命令和一致性级别并直接交给状态机应用
+type countingConfirmer struct {
+	applier *v1.FileCommandApplier
+	cmds    []raft.Command
+	levels  []raft.ConsistencyLevel
+}
+
+func (c *countingConfirmer) ProposeWrite(ctx context.Context, cmd raft.Command, level raft.ConsistencyLevel) error {
+	c.cmds = append(c.cmds, cmd)
+	c.levels = append(c.levels, level)
+	return c.applier.ApplyCommand(ctx, cmd)
+}
+
+// newNoQuorumConfirmer 创建三成员集群中唯一运行的节点，它无法获得多数派
+func newNoQuorumConfirmer(t *testing.T, timeout time.Duration) raftConfirmer {
+	cfg := raft.DefaultConfig()
+	cfg.Peers = []uint64{1, 2, 3}
+	node, err := raft.NewRaftNode(cfg, droppingTransport{})
+	require.NoError(t, err)
+	t.Cleanup(node.Stop)
+	return raftConfirmer{node: node, timeout: timeout}
+}
+
+func TestFileWrites_NoQuorumReturns504(t *testing.T) {
+	const timeout = 300 * time.Millisecond
+	store := newFilesTestStore(t)
+	_, err := store.CreateFile(context.Background(), metadata.FileInfo{BasicFileInfo: types.BasicFileInfo{Path: "/b.txt"}, Size: 1})
+	require.NoError(t, err)
+	api := v1.NewFilesAPI(store, v1.WithWriteConfirmer(newNoQuorumConfirmer(t, timeout)))
+
+	for _, tc := range []struct {
+		method  string
+		path    string
+		body    string
+		handler http.HandlerFunc
+	}{
+		{http.MethodPost, "/a.txt", `{"name":"a.txt","size":1}`, api.CreateFile},
+		{http.MethodPut, "/b.txt", `{"mime_type":"text/plain"}`, api.UpdateFile},
+		{http.MethodDelete, "/b.txt", "", api.DeleteFile},
+	} {
+		t.Run(tc.method, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/api/v1/files"+tc.path, strings.NewReader(tc.body))
+			req = mux.SetURLVars(req, map[string]string{"path": tc.path})
+			w := httptest.NewRecorder()
+
+			start := time.Now()
+			tc.handler(w, req)
+			elapsed := time.Since(start)
+
+			assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+			assert.Contains(t, w.Body.String(), "timeout")
+			assert.GreaterOrEqual(t, elapsed, timeout)
+			assert.Less(t, elapsed, timeout+time.Second, "写入应在配置的超时时间内返回")
+		})
+	}
+
+	// 未确认的写入没有应用到本地存储
+	_, err = store.GetFileInfo(context.Background(), "/a.txt")
+	assert.Error(t, err)
+	info, err := store.GetFileInfo(context.Background(), "/b.txt")
+	require.NoError(t, err)
+	assert.Empty(t, info.MimeType)
+}
+
+func TestFileWrites_ConfirmedWriteApplied(t *testing.T) {
+	mgr, err := election.NewManager(&election.ManagerConfig{NodeID: "1"}, logging.NewLogger())
+	require.NoError(t, err)
+	store := newFilesTestStore(t)
+	mgr.SetCommandApplier(v1.NewFileCommandApplier(store))
+	require.NoError(t, mgr.Start())
+	t.Cleanup(func() { mgr.Stop() })
+	require.Eventually(t, mgr.IsLeader, 5*time.Second, 10*time.Millisecond)
+
+	api := v1.NewFilesAPI(store, v1.WithWriteConfirmer(electionConfirmer{mgr}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/files/b.txt", strings.NewReader(`{"name":"b.txt","size":1}`))
+	req = mux.SetURLVars(req, map[string]string{"path": "/b.txt"})
+	w := httptest.NewRecorder()
+	api.CreateFile(w, req)
+
+	// 处理器不直接修改存储，文件由状态机应用已提交的命令创建
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"size":1`)
+	_, err = store.GetFileInfo(context.Background(), "/b.txt")
+	assert.NoError(t, err)
+}
+
+// electionConfirmer 通过选举管理器提交命令，与cluster.Manager的ProposeWrite相同
+type electionConfirmer struct {
+	mgr *election.Manager
+}
+
+func (c electionConfirmer) ProposeWrite(ctx context.Context, cmd raft.Command, level raft.ConsistencyLevel) error {
+	_, err := c.mgr.ProposeCommandLevel(ctx, cmd, level)
+	return err
+}
+
+func TestFileWrites_InvalidWritesNotProposed(t *testing.T) {
+	store := newFilesTestStore(t)
+	_, err := store.CreateFile(context.Background(), metadata.FileInfo{BasicFileInfo: types.BasicFileInfo{Path: "/exists.txt"}})
+	require.NoError(t, err)
+	confirmer := &countingConfirmer{applier: v1.NewFileCommandApplier(store)}
+	api := v1.NewFilesAPI(store, v1.WithWriteConfirmer(confirmer))
+
+	for _, tc := range []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		handler http.HandlerFunc
+		code    int
+	}{
+		{"创建已存在的文件", http.MethodPost, "/exists.txt", `{"size":1}`, api.CreateFile, http.StatusConflict},
+		{"文件大小为负", http.MethodPost, "/neg.txt", `{"size":-1}`, api.CreateFile, http.StatusBadRequest},
+		{"更新不存在的文件", http.MethodPut, "/missing.txt", `{"mime_type":"text/plain"}`, api.UpdateFile, http.StatusNotFound},
+		{"删除不存在的文件", http.MethodDelete, "/missing.txt", "", api.DeleteFile, http.StatusNotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/api/v1/files"+tc.path, strings.NewReader(tc.body))
+			req = mux.SetURLVars(req, map[string]string{"path": tc.path})
+			w := httptest.NewRecorder()
+			tc.handler(w, req)
+			assert.Equal(t, tc.code, w.Code)
+		})
+	}
+	assert.Empty(t, confirmer.cmds, "校验失败的写操作不应提交到集群")
+}
+
+func TestFileWrites_ConsistencyLevel(t *testing.T) {
+	store := newFilesTestStore(t)
+	confirmer := &countingConfirmer{applier: v1.NewFileCommandApplier(store)}
+	api := v1.NewFilesAPI(store, v1.WithWriteConfirmer(confirmer))
+
+	for _, tc := range []struct {
+		name   string
+		query  string
+		header string
+		level  raft.ConsistencyLevel
+		code   int
+	}{
+		{"默认为quorum", "", "", raft.ConsistencyQuorum, http.StatusCreated},
+		{"查询参数", "?consistency=all", "", raft.ConsistencyAll, http.StatusCreated},
+		{"请求头", "", "ONE", raft.ConsistencyOne, http.StatusAccepted},
+		{"查询参数优先于请求头", "?consistency=quorum", "one", raft.ConsistencyQuorum, http.StatusCreated},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			filePath := "/" + strings.ReplaceAll(tc.name, " ", "_") + ".txt"
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/files"+filePath+tc.query, strings.NewReader(`{"size":1}`))
+			req.Header.Set(v1.ConsistencyHeader, tc.header)
+			req = mux.SetURLVars(req, map[string]string{"path": filePath})
+			w := httptest.NewRecorder()
+			api.CreateFile(w, req)
+
+			assert.Equal(t, tc.code, w.Code)
+			require.NotEmpty(t, confirmer.levels)
+			assert.Equal(t, tc.level, confirmer.levels[len(confirmer.levels)-1])
+		})
+	}
+
+	// 无效的一致性级别在提交前被拒绝
+	proposed := len(confirmer.cmds)
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/files/x.txt?consistency=some", nil)
+	req = mux.SetURLVars(req, map[string]string{"path": "/x.txt"})
+	w := httptest.NewRecorder()
+	api.DeleteFile(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Len(t, confirmer.cmds, proposed)
+}
@@ -0,0 +1,215 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterConfig_ApplyDefaults(t *testing.T) {
+	cfg := metaconfig.ClusterConfig{NodeID: "1", Bootstrap: true}
+	cfg.ApplyDefaults()
+
+	assert.Empty(t, cfg.Peers, "成员列表不默认包含本节点")
+	assert.Equal(t, metaconfig.DefaultElectionTimeout, cfg.ElectionTimeout)
+	assert.Equal(t, metaconfig.DefaultHeartbeatTimeout, cfg.HeartbeatTimeout)
+	assert.Equal(t, metaconfig.DefaultHeartbeatInterval, cfg.HeartbeatInterval)
+	assert.Equal(t, metaconfig.DefaultPeerRefreshInterval, cfg.PeerRefreshInterval)
+	assert.Equal(t, metaconfig.DefaultImbalanceStopRatio, cfg.ImbalanceStopRatio)
+	assert.Equal(t, metaconfig.DefaultSuspectTimeout, cfg.SuspectTimeout)
+	assert.Equal(t, metaconfig.DefaultDeadTimeout, cfg.DeadTimeout)
+	assert.Equal(t, metaconfig.DefaultCleanupInterval, cfg.CleanupInterval)
+	assert.Equal(t, metaconfig.DefaultRebalanceEvaluationInterval, cfg.RebalanceEvaluationInterval)
+	assert.Equal(t, metaconfig.DefaultImbalanceThreshold, cfg.ImbalanceThreshold)
+	assert.Equal(t, metaconfig.DefaultMaxConcurrentMigrations, cfg.MaxConcurrentMigrations)
+	assert.Equal(t, metaconfig.DefaultEventHistorySize, cfg.EventHistorySize)
+	assert.Equal(t, metaconfig.DefaultEventBatchSize, cfg.EventBatchSize)
+	assert.Zero(t, cfg.EventBatchWindow, "事件合并默认关闭")
+	assert.Equal(t, metaconfig.DefaultStatusCacheTTL, cfg.StatusCacheTTL)
+	assert.Equal(t, metaconfig.DefaultLeaderGracePeriod, cfg.LeaderGracePeriod)
+	assert.Equal(t, metaconfig.DefaultSubsystemStopTimeout, cfg.SubsystemStopTimeout)
+	assert.Equal(t, metaconfig.DefaultMetricsHistorySize, cfg.MetricsHistorySize)
+	assert.Equal(t, metaconfig.FailureDetectorFixed, cfg.FailureDetector, "默认按固定超时判定节点故障，phi需显式启用")
+	assert.Equal(t, metaconfig.DefaultPhiSuspectThreshold, cfg.PhiSuspectThreshold)
+	assert.Equal(t, metaconfig.DefaultPhiDeadThreshold, cfg.PhiDeadThreshold)
+	assert.Equal(t, metaconfig.DefaultPhiMinStdDeviation, cfg.PhiMinStdDeviation)
+	assert.Equal(t, metaconfig.DefaultPhiWindowSize, cfg.PhiWindowSize)
+
+	// 零值有含义的配置项保持不变
+	assert.Zero(t, cfg.MaxClusterSize)
+	assert.Zero(t, cfg.DeadNodeReapDelay)
+	assert.Zero(t, cfg.RebalanceSlowStartWindow)
+
+	require.NoError(t, cfg.Validate())
+}
+
+func TestClusterConfig_ApplyDefaultsKeepsExplicitValues(t *testing.T) {
+	cfg := metaconfig.ClusterConfig{
+		NodeID:          "1",
+		Peers:           []string{"1", "2", "3"},
+		ElectionTimeout: 5 * time.Second,
+		SuspectTimeout:  4 * time.Second,
+	}
+	cfg.ApplyDefaults()
+
+	assert.Equal(t, []string{"1", "2", "3"}, cfg.Peers)
+	assert.Equal(t, 5*time.Second, cfg.ElectionTimeout)
+	assert.Equal(t, 4*time.Second, cfg.SuspectTimeout)
+	require.NoError(t, cfg.Validate())
+}
+
+func TestClusterConfig_ValidateRejectsInvalidOrderings(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*metaconfig.ClusterConfig)
+		want   string
+	}{
+		{
+			name:   "心跳超时不小于选举超时",
+			modify: func(c *metaconfig.ClusterConfig) { c.HeartbeatTimeout = c.ElectionTimeout },
+			want:   "heartbeat_timeout",
+		},
+		{
+			name:   "可疑超时不小于死亡超时",
+			modify: func(c *metaconfig.ClusterConfig) { c.SuspectTimeout = 20 * time.Second },
+			want:   "suspect_timeout",
+		},
+		{
+			name:   "心跳间隔不小于可疑超时",
+			modify: func(c *metaconfig.ClusterConfig) { c.HeartbeatInterval = c.SuspectTimeout },
+			want:   "heartbeat_interval",
+		},
+		{
+			name:   "负的清理间隔",
+			modify: func(c *metaconfig.ClusterConfig) { c.CleanupInterval = -time.Second },
+			want:   "cleanup_interval",
+		},
+		{
+			name:   "停止阈值比例超出范围",
+			modify: func(c *metaconfig.ClusterConfig) { c.ImbalanceStopRatio = 1.5 },
+			want:   "imbalance_stop_ratio",
+		},
+		{
+			name:   "停止阈值比例为0",
+			modify: func(c *metaconfig.ClusterConfig) { c.ImbalanceStopRatio = 0 },
+			want:   "imbalance_stop_ratio",
+		},
+		{
+			name:   "负的再平衡最少节点数",
+			modify: func(c *metaconfig.ClusterConfig) { c.RebalanceMinNodes = -1 },
+			want:   "rebalance_min_nodes",
+		},
+		{
+			name:   "成员数超过上限",
+			modify: func(c *metaconfig.ClusterConfig) { c.Peers = []string{"1", "2", "3"}; c.MaxClusterSize = 2 },
+			want:   "max_cluster_size",
+		},
+		{
+			name:   "地址与成员数量不一致",
+			modify: func(c *metaconfig.ClusterConfig) { c.PeerAddresses = []string{"http://a", "http://b"} },
+			want:   "peer_addresses",
+		},
+		{
+			name:   "未知的故障检测方式",
+			modify: func(c *metaconfig.ClusterConfig) { c.FailureDetector = "timeout" },
+			want:   "failure_detector",
+		},
+		{
+			name: "phi可疑阈值不小于死亡阈值",
+			modify: func(c *metaconfig.ClusterConfig) {
+				c.FailureDetector = metaconfig.FailureDetectorPhi
+				c.PhiSuspectThreshold = c.PhiDeadThreshold
+			},
+			want: "phi_suspect_threshold",
+		},
+		{
+			name: "phi窗口过小",
+			modify: func(c *metaconfig.ClusterConfig) {
+				c.FailureDetector = metaconfig.FailureDetectorPhi
+				c.PhiWindowSize = 1
+			},
+			want: "phi_window_size",
+		},
+		{
+			name:   "缺少节点ID",
+			modify: func(c *metaconfig.ClusterConfig) { c.NodeID = "" },
+			want:   "节点ID",
+		},
+		{
+			name:   "既不引导也不加入",
+			modify: func(c *metaconfig.ClusterConfig) { c.Peers = nil },
+			want:   "bootstrap",
+		},
+		{
+			name: "引导时又配置了种子节点",
+			modify: func(c *metaconfig.ClusterConfig) {
+				c.Peers = nil
+				c.Bootstrap = true
+				c.SeedPeers = []string{"http://seed"}
+			},
+			want: "bootstrap",
+		},
+		{
+			name: "通过种子节点加入但没有本节点地址",
+			modify: func(c *metaconfig.ClusterConfig) {
+				c.Peers = nil
+				c.SeedPeers = []string{"http://seed"}
+			},
+			want: "node_address",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := metaconfig.ClusterConfig{NodeID: "1", Peers: []string{"1"}}
+			cfg.ApplyDefaults()
+			tt.modify(&cfg)
+
+			err := cfg.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestHeartbeatConfig_ApplyDefaults(t *testing.T) {
+	cfg := metaconfig.HeartbeatConfig{NodeID: "1", DeadTimeout: time.Minute}
+	cfg.ApplyDefaults()
+
+	assert.Equal(t, metaconfig.DefaultHeartbeatInterval, cfg.HeartbeatInterval)
+	assert.Equal(t, metaconfig.DefaultSuspectTimeout, cfg.SuspectTimeout)
+	assert.Equal(t, time.Minute, cfg.DeadTimeout)
+	assert.Equal(t, metaconfig.DefaultCleanupInterval, cfg.CleanupInterval)
+	assert.Equal(t, metaconfig.FailureDetectorFixed, cfg.FailureDetector)
+	require.NoError(t, cfg.Validate())
+}
+
+func TestHeartbeatConfig_FixedModeIgnoresPhiSettings(t *testing.T) {
+	cfg := metaconfig.HeartbeatConfig{FailureDetector: metaconfig.FailureDetectorFixed, PhiSuspectThreshold: 20}
+	cfg.ApplyDefaults()
+	assert.NoError(t, cfg.Validate(), "fixed模式下不检查phi参数")
+
+	cfg.FailureDetector = metaconfig.FailureDetectorPhi
+	assert.Error(t, cfg.Validate(), "phi模式下可疑阈值不能超过死亡阈值")
+}
+
+func TestClusterConfig_SubsystemToggles(t *testing.T) {
+	var cfg metaconfig.ClusterConfig
+	assert.True(t, cfg.RebalanceEnabled(), "未设置时默认启用")
+	assert.True(t, cfg.HeartbeatReaperEnabled())
+
+	path := writeConfig(t, `
+cluster:
+  node_id: node-1
+  bootstrap: true
+  enable_rebalance: false
+  enable_heartbeat_reaper: true
+`)
+	loaded, err := metaconfig.LoadMetaServerConfig(path)
+	require.NoError(t, err)
+	assert.False(t, loaded.Cluster.RebalanceEnabled())
+	assert.True(t, loaded.Cluster.HeartbeatReaperEnabled())
+}
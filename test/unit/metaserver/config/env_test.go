@@ -0,0 +1,49 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConfig 在临时目录中写入YAML配置文件并返回路径
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+const envTestConfig = `
+cluster:
+  node_id: node-1
+  election_timeout: 2s
+  peers: [node-1]
+`
+
+func TestLoadMetaServerConfig_EnvOverridesDurationAndPeers(t *testing.T) {
+	path := writeConfig(t, envTestConfig)
+	t.Setenv("CLUSTER_ELECTION_TIMEOUT", "3s")
+	t.Setenv("CLUSTER_HEARTBEAT_TIMEOUT", "250ms")
+	t.Setenv("CLUSTER_PEERS", "node-1, node-2,node-3")
+
+	cfg, err := config.LoadMetaServerConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, 3*time.Second, cfg.Cluster.ElectionTimeout)
+	assert.Equal(t, 250*time.Millisecond, cfg.Cluster.HeartbeatTimeout)
+	assert.Equal(t, []string{"node-1", "node-2", "node-3"}, cfg.Cluster.Peers)
+}
+
+func TestLoadMetaServerConfig_RejectsMalformedEnv(t *testing.T) {
+	path := writeConfig(t, envTestConfig)
+
+	t.Setenv("CLUSTER_ELECTION_TIMEOUT", "3")
+	_, err := config.LoadMetaServerConfig(path)
+	require.Error(t, err, "缺少单位的时长应被拒绝")
+	assert.Contains(t, err.Error(), "CLUSTER_ELECTION_TIMEOUT")
+}
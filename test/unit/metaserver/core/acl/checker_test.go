@@ -0,0 +1,163 @@
+package acl_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/errors"
+	"github.com/22827099/DFS_v1/common/security/auth"
+	"github.com/22827099/DFS_v1/common/security/token"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/database"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/acl"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/user"
+	"github.com/22827099/DFS_v1/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试目录树：
+//
+//	/ (1)
+//	└── projects (2)
+//	    ├── alpha (3)
+//	    │   └── report.txt (文件100)
+//	    └── beta (4)
+//	home (5)，与projects同级
+const (
+	rootDir     int64 = 1
+	projectsDir int64 = 2
+	alphaDir    int64 = 3
+	betaDir     int64 = 4
+	homeDir     int64 = 5
+	reportFile  int64 = 100
+
+	aliceID = 2
+	bobID   = 3
+)
+
+// newTestStore 创建带有测试目录树和用户的SQLite数据库
+func newTestStore(t *testing.T) (*database.Manager, *acl.DBStore) {
+	t.Helper()
+	db := testutil.NewMetaDB(t)
+
+	maker, err := token.NewJWTMaker("0123456789abcdef0123456789abcdef")
+	require.NoError(t, err)
+	users, err := user.NewService(db, maker)
+	require.NoError(t, err)
+	for _, u := range []struct {
+		id   int
+		name string
+	}{{aliceID, "alice"}, {bobID, "bob"}} {
+		created, err := users.Create(context.Background(), u.name, "password123")
+		require.NoError(t, err)
+		require.Equal(t, u.id, created.ID)
+	}
+
+	testutil.Mkdir(t, db, projectsDir, rootDir, "projects")
+	testutil.Mkdir(t, db, alphaDir, projectsDir, "alpha")
+	testutil.Mkdir(t, db, betaDir, projectsDir, "beta")
+	testutil.Mkdir(t, db, homeDir, rootDir, "home")
+	testutil.CreateFile(t, db, reportFile, alphaDir, "report.txt")
+	return db, acl.NewDBStore(db)
+}
+
+func userContext(userID, username string, roles ...auth.Role) context.Context {
+	return auth.WithUserContext(context.Background(), &auth.UserInfo{UserID: userID, Username: username, Roles: roles})
+}
+
+func assertDenied(t *testing.T, err error) {
+	t.Helper()
+	require.Error(t, err)
+	assert.True(t, errors.IsPermissionDenied(err), "期望PermissionDenied，实际: %v", err)
+}
+
+func TestChecker_DirectGrant(t *testing.T) {
+	_, store := newTestStore(t)
+	checker := acl.NewChecker(store)
+	ctx := context.Background()
+
+	require.NoError(t, store.Grant(ctx, aliceID, acl.ObjectDirectory, homeDir, acl.PermissionRead))
+
+	alice := userContext("2", "alice")
+	assert.NoError(t, checker.Check(alice, acl.ObjectDirectory, homeDir, acl.PermissionRead))
+	assertDenied(t, checker.Check(alice, acl.ObjectDirectory, homeDir, acl.PermissionWrite))
+
+	// 其他用户不受影响
+	bob := userContext("3", "bob")
+	assertDenied(t, checker.Check(bob, acl.ObjectDirectory, homeDir, acl.PermissionRead))
+}
+
+func TestChecker_InheritsDownSubtree(t *testing.T) {
+	_, store := newTestStore(t)
+	checker := acl.NewChecker(store)
+	ctx := context.Background()
+
+	require.NoError(t, store.Grant(ctx, aliceID, acl.ObjectDirectory, projectsDir, acl.PermissionWrite))
+	alice := userContext("2", "alice")
+
+	// 授予projects的权限对其子目录和其中的文件生效
+	assert.NoError(t, checker.Check(alice, acl.ObjectDirectory, projectsDir, acl.PermissionWrite))
+	assert.NoError(t, checker.Check(alice, acl.ObjectDirectory, alphaDir, acl.PermissionWrite))
+	assert.NoError(t, checker.Check(alice, acl.ObjectDirectory, betaDir, acl.PermissionWrite))
+	assert.NoError(t, checker.Check(alice, acl.ObjectFile, reportFile, acl.PermissionWrite))
+
+	// 子树之外以及上级目录不受影响
+	assertDenied(t, checker.Check(alice, acl.ObjectDirectory, homeDir, acl.PermissionWrite))
+	assertDenied(t, checker.Check(alice, acl.ObjectDirectory, rootDir, acl.PermissionWrite))
+
+	// 撤销后继承的权限随之失效
+	require.NoError(t, store.Revoke(ctx, aliceID, acl.ObjectDirectory, projectsDir, acl.PermissionWrite))
+	assertDenied(t, checker.Check(alice, acl.ObjectFile, reportFile, acl.PermissionWrite))
+}
+
+func TestChecker_GrantOnSubdirectoryDoesNotWidenParent(t *testing.T) {
+	_, store := newTestStore(t)
+	checker := acl.NewChecker(store)
+
+	require.NoError(t, store.Grant(context.Background(), bobID, acl.ObjectDirectory, alphaDir, acl.PermissionRead))
+	bob := userContext("3", "bob")
+
+	assert.NoError(t, checker.Check(bob, acl.ObjectFile, reportFile, acl.PermissionRead))
+	assertDenied(t, checker.Check(bob, acl.ObjectDirectory, projectsDir, acl.PermissionRead))
+	assertDenied(t, checker.Check(bob, acl.ObjectDirectory, betaDir, acl.PermissionRead))
+}
+
+func TestChecker_PrivilegedAndAnonymousUsers(t *testing.T) {
+	_, store := newTestStore(t)
+	checker := acl.NewChecker(store)
+
+	admin := userContext("9", "root", auth.RoleAdmin)
+	assert.NoError(t, checker.Check(admin, acl.ObjectDirectory, homeDir, acl.PermissionWrite))
+
+	assertDenied(t, checker.Check(context.Background(), acl.ObjectDirectory, homeDir, acl.PermissionRead))
+	assertDenied(t, checker.Check(userContext("not-a-number", "mallory"), acl.ObjectDirectory, homeDir, acl.PermissionRead))
+}
+
+func TestDBStore_GrantIsIdempotent(t *testing.T) {
+	db, store := newTestStore(t)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, store.Grant(ctx, aliceID, acl.ObjectDirectory, homeDir, acl.PermissionRead))
+	}
+	require.NoError(t, store.Grant(ctx, aliceID, acl.ObjectDirectory, homeDir, acl.PermissionExecute))
+
+	var count int
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT COUNT(*) FROM permissions WHERE user_id = ?", aliceID).Scan(&count))
+	assert.Equal(t, 2, count)
+
+	grants, err := store.Grants(ctx, aliceID, acl.ObjectDirectory, homeDir)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []acl.Permission{acl.PermissionRead, acl.PermissionExecute}, grants)
+}
+
+func TestOperation_RequiredPermission(t *testing.T) {
+	assert.Equal(t, acl.PermissionRead, acl.OpRead.RequiredPermission())
+	assert.False(t, acl.OpRead.OnParent())
+	assert.Equal(t, acl.PermissionWrite, acl.OpUpdate.RequiredPermission())
+	assert.False(t, acl.OpUpdate.OnParent())
+	for _, op := range []acl.Operation{acl.OpCreate, acl.OpDelete} {
+		assert.Equal(t, acl.PermissionWrite, op.RequiredPermission())
+		assert.True(t, op.OnParent(), "%s检查父目录", op)
+	}
+}
@@ -0,0 +1,154 @@
+package election_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/election"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startSingleNode 以单节点引导模式启动选举管理器
+func startSingleNode(t *testing.T, cfg *election.ManagerConfig) *election.Manager {
+	t.Helper()
+	mgr, err := election.NewManager(cfg, logging.NewLogger())
+	require.NoError(t, err)
+	require.True(t, mgr.SingleNode())
+	require.NoError(t, mgr.Start())
+	t.Cleanup(func() { mgr.Stop() })
+	return mgr
+}
+
+func TestSingleNode_LeaderImmediately(t *testing.T) {
+	for name, cfg := range map[string]*election.ManagerConfig{
+		"无节点列表":   {NodeID: "1"},
+		"只包含本节点":  {NodeID: "1", PeerList: []string{"1"}},
+		"非数字节点ID": {NodeID: "meta-0"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			start := time.Now()
+			mgr := startSingleNode(t, cfg)
+
+			// 默认选举超时为2秒，单节点不应等待选举超时
+			require.Eventually(t, mgr.IsLeader, time.Second, 10*time.Millisecond)
+			assert.Less(t, time.Since(start), time.Second)
+
+			select {
+			case leader := <-mgr.LeaderChangeChan():
+				assert.Equal(t, string(cfg.NodeID), leader)
+			case <-time.After(time.Second):
+				t.Fatal("未收到领导者变更通知")
+			}
+			assert.Equal(t, string(cfg.NodeID), mgr.GetCurrentLeader())
+		})
+	}
+}
+
+func TestSingleNode_ServesWrites(t *testing.T) {
+	mgr := startSingleNode(t, &election.ManagerConfig{NodeID: "meta-0"})
+	require.Eventually(t, mgr.IsLeader, time.Second, 10*time.Millisecond)
+
+	for _, level := range []raft.ConsistencyLevel{raft.ConsistencyQuorum, raft.ConsistencyAll} {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		index, err := mgr.ProposeWait(ctx, []byte("write-"+string(level)), level)
+		cancel()
+		require.NoError(t, err, level)
+		assert.NotZero(t, index)
+	}
+}
+
+func TestNewManager_MultiNodeRequiresNumericID(t *testing.T) {
+	mgr, err := election.NewManager(&election.ManagerConfig{
+		NodeID:   "meta-0",
+		PeerList: []string{"meta-0", "2", "3"},
+	}, logging.NewLogger())
+	assert.Error(t, err)
+	assert.Nil(t, mgr)
+}
+
+func TestSingleNode_GetPeersReadsConfState(t *testing.T) {
+	for name, cfg := range map[string]*election.ManagerConfig{
+		"数字节点ID":  {NodeID: "7"},
+		"非数字节点ID": {NodeID: "meta-0"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mgr := startSingleNode(t, cfg)
+			// 引导时的成员变更应用后成员配置中才有本节点
+			require.Eventually(t, mgr.IsLeader, time.Second, 10*time.Millisecond)
+			assert.Equal(t, []string{string(cfg.NodeID)}, mgr.GetPeers())
+		})
+	}
+}
+
+func TestSingleNode_AddPeerAppliesConfChange(t *testing.T) {
+	mgr := startSingleNode(t, &election.ManagerConfig{NodeID: "1"})
+	require.Eventually(t, mgr.IsLeader, time.Second, 10*time.Millisecond)
+
+	// 成员变更经Raft提交并应用后才出现在成员配置中
+	require.NoError(t, mgr.AddPeer("2"))
+	require.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"1", "2"}, mgr.GetPeers())
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.ErrorIs(t, mgr.AddPeer("2"), election.ErrPeerExists)
+
+	// 停止后提议失败，返回错误而不是静默成功
+	mgr.Stop()
+	assert.Error(t, mgr.RemovePeer("2"))
+}
+
+func TestAddPeer_UnappliedConfChangeIsNotReportedAsMember(t *testing.T) {
+	mgr := startSingleNode(t, &election.ManagerConfig{NodeID: "1"})
+	require.Eventually(t, mgr.IsLeader, time.Second, 10*time.Millisecond)
+
+	// 节点2从未启动，加入后集群失去多数派，之后的成员变更无法提交
+	require.NoError(t, mgr.AddPeer("2"))
+	err := mgr.AddPeer("3")
+	require.Error(t, err, "未应用的成员变更不应报告成功")
+	assert.NotErrorIs(t, err, election.ErrPeerExists)
+
+	// 重试仍然提议变更，而不是把未应用的节点当作已有成员
+	assert.NotErrorIs(t, mgr.AddPeer("3"), election.ErrPeerExists)
+	assert.Equal(t, []string{"1", "2"}, mgr.GetPeers())
+}
+
+// recordingApplier 记录状态机收到的命令
+type recordingApplier struct {
+	mu   sync.Mutex
+	cmds []raft.Command
+}
+
+func (a *recordingApplier) ApplyCommand(ctx context.Context, cmd raft.Command) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cmds = append(a.cmds, cmd)
+	return nil
+}
+
+func (a *recordingApplier) commands() []raft.Command {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]raft.Command(nil), a.cmds...)
+}
+
+func TestSingleNode_ConfChangesNotAppliedAsCommands(t *testing.T) {
+	mgr, err := election.NewManager(&election.ManagerConfig{NodeID: "1"}, logging.NewLogger())
+	require.NoError(t, err)
+	applier := &recordingApplier{}
+	mgr.SetCommandApplier(applier)
+	require.NoError(t, mgr.Start())
+	t.Cleanup(func() { mgr.Stop() })
+	require.Eventually(t, mgr.IsLeader, time.Second, 10*time.Millisecond)
+
+	// 引导时的成员变更不交给状态机，ProposeCommand返回时命令已被应用
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	cmd := raft.Command{Op: "put", Key: "/a.txt", Value: []byte("v")}
+	_, err = mgr.ProposeCommand(ctx, cmd)
+	require.NoError(t, err)
+	assert.Equal(t, []raft.Command{cmd}, applier.commands())
+}
@@ -0,0 +1,145 @@
+package heartbeat_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	httplib "github.com/22827099/DFS_v1/common/network/http"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/heartbeat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// connCountingServer 统计建立和关闭的TCP连接数以及收到的心跳数
+type connCountingServer struct {
+	addr   string
+	hits   int32
+	mu     sync.Mutex
+	opened int
+	closed int
+}
+
+func (s *connCountingServer) counts() (opened, closed int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.opened, s.closed
+}
+
+// startConnCountingServer 启动统计连接数的心跳接收服务器
+func startConnCountingServer(t testing.TB) *connCountingServer {
+	t.Helper()
+	s := &connCountingServer{}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/heartbeat" {
+			atomic.AddInt32(&s.hits, 1)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		switch state {
+		case http.StateNew:
+			s.opened++
+		case http.StateClosed, http.StateHijacked:
+			s.closed++
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+	s.addr = strings.TrimPrefix(server.URL, "http://")
+	return s
+}
+
+func TestHeartbeat_ReusesConnectionAcrossTicks(t *testing.T) {
+	server := startConnCountingServer(t)
+	m, err := heartbeat.NewManager(fastHeartbeatConfig(map[string]string{"peer": server.addr}), logging.NewLogger())
+	require.NoError(t, err)
+	m.RegisterNode("peer")
+	require.NoError(t, m.Start())
+
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&server.hits) >= 20 },
+		2*time.Second, 5*time.Millisecond)
+	opened, _ := server.counts()
+	// 每个周期新建客户端时连接数随心跳数增长，复用时只在请求偶尔重叠时多建连接
+	assert.LessOrEqual(t, opened, 2, "心跳应复用已有连接")
+
+	// 停止后关闭全部连接
+	require.NoError(t, m.Stop())
+	require.Eventually(t, func() bool {
+		opened, closed := server.counts()
+		return closed == opened
+	}, time.Second, 5*time.Millisecond, "停止后不应残留心跳连接")
+}
+
+func TestHeartbeat_RecreatesClientWhenAddressChanges(t *testing.T) {
+	oldServer := startConnCountingServer(t)
+	newServer := startConnCountingServer(t)
+	res := &switchingResolver{addr: oldServer.addr}
+
+	m, err := heartbeat.NewManager(fastHeartbeatConfig(nil), logging.NewLogger(), heartbeat.WithResolver(res))
+	require.NoError(t, err)
+	m.RegisterNode("peer")
+	require.NoError(t, m.Start())
+	defer m.Stop()
+
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&oldServer.hits) > 0 },
+		time.Second, 5*time.Millisecond)
+
+	res.set(newServer.addr)
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&newServer.hits) > 0 },
+		time.Second, 5*time.Millisecond)
+
+	// 地址变化后旧客户端的空闲连接被关闭
+	require.Eventually(t, func() bool {
+		opened, closed := oldServer.counts()
+		return opened > 0 && closed == opened
+	}, time.Second, 5*time.Millisecond, "旧地址的连接应被关闭")
+}
+
+// benchmarkHeartbeatRequests 以newClient返回的客户端发送b.N次心跳请求，报告建立的连接数
+func benchmarkHeartbeatRequests(b *testing.B, newClient func(baseURL string) *httplib.Client) {
+	server := startConnCountingServer(b)
+	baseURL := "http://" + server.addr
+	body := map[string]string{"sender_id": "self"}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var resp map[string]interface{}
+		if err := newClient(baseURL).PostJSON(context.Background(), "/api/v1/heartbeat", body, &resp); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	opened, _ := server.counts()
+	b.ReportMetric(float64(opened)/float64(b.N), "conns/op")
+}
+
+// BenchmarkHeartbeatClient_PerTick 每次心跳创建独立传输的客户端，每次都要重新建立连接
+func BenchmarkHeartbeatClient_PerTick(b *testing.B) {
+	benchmarkHeartbeatRequests(b, func(baseURL string) *httplib.Client {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		return httplib.NewClient(baseURL, httplib.WithHTTPClient(&http.Client{Transport: transport}))
+	})
+}
+
+// BenchmarkHeartbeatClient_Pooled 复用同一个客户端，连接在请求之间保持
+func BenchmarkHeartbeatClient_Pooled(b *testing.B) {
+	var client *httplib.Client
+	benchmarkHeartbeatRequests(b, func(baseURL string) *httplib.Client {
+		if client == nil {
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			client = httplib.NewClient(baseURL, httplib.WithHTTPClient(&http.Client{Transport: transport}))
+		}
+		return client
+	})
+}
@@ -0,0 +1,128 @@
+package heartbeat_test
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/common/types"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/heartbeat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// feedArrivals 按intervals依次向检测器记录心跳，返回最后一次心跳的到达时间
+func feedArrivals(d *heartbeat.PhiAccrualDetector, start time.Time, intervals []time.Duration) time.Time {
+	at := start
+	for _, interval := range intervals {
+		at = at.Add(interval)
+		d.Heartbeat(at)
+	}
+	return at
+}
+
+// steadyIntervals 生成在interval上下jitter范围内均匀分布的到达间隔
+func steadyIntervals(rng *rand.Rand, count int, interval, jitter time.Duration) []time.Duration {
+	intervals := make([]time.Duration, count)
+	for i := range intervals {
+		intervals[i] = interval - jitter + time.Duration(rng.Int63n(int64(2*jitter)))
+	}
+	return intervals
+}
+
+func newTestDetector(start time.Time) *heartbeat.PhiAccrualDetector {
+	return heartbeat.NewPhiAccrualDetector(metaconfig.DefaultPhiWindowSize, metaconfig.DefaultPhiMinStdDeviation,
+		metaconfig.DefaultHeartbeatInterval, start)
+}
+
+func TestPhiAccrual_JitteryNodeNotDeclaredDead(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	start := time.Unix(0, 0)
+
+	// 稳定节点每秒一次心跳；抖动节点平均约1.85秒一次，间隔在0.2秒到3.5秒之间波动
+	steady := newTestDetector(start)
+	steadyLast := feedArrivals(steady, start, steadyIntervals(rng, 100, time.Second, 10*time.Millisecond))
+	jittery := newTestDetector(start)
+	jitteryLast := feedArrivals(jittery, start, steadyIntervals(rng, 100, 1850*time.Millisecond, 1650*time.Millisecond))
+
+	// 抖动节点经常出现的最长间隔不应使其被怀疑，固定超时(3秒)会把它判定为可疑
+	assert.Less(t, jittery.Phi(jitteryLast.Add(3500*time.Millisecond)), metaconfig.DefaultPhiSuspectThreshold)
+
+	// 同样沉默6秒：稳定节点已被判定为死亡，抖动节点仍未达到可疑阈值
+	silence := 6 * time.Second
+	assert.GreaterOrEqual(t, steady.Phi(steadyLast.Add(silence)), metaconfig.DefaultPhiDeadThreshold)
+	assert.Less(t, jittery.Phi(jitteryLast.Add(silence)), metaconfig.DefaultPhiSuspectThreshold)
+
+	// 抖动节点真正失联足够久后仍会被判定为死亡
+	assert.GreaterOrEqual(t, jittery.Phi(jitteryLast.Add(20*time.Second)), metaconfig.DefaultPhiDeadThreshold)
+}
+
+func TestPhiAccrual_PhiGrowsWithSilence(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	start := time.Unix(0, 0)
+	d := newTestDetector(start)
+	last := feedArrivals(d, start, steadyIntervals(rng, 50, time.Second, 100*time.Millisecond))
+
+	previous := d.Phi(last)
+	for elapsed := 500 * time.Millisecond; elapsed <= 10*time.Second; elapsed += 500 * time.Millisecond {
+		phi := d.Phi(last.Add(elapsed))
+		assert.GreaterOrEqual(t, phi, previous, "沉默%v时phi不应下降", elapsed)
+		previous = phi
+	}
+
+	// 恢复心跳后phi回落
+	d.Heartbeat(last.Add(10 * time.Second))
+	assert.Less(t, d.Phi(last.Add(10*time.Second)), 1.0)
+}
+
+func TestPhiAccrual_BootstrapsFromExpectedInterval(t *testing.T) {
+	start := time.Unix(0, 0)
+	d := newTestDetector(start)
+
+	// 尚未收到心跳时按期望间隔估计，间隔内不怀疑，远超期望间隔时判定为死亡
+	assert.Less(t, d.Phi(start.Add(time.Second)), 1.0)
+	assert.GreaterOrEqual(t, d.Phi(start.Add(10*time.Second)), metaconfig.DefaultPhiDeadThreshold)
+}
+
+func TestPhiAccrual_WindowForgetsOldIntervals(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	start := time.Unix(0, 0)
+	d := heartbeat.NewPhiAccrualDetector(10, 100*time.Millisecond, time.Second, start)
+
+	// 窗口只保留最近10个间隔，早期的大间隔被稳定的间隔替换后不再放宽判定
+	last := feedArrivals(d, start, steadyIntervals(rng, 10, 5*time.Second, time.Second))
+	wide := d.Phi(last.Add(3 * time.Second))
+	last = feedArrivals(d, last, steadyIntervals(rng, 10, time.Second, 10*time.Millisecond))
+	assert.Less(t, wide, 1.0)
+	assert.GreaterOrEqual(t, d.Phi(last.Add(3*time.Second)), metaconfig.DefaultPhiDeadThreshold)
+}
+
+func TestHeartbeat_PhiModeMarksSilentNodeDead(t *testing.T) {
+	m, err := heartbeat.NewManager(&metaconfig.HeartbeatConfig{
+		NodeID:             "self",
+		HeartbeatInterval:  10 * time.Millisecond,
+		CleanupInterval:    time.Minute,
+		FailureDetector:    metaconfig.FailureDetectorPhi,
+		PhiMinStdDeviation: 5 * time.Millisecond,
+	}, logging.NewLogger(), heartbeat.WithReaperDisabled())
+	require.NoError(t, err)
+	m.RegisterNode(testNodeID)
+	require.NoError(t, m.Start())
+	defer m.Stop()
+
+	// fixed模式的超时对phi模式不起作用，节点仍按怀疑度依次变为可疑和死亡
+	waitForState(t, m, testNodeID, types.NodeStatusSuspect)
+	waitForState(t, m, testNodeID, types.NodeStatusDead)
+	assert.GreaterOrEqual(t, m.GetNodePhi(testNodeID), metaconfig.DefaultPhiDeadThreshold)
+
+	m.RecordHeartbeat(testNodeID)
+	assert.Equal(t, types.NodeStatusHealthy, m.GetNodeState(testNodeID))
+	assert.Zero(t, m.GetNodePhi("unknown"))
+}
+
+func TestHeartbeat_RejectsUnknownFailureDetector(t *testing.T) {
+	_, err := heartbeat.NewManager(&metaconfig.HeartbeatConfig{FailureDetector: "timeout"}, logging.NewLogger())
+	assert.Error(t, err)
+}
@@ -0,0 +1,108 @@
+package heartbeat_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/common/types"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/heartbeat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 使用本地回环地址作为节点ID，后台发送的心跳会被快速拒绝
+const testNodeID = "127.0.0.1"
+
+// newFastManager 创建超时很短的心跳管理器
+func newFastManager(t *testing.T) *heartbeat.Manager {
+	t.Helper()
+	m, err := heartbeat.NewManager(&metaconfig.HeartbeatConfig{
+		NodeID:            "self",
+		HeartbeatInterval: 10 * time.Millisecond,
+		SuspectTimeout:    30 * time.Millisecond,
+		DeadTimeout:       time.Minute,
+		CleanupInterval:   time.Minute,
+	}, logging.NewLogger())
+	require.NoError(t, err)
+	return m
+}
+
+// waitForState 等待节点进入指定状态
+func waitForState(t *testing.T, m *heartbeat.Manager, nodeID string, state types.NodeStatus) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		return m.GetNodeState(nodeID) == state
+	}, time.Second, 5*time.Millisecond, "节点应进入%s状态", state)
+}
+
+func TestRegisterNode_Idempotent(t *testing.T) {
+	m := newFastManager(t)
+
+	assert.True(t, m.RegisterNode(testNodeID), "首次注册应返回true")
+	assert.False(t, m.RegisterNode(testNodeID), "重复注册应返回false")
+	assert.Equal(t, types.NodeStatusHealthy, m.GetNodeState(testNodeID))
+	assert.Len(t, m.GetAllNodeStates(), 1, "重复注册不应产生重复条目")
+}
+
+func TestRegisterNode_PreservesSuspectState(t *testing.T) {
+	m := newFastManager(t)
+	require.NoError(t, m.Start())
+	defer m.Stop()
+
+	m.RegisterNode(testNodeID)
+	waitForState(t, m, testNodeID, types.NodeStatusSuspect)
+	failCount := m.GetNodeFailCount(testNodeID)
+	require.Greater(t, failCount, 0)
+
+	// 重新注册不能在没有真实心跳的情况下将节点恢复为健康
+	assert.False(t, m.RegisterNode(testNodeID))
+	assert.Equal(t, types.NodeStatusSuspect, m.GetNodeState(testNodeID))
+	assert.Equal(t, failCount, m.GetNodeFailCount(testNodeID))
+
+	// 收到真实心跳后恢复健康
+	m.RecordHeartbeat(testNodeID)
+	assert.Equal(t, types.NodeStatusHealthy, m.GetNodeState(testNodeID))
+	assert.Equal(t, 0, m.GetNodeFailCount(testNodeID))
+}
+
+func TestForceRegisterNode_ResetsState(t *testing.T) {
+	m := newFastManager(t)
+	require.NoError(t, m.Start())
+	defer m.Stop()
+
+	m.RegisterNode(testNodeID)
+	waitForState(t, m, testNodeID, types.NodeStatusSuspect)
+
+	m.ForceRegisterNode(testNodeID)
+	assert.Equal(t, types.NodeStatusHealthy, m.GetNodeState(testNodeID))
+	assert.Equal(t, 0, m.GetNodeFailCount(testNodeID))
+}
+
+func TestRecordHeartbeat_AfterRegisterDoesNotDuplicate(t *testing.T) {
+	m := newFastManager(t)
+
+	m.RecordHeartbeat(testNodeID)
+	assert.False(t, m.RegisterNode(testNodeID), "心跳自动注册后再注册应视为已存在")
+	assert.Len(t, m.GetAllNodeStates(), 1)
+}
+
+func TestGetNodeLastHeartbeat_TracksRealHeartbeats(t *testing.T) {
+	m := newFastManager(t)
+	require.NoError(t, m.Start())
+	defer m.Stop()
+
+	assert.True(t, m.GetNodeLastHeartbeat(testNodeID).IsZero(), "未注册节点没有心跳时间")
+
+	m.RegisterNode(testNodeID)
+	registered := m.GetNodeLastHeartbeat(testNodeID)
+	require.False(t, registered.IsZero())
+
+	// 变为可疑不会更新最后心跳时间
+	waitForState(t, m, testNodeID, types.NodeStatusSuspect)
+	assert.Equal(t, registered, m.GetNodeLastHeartbeat(testNodeID))
+
+	m.RecordHeartbeat(testNodeID)
+	assert.True(t, m.GetNodeLastHeartbeat(testNodeID).After(registered))
+}
@@ -0,0 +1,137 @@
+package heartbeat_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/heartbeat"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/resolver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// switchingResolver 返回可在测试中修改的地址
+type switchingResolver struct {
+	mu   sync.Mutex
+	addr string
+}
+
+func (r *switchingResolver) set(addr string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.addr = addr
+}
+
+func (r *switchingResolver) Resolve(nodeID string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.addr, nil
+}
+
+// startHeartbeatReceiver 启动统计心跳请求数的服务器，返回其host:port
+func startHeartbeatReceiver(t *testing.T, count *int32) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/heartbeat" {
+			atomic.AddInt32(count, 1)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+func TestHeartbeat_SendsToLatestResolvedAddress(t *testing.T) {
+	var oldHits, newHits int32
+	oldAddr := startHeartbeatReceiver(t, &oldHits)
+	newAddr := startHeartbeatReceiver(t, &newHits)
+
+	res := &switchingResolver{addr: oldAddr}
+	m, err := heartbeat.NewManager(&metaconfig.HeartbeatConfig{
+		NodeID:            "self",
+		HeartbeatInterval: 10 * time.Millisecond,
+		SuspectTimeout:    time.Minute,
+		DeadTimeout:       time.Minute,
+		CleanupInterval:   time.Minute,
+	}, logging.NewLogger(), heartbeat.WithResolver(res))
+	require.NoError(t, err)
+	m.RegisterNode("peer")
+	require.NoError(t, m.Start())
+	defer m.Stop()
+
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&oldHits) > 0 },
+		time.Second, 5*time.Millisecond, "心跳应发送到解析出的地址")
+
+	// 节点地址变化后，之后的心跳应发送到新地址
+	res.set(newAddr)
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&newHits) > 0 },
+		time.Second, 5*time.Millisecond, "地址变化后心跳应发送到新地址")
+
+	// 等待切换前已发出的请求完成
+	time.Sleep(50 * time.Millisecond)
+	before := atomic.LoadInt32(&oldHits)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, before, atomic.LoadInt32(&oldHits), "旧地址不应再收到心跳")
+	assert.Greater(t, atomic.LoadInt32(&newHits), int32(1))
+}
+
+// fastHeartbeatConfig 心跳间隔很短、不会判定节点死亡的配置
+func fastHeartbeatConfig(peerMap map[string]string) *metaconfig.HeartbeatConfig {
+	return &metaconfig.HeartbeatConfig{
+		NodeID:            "self",
+		HeartbeatInterval: 10 * time.Millisecond,
+		SuspectTimeout:    time.Minute,
+		DeadTimeout:       time.Minute,
+		CleanupInterval:   time.Minute,
+		PeerMap:           peerMap,
+	}
+}
+
+// assertEachReceivesHeartbeats 启动m后每个节点的服务器都应收到心跳
+func assertEachReceivesHeartbeats(t *testing.T, m *heartbeat.Manager, hits map[string]*int32) {
+	t.Helper()
+	for nodeID := range hits {
+		m.RegisterNode(nodeID)
+	}
+	require.NoError(t, m.Start())
+	t.Cleanup(func() { m.Stop() })
+
+	for nodeID, count := range hits {
+		require.Eventually(t, func() bool { return atomic.LoadInt32(count) > 0 },
+			time.Second, 5*time.Millisecond, "节点%s的地址应收到心跳", nodeID)
+	}
+}
+
+func TestHeartbeat_ResolverDistinguishesPortsOnSameHost(t *testing.T) {
+	var hitsA, hitsB int32
+	addrs := resolver.Static{
+		"node-a": startHeartbeatReceiver(t, &hitsA),
+		"node-b": startHeartbeatReceiver(t, &hitsB),
+	}
+	require.NotEqual(t, addrs["node-a"], addrs["node-b"])
+
+	m, err := heartbeat.NewManager(fastHeartbeatConfig(nil), logging.NewLogger(), heartbeat.WithResolver(addrs))
+	require.NoError(t, err)
+	assertEachReceivesHeartbeats(t, m, map[string]*int32{"node-a": &hitsA, "node-b": &hitsB})
+}
+
+func TestHeartbeat_DefaultsToPeerMap(t *testing.T) {
+	var hitsA, hitsB int32
+	peerMap := map[string]string{
+		"node-a": startHeartbeatReceiver(t, &hitsA),
+		"node-b": "http://" + startHeartbeatReceiver(t, &hitsB),
+	}
+
+	// 未指定解析器时使用配置中的PeerMap，地址可以带协议前缀
+	m, err := heartbeat.NewManager(fastHeartbeatConfig(peerMap), logging.NewLogger())
+	require.NoError(t, err)
+	assertEachReceivesHeartbeats(t, m, map[string]*int32{"node-a": &hitsA, "node-b": &hitsB})
+}
@@ -0,0 +1,47 @@
+package manager_test
+
+import (
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newWeightsTestManager(t *testing.T, leader bool) cluster.Manager {
+	t.Helper()
+	mgr, err := cluster.NewManager(testClusterConfig(true), logging.NewLogger(),
+		cluster.WithElectionManager(newFakeElection(leader, "1")))
+	require.NoError(t, err)
+	return mgr
+}
+
+func TestBalanceWeights_LeaderReadsAndUpdates(t *testing.T) {
+	mgr := newWeightsTestManager(t, true)
+
+	weights, err := mgr.GetBalanceWeights()
+	require.NoError(t, err)
+	assert.Equal(t, rebalance.DefaultScoreWeights(), weights)
+
+	applied, err := mgr.UpdateBalanceWeights(rebalance.ScoreWeights{CPU: 3, Shard: 1})
+	require.NoError(t, err)
+	assert.Equal(t, rebalance.ScoreWeights{CPU: 0.75, Shard: 0.25}, applied)
+
+	weights, err = mgr.GetBalanceWeights()
+	require.NoError(t, err)
+	assert.Equal(t, applied, weights)
+
+	_, err = mgr.UpdateBalanceWeights(rebalance.ScoreWeights{})
+	assert.ErrorIs(t, err, rebalance.ErrInvalidWeights)
+}
+
+func TestBalanceWeights_FollowerRejected(t *testing.T) {
+	mgr := newWeightsTestManager(t, false)
+
+	_, err := mgr.GetBalanceWeights()
+	assert.ErrorIs(t, err, cluster.ErrNotLeader)
+	_, err = mgr.UpdateBalanceWeights(rebalance.ScoreWeights{CPU: 1})
+	assert.ErrorIs(t, err, cluster.ErrNotLeader)
+}
@@ -0,0 +1,55 @@
+package manager_test
+
+import (
+	"errors"
+	"testing"
+
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterManager_MaxClusterSize(t *testing.T) {
+	election := newFakeElection(true, "1")
+	mgr := newDiscoveryManager(t, metaconfig.ClusterConfig{
+		NodeID:         "1",
+		Peers:          []string{"1"},
+		MaxClusterSize: 3,
+	}, election)
+
+	// 达到上限前正常加入
+	for _, nodeID := range []string{"2", "3"} {
+		_, err := mgr.JoinNode(cluster.Member{NodeID: nodeID, Address: "http://10.0.0." + nodeID + ":8080"})
+		require.NoError(t, err, "节点%s应能加入", nodeID)
+		assert.True(t, election.hasPeer(nodeID))
+	}
+	assert.Len(t, mgr.Membership().Members, 3)
+
+	// 超过上限被拒绝，且不会提议成员变更
+	_, err := mgr.JoinNode(cluster.Member{NodeID: "4", Address: "http://10.0.0.4:8080"})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, cluster.ErrClusterFull))
+	assert.Contains(t, err.Error(), "上限为3")
+	assert.False(t, election.hasPeer("4"))
+	assert.True(t, errors.Is(mgr.AddPeer("4"), cluster.ErrClusterFull))
+
+	// 已有成员重复加入不受上限影响
+	_, err = mgr.JoinNode(cluster.Member{NodeID: "3", Address: "http://10.0.0.3:8080"})
+	assert.NoError(t, err)
+
+	// 移除成员后可以再加入新节点
+	require.NoError(t, mgr.RemovePeer("2"))
+	_, err = mgr.JoinNode(cluster.Member{NodeID: "4", Address: "http://10.0.0.4:8080"})
+	assert.NoError(t, err)
+	assert.True(t, election.hasPeer("4"))
+}
+
+func TestClusterManager_UnlimitedClusterSize(t *testing.T) {
+	mgr := newDiscoveryManager(t, metaconfig.ClusterConfig{NodeID: "1", Peers: []string{"1"}}, newFakeElection(true, "1"))
+
+	for _, nodeID := range []string{"2", "3", "4", "5", "6", "7", "8", "9", "10", "11"} {
+		require.NoError(t, mgr.AddPeer(nodeID))
+	}
+	assert.Len(t, mgr.Membership().Members, 11)
+}
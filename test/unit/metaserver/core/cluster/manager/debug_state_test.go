@@ -0,0 +1,95 @@
+package manager_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/consensus/raft"
+	"github.com/22827099/DFS_v1/common/logging"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// raftReportingElection 能报告Raft状态的选举管理器，block不为nil时RaftStatus一直阻塞到其关闭
+type raftReportingElection struct {
+	*fakeElection
+	block chan struct{}
+}
+
+func (e *raftReportingElection) RaftStatus() raft.Status {
+	if e.block != nil {
+		<-e.block
+	}
+	return raft.Status{ID: 1, Term: 3, RaftState: "StateLeader", IsLeader: true}
+}
+
+// startDebugManager 启动使用election的集群管理器，测试结束时停止
+func startDebugManager(t *testing.T, election cluster.ElectionManager) cluster.Manager {
+	t.Helper()
+	rebalancer, err := rebalance.NewManager(&metaconfig.LoadBalancerConfig{EvaluationInterval: time.Hour}, logging.NewLogger())
+	require.NoError(t, err)
+
+	mgr, err := cluster.NewManager(testClusterConfig(true), logging.NewLogger(),
+		cluster.WithElectionManager(election),
+		cluster.WithRebalanceManager(rebalancer))
+	require.NoError(t, err)
+	require.NoError(t, mgr.Start())
+	t.Cleanup(func() { mgr.Stop(context.Background()) })
+	return mgr
+}
+
+func TestDebugState_Sections(t *testing.T) {
+	mgr := startDebugManager(t, &raftReportingElection{fakeElection: newFakeElection(true, "1")})
+
+	state := mgr.DebugState(context.Background())
+
+	locks, ok := state["locks"].(map[string]bool)
+	require.True(t, ok, "locks应为锁名到是否被占用的映射")
+	for _, name := range []string{"membership_mu", "state_mu", "cache_mu", "events_mu"} {
+		held, exists := locks[name]
+		assert.True(t, exists, "缺少锁%s", name)
+		assert.False(t, held, "空闲时%s不应被占用", name)
+	}
+
+	clusterState, ok := state["state"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Contains(t, clusterState, "nodes")
+	assert.Contains(t, clusterState, "members")
+	assert.Equal(t, true, state["event_loop_running"])
+
+	status, ok := state["raft"].(raft.Status)
+	require.True(t, ok, "选举管理器能报告Raft状态时应包含raft部分")
+	assert.Equal(t, uint64(3), status.Term)
+
+	assert.Contains(t, state, "migrations")
+}
+
+func TestDebugState_DoesNotBlockOnStuckRaft(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	mgr := startDebugManager(t, &raftReportingElection{fakeElection: newFakeElection(true, "1"), block: block})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	state := mgr.DebugState(ctx)
+	assert.Less(t, time.Since(start), time.Second, "Raft状态阻塞时诊断不应一直等待")
+
+	raftState, ok := state["raft"].(string)
+	require.True(t, ok)
+	assert.Contains(t, raftState, "超时")
+	assert.Contains(t, state, "locks", "其余部分照常返回")
+}
+
+func TestDebugState_WithoutRaftReporter(t *testing.T) {
+	mgr := startEventsManager(t, newFakeElection(true, "1"), 0)
+
+	state := mgr.DebugState(context.Background())
+	assert.NotContains(t, state, "raft")
+	assert.Contains(t, state, "locks")
+}
@@ -0,0 +1,83 @@
+package manager_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingRebalancer 启动总是失败的负载均衡管理器
+type failingRebalancer struct {
+	*rebalance.Manager
+}
+
+func (f *failingRebalancer) Start() error {
+	return errors.New("模拟启动失败")
+}
+
+// newFailingRebalancer 创建启动失败的负载均衡管理器
+func newFailingRebalancer(t *testing.T) *failingRebalancer {
+	t.Helper()
+	mgr, err := rebalance.NewManager(&metaconfig.LoadBalancerConfig{EvaluationInterval: time.Hour}, logging.NewLogger())
+	require.NoError(t, err)
+	return &failingRebalancer{Manager: mgr}
+}
+
+// testClusterConfig 单节点集群配置
+func testClusterConfig(allowDegraded bool) metaconfig.ClusterConfig {
+	return metaconfig.ClusterConfig{
+		NodeID:                      "1",
+		Peers:                       []string{"1"},
+		ElectionTimeout:             time.Second,
+		HeartbeatTimeout:            100 * time.Millisecond,
+		HeartbeatInterval:           time.Second,
+		RebalanceEvaluationInterval: time.Hour,
+		AllowDegradedRebalance:      allowDegraded,
+	}
+}
+
+func TestClusterManager_StartsDegradedWhenRebalanceFails(t *testing.T) {
+	mgr, err := cluster.NewManager(testClusterConfig(true), logging.NewLogger(),
+		cluster.WithRebalanceManager(newFailingRebalancer(t)))
+	require.NoError(t, err)
+
+	require.NoError(t, mgr.Start(), "负载均衡启动失败不应导致集群管理器启动失败")
+	defer mgr.Stop(context.Background())
+
+	status := mgr.GetRebalanceStatus()
+	assert.Equal(t, false, status["enabled"])
+	assert.Equal(t, true, status["degraded"])
+	assert.Contains(t, status["error"], "模拟启动失败")
+
+	// 降级模式下触发再平衡不会产生任何效果
+	mgr.TriggerRebalance()
+	assert.Equal(t, true, mgr.GetRebalanceStatus()["degraded"])
+}
+
+func TestClusterManager_RebalanceFailureFatalWhenNotAllowed(t *testing.T) {
+	mgr, err := cluster.NewManager(testClusterConfig(false), logging.NewLogger(),
+		cluster.WithRebalanceManager(newFailingRebalancer(t)))
+	require.NoError(t, err)
+
+	assert.Error(t, mgr.Start(), "未允许降级时负载均衡启动失败应返回错误")
+}
+
+func TestClusterManager_RebalanceStatusNotDegraded(t *testing.T) {
+	mgr, err := cluster.NewManager(testClusterConfig(true), logging.NewLogger())
+	require.NoError(t, err)
+
+	require.NoError(t, mgr.Start())
+	defer mgr.Stop(context.Background())
+
+	status := mgr.GetRebalanceStatus()
+	assert.Equal(t, true, status["enabled"])
+	assert.Equal(t, false, status["degraded"])
+}
@@ -0,0 +1,133 @@
+package manager_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// membershipHandler 以元数据服务API的响应格式暴露成员视图和加入接口
+func membershipHandler(mgr *cluster.Manager) http.Handler {
+	respond := func(w http.ResponseWriter, code int, data interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "success", "data": data})
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(cluster.MembershipPath, func(w http.ResponseWriter, r *http.Request) {
+		respond(w, http.StatusOK, (*mgr).Membership())
+	})
+	mux.HandleFunc(cluster.JoinPath, func(w http.ResponseWriter, r *http.Request) {
+		var req cluster.JoinRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		view, err := (*mgr).JoinNode(cluster.Member{NodeID: req.NodeID, Address: req.Address})
+		if errors.Is(err, cluster.ErrNotLeader) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		respond(w, http.StatusOK, view)
+	})
+	return mux
+}
+
+// newDiscoveryManager 创建使用假选举管理器的集群管理器
+func newDiscoveryManager(t *testing.T, cfg metaconfig.ClusterConfig, election *fakeElection) cluster.Manager {
+	t.Helper()
+	rebalancer, err := rebalance.NewManager(&metaconfig.LoadBalancerConfig{EvaluationInterval: time.Hour}, logging.NewLogger())
+	require.NoError(t, err)
+
+	cfg.ElectionTimeout = time.Second
+	cfg.HeartbeatInterval = time.Second
+	cfg.RebalanceEvaluationInterval = time.Hour
+	mgr, err := cluster.NewManager(cfg, logging.NewLogger(),
+		cluster.WithElectionManager(election),
+		cluster.WithRebalanceManager(rebalancer))
+	require.NoError(t, err)
+	return mgr
+}
+
+func TestClusterManager_JoinsViaSeed(t *testing.T) {
+	var leader, follower cluster.Manager
+	leaderSrv := httptest.NewServer(membershipHandler(&leader))
+	defer leaderSrv.Close()
+	followerSrv := httptest.NewServer(membershipHandler(&follower))
+	defer followerSrv.Close()
+
+	peers := []string{"1", "2"}
+	addresses := []string{leaderSrv.URL, followerSrv.URL}
+
+	leaderElection := newFakeElection(true, "1", "2")
+	leaderElection.leaderID = "1"
+	leader = newDiscoveryManager(t, metaconfig.ClusterConfig{
+		NodeID: "1", NodeAddress: leaderSrv.URL, Peers: peers, PeerAddresses: addresses,
+	}, leaderElection)
+
+	followerElection := newFakeElection(false, "1", "2")
+	followerElection.leaderID = "1"
+	follower = newDiscoveryManager(t, metaconfig.ClusterConfig{
+		NodeID: "2", NodeAddress: followerSrv.URL, Peers: peers, PeerAddresses: addresses,
+	}, followerElection)
+
+	// 新节点只配置了一个非领导者的种子节点
+	newcomerElection := newFakeElection(false)
+	newcomerElection.leaderID = "1"
+	newcomer := newDiscoveryManager(t, metaconfig.ClusterConfig{
+		NodeID:      "3",
+		NodeAddress: "http://10.0.0.3:8080",
+		SeedPeers:   []string{followerSrv.URL},
+	}, newcomerElection)
+	require.NoError(t, newcomer.Start())
+	defer newcomer.Stop(context.Background())
+
+	// 新节点发现了完整的成员列表
+	view := newcomer.Membership()
+	assert.Equal(t, "1", view.LeaderID)
+	require.Len(t, view.Members, 3)
+	assert.Equal(t, leaderSrv.URL, view.Address("1"))
+	assert.Equal(t, followerSrv.URL, view.Address("2"))
+	assert.Equal(t, "http://10.0.0.3:8080", view.Address("3"))
+	assert.Equal(t, 2, newcomer.GetNodeCount(), "其他成员应注册到心跳监控")
+
+	// 领导者接受了加入请求并提议成员变更
+	assert.True(t, leaderElection.hasPeer("3"))
+	assert.Equal(t, "http://10.0.0.3:8080", leader.Membership().Address("3"))
+	assert.False(t, followerElection.hasPeer("3"), "非领导者不应处理成员变更")
+
+	// 重复加入是幂等的
+	again, err := leader.JoinNode(cluster.Member{NodeID: "3", Address: "http://10.0.0.3:8080"})
+	require.NoError(t, err)
+	assert.Len(t, again.Members, 3)
+	assert.Equal(t, "http://10.0.0.3:8080", again.Address("3"))
+}
+
+func TestClusterManager_StartFailsWithoutReachableSeed(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	mgr := newDiscoveryManager(t, metaconfig.ClusterConfig{
+		NodeID:           "3",
+		NodeAddress:      "http://10.0.0.3:8080",
+		SeedPeers:        []string{srv.URL},
+		DiscoveryTimeout: time.Second,
+	}, newFakeElection(false))
+	assert.Error(t, mgr.Start(), "无法加入集群时不应独自启动")
+}
@@ -0,0 +1,51 @@
+package manager_test
+
+import (
+	"errors"
+	"testing"
+
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterManager_RejectsDuplicateNodeID(t *testing.T) {
+	election := newFakeElection(true, "1")
+	mgr := newDiscoveryManager(t, metaconfig.ClusterConfig{
+		NodeID:      "1",
+		NodeAddress: "http://10.0.0.1:8080",
+		Peers:       []string{"1"},
+	}, election)
+
+	_, err := mgr.JoinNode(cluster.Member{NodeID: "2", Address: "http://10.0.0.2:8080"})
+	require.NoError(t, err)
+	before := mgr.Membership()
+
+	// 另一个地址使用同一ID加入被拒绝，成员视图不变
+	view, err := mgr.JoinNode(cluster.Member{NodeID: "2", Address: "http://10.0.0.9:8080"})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, cluster.ErrDuplicateNodeID))
+	assert.Contains(t, err.Error(), "http://10.0.0.2:8080")
+	assert.Equal(t, before.Members, view.Members)
+	assert.Equal(t, "http://10.0.0.2:8080", mgr.Membership().Address("2"))
+	assert.True(t, election.hasPeer("2"))
+	assert.Equal(t, 1, mgr.GetNodeCount())
+
+	// 使用本节点的ID加入同样被拒绝
+	_, err = mgr.JoinNode(cluster.Member{NodeID: "1", Address: "http://10.0.0.9:8080"})
+	assert.True(t, errors.Is(err, cluster.ErrDuplicateNodeID))
+	assert.Equal(t, "http://10.0.0.1:8080", mgr.Membership().Address("1"))
+
+	// 同一地址重新加入（如节点重启）是幂等的
+	view, err = mgr.JoinNode(cluster.Member{NodeID: "2", Address: "http://10.0.0.2:8080/"})
+	require.NoError(t, err)
+	assert.Len(t, view.Members, 2)
+
+	// 没有地址的加入请求无法做重复检查，被拒绝
+	_, err = mgr.JoinNode(cluster.Member{NodeID: "2"})
+	assert.True(t, errors.Is(err, cluster.ErrMissingAddress))
+	_, err = mgr.JoinNode(cluster.Member{NodeID: "3"})
+	assert.True(t, errors.Is(err, cluster.ErrMissingAddress))
+	assert.False(t, election.hasPeer("3"))
+}
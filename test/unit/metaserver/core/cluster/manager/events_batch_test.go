@@ -0,0 +1,151 @@
+package manager_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBatchingManager 创建按window合并事件、单个batch最多size条事件的集群管理器
+func newBatchingManager(t *testing.T, election *fakeElection, window time.Duration, size int) cluster.Manager {
+	t.Helper()
+	cfg := testClusterConfig(true)
+	cfg.EventHistorySize = -1
+	cfg.EventBatchWindow = window
+	cfg.EventBatchSize = size
+
+	rebalancer, err := rebalance.NewManager(&metaconfig.LoadBalancerConfig{EvaluationInterval: time.Hour}, logging.NewLogger())
+	require.NoError(t, err)
+
+	mgr, err := cluster.NewManager(cfg, logging.NewLogger(),
+		cluster.WithElectionManager(election),
+		cluster.WithRebalanceManager(rebalancer))
+	require.NoError(t, err)
+	return mgr
+}
+
+// startBatchingManager 启动合并事件的集群管理器，测试结束时停止
+func startBatchingManager(t *testing.T, election *fakeElection, window time.Duration, size int) cluster.Manager {
+	t.Helper()
+	mgr := newBatchingManager(t, election, window, size)
+	require.NoError(t, mgr.Start())
+	t.Cleanup(func() { mgr.Stop(context.Background()) })
+	return mgr
+}
+
+// eventLeaders 返回事件中的领导者变更，batch事件按顺序展开
+func eventLeaders(t *testing.T, event cluster.ClusterEvent) []string {
+	t.Helper()
+	if event.Type == cluster.EventBatch {
+		batch, ok := event.Data.([]cluster.ClusterEvent)
+		require.True(t, ok, "batch事件的Data应为[]ClusterEvent")
+		var leaders []string
+		for _, inner := range batch {
+			leaders = append(leaders, eventLeaders(t, inner)...)
+		}
+		return leaders
+	}
+	if event.Type == cluster.EventLeaderChange {
+		return []string{event.NodeID}
+	}
+	return nil
+}
+
+// collectUntilLeader 读取事件直到收到leader的领导者变更，返回读到的事件
+func collectUntilLeader(t *testing.T, events <-chan cluster.ClusterEvent, leader string) []cluster.ClusterEvent {
+	t.Helper()
+	var received []cluster.ClusterEvent
+	for {
+		event := nextEvent(t, events)
+		received = append(received, event)
+		leaders := eventLeaders(t, event)
+		if len(leaders) > 0 && leaders[len(leaders)-1] == leader {
+			return received
+		}
+	}
+}
+
+func TestSubscribeEvents_BatchingCoalescesRapidEvents(t *testing.T) {
+	election := newFakeElection(true, "1")
+	mgr := startBatchingManager(t, election, 200*time.Millisecond, 0)
+
+	events, cancel := mgr.SubscribeEvents(0)
+	defer cancel()
+
+	for _, leader := range []string{"a", "b", "c"} {
+		election.leaderCh <- leader
+	}
+
+	received := collectUntilLeader(t, events, "c")
+	last := received[len(received)-1]
+	require.Equal(t, cluster.EventBatch, last.Type, "窗口内的多个事件应合并为一个batch事件")
+	assert.Equal(t, []string{"a", "b", "c"}, eventLeaders(t, last))
+	assert.False(t, last.Timestamp.IsZero())
+}
+
+func TestSubscribeEvents_BatchSizeFlushesImmediately(t *testing.T) {
+	election := newFakeElection(true, "1")
+	mgr := startBatchingManager(t, election, time.Hour, 2)
+
+	events, cancel := mgr.SubscribeEvents(0)
+	defer cancel()
+
+	// 窗口远长于测试超时，只有达到batch大小才会分发
+	election.leaderCh <- "a"
+	election.leaderCh <- "b"
+
+	received := collectUntilLeader(t, events, "b")
+	last := received[len(received)-1]
+	require.Equal(t, cluster.EventBatch, last.Type)
+	leaders := eventLeaders(t, last)
+	assert.Len(t, leaders, 2)
+	assert.Equal(t, "b", leaders[1])
+}
+
+func TestSubscribeEvents_BatchingDisabledDeliversIndividually(t *testing.T) {
+	election := newFakeElection(true, "1")
+	mgr := startBatchingManager(t, election, 0, 0)
+
+	events, cancel := mgr.SubscribeEvents(0)
+	defer cancel()
+
+	for _, leader := range []string{"a", "b", "c"} {
+		election.leaderCh <- leader
+	}
+
+	var leaders []string
+	for _, event := range collectUntilLeader(t, events, "c") {
+		require.NotEqual(t, cluster.EventBatch, event.Type, "未启用合并时不应收到batch事件")
+		leaders = append(leaders, eventLeaders(t, event)...)
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, leaders[len(leaders)-3:])
+}
+
+func TestSubscribeEvents_StopFlushesPendingBatch(t *testing.T) {
+	election := newFakeElection(true, "1")
+	mgr := newBatchingManager(t, election, time.Hour, 0)
+	require.NoError(t, mgr.Start())
+
+	events, cancel := mgr.SubscribeEvents(0)
+	defer cancel()
+
+	election.leaderCh <- "a"
+	election.leaderCh <- "b"
+	// 再发送一次保证"b"已被事件循环处理并暂存
+	election.leaderCh <- "c"
+
+	require.NoError(t, mgr.Stop(context.Background()))
+
+	var leaders []string
+	for event := range events {
+		leaders = append(leaders, eventLeaders(t, event)...)
+	}
+	assert.Contains(t, leaders, "b", "停止时暂存的事件应分发后再关闭通道")
+}
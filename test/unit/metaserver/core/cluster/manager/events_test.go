@@ -0,0 +1,179 @@
+package manager_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/common/types"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newEventsManager 创建保留historySize条历史事件的集群管理器
+func newEventsManager(t *testing.T, election *fakeElection, historySize int) cluster.Manager {
+	t.Helper()
+	cfg := testClusterConfig(true)
+	cfg.EventHistorySize = historySize
+
+	rebalancer, err := rebalance.NewManager(&metaconfig.LoadBalancerConfig{EvaluationInterval: time.Hour}, logging.NewLogger())
+	require.NoError(t, err)
+
+	mgr, err := cluster.NewManager(cfg, logging.NewLogger(),
+		cluster.WithElectionManager(election),
+		cluster.WithRebalanceManager(rebalancer))
+	require.NoError(t, err)
+	return mgr
+}
+
+// startEventsManager 启动集群管理器，测试结束时停止
+func startEventsManager(t *testing.T, election *fakeElection, historySize int) cluster.Manager {
+	t.Helper()
+	mgr := newEventsManager(t, election, historySize)
+	require.NoError(t, mgr.Start())
+	t.Cleanup(func() { mgr.Stop(context.Background()) })
+	return mgr
+}
+
+// nextEvent 读取下一个事件，超时则测试失败
+func nextEvent(t *testing.T, ch <-chan cluster.ClusterEvent) cluster.ClusterEvent {
+	t.Helper()
+	select {
+	case event, ok := <-ch:
+		require.True(t, ok, "订阅通道已关闭")
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("等待集群事件超时")
+		return cluster.ClusterEvent{}
+	}
+}
+
+// bufferedLeaders 不阻塞地读出通道中已有的领导者变更事件
+func bufferedLeaders(ch <-chan cluster.ClusterEvent) []string {
+	var leaders []string
+	for {
+		select {
+		case event := <-ch:
+			if event.Type == cluster.EventLeaderChange {
+				leaders = append(leaders, event.NodeID)
+			}
+		default:
+			return leaders
+		}
+	}
+}
+
+func TestSubscribeEvents_LateSubscriberReceivesHistoryThenLive(t *testing.T) {
+	election := newFakeElection(true, "1")
+	mgr := startEventsManager(t, election, 0)
+
+	// 订阅前发生的领导者变更
+	for _, leader := range []string{"a", "b", "c"} {
+		election.leaderCh <- leader
+	}
+
+	events, cancel := mgr.SubscribeEvents(0)
+	defer cancel()
+
+	// 先按顺序回放历史事件
+	for _, want := range []string{"a", "b", "c"} {
+		event := nextEvent(t, events)
+		assert.Equal(t, cluster.EventLeaderChange, event.Type)
+		assert.Equal(t, want, event.NodeID)
+		assert.False(t, event.Timestamp.IsZero())
+	}
+
+	// 随后收到实时事件
+	election.leaderCh <- "d"
+	event := nextEvent(t, events)
+	assert.Equal(t, cluster.EventLeaderChange, event.Type)
+	assert.Equal(t, "d", event.NodeID)
+}
+
+func TestSubscribeEvents_HistoryIsBounded(t *testing.T) {
+	election := newFakeElection(true, "1")
+	mgr := startEventsManager(t, election, 2)
+
+	for _, leader := range []string{"a", "b", "c", "d"} {
+		election.leaderCh <- leader
+	}
+
+	// 只保留最近两条事件，且按发生顺序回放
+	require.Eventually(t, func() bool {
+		events, cancel := mgr.SubscribeEvents(0)
+		defer cancel()
+		leaders := bufferedLeaders(events)
+		return len(leaders) == 2 && leaders[0] == "c" && leaders[1] == "d"
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestSubscribeEvents_NegativeHistoryDisablesReplay(t *testing.T) {
+	election := newFakeElection(true, "1")
+	mgr := startEventsManager(t, election, -1)
+
+	// 下一次发送被事件循环接收时，"a"的处理（包括发布）已经完成
+	election.leaderCh <- "a"
+	election.leaderCh <- "b"
+
+	events, cancel := mgr.SubscribeEvents(0)
+	defer cancel()
+
+	election.leaderCh <- "c"
+	for {
+		event := nextEvent(t, events)
+		require.NotEqual(t, "a", event.NodeID, "不保留历史时不应回放订阅前的事件")
+		if event.NodeID == "c" {
+			break
+		}
+	}
+}
+
+func TestSubscribeEvents_NodeStatusEvents(t *testing.T) {
+	election := newFakeElection(true, "1")
+	mgr := startReapingManager(t, election, time.Hour)
+
+	events, cancel := mgr.SubscribeEvents(64)
+	defer cancel()
+
+	// 注册后不发送心跳，节点依次变为可疑、死亡
+	mgr.RegisterNode("127.0.0.2")
+	var states []types.NodeStatus
+	for len(states) < 2 {
+		event := nextEvent(t, events)
+		if event.Type != cluster.EventNodeStatus || event.NodeID != "127.0.0.2" {
+			continue
+		}
+		state, ok := event.Data.(types.NodeStatus)
+		require.True(t, ok)
+		if state != types.NodeStatusHealthy {
+			states = append(states, state)
+		}
+	}
+	assert.Equal(t, []types.NodeStatus{types.NodeStatusSuspect, types.NodeStatusDead}, states)
+}
+
+func TestSubscribeEvents_CancelAndStopCloseChannel(t *testing.T) {
+	mgr := newEventsManager(t, newFakeElection(true, "1"), 0)
+	require.NoError(t, mgr.Start())
+
+	first, cancelFirst := mgr.SubscribeEvents(0)
+	second, cancelSecond := mgr.SubscribeEvents(0)
+	defer cancelSecond()
+
+	cancelFirst()
+	cancelFirst() // 重复取消是安全的
+	_, ok := <-first
+	assert.False(t, ok, "取消订阅后通道应关闭")
+
+	require.NoError(t, mgr.Stop(context.Background()))
+	select {
+	case _, ok := <-second:
+		assert.False(t, ok, "停止集群管理器后通道应关闭")
+	case <-time.After(time.Second):
+		t.Fatal("停止集群管理器后订阅通道未关闭")
+	}
+}
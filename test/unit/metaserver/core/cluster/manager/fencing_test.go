@@ -0,0 +1,34 @@
+package manager_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterManager_LeaderChangeAdvancesFencingTerm(t *testing.T) {
+	election := newFakeElection(true, "1")
+	mgr := startEventsManager(t, election, 0)
+	events, cancel := mgr.SubscribeEvents(0)
+	defer cancel()
+
+	for term, leader := range []string{"1", "2"} {
+		election.mu.Lock()
+		election.term = uint64(term + 1)
+		election.mu.Unlock()
+		election.leaderCh <- leader
+
+		event := nextEvent(t, events)
+		require.Equal(t, cluster.EventLeaderChange, event.Type)
+		assert.Equal(t, uint64(term+1), event.Data, "领导者变更事件应携带任期")
+	}
+
+	// 新领导者当选后负载均衡管理器只接受任期2签发的迁移任务
+	require.Eventually(t, func() bool {
+		return mgr.GetRebalanceStatus()["fencing_term"] == rebalance.FencingToken(2)
+	}, time.Second, time.Millisecond)
+}
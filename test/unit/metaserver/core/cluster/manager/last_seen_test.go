@@ -0,0 +1,40 @@
+package manager_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterManager_LastSeenReflectsLastHeartbeat(t *testing.T) {
+	election := newFakeElection(true, "1", "127.0.0.1")
+	mgr := startReapingManager(t, election, -1)
+
+	registered := time.Now().Unix()
+	mgr.RegisterNode("127.0.0.1")
+
+	require.Eventually(t, func() bool {
+		info, err := mgr.GetNodeInfo(context.Background(), "127.0.0.1")
+		return err == nil && info.Status == types.NodeStatusDead
+	}, time.Second, 5*time.Millisecond)
+
+	// LastSeen精确到秒，等到当前时间明显晚于注册时间
+	require.Eventually(t, func() bool {
+		return time.Now().Unix() > registered+1
+	}, 3*time.Second, 10*time.Millisecond)
+
+	info, err := mgr.GetNodeInfo(context.Background(), "127.0.0.1")
+	require.NoError(t, err)
+	assert.InDelta(t, registered, info.LastSeen, 1, "死亡节点的LastSeen应为最后一次心跳的时间")
+	assert.Less(t, info.LastSeen, time.Now().Unix())
+
+	nodes, err := mgr.ListNodes(context.Background())
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	assert.Equal(t, info.LastSeen, nodes[0].LastSeen)
+	assert.Equal(t, types.NodeStatusDead, nodes[0].Status)
+}
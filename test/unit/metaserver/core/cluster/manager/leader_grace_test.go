@@ -0,0 +1,68 @@
+package manager_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/types"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// becomeLeader 让集群管理器收到本节点当选的通知
+func becomeLeader(t *testing.T, mgr cluster.Manager, election *fakeElection) {
+	t.Helper()
+	election.mu.Lock()
+	election.leader = true
+	election.leaderID = "1"
+	election.mu.Unlock()
+	election.leaderCh <- "1"
+	require.Eventually(t, mgr.IsLeader, time.Second, time.Millisecond)
+}
+
+func TestClusterManager_LeaderGracePeriodDefersRemoval(t *testing.T) {
+	const grace = 300 * time.Millisecond
+	election := newFakeElection(false, "1", "127.0.0.1")
+	mgr := startReapingManager(t, election, 20*time.Millisecond, func(cfg *metaconfig.ClusterConfig) {
+		cfg.LeaderGracePeriod = grace
+	})
+
+	// 宽限期从集群管理器处理当选通知时开始，在发送通知前计时
+	elected := time.Now()
+	becomeLeader(t, mgr, election)
+	// 新领导者还没有收到该节点的任何心跳
+	mgr.RegisterNode("127.0.0.1")
+
+	// 宽限期内远超死亡超时和清理延迟，节点仍不被判定死亡或移除
+	time.Sleep(grace / 2)
+	info, err := mgr.GetNodeInfo(context.Background(), "127.0.0.1")
+	require.NoError(t, err)
+	assert.NotEqual(t, types.NodeStatusDead, info.Status)
+	assert.True(t, election.hasPeer("127.0.0.1"), "宽限期内不应移除节点")
+
+	// 宽限期结束后仍无心跳的节点被判定死亡并移除
+	require.Eventually(t, func() bool {
+		return !election.hasPeer("127.0.0.1")
+	}, 2*time.Second, 5*time.Millisecond)
+	assert.GreaterOrEqual(t, time.Since(elected), grace)
+	assert.Equal(t, 0, mgr.GetNodeCount())
+}
+
+func TestClusterManager_LeaderGracePeriodDisabled(t *testing.T) {
+	election := newFakeElection(false, "1", "127.0.0.1")
+	mgr := startReapingManager(t, election, 20*time.Millisecond, func(cfg *metaconfig.ClusterConfig) {
+		cfg.LeaderGracePeriod = -1
+	})
+
+	becomeLeader(t, mgr, election)
+	elected := time.Now()
+	mgr.RegisterNode("127.0.0.1")
+
+	require.Eventually(t, func() bool {
+		return !election.hasPeer("127.0.0.1")
+	}, 2*time.Second, 5*time.Millisecond)
+	assert.Less(t, time.Since(elected), time.Second)
+}
@@ -0,0 +1,62 @@
+package manager_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaderChangeChan_CoalescesToLatestLeader(t *testing.T) {
+	election := newFakeElection(false, "1")
+	mgr := startEventsManager(t, election, 0)
+
+	// 没有消费者读取时连续发生多次变更
+	for i := 0; i < 50; i++ {
+		election.leaderCh <- fmt.Sprintf("leader-%d", i)
+	}
+	require.Eventually(t, func() bool {
+		return mgr.GetCurrentLeader() == "leader-49"
+	}, 2*time.Second, time.Millisecond)
+
+	select {
+	case leader := <-mgr.LeaderChangeChan():
+		assert.Equal(t, "leader-49", leader, "被跳过的只能是中间的变更")
+	case <-time.After(time.Second):
+		t.Fatal("消费者应收到最新的领导者")
+	}
+	select {
+	case leader := <-mgr.LeaderChangeChan():
+		t.Fatalf("通道中只应保留一个值，多出%q", leader)
+	default:
+	}
+}
+
+func TestLeaderChangeChan_SlowConsumerConvergesToFinalLeader(t *testing.T) {
+	election := newFakeElection(false, "1")
+	mgr := startEventsManager(t, election, 0)
+
+	observed := make(chan string, 100)
+	go func() {
+		for leader := range mgr.LeaderChangeChan() {
+			observed <- leader
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		election.leaderCh <- fmt.Sprintf("leader-%d", i)
+	}
+
+	var last string
+	deadline := time.After(2 * time.Second)
+	for last != "leader-99" {
+		select {
+		case last = <-observed:
+		case <-deadline:
+			t.Fatalf("消费者最终应看到最后的领导者，最后看到%q", last)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package manager_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClusterManager_ConcurrentAccessNoDeadlock 并发执行读取、指标更新与领导者/节点状态事件，
+// 配合-race检查锁顺序和数据竞争；结束后节点信息必须反映最后一次领导者变更
+func TestClusterManager_ConcurrentAccessNoDeadlock(t *testing.T) {
+	election := newFakeElection(true, "1")
+	mgr := startReapingManager(t, election, time.Hour)
+
+	nodes := []string{"127.0.0.2", "127.0.0.3", "127.0.0.4"}
+	for _, node := range nodes {
+		mgr.RegisterNode(node)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	run := func(fn func(i int)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; ctx.Err() == nil; i++ {
+				fn(i)
+			}
+		}()
+	}
+
+	for r := 0; r < 4; r++ {
+		run(func(int) { mgr.ListNodes(context.Background()) })
+	}
+	run(func(i int) { mgr.GetNodeInfo(context.Background(), nodes[i%len(nodes)]) })
+	run(func(i int) {
+		mgr.UpdateNodeMetrics(nodes[i%len(nodes)], &types.NodeMetrics{CPUUsagePercent: float64(i % 100)})
+	})
+	run(func(int) {
+		mgr.GetRebalanceStatus()
+		mgr.Membership()
+		mgr.LastElectionTime()
+	})
+	// 节点状态事件：注销后重新注册，配合很短的超时不断产生可疑/死亡状态变更
+	run(func(i int) {
+		node := nodes[i%len(nodes)]
+		mgr.UnregisterNode(node)
+		mgr.RegisterNode(node)
+		time.Sleep(time.Millisecond)
+	})
+	// 领导者变更事件，由事件循环处理
+	leaderDone := make(chan struct{})
+	stopLeaderChanges := make(chan struct{})
+	go func() {
+		defer close(leaderDone)
+		for i := 0; ; i++ {
+			select {
+			case election.leaderCh <- nodes[i%len(nodes)]:
+			case <-stopLeaderChanges:
+				return
+			}
+		}
+	}()
+
+	time.Sleep(300 * time.Millisecond)
+	close(stopLeaderChanges)
+	<-leaderDone
+
+	// 读取仍在进行时切换到最终领导者，进行中的读取不能把旧领导者写回缓存
+	finalLeader := "127.0.0.3"
+	election.leaderCh <- finalLeader
+	require.Eventually(t, func() bool {
+		return mgr.GetCurrentLeader() == finalLeader
+	}, time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("并发访问未在期限内结束，可能发生死锁")
+	}
+
+	infos, err := mgr.ListNodes(context.Background())
+	require.NoError(t, err)
+	require.NotEmpty(t, infos)
+	for _, info := range infos {
+		assert.Equal(t, string(info.NodeID) == finalLeader, info.IsLeader, "节点%s的领导者标记已过期", info.NodeID)
+	}
+}
@@ -0,0 +1,66 @@
+package manager_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/common/types"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMetricsTestManager 创建使用指定负载均衡管理器的集群管理器，无需启动
+func newMetricsTestManager(t *testing.T) (cluster.Manager, *rebalance.Manager) {
+	t.Helper()
+	rebalancer, err := rebalance.NewManager(&metaconfig.LoadBalancerConfig{EvaluationInterval: time.Hour}, logging.NewLogger())
+	require.NoError(t, err)
+
+	mgr, err := cluster.NewManager(testClusterConfig(true), logging.NewLogger(),
+		cluster.WithRebalanceManager(rebalancer))
+	require.NoError(t, err)
+	return mgr, rebalancer
+}
+
+func TestClusterManager_UpdateNodeMetricsBatch(t *testing.T) {
+	mgr, rebalancer := newMetricsTestManager(t)
+
+	batch := map[string]*types.NodeMetrics{
+		"node-a": {CPUUsagePercent: 30, DiskUsageRatio: 0.4, ShardCount: 100},
+		"node-b": {NodeID: "node-b", CPUUsagePercent: 50, DiskUsageRatio: 0.6, ShardCount: 150},
+		"node-c": {CPUUsagePercent: 70, DiskUsageRatio: 0.8, ShardCount: 200},
+	}
+	assert.Empty(t, mgr.UpdateNodeMetricsBatch(batch))
+
+	for nodeID, want := range batch {
+		stored := rebalancer.GetNodeMetrics(nodeID)
+		require.NotNil(t, stored, "节点%s的指标应已写入", nodeID)
+		assert.Equal(t, types.NodeID(nodeID), stored.NodeID)
+		assert.Equal(t, want.CPUUsagePercent, stored.CPUUsagePercent)
+		assert.Equal(t, want.ShardCount, stored.ShardCount)
+	}
+}
+
+func TestClusterManager_UpdateNodeMetricsBatchRejectsInvalid(t *testing.T) {
+	mgr, rebalancer := newMetricsTestManager(t)
+
+	invalid := mgr.UpdateNodeMetricsBatch(map[string]*types.NodeMetrics{
+		"node-a": {CPUUsagePercent: 30, DiskUsageRatio: 0.4},
+		"node-b": {CPUUsagePercent: 150},
+		"node-c": {NodeID: "other", DiskUsageRatio: 0.5},
+		"node-d": nil,
+	})
+
+	require.Len(t, invalid, 3)
+	assert.Contains(t, invalid, "node-b")
+	assert.Contains(t, invalid, "node-c")
+	assert.Contains(t, invalid, "node-d")
+	assert.Nil(t, rebalancer.GetNodeMetrics("node-a"), "整批被拒绝时合法节点的指标也不应写入")
+
+	// 与单节点上报使用相同的校验规则
+	assert.Equal(t, invalid["node-b"].Error(),
+		cluster.ValidateNodeMetrics("node-b", &types.NodeMetrics{CPUUsagePercent: 150}).Error())
+}
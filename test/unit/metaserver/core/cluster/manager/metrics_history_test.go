@@ -0,0 +1,93 @@
+package manager_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/common/types"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newHistoryManager 创建每个节点保留size个指标样本的集群管理器，无需启动
+func newHistoryManager(t *testing.T, size int) cluster.Manager {
+	t.Helper()
+	cfg := testClusterConfig(true)
+	cfg.MetricsHistorySize = size
+	mgr, err := cluster.NewManager(cfg, logging.NewLogger())
+	require.NoError(t, err)
+	return mgr
+}
+
+// cpuSeries 提取样本的CPU使用率，用于检查顺序
+func cpuSeries(samples []cluster.MetricsSample) []float64 {
+	result := make([]float64, len(samples))
+	for i, s := range samples {
+		result[i] = s.Metrics.CPUUsagePercent
+	}
+	return result
+}
+
+func TestClusterManager_MetricsHistoryRetainsLatestSamplesInOrder(t *testing.T) {
+	mgr := newHistoryManager(t, 5)
+
+	_, ok := mgr.GetNodeMetricsHistory("node-a", cluster.MetricsHistoryQuery{})
+	assert.False(t, ok, "未上报过指标的节点没有历史")
+
+	for i := 1; i <= 8; i++ {
+		mgr.UpdateNodeMetrics("node-a", &types.NodeMetrics{CPUUsagePercent: float64(i * 10), ShardCount: i})
+	}
+	mgr.UpdateNodeMetrics("node-b", &types.NodeMetrics{CPUUsagePercent: 99})
+
+	samples, ok := mgr.GetNodeMetricsHistory("node-a", cluster.MetricsHistoryQuery{})
+	require.True(t, ok)
+	assert.Equal(t, []float64{40, 50, 60, 70, 80}, cpuSeries(samples), "只保留最近5个样本，按时间先后排列")
+	for i := 1; i < len(samples); i++ {
+		assert.False(t, samples[i].Timestamp.Before(samples[i-1].Timestamp))
+	}
+
+	samples, ok = mgr.GetNodeMetricsHistory("node-b", cluster.MetricsHistoryQuery{})
+	require.True(t, ok)
+	assert.Equal(t, []float64{99}, cpuSeries(samples), "各节点的历史相互独立")
+
+	// 注销节点时删除其历史
+	mgr.UnregisterNode("node-b")
+	_, ok = mgr.GetNodeMetricsHistory("node-b", cluster.MetricsHistoryQuery{})
+	assert.False(t, ok)
+}
+
+func TestClusterManager_MetricsHistoryWindowAndDownsampling(t *testing.T) {
+	mgr := newHistoryManager(t, 100)
+
+	for i := 1; i <= 6; i++ {
+		mgr.UpdateNodeMetrics("node-a", &types.NodeMetrics{CPUUsagePercent: float64(i * 10), ShardCount: i})
+	}
+	cutoff := time.Now()
+	time.Sleep(time.Millisecond)
+	mgr.UpdateNodeMetrics("node-a", &types.NodeMetrics{CPUUsagePercent: 70, ShardCount: 7})
+
+	samples, _ := mgr.GetNodeMetricsHistory("node-a", cluster.MetricsHistoryQuery{Since: cutoff})
+	assert.Equal(t, []float64{70}, cpuSeries(samples))
+
+	// 7个样本降为3个：分组为[10 20] [30 40] [50 60 70]，瞬时值取平均，分片数取组内最后一个
+	samples, _ = mgr.GetNodeMetricsHistory("node-a", cluster.MetricsHistoryQuery{MaxPoints: 3})
+	require.Len(t, samples, 3)
+	assert.Equal(t, []float64{15, 35, 60}, cpuSeries(samples))
+	assert.Equal(t, 2, samples[0].Metrics.ShardCount)
+	assert.Equal(t, 4, samples[1].Metrics.ShardCount)
+	assert.Equal(t, 7, samples[2].Metrics.ShardCount)
+
+	// 样本数不超过MaxPoints时原样返回
+	samples, _ = mgr.GetNodeMetricsHistory("node-a", cluster.MetricsHistoryQuery{MaxPoints: 10})
+	assert.Len(t, samples, 7)
+}
+
+func TestClusterManager_MetricsHistoryDisabled(t *testing.T) {
+	mgr := newHistoryManager(t, -1)
+
+	mgr.UpdateNodeMetrics("node-a", &types.NodeMetrics{CPUUsagePercent: 10})
+	_, ok := mgr.GetNodeMetricsHistory("node-a", cluster.MetricsHistoryQuery{})
+	assert.False(t, ok)
+}
@@ -0,0 +1,32 @@
+package manager_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// listingElection 能列出Raft成员配置的选举管理器，confPeers模拟已应用的成员配置
+type listingElection struct {
+	*fakeElection
+	confPeers []string
+}
+
+func (e *listingElection) GetPeers() []string {
+	return e.confPeers
+}
+
+func TestClusterManager_IsPeerActiveReadsConfState(t *testing.T) {
+	election := &listingElection{fakeElection: newFakeElection(true, "1"), confPeers: []string{"1", "2"}}
+	mgr := startDebugManager(t, election)
+
+	assert.True(t, mgr.IsPeerActive("2"), "成员配置中的节点应视为活跃")
+	assert.True(t, mgr.IsPeerActive("1"))
+	assert.False(t, mgr.IsPeerActive("3"), "新节点不在成员配置中")
+}
+
+func TestClusterManager_IsPeerActiveWithoutPeerListing(t *testing.T) {
+	// 选举管理器不能列出成员时不认为任何节点已是成员
+	mgr := startDebugManager(t, newFakeElection(true, "1", "2"))
+	assert.False(t, mgr.IsPeerActive("2"))
+}
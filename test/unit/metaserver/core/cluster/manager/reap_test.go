@@ -0,0 +1,132 @@
+package manager_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/common/types"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeElection 记录成员变更的选举管理器
+type fakeElection struct {
+	mu       sync.Mutex
+	leader   bool
+	leaderID string
+	term     uint64
+	peers    map[string]bool
+	leaderCh chan string
+}
+
+func newFakeElection(leader bool, peers ...string) *fakeElection {
+	e := &fakeElection{leader: leader, peers: make(map[string]bool), leaderCh: make(chan string)}
+	for _, peer := range peers {
+		e.peers[peer] = true
+	}
+	return e
+}
+
+func (e *fakeElection) Start() error                    { return nil }
+func (e *fakeElection) Stop() error                     { return nil }
+func (e *fakeElection) GetCurrentLeader() string        { return e.leaderID }
+func (e *fakeElection) LeaderChangeChan() <-chan string { return e.leaderCh }
+
+func (e *fakeElection) CurrentTerm() uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.term
+}
+
+func (e *fakeElection) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leader
+}
+
+func (e *fakeElection) AddPeer(peerID string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.peers[peerID] = true
+	return nil
+}
+
+func (e *fakeElection) RemovePeer(peerID string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.peers, peerID)
+	return nil
+}
+
+func (e *fakeElection) hasPeer(peerID string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.peers[peerID]
+}
+
+// startReapingManager 启动心跳超时很短的集群管理器，configure可进一步修改配置
+func startReapingManager(t *testing.T, election *fakeElection, reapDelay time.Duration, configure ...func(*metaconfig.ClusterConfig)) cluster.Manager {
+	t.Helper()
+	cfg := testClusterConfig(true)
+	cfg.HeartbeatInterval = 10 * time.Millisecond
+	cfg.SuspectTimeout = 20 * time.Millisecond
+	cfg.DeadTimeout = 40 * time.Millisecond
+	cfg.CleanupInterval = 10 * time.Millisecond
+	cfg.DeadNodeReapDelay = reapDelay
+	for _, fn := range configure {
+		fn(&cfg)
+	}
+
+	rebalancer, err := rebalance.NewManager(&metaconfig.LoadBalancerConfig{EvaluationInterval: time.Hour}, logging.NewLogger())
+	require.NoError(t, err)
+
+	mgr, err := cluster.NewManager(cfg, logging.NewLogger(),
+		cluster.WithElectionManager(election),
+		cluster.WithRebalanceManager(rebalancer))
+	require.NoError(t, err)
+	require.NoError(t, mgr.Start())
+	t.Cleanup(func() { mgr.Stop(context.Background()) })
+	return mgr
+}
+
+func TestClusterManager_ReapedNodeRemovedFromMembership(t *testing.T) {
+	election := newFakeElection(true, "1", "127.0.0.1")
+	mgr := startReapingManager(t, election, 100*time.Millisecond)
+
+	mgr.RegisterNode("127.0.0.1")
+	require.Equal(t, 1, mgr.GetNodeCount())
+
+	// 节点死亡后在清理延迟内仍是集群成员
+	require.Eventually(t, func() bool {
+		info, err := mgr.GetNodeInfo(context.Background(), "127.0.0.1")
+		return err == nil && info.Status == types.NodeStatusDead
+	}, time.Second, 5*time.Millisecond)
+	assert.True(t, election.hasPeer("127.0.0.1"), "清理延迟内不应移除死亡节点")
+
+	// 超过清理延迟后同时从心跳状态和Raft成员中移除
+	require.Eventually(t, func() bool {
+		return !election.hasPeer("127.0.0.1")
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, mgr.GetNodeCount())
+	assert.True(t, election.hasPeer("1"))
+}
+
+func TestClusterManager_FollowerDoesNotProposeRemoval(t *testing.T) {
+	election := newFakeElection(false, "1", "127.0.0.1")
+	mgr := startReapingManager(t, election, 50*time.Millisecond)
+
+	mgr.RegisterNode("127.0.0.1")
+	require.Eventually(t, func() bool {
+		return mgr.GetNodeCount() == 0
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// 成员变更只能由领导者提议
+	time.Sleep(50 * time.Millisecond)
+	assert.True(t, election.hasPeer("127.0.0.1"))
+}
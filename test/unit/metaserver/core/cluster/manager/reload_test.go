@@ -0,0 +1,94 @@
+package manager_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newReloadTestManager 创建使用容量策略的集群管理器，便于直接观察阈值
+func newReloadTestManager(t *testing.T, cfg metaconfig.ClusterConfig) (cluster.Manager, *rebalance.Manager) {
+	t.Helper()
+	rebalancer, err := rebalance.NewManager(&metaconfig.LoadBalancerConfig{
+		EvaluationInterval: time.Hour,
+		ImbalanceThreshold: cfg.ImbalanceThreshold,
+		ImbalanceStopRatio: cfg.ImbalanceStopRatio,
+	}, logging.NewLogger())
+	require.NoError(t, err)
+	rebalancer.SetStrategy(rebalance.NewCapacityBalanceStrategy(cfg.ImbalanceThreshold))
+
+	mgr, err := cluster.NewManager(cfg, logging.NewLogger(),
+		cluster.WithElectionManager(newFakeElection(true)),
+		cluster.WithRebalanceManager(rebalancer))
+	require.NoError(t, err)
+	return mgr, rebalancer
+}
+
+func TestReload_AppliesLogLevelAndThresholdLive(t *testing.T) {
+	clusterCfg := testClusterConfig(true)
+	clusterCfg.ImbalanceThreshold = 20
+	clusterCfg.ImbalanceStopRatio = 0.8
+	mgr, rebalancer := newReloadTestManager(t, clusterCfg)
+
+	current := &metaconfig.Config{Cluster: clusterCfg}
+	current.Logging.Level = "info"
+
+	var buf bytes.Buffer
+	logger := logging.GetLogger("reload_test")
+	logger.SetOutput(&buf)
+	defer logging.SetGlobalLevel(logging.LevelInfo)
+
+	logger.Debug("重载前的调试日志")
+	assert.NotContains(t, buf.String(), "重载前的调试日志")
+	evaluation := rebalancer.Evaluate()
+	assert.Equal(t, 20.0, evaluation.Threshold)
+	assert.Equal(t, 16.0, evaluation.StopThreshold)
+
+	next := *current
+	next.Logging.Level = "debug"
+	next.Cluster.ImbalanceThreshold = 35
+	next.Cluster.ImbalanceStopRatio = 0.5
+	next.Cluster.NodeAddress = "http://10.0.0.9:8080"
+
+	result := metaconfig.Reload(current, &next, mgr)
+
+	assert.ElementsMatch(t, []string{"logging.level", "cluster.imbalance_threshold", "cluster.imbalance_stop_ratio"}, result.Applied)
+	assert.Equal(t, []string{"cluster.node_address"}, result.RestartRequired)
+
+	logger.Debug("重载后的调试日志")
+	assert.Contains(t, buf.String(), "重载后的调试日志")
+	evaluation = rebalancer.Evaluate()
+	assert.Equal(t, 35.0, evaluation.Threshold)
+	assert.Equal(t, 17.5, evaluation.StopThreshold)
+
+	// 已应用的配置写回当前配置，需要重启的配置保持原值
+	assert.Equal(t, "debug", current.Logging.Level)
+	assert.Equal(t, 35.0, current.Cluster.ImbalanceThreshold)
+	assert.Empty(t, current.Cluster.NodeAddress)
+}
+
+func TestReload_NoChanges(t *testing.T) {
+	current := &metaconfig.Config{Cluster: testClusterConfig(true)}
+	next := *current
+
+	result := metaconfig.Reload(current, &next, nil)
+	assert.False(t, result.Changed())
+}
+
+func TestReload_ClusterFieldsRequireRestartWithoutReloader(t *testing.T) {
+	current := &metaconfig.Config{Cluster: testClusterConfig(true)}
+	next := *current
+	next.Cluster.ImbalanceThreshold = 30
+
+	result := metaconfig.Reload(current, &next, nil)
+	assert.Empty(t, result.Applied)
+	assert.Equal(t, []string{"cluster.imbalance_threshold"}, result.RestartRequired)
+	assert.Zero(t, current.Cluster.ImbalanceThreshold)
+}
@@ -0,0 +1,82 @@
+package manager_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hangingRebalancer Stop一直阻塞直到release被关闭的负载均衡管理器
+type hangingRebalancer struct {
+	*rebalance.Manager
+	release chan struct{}
+}
+
+func (h *hangingRebalancer) Stop() error {
+	<-h.release
+	return h.Manager.Stop()
+}
+
+// recordingElection 记录Stop是否被调用的选举管理器
+type recordingElection struct {
+	*fakeElection
+	stopped atomic.Bool
+}
+
+func (e *recordingElection) Stop() error {
+	e.stopped.Store(true)
+	return nil
+}
+
+// newHangingManager 创建负载均衡管理器停止时卡住的集群管理器
+func newHangingManager(t *testing.T, stopTimeout time.Duration) (cluster.Manager, *recordingElection) {
+	t.Helper()
+	rebalancer := &hangingRebalancer{Manager: newFailingRebalancer(t).Manager, release: make(chan struct{})}
+	t.Cleanup(func() { close(rebalancer.release) })
+	election := &recordingElection{fakeElection: newFakeElection(true, "1")}
+
+	cfg := testClusterConfig(true)
+	cfg.SubsystemStopTimeout = stopTimeout
+	mgr, err := cluster.NewManager(cfg, logging.NewLogger(),
+		cluster.WithRebalanceManager(rebalancer), cluster.WithElectionManager(election))
+	require.NoError(t, err)
+	require.NoError(t, mgr.Start())
+	return mgr, election
+}
+
+func TestClusterManager_StopBoundsHungSubsystem(t *testing.T) {
+	mgr, election := newHangingManager(t, 100*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	err := mgr.Stop(ctx)
+	elapsed := time.Since(start)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "负载均衡管理器超时")
+	assert.Less(t, elapsed, time.Second, "卡住的子系统只应占用自己的停止超时")
+	assert.True(t, election.stopped.Load(), "其余子系统仍应被停止")
+}
+
+func TestClusterManager_StopHonorsOverallDeadline(t *testing.T) {
+	// 子系统超时长于整体截止时间时，以整体截止时间为准
+	mgr, election := newHangingManager(t, time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := mgr.Stop(ctx)
+
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	// 截止时间过后其余子系统的Stop仍会被调用，只是不再等待其完成
+	assert.Eventually(t, election.stopped.Load, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,227 @@
+package manager_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/common/types"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowMetricsRebalancer 获取节点指标时长时间阻塞的负载均衡管理器
+type slowMetricsRebalancer struct {
+	*rebalance.Manager
+	delay time.Duration
+}
+
+func (s *slowMetricsRebalancer) GetNodeMetrics(nodeID string) *types.NodeMetrics {
+	time.Sleep(s.delay)
+	return s.Manager.GetNodeMetrics(nodeID)
+}
+
+func newSnapshotTestManager(t *testing.T, metricsDelay time.Duration, nodes ...string) cluster.Manager {
+	t.Helper()
+	inner, err := rebalance.NewManager(&metaconfig.LoadBalancerConfig{EvaluationInterval: time.Hour}, logging.NewLogger())
+	require.NoError(t, err)
+
+	mgr, err := cluster.NewManager(testClusterConfig(true), logging.NewLogger(),
+		cluster.WithElectionManager(newFakeElection(true, "1")),
+		cluster.WithRebalanceManager(&slowMetricsRebalancer{Manager: inner, delay: metricsDelay}))
+	require.NoError(t, err)
+
+	for _, node := range nodes {
+		mgr.RegisterNode(node)
+	}
+	return mgr
+}
+
+func TestGetClusterSnapshot_ReturnsPartialDataOnTimeout(t *testing.T) {
+	mgr := newSnapshotTestManager(t, 2*time.Second, "127.0.0.2", "127.0.0.3")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	snapshot := mgr.GetClusterSnapshot(ctx)
+	elapsed := time.Since(start)
+
+	assert.Less(t, elapsed, time.Second, "快照应在超时后立即返回")
+	assert.Equal(t, true, snapshot["partial"])
+	assert.Contains(t, snapshot["error"], "context deadline exceeded")
+
+	// 超时后的节点仍然列出，只是不含指标
+	nodes, ok := snapshot["nodes"].([]types.NodeInfo)
+	require.True(t, ok)
+	assert.Len(t, nodes, 2)
+	assert.Equal(t, 2, snapshot["total_nodes"])
+	for _, node := range nodes {
+		assert.Nil(t, node.Metrics)
+	}
+}
+
+func TestGetClusterSnapshot_CompleteWhenMetricsAreFast(t *testing.T) {
+	mgr := newSnapshotTestManager(t, 0, "127.0.0.2")
+	mgr.UpdateNodeMetrics("127.0.0.2", &types.NodeMetrics{CPUUsagePercent: 42})
+
+	snapshot := mgr.GetClusterSnapshot(context.Background())
+
+	assert.Equal(t, false, snapshot["partial"])
+	assert.NotContains(t, snapshot, "error")
+	nodes := snapshot["nodes"].([]types.NodeInfo)
+	require.Len(t, nodes, 1)
+	require.NotNil(t, nodes[0].Metrics)
+	assert.Equal(t, 42.0, nodes[0].Metrics.CPUUsagePercent)
+}
+
+func TestListNodes_CancelledContextSkipsCache(t *testing.T) {
+	mgr := newSnapshotTestManager(t, 300*time.Millisecond, "127.0.0.2")
+	mgr.UpdateNodeMetrics("127.0.0.2", &types.NodeMetrics{CPUUsagePercent: 10})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	nodes, err := mgr.ListNodes(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Len(t, nodes, 1)
+	assert.Nil(t, nodes[0].Metrics)
+
+	// 超时产生的不完整节点信息不写入缓存，后续请求重新获取指标
+	nodes, err = mgr.ListNodes(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, nodes[0].Metrics)
+}
+
+// concurrencyRebalancer 记录同时进行的指标获取数的负载均衡管理器
+type concurrencyRebalancer struct {
+	*rebalance.Manager
+	mu      sync.Mutex
+	active  int
+	maxSeen int
+}
+
+func (c *concurrencyRebalancer) GetNodeMetrics(nodeID string) *types.NodeMetrics {
+	c.mu.Lock()
+	c.active++
+	if c.active > c.maxSeen {
+		c.maxSeen = c.active
+	}
+	c.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	c.mu.Lock()
+	c.active--
+	c.mu.Unlock()
+	return c.Manager.GetNodeMetrics(nodeID)
+}
+
+func TestListNodes_BoundsConcurrentMetricsFetches(t *testing.T) {
+	inner, err := rebalance.NewManager(&metaconfig.LoadBalancerConfig{EvaluationInterval: time.Hour}, logging.NewLogger())
+	require.NoError(t, err)
+	rebalancer := &concurrencyRebalancer{Manager: inner}
+	mgr, err := cluster.NewManager(testClusterConfig(true), logging.NewLogger(),
+		cluster.WithElectionManager(newFakeElection(true, "1")),
+		cluster.WithRebalanceManager(rebalancer))
+	require.NoError(t, err)
+
+	for i := 0; i < 40; i++ {
+		node := fmt.Sprintf("127.0.1.%d", i+1)
+		mgr.RegisterNode(node)
+		mgr.UpdateNodeMetrics(node, &types.NodeMetrics{CPUUsagePercent: float64(i)})
+	}
+
+	nodes, err := mgr.ListNodes(context.Background())
+	require.NoError(t, err)
+	require.Len(t, nodes, 40)
+	for _, node := range nodes {
+		assert.NotNil(t, node.Metrics, string(node.NodeID))
+	}
+
+	rebalancer.mu.Lock()
+	defer rebalancer.mu.Unlock()
+	assert.Greater(t, rebalancer.maxSeen, 1, "指标应并发获取")
+	assert.LessOrEqual(t, rebalancer.maxSeen, 8, "并发获取数不应超过工作goroutine数")
+}
+
+func newStaleTestManager(t *testing.T, ttl time.Duration) (cluster.Manager, *fakeElection) {
+	t.Helper()
+	election := newFakeElection(false, "1")
+	election.leaderID = "2"
+
+	cfg := testClusterConfig(true)
+	cfg.StatusCacheTTL = ttl
+	mgr, err := cluster.NewManager(cfg, logging.NewLogger(), cluster.WithElectionManager(election))
+	require.NoError(t, err)
+	mgr.RegisterNode("127.0.0.2")
+	return mgr, election
+}
+
+func TestGetClusterSnapshot_ServesStaleSnapshotWhenListingFails(t *testing.T) {
+	mgr, _ := newStaleTestManager(t, time.Minute)
+
+	fresh := mgr.GetClusterSnapshot(context.Background())
+	require.Equal(t, false, fresh["stale"])
+	require.Equal(t, "2", fresh["leader_id"])
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	snapshot := mgr.GetClusterSnapshot(cancelled)
+
+	assert.Equal(t, true, snapshot["stale"])
+	assert.Equal(t, false, snapshot["partial"])
+	assert.Contains(t, snapshot["stale_reason"], "context canceled")
+	assert.IsType(t, time.Time{}, snapshot["cached_at"])
+	assert.Equal(t, "2", snapshot["leader_id"])
+	nodes, ok := snapshot["nodes"].([]types.NodeInfo)
+	require.True(t, ok)
+	assert.Len(t, nodes, 1)
+
+	// 缓存中的快照不被返回结果上的标记修改
+	again := mgr.GetClusterSnapshot(context.Background())
+	assert.Equal(t, false, again["stale"])
+	assert.NotContains(t, again, "stale_reason")
+}
+
+func TestGetClusterSnapshot_ServesStaleSnapshotWhenLeaderUnknown(t *testing.T) {
+	mgr, election := newStaleTestManager(t, time.Minute)
+	mgr.GetClusterSnapshot(context.Background())
+
+	election.leaderID = ""
+	snapshot := mgr.GetClusterSnapshot(context.Background())
+
+	assert.Equal(t, true, snapshot["stale"])
+	assert.Equal(t, "2", snapshot["leader_id"], "返回最后已知的领导者")
+	assert.NotEmpty(t, snapshot["stale_reason"])
+}
+
+func TestGetClusterSnapshot_ExpiredCacheFallsBackToLiveData(t *testing.T) {
+	mgr, election := newStaleTestManager(t, 50*time.Millisecond)
+	mgr.GetClusterSnapshot(context.Background())
+
+	election.leaderID = ""
+	time.Sleep(100 * time.Millisecond)
+	snapshot := mgr.GetClusterSnapshot(context.Background())
+
+	assert.Equal(t, false, snapshot["stale"])
+	assert.Equal(t, "", snapshot["leader_id"])
+	assert.NotContains(t, snapshot, "cached_at")
+}
+
+func TestGetClusterSnapshot_NegativeTTLDisablesCache(t *testing.T) {
+	mgr, _ := newStaleTestManager(t, -1)
+	mgr.GetClusterSnapshot(context.Background())
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	snapshot := mgr.GetClusterSnapshot(cancelled)
+
+	assert.Equal(t, false, snapshot["stale"])
+	assert.Equal(t, true, snapshot["partial"])
+}
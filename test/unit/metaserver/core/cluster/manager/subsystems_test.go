@@ -0,0 +1,102 @@
+package manager_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/common/types"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingRebalancer 记录启动和停止次数的负载均衡管理器
+type countingRebalancer struct {
+	*rebalance.Manager
+	starts atomic.Int32
+	stops  atomic.Int32
+}
+
+func (c *countingRebalancer) Start() error {
+	c.starts.Add(1)
+	return c.Manager.Start()
+}
+
+func (c *countingRebalancer) Stop() error {
+	c.stops.Add(1)
+	return c.Manager.Stop()
+}
+
+func TestClusterManager_RebalanceDisabledNotStarted(t *testing.T) {
+	inner, err := rebalance.NewManager(&metaconfig.LoadBalancerConfig{EvaluationInterval: time.Hour}, logging.NewLogger())
+	require.NoError(t, err)
+	rebalancer := &countingRebalancer{Manager: inner}
+
+	cfg := testClusterConfig(true)
+	disabled := false
+	cfg.EnableRebalance = &disabled
+	mgr, err := cluster.NewManager(cfg, logging.NewLogger(),
+		cluster.WithElectionManager(newFakeElection(true, "1")),
+		cluster.WithRebalanceManager(rebalancer))
+	require.NoError(t, err)
+
+	require.NoError(t, mgr.Start())
+	assert.False(t, mgr.RebalanceEnabled())
+	assert.Zero(t, rebalancer.starts.Load(), "禁用时不应启动负载均衡管理器")
+
+	status := mgr.GetRebalanceStatus()
+	assert.Equal(t, false, status["enabled"])
+	assert.Equal(t, true, status["disabled"])
+	assert.Equal(t, false, status["degraded"], "配置禁用不是降级")
+
+	// 禁用时触发再平衡不会产生任何效果，其余功能正常
+	mgr.TriggerRebalance()
+	mgr.RegisterNode("2")
+	assert.Equal(t, 1, mgr.GetNodeCount())
+
+	require.NoError(t, mgr.Stop(context.Background()))
+	assert.Zero(t, rebalancer.stops.Load(), "未启动的负载均衡管理器不需要停止")
+}
+
+func TestClusterManager_RebalanceEnabledByDefault(t *testing.T) {
+	inner, err := rebalance.NewManager(&metaconfig.LoadBalancerConfig{EvaluationInterval: time.Hour}, logging.NewLogger())
+	require.NoError(t, err)
+	rebalancer := &countingRebalancer{Manager: inner}
+
+	mgr, err := cluster.NewManager(testClusterConfig(true), logging.NewLogger(),
+		cluster.WithElectionManager(newFakeElection(true, "1")),
+		cluster.WithRebalanceManager(rebalancer))
+	require.NoError(t, err)
+	require.NoError(t, mgr.Start())
+
+	assert.True(t, mgr.RebalanceEnabled())
+	assert.Equal(t, int32(1), rebalancer.starts.Load())
+	assert.Equal(t, true, mgr.GetRebalanceStatus()["enabled"])
+
+	require.NoError(t, mgr.Stop(context.Background()))
+	assert.Equal(t, int32(1), rebalancer.stops.Load())
+}
+
+func TestClusterManager_HeartbeatReaperDisabledKeepsDeadNodes(t *testing.T) {
+	election := newFakeElection(true, "1", "127.0.0.1")
+	mgr := startReapingManager(t, election, 20*time.Millisecond, func(cfg *metaconfig.ClusterConfig) {
+		disabled := false
+		cfg.EnableHeartbeatReaper = &disabled
+	})
+
+	mgr.RegisterNode("127.0.0.1")
+	require.Eventually(t, func() bool {
+		info, err := mgr.GetNodeInfo(context.Background(), "127.0.0.1")
+		return err == nil && info.Status == types.NodeStatusDead
+	}, time.Second, 5*time.Millisecond)
+
+	// 远超清理延迟后死亡节点仍保留在心跳监控和Raft成员中
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, 1, mgr.GetNodeCount())
+	assert.True(t, election.hasPeer("127.0.0.1"))
+}
@@ -0,0 +1,86 @@
+package rebalance_test
+
+import (
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/types"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadAndCapacityMetrics node-a/node-b之间CPU负载不均，node-c/node-d之间磁盘容量不均
+func loadAndCapacityMetrics() map[string]*types.NodeMetrics {
+	return map[string]*types.NodeMetrics{
+		"node-a": {NodeID: "node-a", CPUUsagePercent: 90, DiskUsageRatio: 0.5, ShardCount: 20},
+		"node-b": {NodeID: "node-b", CPUUsagePercent: 10, DiskUsageRatio: 0.5, ShardCount: 20},
+		"node-c": {NodeID: "node-c", CPUUsagePercent: 50, DiskUsageRatio: 0.9, ShardCount: 20},
+		"node-d": {NodeID: "node-d", CPUUsagePercent: 50, DiskUsageRatio: 0.1, ShardCount: 20},
+	}
+}
+
+// planPairs 提取计划中的源/目标节点对
+func planPairs(plans []*rebalance.MigrationPlan) map[string]string {
+	pairs := make(map[string]string, len(plans))
+	for _, plan := range plans {
+		pairs[string(plan.SourceNodeID)] = string(plan.TargetNodeID)
+	}
+	return pairs
+}
+
+func TestCompositeStrategy_MergesPlansFromAllStrategies(t *testing.T) {
+	composite := rebalance.NewCompositeStrategy([]rebalance.BalanceStrategy{
+		rebalance.NewAccessFrequencyStrategy(20),
+		rebalance.NewCapacityBalanceStrategy(20),
+	}, []float64{0.7, 0.3})
+
+	plans, err := composite.GeneratePlan(loadAndCapacityMetrics())
+	require.NoError(t, err)
+
+	// 负载和容量的不均衡都应被处理，而不是只采用得分最高的策略
+	pairs := planPairs(plans)
+	assert.Equal(t, "node-b", pairs["node-a"], "应包含负载均衡计划")
+	assert.Equal(t, "node-d", pairs["node-c"], "应包含容量均衡计划")
+	assert.Len(t, plans, 2)
+
+	// 权重较高的负载计划排在前面
+	assert.Equal(t, types.NodeID("node-a"), plans[0].SourceNodeID)
+}
+
+func TestCompositeStrategy_DeduplicatesConflictingPairs(t *testing.T) {
+	// CPU和磁盘方向相反：负载策略要求a→b，容量策略要求b→a
+	metrics := map[string]*types.NodeMetrics{
+		"node-a": {NodeID: "node-a", CPUUsagePercent: 90, DiskUsageRatio: 0.1, ShardCount: 20},
+		"node-b": {NodeID: "node-b", CPUUsagePercent: 10, DiskUsageRatio: 0.9, ShardCount: 20},
+	}
+
+	composite := rebalance.NewCompositeStrategy([]rebalance.BalanceStrategy{
+		rebalance.NewAccessFrequencyStrategy(20),
+		rebalance.NewCapacityBalanceStrategy(20),
+	}, []float64{0.7, 0.3})
+
+	plans, err := composite.GeneratePlan(metrics)
+	require.NoError(t, err)
+	require.Len(t, plans, 1, "方向相反的计划只应保留一个")
+	assert.Equal(t, types.NodeID("node-a"), plans[0].SourceNodeID, "应保留权重较高的策略的计划")
+}
+
+func TestCompositeStrategy_CapsTotalMovementByWeight(t *testing.T) {
+	composite := rebalance.NewCompositeStrategy([]rebalance.BalanceStrategy{
+		rebalance.NewAccessFrequencyStrategy(20),
+		rebalance.NewCapacityBalanceStrategy(20),
+	}, []float64{0.5, 0.5})
+	composite.SetShardLimit(4)
+
+	plans, err := composite.GeneratePlan(loadAndCapacityMetrics())
+	require.NoError(t, err)
+	require.Len(t, plans, 2)
+
+	total := 0
+	for _, plan := range plans {
+		assert.LessOrEqual(t, len(plan.ShardIDs), 2, "每个策略最多分得一半的分片预算")
+		assert.Equal(t, uint64(len(plan.ShardIDs))*uint64(1<<30), plan.EstimatedBytes)
+		total += len(plan.ShardIDs)
+	}
+	assert.LessOrEqual(t, total, 4)
+}
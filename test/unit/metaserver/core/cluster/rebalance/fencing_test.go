@@ -0,0 +1,118 @@
+package rebalance_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFence_TermIsMonotonic(t *testing.T) {
+	fence := rebalance.NewFence()
+	assert.Equal(t, rebalance.FencingToken(0), fence.Token())
+
+	assert.True(t, fence.Advance(3))
+	assert.False(t, fence.Advance(3), "相同任期不算推进")
+	assert.False(t, fence.Advance(2), "任期不能回退")
+	assert.Equal(t, rebalance.FencingToken(3), fence.Token())
+
+	assert.NoError(t, fence.Check(3))
+	assert.NoError(t, fence.Check(4), "更新的任期有效")
+	err := fence.Check(2)
+	require.ErrorIs(t, err, rebalance.ErrStaleFencingToken)
+	assert.Contains(t, err.Error(), "当前任期3")
+}
+
+// waitForTaskState 等待任务进入终态
+func waitForTaskState(t *testing.T, migrator *rebalance.Migrator, taskID string) *rebalance.MigrationTask {
+	t.Helper()
+	var task *rebalance.MigrationTask
+	require.Eventually(t, func() bool {
+		var ok bool
+		task, ok = migrator.GetTaskStatus(taskID)
+		return ok && (task.State == rebalance.TaskStateCompleted || task.State == rebalance.TaskStateFailed)
+	}, 2*time.Second, 5*time.Millisecond)
+	return task
+}
+
+func newFencedMigrator(t *testing.T, fence *rebalance.Fence, shardTime time.Duration) *rebalance.Migrator {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	migrator := rebalance.NewMigrator(ctx, 1, logging.NewLogger(),
+		rebalance.WithShardMigrationTime(shardTime),
+		rebalance.WithProgressInterval(5*time.Millisecond),
+		rebalance.WithFence(fence),
+	)
+	t.Cleanup(func() {
+		cancel()
+		migrator.Stop()
+	})
+	return migrator
+}
+
+func TestMigrator_RejectsTaskIssuedUnderOldTerm(t *testing.T) {
+	fence := rebalance.NewFence()
+	fence.Advance(1)
+	migrator := newFencedMigrator(t, fence, 10*time.Millisecond)
+
+	// 旧领导者在任期1签发任务，执行前新领导者在任期2当选
+	taskIDs := migrator.SubmitTasks([]*rebalance.MigrationPlan{{
+		ShardIDs:       []string{"s1"},
+		EstimatedBytes: 100,
+	}}, fence.Token())
+	fence.Advance(2)
+	migrator.Start()
+
+	task := waitForTaskState(t, migrator, taskIDs[0])
+	assert.Equal(t, rebalance.TaskStateFailed, task.State)
+	assert.Contains(t, task.ErrorDetail, rebalance.ErrStaleFencingToken.Error())
+	assert.Equal(t, uint64(0), task.BytesTransferred, "过期任务不应迁移任何数据")
+	assert.Equal(t, rebalance.FencingToken(1), task.FencingToken)
+
+	// 新任期签发的任务正常执行
+	taskIDs = migrator.SubmitTasks([]*rebalance.MigrationPlan{{
+		ShardIDs:       []string{"s1"},
+		EstimatedBytes: 100,
+	}}, fence.Token())
+	task = waitForTaskState(t, migrator, taskIDs[0])
+	assert.Equal(t, rebalance.TaskStateCompleted, task.State)
+}
+
+func TestMigrator_StopsRunningTaskAfterLeadershipChange(t *testing.T) {
+	fence := rebalance.NewFence()
+	fence.Advance(1)
+	migrator := newFencedMigrator(t, fence, 50*time.Millisecond)
+	migrator.Start()
+
+	taskIDs := migrator.SubmitTasks([]*rebalance.MigrationPlan{{
+		ShardIDs:       []string{"s1", "s2", "s3", "s4"},
+		EstimatedBytes: 4000,
+	}}, fence.Token())
+
+	// 第一个分片迁移期间任期推进，剩余分片不再迁移
+	require.Eventually(t, func() bool {
+		task, _ := migrator.GetTaskStatus(taskIDs[0])
+		return task.State == rebalance.TaskStateRunning
+	}, time.Second, time.Millisecond)
+	fence.Advance(2)
+
+	task := waitForTaskState(t, migrator, taskIDs[0])
+	assert.Equal(t, rebalance.TaskStateFailed, task.State)
+	assert.Contains(t, task.ErrorDetail, rebalance.ErrStaleFencingToken.Error())
+	assert.Less(t, task.BytesTransferred, task.TotalBytes)
+}
+
+func TestManager_AdvanceTermReportedInStatus(t *testing.T) {
+	mgr, err := rebalance.NewManager(&metaconfig.LoadBalancerConfig{EvaluationInterval: time.Hour}, logging.NewLogger())
+	require.NoError(t, err)
+	mgr.AdvanceTerm(5)
+	mgr.AdvanceTerm(4)
+
+	assert.Equal(t, rebalance.FencingToken(5), mgr.FencingToken())
+	assert.Equal(t, rebalance.FencingToken(5), mgr.GetStatus()["fencing_term"])
+}
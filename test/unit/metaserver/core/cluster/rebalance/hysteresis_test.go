@@ -0,0 +1,164 @@
+package rebalance_test
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/common/types"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedStrategy 按设定值返回不平衡度的策略，阈值固定为20
+type scriptedStrategy struct {
+	mu        sync.Mutex
+	score     float64
+	evaluated int32
+	planned   int32
+}
+
+func (s *scriptedStrategy) setScore(score float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.score = score
+}
+
+func (s *scriptedStrategy) EvaluateDetail(nodeMetrics map[string]*types.NodeMetrics) rebalance.StrategyEvaluation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	atomic.AddInt32(&s.evaluated, 1)
+	return rebalance.StrategyEvaluation{
+		Strategy:       "scripted",
+		NeedRebalance:  s.score > 20,
+		ImbalanceScore: s.score,
+		Threshold:      20,
+		NodeCount:      len(nodeMetrics),
+	}
+}
+
+func (s *scriptedStrategy) Evaluate(nodeMetrics map[string]*types.NodeMetrics) (bool, float64) {
+	evaluation := s.EvaluateDetail(nodeMetrics)
+	return evaluation.NeedRebalance, evaluation.ImbalanceScore
+}
+
+func (s *scriptedStrategy) GeneratePlan(map[string]*types.NodeMetrics) ([]*rebalance.MigrationPlan, error) {
+	atomic.AddInt32(&s.planned, 1)
+	return nil, nil
+}
+
+func evaluationWithScore(score float64) rebalance.StrategyEvaluation {
+	return rebalance.StrategyEvaluation{
+		NeedRebalance:  score > 20,
+		ImbalanceScore: score,
+		Threshold:      20,
+		NodeCount:      3,
+	}
+}
+
+func TestHysteresis_DoesNotOscillateInsideBand(t *testing.T) {
+	h := rebalance.NewHysteresis(0.8)
+	assert.Equal(t, 16.0, h.StopThreshold(20))
+
+	steps := []struct {
+		score float64
+		want  bool
+	}{
+		{19, false}, // 未达到启动阈值
+		{21, true},  // 超过启动阈值，开始再平衡
+		{19, true},  // 位于迟滞区间内，继续
+		{21, true},
+		{17, true},
+		{15, false}, // 低于停止阈值，恢复均衡
+		{19, false}, // 位于迟滞区间内，不重新启动
+		{21, true},
+	}
+	for i, step := range steps {
+		evaluation := h.Update(evaluationWithScore(step.score))
+		assert.Equal(t, step.want, evaluation.NeedRebalance, "step %d score %v", i, step.score)
+		assert.Equal(t, 16.0, evaluation.StopThreshold)
+		assert.Equal(t, step.want, h.Active())
+	}
+
+	// Apply不改变状态
+	h.Reset()
+	assert.True(t, h.Apply(evaluationWithScore(21)).NeedRebalance)
+	assert.False(t, h.Active())
+}
+
+func TestHysteresis_DisabledRatio(t *testing.T) {
+	for _, ratio := range []float64{0, -1, 1, 1.5} {
+		h := rebalance.NewHysteresis(ratio)
+		assert.Equal(t, 20.0, h.StopThreshold(20), "ratio %v", ratio)
+
+		h.Update(evaluationWithScore(21))
+		assert.False(t, h.Update(evaluationWithScore(19)).NeedRebalance, "ratio %v", ratio)
+	}
+}
+
+func TestManager_HysteresisPreventsFlapping(t *testing.T) {
+	m, err := rebalance.NewManager(&metaconfig.LoadBalancerConfig{
+		EvaluationInterval: time.Hour,
+		ImbalanceStopRatio: 0.8,
+	}, logging.NewLogger())
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		nodeID := fmt.Sprintf("node-%d", i)
+		m.UpdateNodeMetrics(nodeID, &types.NodeMetrics{NodeID: types.NodeID(nodeID)})
+	}
+	strategy := &scriptedStrategy{}
+	m.SetStrategy(strategy)
+	require.NoError(t, m.Start())
+	defer m.Stop()
+
+	// evaluate 设置不平衡度并触发一次评估，返回本次评估是否生成了迁移计划
+	evaluate := func(score float64) bool {
+		strategy.setScore(score)
+		evaluated := atomic.LoadInt32(&strategy.evaluated)
+		planned := atomic.LoadInt32(&strategy.planned)
+		m.TriggerRebalance()
+		require.Eventually(t, func() bool {
+			return atomic.LoadInt32(&strategy.evaluated) > evaluated && !m.IsRebalancing()
+		}, time.Second, 5*time.Millisecond)
+		return atomic.LoadInt32(&strategy.planned) > planned
+	}
+
+	assert.False(t, evaluate(19))
+	assert.True(t, evaluate(21))
+	for i := 0; i < 3; i++ {
+		assert.True(t, evaluate(18), "迟滞区间内应持续再平衡")
+		assert.True(t, evaluate(21))
+	}
+	assert.False(t, evaluate(15))
+	for i := 0; i < 3; i++ {
+		assert.False(t, evaluate(19), "恢复均衡后迟滞区间内不应重新启动")
+	}
+
+	status := m.GetStatus()
+	assert.Equal(t, 16.0, status["stop_threshold"])
+	assert.Equal(t, false, status["rebalance_active"])
+}
+
+func TestManager_UpdateThresholdsIgnoresInvalidStopRatio(t *testing.T) {
+	m, err := rebalance.NewManager(&metaconfig.LoadBalancerConfig{
+		EvaluationInterval: time.Hour,
+		ImbalanceStopRatio: 0.8,
+	}, logging.NewLogger())
+	require.NoError(t, err)
+	m.SetStrategy(&scriptedStrategy{})
+
+	// 超出(0, 1]范围的停止比例保持当前值
+	for _, ratio := range []float64{0, -1, 1.5} {
+		m.UpdateThresholds(20, ratio)
+		assert.Equal(t, 16.0, m.GetStatus()["stop_threshold"], "ratio %v", ratio)
+	}
+
+	m.UpdateThresholds(20, 0.5)
+	assert.Equal(t, 10.0, m.GetStatus()["stop_threshold"])
+}
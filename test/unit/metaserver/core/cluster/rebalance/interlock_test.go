@@ -0,0 +1,73 @@
+package rebalance_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSafetyInterlock_HealthRatio(t *testing.T) {
+	interlock := rebalance.NewSafetyInterlock(0.75, 0)
+
+	// 未收到健康信息时不阻止
+	assert.False(t, interlock.Status().Engaged)
+
+	interlock.UpdateClusterHealth(2, 4)
+	status := interlock.Status()
+	assert.True(t, status.Engaged)
+	assert.InDelta(t, 0.5, status.HealthyRatio, 1e-9)
+	assert.NotEmpty(t, status.Reason)
+
+	interlock.UpdateClusterHealth(3, 4)
+	assert.False(t, interlock.Status().Engaged, "健康比例达到阈值后应解除联锁")
+}
+
+func TestSafetyInterlock_RecentLeaderChange(t *testing.T) {
+	interlock := rebalance.NewSafetyInterlock(0, 100*time.Millisecond)
+	interlock.UpdateClusterHealth(3, 3)
+
+	interlock.RecordLeaderChange(time.Now())
+	assert.True(t, interlock.Status().Engaged, "领导者刚变更时应阻止迁移")
+
+	time.Sleep(120 * time.Millisecond)
+	assert.False(t, interlock.Status().Engaged, "稳定期过后应解除联锁")
+}
+
+func TestManager_InterlockBlocksRebalance(t *testing.T) {
+	cfg := &metaconfig.LoadBalancerConfig{
+		EvaluationInterval:      time.Hour,
+		MaxConcurrentMigrations: 2,
+		MinHealthyRatio:         0.8,
+	}
+	m, err := rebalance.NewManager(cfg, logging.NewLogger())
+	require.NoError(t, err)
+	require.NoError(t, m.Start())
+	defer m.Stop()
+
+	feedImbalancedMetrics(m)
+
+	// 5个节点中只有3个健康，低于80%
+	m.UpdateClusterHealth(3, 5)
+	require.True(t, m.InterlockStatus().Engaged)
+
+	m.TriggerRebalance()
+	time.Sleep(100 * time.Millisecond)
+
+	status := m.GetStatus()
+	assert.Equal(t, 0, status["active_tasks_count"], "健康度不足时不应提交迁移任务")
+	assert.True(t, status["interlock"].(rebalance.InterlockStatus).Engaged)
+
+	// 健康恢复后再平衡正常进行
+	m.UpdateClusterHealth(5, 5)
+	assert.False(t, m.InterlockStatus().Engaged)
+	m.TriggerRebalance()
+
+	assert.Eventually(t, func() bool {
+		return m.GetStatus()["active_tasks_count"].(int) > 0
+	}, time.Second, 10*time.Millisecond, "健康恢复后应提交迁移任务")
+}
@@ -0,0 +1,128 @@
+package rebalance_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/common/types"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mixedDiskMetrics 两个SSD节点和两个HDD节点，各自组内负载不均
+func mixedDiskMetrics() map[string]*types.NodeMetrics {
+	ssd := map[string]string{types.LabelDiskType: "ssd"}
+	hdd := map[string]string{types.LabelDiskType: "hdd"}
+	return map[string]*types.NodeMetrics{
+		"ssd-hot":  {NodeID: "ssd-hot", DiskUsageRatio: 0.9, ShardCount: 40, Labels: ssd},
+		"ssd-cold": {NodeID: "ssd-cold", DiskUsageRatio: 0.5, ShardCount: 20, Labels: ssd},
+		"hdd-hot":  {NodeID: "hdd-hot", DiskUsageRatio: 0.6, ShardCount: 40, Labels: hdd},
+		"hdd-cold": {NodeID: "hdd-cold", DiskUsageRatio: 0.05, ShardCount: 5, Labels: hdd},
+	}
+}
+
+func TestLabelConstrainedStrategy_KeepsMigrationsWithinGroup(t *testing.T) {
+	metrics := mixedDiskMetrics()
+
+	// 无约束时最热节点的分片会迁往最空闲的HDD节点
+	plans, err := rebalance.NewCapacityBalanceStrategy(10).GeneratePlan(metrics)
+	require.NoError(t, err)
+	require.NotEmpty(t, plans)
+	assert.Equal(t, types.NodeID("hdd-cold"), plans[0].TargetNodeID)
+
+	constrained := rebalance.NewLabelConstrainedStrategy(
+		rebalance.NewCapacityBalanceStrategy(10), []string{types.LabelDiskType}, nil)
+
+	plans, err = constrained.GeneratePlan(metrics)
+	require.NoError(t, err)
+	require.Len(t, plans, 2, "每个磁盘类型分组各生成一个计划")
+	for _, plan := range plans {
+		source := metrics[string(plan.SourceNodeID)].Labels[types.LabelDiskType]
+		target := metrics[string(plan.TargetNodeID)].Labels[types.LabelDiskType]
+		assert.Equal(t, source, target, "迁移不应跨越磁盘类型: %s -> %s", plan.SourceNodeID, plan.TargetNodeID)
+	}
+
+	evaluation := rebalance.EvaluateStrategy(constrained, metrics)
+	assert.True(t, evaluation.NeedRebalance)
+	assert.Equal(t, rebalance.StrategyNameCapacity, evaluation.Strategy)
+	assert.Contains(t, evaluation.Source, types.LabelDiskType+"=")
+}
+
+func TestLabelConstrainedStrategy_TargetSelector(t *testing.T) {
+	metrics := mixedDiskMetrics()
+	selector, err := types.ParseLabelSelector("disk-type=ssd")
+	require.NoError(t, err)
+
+	constrained := rebalance.NewLabelConstrainedStrategy(
+		rebalance.NewCapacityBalanceStrategy(10), []string{types.LabelDiskType}, selector)
+
+	plans, err := constrained.GeneratePlan(metrics)
+	require.NoError(t, err)
+	require.Len(t, plans, 1, "只保留目标为SSD节点的计划")
+	assert.Equal(t, types.NodeID("ssd-cold"), plans[0].TargetNodeID)
+}
+
+func TestLabelConstrainedStrategy_SelectsEligibleTarget(t *testing.T) {
+	// 最空闲的节点不满足选择器，约束在配对时生效，应改选满足选择器的节点而不是放弃迁移
+	metrics := map[string]*types.NodeMetrics{
+		"hdd-hot":  {NodeID: "hdd-hot", DiskUsageRatio: 0.9, ShardCount: 40, Labels: map[string]string{types.LabelDiskType: "hdd"}},
+		"ssd-mid":  {NodeID: "ssd-mid", DiskUsageRatio: 0.3, ShardCount: 10, Labels: map[string]string{types.LabelDiskType: "ssd"}},
+		"hdd-cold": {NodeID: "hdd-cold", DiskUsageRatio: 0.05, ShardCount: 5, Labels: map[string]string{types.LabelDiskType: "hdd"}},
+	}
+	selector, err := types.ParseLabelSelector("disk-type=ssd")
+	require.NoError(t, err)
+
+	for _, inner := range []rebalance.BalanceStrategy{
+		rebalance.NewCapacityBalanceStrategy(10),
+		rebalance.NewCompositeStrategy([]rebalance.BalanceStrategy{rebalance.NewCapacityBalanceStrategy(10)}, nil),
+	} {
+		plans, err := rebalance.NewLabelConstrainedStrategy(inner, nil, selector).GeneratePlan(metrics)
+		require.NoError(t, err)
+		require.Len(t, plans, 1)
+		assert.Equal(t, types.NodeID("hdd-hot"), plans[0].SourceNodeID)
+		assert.Equal(t, types.NodeID("ssd-mid"), plans[0].TargetNodeID)
+	}
+}
+
+func TestManager_RespectsLabelConstraintFromConfig(t *testing.T) {
+	m, err := rebalance.NewManager(&metaconfig.LoadBalancerConfig{
+		EvaluationInterval:      time.Hour,
+		MaxConcurrentMigrations: 2,
+		LabelGroupKeys:          []string{types.LabelDiskType},
+		TargetSelector:          "disk-type=ssd",
+	}, logging.NewLogger())
+	require.NoError(t, err)
+
+	for nodeID, metrics := range mixedDiskMetrics() {
+		m.UpdateNodeMetrics(nodeID, metrics)
+	}
+	m.SetStrategy(rebalance.NewCapacityBalanceStrategy(10))
+	require.NoError(t, m.Start())
+	defer m.Stop()
+
+	m.TriggerRebalance()
+	require.Eventually(t, func() bool {
+		return m.GetStatus()["active_tasks_count"].(int) > 0
+	}, time.Second, 10*time.Millisecond)
+
+	for _, task := range m.GetStatus()["active_tasks"].([]*rebalance.MigrationTask) {
+		assert.Equal(t, "ssd", m.GetNodeMetrics(string(task.Plan.TargetNodeID)).Labels[types.LabelDiskType],
+			"迁移目标必须满足标签选择器")
+	}
+
+	// 标签随指标一起保存，并返回独立副本
+	labels := m.GetNodeMetrics("ssd-hot").Labels
+	labels[types.LabelDiskType] = "changed"
+	assert.Equal(t, "ssd", m.GetNodeMetrics("ssd-hot").Labels[types.LabelDiskType])
+}
+
+func TestNewManager_InvalidTargetSelector(t *testing.T) {
+	_, err := rebalance.NewManager(&metaconfig.LoadBalancerConfig{
+		EvaluationInterval: time.Hour,
+		TargetSelector:     "=ssd",
+	}, logging.NewLogger())
+	assert.Error(t, err)
+}
@@ -0,0 +1,95 @@
+package rebalance_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrator_ReportsByteProgress(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	migrator := rebalance.NewMigrator(ctx, 1, logging.NewLogger(),
+		rebalance.WithShardMigrationTime(100*time.Millisecond),
+		rebalance.WithProgressInterval(10*time.Millisecond),
+	)
+	migrator.Start()
+	// 迁移器随上下文取消而退出
+	defer func() {
+		cancel()
+		migrator.Stop()
+	}()
+
+	const totalBytes = 3 * 1000
+	taskIDs := migrator.SubmitTasks([]*rebalance.MigrationPlan{{
+		PlanID:         "plan-1",
+		SourceNodeID:   "node-1",
+		TargetNodeID:   "node-2",
+		ShardIDs:       []string{"s1", "s2", "s3"},
+		EstimatedBytes: totalBytes,
+	}}, 0)
+	require.Len(t, taskIDs, 1)
+
+	task, ok := migrator.GetTaskStatus(taskIDs[0])
+	require.True(t, ok)
+	assert.Equal(t, uint64(totalBytes), task.TotalBytes)
+	assert.Equal(t, uint64(0), task.BytesTransferred)
+
+	// 轮询直到完成，记录观察到的进度
+	var samples []uint64
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		task, _ = migrator.GetTaskStatus(taskIDs[0])
+		samples = append(samples, task.BytesTransferred)
+		if task.State == rebalance.TaskStateCompleted {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	require.Equal(t, rebalance.TaskStateCompleted, task.State)
+	assert.Equal(t, uint64(totalBytes), task.BytesTransferred)
+	assert.Equal(t, 100.0, task.Progress)
+
+	// 进度单调递增，且完成前能观察到中间值
+	var intermediate int
+	for i, v := range samples {
+		if i > 0 {
+			assert.GreaterOrEqual(t, v, samples[i-1], "进度不应回退")
+		}
+		if v > 0 && v < totalBytes {
+			intermediate++
+		}
+	}
+	assert.Greater(t, intermediate, 2, "迁移过程中应能观察到逐步增长的进度")
+}
+
+func TestMigrator_ProgressStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	migrator := rebalance.NewMigrator(ctx, 1, logging.NewLogger(),
+		rebalance.WithShardMigrationTime(time.Second),
+		rebalance.WithProgressInterval(10*time.Millisecond),
+	)
+	migrator.Start()
+
+	taskIDs := migrator.SubmitTasks([]*rebalance.MigrationPlan{{
+		ShardIDs:       []string{"s1", "s2"},
+		EstimatedBytes: 2000,
+	}}, 0)
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	migrator.Stop()
+
+	task, ok := migrator.GetTaskStatus(taskIDs[0])
+	require.True(t, ok)
+	assert.Equal(t, rebalance.TaskStateFailed, task.State)
+	assert.Less(t, task.BytesTransferred, task.TotalBytes)
+	assert.Less(t, task.Progress, 100.0)
+}
@@ -0,0 +1,80 @@
+package rebalance_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/common/types"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMinNodesManager 创建要求至少minNodes个节点才再平衡的管理器，并写入count个磁盘使用率差异很大的节点
+func newMinNodesManager(t *testing.T, minNodes, count int) *rebalance.Manager {
+	t.Helper()
+	m, err := rebalance.NewManager(&metaconfig.LoadBalancerConfig{
+		EvaluationInterval:      time.Hour,
+		MaxConcurrentMigrations: 4,
+		MinNodesForRebalance:    minNodes,
+	}, logging.NewLogger())
+	require.NoError(t, err)
+	m.SetStrategy(rebalance.NewCapacityBalanceStrategy(10))
+
+	for i := 0; i < count; i++ {
+		nodeID := fmt.Sprintf("node-%d", i)
+		m.UpdateNodeMetrics(nodeID, &types.NodeMetrics{
+			NodeID:         types.NodeID(nodeID),
+			DiskUsageRatio: 0.1 + 0.8*float64(i)/float64(count-1),
+			ShardCount:     10 + 80*i,
+		})
+	}
+	return m
+}
+
+func TestManager_NoRebalanceBelowMinNodes(t *testing.T) {
+	m := newMinNodesManager(t, 3, 2)
+
+	evaluation := m.Evaluate()
+	assert.True(t, evaluation.BelowMinNodes)
+	assert.False(t, evaluation.NeedRebalance)
+	assert.Equal(t, 2, evaluation.NodeCount)
+	assert.Equal(t, rebalance.StrategyNameCapacity, evaluation.Strategy)
+	assert.Equal(t, true, m.GetStatus()["is_balanced"])
+
+	require.NoError(t, m.Start())
+	defer m.Stop()
+
+	m.TriggerRebalance()
+	assert.Never(t, func() bool {
+		return m.GetStatus()["active_tasks_count"].(int) > 0
+	}, 300*time.Millisecond, 10*time.Millisecond, "节点数不足时不应生成迁移任务")
+}
+
+func TestManager_RebalancesAtMinNodes(t *testing.T) {
+	m := newMinNodesManager(t, 3, 3)
+
+	evaluation := m.Evaluate()
+	assert.False(t, evaluation.BelowMinNodes)
+	assert.True(t, evaluation.NeedRebalance)
+	assert.Equal(t, 3, evaluation.NodeCount)
+
+	require.NoError(t, m.Start())
+	defer m.Stop()
+
+	m.TriggerRebalance()
+	require.Eventually(t, func() bool {
+		return m.GetStatus()["active_tasks_count"].(int) > 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestManager_MinNodesDisabledByDefault(t *testing.T) {
+	m := newMinNodesManager(t, 0, 2)
+
+	evaluation := m.Evaluate()
+	assert.False(t, evaluation.BelowMinNodes)
+	assert.True(t, evaluation.NeedRebalance, "未配置最少节点数时两个节点也会评估")
+}
@@ -0,0 +1,46 @@
+package rebalance_test
+
+import (
+	"testing"
+	"unicode"
+
+	"github.com/22827099/DFS_v1/common/types"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrategies_PlaceholderShardIDsDistinctAndPrintable(t *testing.T) {
+	// 热节点有2000个分片，各策略都会一次迁移数百个分片，序号远超127
+	metrics := map[string]*types.NodeMetrics{
+		"hot":  {NodeID: "hot", CPUUsagePercent: 90, MemoryUsageBytes: 8 << 30, DiskUsageRatio: 0.9, ShardCount: 2000},
+		"cold": {NodeID: "cold", CPUUsagePercent: 10, MemoryUsageBytes: 1 << 30, DiskUsageRatio: 0.1},
+	}
+	strategies := map[string]rebalance.BalanceStrategy{
+		"weighted_score":   rebalance.NewWeightedScoreStrategy(0.4, 0.2, 0.2, 0.2),
+		"capacity":         rebalance.NewCapacityBalanceStrategy(20),
+		"access_frequency": rebalance.NewAccessFrequencyStrategy(20),
+	}
+
+	for name, strategy := range strategies {
+		t.Run(name, func(t *testing.T) {
+			plans, err := strategy.GeneratePlan(metrics)
+			require.NoError(t, err)
+			require.NotEmpty(t, plans)
+
+			seen := make(map[string]bool)
+			for _, plan := range plans {
+				require.Greater(t, len(plan.ShardIDs), 255, "应迁移足够多的分片以覆盖序号超过255的情况")
+				for _, shardID := range plan.ShardIDs {
+					assert.False(t, seen[shardID], "分片ID重复: %q", shardID)
+					seen[shardID] = true
+					for _, r := range shardID {
+						if !assert.True(t, r < unicode.MaxASCII && unicode.IsPrint(r), "分片ID包含不可打印字符: %q", shardID) {
+							break
+						}
+					}
+				}
+			}
+		})
+	}
+}
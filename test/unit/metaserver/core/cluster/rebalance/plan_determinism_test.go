@@ -0,0 +1,116 @@
+package rebalance_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/types"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// planSummary 迁移计划中除随机PlanID之外的内容
+type planSummary struct {
+	Source, Target types.NodeID
+	ShardIDs       []string
+	Priority       int
+}
+
+func summarizePlans(plans []*rebalance.MigrationPlan) []planSummary {
+	summaries := make([]planSummary, len(plans))
+	for i, plan := range plans {
+		summaries[i] = planSummary{plan.SourceNodeID, plan.TargetNodeID, plan.ShardIDs, plan.Priority}
+	}
+	return summaries
+}
+
+// tiedNodeMetrics 返回heavy个指标完全相同的高负载节点和light个指标完全相同的低负载节点，
+// 节点ID交错，按ID排序为 heavy-0, heavy-1, ..., light-0, light-1, ...
+func tiedNodeMetrics(heavy, light int) map[string]*types.NodeMetrics {
+	metrics := make(map[string]*types.NodeMetrics, heavy+light)
+	for i := 0; i < heavy; i++ {
+		nodeID := fmt.Sprintf("heavy-%d", i)
+		metrics[nodeID] = &types.NodeMetrics{
+			NodeID: types.NodeID(nodeID), CPUUsagePercent: 90, MemoryUsageBytes: 8 << 30, DiskUsageRatio: 0.9, ShardCount: 400,
+		}
+	}
+	for i := 0; i < light; i++ {
+		nodeID := fmt.Sprintf("light-%d", i)
+		metrics[nodeID] = &types.NodeMetrics{
+			NodeID: types.NodeID(nodeID), CPUUsagePercent: 10, MemoryUsageBytes: 1 << 30, DiskUsageRatio: 0.1, ShardCount: 40,
+		}
+	}
+	return metrics
+}
+
+func newPlanStrategies() map[string]rebalance.BalanceStrategy {
+	return map[string]rebalance.BalanceStrategy{
+		rebalance.StrategyNameWeightedScore:   rebalance.NewWeightedScoreStrategy(0.4, 0.2, 0.2, 0.2),
+		rebalance.StrategyNameCapacity:        rebalance.NewCapacityBalanceStrategy(20),
+		rebalance.StrategyNameAccessFrequency: rebalance.NewAccessFrequencyStrategy(20),
+	}
+}
+
+func TestGeneratePlan_EqualScoresDeterministic(t *testing.T) {
+	for name, strategy := range newPlanStrategies() {
+		t.Run(name, func(t *testing.T) {
+			first, err := strategy.GeneratePlan(tiedNodeMetrics(3, 3))
+			require.NoError(t, err)
+			require.Len(t, first, 2)
+
+			// 得分相同的节点按ID排序：最重的heavy-0迁往排在最后的light-2，其次heavy-1迁往light-1
+			assert.Equal(t, types.NodeID("heavy-0"), first[0].SourceNodeID)
+			assert.Equal(t, types.NodeID("light-2"), first[0].TargetNodeID)
+			assert.Equal(t, types.NodeID("heavy-1"), first[1].SourceNodeID)
+			assert.Equal(t, types.NodeID("light-1"), first[1].TargetNodeID)
+
+			// 每次重新构建map使遍历顺序不同，计划内容应完全一致
+			for run := 0; run < 50; run++ {
+				plans, err := strategy.GeneratePlan(tiedNodeMetrics(3, 3))
+				require.NoError(t, err)
+				require.Equal(t, summarizePlans(first), summarizePlans(plans), "第%d次生成的计划不同", run)
+			}
+		})
+	}
+}
+
+func TestGeneratePlan_MaxPlansCapsPlanCount(t *testing.T) {
+	for name, strategy := range newPlanStrategies() {
+		t.Run(name, func(t *testing.T) {
+			uncapped, err := strategy.GeneratePlan(tiedNodeMetrics(6, 6))
+			require.NoError(t, err)
+			require.Greater(t, len(uncapped), 1)
+
+			rebalance.ApplyMaxPlans(strategy, 1)
+			capped, err := strategy.GeneratePlan(tiedNodeMetrics(6, 6))
+			require.NoError(t, err)
+			require.Len(t, capped, 1)
+			assert.Equal(t, summarizePlans(uncapped)[:1], summarizePlans(capped), "上限只截去优先级较低的计划")
+
+			// 0表示不额外限制
+			rebalance.ApplyMaxPlans(strategy, 0)
+			restored, err := strategy.GeneratePlan(tiedNodeMetrics(6, 6))
+			require.NoError(t, err)
+			assert.Len(t, restored, len(uncapped))
+		})
+	}
+}
+
+func TestGeneratePlan_MaxPlansCapsCompositeMerge(t *testing.T) {
+	strategies := newPlanStrategies()
+	composite := rebalance.NewCompositeStrategy([]rebalance.BalanceStrategy{
+		strategies[rebalance.StrategyNameWeightedScore],
+		strategies[rebalance.StrategyNameCapacity],
+	}, nil)
+	composite.SetShardLimit(10000)
+
+	uncapped, err := composite.GeneratePlan(tiedNodeMetrics(6, 6))
+	require.NoError(t, err)
+	require.Greater(t, len(uncapped), 2)
+
+	rebalance.ApplyMaxPlans(composite, 2)
+	capped, err := composite.GeneratePlan(tiedNodeMetrics(6, 6))
+	require.NoError(t, err)
+	assert.Len(t, capped, 2)
+}
@@ -0,0 +1,116 @@
+package rebalance_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/common/types"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// feedImbalancedMetrics 写入明显不均衡的节点指标，足以触发再平衡
+func feedImbalancedMetrics(m *rebalance.Manager) {
+	m.UpdateNodeMetrics("node-1", &types.NodeMetrics{NodeID: "node-1", CPUUsagePercent: 95, DiskUsageRatio: 0.9, ShardCount: 200})
+	m.UpdateNodeMetrics("node-2", &types.NodeMetrics{NodeID: "node-2", CPUUsagePercent: 5, DiskUsageRatio: 0.1, ShardCount: 10})
+	m.UpdateNodeMetrics("node-3", &types.NodeMetrics{NodeID: "node-3", CPUUsagePercent: 5, DiskUsageRatio: 0.1, ShardCount: 10})
+}
+
+func TestSlowStartConcurrency(t *testing.T) {
+	window := time.Minute
+	ramp := 4 * time.Minute
+
+	tests := []struct {
+		elapsed  time.Duration
+		expected int
+	}{
+		{0, 0},
+		{30 * time.Second, 0},
+		{time.Minute, 1},
+		{2 * time.Minute, 2},
+		{3 * time.Minute, 3},
+		{4 * time.Minute, 4},
+		{5 * time.Minute, 5},
+		{time.Hour, 5},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, rebalance.SlowStartConcurrency(tt.elapsed, window, ramp, 5),
+			"elapsed=%v", tt.elapsed)
+	}
+
+	// 爬坡过程中并发数单调递增，且每次最多增加1
+	prev := 0
+	for elapsed := time.Duration(0); elapsed <= window+ramp; elapsed += time.Second {
+		cur := rebalance.SlowStartConcurrency(elapsed, window, ramp, 5)
+		assert.GreaterOrEqual(t, cur, prev)
+		assert.LessOrEqual(t, cur-prev, 1, "elapsed=%v时并发数跳变", elapsed)
+		prev = cur
+	}
+
+	// 未配置慢启动时直接使用最大并发
+	assert.Equal(t, 5, rebalance.SlowStartConcurrency(0, 0, 0, 5))
+}
+
+func TestManager_NoPlansDuringSlowStartWindow(t *testing.T) {
+	cfg := &metaconfig.LoadBalancerConfig{
+		EvaluationInterval:      time.Hour,
+		MaxConcurrentMigrations: 2,
+		SlowStartWindow:         300 * time.Millisecond,
+	}
+	m, err := rebalance.NewManager(cfg, logging.NewLogger())
+	require.NoError(t, err)
+	require.NoError(t, m.Start())
+	defer m.Stop()
+
+	feedImbalancedMetrics(m)
+	assert.True(t, m.InSlowStartWindow())
+
+	m.TriggerRebalance()
+	time.Sleep(100 * time.Millisecond)
+
+	status := m.GetStatus()
+	assert.Equal(t, 0, status["active_tasks_count"], "慢启动窗口内不应生成迁移计划")
+	assert.Equal(t, true, status["slow_start"])
+
+	// 窗口结束后可以正常生成迁移计划
+	time.Sleep(300 * time.Millisecond)
+	assert.False(t, m.InSlowStartWindow())
+	m.TriggerRebalance()
+
+	assert.Eventually(t, func() bool {
+		return m.GetStatus()["active_tasks_count"].(int) > 0
+	}, time.Second, 10*time.Millisecond, "窗口结束后应提交迁移任务")
+}
+
+func TestManager_SlowStartRampsConcurrency(t *testing.T) {
+	cfg := &metaconfig.LoadBalancerConfig{
+		EvaluationInterval:      time.Hour,
+		MaxConcurrentMigrations: 4,
+		SlowStartWindow:         50 * time.Millisecond,
+		SlowStartRamp:           600 * time.Millisecond,
+	}
+	m, err := rebalance.NewManager(cfg, logging.NewLogger())
+	require.NoError(t, err)
+	require.NoError(t, m.Start())
+	defer m.Stop()
+
+	// 记录观察到的并发上限序列
+	var observed []int
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		limit := m.GetStatus()["concurrency_limit"].(int)
+		if len(observed) == 0 || observed[len(observed)-1] != limit {
+			observed = append(observed, limit)
+		}
+		if limit == cfg.MaxConcurrentMigrations {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	assert.Equal(t, []int{1, 2, 3, 4}, observed, "并发上限应逐级提升而非直接跳到最大值")
+	assert.Equal(t, false, m.GetStatus()["slow_start"])
+}
@@ -0,0 +1,67 @@
+package rebalance_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/common/types"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricCollector_FreshMetrics(t *testing.T) {
+	c := rebalance.NewMetricCollector()
+	c.UpdateNodeMetrics("old", &types.NodeMetrics{NodeID: "old"})
+	time.Sleep(60 * time.Millisecond)
+	c.UpdateNodeMetrics("new", &types.NodeMetrics{NodeID: "new"})
+
+	fresh := c.GetFreshMetrics(30 * time.Millisecond)
+	assert.Contains(t, fresh, "new")
+	assert.NotContains(t, fresh, "old")
+	assert.Equal(t, []string{"old"}, c.StaleNodes(30*time.Millisecond))
+
+	// 窗口为0时不做过期检查
+	assert.Len(t, c.GetFreshMetrics(0), 2)
+	assert.Empty(t, c.StaleNodes(0))
+
+	// 重新上报后恢复为新鲜
+	c.UpdateNodeMetrics("old", &types.NodeMetrics{NodeID: "old"})
+	assert.Len(t, c.GetFreshMetrics(30*time.Millisecond), 2)
+}
+
+func TestManager_ExcludesStaleNodesFromPlanning(t *testing.T) {
+	m, err := rebalance.NewManager(&metaconfig.LoadBalancerConfig{
+		EvaluationInterval:      time.Hour,
+		MaxConcurrentMigrations: 4,
+		MetricsStalenessWindow:  100 * time.Millisecond,
+	}, logging.NewLogger())
+	require.NoError(t, err)
+	m.SetStrategy(rebalance.NewCapacityBalanceStrategy(10))
+
+	// 空闲节点停止上报，否则它会是首选迁移目标
+	m.UpdateNodeMetrics("silent", &types.NodeMetrics{NodeID: "silent", DiskUsageRatio: 0.01, ShardCount: 1})
+	time.Sleep(150 * time.Millisecond)
+	m.UpdateNodeMetrics("hot", &types.NodeMetrics{NodeID: "hot", DiskUsageRatio: 0.9, ShardCount: 90})
+	m.UpdateNodeMetrics("warm", &types.NodeMetrics{NodeID: "warm", DiskUsageRatio: 0.5, ShardCount: 50})
+	m.UpdateNodeMetrics("cool", &types.NodeMetrics{NodeID: "cool", DiskUsageRatio: 0.2, ShardCount: 20})
+
+	assert.Equal(t, []string{"silent"}, m.StaleNodes())
+	assert.Equal(t, []string{"silent"}, m.GetStatus()["stale_nodes"])
+	assert.Equal(t, 3, m.Evaluate().NodeCount, "过期节点不参与评估")
+
+	require.NoError(t, m.Start())
+	defer m.Stop()
+
+	m.TriggerRebalance()
+	require.Eventually(t, func() bool {
+		return m.GetStatus()["active_tasks_count"].(int) > 0
+	}, time.Second, 10*time.Millisecond)
+
+	for _, task := range m.GetStatus()["active_tasks"].([]*rebalance.MigrationTask) {
+		assert.NotEqual(t, types.NodeID("silent"), task.Plan.SourceNodeID)
+		assert.NotEqual(t, types.NodeID("silent"), task.Plan.TargetNodeID, "过期节点不能作为迁移目标")
+	}
+}
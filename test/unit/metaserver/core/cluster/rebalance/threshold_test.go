@@ -0,0 +1,92 @@
+package rebalance_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/common/types"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newIdleManager 创建不会自动评估的管理器，并写入count个节点的指标
+func newIdleManager(t *testing.T, cfg *metaconfig.LoadBalancerConfig, count int) *rebalance.Manager {
+	t.Helper()
+	cfg.EvaluationInterval = time.Hour
+	m, err := rebalance.NewManager(cfg, logging.NewLogger())
+	require.NoError(t, err)
+
+	for i := 0; i < count; i++ {
+		nodeID := fmt.Sprintf("node-%d", i)
+		m.UpdateNodeMetrics(nodeID, &types.NodeMetrics{
+			NodeID:          types.NodeID(nodeID),
+			CPUUsagePercent: float64(10 + 20*i),
+			DiskUsageRatio:  0.2 + 0.1*float64(i),
+			ShardCount:      10 + 10*i,
+		})
+	}
+	return m
+}
+
+func TestManager_StatusReportsEffectiveThreshold(t *testing.T) {
+	twoNodes := newIdleManager(t, &metaconfig.LoadBalancerConfig{ImbalanceThreshold: 20}, 2)
+	status := twoNodes.GetStatus()
+	assert.Equal(t, rebalance.StrategyNameWeightedScore, status["strategy"])
+	assert.Equal(t, 30.0, status["threshold"], "少于3个节点时阈值应提高到30")
+
+	fourNodes := newIdleManager(t, &metaconfig.LoadBalancerConfig{ImbalanceThreshold: 20}, 4)
+	status = fourNodes.GetStatus()
+	assert.Equal(t, 20.0, status["threshold"])
+
+	evaluation := status["evaluation"].(rebalance.StrategyEvaluation)
+	assert.Equal(t, 4, evaluation.NodeCount)
+	assert.Equal(t, evaluation.ImbalanceScore > evaluation.Threshold, evaluation.NeedRebalance)
+}
+
+func TestManager_PerStrategyThresholdOverride(t *testing.T) {
+	m := newIdleManager(t, &metaconfig.LoadBalancerConfig{
+		ImbalanceThreshold: 20,
+		StrategyThresholds: map[string]float64{
+			rebalance.StrategyNameWeightedScore: 40,
+			rebalance.StrategyNameCapacity:      10,
+		},
+	}, 4)
+	assert.Equal(t, 40.0, m.GetStatus()["threshold"])
+
+	// 替换为复合策略后，各子策略按名称应用阈值
+	m.SetStrategy(rebalance.NewCompositeStrategy([]rebalance.BalanceStrategy{
+		rebalance.NewWeightedScoreStrategy(0.4, 0.2, 0.2, 0.2),
+		rebalance.NewCapacityBalanceStrategy(0),
+		rebalance.NewAccessFrequencyStrategy(0),
+	}, nil))
+
+	evaluation := m.Evaluate()
+	require.Len(t, evaluation.Components, 3)
+	assert.Equal(t, 40.0, evaluation.Components[0].Threshold)
+	assert.Equal(t, 10.0, evaluation.Components[1].Threshold)
+	assert.Equal(t, 20.0, evaluation.Components[2].Threshold)
+}
+
+func TestCompositeStrategy_ReportsSource(t *testing.T) {
+	metrics := map[string]*types.NodeMetrics{
+		"a": {CPUUsagePercent: 50, DiskUsageRatio: 0.9, ShardCount: 10},
+		"b": {CPUUsagePercent: 50, DiskUsageRatio: 0.1, ShardCount: 10},
+		"c": {CPUUsagePercent: 50, DiskUsageRatio: 0.1, ShardCount: 10},
+	}
+
+	// CPU均衡，只有容量策略会触发
+	composite := rebalance.NewCompositeStrategy([]rebalance.BalanceStrategy{
+		rebalance.NewAccessFrequencyStrategy(20),
+		rebalance.NewCapacityBalanceStrategy(15),
+	}, nil)
+
+	evaluation := rebalance.EvaluateStrategy(composite, metrics)
+	assert.True(t, evaluation.NeedRebalance)
+	assert.Equal(t, rebalance.StrategyNameComposite, evaluation.Strategy)
+	assert.Equal(t, rebalance.StrategyNameCapacity, evaluation.Source)
+	assert.Equal(t, 15.0, evaluation.Threshold)
+}
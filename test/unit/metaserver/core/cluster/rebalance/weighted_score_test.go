@@ -0,0 +1,130 @@
+package rebalance_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/types"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试使用的加权得分策略权重
+const (
+	testCPUWeight    = 0.4
+	testMemoryWeight = 0.2
+	testDiskWeight   = 0.2
+	testShardWeight  = 0.2
+)
+
+// randomNodeMetrics 生成count个节点的随机指标，相同的seed生成相同的指标
+func randomNodeMetrics(count int, seed int64) map[string]*types.NodeMetrics {
+	rng := rand.New(rand.NewSource(seed))
+	metrics := make(map[string]*types.NodeMetrics, count)
+	for i := 0; i < count; i++ {
+		nodeID := fmt.Sprintf("node-%d", i)
+		metrics[nodeID] = &types.NodeMetrics{
+			NodeID:           types.NodeID(nodeID),
+			CPUUsagePercent:  rng.Float64() * 100,
+			MemoryUsageBytes: uint64(rng.Int63n(64 << 30)),
+			DiskUsageRatio:   rng.Float64(),
+			ShardCount:       rng.Intn(5000),
+		}
+	}
+	return metrics
+}
+
+// referenceNodeScore 逐节点重新计算集群平均分片数的原始实现，作为得分的基准
+func referenceNodeScore(metrics *types.NodeMetrics, allMetrics map[string]*types.NodeMetrics) float64 {
+	avgShards := 0.0
+	for _, m := range allMetrics {
+		avgShards += float64(m.ShardCount)
+	}
+	avgShards /= float64(len(allMetrics))
+
+	normalizedShards := 0.0
+	if avgShards > 0 {
+		normalizedShards = float64(metrics.ShardCount) / avgShards
+	}
+	return testCPUWeight*metrics.CPUUsagePercent +
+		testMemoryWeight*(float64(metrics.MemoryUsageBytes)/float64(1<<30)) +
+		testDiskWeight*metrics.DiskUsageRatio*100 +
+		testShardWeight*normalizedShards*100.0
+}
+
+// referenceImbalance 以原始实现的得分计算变异系数
+func referenceImbalance(allMetrics map[string]*types.NodeMetrics) float64 {
+	scores := make([]float64, 0, len(allMetrics))
+	var sum float64
+	for _, metrics := range allMetrics {
+		score := referenceNodeScore(metrics, allMetrics)
+		scores = append(scores, score)
+		sum += score
+	}
+	avg := sum / float64(len(scores))
+	var squaredDiffSum float64
+	for _, score := range scores {
+		squaredDiffSum += (score - avg) * (score - avg)
+	}
+	return math.Sqrt(squaredDiffSum/float64(len(scores))) / avg * 100.0
+}
+
+func TestWeightedScore_ScoresMatchReference(t *testing.T) {
+	strategy := rebalance.NewWeightedScoreStrategy(testCPUWeight, testMemoryWeight, testDiskWeight, testShardWeight)
+
+	for _, count := range []int{2, 3, 50, 1000} {
+		metrics := randomNodeMetrics(count, int64(count))
+		scores := strategy.NodeScores(metrics)
+		require.Len(t, scores, count)
+		for nodeID, m := range metrics {
+			assert.Equal(t, referenceNodeScore(m, metrics), scores[nodeID], "节点%s的得分", nodeID)
+		}
+
+		// 变异系数的求和顺序随map遍历顺序变化，只比较到浮点误差范围内
+		result := strategy.EvaluateDetail(metrics)
+		assert.InEpsilon(t, referenceImbalance(metrics), result.ImbalanceScore, 1e-9)
+	}
+}
+
+func TestWeightedScore_ZeroShardsScoresWithoutShardTerm(t *testing.T) {
+	strategy := rebalance.NewWeightedScoreStrategy(testCPUWeight, testMemoryWeight, testDiskWeight, testShardWeight)
+	metrics := map[string]*types.NodeMetrics{
+		"a": {CPUUsagePercent: 50},
+		"b": {CPUUsagePercent: 10, DiskUsageRatio: 0.5},
+	}
+	scores := strategy.NodeScores(metrics)
+	assert.Equal(t, testCPUWeight*50, scores["a"])
+	assert.Equal(t, testCPUWeight*10+testDiskWeight*50, scores["b"])
+}
+
+func BenchmarkWeightedScore_Evaluate1000(b *testing.B) {
+	strategy := rebalance.NewWeightedScoreStrategy(testCPUWeight, testMemoryWeight, testDiskWeight, testShardWeight)
+	metrics := randomNodeMetrics(1000, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		strategy.EvaluateDetail(metrics)
+	}
+}
+
+// BenchmarkWeightedScore_ReferenceEvaluate1000 逐节点重新计算平均分片数的原始实现，用于对比
+func BenchmarkWeightedScore_ReferenceEvaluate1000(b *testing.B) {
+	metrics := randomNodeMetrics(1000, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		referenceImbalance(metrics)
+	}
+}
+
+func BenchmarkWeightedScore_GeneratePlan1000(b *testing.B) {
+	strategy := rebalance.NewWeightedScoreStrategy(testCPUWeight, testMemoryWeight, testDiskWeight, testShardWeight)
+	metrics := randomNodeMetrics(1000, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := strategy.GeneratePlan(metrics); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
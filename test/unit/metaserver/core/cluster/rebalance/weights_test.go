@@ -0,0 +1,99 @@
+package rebalance_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/types"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/rebalance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoreWeights_Normalize(t *testing.T) {
+	normalized, err := rebalance.ScoreWeights{CPU: 2, Memory: 1, Disk: 1}.Normalize()
+	require.NoError(t, err)
+	assert.Equal(t, rebalance.ScoreWeights{CPU: 0.5, Memory: 0.25, Disk: 0.25}, normalized)
+
+	for name, weights := range map[string]rebalance.ScoreWeights{
+		"负数":   {CPU: 1, Memory: -0.5},
+		"全部为0": {},
+		"NaN":  {CPU: math.NaN()},
+		"无穷大":  {Disk: math.Inf(1)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := weights.Normalize()
+			assert.ErrorIs(t, err, rebalance.ErrInvalidWeights)
+		})
+	}
+}
+
+func TestManager_WeightsDefaultsAndUpdate(t *testing.T) {
+	m := newIdleManager(t, &metaconfig.LoadBalancerConfig{}, 0)
+
+	weights, err := m.Weights()
+	require.NoError(t, err)
+	assert.Equal(t, rebalance.DefaultScoreWeights(), weights)
+
+	applied, err := m.UpdateWeights(rebalance.ScoreWeights{CPU: 1, Memory: 1, Disk: 1, Shard: 1})
+	require.NoError(t, err)
+	assert.Equal(t, rebalance.ScoreWeights{CPU: 0.25, Memory: 0.25, Disk: 0.25, Shard: 0.25}, applied)
+
+	weights, err = m.Weights()
+	require.NoError(t, err)
+	assert.Equal(t, applied, weights)
+
+	// 无效的权重被拒绝，当前权重保持不变
+	_, err = m.UpdateWeights(rebalance.ScoreWeights{CPU: -1, Shard: 2})
+	assert.ErrorIs(t, err, rebalance.ErrInvalidWeights)
+	weights, err = m.Weights()
+	require.NoError(t, err)
+	assert.Equal(t, applied, weights)
+}
+
+func TestManager_NextEvaluationUsesUpdatedWeights(t *testing.T) {
+	m := newIdleManager(t, &metaconfig.LoadBalancerConfig{ImbalanceThreshold: 20}, 0)
+	// CPU使用率差异很大，分片数相同
+	for i := 0; i < 3; i++ {
+		nodeID := fmt.Sprintf("node-%d", i)
+		m.UpdateNodeMetrics(nodeID, &types.NodeMetrics{
+			NodeID:          types.NodeID(nodeID),
+			CPUUsagePercent: float64(10 + 40*i),
+			ShardCount:      20,
+		})
+	}
+
+	before := m.Evaluate()
+	assert.True(t, before.NeedRebalance)
+	assert.Greater(t, before.ImbalanceScore, 20.0)
+
+	// 只看分片数时各节点得分相同
+	_, err := m.UpdateWeights(rebalance.ScoreWeights{Shard: 5})
+	require.NoError(t, err)
+
+	after := m.Evaluate()
+	assert.False(t, after.NeedRebalance)
+	assert.InDelta(t, 0, after.ImbalanceScore, 1e-9)
+}
+
+func TestManager_WeightsOfCompositeStrategy(t *testing.T) {
+	m := newIdleManager(t, &metaconfig.LoadBalancerConfig{}, 0)
+	weighted := rebalance.NewWeightedScoreStrategy(0.4, 0.2, 0.2, 0.2)
+	m.SetStrategy(rebalance.NewCompositeStrategy([]rebalance.BalanceStrategy{
+		rebalance.NewCapacityBalanceStrategy(0),
+		weighted,
+	}, []float64{1, 1}))
+
+	_, err := m.UpdateWeights(rebalance.ScoreWeights{CPU: 1})
+	require.NoError(t, err)
+	assert.Equal(t, rebalance.ScoreWeights{CPU: 1}, weighted.Weights())
+
+	// 策略中没有加权得分策略时无法读取或调整权重
+	m.SetStrategy(rebalance.NewCapacityBalanceStrategy(0))
+	_, err = m.Weights()
+	assert.ErrorIs(t, err, rebalance.ErrNoWeightedStrategy)
+	_, err = m.UpdateWeights(rebalance.ScoreWeights{CPU: 1})
+	assert.ErrorIs(t, err, rebalance.ErrNoWeightedStrategy)
+}
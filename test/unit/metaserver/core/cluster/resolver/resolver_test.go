@@ -0,0 +1,206 @@
+package resolver_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/internal/metaserver/core/cluster/resolver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLookup 返回可在测试中修改的查找结果
+type fakeLookup struct {
+	mu    sync.Mutex
+	addrs map[string]string
+	err   error
+	calls int
+}
+
+func (f *fakeLookup) set(addrs map[string]string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.addrs, f.err = addrs, err
+}
+
+func (f *fakeLookup) lookup(ctx context.Context) (map[string]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return f.addrs, f.err
+}
+
+func (f *fakeLookup) callCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func TestStatic_Resolve(t *testing.T) {
+	r := resolver.Static{"a": "10.0.0.1:8080", "empty": ""}
+
+	addr, err := r.Resolve("a")
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.1:8080", addr)
+
+	_, err = r.Resolve("empty")
+	assert.ErrorIs(t, err, resolver.ErrUnknownNode)
+	_, err = r.Resolve("missing")
+	assert.ErrorIs(t, err, resolver.ErrUnknownNode)
+}
+
+func TestChain_FallsBackInOrder(t *testing.T) {
+	primary := resolver.Static{"a": "dns-a:8080"}
+	fallback := resolver.Static{"a": "static-a:8080", "b": "static-b:8080"}
+	r := resolver.Chain(nil, primary, fallback)
+
+	addr, err := r.Resolve("a")
+	require.NoError(t, err)
+	assert.Equal(t, "dns-a:8080", addr, "应优先使用前面的解析器")
+
+	addr, err = r.Resolve("b")
+	require.NoError(t, err)
+	assert.Equal(t, "static-b:8080", addr)
+
+	_, err = r.Resolve("c")
+	assert.ErrorIs(t, err, resolver.ErrUnknownNode)
+}
+
+func TestRefreshing_UsesLatestLookup(t *testing.T) {
+	lookup := &fakeLookup{addrs: map[string]string{"a": "10.0.0.1:8080"}}
+	r := resolver.NewRefreshing(lookup.lookup, time.Hour)
+
+	require.NoError(t, r.Refresh(context.Background()))
+	addr, err := r.Resolve("a")
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.1:8080", addr)
+
+	// 节点被重新调度到新的IP
+	lookup.set(map[string]string{"a": "10.0.0.9:8080", "b": "10.0.0.2:8080"}, nil)
+	require.NoError(t, r.Refresh(context.Background()))
+	addr, _ = r.Resolve("a")
+	assert.Equal(t, "10.0.0.9:8080", addr)
+	addr, _ = r.Resolve("b")
+	assert.Equal(t, "10.0.0.2:8080", addr)
+
+	// 从结果中消失的节点不再可解析
+	lookup.set(map[string]string{"b": "10.0.0.2:8080"}, nil)
+	require.NoError(t, r.Refresh(context.Background()))
+	_, err = r.Resolve("a")
+	assert.ErrorIs(t, err, resolver.ErrUnknownNode)
+}
+
+func TestRefreshing_KeepsLastGoodOnFailure(t *testing.T) {
+	lookup := &fakeLookup{addrs: map[string]string{"a": "10.0.0.1:8080"}}
+	r := resolver.NewRefreshing(lookup.lookup, time.Hour)
+	require.NoError(t, r.Refresh(context.Background()))
+	refreshedAt, lastErr := r.Status()
+	require.NoError(t, lastErr)
+
+	lookup.set(nil, errors.New("dns unavailable"))
+	assert.Error(t, r.Refresh(context.Background()))
+	addr, err := r.Resolve("a")
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.1:8080", addr, "查找失败时应保留上一次的地址")
+
+	lookup.set(map[string]string{}, nil)
+	assert.Error(t, r.Refresh(context.Background()), "空结果应视为失败")
+	addr, _ = r.Resolve("a")
+	assert.Equal(t, "10.0.0.1:8080", addr)
+
+	at, lastErr := r.Status()
+	assert.Error(t, lastErr)
+	assert.Equal(t, refreshedAt, at, "失败的刷新不应更新最近成功刷新的时间")
+}
+
+func TestRefreshing_RefreshesPeriodically(t *testing.T) {
+	lookup := &fakeLookup{err: errors.New("not yet")}
+	failures := make(chan error, 100)
+	r := resolver.NewRefreshing(lookup.lookup, 10*time.Millisecond,
+		resolver.WithRefreshErrorHandler(func(err error) { failures <- err }))
+
+	r.Start()
+	defer r.Stop()
+	select {
+	case err := <-failures:
+		assert.Error(t, err, "首次刷新失败应通知回调")
+	case <-time.After(time.Second):
+		t.Fatal("首次刷新应在启动后立即进行")
+	}
+
+	lookup.set(map[string]string{"a": "10.0.0.1:8080"}, nil)
+	require.Eventually(t, func() bool {
+		addr, err := r.Resolve("a")
+		return err == nil && addr == "10.0.0.1:8080"
+	}, time.Second, 5*time.Millisecond, "后台刷新应在失败后重试")
+
+	r.Stop()
+	calls := lookup.callCount()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, calls, lookup.callCount(), "停止后不应再刷新")
+}
+
+func TestRefreshing_StartDoesNotWaitForSlowLookup(t *testing.T) {
+	blocked := func(ctx context.Context) (map[string]string, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	r := resolver.NewRefreshing(blocked, time.Hour, resolver.WithLookupTimeout(200*time.Millisecond))
+
+	start := time.Now()
+	r.Start()
+	defer r.Stop()
+	assert.Less(t, time.Since(start), 100*time.Millisecond, "启动不应等待查找完成")
+
+	// 查找在超时后放弃
+	require.Eventually(t, func() bool {
+		_, err := r.Status()
+		return errors.Is(err, context.DeadlineExceeded)
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestAddressesFromSRV(t *testing.T) {
+	records := []*net.SRV{
+		{Target: "ms-0.metaserver.default.svc.cluster.local.", Port: 7000, Priority: 10, Weight: 5},
+		{Target: "ms-1.metaserver.default.svc.cluster.local.", Port: 7000, Priority: 10, Weight: 5},
+		// 同一节点的多条记录取优先级数值最小的
+		{Target: "ms-1.metaserver.default.svc.cluster.local.", Port: 7001, Priority: 0, Weight: 1},
+		{Target: "5.metaserver.local.", Port: 7005},
+		// 得不出节点ID的记录被忽略
+		{Target: "metaserver.local.", Port: 7002},
+		{Target: "0.metaserver.local.", Port: 7002},
+		{Target: ".", Port: 7003},
+	}
+
+	// 节点ID与Raft节点ID一致，可以直接用于解析Raft传输的目标地址
+	assert.Equal(t, map[string]string{
+		"1": "ms-0.metaserver.default.svc.cluster.local:7000",
+		"2": "ms-1.metaserver.default.svc.cluster.local:7001",
+		"5": "5.metaserver.local:7005",
+	}, resolver.AddressesFromSRV(records))
+}
+
+func TestNodeIDFromHost(t *testing.T) {
+	tests := []struct {
+		label string
+		want  string
+		ok    bool
+	}{
+		{"3", "3", true},
+		{"ms-0", "1", true},
+		{"meta-server-12", "13", true},
+		{"0", "", false},
+		{"metaserver", "", false},
+		{"ms-", "", false},
+		{"-1", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := resolver.NodeIDFromHost(tt.label)
+		assert.Equal(t, tt.ok, ok, tt.label)
+		assert.Equal(t, tt.want, got, tt.label)
+	}
+}
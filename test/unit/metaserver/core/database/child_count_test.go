@@ -0,0 +1,185 @@
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"testing"
+
+	"github.com/22827099/DFS_v1/internal/metaserver/core/database"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/namespace"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/models"
+	"github.com/22827099/DFS_v1/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rootDirID = testutil.RootDirID
+
+// newCountingDB 创建只有根目录的SQLite数据库
+func newCountingDB(t *testing.T) *database.Manager {
+	t.Helper()
+	return testutil.NewMetaDB(t)
+}
+
+// 以下辅助函数通过命名空间仓库修改子项，仓库在同一事务内维护父目录计数
+
+func mkdir(t *testing.T, db *database.Manager, id, parent int64) {
+	t.Helper()
+	testutil.Mkdir(t, db, id, parent, "d"+strconv.FormatInt(id, 10))
+}
+
+func createFile(t *testing.T, db *database.Manager, id, parent int64) {
+	t.Helper()
+	testutil.CreateFile(t, db, id, parent, "f"+strconv.FormatInt(id, 10))
+}
+
+// assertCountsMatchScan 逐个目录比较计数与实际扫描结果
+func assertCountsMatchScan(t *testing.T, db *database.Manager) {
+	t.Helper()
+	ctx := context.Background()
+	rows, err := db.QueryContext(ctx, "SELECT dir_id, child_count FROM directories")
+	require.NoError(t, err)
+	counts := map[int64]int64{}
+	for rows.Next() {
+		var id, count int64
+		require.NoError(t, rows.Scan(&id, &count))
+		counts[id] = count
+	}
+	require.NoError(t, rows.Err())
+	rows.Close()
+
+	for id, count := range counts {
+		var dirs, files int64
+		require.NoError(t, db.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM directories WHERE parent_id = ? AND is_deleted = FALSE", id).Scan(&dirs))
+		require.NoError(t, db.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM files WHERE parent_dir_id = ? AND is_deleted = FALSE", id).Scan(&files))
+		assert.Equal(t, dirs+files, count, "目录%d的子项计数与实际不符", id)
+	}
+}
+
+func childCount(t *testing.T, db *database.Manager, dirID int64) int64 {
+	t.Helper()
+	count, err := db.ChildCount(context.Background(), dirID)
+	require.NoError(t, err)
+	return count
+}
+
+func TestChildCount_TracksCreatesDeletesAndMoves(t *testing.T) {
+	db := newCountingDB(t)
+	assert.Equal(t, int64(0), childCount(t, db, rootDirID))
+
+	// /a(2) /b(3) /a/c(4)，/a 下两个文件
+	mkdir(t, db, 2, rootDirID)
+	mkdir(t, db, 3, rootDirID)
+	mkdir(t, db, 4, 2)
+	createFile(t, db, 100, 2)
+	createFile(t, db, 101, 2)
+	assert.Equal(t, int64(2), childCount(t, db, rootDirID))
+	assert.Equal(t, int64(3), childCount(t, db, 2))
+	assertCountsMatchScan(t, db)
+
+	testutil.DeleteFile(t, db, 100)
+	assert.Equal(t, int64(2), childCount(t, db, 2))
+	assertCountsMatchScan(t, db)
+
+	// 移动 /a/c 到 /b
+	testutil.MoveDirectory(t, db, 4, 3)
+	assert.Equal(t, int64(1), childCount(t, db, 2))
+	assert.Equal(t, int64(1), childCount(t, db, 3))
+	assertCountsMatchScan(t, db)
+
+	// 移动到原父目录不改变计数
+	testutil.MoveDirectory(t, db, 4, 3)
+	assert.Equal(t, int64(1), childCount(t, db, 3))
+	assertCountsMatchScan(t, db)
+}
+
+func TestChildCount_RolledBackWithTransaction(t *testing.T) {
+	db := newCountingDB(t)
+	ctx := context.Background()
+
+	err := db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		dir := &models.DirectoryMetadata{DirID: 2, ParentID: rootDirID, Name: "a", OwnerID: 1}
+		if _, err := namespace.NewDirectoryRepository(db).Create(ctx, tx, dir); err != nil {
+			return err
+		}
+		return assert.AnError
+	})
+	require.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, int64(0), childCount(t, db, rootDirID), "事务回滚后计数应恢复")
+	assertCountsMatchScan(t, db)
+}
+
+func TestChildCount_RejectsNegativeOrMissing(t *testing.T) {
+	db := newCountingDB(t)
+	ctx := context.Background()
+
+	err := db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		return database.AdjustChildCount(ctx, tx, rootDirID, -1)
+	})
+	assert.Error(t, err, "计数不能变为负数")
+
+	err = db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		return database.AdjustChildCount(ctx, tx, 999, 1)
+	})
+	assert.Error(t, err, "不存在的目录")
+	assert.Equal(t, int64(0), childCount(t, db, rootDirID))
+}
+
+func TestIsDirectoryEmpty_ReadsCounterWithoutScanning(t *testing.T) {
+	db := newCountingDB(t)
+	ctx := context.Background()
+
+	mkdir(t, db, 2, rootDirID)
+	empty, err := db.IsDirectoryEmpty(ctx, 2)
+	require.NoError(t, err)
+	assert.True(t, empty)
+
+	empty, err = db.IsDirectoryEmpty(ctx, rootDirID)
+	require.NoError(t, err)
+	assert.False(t, empty)
+
+	// 绕过计数维护直接插入子项：判断结果只取决于计数列，说明没有扫描子项
+	_, err = db.ExecContext(ctx, "INSERT INTO files (file_id, parent_dir_id, name, owner_id) VALUES (100, 2, 'hidden', 1)")
+	require.NoError(t, err)
+	empty, err = db.IsDirectoryEmpty(ctx, 2)
+	require.NoError(t, err)
+	assert.True(t, empty)
+
+	_, err = db.IsDirectoryEmpty(ctx, 999)
+	assert.Error(t, err)
+}
+
+func TestSchemaMigrations_BackfillsExistingCounts(t *testing.T) {
+	db := newCountingDB(t)
+	ctx := context.Background()
+
+	// 模拟计数列出现之前写入的数据：子项存在但计数为0
+	statements := []string{
+		"INSERT INTO directories (dir_id, parent_id, name, owner_id) VALUES (2, 1, 'a', 1), (3, 1, 'b', 1), (4, 2, 'c', 1)",
+		"INSERT INTO directories (dir_id, parent_id, name, owner_id, is_deleted) VALUES (5, 2, 'gone', 1, TRUE)",
+		"INSERT INTO files (file_id, parent_dir_id, name, owner_id) VALUES (100, 2, 'x', 1), (101, 4, 'y', 1)",
+		"INSERT INTO files (file_id, parent_dir_id, name, owner_id, is_deleted) VALUES (102, 4, 'z', 1, TRUE)",
+		"DELETE FROM schema_migrations WHERE version = 2",
+	}
+	for _, stmt := range statements {
+		_, err := db.ExecContext(ctx, stmt)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(0), childCount(t, db, 2))
+
+	require.NoError(t, database.NewMigrationManager(db).ApplyMigrations(ctx, database.SchemaMigrations()))
+
+	assert.Equal(t, int64(2), childCount(t, db, rootDirID))
+	assert.Equal(t, int64(2), childCount(t, db, 2), "已删除的子项不计入")
+	assert.Equal(t, int64(0), childCount(t, db, 3))
+	assert.Equal(t, int64(1), childCount(t, db, 4))
+	assertCountsMatchScan(t, db)
+
+	applied, err := database.NewMigrationManager(db).GetAppliedMigrations(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, applied, 1)
+	assert.Contains(t, applied, 2)
+}
@@ -0,0 +1,88 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/22827099/DFS_v1/internal/metaserver/core/database"
+	"github.com/22827099/DFS_v1/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryBuilder_EmitsParameterizedSQL(t *testing.T) {
+	qb := database.NewQueryBuilder("directories").
+		Select("dir_id", "name").
+		Where("parent_id = ?", int64(7)).
+		Where("name = ? AND is_deleted = false", "x' OR '1'='1").
+		OrderBy("name ASC, dir_id").
+		Limit(10)
+	require.NoError(t, qb.Err())
+
+	query, args := qb.BuildSelect()
+	assert.Equal(t,
+		"SELECT dir_id, name FROM directories WHERE parent_id = ? AND name = ? AND is_deleted = false ORDER BY name ASC, dir_id LIMIT 10",
+		query)
+	// 恶意输入只作为参数传递，不出现在SQL中
+	assert.Equal(t, []interface{}{int64(7), "x' OR '1'='1"}, args)
+	assert.NotContains(t, query, "OR '1'")
+
+	query, args = qb.BuildCount()
+	assert.Equal(t, "SELECT COUNT(*) FROM directories WHERE parent_id = ? AND name = ? AND is_deleted = false", query)
+	assert.Len(t, args, 2)
+}
+
+func TestQueryBuilder_RejectsInterpolatedConditions(t *testing.T) {
+	conditions := map[string]string{
+		"字符串字面量":  "name = 'a.txt'",
+		"数字字面量":   "parent_id = 7",
+		"负数字面量":   "parent_id >= -1",
+		"注入的永真条件": "name = ? OR 1=1",
+		"行注释":     "name = ? -- AND is_deleted = false",
+		"块注释":     "name = ? /* x */",
+		"语句分隔符":   "name = ?; DROP TABLE users",
+		"空条件":     "  ",
+	}
+	for name, cond := range conditions {
+		t.Run(name, func(t *testing.T) {
+			err := database.NewQueryBuilder("files").Where(cond, "a").Err()
+			assert.ErrorIs(t, err, database.ErrUnsafeSQL)
+		})
+	}
+}
+
+func TestQueryBuilder_PlaceholderCountMustMatchArgs(t *testing.T) {
+	assert.ErrorIs(t, database.NewQueryBuilder("files").Where("file_id = ? AND name = ?", 1).Err(), database.ErrUnsafeSQL)
+	assert.ErrorIs(t, database.NewQueryBuilder("files").Where("file_id = ?", 1, 2).Err(), database.ErrUnsafeSQL)
+	assert.NoError(t, database.NewQueryBuilder("files").Where("file_id = ?", 1).Where("name = ?", "a").Err())
+}
+
+func TestQueryBuilder_RejectsUnsafeIdentifiers(t *testing.T) {
+	assert.ErrorIs(t, database.NewQueryBuilder("files; DROP TABLE users").Err(), database.ErrUnsafeSQL)
+	assert.ErrorIs(t, database.NewQueryBuilder("files").Select("name, (SELECT password_hash FROM users)").Err(), database.ErrUnsafeSQL)
+	assert.ErrorIs(t, database.NewQueryBuilder("files").OrderBy("name; DROP TABLE users").Err(), database.ErrUnsafeSQL)
+	assert.ErrorIs(t, database.NewQueryBuilder("files").OrderBy("(CASE WHEN 1=1 THEN name END)").Err(), database.ErrUnsafeSQL)
+	assert.NoError(t, database.NewQueryBuilder("files").Select("*").OrderBy("files.name desc").Err())
+}
+
+func TestRepository_RejectsInterpolatedWhereBeforeExecuting(t *testing.T) {
+	db := testutil.NewMetaDB(t)
+
+	ctx := context.Background()
+	repo := database.NewRepository(db, "users")
+
+	// 拼接进条件的值被拒绝，语句不会执行
+	_, err := repo.Delete(ctx, "username = 'system' OR 1=1")
+	assert.ErrorIs(t, err, database.ErrUnsafeSQL)
+	_, err = repo.Count(ctx, "user_id = 1")
+	assert.ErrorIs(t, err, database.ErrUnsafeSQL)
+
+	count, err := repo.Count(ctx, "username = ?", "system")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "系统用户不应被删除")
+
+	// 注入字符串作为参数传递时只是普通的值
+	count, err = repo.Count(ctx, "username = ?", "system' OR '1'='1")
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
@@ -0,0 +1,205 @@
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/22827099/DFS_v1/internal/metaserver/core/database"
+	"github.com/22827099/DFS_v1/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func isDeleted(t *testing.T, db *database.Manager, table string, id int64) bool {
+	t.Helper()
+	query := map[string]string{
+		"files":       "SELECT is_deleted FROM files WHERE file_id = ?",
+		"directories": "SELECT is_deleted FROM directories WHERE dir_id = ?",
+	}[table]
+	var deleted bool
+	require.NoError(t, db.QueryRowContext(context.Background(), query, id).Scan(&deleted))
+	return deleted
+}
+
+func restoreFile(db *database.Manager, id int64) error {
+	return db.WithTransaction(context.Background(), func(tx *sql.Tx) error {
+		return database.RestoreFile(context.Background(), tx, id)
+	})
+}
+
+func restoreDir(db *database.Manager, id int64, recursive bool) (int, error) {
+	var restored int
+	err := db.WithTransaction(context.Background(), func(tx *sql.Tx) error {
+		var err error
+		restored, err = database.RestoreDirectory(context.Background(), tx, id, recursive)
+		return err
+	})
+	return restored, err
+}
+
+func TestRestoreFile_ClearsDeletionAndRestoresCount(t *testing.T) {
+	db := newCountingDB(t)
+	createFile(t, db, 10, rootDirID)
+	testutil.DeleteFile(t, db, 10)
+	assert.Equal(t, int64(0), childCount(t, db, rootDirID))
+
+	require.NoError(t, restoreFile(db, 10))
+	assert.False(t, isDeleted(t, db, "files", 10))
+	assert.Equal(t, int64(1), childCount(t, db, rootDirID))
+
+	// 未删除的文件不能再次恢复
+	assert.ErrorIs(t, restoreFile(db, 10), database.ErrNotDeleted)
+	assert.Equal(t, int64(1), childCount(t, db, rootDirID))
+}
+
+func TestRestoreFile_RejectsNameCollision(t *testing.T) {
+	db := newCountingDB(t)
+	createFile(t, db, 10, rootDirID)
+	testutil.DeleteFile(t, db, 10)
+
+	// 删除后在同一位置创建了同名目录
+	testutil.Mkdir(t, db, 2, rootDirID, "f10")
+
+	assert.ErrorIs(t, restoreFile(db, 10), database.ErrRestoreConflict)
+	assert.True(t, isDeleted(t, db, "files", 10))
+	assert.Equal(t, int64(1), childCount(t, db, rootDirID))
+}
+
+func TestRestoreFile_RequiresLiveParent(t *testing.T) {
+	db := newCountingDB(t)
+	mkdir(t, db, 2, rootDirID)
+	createFile(t, db, 10, 2)
+	testutil.DeleteFile(t, db, 10)
+	testutil.DeleteDirectory(t, db, 2)
+
+	assert.ErrorIs(t, restoreFile(db, 10), database.ErrParentDeleted)
+
+	// 先恢复父目录后可以恢复文件
+	restored, err := restoreDir(db, 2, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, restored)
+	require.NoError(t, restoreFile(db, 10))
+	assertCountsMatchScan(t, db)
+}
+
+// deleteTree 在一次操作中递归删除目录，返回删除的条目数
+func deleteTree(t *testing.T, db *database.Manager, id int64) int {
+	t.Helper()
+	var deleted int
+	err := db.WithTransaction(context.Background(), func(tx *sql.Tx) error {
+		var err error
+		deleted, err = database.DeleteDirectory(context.Background(), tx, id, true)
+		return err
+	})
+	require.NoError(t, err)
+	return deleted
+}
+
+// newSubtree 构造子树：d2/{f10, d3/{f11, d4/}}
+func newSubtree(t *testing.T) *database.Manager {
+	db := newCountingDB(t)
+	mkdir(t, db, 2, rootDirID)
+	createFile(t, db, 10, 2)
+	mkdir(t, db, 3, 2)
+	createFile(t, db, 11, 3)
+	mkdir(t, db, 4, 3)
+	return db
+}
+
+// newDeletedSubtree 构造在一次操作中整体删除的子树：d2/{f10, d3/{f11, d4/}}
+func newDeletedSubtree(t *testing.T) *database.Manager {
+	db := newSubtree(t)
+	assert.Equal(t, 5, deleteTree(t, db, 2))
+	assertCountsMatchScan(t, db)
+	return db
+}
+
+func TestRestoreDirectory_NonRecursiveRestoresOnlyDirectory(t *testing.T) {
+	db := newDeletedSubtree(t)
+
+	restored, err := restoreDir(db, 2, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, restored)
+	assert.False(t, isDeleted(t, db, "directories", 2))
+	assert.True(t, isDeleted(t, db, "directories", 3))
+	assert.True(t, isDeleted(t, db, "files", 10))
+	assert.Equal(t, int64(0), childCount(t, db, 2))
+	assertCountsMatchScan(t, db)
+}
+
+func TestRestoreDirectory_RecursiveRestoresSubtree(t *testing.T) {
+	db := newDeletedSubtree(t)
+
+	restored, err := restoreDir(db, 2, true)
+	require.NoError(t, err)
+	assert.Equal(t, 5, restored)
+	for _, id := range []int64{2, 3, 4} {
+		assert.False(t, isDeleted(t, db, "directories", id), "目录%d", id)
+	}
+	for _, id := range []int64{10, 11} {
+		assert.False(t, isDeleted(t, db, "files", id), "文件%d", id)
+	}
+	assert.Equal(t, int64(1), childCount(t, db, rootDirID))
+	assert.Equal(t, int64(2), childCount(t, db, 2))
+	assert.Equal(t, int64(2), childCount(t, db, 3))
+	assertCountsMatchScan(t, db)
+}
+
+func TestRestoreDirectory_RecursiveConflictRollsBackEverything(t *testing.T) {
+	db := newDeletedSubtree(t)
+
+	// 删除后有并发写入在已删除的d3下创建了与f11同名的目录
+	testutil.Mkdir(t, db, 5, 3, "f11")
+
+	_, err := restoreDir(db, 2, true)
+	assert.ErrorIs(t, err, database.ErrRestoreConflict)
+	assert.True(t, isDeleted(t, db, "directories", 2))
+	assert.True(t, isDeleted(t, db, "files", 10))
+	assert.True(t, isDeleted(t, db, "files", 11))
+	assertCountsMatchScan(t, db)
+}
+
+func TestRestoreDirectory_RecursiveSkipsEntriesDeletedSeparately(t *testing.T) {
+	db := newSubtree(t)
+
+	// f10和d3（连同其子树）在删除d2之前已单独删除
+	testutil.DeleteFile(t, db, 10)
+	assert.Equal(t, 3, deleteTree(t, db, 3))
+	assert.Equal(t, 1, deleteTree(t, db, 2))
+
+	restored, err := restoreDir(db, 2, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, restored)
+	assert.False(t, isDeleted(t, db, "directories", 2))
+	assert.True(t, isDeleted(t, db, "files", 10))
+	assert.True(t, isDeleted(t, db, "directories", 3))
+	assert.True(t, isDeleted(t, db, "files", 11))
+	assert.Equal(t, int64(0), childCount(t, db, 2))
+	assertCountsMatchScan(t, db)
+
+	// d3的子树仍可以单独恢复
+	restored, err = restoreDir(db, 3, true)
+	require.NoError(t, err)
+	assert.Equal(t, 3, restored)
+	assertCountsMatchScan(t, db)
+}
+
+func TestDeleteDirectory_NonRecursiveRequiresEmpty(t *testing.T) {
+	db := newSubtree(t)
+
+	err := db.WithTransaction(context.Background(), func(tx *sql.Tx) error {
+		_, err := database.DeleteDirectory(context.Background(), tx, 3, false)
+		return err
+	})
+	assert.ErrorIs(t, err, database.ErrDirectoryNotEmpty)
+	assert.False(t, isDeleted(t, db, "directories", 3))
+
+	err = db.WithTransaction(context.Background(), func(tx *sql.Tx) error {
+		_, err := database.DeleteDirectory(context.Background(), tx, 4, false)
+		return err
+	})
+	require.NoError(t, err)
+	assert.True(t, isDeleted(t, db, "directories", 4))
+	assertCountsMatchScan(t, db)
+}
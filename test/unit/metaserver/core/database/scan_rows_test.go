@@ -0,0 +1,207 @@
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/internal/metaserver/core/database"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/namespace"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/models"
+	"github.com/22827099/DFS_v1/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newScanDB 创建元数据库，并写入/a(2)和/a下的两个文件
+func newScanDB(t *testing.T) *database.Manager {
+	t.Helper()
+	db := testutil.NewMetaDB(t)
+
+	testutil.SeedDirectory(t, db, models.DirectoryMetadata{DirID: 2, ParentID: testutil.RootDirID, Name: "a", OwnerID: 1, Mode: 700})
+	testutil.SeedFile(t, db, models.FileMetadata{FileID: 100, DirID: 2, Name: "f1", Size: 1024, OwnerID: 1, Mode: 644, Checksum: "abc"})
+	// 校验和为空时仓库写入NULL
+	testutil.SeedFile(t, db, models.FileMetadata{FileID: 101, DirID: 2, Name: "f2", Size: 2048, OwnerID: 1, Mode: 644})
+	return db
+}
+
+// query 执行查询并扫描到dest
+func query(t *testing.T, db *database.Manager, dest interface{}, q string, args ...interface{}) {
+	t.Helper()
+	rows, err := db.QueryContext(context.Background(), q, args...)
+	require.NoError(t, err)
+	defer rows.Close()
+	require.NoError(t, database.ScanRows(rows, dest))
+}
+
+func TestScanRows_DirectoryMetadata(t *testing.T) {
+	db := newScanDB(t)
+
+	var dirs []models.DirectoryMetadata
+	query(t, db, &dirs, "SELECT * FROM directories ORDER BY dir_id")
+
+	require.Len(t, dirs, 2)
+	// 根目录的parent_id为NULL，非指针字段保持零值
+	assert.Equal(t, int64(1), dirs[0].DirID)
+	assert.Equal(t, int64(0), dirs[0].ParentID)
+	assert.Equal(t, "/", dirs[0].Name)
+
+	assert.Equal(t, int64(2), dirs[1].DirID)
+	assert.Equal(t, int64(1), dirs[1].ParentID)
+	assert.Equal(t, "a", dirs[1].Name)
+	assert.Equal(t, int32(700), dirs[1].Mode)
+	assert.False(t, dirs[1].Deleted)
+	assert.False(t, dirs[1].CreateTime.IsZero())
+	assert.WithinDuration(t, time.Now(), dirs[1].ModifyTime, time.Hour)
+}
+
+func TestScanRows_FileMetadata(t *testing.T) {
+	db := newScanDB(t)
+
+	var files []models.FileMetadata
+	query(t, db, &files, "SELECT * FROM files WHERE parent_dir_id = ? ORDER BY file_id", int64(2))
+
+	require.Len(t, files, 2)
+	assert.Equal(t, models.FileMetadata{
+		FileID:     100,
+		DirID:      2,
+		Name:       "f1",
+		Size:       1024,
+		Checksum:   "abc",
+		OwnerID:    1,
+		Mode:       644,
+		Version:    1,
+		CreateTime: files[0].CreateTime,
+		ModifyTime: files[0].ModifyTime,
+		AccessTime: files[0].AccessTime,
+	}, files[0])
+	assert.False(t, files[0].CreateTime.IsZero())
+	assert.False(t, files[0].ModifyTime.IsZero())
+	assert.False(t, files[0].AccessTime.IsZero())
+
+	// NULL的校验和扫描为空字符串
+	assert.Equal(t, int64(101), files[1].FileID)
+	assert.Equal(t, int64(2048), files[1].Size)
+	assert.Empty(t, files[1].Checksum)
+}
+
+// auditColumns 嵌入在行结构体中的公共列
+type auditColumns struct {
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// directoryRow 使用指针和sql.NullX字段接收可能为NULL的列
+type directoryRow struct {
+	auditColumns
+	ID       int64          `db:"dir_id"`
+	ParentID *int64         `db:"parent_id"`
+	Name     sql.NullString `db:"name"`
+	Mode     sql.NullInt64  `db:"mode"`
+	Internal string         `db:"-"`
+}
+
+func TestScanRows_PointerAndNullFields(t *testing.T) {
+	db := newScanDB(t)
+
+	var dirs []*directoryRow
+	query(t, db, &dirs, "SELECT * FROM directories ORDER BY dir_id")
+
+	require.Len(t, dirs, 2)
+	assert.Nil(t, dirs[0].ParentID, "根目录没有父目录")
+	require.NotNil(t, dirs[1].ParentID)
+	assert.Equal(t, int64(1), *dirs[1].ParentID)
+	assert.Equal(t, sql.NullString{String: "a", Valid: true}, dirs[1].Name)
+	assert.Equal(t, sql.NullInt64{Int64: 700, Valid: true}, dirs[1].Mode)
+	assert.False(t, dirs[1].CreatedAt.IsZero(), "嵌入结构体的字段同样按标签匹配")
+
+	var nullable []directoryRow
+	query(t, db, &nullable, "SELECT dir_id, NULL AS name, NULL AS mode FROM directories WHERE dir_id = 2")
+	require.Len(t, nullable, 1)
+	assert.False(t, nullable[0].Name.Valid)
+	assert.False(t, nullable[0].Mode.Valid)
+}
+
+func TestScanRows_FindAllUsesTags(t *testing.T) {
+	db := newScanDB(t)
+
+	var files []models.FileMetadata
+	err := database.NewRepository(db, "files").FindAll(context.Background(), &files, "parent_dir_id = ?", int64(2))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, "f1", files[0].Name)
+	assert.Equal(t, "f2", files[1].Name)
+}
+
+// TestScanRows_NamespaceRepositories 通过命名空间仓库以SELECT *读取，所有与列对应的字段都被填充
+func TestScanRows_NamespaceRepositories(t *testing.T) {
+	db := newScanDB(t)
+	ctx := context.Background()
+	dirRepo := namespace.NewDirectoryRepository(db)
+	fileRepo := namespace.NewFileRepository(db)
+
+	assertFile := func(file models.FileMetadata) {
+		t.Helper()
+		assert.Equal(t, int64(2), file.DirID)
+		assert.False(t, file.CreateTime.IsZero())
+		assert.False(t, file.ModifyTime.IsZero())
+		assert.False(t, file.AccessTime.IsZero())
+	}
+
+	var file models.FileMetadata
+	require.NoError(t, fileRepo.FindByID(ctx, 100, &file))
+	assertFile(file)
+	assert.Equal(t, "f1", file.Name)
+
+	require.NoError(t, fileRepo.FindByDirAndName(ctx, 2, "f2", &file))
+	assertFile(file)
+	assert.Equal(t, int64(101), file.FileID)
+
+	var files []models.FileMetadata
+	require.NoError(t, fileRepo.FindAll(ctx, &files, "parent_dir_id = ?", int64(2)))
+	require.Len(t, files, 2)
+	for _, f := range files {
+		assertFile(f)
+	}
+
+	files = nil
+	require.NoError(t, fileRepo.FindPage(ctx, &files, namespace.PageQuery{
+		Where:   "parent_dir_id = ?",
+		Args:    []interface{}{int64(2)},
+		OrderBy: "file_id DESC",
+		Limit:   1,
+	}))
+	require.Len(t, files, 1)
+	assert.Equal(t, int64(101), files[0].FileID)
+	assertFile(files[0])
+
+	var dir models.DirectoryMetadata
+	require.NoError(t, dirRepo.FindByID(ctx, 2, &dir))
+	assert.Equal(t, int64(1), dir.ParentID)
+	assert.False(t, dir.CreateTime.IsZero())
+	assert.False(t, dir.ModifyTime.IsZero())
+
+	children, err := dirRepo.FindChildren(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, children, 1)
+	assert.Equal(t, "a", children[0].Name)
+	assert.False(t, children[0].CreateTime.IsZero())
+}
+
+func TestScanRows_RejectsInvalidDestination(t *testing.T) {
+	db := newScanDB(t)
+	ctx := context.Background()
+
+	for name, dest := range map[string]interface{}{
+		"非指针":    []models.DirectoryMetadata{},
+		"非切片":    &models.DirectoryMetadata{},
+		"非结构体元素": &[]int64{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rows, err := db.QueryContext(ctx, "SELECT dir_id FROM directories")
+			require.NoError(t, err)
+			defer rows.Close()
+			assert.Error(t, database.ScanRows(rows, dest))
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package database_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// repositoryLayerDirs 需要检查的仓库层源码目录，相对于本测试目录
+var repositoryLayerDirs = []string{
+	"../../../../../internal/metaserver/core/database",
+	"../../../../../internal/metaserver/core/metadata",
+}
+
+var (
+	sqlKeywordPattern = regexp.MustCompile(`(?i)\b(SELECT|INSERT|UPDATE|DELETE|WHERE|VALUES|SET)\b`)
+	// 在比较运算符之后、引号内或以%v/%q格式化值，都是把值拼进了SQL
+	sqlValueVerbPattern = regexp.MustCompile(`([=<>]\s*'?%|'%|%[vq])`)
+	// 以引号或比较运算符结尾的SQL片段后面拼接的是值
+	sqlValueConcatPattern = regexp.MustCompile(`([=<>(,]\s*'?|')$`)
+)
+
+// findInterpolatedSQL 返回源码中用fmt.Sprintf或字符串拼接把值写入SQL的位置
+func findInterpolatedSQL(fset *token.FileSet, file *ast.File) []string {
+	var findings []string
+	report := func(node ast.Node, reason string) {
+		findings = append(findings, fset.Position(node.Pos()).String()+": "+reason)
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.CallExpr:
+			sel, ok := node.Fun.(*ast.SelectorExpr)
+			if !ok || len(node.Args) == 0 {
+				return true
+			}
+			if pkg, ok := sel.X.(*ast.Ident); !ok || pkg.Name != "fmt" || sel.Sel.Name != "Sprintf" {
+				return true
+			}
+			if format, ok := stringLiteral(node.Args[0]); ok &&
+				sqlKeywordPattern.MatchString(format) && sqlValueVerbPattern.MatchString(format) {
+				report(node, "fmt.Sprintf将值格式化进SQL")
+			}
+		case *ast.BinaryExpr:
+			if node.Op != token.ADD {
+				return true
+			}
+			left, leftIsLiteral := stringLiteral(node.X)
+			_, rightIsLiteral := stringLiteral(node.Y)
+			if leftIsLiteral && !rightIsLiteral &&
+				sqlKeywordPattern.MatchString(left) && sqlValueConcatPattern.MatchString(strings.TrimRight(left, " ")) {
+				report(node, "字符串拼接将值写入SQL")
+			}
+		}
+		return true
+	})
+	return findings
+}
+
+// stringLiteral 返回字符串字面量的值
+func stringLiteral(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	value, err := strconv.Unquote(lit.Value)
+	return value, err == nil
+}
+
+func TestRepositoryLayer_NoInterpolatedSQL(t *testing.T) {
+	fset := token.NewFileSet()
+	scanned := 0
+	for _, dir := range repositoryLayerDirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+				return err
+			}
+			file, err := parser.ParseFile(fset, path, nil, 0)
+			if err != nil {
+				return err
+			}
+			scanned++
+			for _, finding := range findInterpolatedSQL(fset, file) {
+				t.Errorf("%s，应使用?占位符传递参数", finding)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+	}
+	assert.Greater(t, scanned, 5, "未扫描到仓库层源码，检查目录是否正确")
+}
+
+func TestFindInterpolatedSQL_DetectsUnsafePatterns(t *testing.T) {
+	src := `package p
+
+import "fmt"
+
+func unsafe(name string, id int64) {
+	_ = fmt.Sprintf("SELECT * FROM files WHERE name = '%s'", name)
+	_ = fmt.Sprintf("DELETE FROM files WHERE file_id = %d", id)
+	_ = fmt.Sprintf("SELECT * FROM files WHERE name IN (%v)", name)
+	_ = "SELECT * FROM users WHERE username = '" + name + "'"
+	_ = "UPDATE files SET name = " + name
+}
+
+func safe(table, where string, limit int) {
+	_ = fmt.Sprintf("SELECT %s FROM %s", "*", table)
+	_ = fmt.Sprintf(" LIMIT %d", limit)
+	_ = fmt.Sprintf("%s = ?", "name")
+	_ = " WHERE " + where
+	_ = "SELECT * FROM files " + "WHERE name = ?"
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "sample.go", src, 0)
+	require.NoError(t, err)
+
+	findings := findInterpolatedSQL(fset, file)
+	assert.Len(t, findings, 5, "%v", findings)
+	for _, finding := range findings {
+		assert.Contains(t, finding, "sample.go:")
+		line, _ := strconv.Atoi(strings.Split(finding, ":")[1])
+		assert.True(t, line >= 6 && line <= 10, "只应报告unsafe函数中的用法: %s", finding)
+	}
+}
@@ -0,0 +1,164 @@
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/22827099/DFS_v1/internal/metaserver/core/database"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/namespace"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/models"
+	"github.com/22827099/DFS_v1/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLargeDirectory 在根目录下创建count个子目录，名称长度约为nameSize字节
+func newLargeDirectory(t *testing.T, count, nameSize int) *database.Manager {
+	t.Helper()
+	db := testutil.NewMetaDB(t)
+
+	ctx := context.Background()
+	padding := strings.Repeat("x", nameSize)
+	err := db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		repo := namespace.NewDirectoryRepository(db)
+		for id := int64(2); id < int64(count)+2; id++ {
+			dir := &models.DirectoryMetadata{DirID: id, ParentID: testutil.RootDirID, Name: padding + strconv.FormatInt(id, 10), OwnerID: 1}
+			if _, err := repo.Create(ctx, tx, dir); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return db
+}
+
+// childrenOfRoot 根目录下子目录的流式查询选项
+func childrenOfRoot(pageSize int) database.StreamOptions {
+	return database.StreamOptions{
+		Columns:  []string{"dir_id", "name"},
+		Where:    "parent_id = ? AND is_deleted = false",
+		Args:     []interface{}{int64(1)},
+		OrderBy:  "dir_id",
+		PageSize: pageSize,
+	}
+}
+
+func TestStream_VisitsEveryRowInOrder(t *testing.T) {
+	const count = 2500
+	db := newLargeDirectory(t, count, 8)
+	repo := database.NewRepository(db, "directories")
+
+	var ids []int64
+	err := repo.Stream(context.Background(), childrenOfRoot(100), func(rows *sql.Rows) error {
+		var id int64
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return err
+		}
+		ids = append(ids, id)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, ids, count)
+	for i, id := range ids {
+		require.Equal(t, int64(i+2), id, "分页结果应按dir_id连续且不重复")
+	}
+}
+
+func TestStream_MemoryBoundedByPageSize(t *testing.T) {
+	if testing.Short() {
+		t.Skip("大目录测试在-short模式下跳过")
+	}
+	// 名称总量约40MB，全部加载到内存时堆增长远超一页的大小
+	const (
+		count    = 10000
+		nameSize = 4096
+	)
+	db := newLargeDirectory(t, count, nameSize)
+	repo := database.NewRepository(db, "directories")
+
+	var before runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	var peak uint64
+	visited := 0
+	err := repo.Stream(context.Background(), childrenOfRoot(500), func(rows *sql.Rows) error {
+		var id int64
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return err
+		}
+		visited++
+		if visited%1000 == 0 {
+			var stats runtime.MemStats
+			runtime.ReadMemStats(&stats)
+			if stats.HeapAlloc > peak {
+				peak = stats.HeapAlloc
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, count, visited)
+
+	growth := int64(peak) - int64(before.HeapAlloc)
+	assert.Less(t, growth, int64(count*nameSize/2),
+		"流式查询的堆增长(%d字节)不应接近全部结果的大小", growth)
+}
+
+func TestStream_CancellationStopsPagination(t *testing.T) {
+	db := newLargeDirectory(t, 1000, 8)
+	repo := database.NewRepository(db, "directories")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	visited := 0
+	err := repo.Stream(ctx, childrenOfRoot(100), func(rows *sql.Rows) error {
+		visited++
+		if visited == 150 {
+			cancel()
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 150, visited, "取消后不应继续处理剩余的行和页")
+}
+
+func TestStream_CallbackErrorStopsPagination(t *testing.T) {
+	db := newLargeDirectory(t, 300, 8)
+	repo := database.NewRepository(db, "directories")
+
+	stop := assert.AnError
+	visited := 0
+	err := repo.Stream(context.Background(), childrenOfRoot(100), func(rows *sql.Rows) error {
+		visited++
+		if visited == 120 {
+			return stop
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 120, visited)
+}
+
+func TestStream_RequiresOrderAndParameterizedWhere(t *testing.T) {
+	db := newLargeDirectory(t, 1, 8)
+	repo := database.NewRepository(db, "directories")
+	noop := func(*sql.Rows) error { return nil }
+
+	opts := childrenOfRoot(10)
+	opts.OrderBy = ""
+	assert.Error(t, repo.Stream(context.Background(), opts, noop), "缺少排序时翻页结果不稳定")
+
+	opts = childrenOfRoot(10)
+	opts.Where, opts.Args = "parent_id = 1", nil
+	assert.ErrorIs(t, repo.Stream(context.Background(), opts, noop), database.ErrUnsafeSQL)
+}
@@ -0,0 +1,141 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func appendPaths(log *events.Log, paths ...string) {
+	for _, p := range paths {
+		log.Append(events.Event{Type: events.Create, Path: p})
+	}
+}
+
+func paths(list []events.Event) []string {
+	result := make([]string, len(list))
+	for i, e := range list {
+		result[i] = e.Path
+	}
+	return result
+}
+
+func TestLog_AssignsSequenceAndTimestamp(t *testing.T) {
+	log := events.NewLog(10)
+	assert.Equal(t, uint64(0), log.LastSeq())
+
+	first := log.Append(events.Event{Type: events.Create, Path: "/a"})
+	second := log.Append(events.Event{Type: events.Delete, Path: "/a"})
+	assert.Equal(t, uint64(1), first.Seq)
+	assert.Equal(t, uint64(2), second.Seq)
+	assert.False(t, first.Timestamp.IsZero())
+	assert.Equal(t, uint64(2), log.LastSeq())
+}
+
+func TestLog_SinceAdvancesCursor(t *testing.T) {
+	log := events.NewLog(10)
+	appendPaths(log, "/a", "/b", "/c", "/d", "/e")
+
+	list, cursor, err := log.Since(0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/a", "/b"}, paths(list))
+	assert.Equal(t, uint64(2), cursor)
+
+	list, cursor, err = log.Since(cursor, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/c", "/d", "/e"}, paths(list))
+	assert.Equal(t, uint64(5), cursor)
+
+	// 没有新事件时游标不变
+	list, cursor, err = log.Since(cursor, 0)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+	assert.Equal(t, uint64(5), cursor)
+
+	appendPaths(log, "/f")
+	list, cursor, err = log.Since(cursor, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/f"}, paths(list))
+	assert.Equal(t, uint64(6), cursor)
+}
+
+func TestLog_ExpiredCursor(t *testing.T) {
+	log := events.NewLog(3)
+	appendPaths(log, "/a", "/b", "/c", "/d", "/e")
+
+	// 只保留序号3~5，游标2之后的事件完整，游标1之后的事件/b已被丢弃
+	list, _, err := log.Since(2, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/c", "/d", "/e"}, paths(list))
+
+	_, cursor, err := log.Since(1, 0)
+	assert.ErrorIs(t, err, events.ErrCursorExpired)
+	assert.Equal(t, uint64(1), cursor)
+
+	// 游标超过最新序号说明日志已重置
+	_, _, err = log.Since(9, 0)
+	assert.ErrorIs(t, err, events.ErrCursorExpired)
+
+	_, _, err = log.Subscribe(1, 0)
+	assert.ErrorIs(t, err, events.ErrCursorExpired)
+}
+
+func TestLog_SubscribeReplaysThenStreams(t *testing.T) {
+	log := events.NewLog(10)
+	appendPaths(log, "/a", "/b", "/c")
+
+	ch, cancel, err := log.Subscribe(1, 4)
+	require.NoError(t, err)
+	defer cancel()
+
+	appendPaths(log, "/d")
+	var got []events.Event
+	for i := 0; i < 3; i++ {
+		got = append(got, <-ch)
+	}
+	assert.Equal(t, []string{"/b", "/c", "/d"}, paths(got))
+	for i, e := range got {
+		assert.Equal(t, uint64(i+2), e.Seq)
+	}
+
+	cancel()
+	_, ok := <-ch
+	assert.False(t, ok)
+}
+
+func TestLog_SlowSubscriberIsDisconnected(t *testing.T) {
+	log := events.NewLog(10)
+	ch, cancel, err := log.Subscribe(0, 1)
+	require.NoError(t, err)
+	defer cancel()
+
+	appendPaths(log, "/a", "/b")
+
+	// 第二个事件放不下时通道被关闭，消费者从最后收到的序号重新订阅即可补齐
+	first, ok := <-ch
+	require.True(t, ok)
+	_, ok = <-ch
+	assert.False(t, ok)
+
+	ch, cancel2, err := log.Subscribe(first.Seq, 1)
+	require.NoError(t, err)
+	defer cancel2()
+	assert.Equal(t, "/b", (<-ch).Path)
+}
+
+func TestLog_CloseEndsSubscriptions(t *testing.T) {
+	log := events.NewLog(10)
+	ch, cancel, err := log.Subscribe(0, 1)
+	require.NoError(t, err)
+	defer cancel()
+
+	log.Close()
+	_, ok := <-ch
+	assert.False(t, ok)
+
+	// 关闭后追加的事件被忽略
+	assert.Equal(t, uint64(0), log.Append(events.Event{Type: events.Create, Path: "/a"}).Seq)
+	assert.Equal(t, uint64(0), log.LastSeq())
+}
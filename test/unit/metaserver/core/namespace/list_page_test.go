@@ -0,0 +1,167 @@
+package namespace_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/internal/metaserver/core/database"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/namespace"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/models"
+	"github.com/22827099/DFS_v1/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPagingManager 创建命名空间管理器，根目录下有目录dir1~dir3和文件file1.txt~file3.txt，
+// 文件大小分别为1024、2048和4096
+func newPagingManager(t *testing.T) (*namespace.Manager, *database.Manager) {
+	t.Helper()
+	manager, db := newTestManager(t)
+	testutil.Mkdir(t, db, 2, testutil.RootDirID, "dir1")
+	testutil.Mkdir(t, db, 3, testutil.RootDirID, "dir2")
+	testutil.Mkdir(t, db, 4, testutil.RootDirID, "dir3")
+	testutil.SeedFile(t, db, models.FileMetadata{FileID: 10, DirID: testutil.RootDirID, Name: "file1.txt", Size: 1024, OwnerID: 1, Mode: 644})
+	testutil.SeedFile(t, db, models.FileMetadata{FileID: 11, DirID: testutil.RootDirID, Name: "file2.txt", Size: 2048, OwnerID: 1, Mode: 644})
+	testutil.SeedFile(t, db, models.FileMetadata{FileID: 12, DirID: testutil.RootDirID, Name: "file3.txt", Size: 4096, OwnerID: 1, Mode: 644})
+	return manager, db
+}
+
+func TestListDirectoryPage_Offset(t *testing.T) {
+	ctx := context.Background()
+	manager, _ := newPagingManager(t)
+
+	page, err := manager.ListDirectoryPage(ctx, "/", namespace.WithPagination(2, 2))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dir3", "file1.txt"}, names(page.Entries))
+	assert.NotEmpty(t, page.NextCursor)
+
+	// 按大小降序时目录大小视为0，排在文件之后
+	items, err := manager.ListDirectory(ctx, "/", namespace.WithSort("size", "desc"), namespace.WithPagination(1, 2))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"file2.txt", "file1.txt"}, names(items))
+
+	// 偏移量超过条目数时返回空页
+	page, err = manager.ListDirectoryPage(ctx, "/", namespace.WithPagination(6, 2))
+	require.NoError(t, err)
+	assert.Empty(t, page.Entries)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestListDirectoryPage_Cursor(t *testing.T) {
+	ctx := context.Background()
+	manager, db := newPagingManager(t)
+
+	page, err := manager.ListDirectoryPage(ctx, "/", namespace.WithPagination(0, 2))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dir1", "dir2"}, names(page.Entries))
+	require.NotEmpty(t, page.NextCursor)
+
+	// 游标记录上一页最后一个条目，在其之前新增的条目不影响后续页
+	testutil.Mkdir(t, db, 0, testutil.RootDirID, "a")
+
+	page, err = manager.ListDirectoryPage(ctx, "/", namespace.WithPagination(0, 2), namespace.WithCursor(page.NextCursor))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dir3", "file1.txt"}, names(page.Entries))
+	require.NotEmpty(t, page.NextCursor)
+
+	page, err = manager.ListDirectoryPage(ctx, "/", namespace.WithPagination(0, 2), namespace.WithCursor(page.NextCursor))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"file2.txt", "file3.txt"}, names(page.Entries))
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestListDirectoryPage_CursorDirectoriesFirst(t *testing.T) {
+	ctx := context.Background()
+	manager, _ := newPagingManager(t)
+	options := []namespace.ListOption{
+		namespace.WithSort("name", "desc"),
+		namespace.WithDirectoriesFirst(true),
+		namespace.WithPagination(0, 4),
+	}
+
+	page, err := manager.ListDirectoryPage(ctx, "/", options...)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dir3", "dir2", "dir1", "file3.txt"}, names(page.Entries))
+	require.NotEmpty(t, page.NextCursor)
+
+	page, err = manager.ListDirectoryPage(ctx, "/", append(options, namespace.WithCursor(page.NextCursor))...)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"file2.txt", "file1.txt"}, names(page.Entries))
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestListDirectoryPage_CursorBySize(t *testing.T) {
+	ctx := context.Background()
+	manager, _ := newPagingManager(t)
+	options := []namespace.ListOption{namespace.WithSort("size", "asc"), namespace.WithPagination(0, 2)}
+
+	// 目录大小视为0，大小相同的目录按ID排序
+	var all []string
+	page, err := manager.ListDirectoryPage(ctx, "/", options...)
+	for {
+		require.NoError(t, err)
+		all = append(all, names(page.Entries)...)
+		if page.NextCursor == "" {
+			break
+		}
+		page, err = manager.ListDirectoryPage(ctx, "/", append(options, namespace.WithCursor(page.NextCursor))...)
+	}
+	assert.Equal(t, []string{"dir1", "dir2", "dir3", "file1.txt", "file2.txt", "file3.txt"}, all)
+}
+
+func TestListDirectoryPage_InvalidCursor(t *testing.T) {
+	ctx := context.Background()
+	manager, _ := newPagingManager(t)
+
+	_, err := manager.ListDirectoryPage(ctx, "/", namespace.WithCursor("not a cursor"))
+	assert.ErrorIs(t, err, namespace.ErrInvalidCursor)
+
+	// 游标只能用于生成它时的排序选项
+	page, err := manager.ListDirectoryPage(ctx, "/", namespace.WithPagination(0, 1))
+	require.NoError(t, err)
+	require.NotEmpty(t, page.NextCursor)
+
+	_, err = manager.ListDirectoryPage(ctx, "/", namespace.WithSort("size", "asc"), namespace.WithCursor(page.NextCursor))
+	assert.ErrorIs(t, err, namespace.ErrInvalidCursor)
+}
+
+func TestListDirectoryPage_CursorByModifiedAt(t *testing.T) {
+	ctx := context.Background()
+	manager, db := newTestManager(t)
+
+	// 修改时间与名称顺序相反，其中两个条目修改时间相同
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	testutil.SeedDirectory(t, db, models.DirectoryMetadata{DirID: 2, ParentID: testutil.RootDirID, Name: "a", OwnerID: 1, CreateTime: base, ModifyTime: base.Add(3 * time.Second)})
+	testutil.SeedFile(t, db, models.FileMetadata{FileID: 10, DirID: testutil.RootDirID, Name: "b", OwnerID: 1, CreateTime: base, ModifyTime: base.Add(2 * time.Second)})
+	testutil.SeedDirectory(t, db, models.DirectoryMetadata{DirID: 3, ParentID: testutil.RootDirID, Name: "c", OwnerID: 1, CreateTime: base, ModifyTime: base.Add(2 * time.Second)})
+	testutil.SeedFile(t, db, models.FileMetadata{FileID: 11, DirID: testutil.RootDirID, Name: "d", OwnerID: 1, CreateTime: base, ModifyTime: base.Add(time.Second)})
+
+	options := []namespace.ListOption{namespace.WithSort(namespace.SortByModifiedAt, "asc"), namespace.WithPagination(0, 2)}
+	page, err := manager.ListDirectoryPage(ctx, "/", options...)
+	require.NoError(t, err)
+	// 修改时间相同时目录在前
+	assert.Equal(t, []string{"d", "c"}, names(page.Entries))
+	require.NotEmpty(t, page.NextCursor)
+
+	dir, ok := page.Entries[1].Metadata.(models.DirectoryMetadata)
+	require.True(t, ok)
+	assert.True(t, dir.ModifyTime.Equal(base.Add(2*time.Second)))
+
+	page, err = manager.ListDirectoryPage(ctx, "/", append(options, namespace.WithCursor(page.NextCursor))...)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "a"}, names(page.Entries))
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestListDirectoryPage_DefaultPageSize(t *testing.T) {
+	ctx := context.Background()
+	manager, _ := newPagingManager(t)
+	manager.SetListLimits(0, 4)
+
+	// WithPagination(0, 0)同样分页，每页条目数取默认值
+	page, err := manager.ListDirectoryPage(ctx, "/", namespace.WithPagination(0, 0))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dir1", "dir2", "dir3", "file1.txt"}, names(page.Entries))
+	assert.NotEmpty(t, page.NextCursor)
+}
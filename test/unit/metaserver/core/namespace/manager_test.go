@@ -2,125 +2,39 @@ package namespace_test
 
 import (
 	"context"
-	"database/sql"
-	"errors"
 	"testing"
-	"time"
 
 	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/database"
 	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/lock"
 	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/namespace"
 	"github.com/22827099/DFS_v1/internal/metaserver/core/models"
+	"github.com/22827099/DFS_v1/test/testutil"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
-// MockRepository 是Repository接口的模拟实现
-type MockRepository struct {
-	mock.Mock
-}
-
-func (m *MockRepository) FindOne(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	callArgs := []interface{}{ctx, dest, query}
-	for _, arg := range args {
-		callArgs = append(callArgs, arg)
-	}
-	return m.Called(callArgs...).Error(0)
-}
-
-func (m *MockRepository) Find(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	callArgs := []interface{}{ctx, dest, query}
-	for _, arg := range args {
-		callArgs = append(callArgs, arg)
+// newTestManager 创建基于SQLite元数据库并已启动的命名空间管理器
+func newTestManager(t *testing.T) (*namespace.Manager, *database.Manager) {
+	t.Helper()
+	db := testutil.NewMetaDB(t)
+	lockMgr, err := lock.NewManager(logging.NewLogger())
+	require.NoError(t, err)
+
+	manager, err := namespace.NewManager(db, lockMgr, logging.NewLogger())
+	require.NoError(t, err)
+	require.NoError(t, manager.Start())
+	t.Cleanup(func() { manager.Stop(context.Background()) })
+	return manager, db
+}
+
+// names 返回条目名称，用于比较列出顺序
+func names(items []models.PathInfo) []string {
+	result := make([]string, len(items))
+	for i, item := range items {
+		result[i] = item.Name
 	}
-	return m.Called(callArgs...).Error(0)
-}
-
-func (m *MockRepository) FindByID(ctx context.Context, id int64, dest interface{}) error {
-	return m.Called(ctx, id, dest).Error(0)
-}
-
-func (m *MockRepository) Create(ctx context.Context, tx *sql.Tx, entity interface{}) (sql.Result, error) {
-	args := m.Called(ctx, tx, entity)
-	return args.Get(0).(sql.Result), args.Error(1)
-}
-
-func (m *MockRepository) Update(ctx context.Context, tx *sql.Tx, entity interface{}) (sql.Result, error) {
-	args := m.Called(ctx, tx, entity)
-	return args.Get(0).(sql.Result), args.Error(1)
-}
-
-func (m *MockRepository) Delete(ctx context.Context, tx *sql.Tx, id int64) (sql.Result, error) {
-	args := m.Called(ctx, tx, id)
-	return args.Get(0).(sql.Result), args.Error(1)
-}
-
-// MockDirectoryRepository 是DirectoryRepository接口的模拟实现
-type MockDirectoryRepository struct {
-	MockRepository
-}
-
-func (m *MockDirectoryRepository) FindByParentAndName(ctx context.Context, parentID int64, name string, dest *models.DirectoryMetadata) error {
-	return m.Called(ctx, parentID, name, dest).Error(0)
-}
-
-func (m *MockDirectoryRepository) FindChildren(ctx context.Context, dirID int64) ([]models.DirectoryMetadata, error) {
-	args := m.Called(ctx, dirID)
-	return args.Get(0).([]models.DirectoryMetadata), args.Error(1)
-}
-
-// MockFileRepository 是FileRepository接口的模拟实现
-type MockFileRepository struct {
-	MockRepository
-}
-
-func (m *MockFileRepository) FindByDirAndName(ctx context.Context, dirID int64, name string, dest *models.FileMetadata) error {
-	return m.Called(ctx, dirID, name, dest).Error(0)
-}
-
-func (m *MockFileRepository) FindByDir(ctx context.Context, dirID int64) ([]models.FileMetadata, error) {
-	args := m.Called(ctx, dirID)
-	return args.Get(0).([]models.FileMetadata), args.Error(1)
-}
-
-// MockSQLResult 是sql.Result接口的模拟实现
-type MockSQLResult struct {
-	mock.Mock
-}
-
-func (m *MockSQLResult) LastInsertId() (int64, error) {
-	args := m.Called()
-	return args.Get(0).(int64), args.Error(1)
-}
-
-func (m *MockSQLResult) RowsAffected() (int64, error) {
-	args := m.Called()
-	return args.Get(0).(int64), args.Error(1)
-}
-
-// MockDBManager 是database.Manager的模拟实现
-type MockDBManager struct {
-	mock.Mock
-}
-
-// MockLockManager 是lock.Manager的模拟实现
-type MockLockManager struct {
-	mock.Mock
-}
-
-func (m *MockLockManager) AcquireLock(ctx context.Context, resourceID string) (lock.LockHandle, error) {
-	args := m.Called(ctx, resourceID)
-	return args.Get(0).(lock.LockHandle), args.Error(1)
-}
-
-func (m *MockLockManager) ReleaseLock(ctx context.Context, handle lock.LockHandle) error {
-	return m.Called(ctx, handle).Error(0)
-}
-
-// MockLockHandle 是lock.LockHandle的模拟实现
-type MockLockHandle struct {
-	ID string
+	return result
 }
 
 // TestNamespaceManager 测试命名空间管理器
@@ -129,68 +43,18 @@ func TestNamespaceManager(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("Start", func(t *testing.T) {
-		// 创建Mock对象
-		mockDirRepo := new(MockDirectoryRepository)
-		mockFileRepo := new(MockFileRepository)
-		mockLockMgr := new(MockLockManager)
-		mockDB := new(MockDBManager)
-		logger := logging.NewLogger()
+		manager, _ := newTestManager(t)
 
-		// 预期行为 - 根目录查询
-		rootDir := struct {
-			DirID int64 `db:"dir_id"`
-		}{DirID: 1}
-		mockDirRepo.On("FindOne", mock.Anything, mock.Anything,
-			"parent_id IS NULL AND name='/'").Run(func(args mock.Arguments) {
-			dest := args.Get(1).(*struct{ DirID int64 })
-			*dest = rootDir
-		}).Return(nil)
-
-		// 创建namespace管理器
-		manager, err := namespace.NewManager(mockDB, mockLockMgr, logger)
-		require.NoError(t, err)
-
-		// 设置Manager的储存库
-		manager.SetRepositories(mockDirRepo, mockFileRepo)
-
-		// 启动管理器
-		err = manager.Start()
+		// 启动时预加载根目录，解析根路径不需要再次查找
+		pathInfo, err := manager.ResolvePath(ctx, "/")
 		require.NoError(t, err)
-
-		// 验证调用
-		mockDirRepo.AssertExpectations(t)
+		dir, ok := pathInfo.Metadata.(*models.DirectoryMetadata)
+		require.True(t, ok)
+		assert.Equal(t, testutil.RootDirID, dir.DirID)
 	})
 
 	t.Run("ResolvePath_Root", func(t *testing.T) {
-		// 创建Mock对象
-		mockDirRepo := new(MockDirectoryRepository)
-		mockFileRepo := new(MockFileRepository)
-		mockLockMgr := new(MockLockManager)
-		mockDB := new(MockDBManager)
-		logger := logging.NewLogger()
-
-		// 创建namespace管理器
-		manager, err := namespace.NewManager(mockDB, mockLockMgr, logger)
-		require.NoError(t, err)
-		manager.SetRepositories(mockDirRepo, mockFileRepo)
-
-		// 设置根目录缓存（模拟Start方法已执行）
-		rootDirID := int64(1)
-		manager.SetRootDirID(rootDirID)
-
-		// 预期行为 - 查询根目录
-		rootDir := models.DirectoryMetadata{
-			DirID:      rootDirID,
-			Name:       "/",
-			Path:       "/",
-			ParentID:   nil,
-			CreatedAt:  time.Now(),
-			ModifiedAt: time.Now(),
-		}
-		mockDirRepo.On("FindByID", ctx, rootDirID, mock.Anything).Run(func(args mock.Arguments) {
-			dest := args.Get(2).(*models.DirectoryMetadata)
-			*dest = rootDir
-		}).Return(nil)
+		manager, _ := newTestManager(t)
 
 		// 测试解析根路径
 		pathInfo, err := manager.ResolvePath(ctx, "/")
@@ -199,77 +63,12 @@ func TestNamespaceManager(t *testing.T) {
 		assert.True(t, pathInfo.Exists)
 		assert.True(t, pathInfo.IsDir)
 		assert.Equal(t, "/", pathInfo.Path)
-
-		// 验证调用
-		mockDirRepo.AssertExpectations(t)
 	})
 
 	t.Run("ResolvePath_DeepPath", func(t *testing.T) {
-		// 创建Mock对象
-		mockDirRepo := new(MockDirectoryRepository)
-		mockFileRepo := new(MockFileRepository)
-		mockLockMgr := new(MockLockManager)
-		mockDB := new(MockDBManager)
-		logger := logging.NewLogger()
-
-		// 创建namespace管理器
-		manager, err := namespace.NewManager(mockDB, mockLockMgr, logger)
-		require.NoError(t, err)
-		manager.SetRepositories(mockDirRepo, mockFileRepo)
-
-		// 设置根目录缓存（模拟Start方法已执行）
-		rootDirID := int64(1)
-		manager.SetRootDirID(rootDirID)
-
-		// 预期行为 - 查询整个路径
-		rootDir := models.DirectoryMetadata{
-			DirID:      rootDirID,
-			Name:       "/",
-			Path:       "/",
-			ParentID:   nil,
-			CreatedAt:  time.Now(),
-			ModifiedAt: time.Now(),
-		}
-
-		dir1 := models.DirectoryMetadata{
-			DirID:      2,
-			Name:       "dir1",
-			Path:       "/dir1",
-			ParentID:   &rootDirID,
-			CreatedAt:  time.Now(),
-			ModifiedAt: time.Now(),
-		}
-
-		file1 := models.FileMetadata{
-			FileID:      10,
-			Name:        "file.txt",
-			Size:        1024,
-			ParentDirID: dir1.DirID,
-			CreatedAt:   time.Now(),
-			ModifiedAt:  time.Now(),
-		}
-
-		// 设置根目录查询行为
-		mockDirRepo.On("FindByID", ctx, rootDirID, mock.Anything).Run(func(args mock.Arguments) {
-			dest := args.Get(2).(*models.DirectoryMetadata)
-			*dest = rootDir
-		}).Return(nil)
-
-		// dir1目录查询行为
-		mockDirRepo.On("FindOne", ctx, mock.Anything,
-			"parent_id = ? AND name = ? AND is_deleted = false", rootDirID, "dir1").
-			Run(func(args mock.Arguments) {
-				dest := args.Get(1).(*models.DirectoryMetadata)
-				*dest = dir1
-			}).Return(nil)
-
-		// file.txt文件查询行为
-		mockFileRepo.On("FindOne", ctx, mock.Anything,
-			"parent_dir_id = ? AND name = ? AND is_deleted = false", dir1.DirID, "file.txt").
-			Run(func(args mock.Arguments) {
-				dest := args.Get(1).(*models.FileMetadata)
-				*dest = file1
-			}).Return(nil)
+		manager, db := newTestManager(t)
+		dir1 := testutil.Mkdir(t, db, 0, testutil.RootDirID, "dir1")
+		fileID := testutil.SeedFile(t, db, models.FileMetadata{DirID: dir1, Name: "file.txt", Size: 1024, OwnerID: 1, Mode: 644})
 
 		// 测试解析路径
 		pathInfo, err := manager.ResolvePath(ctx, "/dir1/file.txt")
@@ -282,52 +81,16 @@ func TestNamespaceManager(t *testing.T) {
 		assert.Equal(t, "file.txt", pathInfo.Name)
 		assert.Equal(t, "/dir1", pathInfo.ParentPath)
 
-		// 验证调用
-		mockDirRepo.AssertExpectations(t)
-		mockFileRepo.AssertExpectations(t)
+		file, ok := pathInfo.Metadata.(models.FileMetadata)
+		require.True(t, ok)
+		assert.Equal(t, fileID, file.FileID)
+		assert.Equal(t, int64(1024), file.Size)
+		require.NotNil(t, pathInfo.ParentDir)
+		assert.Equal(t, dir1, pathInfo.ParentDir.DirID)
 	})
 
 	t.Run("ResolvePath_NonExistentPath", func(t *testing.T) {
-		// 创建Mock对象
-		mockDirRepo := new(MockDirectoryRepository)
-		mockFileRepo := new(MockFileRepository)
-		mockLockMgr := new(MockLockManager)
-		mockDB := new(MockDBManager)
-		logger := logging.NewLogger()
-
-		// 创建namespace管理器
-		manager, err := namespace.NewManager(mockDB, mockLockMgr, logger)
-		require.NoError(t, err)
-		manager.SetRepositories(mockDirRepo, mockFileRepo)
-
-		// 设置根目录缓存（模拟Start方法已执行）
-		rootDirID := int64(1)
-		manager.SetRootDirID(rootDirID)
-
-		// 预期行为 - 查询目录
-		rootDir := models.DirectoryMetadata{
-			DirID:      rootDirID,
-			Name:       "/",
-			Path:       "/",
-			ParentID:   nil,
-			CreatedAt:  time.Now(),
-			ModifiedAt: time.Now(),
-		}
-
-		// 设置根目录查询行为
-		mockDirRepo.On("FindByID", ctx, rootDirID, mock.Anything).Run(func(args mock.Arguments) {
-			dest := args.Get(2).(*models.DirectoryMetadata)
-			*dest = rootDir
-		}).Return(nil)
-
-		// 设置不存在目录的查询行为
-		mockDirRepo.On("FindOne", ctx, mock.Anything,
-			"parent_id = ? AND name = ? AND is_deleted = false", rootDirID, "nonexistent").
-			Return(errors.New("directory not found"))
-
-		mockFileRepo.On("FindOne", ctx, mock.Anything,
-			"parent_dir_id = ? AND name = ? AND is_deleted = false", rootDirID, "nonexistent").
-			Return(errors.New("file not found"))
+		manager, _ := newTestManager(t)
 
 		// 测试解析路径
 		pathInfo, err := manager.ResolvePath(ctx, "/nonexistent")
@@ -337,160 +100,84 @@ func TestNamespaceManager(t *testing.T) {
 		assert.Equal(t, "/nonexistent", pathInfo.Path)
 		assert.Equal(t, "nonexistent", pathInfo.Name)
 		assert.Equal(t, "/", pathInfo.ParentPath)
-
-		// 验证调用
-		mockDirRepo.AssertExpectations(t)
-		mockFileRepo.AssertExpectations(t)
 	})
 
 	t.Run("ListDirectory", func(t *testing.T) {
-		// 创建Mock对象
-		mockDirRepo := new(MockDirectoryRepository)
-		mockFileRepo := new(MockFileRepository)
-		mockLockMgr := new(MockLockManager)
-		mockDB := new(MockDBManager)
-		logger := logging.NewLogger()
-
-		// 创建namespace管理器
-		manager, err := namespace.NewManager(mockDB, mockLockMgr, logger)
-		require.NoError(t, err)
-		manager.SetRepositories(mockDirRepo, mockFileRepo)
-
-		// 设置根目录缓存（模拟Start方法已执行）
-		rootDirID := int64(1)
-		manager.SetRootDirID(rootDirID)
-
-		// 预期行为 - 目录列表查询
-		rootDir := models.DirectoryMetadata{
-			DirID:      rootDirID,
-			Name:       "/",
-			Path:       "/",
-			ParentID:   nil,
-			CreatedAt:  time.Now(),
-			ModifiedAt: time.Now(),
-		}
-
-		// 模拟子目录数据
-		childDirs := []models.DirectoryMetadata{
-			{
-				DirID:      2,
-				Name:       "dir1",
-				Path:       "/dir1",
-				ParentID:   &rootDirID,
-				CreatedAt:  time.Now(),
-				ModifiedAt: time.Now(),
-			},
-			{
-				DirID:      3,
-				Name:       "dir2",
-				Path:       "/dir2",
-				ParentID:   &rootDirID,
-				CreatedAt:  time.Now(),
-				ModifiedAt: time.Now(),
-			},
-		}
-
-		// 模拟子文件数据
-		childFiles := []models.FileMetadata{
-			{
-				FileID:      10,
-				Name:        "file1.txt",
-				Size:        1024,
-				ParentDirID: rootDirID,
-				CreatedAt:   time.Now(),
-				ModifiedAt:  time.Now(),
-			},
-			{
-				FileID:      11,
-				Name:        "file2.txt",
-				Size:        2048,
-				ParentDirID: rootDirID,
-				CreatedAt:   time.Now(),
-				ModifiedAt:  time.Now(),
-			},
-		}
-
-		// 设置根目录查询行为
-		mockDirRepo.On("FindByID", ctx, rootDirID, mock.Anything).Run(func(args mock.Arguments) {
-			dest := args.Get(2).(*models.DirectoryMetadata)
-			*dest = rootDir
-		}).Return(nil)
-
-		// 设置目录子项查询行为
-		mockDirRepo.On("FindAll", ctx, mock.Anything,
-			"parent_id = ? AND is_deleted = false", rootDirID).
-			Run(func(args mock.Arguments) {
-				dest := args.Get(1).(*[]models.DirectoryMetadata)
-				*dest = childDirs
-			}).Return(nil)
-
-		// 设置文件子项查询行为
-		mockFileRepo.On("FindAll", ctx, mock.Anything,
-			"parent_dir_id = ? AND is_deleted = false", rootDirID).
-			Run(func(args mock.Arguments) {
-				dest := args.Get(1).(*[]models.FileMetadata)
-				*dest = childFiles
-			}).Return(nil)
+		manager, db := newTestManager(t)
+		testutil.Mkdir(t, db, 0, testutil.RootDirID, "dir2")
+		testutil.Mkdir(t, db, 0, testutil.RootDirID, "dir1")
+		testutil.SeedFile(t, db, models.FileMetadata{DirID: testutil.RootDirID, Name: "file2.txt", Size: 2048, OwnerID: 1, Mode: 644})
+		testutil.SeedFile(t, db, models.FileMetadata{DirID: testutil.RootDirID, Name: "file1.txt", Size: 1024, OwnerID: 1, Mode: 644})
+		// 已删除的条目不列出
+		testutil.DeleteFile(t, db, testutil.CreateFile(t, db, 0, testutil.RootDirID, "gone.txt"))
 
 		// 测试列出目录内容
 		items, err := manager.ListDirectory(ctx, "/")
 		require.NoError(t, err)
-		assert.NotNil(t, items)
 		assert.Equal(t, 4, len(items)) // 2个目录 + 2个文件
 
-		// 验证调用
-		mockDirRepo.AssertExpectations(t)
-		mockFileRepo.AssertExpectations(t)
-
-		// 检查排序
-		assert.Equal(t, "dir1", items[0].Name) // 目录应该排在前面
-		assert.Equal(t, "dir2", items[1].Name)
-		assert.Equal(t, "file1.txt", items[2].Name)
-		assert.Equal(t, "file2.txt", items[3].Name)
+		// 默认按名称升序
+		assert.Equal(t, []string{"dir1", "dir2", "file1.txt", "file2.txt"}, names(items))
 	})
 
 	t.Run("ListDirectory_WithSort", func(t *testing.T) {
-		// 创建Mock对象
-		mockDirRepo := new(MockDirectoryRepository)
-		mockFileRepo := new(MockFileRepository)
-		mockLockMgr := new(MockLockManager)
-		mockDB := new(MockDBManager)
-		logger := logging.NewLogger()
+		manager, db := newTestManager(t)
+		testutil.Mkdir(t, db, 2, testutil.RootDirID, "dir1")
+		testutil.Mkdir(t, db, 3, testutil.RootDirID, "dir2")
+		testutil.SeedFile(t, db, models.FileMetadata{FileID: 10, DirID: testutil.RootDirID, Name: "file1.txt", Size: 1024, OwnerID: 1, Mode: 644})
+		testutil.SeedFile(t, db, models.FileMetadata{FileID: 11, DirID: testutil.RootDirID, Name: "file2.txt", Size: 2048, OwnerID: 1, Mode: 644})
 
-		// 创建namespace管理器
-		manager, err := namespace.NewManager(mockDB, mockLockMgr, logger)
+		// 目录和文件统一按名称降序排序
+		items, err := manager.ListDirectory(ctx, "/", namespace.WithSort("name", "desc"))
 		require.NoError(t, err)
-		manager.SetRepositories(mockDirRepo, mockFileRepo)
+		assert.Equal(t, []string{"file2.txt", "file1.txt", "dir2", "dir1"}, names(items))
 
-		// 设置根目录缓存（模拟Start方法已执行）
-		rootDirID := int64(1)
-		manager.SetRootDirID(rootDirID)
+		// 目录优先时目录和文件各自按名称降序排序
+		items, err = manager.ListDirectory(ctx, "/", namespace.WithSort("name", "desc"), namespace.WithDirectoriesFirst(true))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"dir2", "dir1", "file2.txt", "file1.txt"}, names(items))
 
-		// 与上一个测试相同的数据设置
-		// ...
+		// 按大小降序时目录大小视为0，大小相同的条目按ID排序
+		items, err = manager.ListDirectory(ctx, "/", namespace.WithSort("size", "desc"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"file2.txt", "file1.txt", "dir1", "dir2"}, names(items))
 
-		// 测试带排序的目录列表
-		items, err := manager.ListDirectory(ctx, "/", namespace.WithSort("name", "desc"))
+		// 无效的排序字段按名称升序
+		items, err = manager.ListDirectory(ctx, "/", namespace.WithSort("owner", "desc"))
 		require.NoError(t, err)
-		// 验证排序效果，这里需要实际根据排序选项实现排序
-		// ...
+		assert.Equal(t, []string{"dir1", "dir2", "file1.txt", "file2.txt"}, names(items))
 	})
 
-	t.Run("Stop", func(t *testing.T) {
-		// 创建Mock对象
-		mockDirRepo := new(MockDirectoryRepository)
-		mockFileRepo := new(MockFileRepository)
-		mockLockMgr := new(MockLockManager)
-		mockDB := new(MockDBManager)
-		logger := logging.NewLogger()
+	t.Run("Delete_RestoreRecursive", func(t *testing.T) {
+		manager, db := newTestManager(t)
+		docs := testutil.Mkdir(t, db, 0, testutil.RootDirID, "docs")
+		testutil.CreateFile(t, db, 0, docs, "a.txt")
+		testutil.CreateFile(t, db, 0, docs, "old.txt")
 
-		// 创建namespace管理器
-		manager, err := namespace.NewManager(mockDB, mockLockMgr, logger)
+		// old.txt先单独删除，之后整个docs在一次操作中删除
+		deleted, err := manager.Delete(ctx, "/docs/old.txt", false)
+		require.NoError(t, err)
+		assert.Equal(t, 1, deleted)
+		_, err = manager.Delete(ctx, "/docs", false)
+		assert.ErrorIs(t, err, database.ErrDirectoryNotEmpty)
+		deleted, err = manager.Delete(ctx, "/docs", true)
 		require.NoError(t, err)
-		manager.SetRepositories(mockDirRepo, mockFileRepo)
+		assert.Equal(t, 2, deleted)
+
+		// 递归恢复只恢复与docs同一次删除的条目
+		restored, err := manager.Restore(ctx, "/docs", true)
+		require.NoError(t, err)
+		assert.Equal(t, 2, restored)
+		items, err := manager.ListDirectory(ctx, "/docs")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a.txt"}, names(items))
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		manager, _ := newTestManager(t)
 
 		// 测试停止管理器
-		err = manager.Stop(ctx)
+		err := manager.Stop(ctx)
 		require.NoError(t, err)
 	})
 }
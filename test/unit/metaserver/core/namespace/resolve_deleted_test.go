@@ -0,0 +1,251 @@
+package namespace_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/logging"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/database"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/lock"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/namespace"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tombstoneRow 内存仓库中的一行，deleted表示已软删除
+type tombstoneRow struct {
+	id       int64
+	parentID int64
+	name     string
+	deleted  bool
+	dir      models.DirectoryMetadata
+	file     models.FileMetadata
+}
+
+// tombstoneRepo 按ResolvePath使用的查询条件过滤行的内存仓库，同时实现目录和文件仓库接口
+type tombstoneRepo struct {
+	rows []tombstoneRow
+}
+
+func (r *tombstoneRepo) match(query string, args []interface{}) []tombstoneRow {
+	var result []tombstoneRow
+	for _, row := range r.rows {
+		if row.parentID != args[0].(int64) || row.name != args[1].(string) {
+			continue
+		}
+		if strings.Contains(query, "is_deleted = false") && row.deleted {
+			continue
+		}
+		if strings.Contains(query, "is_deleted = true") && !row.deleted {
+			continue
+		}
+		result = append(result, row)
+	}
+	return result
+}
+
+func (r *tombstoneRepo) FindOne(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	rows := r.match(query, args)
+	if len(rows) == 0 {
+		return sql.ErrNoRows
+	}
+	switch d := dest.(type) {
+	case *models.DirectoryMetadata:
+		*d = rows[0].dir
+	case *models.FileMetadata:
+		*d = rows[0].file
+	default:
+		return fmt.Errorf("unexpected dest %T", dest)
+	}
+	return nil
+}
+
+func (r *tombstoneRepo) Find(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	slice := reflect.ValueOf(dest).Elem()
+	for _, row := range r.match(query, args) {
+		switch slice.Type().Elem() {
+		case reflect.TypeOf(models.DirectoryMetadata{}):
+			slice.Set(reflect.Append(slice, reflect.ValueOf(row.dir)))
+		case reflect.TypeOf(models.FileMetadata{}):
+			slice.Set(reflect.Append(slice, reflect.ValueOf(row.file)))
+		}
+	}
+	return nil
+}
+
+func (r *tombstoneRepo) FindAll(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return r.Find(ctx, dest, query, args...)
+}
+
+func (r *tombstoneRepo) FindPage(ctx context.Context, dest interface{}, page namespace.PageQuery) error {
+	return fmt.Errorf("unexpected FindPage %q", page.Where)
+}
+
+func (r *tombstoneRepo) FindByID(ctx context.Context, id int64, dest interface{}) error {
+	for _, row := range r.rows {
+		if row.id == id {
+			*dest.(*models.DirectoryMetadata) = row.dir
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (r *tombstoneRepo) Create(ctx context.Context, tx *sql.Tx, entity interface{}) (sql.Result, error) {
+	return nil, nil
+}
+
+func (r *tombstoneRepo) Update(ctx context.Context, tx *sql.Tx, entity interface{}) (sql.Result, error) {
+	return nil, nil
+}
+
+func (r *tombstoneRepo) Delete(ctx context.Context, tx *sql.Tx, id int64) (sql.Result, error) {
+	return nil, nil
+}
+
+func (r *tombstoneRepo) FindByParentAndName(ctx context.Context, parentID int64, name string, dest *models.DirectoryMetadata) error {
+	return r.FindOne(ctx, dest, "is_deleted = false", parentID, name)
+}
+
+func (r *tombstoneRepo) FindChildren(ctx context.Context, dirID int64) ([]models.DirectoryMetadata, error) {
+	return nil, nil
+}
+
+func (r *tombstoneRepo) FindByDirAndName(ctx context.Context, dirID int64, name string, dest *models.FileMetadata) error {
+	return r.FindOne(ctx, dest, "is_deleted = false", dirID, name)
+}
+
+func (r *tombstoneRepo) FindByDir(ctx context.Context, dirID int64) ([]models.FileMetadata, error) {
+	return nil, nil
+}
+
+func (r *tombstoneRepo) addDir(id, parentID int64, name string, deleted bool) {
+	r.rows = append(r.rows, tombstoneRow{id: id, parentID: parentID, name: name, deleted: deleted,
+		dir: models.DirectoryMetadata{DirID: id, ParentID: parentID, Name: name}})
+}
+
+func (r *tombstoneRepo) addFile(id, parentID int64, name string, size int64, deleted bool) {
+	r.rows = append(r.rows, tombstoneRow{id: id, parentID: parentID, name: name, deleted: deleted,
+		file: models.FileMetadata{FileID: id, DirID: parentID, Name: name, Size: size}})
+}
+
+// newTombstoneManager 创建使用内存仓库的命名空间管理器，目录树为：
+//
+//	/docs            存在
+//	/docs/report.txt 已删除两次（大小100和200），最近一次为200
+//	/docs/old        已删除的目录
+//	/docs/old/a.txt  未单独删除，但父目录已删除
+//	/trash           已删除的目录
+func newTombstoneManager(t *testing.T) *namespace.Manager {
+	db, err := database.NewManager(metaconfig.DatabaseConfig{Type: "sqlite3"}, logging.NewLogger())
+	require.NoError(t, err)
+	lockMgr, err := lock.NewManager(logging.NewLogger())
+	require.NoError(t, err)
+
+	manager, err := namespace.NewManager(db, lockMgr, logging.NewLogger())
+	require.NoError(t, err)
+
+	dirs := &tombstoneRepo{}
+	dirs.addDir(1, 0, "/", false)
+	dirs.addDir(2, 1, "docs", false)
+	dirs.addDir(3, 2, "old", true)
+	dirs.addDir(4, 1, "trash", true)
+
+	files := &tombstoneRepo{}
+	files.addFile(10, 2, "report.txt", 100, true)
+	files.addFile(11, 2, "report.txt", 200, true)
+	files.addFile(12, 3, "a.txt", 300, false)
+
+	manager.SetRepositories(dirs, files)
+	manager.SetRootDirID(1)
+	return manager
+}
+
+func TestResolvePathIncludeDeleted(t *testing.T) {
+	ctx := context.Background()
+	manager := newTombstoneManager(t)
+
+	t.Run("DeletedFileWithoutOption", func(t *testing.T) {
+		info, err := manager.ResolvePath(ctx, "/docs/report.txt")
+		require.NoError(t, err)
+		assert.False(t, info.Exists)
+		assert.False(t, info.Deleted)
+		assert.Nil(t, info.Metadata)
+	})
+
+	t.Run("DeletedFileWithOption", func(t *testing.T) {
+		info, err := manager.ResolvePath(ctx, "/docs/report.txt", namespace.IncludeDeleted())
+		require.NoError(t, err)
+		assert.False(t, info.Exists)
+		assert.True(t, info.Deleted)
+		assert.True(t, info.IsFile)
+		assert.Equal(t, "/docs/report.txt", info.Path)
+		assert.Equal(t, "/docs", info.ParentPath)
+		require.NotNil(t, info.ParentDir)
+		assert.Equal(t, int64(2), info.ParentDir.DirID)
+
+		// 多条删除记录时返回最近的一条
+		file, ok := info.Metadata.(models.FileMetadata)
+		require.True(t, ok)
+		assert.Equal(t, int64(11), file.FileID)
+		assert.Equal(t, int64(200), file.Size)
+	})
+
+	t.Run("NeverExistedWithOption", func(t *testing.T) {
+		info, err := manager.ResolvePath(ctx, "/docs/missing.txt", namespace.IncludeDeleted())
+		require.NoError(t, err)
+		assert.False(t, info.Exists)
+		assert.False(t, info.Deleted)
+		assert.Nil(t, info.Metadata)
+	})
+
+	t.Run("DeletedDirectory", func(t *testing.T) {
+		info, err := manager.ResolvePath(ctx, "/trash")
+		require.NoError(t, err)
+		assert.False(t, info.Exists)
+		assert.False(t, info.Deleted)
+
+		info, err = manager.ResolvePath(ctx, "/trash", namespace.IncludeDeleted())
+		require.NoError(t, err)
+		assert.False(t, info.Exists)
+		assert.True(t, info.Deleted)
+		assert.True(t, info.IsDir)
+		dir, ok := info.Metadata.(*models.DirectoryMetadata)
+		require.True(t, ok)
+		assert.Equal(t, int64(4), dir.DirID)
+	})
+
+	t.Run("EntryUnderDeletedDirectory", func(t *testing.T) {
+		info, err := manager.ResolvePath(ctx, "/docs/old/a.txt")
+		require.NoError(t, err)
+		assert.False(t, info.Exists)
+		assert.False(t, info.Deleted)
+
+		info, err = manager.ResolvePath(ctx, "/docs/old/a.txt", namespace.IncludeDeleted())
+		require.NoError(t, err)
+		assert.False(t, info.Exists)
+		assert.True(t, info.Deleted)
+		file, ok := info.Metadata.(models.FileMetadata)
+		require.True(t, ok)
+		assert.Equal(t, int64(12), file.FileID)
+
+		// 已删除目录下从未存在的路径仍然不是已删除
+		info, err = manager.ResolvePath(ctx, "/docs/old/b.txt", namespace.IncludeDeleted())
+		require.NoError(t, err)
+		assert.False(t, info.Deleted)
+	})
+
+	t.Run("LiveEntryUnaffected", func(t *testing.T) {
+		info, err := manager.ResolvePath(ctx, "/docs", namespace.IncludeDeleted())
+		require.NoError(t, err)
+		assert.True(t, info.Exists)
+		assert.False(t, info.Deleted)
+		assert.True(t, info.IsDir)
+	})
+}
@@ -0,0 +1,217 @@
+package placement_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/errors"
+	"github.com/22827099/DFS_v1/common/types"
+	metaconfig "github.com/22827099/DFS_v1/internal/metaserver/config"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/placement"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func node(id string, rack string, metrics *types.NodeMetrics) types.NodeInfo {
+	info := types.NodeInfo{NodeID: types.NodeID(id), Status: types.NodeStatusHealthy, Metrics: metrics}
+	if rack != "" {
+		info.Labels = map[string]string{types.LabelRack: rack}
+	}
+	return info
+}
+
+func ids(values ...string) []types.NodeID {
+	result := make([]types.NodeID, len(values))
+	for i, v := range values {
+		result[i] = types.NodeID(v)
+	}
+	return result
+}
+
+// rackedNodes 三个机架各两个节点，故意打乱顺序
+func rackedNodes() []types.NodeInfo {
+	return []types.NodeInfo{
+		node("n4", "b", nil), node("n1", "a", nil), node("n6", "c", nil),
+		node("n2", "a", nil), node("n5", "c", nil), node("n3", "b", nil),
+	}
+}
+
+func TestNew_SelectsStrategyFromConfig(t *testing.T) {
+	for name, expected := range map[string]string{
+		"":                             metaconfig.PlacementRoundRobin,
+		metaconfig.PlacementRoundRobin: metaconfig.PlacementRoundRobin,
+		metaconfig.PlacementLeastUsed:  metaconfig.PlacementLeastUsed,
+		metaconfig.PlacementRackAware:  metaconfig.PlacementRackAware,
+	} {
+		strategy, err := placement.New(metaconfig.PlacementConfig{Strategy: name})
+		require.NoError(t, err, name)
+		assert.Equal(t, expected, strategy.Name())
+	}
+
+	_, err := placement.New(metaconfig.PlacementConfig{Strategy: "random"})
+	assert.True(t, errors.IsInvalidArgument(err))
+}
+
+func TestRoundRobin_RotatesStartingNode(t *testing.T) {
+	strategy := placement.NewRoundRobin()
+	nodes := []types.NodeInfo{node("n3", "", nil), node("n1", "", nil), node("n4", "", nil), node("n2", "", nil)}
+
+	expected := [][]types.NodeID{
+		ids("n1", "n2", "n3"),
+		ids("n2", "n3", "n4"),
+		ids("n3", "n4", "n1"),
+		ids("n4", "n1", "n2"),
+		ids("n1", "n2", "n3"),
+	}
+	for i, want := range expected {
+		got, err := strategy.Place("/f#"+strconv.Itoa(i), nodes, 3)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, "第%d个块", i)
+	}
+}
+
+func TestLeastUsed_PrefersLowestDiskUsage(t *testing.T) {
+	strategy := placement.NewLeastUsed()
+	nodes := []types.NodeInfo{
+		node("n1", "", &types.NodeMetrics{DiskUsageRatio: 0.8}),
+		node("n2", "", &types.NodeMetrics{DiskUsageRatio: 0.2, ShardCount: 3}),
+		node("n3", "", &types.NodeMetrics{DiskUsageBytes: 50, DiskCapacityBytes: 100}),
+		node("n4", "", nil),
+		node("n5", "", &types.NodeMetrics{DiskUsageRatio: 0.2, ShardCount: 10}),
+	}
+
+	got, err := strategy.Place("/f#0", nodes, 3)
+	require.NoError(t, err)
+	assert.Equal(t, ids("n2", "n5", "n3"), got)
+
+	// 没有指标的节点排在最后
+	got, err = strategy.Place("/f#0", nodes, 5)
+	require.NoError(t, err)
+	assert.Equal(t, ids("n2", "n5", "n3", "n1", "n4"), got)
+}
+
+func TestRackAware_SpreadsReplicasAcrossRacks(t *testing.T) {
+	strategy := placement.NewRackAware(64)
+	nodes := rackedNodes()
+	racks := map[types.NodeID]string{}
+	for _, n := range nodes {
+		racks[n.NodeID] = placement.Rack(n)
+	}
+
+	for i := 0; i < 200; i++ {
+		key := placement.ChunkKey("/data/file", i)
+		got, err := strategy.Place(key, nodes, 3)
+		require.NoError(t, err)
+		require.Len(t, got, 3)
+
+		seen := map[string]bool{}
+		for _, id := range got {
+			seen[racks[id]] = true
+		}
+		assert.Len(t, seen, 3, "块%s的副本应分布在3个机架: %v", key, got)
+
+		// 同一节点集合和键总是得到相同的放置，与节点顺序无关
+		reversed := make([]types.NodeInfo, len(nodes))
+		for j, n := range nodes {
+			reversed[len(nodes)-1-j] = n
+		}
+		again, err := strategy.Place(key, reversed, 3)
+		require.NoError(t, err)
+		assert.Equal(t, got, again)
+	}
+}
+
+func TestRackAware_FillsFromUsedRacksWhenRacksRunOut(t *testing.T) {
+	strategy := placement.NewRackAware(64)
+	nodes := rackedNodes()
+
+	got, err := strategy.Place("/data/file#0", nodes, 4)
+	require.NoError(t, err)
+	require.Len(t, got, 4)
+
+	distinct := map[types.NodeID]bool{}
+	racks := map[string]bool{}
+	for i, id := range got {
+		distinct[id] = true
+		for _, n := range nodes {
+			if n.NodeID == id && i < 3 {
+				racks[placement.Rack(n)] = true
+			}
+		}
+	}
+	assert.Len(t, distinct, 4)
+	assert.Len(t, racks, 3, "前3个副本应覆盖全部机架")
+}
+
+func TestRackAware_AddingNodeMovesFewPrimaries(t *testing.T) {
+	strategy := placement.NewRackAware(64)
+	nodes := rackedNodes()
+	grown := append(append([]types.NodeInfo(nil), nodes...), node("n7", "d", nil))
+
+	const chunks = 2000
+	moved := 0
+	primaries := map[types.NodeID]int{}
+	for i := 0; i < chunks; i++ {
+		key := placement.ChunkKey("/data/file", i)
+		before, err := strategy.Place(key, nodes, 1)
+		require.NoError(t, err)
+		after, err := strategy.Place(key, grown, 1)
+		require.NoError(t, err)
+		if before[0] != after[0] {
+			moved++
+			assert.Equal(t, types.NodeID("n7"), after[0], "主副本只应迁到新节点")
+		}
+		primaries[before[0]]++
+	}
+
+	// 新节点约接管1/7的块，远少于重新取模时的大部分块
+	assert.Less(t, moved, chunks/4)
+	assert.Greater(t, moved, 0)
+	for _, n := range nodes {
+		assert.Greater(t, primaries[n.NodeID], chunks/len(nodes)/3, "节点%s负责的块过少", n.NodeID)
+	}
+}
+
+func TestStrategies_PlaceOnAvailableNodesWhenInsufficient(t *testing.T) {
+	nodes := []types.NodeInfo{node("n1", "a", nil), node("n2", "b", nil)}
+	for _, strategy := range []placement.Strategy{
+		placement.NewRoundRobin(), placement.NewLeastUsed(), placement.NewRackAware(8),
+	} {
+		// 节点少于副本数时放在全部节点上
+		got, err := strategy.Place("/f#0", nodes, 3)
+		require.NoError(t, err, strategy.Name())
+		assert.ElementsMatch(t, ids("n1", "n2"), got, strategy.Name())
+
+		_, err = strategy.Place("/f#0", nil, 3)
+		assert.True(t, errors.IsErrorCode(err, errors.Unavailable), strategy.Name())
+
+		_, err = strategy.Place("/f#0", nodes, 0)
+		assert.True(t, errors.IsInvalidArgument(err), strategy.Name())
+	}
+}
+
+func TestPlacer_PlacesEveryChunkOnLiveNodes(t *testing.T) {
+	nodes := []types.NodeInfo{node("n1", "", nil), node("n2", "", nil), node("n3", "", nil)}
+	nodes[1].Status = types.NodeStatusDead
+	source := func(ctx context.Context) ([]types.NodeInfo, error) { return nodes, nil }
+
+	placer := placement.NewPlacer(placement.NewRoundRobin(), source, 2)
+	placed, err := placer.PlaceChunks(context.Background(), "/f", 3, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, placed.Replicas)
+	assert.Equal(t, [][]types.NodeID{ids("n1", "n3"), ids("n3", "n1"), ids("n1", "n3")}, placed.Chunks)
+	assert.Zero(t, placed.Shortfall())
+
+	// 指定的副本数超过存活节点数时放在全部存活节点上，并报告缺少的副本数
+	placed, err = placer.PlaceChunks(context.Background(), "/f", 1, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, placed.Replicas)
+	assert.ElementsMatch(t, ids("n1", "n3"), placed.Chunks[0])
+	assert.Equal(t, 1, placed.Shortfall())
+
+	// 没有存活节点时失败
+	nodes[0].Status, nodes[2].Status = types.NodeStatusDead, types.NodeStatusDead
+	_, err = placer.PlaceChunks(context.Background(), "/f", 1, 3)
+	assert.True(t, errors.IsErrorCode(err, errors.Unavailable))
+}
@@ -0,0 +1,183 @@
+package user_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/errors"
+	"github.com/22827099/DFS_v1/common/security/auth"
+	"github.com/22827099/DFS_v1/common/security/token"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/database"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/acl"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/user"
+	"github.com/22827099/DFS_v1/internal/metaserver/server/middleware"
+	"github.com/22827099/DFS_v1/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 用户服务直接用作认证中间件的令牌验证服务
+var _ middleware.AuthService = (*user.Service)(nil)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// newTestService 创建基于临时SQLite数据库的用户服务
+func newTestService(t *testing.T, opts ...user.ServiceOption) (*database.Manager, *user.Service) {
+	t.Helper()
+	db := testutil.NewMetaDB(t)
+
+	maker, err := token.NewJWTMaker(testSecret)
+	require.NoError(t, err)
+	svc, err := user.NewService(db, maker, opts...)
+	require.NoError(t, err)
+	return db, svc
+}
+
+func TestCreateUser_StoresSaltedHash(t *testing.T) {
+	db, svc := newTestService(t)
+	ctx := context.Background()
+
+	alice, err := svc.Create(ctx, "alice", "correct-horse")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", alice.Username)
+	assert.Equal(t, user.StatusActive, alice.Status)
+	assert.Greater(t, alice.ID, user.SystemUserID)
+
+	bob, err := svc.Create(ctx, "bob", "correct-horse")
+	require.NoError(t, err)
+	assert.NotEqual(t, alice.ID, bob.ID)
+
+	// 相同密码因盐值不同得到不同哈希，且不以明文存储
+	var hashes, salts []string
+	for _, id := range []int{alice.ID, bob.ID} {
+		var hash, salt string
+		require.NoError(t, db.QueryRowContext(ctx,
+			"SELECT password_hash, salt FROM users WHERE user_id = ?", id).Scan(&hash, &salt))
+		assert.NotContains(t, hash, "correct-horse")
+		assert.Len(t, salt, 32)
+		hashes = append(hashes, hash)
+		salts = append(salts, salt)
+	}
+	assert.NotEqual(t, salts[0], salts[1])
+	assert.NotEqual(t, hashes[0], hashes[1])
+}
+
+func TestCreateUser_RejectsDuplicateUsername(t *testing.T) {
+	_, svc := newTestService(t)
+	ctx := context.Background()
+
+	_, err := svc.Create(ctx, "alice", "correct-horse")
+	require.NoError(t, err)
+
+	_, err = svc.Create(ctx, "alice", "another-password")
+	assert.True(t, errors.IsAlreadyExists(err), "重复用户名应返回AlreadyExists: %v", err)
+
+	// 数据库初始化时创建的系统用户同样占用用户名
+	_, err = svc.Create(ctx, "system", "correct-horse")
+	assert.True(t, errors.IsAlreadyExists(err))
+}
+
+func TestCreateUser_RejectsInvalidInput(t *testing.T) {
+	_, svc := newTestService(t)
+
+	_, err := svc.Create(context.Background(), "  ", "correct-horse")
+	assert.True(t, errors.IsInvalidArgument(err))
+	_, err = svc.Create(context.Background(), "alice", "short")
+	assert.True(t, errors.IsInvalidArgument(err))
+}
+
+func TestLogin_IssuesTokenAcceptedByMiddleware(t *testing.T) {
+	_, svc := newTestService(t, user.WithAdminUsers("alice"))
+	ctx := context.Background()
+
+	created, err := svc.Create(ctx, "alice", "correct-horse")
+	require.NoError(t, err)
+
+	tokenStr, loggedIn, err := svc.Login(ctx, "alice", "correct-horse")
+	require.NoError(t, err)
+	require.NotEmpty(t, tokenStr)
+	assert.Equal(t, created.ID, loggedIn.ID)
+
+	info, err := svc.VerifyToken(tokenStr)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(created.ID), info.UserID)
+	assert.Equal(t, "alice", info.Username)
+	assert.Equal(t, []auth.Role{auth.RoleAdmin}, info.Roles)
+	assert.True(t, svc.HasPermission(info, "/api/v1/admin/users", "write"))
+}
+
+func TestLogin_RejectsWrongPassword(t *testing.T) {
+	_, svc := newTestService(t)
+	ctx := context.Background()
+
+	_, err := svc.Create(ctx, "alice", "correct-horse")
+	require.NoError(t, err)
+
+	_, _, err = svc.Login(ctx, "alice", "wrong-password")
+	assert.True(t, errors.IsUnauthenticated(err), "密码错误应返回Unauthenticated: %v", err)
+
+	// 不存在的用户返回相同错误，不泄露用户名是否存在
+	_, _, errUnknown := svc.Login(ctx, "mallory", "wrong-password")
+	assert.True(t, errors.IsUnauthenticated(errUnknown))
+	assert.Equal(t, errors.GetMessage(err), errors.GetMessage(errUnknown))
+}
+
+func TestHasPermission_AdminPathsRequireAdmin(t *testing.T) {
+	_, svc := newTestService(t)
+	ctx := context.Background()
+
+	_, err := svc.Create(ctx, "bob", "correct-horse")
+	require.NoError(t, err)
+	tokenStr, _, err := svc.Login(ctx, "bob", "correct-horse")
+	require.NoError(t, err)
+
+	info, err := svc.VerifyToken(tokenStr)
+	require.NoError(t, err)
+	assert.Equal(t, []auth.Role{auth.RoleUser}, info.Roles)
+	assert.False(t, svc.HasPermission(info, "/api/v1/admin/users", "write"))
+	assert.True(t, svc.HasPermission(info, "/api/v1/files/a.txt", "read"))
+}
+
+func TestDeleteUser_InvalidatesToken(t *testing.T) {
+	_, svc := newTestService(t)
+	ctx := context.Background()
+
+	created, err := svc.Create(ctx, "alice", "correct-horse")
+	require.NoError(t, err)
+	tokenStr, _, err := svc.Login(ctx, "alice", "correct-horse")
+	require.NoError(t, err)
+
+	require.NoError(t, svc.Delete(ctx, created.ID))
+	_, err = svc.VerifyToken(tokenStr)
+	assert.Error(t, err, "删除用户后令牌应失效")
+
+	_, _, err = svc.Login(ctx, "alice", "correct-horse")
+	assert.True(t, errors.IsUnauthenticated(err))
+
+	assert.True(t, errors.IsNotFound(svc.Delete(ctx, created.ID)))
+	assert.True(t, errors.IsPermissionDenied(svc.Delete(ctx, user.SystemUserID)))
+}
+
+func TestDeleteUser_RemovesGrantsAndDoesNotReuseID(t *testing.T) {
+	db, svc := newTestService(t)
+	ctx := context.Background()
+	grants := acl.NewDBStore(db)
+
+	alice, err := svc.Create(ctx, "alice", "correct-horse")
+	require.NoError(t, err)
+	require.NoError(t, grants.Grant(ctx, alice.ID, acl.ObjectDirectory, 1, acl.PermissionWrite))
+
+	require.NoError(t, svc.Delete(ctx, alice.ID))
+	var remaining int
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT COUNT(*) FROM permissions WHERE user_id = ?", alice.ID).Scan(&remaining))
+	assert.Zero(t, remaining, "删除用户应同时删除其授权")
+
+	// 新用户不会拿到已删除用户的ID
+	bob, err := svc.Create(ctx, "bob", "correct-horse")
+	require.NoError(t, err)
+	assert.Greater(t, bob.ID, alice.ID)
+	perms, err := grants.Grants(ctx, bob.ID, acl.ObjectDirectory, 1)
+	require.NoError(t, err)
+	assert.Empty(t, perms)
+}
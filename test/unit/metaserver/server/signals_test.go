@@ -0,0 +1,59 @@
+package server_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/config"
+	"github.com/22827099/DFS_v1/common/logging"
+	"github.com/22827099/DFS_v1/internal/metaserver/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConfig 在临时目录中写入元数据服务器配置文件
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "metaserver.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestHandleSignals_ReloadsOnSIGHUP(t *testing.T) {
+	srv, err := server.NewServer(&config.SystemConfig{NodeID: "1"})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	logger := logging.GetLogger("signals_test")
+	logger.SetOutput(&buf)
+	defer logging.SetGlobalLevel(logging.LevelInfo)
+
+	valid := writeConfig(t, `{"logging": {"level": "debug"}, "cluster": {"node_id": "1", "bootstrap": true}}`)
+	signals := make(chan os.Signal, 2)
+	signals <- syscall.SIGHUP
+	signals <- syscall.SIGTERM
+
+	assert.Equal(t, syscall.SIGTERM, srv.HandleSignals(signals, valid))
+	logger.Debug("重载后的调试日志")
+	assert.Contains(t, buf.String(), "重载后的调试日志")
+}
+
+func TestHandleSignals_KeepsRunningWhenReloadFails(t *testing.T) {
+	srv, err := server.NewServer(&config.SystemConfig{NodeID: "1"})
+	require.NoError(t, err)
+
+	// 既不引导也不加入集群的配置无法通过校验
+	invalid := writeConfig(t, `{"cluster": {"node_id": "1"}}`)
+	signals := make(chan os.Signal, 3)
+	signals <- syscall.SIGHUP
+	signals <- syscall.SIGHUP
+	signals <- syscall.SIGINT
+
+	assert.Equal(t, syscall.SIGINT, srv.HandleSignals(signals, invalid))
+
+	close(signals)
+	assert.Nil(t, srv.HandleSignals(signals, invalid))
+}
@@ -0,0 +1,177 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/errors"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/events"
+	"github.com/22827099/DFS_v1/internal/metaserver/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBatchStore 构造 /a/f1.txt、/b/f2.txt、/c/ 三个目录和两个文件
+func newBatchStore(t *testing.T) (*server.MemoryStore, *events.Log) {
+	store := newInitializedStore(t)
+	for _, p := range []string{"/a/f1.txt", "/b/f2.txt"} {
+		var file metadata.FileInfo
+		file.Path = p
+		_, err := store.CreateFileWithParents(context.Background(), file)
+		require.NoError(t, err)
+	}
+	var dir metadata.DirectoryInfo
+	dir.Path = "/c"
+	_, err := store.CreateDirectory(context.Background(), dir)
+	require.NoError(t, err)
+
+	log := events.NewLog(100)
+	store.SetEventLog(log)
+	return store, log
+}
+
+// assertUnchanged 检查批量移动失败后存储保持原样
+func assertUnchanged(t *testing.T, store *server.MemoryStore, log *events.Log) {
+	t.Helper()
+	ctx := context.Background()
+	for _, p := range []string{"/a/f1.txt", "/b/f2.txt"} {
+		info, err := store.GetFileInfo(ctx, p)
+		require.NoError(t, err, p)
+		assert.Equal(t, p, info.Path)
+	}
+	for dir, count := range map[string]int{"/a": 1, "/b": 1, "/c": 0} {
+		entries, err := store.ListDirectory(ctx, dir, false, 0)
+		require.NoError(t, err, dir)
+		assert.Len(t, entries, count, dir)
+	}
+	entries, err := store.ListDirectory(ctx, "/", false, 0)
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+	assert.Equal(t, uint64(0), log.LastSeq(), "失败的批量移动不应产生事件")
+}
+
+func TestMoveBatch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("MovesAllEntries", func(t *testing.T) {
+		store, log := newBatchStore(t)
+
+		err := store.MoveBatch(ctx, []metadata.MoveOp{
+			{Src: "/a/f1.txt", Dst: "/c/renamed.txt"},
+			{Src: "/b", Dst: "/c/b"},
+			// 源路径按批量移动前的状态解析，嵌套在其他源中的条目先被取出
+			{Src: "/b/f2.txt", Dst: "/c/f2.txt"},
+		})
+		require.NoError(t, err)
+
+		for _, p := range []string{"/c/renamed.txt", "/c/f2.txt"} {
+			_, err := store.GetFileInfo(ctx, p)
+			assert.NoError(t, err, p)
+		}
+		entries, err := store.ListDirectory(ctx, "/c", false, 0)
+		require.NoError(t, err)
+		assert.Len(t, entries, 3)
+		entries, err = store.ListDirectory(ctx, "/c/b", false, 0)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+		entries, err = store.ListDirectory(ctx, "/", false, 0)
+		require.NoError(t, err)
+		assert.Len(t, entries, 2, "临时名称不应残留")
+
+		list, _, err := log.Since(0, 0)
+		require.NoError(t, err)
+		require.Len(t, list, 3)
+		assert.Equal(t, "/a/f1.txt", list[0].OldPath)
+		assert.Equal(t, "/c/b", list[1].Path)
+		assert.True(t, list[1].IsDir)
+		assert.Equal(t, "/b/f2.txt", list[2].OldPath)
+		assert.Equal(t, "/c/f2.txt", list[2].Path)
+	})
+
+	t.Run("SwapsEntries", func(t *testing.T) {
+		store, log := newBatchStore(t)
+
+		err := store.MoveBatch(ctx, []metadata.MoveOp{
+			{Src: "/a", Dst: "/b"},
+			{Src: "/b", Dst: "/a"},
+		})
+		require.NoError(t, err)
+
+		for p, name := range map[string]string{"/b/f1.txt": "f1.txt", "/a/f2.txt": "f2.txt"} {
+			info, err := store.GetFileInfo(ctx, p)
+			require.NoError(t, err, p)
+			assert.Equal(t, name, info.Name)
+		}
+		_, err = store.GetFileInfo(ctx, "/a/f1.txt")
+		assert.True(t, errors.IsNotFound(err))
+		entries, err := store.ListDirectory(ctx, "/", false, 0)
+		require.NoError(t, err)
+		names := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			names = append(names, entry.Name)
+		}
+		assert.ElementsMatch(t, []string{"a", "b", "c"}, names, "交换后的目录使用新路径的名称")
+		assert.Equal(t, uint64(2), log.LastSeq())
+	})
+
+	t.Run("RotatesEntriesIntoMovedDirectory", func(t *testing.T) {
+		store, _ := newBatchStore(t)
+
+		// /c占用/a原来的位置，目标路径按移动后的状态解析，/a/old-a位于新的/a（原来的/c）之下
+		err := store.MoveBatch(ctx, []metadata.MoveOp{
+			{Src: "/a", Dst: "/a/old-a"},
+			{Src: "/c", Dst: "/a"},
+			{Src: "/b/f2.txt", Dst: "/a/f2.txt"},
+		})
+		require.NoError(t, err)
+
+		for _, p := range []string{"/a/old-a/f1.txt", "/a/f2.txt"} {
+			_, err := store.GetFileInfo(ctx, p)
+			assert.NoError(t, err, p)
+		}
+		entries, err := store.ListDirectory(ctx, "/", false, 0)
+		require.NoError(t, err)
+		assert.Len(t, entries, 2)
+	})
+
+	t.Run("RejectsDestinationCollision", func(t *testing.T) {
+		store, log := newBatchStore(t)
+
+		err := store.MoveBatch(ctx, []metadata.MoveOp{
+			{Src: "/a/f1.txt", Dst: "/c/x.txt"},
+			{Src: "/b/f2.txt", Dst: "/c/./x.txt"},
+		})
+		assert.True(t, errors.IsInvalidArgument(err))
+		assertUnchanged(t, store, log)
+	})
+
+	t.Run("RejectsCycle", func(t *testing.T) {
+		store, log := newBatchStore(t)
+
+		// /a移到/b下，同时/b移到/a下
+		err := store.MoveBatch(ctx, []metadata.MoveOp{
+			{Src: "/a", Dst: "/b/a"},
+			{Src: "/b", Dst: "/a/b"},
+		})
+		assert.True(t, errors.IsInvalidArgument(err))
+		assertUnchanged(t, store, log)
+
+		// 目录移到自身子树下
+		err = store.MoveBatch(ctx, []metadata.MoveOp{{Src: "/a", Dst: "/a/inner"}})
+		assert.True(t, errors.IsInvalidArgument(err))
+		assertUnchanged(t, store, log)
+	})
+
+	t.Run("RollsBackWhenLaterMoveFails", func(t *testing.T) {
+		store, log := newBatchStore(t)
+
+		err := store.MoveBatch(ctx, []metadata.MoveOp{
+			{Src: "/a/f1.txt", Dst: "/c/f1.txt"},
+			{Src: "/b", Dst: "/c/b"},
+			{Src: "/missing", Dst: "/c/missing"},
+		})
+		assert.True(t, errors.IsNotFound(err))
+		assertUnchanged(t, store, log)
+	})
+}
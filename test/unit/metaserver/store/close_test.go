@@ -0,0 +1,62 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/errors"
+	"github.com/22827099/DFS_v1/common/types"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+	"github.com/22827099/DFS_v1/internal/metaserver/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_CloseIsIdempotent(t *testing.T) {
+	store, err := server.NewMemoryStore()
+	require.NoError(t, err)
+	require.NoError(t, store.Initialize())
+
+	ctx := context.Background()
+	assert.NoError(t, store.Close(ctx))
+	assert.NoError(t, store.Close(ctx), "重复关闭不应返回错误")
+
+	// 未初始化的存储同样可以关闭
+	fresh, err := server.NewMemoryStore()
+	require.NoError(t, err)
+	assert.NoError(t, fresh.Close(ctx))
+	assert.NoError(t, fresh.Close(ctx))
+}
+
+func TestMemoryStore_OperationsAfterClose(t *testing.T) {
+	store, err := server.NewMemoryStore()
+	require.NoError(t, err)
+	require.NoError(t, store.Initialize())
+
+	ctx := context.Background()
+	_, err = store.CreateFile(ctx, metadata.FileInfo{BasicFileInfo: types.BasicFileInfo{Path: "/a.txt", Name: "a.txt", CreatedAt: time.Now()}})
+	require.NoError(t, err)
+	require.NoError(t, store.Close(ctx))
+
+	_, err = store.GetFileInfo(ctx, "/a.txt")
+	assert.True(t, errors.IsErrorCode(err, errors.Unavailable), "关闭后读取应返回Unavailable: %v", err)
+	_, err = store.CreateFile(ctx, metadata.FileInfo{BasicFileInfo: types.BasicFileInfo{Path: "/b.txt", Name: "b.txt"}})
+	assert.True(t, errors.IsErrorCode(err, errors.Unavailable))
+	_, err = store.ListDirectory(ctx, "/", false, 0)
+	assert.True(t, errors.IsErrorCode(err, errors.Unavailable))
+	assert.True(t, errors.IsErrorCode(store.DeleteFile(ctx, "/a.txt"), errors.Unavailable))
+
+	// 关闭的存储不能重新初始化
+	assert.True(t, errors.IsErrorCode(store.Initialize(), errors.Unavailable))
+}
+
+func TestMemoryStore_OperationsBeforeInitialize(t *testing.T) {
+	store, err := server.NewMemoryStore()
+	require.NoError(t, err)
+
+	_, err = store.GetFileInfo(context.Background(), "/a.txt")
+	require.Error(t, err)
+	assert.False(t, errors.IsErrorCode(err, errors.Unavailable), "未初始化与已关闭应能区分")
+	assert.Contains(t, err.Error(), "未初始化")
+}
@@ -0,0 +1,127 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/errors"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+	"github.com/22827099/DFS_v1/internal/metaserver/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newInitializedStore(t *testing.T) *server.MemoryStore {
+	store, err := server.NewMemoryStore()
+	require.NoError(t, err)
+	require.NoError(t, store.Initialize())
+	return store
+}
+
+func TestCreateParents(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("DeepFileCreation", func(t *testing.T) {
+		store := newInitializedStore(t)
+
+		file := metadata.FileInfo{}
+		file.Path = "/a/b/c/file.txt"
+		result, err := store.CreateFileWithParents(ctx, file)
+		require.NoError(t, err)
+		assert.Equal(t, "/a/b/c/file.txt", result.Path)
+
+		for _, dir := range []string{"/", "/a", "/a/b", "/a/b/c"} {
+			entries, err := store.ListDirectory(ctx, dir, false, 0)
+			require.NoError(t, err, dir)
+			assert.Len(t, entries, 1, dir)
+		}
+
+		// 不带create_parents时父目录缺失仍然报错
+		file.Path = "/x/y/file.txt"
+		_, err = store.CreateFile(ctx, file)
+		assert.True(t, errors.IsNotFound(err))
+	})
+
+	t.Run("DeepDirectoryCreation", func(t *testing.T) {
+		store := newInitializedStore(t)
+
+		dir := metadata.DirectoryInfo{}
+		dir.Path = "/a/b/c"
+		result, err := store.CreateDirectoryWithParents(ctx, dir)
+		require.NoError(t, err)
+		assert.Equal(t, "/a/b/c/", result.Path)
+		assert.Equal(t, "c", result.Name)
+
+		// 中间目录可以作为普通目录的父目录使用
+		dir.Path = "/a/b/d"
+		_, err = store.CreateDirectory(ctx, dir)
+		require.NoError(t, err)
+
+		entries, err := store.ListDirectory(ctx, "/a/b", false, 0)
+		require.NoError(t, err)
+		assert.Len(t, entries, 2)
+	})
+
+	t.Run("FileAsAncestorConflict", func(t *testing.T) {
+		store := newInitializedStore(t)
+
+		dir := metadata.DirectoryInfo{}
+		dir.Path = "/a"
+		_, err := store.CreateDirectory(ctx, dir)
+		require.NoError(t, err)
+
+		file := metadata.FileInfo{}
+		file.Path = "/a/b"
+		_, err = store.CreateFile(ctx, file)
+		require.NoError(t, err)
+
+		file.Path = "/a/b/c/file.txt"
+		_, err = store.CreateFileWithParents(ctx, file)
+		require.Error(t, err)
+		assert.True(t, errors.IsAlreadyExists(err))
+		assert.Contains(t, err.Error(), "/a/b")
+
+		dir.Path = "/a/b/c"
+		_, err = store.CreateDirectoryWithParents(ctx, dir)
+		assert.True(t, errors.IsAlreadyExists(err))
+
+		// 冲突时不会留下部分创建的目录
+		entries, err := store.ListDirectory(ctx, "/a", true, 0)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.False(t, entries[0].IsDir)
+	})
+
+	t.Run("IdempotentWithExistingAncestors", func(t *testing.T) {
+		store := newInitializedStore(t)
+
+		dir := metadata.DirectoryInfo{}
+		dir.Path = "/a/b"
+		_, err := store.CreateDirectoryWithParents(ctx, dir)
+		require.NoError(t, err)
+
+		dir.Path = "/a/b/c/d"
+		_, err = store.CreateDirectoryWithParents(ctx, dir)
+		require.NoError(t, err)
+
+		// 重复创建返回已有目录
+		again, err := store.CreateDirectoryWithParents(ctx, dir)
+		require.NoError(t, err)
+		assert.Equal(t, "/a/b/c/d/", again.Path)
+
+		file := metadata.FileInfo{}
+		file.Path = "/a/b/file.txt"
+		_, err = store.CreateFileWithParents(ctx, file)
+		require.NoError(t, err)
+
+		// 已存在的祖先目录不会被重复创建，子项计数保持准确
+		entries, err := store.ListDirectory(ctx, "/a", false, 0)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, 2, entries[0].ChildCount)
+
+		// 文件本身已存在时仍然报错
+		_, err = store.CreateFileWithParents(ctx, file)
+		assert.True(t, errors.IsAlreadyExists(err))
+	})
+}
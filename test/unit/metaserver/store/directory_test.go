@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/22827099/DFS_v1/common/types"
 	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
 	"github.com/22827099/DFS_v1/internal/metaserver/server"
 	"github.com/stretchr/testify/assert"
@@ -19,8 +20,10 @@ func TestDirectoryOperations(t *testing.T) {
 
 		// 创建目录
 		dirInfo := metadata.DirectoryInfo{
-			Path: "/test_dir",
-			Name: "test_dir",
+			BasicFileInfo: types.BasicFileInfo{
+				Path: "/test_dir",
+				Name: "test_dir",
+			},
 		}
 		result, err := store.CreateDirectory(context.Background(), dirInfo)
 		require.NoError(t, err)
@@ -29,8 +32,10 @@ func TestDirectoryOperations(t *testing.T) {
 
 		// 测试创建嵌套目录
 		nestedDir := metadata.DirectoryInfo{
-			Path: "/test_dir/nested",
-			Name: "nested",
+			BasicFileInfo: types.BasicFileInfo{
+				Path: "/test_dir/nested",
+				Name: "nested",
+			},
 		}
 		result, err = store.CreateDirectory(context.Background(), nestedDir)
 		require.NoError(t, err)
@@ -43,8 +48,10 @@ func TestDirectoryOperations(t *testing.T) {
 
 		// 测试创建父目录不存在的目录
 		invalidDir := metadata.DirectoryInfo{
-			Path: "/non_existent/subdir",
-			Name: "subdir",
+			BasicFileInfo: types.BasicFileInfo{
+				Path: "/non_existent/subdir",
+				Name: "subdir",
+			},
 		}
 		_, err = store.CreateDirectory(context.Background(), invalidDir)
 		assert.Error(t, err)
@@ -58,8 +65,10 @@ func TestDirectoryOperations(t *testing.T) {
 
 		// 创建目录
 		dirInfo := metadata.DirectoryInfo{
-			Path: "/dir_to_delete",
-			Name: "dir_to_delete",
+			BasicFileInfo: types.BasicFileInfo{
+				Path: "/dir_to_delete",
+				Name: "dir_to_delete",
+			},
 		}
 		_, err = store.CreateDirectory(context.Background(), dirInfo)
 		require.NoError(t, err)
@@ -78,22 +87,28 @@ func TestDirectoryOperations(t *testing.T) {
 		// 测试递归删除
 		// 创建有嵌套内容的目录
 		parentDir := metadata.DirectoryInfo{
-			Path: "/parent_dir",
-			Name: "parent_dir",
+			BasicFileInfo: types.BasicFileInfo{
+				Path: "/parent_dir",
+				Name: "parent_dir",
+			},
 		}
 		_, err = store.CreateDirectory(context.Background(), parentDir)
 		require.NoError(t, err)
 
 		childDir := metadata.DirectoryInfo{
-			Path: "/parent_dir/child_dir",
-			Name: "child_dir",
+			BasicFileInfo: types.BasicFileInfo{
+				Path: "/parent_dir/child_dir",
+				Name: "child_dir",
+			},
 		}
 		_, err = store.CreateDirectory(context.Background(), childDir)
 		require.NoError(t, err)
 
 		fileInfo := metadata.FileInfo{
-			Path: "/parent_dir/test.txt",
-			Name: "test.txt",
+			BasicFileInfo: types.BasicFileInfo{
+				Path: "/parent_dir/test.txt",
+				Name: "test.txt",
+			},
 			Size: 1024,
 		}
 		_, err = store.CreateFile(context.Background(), fileInfo)
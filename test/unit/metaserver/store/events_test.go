@@ -0,0 +1,131 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/errors"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStoreEvents(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("EmitsEventPerMutationInOrder", func(t *testing.T) {
+		store := newInitializedStore(t)
+		log := events.NewLog(100)
+		store.SetEventLog(log)
+
+		var dir metadata.DirectoryInfo
+		dir.Path = "/docs"
+		_, err := store.CreateDirectory(ctx, dir)
+		require.NoError(t, err)
+
+		var file metadata.FileInfo
+		file.Path = "/docs/a.txt"
+		_, err = store.CreateFile(ctx, file)
+		require.NoError(t, err)
+
+		_, err = store.UpdateFile(ctx, "/docs/a.txt", map[string]interface{}{"size": int64(10)})
+		require.NoError(t, err)
+
+		require.NoError(t, store.Move(ctx, "/docs/a.txt", "/docs/b.txt"))
+		require.NoError(t, store.Move(ctx, "/docs", "/archive"))
+		require.NoError(t, store.DeleteFile(ctx, "/archive/b.txt"))
+		require.NoError(t, store.DeleteDirectory(ctx, "/archive", false))
+
+		list, cursor, err := log.Since(0, 0)
+		require.NoError(t, err)
+		expected := []events.Event{
+			{Seq: 1, Type: events.Create, Path: "/docs", IsDir: true},
+			{Seq: 2, Type: events.Create, Path: "/docs/a.txt"},
+			{Seq: 3, Type: events.Update, Path: "/docs/a.txt"},
+			{Seq: 4, Type: events.Move, Path: "/docs/b.txt", OldPath: "/docs/a.txt"},
+			{Seq: 5, Type: events.Move, Path: "/archive", OldPath: "/docs", IsDir: true},
+			{Seq: 6, Type: events.Delete, Path: "/archive/b.txt"},
+			{Seq: 7, Type: events.Delete, Path: "/archive", IsDir: true},
+		}
+		require.Len(t, list, len(expected))
+		for i, e := range list {
+			assert.False(t, e.Timestamp.IsZero())
+			e.Timestamp = expected[i].Timestamp
+			assert.Equal(t, expected[i], e)
+		}
+		assert.Equal(t, uint64(7), cursor)
+	})
+
+	t.Run("FailedMutationsEmitNothing", func(t *testing.T) {
+		store := newInitializedStore(t)
+		log := events.NewLog(100)
+		store.SetEventLog(log)
+
+		var file metadata.FileInfo
+		file.Path = "/missing/a.txt"
+		_, err := store.CreateFile(ctx, file)
+		assert.True(t, errors.IsNotFound(err))
+		assert.True(t, errors.IsNotFound(store.DeleteFile(ctx, "/a.txt")))
+		assert.True(t, errors.IsNotFound(store.Move(ctx, "/a.txt", "/b.txt")))
+
+		assert.Equal(t, uint64(0), log.LastSeq())
+	})
+
+	t.Run("CreateParentsEmitsAncestorsFirst", func(t *testing.T) {
+		store := newInitializedStore(t)
+		log := events.NewLog(100)
+		store.SetEventLog(log)
+
+		var file metadata.FileInfo
+		file.Path = "/a/b/c.txt"
+		_, err := store.CreateFileWithParents(ctx, file)
+		require.NoError(t, err)
+
+		list, _, err := log.Since(0, 0)
+		require.NoError(t, err)
+		var got []string
+		for _, e := range list {
+			assert.Equal(t, events.Create, e.Type)
+			got = append(got, e.Path)
+		}
+		assert.Equal(t, []string{"/a", "/a/b", "/a/b/c.txt"}, got)
+	})
+}
+
+func TestMemoryStoreMove(t *testing.T) {
+	ctx := context.Background()
+	store := newInitializedStore(t)
+
+	var file metadata.FileInfo
+	file.Path = "/src/sub/f.txt"
+	_, err := store.CreateFileWithParents(ctx, file)
+	require.NoError(t, err)
+	var dir metadata.DirectoryInfo
+	dir.Path = "/dst"
+	_, err = store.CreateDirectory(ctx, dir)
+	require.NoError(t, err)
+
+	// 目录不能移动到自身子树下，目标已存在时拒绝
+	err = store.Move(ctx, "/src", "/src/sub/inner")
+	assert.True(t, errors.IsInvalidArgument(err))
+	err = store.Move(ctx, "/src", "/dst")
+	assert.True(t, errors.IsAlreadyExists(err))
+
+	require.NoError(t, store.Move(ctx, "/src", "/dst/moved"))
+
+	moved, err := store.GetFileInfo(ctx, "/dst/moved/sub/f.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "/dst/moved/sub/f.txt", moved.Path)
+	_, err = store.GetFileInfo(ctx, "/src/sub/f.txt")
+	assert.True(t, errors.IsNotFound(err))
+
+	entries, err := store.ListDirectory(ctx, "/", false, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 1, entries[0].ChildCount)
+	entries, err = store.ListDirectory(ctx, "/dst/moved", false, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "sub", entries[0].Name)
+}
@@ -0,0 +1,133 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/errors"
+	"github.com/22827099/DFS_v1/common/types"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+	"github.com/22827099/DFS_v1/internal/metaserver/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ metadata.Leaser = (*server.MemoryStore)(nil)
+
+func TestWriteLease(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ExclusiveAcquisition", func(t *testing.T) {
+		store := newInitializedStore(t)
+
+		lease, err := store.AcquireWriteLease(ctx, "/data/a.bin", "writer-1", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, "writer-1", lease.Holder)
+		assert.Equal(t, "/data/a.bin", lease.Path)
+
+		_, err = store.AcquireWriteLease(ctx, "/data/./a.bin", "writer-2", time.Minute)
+		require.Error(t, err)
+		assert.True(t, errors.IsAlreadyExists(err))
+
+		// 其他文件的租约互不影响
+		_, err = store.AcquireWriteLease(ctx, "/data/b.bin", "writer-2", time.Minute)
+		assert.NoError(t, err)
+
+		// 非持有者不能续约或释放
+		_, err = store.RenewLease(ctx, "/data/a.bin", "writer-2", time.Minute)
+		assert.True(t, errors.IsPermissionDenied(err))
+		assert.True(t, errors.IsPermissionDenied(store.ReleaseLease(ctx, "/data/a.bin", "writer-2")))
+
+		// 释放后其他持有者可以获取
+		require.NoError(t, store.ReleaseLease(ctx, "/data/a.bin", "writer-1"))
+		_, err = store.AcquireWriteLease(ctx, "/data/a.bin", "writer-2", time.Minute)
+		assert.NoError(t, err)
+	})
+
+	t.Run("RenewalExtendsTTL", func(t *testing.T) {
+		store := newInitializedStore(t)
+
+		first, err := store.AcquireWriteLease(ctx, "/a.bin", "writer-1", 100*time.Millisecond)
+		require.NoError(t, err)
+
+		// 多次在到期前续约，累计时间超过原始有效期后租约仍然有效
+		for i := 0; i < 3; i++ {
+			time.Sleep(50 * time.Millisecond)
+			renewed, err := store.RenewLease(ctx, "/a.bin", "writer-1", 100*time.Millisecond)
+			require.NoError(t, err)
+			assert.True(t, renewed.ExpiresAt.After(first.ExpiresAt))
+		}
+
+		_, err = store.AcquireWriteLease(ctx, "/a.bin", "writer-2", time.Minute)
+		assert.True(t, errors.IsAlreadyExists(err), "续约后的租约不应被其他持有者获取")
+	})
+
+	t.Run("ExpiryAllowsAnotherHolder", func(t *testing.T) {
+		store := newInitializedStore(t)
+
+		_, err := store.AcquireWriteLease(ctx, "/a.bin", "writer-1", 30*time.Millisecond)
+		require.NoError(t, err)
+
+		// 持有者停止续约后租约自动失效
+		time.Sleep(60 * time.Millisecond)
+		lease, err := store.AcquireWriteLease(ctx, "/a.bin", "writer-2", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, "writer-2", lease.Holder)
+
+		// 原持有者不能再续约
+		_, err = store.RenewLease(ctx, "/a.bin", "writer-1", time.Minute)
+		assert.True(t, errors.IsPermissionDenied(err))
+	})
+
+	t.Run("RenewExpiredLease", func(t *testing.T) {
+		store := newInitializedStore(t)
+
+		_, err := store.AcquireWriteLease(ctx, "/a.bin", "writer-1", 10*time.Millisecond)
+		require.NoError(t, err)
+		time.Sleep(30 * time.Millisecond)
+
+		_, err = store.RenewLease(ctx, "/a.bin", "writer-1", time.Minute)
+		assert.True(t, errors.IsNotFound(err))
+		assert.NoError(t, store.ReleaseLease(ctx, "/a.bin", "writer-1"), "释放已过期的租约不做任何事")
+	})
+
+	t.Run("InvalidArguments", func(t *testing.T) {
+		store := newInitializedStore(t)
+
+		_, err := store.AcquireWriteLease(ctx, "/a.bin", "", time.Minute)
+		assert.True(t, errors.IsInvalidArgument(err))
+		_, err = store.AcquireWriteLease(ctx, "/a.bin", "writer-1", 0)
+		assert.True(t, errors.IsInvalidArgument(err))
+	})
+	t.Run("MoveAndDeleteRespectLeases", func(t *testing.T) {
+		store := newInitializedStore(t)
+		_, err := store.CreateDirectory(ctx, metadata.DirectoryInfo{BasicFileInfo: types.BasicFileInfo{Path: "/data"}})
+		require.NoError(t, err)
+		_, err = store.CreateFile(ctx, metadata.FileInfo{BasicFileInfo: types.BasicFileInfo{Path: "/data/a.bin"}})
+		require.NoError(t, err)
+		_, err = store.AcquireWriteLease(ctx, "/data/a.bin", "writer-1", time.Minute)
+		require.NoError(t, err)
+
+		other := metadata.WithLeaseHolder(ctx, "writer-2")
+		owner := metadata.WithLeaseHolder(ctx, "writer-1")
+
+		// 租约保护文件本身和包含它的目录，未持有租约的写入者不能移走或删除
+		assert.True(t, errors.IsPermissionDenied(store.CheckWriteLease(ctx, "/data", "writer-2")))
+		assert.True(t, errors.IsPermissionDenied(store.Move(other, "/data/a.bin", "/a.bin")))
+		assert.True(t, errors.IsPermissionDenied(store.Move(ctx, "/data", "/moved")))
+		assert.True(t, errors.IsPermissionDenied(store.MoveBatch(other, []metadata.MoveOp{{Src: "/data", Dst: "/moved"}})))
+		assert.True(t, errors.IsPermissionDenied(store.DeleteDirectory(other, "/data", true)))
+		_, err = store.GetFileInfo(ctx, "/data/a.bin")
+		require.NoError(t, err, "被拒绝的操作不应修改存储")
+
+		// 持有者可以移动，租约随文件移动
+		assert.NoError(t, store.CheckWriteLease(ctx, "/data/a.bin", "writer-1"))
+		require.NoError(t, store.Move(owner, "/data/a.bin", "/data/b.bin"))
+		assert.NoError(t, store.CheckWriteLease(ctx, "/data/a.bin", "writer-2"))
+		assert.True(t, errors.IsPermissionDenied(store.CheckWriteLease(ctx, "/data/b.bin", "writer-2")))
+
+		require.NoError(t, store.ReleaseLease(ctx, "/data/b.bin", "writer-1"))
+		require.NoError(t, store.DeleteDirectory(other, "/data", true))
+	})
+}
@@ -0,0 +1,73 @@
+package store_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+	"github.com/22827099/DFS_v1/internal/metaserver/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fillStore 在根目录下创建n个文件
+func fillStore(t *testing.T, store *server.MemoryStore, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		var file metadata.FileInfo
+		file.Path = fmt.Sprintf("/file-%05d", i)
+		_, err := store.CreateFile(context.Background(), file)
+		require.NoError(t, err)
+	}
+}
+
+func TestListDirectoryHardCap(t *testing.T) {
+	t.Run("UnlimitedRequestIsCapped", func(t *testing.T) {
+		store := newInitializedStore(t)
+		fillStore(t, store, server.DefaultMaxListEntries+50)
+
+		entries, truncated, err := store.ListDirectoryTruncated(context.Background(), "/", false, 0)
+		require.NoError(t, err)
+		assert.Len(t, entries, server.DefaultMaxListEntries)
+		assert.True(t, truncated)
+
+		// 不报告截断的接口同样受上限约束
+		entries, err = store.ListDirectory(context.Background(), "/", false, 0)
+		require.NoError(t, err)
+		assert.Len(t, entries, server.DefaultMaxListEntries)
+	})
+
+	t.Run("ConfiguredCap", func(t *testing.T) {
+		store := newInitializedStore(t)
+		store.SetMaxListEntries(100)
+		fillStore(t, store, 150)
+
+		entries, truncated, err := store.ListDirectoryTruncated(context.Background(), "/", true, 0)
+		require.NoError(t, err)
+		assert.Len(t, entries, 100)
+		assert.True(t, truncated)
+
+		// 超过上限的显式limit同样被截断
+		entries, truncated, err = store.ListDirectoryTruncated(context.Background(), "/", false, 500)
+		require.NoError(t, err)
+		assert.Len(t, entries, 100)
+		assert.True(t, truncated)
+	})
+
+	t.Run("NotTruncatedWhenEverythingFits", func(t *testing.T) {
+		store := newInitializedStore(t)
+		store.SetMaxListEntries(100)
+		fillStore(t, store, 100)
+
+		entries, truncated, err := store.ListDirectoryTruncated(context.Background(), "/", false, 0)
+		require.NoError(t, err)
+		assert.Len(t, entries, 100)
+		assert.False(t, truncated, "恰好达到上限时没有未返回的条目")
+
+		entries, truncated, err = store.ListDirectoryTruncated(context.Background(), "/", false, 10)
+		require.NoError(t, err)
+		assert.Len(t, entries, 10)
+		assert.True(t, truncated, "客户端limit截断时同样报告")
+	})
+}
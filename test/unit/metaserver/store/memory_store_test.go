@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/22827099/DFS_v1/common/types"
 	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
 	"github.com/22827099/DFS_v1/internal/metaserver/server"
 	"github.com/stretchr/testify/assert"
@@ -22,12 +23,14 @@ func TestMetaServerStore(t *testing.T) {
 
 		// 准备测试数据
 		fileInfo := metadata.FileInfo{
-			Path:      "/test.txt",
-			Name:      "test.txt",
-			Size:      1024,
-			MimeType:  "text/plain",
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+			BasicFileInfo: types.BasicFileInfo{
+				Path:      "/test.txt",
+				Name:      "test.txt",
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			},
+			Size:     1024,
+			MimeType: "text/plain",
 		}
 
 		// 创建文件
@@ -47,8 +50,10 @@ func TestMetaServerStore(t *testing.T) {
 
 		// 创建测试文件
 		fileInfo := metadata.FileInfo{
-			Path:     "/read_test.txt",
-			Name:     "read_test.txt",
+			BasicFileInfo: types.BasicFileInfo{
+				Path: "/read_test.txt",
+				Name: "read_test.txt",
+			},
 			Size:     2048,
 			MimeType: "text/plain",
 		}
@@ -74,8 +79,10 @@ func TestMetaServerStore(t *testing.T) {
 
 		// 创建测试文件
 		fileInfo := metadata.FileInfo{
-			Path:     "/update_test.txt",
-			Name:     "update_test.txt",
+			BasicFileInfo: types.BasicFileInfo{
+				Path: "/update_test.txt",
+				Name: "update_test.txt",
+			},
 			Size:     1024,
 			MimeType: "text/plain",
 		}
@@ -108,8 +115,10 @@ func TestMetaServerStore(t *testing.T) {
 
 		// 创建测试文件
 		fileInfo := metadata.FileInfo{
-			Path:     "/delete_test.txt",
-			Name:     "delete_test.txt",
+			BasicFileInfo: types.BasicFileInfo{
+				Path: "/delete_test.txt",
+				Name: "delete_test.txt",
+			},
 			Size:     1024,
 			MimeType: "text/plain",
 		}
@@ -137,16 +146,20 @@ func TestMetaServerStore(t *testing.T) {
 
 		// 创建测试目录
 		dirInfo := metadata.DirectoryInfo{
-			Path: "/test_dir",
-			Name: "test_dir",
+			BasicFileInfo: types.BasicFileInfo{
+				Path: "/test_dir",
+				Name: "test_dir",
+			},
 		}
 		_, err = store.CreateDirectory(context.Background(), dirInfo)
 		require.NoError(t, err)
 
 		// 创建测试文件
 		file1 := metadata.FileInfo{
-			Path:     "/test_dir/file1.txt",
-			Name:     "file1.txt",
+			BasicFileInfo: types.BasicFileInfo{
+				Path: "/test_dir/file1.txt",
+				Name: "file1.txt",
+			},
 			Size:     1024,
 			MimeType: "text/plain",
 		}
@@ -154,8 +167,10 @@ func TestMetaServerStore(t *testing.T) {
 		require.NoError(t, err)
 
 		file2 := metadata.FileInfo{
-			Path:     "/test_dir/file2.txt",
-			Name:     "file2.txt",
+			BasicFileInfo: types.BasicFileInfo{
+				Path: "/test_dir/file2.txt",
+				Name: "file2.txt",
+			},
 			Size:     2048,
 			MimeType: "text/plain",
 		}
@@ -164,8 +179,10 @@ func TestMetaServerStore(t *testing.T) {
 
 		// 创建子目录
 		subDirInfo := metadata.DirectoryInfo{
-			Path: "/test_dir/sub_dir",
-			Name: "sub_dir",
+			BasicFileInfo: types.BasicFileInfo{
+				Path: "/test_dir/sub_dir",
+				Name: "sub_dir",
+			},
 		}
 		_, err = store.CreateDirectory(context.Background(), subDirInfo)
 		require.NoError(t, err)
@@ -0,0 +1,58 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+	"github.com/22827099/DFS_v1/internal/metaserver/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createWithMime(t *testing.T, store *server.MemoryStore, filePath, mimeType string) string {
+	t.Helper()
+	var file metadata.FileInfo
+	file.Path = filePath
+	file.MimeType = mimeType
+	created, err := store.CreateFile(context.Background(), file)
+	require.NoError(t, err)
+	return created.MimeType
+}
+
+func TestMimeInference(t *testing.T) {
+	t.Run("InfersFromCommonExtensions", func(t *testing.T) {
+		store := newInitializedStore(t)
+		store.SetMimeInference(true)
+
+		assert.Equal(t, "image/png", createWithMime(t, store, "/photo.png", ""))
+		assert.Equal(t, "application/pdf", createWithMime(t, store, "/report.PDF", ""))
+		assert.Contains(t, createWithMime(t, store, "/index.html", ""), "text/html")
+
+		// 推断结果被保存
+		info, err := store.GetFileInfo(context.Background(), "/photo.png")
+		require.NoError(t, err)
+		assert.Equal(t, "image/png", info.MimeType)
+	})
+
+	t.Run("ExplicitValueOverrides", func(t *testing.T) {
+		store := newInitializedStore(t)
+		store.SetMimeInference(true)
+
+		assert.Equal(t, "application/x-custom", createWithMime(t, store, "/photo.png", "application/x-custom"))
+	})
+
+	t.Run("FallsBackToOctetStream", func(t *testing.T) {
+		store := newInitializedStore(t)
+		store.SetMimeInference(true)
+
+		assert.Equal(t, metadata.DefaultMimeType, createWithMime(t, store, "/Makefile", ""))
+		assert.Equal(t, metadata.DefaultMimeType, createWithMime(t, store, "/data.unknownext", ""))
+	})
+
+	t.Run("DisabledByDefault", func(t *testing.T) {
+		store := newInitializedStore(t)
+
+		assert.Empty(t, createWithMime(t, store, "/photo.png", ""))
+	})
+}
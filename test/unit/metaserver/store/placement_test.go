@@ -0,0 +1,35 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/22827099/DFS_v1/common/types"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata/placement"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateFile_PlacesChunksOnAvailableNodes(t *testing.T) {
+	store := newInitializedStore(t)
+	nodes := []types.NodeInfo{
+		{NodeID: "n1", Status: types.NodeStatusHealthy},
+		{NodeID: "n2", Status: types.NodeStatusDead},
+	}
+	source := func(ctx context.Context) ([]types.NodeInfo, error) { return nodes, nil }
+	store.SetPlacer(placement.NewPlacer(placement.NewRoundRobin(), source, 3))
+
+	file := metadata.FileInfo{Chunks: make([]metadata.ChunkInfo, 2)}
+	file.Path = "/a.bin"
+	created, err := store.CreateFile(context.Background(), file)
+	require.NoError(t, err)
+
+	// 只有一个存活节点，每个块放在该节点上并记录缺少的两个副本
+	assert.Equal(t, 3, created.Replicas)
+	assert.Equal(t, 2, created.MissingReplicas)
+	for _, chunk := range created.Chunks {
+		assert.Equal(t, []types.NodeID{"n1"}, chunk.Replicas)
+		assert.Equal(t, types.NodeID("n1"), chunk.NodeID)
+	}
+}
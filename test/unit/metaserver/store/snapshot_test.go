@@ -0,0 +1,65 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/common/errors"
+	"github.com/22827099/DFS_v1/common/types"
+	"github.com/22827099/DFS_v1/internal/metaserver/core/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_SnapshotRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src := newInitializedStore(t)
+	_, err := src.CreateDirectory(ctx, metadata.DirectoryInfo{BasicFileInfo: types.BasicFileInfo{Path: "/docs"}})
+	require.NoError(t, err)
+	_, err = src.CreateFile(ctx, metadata.FileInfo{BasicFileInfo: types.BasicFileInfo{Path: "/docs/a.txt"}, Size: 3, MimeType: "text/plain"})
+	require.NoError(t, err)
+	_, err = src.AcquireWriteLease(ctx, "/docs/a.txt", "writer1", time.Minute)
+	require.NoError(t, err)
+
+	data, err := src.SnapshotState()
+	require.NoError(t, err)
+
+	dst := newInitializedStore(t)
+	_, err = dst.CreateFile(ctx, metadata.FileInfo{BasicFileInfo: types.BasicFileInfo{Path: "/stale.txt"}})
+	require.NoError(t, err)
+	require.NoError(t, dst.RestoreState(data))
+
+	// 快照替换全部状态，恢复前的文件不再存在
+	_, err = dst.GetFileInfo(ctx, "/stale.txt")
+	assert.True(t, errors.IsErrorCode(err, errors.NotFound))
+	info, err := dst.GetFileInfo(ctx, "/docs/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), info.Size)
+	assert.Equal(t, "text/plain", info.MimeType)
+
+	// 写租约随快照复制，恢复后其他持有者仍不能写入
+	assert.True(t, errors.IsErrorCode(dst.CheckWriteLease(ctx, "/docs/a.txt", "writer2"), errors.PermissionDenied))
+	require.NoError(t, dst.ReleaseLease(ctx, "/docs/a.txt", "writer1"))
+
+	// 目录的子项数随快照重建，非空目录不能非递归删除
+	assert.Error(t, dst.DeleteDirectory(ctx, "/docs", false))
+	require.NoError(t, dst.DeleteFile(ctx, "/docs/a.txt"))
+	assert.NoError(t, dst.DeleteDirectory(ctx, "/docs", false))
+}
+
+func TestMemoryStore_RestoreInvalidSnapshot(t *testing.T) {
+	ctx := context.Background()
+	store := newInitializedStore(t)
+	_, err := store.CreateFile(ctx, metadata.FileInfo{BasicFileInfo: types.BasicFileInfo{Path: "/a.txt"}})
+	require.NoError(t, err)
+
+	for _, data := range []string{"not json", `{"files":{}}`} {
+		err := store.RestoreState([]byte(data))
+		assert.True(t, errors.IsErrorCode(err, errors.DataCorruption), "快照%q", data)
+	}
+
+	// 无效的快照不修改存储
+	_, err = store.GetFileInfo(ctx, "/a.txt")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,57 @@
+package testutil_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/22827099/DFS_v1/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCluster_ElectsInitialLeader(t *testing.T) {
+	cluster := testutil.NewCluster(t, 3)
+	require.Len(t, cluster.Nodes(), 3)
+
+	leader, err := cluster.WaitForLeader(5 * time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), leader.ID, "默认由节点1发起选举")
+
+	command := []byte("set a=1")
+	require.True(t, leader.Propose(command))
+	for _, node := range cluster.Nodes() {
+		assert.NoError(t, node.WaitForCommitted(command, 5*time.Second))
+	}
+}
+
+func TestCluster_WithInitialLeader(t *testing.T) {
+	cluster := testutil.NewCluster(t, 3, testutil.WithInitialLeader(2))
+
+	leader, err := cluster.WaitForLeader(5 * time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), leader.ID)
+}
+
+func TestCluster_PartitionElectsNewLeaderInMajority(t *testing.T) {
+	cluster := testutil.NewCluster(t, 3, testutil.WithElectionTick(5))
+	leader, err := cluster.WaitForLeader(5 * time.Second)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), leader.ID)
+
+	cluster.Partition([]uint64{1}, []uint64{2, 3})
+
+	var newLeader *testutil.Node
+	require.Eventually(t, func() bool {
+		newLeader = cluster.Leader()
+		return newLeader != nil && newLeader.ID != 1
+	}, 5*time.Second, 10*time.Millisecond, "多数派应选出新的领导者")
+	assert.Greater(t, newLeader.Raft.Term(), cluster.Node(1).Raft.Term())
+
+	command := []byte("after partition")
+	require.True(t, newLeader.Propose(command))
+	require.NoError(t, cluster.Node(2).WaitForCommitted(command, 5*time.Second))
+	require.NoError(t, cluster.Node(3).WaitForCommitted(command, 5*time.Second))
+
+	cluster.Heal()
+	assert.NoError(t, cluster.Node(1).WaitForCommitted(command, 5*time.Second), "恢复后旧领导者应追上日志")
+}